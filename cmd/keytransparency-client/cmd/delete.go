@@ -0,0 +1,94 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/signature"
+	"github.com/google/tink/go/tink"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+
+	"github.com/google/keytransparency/core/crypto/tinkio"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete [user email]",
+	Short: "Delete the account",
+	Long: `Delete replaces the current entry with a tombstone signed by the
+current key-set. Once deleted, the account cannot be modified. eg:
+
+./keytransparency-client delete foobar@example.com
+
+User email MUST match the OAuth account used to authorize the update.
+`,
+
+	RunE: func(_ *cobra.Command, args []string) error {
+		// Validate input.
+		if len(args) < 1 {
+			return fmt.Errorf("user email needs to be provided")
+		}
+		masterKey, err := tinkio.MasterPBKDF(masterPassword)
+		if err != nil {
+			log.Fatal(err)
+		}
+		handle, err := keyset.Read(
+			&tinkio.ProtoKeysetFile{File: keysetFile},
+			masterKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		userID := args[0]
+		ctx := context.Background()
+
+		// Create client.
+		userCreds, err := userCreds(ctx)
+		if err != nil {
+			return err
+		}
+		c, err := GetClient(ctx)
+		if err != nil {
+			return fmt.Errorf("error connecting: %v", err)
+		}
+
+		signer, err := signature.NewSigner(handle)
+		if err != nil {
+			return err
+		}
+
+		timeout := viper.GetDuration("timeout")
+		cctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if _, err := c.Delete(cctx, userID, []tink.Signer{signer},
+			grpc.PerRPCCredentials(userCreds)); err != nil {
+			return fmt.Errorf("delete failed: %v", err)
+		}
+		fmt.Printf("Deleted %v\n", userID)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(deleteCmd)
+
+	deleteCmd.PersistentFlags().StringVarP(&masterPassword, "password", "p", "", "The master key to the local keyset")
+	deleteCmd.Flags().StringVarP(&keysetFile, "keyset-file", "k", defaultKeysetFile, "Keyset file name and path")
+}
@@ -17,9 +17,18 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/google/trillian/types"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/google/keytransparency/core/client"
+)
+
+var (
+	getRevision int64
+	getTime     string
 )
 
 // getCmd represents the get command
@@ -27,7 +36,11 @@ var getCmd = &cobra.Command{
 	Use:   "get [user email]",
 	Short: "Retrieve and verify the current keyset",
 	Long: `Retrieve the user profile from the key server and verify that the
-results are consistent.`,
+results are consistent.
+
+By default the latest revision is read. Use --revision to read a specific
+map revision, or --time to read the latest revision published at or before
+an RFC 3339 timestamp.`,
 	RunE: func(_ *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("user email needs to be provided")
@@ -41,15 +54,45 @@ results are consistent.`,
 		if err != nil {
 			return fmt.Errorf("error connecting: %v", err)
 		}
-		profile, _, err := c.GetUser(ctx, userID)
+		smr, profile, err := getUser(ctx, c, userID)
+		if err == client.ErrDeleted {
+			fmt.Printf("User %v has been deleted as of revision %v\n", userID, smr.Revision)
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("failed to get user: %v", err)
 		}
+		if profile == nil {
+			fmt.Printf("User %v does not exist as of revision %v\n", userID, smr.Revision)
+			return nil
+		}
 		fmt.Printf("Profile for %v: %+v\n", userID, profile)
 		return nil
 	},
 }
 
+// getUser reads userID at the revision selected by the --revision or --time flags.
+func getUser(ctx context.Context, c *client.Client, userID string) (*types.MapRootV1, []byte, error) {
+	switch {
+	case getRevision != 0 && getTime != "":
+		return nil, nil, fmt.Errorf("--revision and --time are mutually exclusive")
+	case getRevision != 0:
+		smr, leaf, err := c.VerifiedGetUserByRevision(ctx, userID, getRevision)
+		return smr, leaf.GetCommitted().GetData(), err
+	case getTime != "":
+		t, err := time.Parse(time.RFC3339, getTime)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid --time: %v", err)
+		}
+		return c.GetUserAtTime(ctx, userID, t)
+	default:
+		return c.GetUser(ctx, userID)
+	}
+}
+
 func init() {
 	RootCmd.AddCommand(getCmd)
+
+	getCmd.Flags().Int64Var(&getRevision, "revision", 0, "Map revision to read. Defaults to the latest revision")
+	getCmd.Flags().StringVar(&getTime, "time", "", "Read the latest revision published at or before this RFC 3339 timestamp")
 }
@@ -38,6 +38,7 @@ var (
 	pageSize      int
 	qps           int
 	testTypes     string
+	batchWriter   string
 	duration      time.Duration
 )
 
@@ -52,6 +53,7 @@ func init() {
 	hammerCmd.Flags().StringVar(&testTypes, "types", "batch,write,read,audit", "Types of stress tests to run, comma separated")
 	hammerCmd.Flags().IntVar(&qps, "qps", 100, "Numer of requests a second")
 	hammerCmd.Flags().IntVar(&pageSize, "batch", 10, "Number of entries to process at once")
+	hammerCmd.Flags().StringVar(&batchWriter, "batch-writer", "", "Identity to send batch writes as. Must be authorized to update any user in the directory")
 	hammerCmd.Flags().IntVar(&maxWorkers, "workers", 1000, "Number of parallel workers. Best when workers = QPS * timeout")
 	hammerCmd.Flags().IntVar(&maxOperations, "operations", 10000, "Number of operations")
 	hammerCmd.Flags().StringVarP(&masterPassword, "password", "p", "", "The master key to the local keyset")
@@ -111,6 +113,8 @@ var hammerCmd = &cobra.Command{
 			HistoryCount:    maxOperations,
 			HistoryPageSize: pageSize,
 
+			BatchWriterID: batchWriter,
+
 			Duration: duration,
 		})
 	},
@@ -36,7 +36,8 @@ var histCmd = &cobra.Command{
 	Use:   "history [user email] [app]",
 	Short: "Retrieve and verify all keys used for this account",
 	Long: `Retrieve all user profiles for this account from the key server
-and verify that the results are consistent.`,
+and verify that the results are consistent. If app is provided, the history
+of the user's entry for that application is retrieved.`,
 	RunE: func(_ *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("user email needs to be provided")
@@ -50,6 +51,9 @@ and verify that the results are consistent.`,
 		if err != nil {
 			return fmt.Errorf("error connecting: %v", err)
 		}
+		if len(args) > 1 {
+			c.AppID = args[1]
+		}
 		if end == 0 {
 			// Get the current revision.
 			slr, smr, err := c.VerifiedGetLatestRevision(ctx)
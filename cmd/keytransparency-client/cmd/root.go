@@ -17,10 +17,12 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/keytransparency/core/client"
@@ -80,6 +82,8 @@ func init() {
 	RootCmd.PersistentFlags().String("kt-cert", "", "Path to public key for Key Transparency")
 	RootCmd.PersistentFlags().Bool("autoconfig", true, "Fetch config info from the server's /v1/directory/info")
 	RootCmd.PersistentFlags().Bool("insecure", false, "Skip TLS checks")
+	RootCmd.PersistentFlags().String("client-cert", "", "Path to a PEM client certificate to present to servers using mtls auth")
+	RootCmd.PersistentFlags().String("client-key", "", "Path to the PEM private key of --client-cert")
 
 	RootCmd.PersistentFlags().String("vrf", "genfiles/vrf-pubkey.pem", "path to vrf public key")
 
@@ -87,9 +91,11 @@ func init() {
 	RootCmd.PersistentFlags().String("map-key", "genfiles/trillian-map.pem", "Path to public key PEM for Trillian Map server")
 
 	RootCmd.PersistentFlags().String("client-secret", "", "Path to client_secret.json file for user creds")
+	RootCmd.PersistentFlags().String("id-token", "", "Path to a file containing an OpenID Connect ID token for servers using oidc auth")
 	RootCmd.PersistentFlags().String("fake-auth-userid", "", "userid to present to the server as identity for authentication. Only succeeds if fake auth is enabled on the server side.")
 
 	// Global flags for use by subcommands.
+	RootCmd.PersistentFlags().String("app", "", "Application ID of the user entries to read and write")
 	RootCmd.PersistentFlags().DurationP("timeout", "t", 15*time.Second, "Time to wait before operations timeout")
 	RootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "Print in/out and verification steps")
 	if err := viper.BindPFlags(RootCmd.PersistentFlags()); err != nil {
@@ -153,34 +159,63 @@ func getCreds(ctx context.Context, clientSecretFile string) (credentials.PerRPCC
 	return oauth.NewOauthAccess(tok), nil
 }
 
+// idTokenCreds returns credentials presenting the ID token stored in idTokenFile.
+func idTokenCreds(idTokenFile string) (credentials.PerRPCCredentials, error) {
+	b, err := ioutil.ReadFile(idTokenFile)
+	if err != nil {
+		return nil, err
+	}
+	tok := &oauth2.Token{AccessToken: strings.TrimSpace(string(b)), TokenType: "Bearer"}
+	return oauth.NewOauthAccess(tok), nil
+}
+
 func transportCreds() (credentials.TransportCredentials, error) {
 	ktCert := viper.GetString("kt-cert")
 	insecure := viper.GetBool("insecure")
+	clientCert := viper.GetString("client-cert")
+	clientKey := viper.GetString("client-key")
 
+	config := &tls.Config{}
 	switch {
 	case insecure: // Impatient insecure.
-		return credentials.NewTLS(&tls.Config{
-			InsecureSkipVerify: true, // nolint
-		}), nil
+		config.InsecureSkipVerify = true // nolint
 
 	case ktCert != "": // Custom CA Cert.
-		return credentials.NewClientTLSFromFile(ktCert, "")
+		b, err := ioutil.ReadFile(ktCert)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("failed to append certificates from %v", ktCert)
+		}
 
 	default: // Use the local set of root certs.
-		return credentials.NewClientTLSFromCert(nil, ""), nil
 	}
+
+	if clientCert != "" || clientKey != "" {
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(config), nil
 }
 
 // userCreds returns PerRPCCredentials. Only one type of credential
 // should exist in an RPC call. Fake credentials have the highest priority, followed
-// by Client credentials and Service Credentials.
+// by ID tokens, Client credentials and Service Credentials.
 func userCreds(ctx context.Context) (credentials.PerRPCCredentials, error) {
 	fakeUserID := viper.GetString("fake-auth-userid")    // Fake user creds.
+	idTokenFile := viper.GetString("id-token")           // OIDC user creds.
 	clientSecretFile := viper.GetString("client-secret") // Real user creds.
 
 	switch {
 	case fakeUserID != "":
 		return authentication.GetFakeCredential(fakeUserID), nil
+	case idTokenFile != "":
+		return idTokenCreds(idTokenFile)
 	case clientSecretFile != "":
 		return getCreds(ctx, clientSecretFile)
 	default:
@@ -215,9 +250,14 @@ func GetClient(ctx context.Context) (*client.Client, error) {
 		return nil, fmt.Errorf("config: %v", err)
 	}
 
-	return client.NewFromConfig(ktCli, config,
+	c, err := client.NewFromConfig(ktCli, config,
 		func(lv *tclient.LogVerifier) verifier.LogTracker { return tracker.NewSynchronous(lv) },
 	)
+	if err != nil {
+		return nil, err
+	}
+	c.AppID = viper.GetString("app")
+	return c, nil
 }
 
 // config selects a source for and returns the client configuration.
@@ -0,0 +1,290 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// keytransparency-dev runs the keyserver, sequencer, admin API and monitor in
+// a single process, on top of in-process Trillian log and map servers. All
+// state is kept in memory and lost on exit.
+//
+// At startup it creates a directory with generated keys and prints a client
+// config that trusts the generated TLS certificate. It is intended for local
+// development only: authentication is faked.
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys/der"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/monitoring/prometheus"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/google/keytransparency/cmd/serverutil"
+	"github.com/google/keytransparency/core/adminserver"
+	"github.com/google/keytransparency/core/fake"
+	"github.com/google/keytransparency/core/keyserver"
+	"github.com/google/keytransparency/core/monitor"
+	"github.com/google/keytransparency/core/monitorserver"
+	"github.com/google/keytransparency/core/mutator/entry"
+	"github.com/google/keytransparency/core/sequencer"
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/impl/authentication"
+	"github.com/google/keytransparency/impl/authorization"
+	"github.com/google/keytransparency/impl/memory"
+	"github.com/google/keytransparency/internal/backoff"
+	"github.com/google/keytransparency/internal/forcemaster"
+
+	mopb "github.com/google/keytransparency/core/api/monitor/v1/monitor_go_proto"
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	authzpb "github.com/google/keytransparency/impl/authorization/authz_go_proto"
+	tcrypto "github.com/google/trillian/crypto"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+
+	_ "github.com/google/trillian/crypto/keys/der/proto"
+	_ "github.com/google/trillian/merkle/coniks"  // Register hasher
+	_ "github.com/google/trillian/merkle/rfc6962" // Register hasher
+)
+
+var (
+	addr        = flag.String("addr", "localhost:8080", "The ip:port to serve the Key Transparency, admin, sequencer and monitor APIs on")
+	metricsAddr = flag.String("metrics-addr", "localhost:8081", "The ip:port to publish metrics on")
+	stateDir    = flag.String("state-dir", "", "Directory to write the generated TLS certificate and client config to. A new temporary directory is used if unset")
+
+	directoryID = flag.String("directory", "default", "ID of the directory to create at startup")
+	user        = flag.String("user", "admin@example.com", "Fake auth user ID that may call the admin API and update every entry of the directory. Written to the client config")
+	minInterval = flag.Duration("min-interval", time.Second, "Minimum time between map revisions of the directory")
+	maxInterval = flag.Duration("max-interval", time.Hour, "Maximum time between map revisions of the directory")
+	runMonitor  = flag.Bool("monitor", true, "Run a monitor of the directory")
+
+	dirRefresh       = flag.Duration("directory-refresh", 5*time.Second, "Time to detect new directory")
+	refresh          = flag.Duration("refresh", time.Second, "Time a directory without new mutations waits before checking again")
+	prune            = flag.Duration("prune", time.Minute, "Time between runs deleting mutations older than each directory's retention policy")
+	batchSize        = flag.Int("batch-size", 100, "Maximum number of mutations to process per map revision")
+	revisionPageSize = flag.Int("revision-page-size", 10, "Max number of revisions to return at once")
+)
+
+func main() {
+	flag.Parse()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *stateDir == "" {
+		d, err := ioutil.TempDir("", "keytransparency-dev")
+		if err != nil {
+			glog.Exit(err)
+		}
+		*stateDir = d
+	}
+	certFile, keyFile, err := writeSelfSignedCert(*stateDir)
+	if err != nil {
+		glog.Exitf("Failed to create TLS certificate: %v", err)
+	}
+
+	tconn, err := startTrillian(ctx)
+	if err != nil {
+		glog.Exitf("Failed to start Trillian: %v", err)
+	}
+	tlog := trillian.NewTrillianLogClient(tconn)
+	tmap := trillian.NewTrillianMapClient(tconn)
+
+	directories := memory.NewDirectoryStorage()
+	mutations := memory.NewMutations()
+	auditLog := memory.NewAuditLog()
+
+	glog.Warning("INSECURE! Using fake authentication.")
+	authz := &authorization.AuthzPolicy{}
+	authz.SetPolicy(&authzpb.AuthorizationPolicy{
+		Roles: map[string]*authzpb.AuthorizationPolicy_Role{
+			"admin": {Principals: []string{*user}},
+		},
+		ResourceToRoleLabels: map[string]*authzpb.AuthorizationPolicy_RoleLabels{
+			"directories/" + *directoryID: {Labels: []string{"admin"}},
+		},
+		MethodToRoleLabels: map[string]*authzpb.AuthorizationPolicy_RoleLabels{
+			"/google.keytransparency.v1.KeyTransparencyAdmin/":            {Labels: []string{"admin"}},
+			"/google.keytransparency.sequencer.KeyTransparencySequencer/": {Labels: []string{"admin"}},
+		},
+	})
+	userAuth := authorization.AuthPair{AuthnFunc: authentication.FakeAuthFunc, AuthzFunc: authz.Authorize}
+	auth := serverutil.AdminAuth(authentication.FakeAuthFunc, authz.AuthorizeMethod)
+	auth["/google.keytransparency.v1.KeyTransparency/QueueEntryUpdate"] = userAuth
+	auth["/google.keytransparency.v1.KeyTransparency/BatchQueueUserUpdate"] = userAuth
+
+	tokens, err := keyserver.NewEphemeralPageTokens(time.Hour)
+	if err != nil {
+		glog.Exitf("Failed to create page token keys: %v", err)
+	}
+	limiter := keyserver.NewRateLimiter(func(ctx context.Context) string {
+		sctx, _ := authentication.FromContext(ctx)
+		return sctx.GetEmail()
+	})
+
+	// As in keytransparency-sequencer, this binary calls the sequencer
+	// service over a loopback connection that is not authenticated.
+	grpcServer := grpc.NewServer(
+		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			grpc_prometheus.UnaryServerInterceptor,
+			authorization.UnaryServerInterceptor(auth),
+			serverutil.AuditInterceptor(auditLog),
+		)),
+	)
+
+	lis, conn, done, err := serverutil.ListenTLS(ctx, *addr, certFile, keyFile)
+	if err != nil {
+		glog.Exitf("Listen(%v): %v", *addr, err)
+	}
+	defer done()
+	internalServer := grpc.NewServer()
+	internalLis, internalConn, internalDone, err := serverutil.ListenLoopback(ctx)
+	if err != nil {
+		glog.Exitf("ListenLoopback(): %v", err)
+	}
+	defer internalDone()
+
+	adminSvr := adminserver.New(tlog, tmap,
+		trillian.NewTrillianAdminClient(tconn),
+		trillian.NewTrillianAdminClient(tconn),
+		directories, mutations, mutations, mutations,
+		func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
+			return der.NewProtoFromSpec(spec)
+		},
+		auditLog)
+	pb.RegisterKeyTransparencyServer(grpcServer, keyserver.New(tlog, tmap, entry.IsValidEntry,
+		directories, mutations, mutations,
+		prometheus.MetricFactory{}, int32(*revisionPageSize), tokens, limiter))
+	pb.RegisterKeyTransparencyAdminServer(grpcServer, adminSvr)
+	seqServer := sequencer.NewServer(
+		directories, tlog, tmap, trillian.NewTrillianMapWriteClient(tconn),
+		mutations, mutations, mutations, mutations,
+		spb.NewKeyTransparencySequencerClient(internalConn),
+		prometheus.MetricFactory{})
+	signer := sequencer.New(
+		spb.NewKeyTransparencySequencerClient(internalConn),
+		directories,
+		election.NewTracker(forcemaster.Factory{}, 1*time.Hour, prometheus.MetricFactory{}),
+	)
+	seqServer.Scheduler = sequencer.NewScheduler(signer, int32(*batchSize), *refresh)
+	spb.RegisterKeyTransparencySequencerServer(grpcServer, seqServer)
+	spb.RegisterKeyTransparencySequencerServer(internalServer, seqServer)
+	monitorStore := fake.NewMonitorStorage()
+	mopb.RegisterMonitorServer(grpcServer, monitorserver.New(monitorStore))
+	reflection.Register(grpcServer)
+	grpc_prometheus.Register(grpcServer)
+	grpc_prometheus.EnableHandlingTimeHistogram()
+
+	// Bootstrap the directory before any client can connect.
+	if _, err := adminSvr.CreateDirectory(ctx, &pb.CreateDirectoryRequest{
+		DirectoryId: *directoryID,
+		MinInterval: ptypes.DurationProto(*minInterval),
+		MaxInterval: ptypes.DurationProto(*maxInterval),
+	}); err != nil {
+		glog.Exitf("CreateDirectory(%v): %v", *directoryID, err)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error { return serverutil.ServeHTTPMetrics(*metricsAddr, serverutil.Healthz()) })
+	g.Go(func() error { return internalServer.Serve(internalLis) })
+	g.Go(func() error {
+		return serverutil.ServeHTTPAPIAndGRPC(gctx, lis, grpcServer, conn,
+			pb.RegisterKeyTransparencyHandler,
+			pb.RegisterKeyTransparencyAdminHandler,
+			mopb.RegisterMonitorHandler)
+	})
+	go seqServer.Scheduler.RunSequencer(gctx, *dirRefresh, *prune)
+	if *runMonitor {
+		go func() {
+			if err := runDirectoryMonitor(gctx, pb.NewKeyTransparencyClient(conn), monitorStore); err != nil {
+				glog.Errorf("Monitor exiting: %v", err)
+			}
+		}()
+	}
+
+	if err := writeClientConfig(lis.Addr(), certFile); err != nil {
+		glog.Exitf("Failed to write client config: %v", err)
+	}
+
+	glog.Errorf("Key Transparency development server exiting: %v", g.Wait())
+}
+
+// writeClientConfig writes a keytransparency-client config to the state
+// directory and prints it.
+func writeClientConfig(addr net.Addr, certFile string) error {
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return err
+	}
+	config := fmt.Sprintf(`kt-url: %v
+kt-cert: %v
+directory: %v
+fake-auth-userid: %v
+`, net.JoinHostPort("localhost", port), certFile, *directoryID, *user)
+
+	configFile := filepath.Join(*stateDir, "keytransparency.yaml")
+	if err := ioutil.WriteFile(configFile, []byte(config), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "# Client config, written to %v.\n# Use it with: keytransparency-client --config=%v\n%v",
+		configFile, configFile, config)
+	return nil
+}
+
+// runDirectoryMonitor verifies every revision of the directory, signing the
+// map roots it accepts with a generated key, and saves the results in store.
+func runDirectoryMonitor(ctx context.Context, ktClient pb.KeyTransparencyClient, store *fake.MonitorStorage) error {
+	// The first call might fail while the server is starting up.
+	cctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	b := backoff.Backoff{
+		Min:    time.Millisecond,
+		Max:    time.Second,
+		Factor: 1.5,
+	}
+	var config *pb.Directory
+	if err := b.Retry(cctx, func() (err error) {
+		config, err = ktClient.GetDirectory(cctx, &pb.GetDirectoryRequest{DirectoryId: *directoryID})
+		return
+	}, codes.Unavailable); err != nil {
+		return fmt.Errorf("could not read directory info: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	mon, err := monitor.NewFromDirectory(ktClient, config, tcrypto.NewSigner(0, key, crypto.SHA256), store)
+	if err != nil {
+		return fmt.Errorf("failed to initialize monitor: %v", err)
+	}
+	return mon.ProcessLoop(ctx, 0)
+}
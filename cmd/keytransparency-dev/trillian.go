@@ -0,0 +1,101 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keys/der"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/server"
+	"github.com/google/trillian/server/admin"
+	"github.com/google/trillian/server/interceptor"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/util/clock"
+	"google.golang.org/grpc"
+
+	"github.com/google/keytransparency/impl/memory/trillianstorage"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+)
+
+// startTrillian runs Trillian admin, log and map servers on in-memory storage,
+// along with the log sequencer, and returns an unauthenticated connection to
+// them. Everything stops when ctx is done.
+func startTrillian(ctx context.Context) (*grpc.ClientConn, error) {
+	adminStorage := memory.NewAdminStorage(memory.NewTreeStorage())
+	registry := extension.Registry{
+		AdminStorage: adminStorage,
+		LogStorage:   trillianstorage.NewLogStorage(adminStorage),
+		MapStorage:   trillianstorage.NewMapStorage(),
+		QuotaManager: quota.Noop(),
+		// Trillian metrics are not exported, so that they cannot collide
+		// with those of Key Transparency.
+		MetricFactory: monitoring.InertMetricFactory{},
+		NewKeyProto: func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
+			return der.NewProtoFromSpec(spec)
+		},
+	}
+
+	ti := interceptor.New(registry.AdminStorage, registry.QuotaManager, false /* quotaDryRun */, registry.MetricFactory)
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			interceptor.ErrorWrapper,
+			ti.UnaryInterceptor,
+		)),
+	)
+	// The in-memory map storage serializes transactions, so map updates
+	// must run in a single one.
+	mapServer := server.NewTrillianMapServer(registry, server.TrillianMapServerOptions{UseSingleTransaction: true})
+	trillian.RegisterTrillianAdminServer(grpcServer, admin.New(registry, nil /* allowedTreeTypes */))
+	trillian.RegisterTrillianLogServer(grpcServer, server.NewTrillianLogRPCServer(registry, clock.System))
+	trillian.RegisterTrillianMapServer(grpcServer, mapServer)
+	trillian.RegisterTrillianMapWriteServer(grpcServer, server.NewTrillianMapWriteServer(registry, mapServer))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			glog.Errorf("Trillian server exiting: %v", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		grpcServer.Stop()
+	}()
+
+	sequencer := log.NewOperationManager(log.OperationInfo{
+		Registry:    registry,
+		BatchSize:   1000,
+		NumWorkers:  1,
+		RunInterval: 100 * time.Millisecond,
+		TimeSource:  clock.System,
+	}, log.NewSequencerManager(registry, 0 /* guardWindow */))
+	go sequencer.OperationLoop(ctx)
+
+	glog.Infof("Trillian listening on %v", lis.Addr())
+	return grpc.DialContext(ctx, lis.Addr().String(), grpc.WithInsecure())
+}
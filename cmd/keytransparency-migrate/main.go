@@ -0,0 +1,78 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// keytransparency-migrate upgrades the schema of a Key Transparency database
+// to the version expected by this release.
+//
+// The server and sequencer apply migrations when they start, but running them
+// ahead of a rollout keeps slow schema changes out of the startup path.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/google/keytransparency/impl/sql/adminaudit"
+	"github.com/google/keytransparency/impl/sql/directory"
+	"github.com/google/keytransparency/impl/sql/mutationstorage"
+
+	ktsql "github.com/google/keytransparency/impl/sql"
+)
+
+var (
+	dbPath  = flag.String("db", "db", "Database connection string. postgres:// URLs select PostgreSQL, sqlite:// URLs select an SQLite file, other strings are MySQL DSNs")
+	timeout = flag.Duration("timeout", time.Hour, "Time allowed for all migrations to complete")
+	dryRun  = flag.Bool("dry_run", false, "Print the current schema versions without applying migrations")
+)
+
+// components are the sets of tables with independently versioned schemas.
+var components = []struct {
+	name       string
+	migrations []ktsql.Migration
+	migrate    func(context.Context, *sql.DB) error
+}{
+	{name: "mutations", migrations: mutationstorage.Migrations, migrate: mutationstorage.Migrate},
+	{name: "directories", migrations: directory.Migrations, migrate: directory.Migrate},
+	{name: "adminaudit", migrations: adminaudit.Migrations, migrate: adminaudit.Migrate},
+}
+
+func main() {
+	flag.Parse()
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	db, err := ktsql.Open(*dbPath)
+	if err != nil {
+		glog.Exitf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, c := range components {
+		version, err := ktsql.SchemaVersion(ctx, db, c.name)
+		if err != nil {
+			glog.Exitf("Failed to read %v schema version: %v", c.name, err)
+		}
+		glog.Infof("%v: schema version %v, latest version %v", c.name, version, len(c.migrations))
+		if *dryRun {
+			continue
+		}
+		if err := c.migrate(ctx, db); err != nil {
+			glog.Exitf("Failed to migrate %v: %v", c.name, err)
+		}
+	}
+}
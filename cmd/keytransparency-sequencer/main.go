@@ -16,9 +16,12 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"flag"
 	"fmt"
 	"os"
+	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/golang/glog"
@@ -37,15 +40,19 @@ import (
 	"github.com/google/keytransparency/core/adminserver"
 	"github.com/google/keytransparency/core/sequencer"
 	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/core/sequencer/runner"
+	"github.com/google/keytransparency/impl/authorization"
+	"github.com/google/keytransparency/impl/sql/adminaudit"
 	"github.com/google/keytransparency/impl/sql/directory"
 	"github.com/google/keytransparency/impl/sql/mutationstorage"
 	"github.com/google/keytransparency/internal/forcemaster"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
-	dir "github.com/google/keytransparency/core/directory"
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 	ktsql "github.com/google/keytransparency/impl/sql"
+	sqlelect "github.com/google/keytransparency/impl/sql/election"
 	etcdelect "github.com/google/trillian/util/election2/etcd"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 
 	_ "github.com/google/trillian/crypto/keys/der/proto"
@@ -62,27 +69,52 @@ var (
 	forceMaster = flag.Bool("force_master", false, "If true, assume master for all directories")
 	etcdServers = flag.String("etcd_servers", "", "A comma-separated list of etcd servers; no etcd registration if empty")
 	lockDir     = flag.String("lock_file_path", "/keytransparency/master", "etcd lock file directory path")
+	sqlElection = flag.Bool("sql_election", false, "If true, elect masters with leases stored in the --db database instead of etcd")
+	leaseTime   = flag.Duration("lease_duration", 10*time.Second, "Time a master keeps mastership without renewing its --sql_election lease")
 
-	serverDBPath = flag.String("db", "db", "Database connection string")
+	serverDBPath = flag.String("db", "db", "Database connection string. postgres:// URLs select PostgreSQL, sqlite:// URLs select an SQLite file, other strings are MySQL DSNs")
+
+	// Authentication and authorization of admin API callers.
+	authType     = flag.String("auth-type", "google", "Sets the type of authentication required from callers of the admin API. Accepted values are "+serverutil.AuthTypes+".")
+	oidcIssuer   = flag.String("oidc-issuer", "", "Required iss claim of OpenID Connect ID tokens")
+	oidcAudience = flag.String("oidc-audience", "", "Required aud claim of OpenID Connect ID tokens, typically the client ID")
+	oidcJWKS     = flag.String("oidc-jwks", "", "Path or URL of the JSON Web Key Set used to verify OpenID Connect ID tokens")
+	oidcKeyTTL   = flag.Duration("oidc-key-ttl", time.Hour, "Time for which keys read from --oidc-jwks are cached")
+	clientCA     = flag.String("client-ca", "", "PEM file of CAs that issue client certificates, used by mtls auth")
+	authzPolicy  = flag.String("authz-policy", "", "Path to an AuthorizationPolicy in protobuf text format granting access to admin and sequencer methods. Reloaded on SIGHUP. If unset, all admin and sequencer calls are denied")
 
 	// Info to connect to the trillian map and log.
 	mapURL = flag.String("map-url", "", "URL of Trillian Map Server")
 	logURL = flag.String("log-url", "", "URL of Trillian Log Server for Signed Map Heads")
 
 	dirRefresh = flag.Duration("directory-refresh", 5*time.Second, "Time to detect new directory")
-	refresh    = flag.Duration("refresh", 5*time.Second, "Time between map revision construction runs")
+	refresh    = flag.Duration("refresh", 5*time.Second, "Time a directory without new mutations waits before checking again")
 	batchSize  = flag.Int("batch-size", 100, "Maximum number of mutations to process per map revision")
+	prune      = flag.Duration("prune", time.Minute, "Time between runs deleting mutations older than each directory's retention policy")
+
+	reduceWorkers          = flag.Int("reduce-workers", runtime.NumCPU(), "Maximum number of workers applying mutations at once, across all directories")
+	reduceDirectoryWorkers = flag.Int("reduce-directory-workers", 0, "Maximum number of workers applying mutations at once for any one directory. 0 means --reduce-workers")
+	maxRevisionMutations   = flag.Int("max-revision-mutations", 1000000, "Maximum number of mutations defined in one revision, which bounds the memory used to apply it")
 )
 
 // getElectionFactory returns an election factory based on flags, and a
 // function which releases the resources associated with the factory.
-func getElectionFactory() (election2.Factory, func()) {
+func getElectionFactory(db *sql.DB) (election2.Factory, func()) {
 	if *forceMaster {
 		glog.Warning("Acting as master for all directories")
 		return forcemaster.Factory{}, func() {}
 	}
+	hostname, _ := os.Hostname()
+	instanceID := fmt.Sprintf("%s.%d", hostname, os.Getpid())
+	if *sqlElection {
+		factory, err := sqlelect.NewFactory(db, instanceID, *leaseTime)
+		if err != nil {
+			glog.Exitf("Failed to create SQL election factory: %v", err)
+		}
+		return factory, func() {}
+	}
 	if len(*etcdServers) == 0 {
-		glog.Exit("Either --force_master or --etcd_servers must be supplied")
+		glog.Exit("One of --force_master, --sql_election or --etcd_servers must be supplied")
 	}
 
 	cli, err := etcd.NewClientFromString(*etcdServers)
@@ -95,8 +127,6 @@ func getElectionFactory() (election2.Factory, func()) {
 		}
 	}
 
-	hostname, _ := os.Hostname()
-	instanceID := fmt.Sprintf("%s.%d", hostname, os.Getpid())
 	factory := etcdelect.NewFactory(instanceID, cli, *lockDir)
 
 	return factory, closeFn
@@ -134,26 +164,97 @@ func main() {
 		glog.Exitf("Failed to create directory storage object: %v", err)
 	}
 
+	// keytransparency-sequencer replay <directory> <revision> checks a
+	// revision instead of running the sequencer.
+	if flag.Arg(0) == "replay" {
+		ok, err := replay(ctx, os.Stdout, flag.Args()[1:], directoryStorage, mutations, mutations, mutations,
+			trillian.NewTrillianMapClient(mconn))
+		if err != nil {
+			glog.Exit(err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	auditLog, err := adminaudit.New(sqldb)
+	if err != nil {
+		glog.Exitf("Failed to create audit log: %v", err)
+	}
+
+	authFunc, clientCAs, err := serverutil.AuthConfig{
+		Type:         *authType,
+		OIDCIssuer:   *oidcIssuer,
+		OIDCAudience: *oidcAudience,
+		OIDCJWKS:     *oidcJWKS,
+		OIDCKeyTTL:   *oidcKeyTTL,
+		ClientCA:     *clientCA,
+	}.AuthFunc(ctx)
+	if err != nil {
+		glog.Exit(err)
+	}
+	authz := &authorization.AuthzPolicy{}
+	if *authzPolicy != "" {
+		if err := authz.Reload(*authzPolicy); err != nil {
+			glog.Exitf("Failed to load authorization policy: %v", err)
+		}
+		go authz.ReloadOnSignal(ctx, *authzPolicy, syscall.SIGHUP)
+	} else {
+		glog.Warning("No --authz-policy provided. All admin and sequencer API calls will be denied.")
+	}
+
+	// Every admin and sequencer method requires an authorized caller, and
+	// authorized admin actions are recorded in the audit log. This
+	// binary calls the sequencer service over a loopback connection that
+	// is not authenticated.
 	grpcServer := grpc.NewServer(
 		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+			grpc_prometheus.UnaryServerInterceptor,
+			authorization.UnaryServerInterceptor(serverutil.AdminAuth(authFunc, authz.AuthorizeMethod)),
+			serverutil.AuditInterceptor(auditLog),
+		)),
 	)
 
 	// Listen and create empty grpc client connection.
-	lis, conn, done, err := serverutil.ListenTLS(ctx, *addr, *certFile, *keyFile)
+	lis, conn, done, err := serverutil.ListenMutualTLS(ctx, *addr, *certFile, *keyFile, clientCAs)
 	if err != nil {
 		glog.Fatalf("Listen(%v): %v", *addr, err)
 	}
 	defer done()
+	internalServer := grpc.NewServer(
+		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
+		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
+	)
+	internalLis, internalConn, internalDone, err := serverutil.ListenLoopback(ctx)
+	if err != nil {
+		glog.Fatalf("ListenLoopback(): %v", err)
+	}
+	defer internalDone()
 
-	spb.RegisterKeyTransparencySequencerServer(grpcServer, sequencer.NewServer(
+	seqServer := sequencer.NewServer(
 		directoryStorage,
 		trillian.NewTrillianLogClient(lconn),
 		trillian.NewTrillianMapClient(mconn),
 		trillian.NewTrillianMapWriteClient(mconn),
-		mutations, mutations,
-		spb.NewKeyTransparencySequencerClient(conn),
-		prometheus.MetricFactory{}))
+		mutations, mutations, mutations, mutations,
+		spb.NewKeyTransparencySequencerClient(internalConn),
+		prometheus.MetricFactory{})
+	seqServer.ReduceBudget = runner.NewBudget(*reduceWorkers, *reduceDirectoryWorkers)
+	seqServer.ReduceWorkers = seqServer.ReduceBudget.PerDirectory()
+	seqServer.MaxRevisionMutations = *maxRevisionMutations
+
+	electionFactory, closeFactory := getElectionFactory(sqldb)
+	defer closeFactory()
+	signer := sequencer.New(
+		spb.NewKeyTransparencySequencerClient(internalConn),
+		directoryStorage,
+		election.NewTracker(electionFactory, 1*time.Hour, prometheus.MetricFactory{}),
+	)
+	seqServer.Scheduler = sequencer.NewScheduler(signer, int32(*batchSize), *refresh)
+	spb.RegisterKeyTransparencySequencerServer(grpcServer, seqServer)
+	spb.RegisterKeyTransparencySequencerServer(internalServer, seqServer)
 
 	pb.RegisterKeyTransparencyAdminServer(grpcServer, adminserver.New(
 		trillian.NewTrillianLogClient(lconn),
@@ -163,9 +264,11 @@ func main() {
 		directoryStorage,
 		mutations,
 		mutations,
+		mutations,
 		func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
 			return der.NewProtoFromSpec(spec)
-		}))
+		},
+		auditLog))
 
 	reflection.Register(grpcServer)
 	grpc_prometheus.Register(grpcServer)
@@ -173,65 +276,12 @@ func main() {
 
 	g, gctx := errgroup.WithContext(ctx)
 	g.Go(func() error { return serverutil.ServeHTTPMetrics(*metricsAddr, serverutil.Readyz(sqldb)) })
+	g.Go(func() error { return internalServer.Serve(internalLis) })
 	g.Go(func() error {
 		return serverutil.ServeHTTPAPIAndGRPC(gctx, lis, grpcServer, conn,
 			pb.RegisterKeyTransparencyAdminHandler)
 	})
-	go runSequencer(gctx, conn, directoryStorage)
+	go seqServer.Scheduler.RunSequencer(gctx, *dirRefresh, *prune)
 
 	glog.Errorf("Sequencer exiting: %v", g.Wait())
 }
-
-func runSequencer(ctx context.Context, conn *grpc.ClientConn, directoryStorage dir.Storage) {
-	glog.Infof("Sequencer starting")
-	electionFactory, closeFactory := getElectionFactory()
-	defer closeFactory()
-	signer := sequencer.New(
-		spb.NewKeyTransparencySequencerClient(conn),
-		directoryStorage,
-		election.NewTracker(electionFactory, 1*time.Hour, prometheus.MetricFactory{}),
-	)
-
-	go signer.TrackMasterships(ctx)
-
-	go sequencer.PeriodicallyRun(ctx, time.Tick(*refresh), func(ctx context.Context) {
-		if err := signer.ForAllMasterships(ctx, func(ctx context.Context, dirID string) error {
-			_, err := spb.NewKeyTransparencySequencerClient(conn).
-				EstimateBacklog(ctx, &spb.EstimateBacklogRequest{
-					DirectoryId:       dirID,
-					MaxUnappliedCount: 100000,
-				})
-			return err
-		}); err != nil {
-			glog.Errorf("UpdateMetrics(): %v", err)
-		}
-	})
-
-	if err := signer.AddAllDirectories(ctx); err != nil {
-		glog.Errorf("runSequencer(AddAllDirectories): %v", err)
-	}
-	go sequencer.PeriodicallyRun(ctx, time.Tick(*dirRefresh), func(ctx context.Context) {
-		if err := signer.AddAllDirectories(ctx); err != nil {
-			glog.Errorf("PeriodicallyRun(AddAllDirectories): %v", err)
-		}
-	})
-
-	go sequencer.PeriodicallyRun(ctx, time.Tick(*refresh), func(ctx context.Context) {
-		if err := signer.DefineRevisionsForAllMasterships(ctx, int32(*batchSize)); err != nil {
-			glog.Errorf("PeriodicallyRun(DefineRevisionsForAllMasterships): %v", err)
-		}
-	})
-	go sequencer.PeriodicallyRun(ctx, time.Tick(*refresh), func(ctx context.Context) {
-		if err := signer.ApplyRevisionsForAllMasterships(ctx); err != nil {
-			glog.Errorf("PeriodicallyRun(ApplyRevisionsForAllMasterships): %v", err)
-		}
-	})
-
-	go sequencer.PeriodicallyRun(ctx, time.Tick(*refresh), func(ctx context.Context) {
-		if err := signer.PublishLogForAllMasterships(ctx); err != nil {
-			glog.Errorf("PeriodicallyRun(PublishRevisionsForAllMasterships): %v", err)
-		}
-	})
-
-	<-ctx.Done() // Block until server exit.
-}
@@ -0,0 +1,72 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/trillian"
+
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/sequencer"
+)
+
+const replayUsage = "usage: keytransparency-sequencer [flags] replay <directory> <revision>"
+
+// replay recomputes a map revision from the mutations in storage and prints
+// how it compares with the revision in the Trillian map. It returns false if
+// the revisions differ.
+func replay(ctx context.Context, w io.Writer, args []string, directories directory.Storage,
+	batcher sequencer.Batcher, logs sequencer.LogsReader, pruner sequencer.Pruner,
+	tmap trillian.TrillianMapClient) (bool, error) {
+	if len(args) != 2 {
+		return false, errors.New(replayUsage)
+	}
+	rev, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid revision %q: %v", args[1], err)
+	}
+	dir, err := directories.Read(ctx, args[0], false)
+	if err != nil {
+		return false, fmt.Errorf("directories.Read(%v): %v", args[0], err)
+	}
+	r, err := sequencer.ReplayRevision(ctx, dir, batcher, logs, pruner, tmap, rev)
+	if err != nil {
+		return false, err
+	}
+
+	fmt.Fprintf(w, "Directory:         %v\n", r.DirectoryID)
+	fmt.Fprintf(w, "Revision:          %v\n", r.Revision)
+	fmt.Fprintf(w, "Mutations:         %v (%v invalid)\n", r.Mutations, r.InvalidMutations)
+	fmt.Fprintf(w, "Indexes:           %v\n", r.Indexes)
+	fmt.Fprintf(w, "Metadata matches:  %v\n", r.MetadataMatches)
+	fmt.Fprintf(w, "Recomputed root:   %x\n", r.RootHash)
+	fmt.Fprintf(w, "Map root:          %x\n", r.MapRootHash)
+	if r.FirstDivergentIndex != nil {
+		fmt.Fprintf(w, "First divergence:  %x\n", r.FirstDivergentIndex)
+		fmt.Fprintf(w, "  recomputed leaf: %x\n", r.Recomputed.GetLeafValue())
+		fmt.Fprintf(w, "  map leaf:        %x\n", r.Stored.GetLeafValue())
+	}
+	if r.Matches() {
+		fmt.Fprintln(w, "OK: the revision matches the map")
+	} else {
+		fmt.Fprintln(w, "MISMATCH: the revision differs from the map")
+	}
+	return r.Matches(), nil
+}
@@ -17,6 +17,9 @@ package main
 import (
 	"context"
 	"flag"
+	"io/ioutil"
+	"syscall"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/google/trillian"
@@ -36,7 +39,6 @@ import (
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 	ktsql "github.com/google/keytransparency/impl/sql"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
-	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 
 	_ "github.com/google/trillian/crypto/keys/der/proto"
@@ -45,14 +47,24 @@ import (
 var (
 	addr         = flag.String("addr", ":8080", "The ip:port combination to listen on")
 	metricsAddr  = flag.String("metrics-addr", ":8081", "The ip:port to publish metrics on")
-	serverDBPath = flag.String("db", "test:zaphod@tcp(localhost:3306)/test", "Database connection string")
+	serverDBPath = flag.String("db", "test:zaphod@tcp(localhost:3306)/test", "Database connection string. postgres:// URLs select PostgreSQL, sqlite:// URLs select an SQLite file, other strings are MySQL DSNs")
 	keyFile      = flag.String("tls-key", "genfiles/server.key", "TLS private key file")
 	certFile     = flag.String("tls-cert", "genfiles/server.crt", "TLS cert file")
-	authType     = flag.String("auth-type", "google", "Sets the type of authentication required from clients to update their entries. Accepted values are google (oauth tokens) and insecure-fake (for testing only).")
+	authType     = flag.String("auth-type", "google", "Sets the type of authentication required from clients to update their entries. Accepted values are "+serverutil.AuthTypes+".")
+	oidcIssuer   = flag.String("oidc-issuer", "", "Required iss claim of OpenID Connect ID tokens")
+	oidcAudience = flag.String("oidc-audience", "", "Required aud claim of OpenID Connect ID tokens, typically the client ID")
+	oidcJWKS     = flag.String("oidc-jwks", "", "Path or URL of the JSON Web Key Set used to verify OpenID Connect ID tokens")
+	oidcKeyTTL   = flag.Duration("oidc-key-ttl", time.Hour, "Time for which keys read from --oidc-jwks are cached")
+	clientCA     = flag.String("client-ca", "", "PEM file of CAs that issue client certificates, used by mtls auth")
+	authzPolicy  = flag.String("authz-policy", "", "Path to an AuthorizationPolicy in protobuf text format. Reloaded on SIGHUP. If unset, users may only update their own entries")
 
 	mapURL           = flag.String("map-url", "", "URL of Trillian Map Server")
 	logURL           = flag.String("log-url", "", "URL of Trillian Log Server for Signed Map Heads")
 	revisionPageSize = flag.Int("revision-page-size", 10, "Max number of revisions to return at once")
+
+	pageTokenKeyFile = flag.String("page-token-key", "", "Path to a file containing a secret of at least 32 bytes used to protect page tokens. Must be shared by all frontends. A random key is used if unset")
+	pageTokenEncrypt = flag.Bool("page-token-encrypt", true, "Encrypt page tokens in addition to authenticating them")
+	pageTokenTTL     = flag.Duration("page-token-ttl", time.Hour, "Time for which page tokens remain valid")
 )
 
 func main() {
@@ -67,20 +79,22 @@ func main() {
 	defer sqldb.Close()
 
 	authz := &authorization.AuthzPolicy{}
-	var authFunc grpc_auth.AuthFunc
-	switch *authType {
-	case "insecure-fake":
-		glog.Warning("INSECURE! Using fake authentication.")
-		authFunc = authentication.FakeAuthFunc
-	case "google":
-		var err error
-		gauth, err := authentication.NewGoogleAuth(ctx)
-		if err != nil {
-			glog.Exitf("Failed to create authentication library instance: %v", err)
+	if *authzPolicy != "" {
+		if err := authz.Reload(*authzPolicy); err != nil {
+			glog.Exitf("Failed to load authorization policy: %v", err)
 		}
-		authFunc = gauth.AuthFunc
-	default:
-		glog.Exitf("Invalid auth-type parameter: %v.", *authType)
+		go authz.ReloadOnSignal(ctx, *authzPolicy, syscall.SIGHUP)
+	}
+	authFunc, clientCAs, err := serverutil.AuthConfig{
+		Type:         *authType,
+		OIDCIssuer:   *oidcIssuer,
+		OIDCAudience: *oidcAudience,
+		OIDCJWKS:     *oidcJWKS,
+		OIDCKeyTTL:   *oidcKeyTTL,
+		ClientCA:     *clientCA,
+	}.AuthFunc(ctx)
+	if err != nil {
+		glog.Exit(err)
 	}
 
 	// Create database and helper objects.
@@ -105,9 +119,19 @@ func main() {
 	tlog := trillian.NewTrillianLogClient(tconn)
 	tmap := trillian.NewTrillianMapClient(mconn)
 
+	tokens, err := pageTokens()
+	if err != nil {
+		glog.Exitf("Failed to create page token keys: %v", err)
+	}
+
+	limiter := keyserver.NewRateLimiter(func(ctx context.Context) string {
+		sctx, _ := authentication.FromContext(ctx)
+		return sctx.GetEmail()
+	})
+
 	// Create gRPC server.
 	ksvr := keyserver.New(tlog, tmap, entry.IsValidEntry, directories, logs, logs,
-		prometheus.MetricFactory{}, int32(*revisionPageSize))
+		prometheus.MetricFactory{}, int32(*revisionPageSize), tokens, limiter)
 	grpcServer := grpc.NewServer(
 		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(
 			grpc_prometheus.StreamServerInterceptor,
@@ -118,7 +142,11 @@ func main() {
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
 			grpc_prometheus.UnaryServerInterceptor,
 			authorization.UnaryServerInterceptor(map[string]authorization.AuthPair{
-				"/google.keytransparency.v1.KeyTransparency/UpdateEntry": {
+				"/google.keytransparency.v1.KeyTransparency/QueueEntryUpdate": {
+					AuthnFunc: authFunc,
+					AuthzFunc: authz.Authorize,
+				},
+				"/google.keytransparency.v1.KeyTransparency/BatchQueueUserUpdate": {
 					AuthnFunc: authFunc,
 					AuthzFunc: authz.Authorize,
 				},
@@ -130,7 +158,7 @@ func main() {
 	grpc_prometheus.Register(grpcServer)
 	grpc_prometheus.EnableHandlingTimeHistogram()
 
-	lis, conn, done, err := serverutil.ListenTLS(ctx, *addr, *certFile, *keyFile)
+	lis, conn, done, err := serverutil.ListenMutualTLS(ctx, *addr, *certFile, *keyFile, clientCAs)
 	if err != nil {
 		glog.Fatalf("Listen(%v): %v", *addr, err)
 	}
@@ -144,3 +172,16 @@ func main() {
 
 	glog.Errorf("Key Transparency Server exiting: %v", g.Wait())
 }
+
+// pageTokens returns the page token keys selected by the page-token flags.
+func pageTokens() (*keyserver.PageTokens, error) {
+	if *pageTokenKeyFile == "" {
+		glog.Warning("No --page-token-key provided. Page tokens will not survive restarts or work across frontends.")
+		return keyserver.NewEphemeralPageTokens(*pageTokenTTL)
+	}
+	key, err := ioutil.ReadFile(*pageTokenKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return keyserver.NewPageTokens(key, *pageTokenEncrypt, *pageTokenTTL)
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"context"
+
+	"github.com/google/keytransparency/core/adminserver"
+	"github.com/google/keytransparency/impl/authentication"
+	"github.com/google/keytransparency/impl/authorization"
+	"google.golang.org/grpc"
+
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+)
+
+const (
+	adminService     = "/google.keytransparency.v1.KeyTransparencyAdmin/"
+	sequencerService = "/google.keytransparency.sequencer.KeyTransparencySequencer/"
+)
+
+// AdminAuth returns the auth of every method of the admin and sequencer APIs,
+// for use with authorization.UnaryServerInterceptor. Callers must be
+// authenticated by authn and authorized by authz.
+func AdminAuth(authn grpc_auth.AuthFunc, authz authorization.AuthzFunc) map[string]authorization.AuthPair {
+	pair := authorization.AuthPair{AuthnFunc: authn, AuthzFunc: authz}
+	return map[string]authorization.AuthPair{
+		adminService:     pair,
+		sequencerService: pair,
+	}
+}
+
+// AuditInterceptor returns the interceptor that records admin actions in
+// audit with the caller's email. It must be chained after
+// authorization.UnaryServerInterceptor.
+func AuditInterceptor(audit adminserver.AuditLog) grpc.UnaryServerInterceptor {
+	return adminserver.AuditInterceptor(audit, func(ctx context.Context) string {
+		sctx, _ := authentication.FromContext(ctx)
+		return sctx.GetEmail()
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/impl/authentication"
+	"github.com/google/keytransparency/impl/authorization"
+	"github.com/google/keytransparency/impl/memory"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+)
+
+func TestAdminInterceptor(t *testing.T) {
+	ctx := context.Background()
+	authz := func(ctx context.Context, _ interface{}) error {
+		if sctx, _ := authentication.FromContext(ctx); sctx.GetEmail() != "admin" {
+			return status.Error(codes.PermissionDenied, "not an admin")
+		}
+		return nil
+	}
+	audit := memory.NewAuditLog()
+	s := grpc.NewServer(grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
+		authorization.UnaryServerInterceptor(AdminAuth(authentication.FakeAuthFunc, authz)),
+		AuditInterceptor(audit),
+	)))
+	spb.RegisterKeyTransparencySequencerServer(s, &spb.UnimplementedKeyTransparencySequencerServer{})
+	pb.RegisterKeyTransparencyAdminServer(s, &pb.UnimplementedKeyTransparencyAdminServer{})
+	lis, conn, done, err := ListenLoopback(ctx)
+	if err != nil {
+		t.Fatalf("ListenLoopback(): %v", err)
+	}
+	defer done()
+	go s.Serve(lis)
+	defer s.Stop()
+	seq := spb.NewKeyTransparencySequencerClient(conn)
+	admin := pb.NewKeyTransparencyAdminClient(conn)
+
+	for _, tc := range []struct {
+		desc   string
+		caller string
+		want   codes.Code
+	}{
+		{desc: "no credentials", want: codes.Unauthenticated},
+		{desc: "not authorized", caller: "alice", want: codes.PermissionDenied},
+		{desc: "authorized", caller: "admin", want: codes.Unimplemented},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			cctx := ctx
+			if tc.caller != "" {
+				cctx = authentication.WithOutgoingFakeAuth(ctx, tc.caller)
+			}
+			_, err := seq.DefineRevisions(cctx, &spb.DefineRevisionsRequest{DirectoryId: "dir"})
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("DefineRevisions(): %v, want %v", err, tc.want)
+			}
+			_, err = admin.GetDirectory(cctx, &pb.GetDirectoryRequest{DirectoryId: "dir"})
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("GetDirectory(): %v, want %v", err, tc.want)
+			}
+			_, err = admin.DeleteDirectory(cctx, &pb.DeleteDirectoryRequest{DirectoryId: "dir"})
+			if got := status.Code(err); got != tc.want {
+				t.Errorf("DeleteDirectory(): %v, want %v", err, tc.want)
+			}
+
+			// Only authorized calls to DeleteDirectory are audited.
+			events, err := audit.List(ctx, 0, 100)
+			if err != nil {
+				t.Fatalf("List(): %v", err)
+			}
+			if tc.caller != "admin" {
+				if len(events) != 0 {
+					t.Errorf("audit events: %v, want none", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("audit events: %v, want 1", events)
+			}
+			if e := events[0]; e.Caller != tc.caller || e.Method != adminService+"DeleteDirectory" ||
+				codes.Code(e.StatusCode) != tc.want || e.CompletionTime == nil {
+				t.Errorf("audit event: %v, want caller %q and code %v", e, tc.caller, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/keytransparency/impl/authentication"
+
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+)
+
+// AuthTypes describes the accepted values of AuthConfig.Type, for use in flag help.
+const AuthTypes = "google (oauth tokens), oidc (ID tokens verified against --oidc-jwks), mtls (client certificates issued by --client-ca) and insecure-fake (for testing only)"
+
+// AuthConfig selects how callers are authenticated.
+type AuthConfig struct {
+	// Type is one of google, oidc, mtls or insecure-fake.
+	Type string
+
+	OIDCIssuer   string
+	OIDCAudience string
+	OIDCJWKS     string
+	OIDCKeyTTL   time.Duration
+
+	// ClientCA is the PEM file of CAs that issue client certificates.
+	ClientCA string
+}
+
+// AuthFunc returns the authentication function selected by c. For mtls, it
+// also returns the CAs the TLS listener should request client certificates for.
+func (c AuthConfig) AuthFunc(ctx context.Context) (grpc_auth.AuthFunc, *x509.CertPool, error) {
+	switch c.Type {
+	case "insecure-fake":
+		glog.Warning("INSECURE! Using fake authentication.")
+		return authentication.FakeAuthFunc, nil, nil
+	case "google":
+		gauth, err := authentication.NewGoogleAuth(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create authentication library instance: %v", err)
+		}
+		return gauth.AuthFunc, nil, nil
+	case "oidc":
+		oidcAuth, err := authentication.NewOIDCAuth(ctx, authentication.OIDCConfig{
+			Issuer:      c.OIDCIssuer,
+			Audience:    c.OIDCAudience,
+			JWKS:        c.OIDCJWKS,
+			KeyCacheTTL: c.OIDCKeyTTL,
+			ClockSkew:   time.Minute,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create OIDC authentication: %v", err)
+		}
+		return oidcAuth.AuthFunc, nil, nil
+	case "mtls":
+		certAuth, err := authentication.NewClientCertAuth(c.ClientCA)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create client certificate authentication: %v", err)
+		}
+		return certAuth.AuthFunc, certAuth.ClientCAs(), nil
+	default:
+		return nil, nil, fmt.Errorf("invalid auth-type parameter: %v", c.Type)
+	}
+}
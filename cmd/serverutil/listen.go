@@ -17,6 +17,7 @@ package serverutil
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"net"
 
 	"github.com/golang/glog"
@@ -26,6 +27,13 @@ import (
 
 // ListenTLS binds to listenAddr and returns a gRPC connection to it.
 func ListenTLS(ctx context.Context, listenAddr, certFile, keyFile string) (net.Listener, *grpc.ClientConn, func(), error) {
+	return ListenMutualTLS(ctx, listenAddr, certFile, keyFile, nil)
+}
+
+// ListenMutualTLS is like ListenTLS, but also verifies client certificates
+// issued by clientCAs. Clients without a certificate are still accepted so
+// that they may use unauthenticated APIs.
+func ListenMutualTLS(ctx context.Context, listenAddr, certFile, keyFile string, clientCAs *x509.CertPool) (net.Listener, *grpc.ClientConn, func(), error) {
 	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return nil, nil, nil, err
@@ -34,6 +42,10 @@ func ListenTLS(ctx context.Context, listenAddr, certFile, keyFile string) (net.L
 		Certificates: []tls.Certificate{cert},
 		NextProtos:   []string{"h2", "http/1.1"},
 	}
+	if clientCAs != nil {
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
 	lis, err := tls.Listen("tcp", listenAddr, config)
 	if err != nil {
 		return nil, nil, nil, err
@@ -57,3 +69,24 @@ func ListenTLS(ctx context.Context, listenAddr, certFile, keyFile string) (net.L
 		}
 	}, nil
 }
+
+// ListenLoopback binds to an unused port on the loopback interface and
+// returns a gRPC connection to it, for services that this process calls
+// itself. Connections are not encrypted, and are reachable from other
+// processes on the same host.
+func ListenLoopback(ctx context.Context) (net.Listener, *grpc.ClientConn, func(), error) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conn, err := grpc.DialContext(ctx, lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		lis.Close()
+		return nil, nil, nil, err
+	}
+	return lis, conn, func() {
+		if err := conn.Close(); err != nil {
+			glog.Errorf("Failed to close connection: %v", err)
+		}
+	}, nil
+}
@@ -18,6 +18,7 @@ package adminserver
 import (
 	"context"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/golang/glog"
@@ -85,9 +86,15 @@ type LogsAdmin interface {
 	// AddLogs creates and adds new logs for writing to a directory.
 	AddLogs(ctx context.Context, directoryID string, logIDs ...int64) error
 	// SetWritable enables or disables new writes from going to logID.
+	// Sealed and retired logs cannot be enabled.
 	SetWritable(ctx context.Context, directoryID string, logID int64, enabled bool) error
-	// ListLogs returns a list of logs, optionally filtered by the writable bit.
+	// ListLogs returns a list of logs that have not been retired, optionally filtered by the writable bit.
 	ListLogs(ctx context.Context, directoryID string, writable bool) ([]int64, error)
+	// ListInputLogs returns all the logs of directoryID, including retired logs.
+	ListInputLogs(ctx context.Context, directoryID string) ([]*pb.InputLog, error)
+	// RetireLog stops a sealed log from being included in new revisions.
+	// Returns FailedPrecondition if the log has not been sealed.
+	RetireLog(ctx context.Context, directoryID string, logID int64) error
 }
 
 // Batcher writes batch definitions to storage.
@@ -96,6 +103,14 @@ type Batcher interface {
 	WriteBatchSources(ctx context.Context, dirID string, rev int64, meta *spb.MapMetadata) error
 }
 
+// DataDeleter deletes the data a directory stores alongside its configuration.
+type DataDeleter interface {
+	// DeleteDirectoryData deletes the input logs, queued mutations, and
+	// revision definitions of directoryID. Deleting the data of a directory
+	// that has none must succeed.
+	DeleteDirectoryData(ctx context.Context, directoryID string) (*pb.DeletedDirectoryData, error)
+}
+
 var _ pb.KeyTransparencyAdminServer = &Server{} // Ensure *Server satisfies the AdminServer interface.
 
 // Server implements pb.KeyTransparencyAdminServer
@@ -107,7 +122,9 @@ type Server struct {
 	directories directory.Storage
 	logsAdmin   LogsAdmin
 	batcher     Batcher
+	data        DataDeleter
 	keygen      keys.ProtoGenerator
+	audit       AuditLog
 }
 
 // New returns a KeyTransparencyAdmin implementation.
@@ -118,7 +135,9 @@ func New(
 	directories directory.Storage,
 	logsAdmin LogsAdmin,
 	batcher Batcher,
+	data DataDeleter,
 	keygen keys.ProtoGenerator,
+	audit AuditLog,
 ) *Server {
 	return &Server{
 		tlog:        tlog,
@@ -128,7 +147,9 @@ func New(
 		directories: directories,
 		logsAdmin:   logsAdmin,
 		batcher:     batcher,
+		data:        data,
 		keygen:      keygen,
+		audit:       audit,
 	}
 }
 
@@ -155,13 +176,16 @@ func (s *Server) ListDirectories(ctx context.Context, in *pb.ListDirectoriesRequ
 // by fetching the relevant info from Trillian.
 func (s *Server) fetchDirectory(ctx context.Context, d *directory.Directory) (*pb.Directory, error) {
 	return &pb.Directory{
-		DirectoryId: d.DirectoryID,
-		Log:         d.Log,
-		Map:         d.Map,
-		Vrf:         d.VRF,
-		MinInterval: ptypes.DurationProto(d.MinInterval),
-		MaxInterval: ptypes.DurationProto(d.MaxInterval),
-		Deleted:     d.Deleted,
+		DirectoryId:   d.DirectoryID,
+		Log:           d.Log,
+		Map:           d.Map,
+		Vrf:           d.VRF,
+		MinInterval:   ptypes.DurationProto(d.MinInterval),
+		MaxInterval:   ptypes.DurationProto(d.MaxInterval),
+		Deleted:       d.Deleted,
+		RateLimits:    d.RateLimits,
+		Retention:     d.Retention,
+		AllowRecovery: d.AllowRecovery,
 	}, nil
 }
 
@@ -226,6 +250,13 @@ func (s *Server) CreateDirectory(ctx context.Context, in *pb.CreateDirectoryRequ
 		return nil, status.Errorf(codes.AlreadyExists, "Directory %v already exists or is soft deleted.", in.GetDirectoryId())
 	}
 
+	if err := validateRateLimits(in.GetRateLimits()); err != nil {
+		return nil, err
+	}
+	if err := validateRetention(in.GetRetention()); err != nil {
+		return nil, err
+	}
+
 	// Generate VRF key.
 	wrapped, err := privKeyOrGen(ctx, in.GetVrfPrivateKey(), s.keygen)
 	if s := status.Convert(err); s.Code() != codes.OK {
@@ -278,13 +309,16 @@ func (s *Server) CreateDirectory(ctx context.Context, in *pb.CreateDirectoryRequ
 
 	// Create directory - {log, map} binding.
 	dir := &directory.Directory{
-		DirectoryID: in.GetDirectoryId(),
-		Map:         trimmedMap,
-		Log:         trimmedLog,
-		VRF:         vrfPublicPB,
-		VRFPriv:     wrapped,
-		MinInterval: minInterval,
-		MaxInterval: maxInterval,
+		DirectoryID:   in.GetDirectoryId(),
+		Map:           trimmedMap,
+		Log:           trimmedLog,
+		VRF:           vrfPublicPB,
+		VRFPriv:       wrapped,
+		MinInterval:   minInterval,
+		MaxInterval:   maxInterval,
+		RateLimits:    in.GetRateLimits(),
+		Retention:     in.GetRetention(),
+		AllowRecovery: in.GetAllowRecovery(),
 	}
 	if s := status.Convert(s.directories.Write(ctx, dir)); s.Code() != codes.OK {
 		return nil, status.Errorf(s.Code(), "adminserver: directories.Write(): %v", s.Message())
@@ -303,12 +337,15 @@ func (s *Server) CreateDirectory(ctx context.Context, in *pb.CreateDirectoryRequ
 	}
 
 	d := &pb.Directory{
-		DirectoryId: in.GetDirectoryId(),
-		Log:         trimmedLog,
-		Map:         trimmedMap,
-		Vrf:         vrfPublicPB,
-		MinInterval: in.MinInterval,
-		MaxInterval: in.MaxInterval,
+		DirectoryId:   in.GetDirectoryId(),
+		Log:           trimmedLog,
+		Map:           trimmedMap,
+		Vrf:           vrfPublicPB,
+		MinInterval:   in.MinInterval,
+		MaxInterval:   in.MaxInterval,
+		RateLimits:    in.GetRateLimits(),
+		Retention:     in.GetRetention(),
+		AllowRecovery: in.GetAllowRecovery(),
 	}
 	glog.Infof("Created directory: %+v", d)
 	return d, nil
@@ -391,15 +428,79 @@ func (s *Server) UndeleteDirectory(ctx context.Context, in *pb.UndeleteDirectory
 	return nil, status.Errorf(codes.Unimplemented, "not implemented")
 }
 
+// SetRateLimits replaces the rate limits of a directory.
+func (s *Server) SetRateLimits(ctx context.Context, in *pb.SetRateLimitsRequest) (*pb.Directory, error) {
+	if err := validateRateLimits(in.GetRateLimits()); err != nil {
+		return nil, err
+	}
+	d, err := s.directories.Read(ctx, in.GetDirectoryId(), false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.directories.SetRateLimits(ctx, in.GetDirectoryId(), in.GetRateLimits()); err != nil {
+		return nil, err
+	}
+	d.RateLimits = in.GetRateLimits()
+	glog.Infof("Set rate limits of directory %v: %v", in.GetDirectoryId(), in.GetRateLimits())
+	return s.fetchDirectory(ctx, d)
+}
+
+// validateRateLimits returns InvalidArgument if any quota in limits is invalid.
+func validateRateLimits(limits *pb.RateLimits) error {
+	for _, q := range []*pb.Quota{limits.GetPerPrincipal(), limits.GetPerUser(), limits.GetPerDirectory()} {
+		if rate := q.GetUpdatesPerSecond(); rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return status.Errorf(codes.InvalidArgument, "invalid updates_per_second %v", rate)
+		}
+		if q.GetBurst() < 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid burst %v", q.GetBurst())
+		}
+	}
+	return nil
+}
+
+// SetRetentionPolicy replaces the retention policy of a directory.
+func (s *Server) SetRetentionPolicy(ctx context.Context, in *pb.SetRetentionPolicyRequest) (*pb.Directory, error) {
+	if err := validateRetention(in.GetRetention()); err != nil {
+		return nil, err
+	}
+	d, err := s.directories.Read(ctx, in.GetDirectoryId(), false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.directories.SetRetention(ctx, in.GetDirectoryId(), in.GetRetention()); err != nil {
+		return nil, err
+	}
+	d.Retention = in.GetRetention()
+	glog.Infof("Set retention policy of directory %v: %v", in.GetDirectoryId(), in.GetRetention())
+	return s.fetchDirectory(ctx, d)
+}
+
+// validateRetention returns InvalidArgument if retention is invalid.
+func validateRetention(retention *pb.RetentionPolicy) error {
+	if retention.GetRevisions() < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid revisions %v", retention.GetRevisions())
+	}
+	if retention.GetMaxAge() == nil {
+		return nil
+	}
+	if maxAge, err := ptypes.Duration(retention.GetMaxAge()); err != nil || maxAge < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid max_age %v", retention.GetMaxAge())
+	}
+	return nil
+}
+
 // ListInputLogs returns a list of input logs for a directory.
 func (s *Server) ListInputLogs(ctx context.Context, in *pb.ListInputLogsRequest) (*pb.ListInputLogsResponse, error) {
-	logIDs, err := s.logsAdmin.ListLogs(ctx, in.GetDirectoryId(), in.GetFilterWritable())
+	logs, err := s.logsAdmin.ListInputLogs(ctx, in.GetDirectoryId())
 	if s := status.Convert(err); s.Code() != codes.OK {
-		return nil, status.Errorf(s.Code(), "adminserver: ListLogs(): %v", s.Message())
+		return nil, status.Errorf(s.Code(), "adminserver: ListInputLogs(): %v", s.Message())
 	}
-	inputLogs := make([]*pb.InputLog, 0, len(logIDs))
-	for _, logID := range logIDs {
-		inputLogs = append(inputLogs, &pb.InputLog{LogId: logID, Writable: true})
+	inputLogs := make([]*pb.InputLog, 0, len(logs))
+	for _, l := range logs {
+		if in.GetFilterWritable() && !l.Writable {
+			continue
+		}
+		inputLogs = append(inputLogs, l)
 	}
 
 	return &pb.ListInputLogsResponse{Logs: inputLogs}, nil
@@ -411,7 +512,7 @@ func (s *Server) CreateInputLog(ctx context.Context, in *pb.InputLog) (*pb.Input
 	if s := status.Convert(err); s.Code() != codes.OK {
 		return nil, status.Errorf(s.Code(), "adminserver: AddLogs(%+v): %v", in.GetLogId(), s.Message())
 	}
-	return &pb.InputLog{LogId: in.GetLogId(), Writable: true}, nil
+	return &pb.InputLog{DirectoryId: in.GetDirectoryId(), LogId: in.GetLogId(), Writable: true}, nil
 }
 
 // UpdateInputLog updates the write bit for an input log.
@@ -420,10 +521,35 @@ func (s *Server) UpdateInputLog(ctx context.Context, in *pb.InputLog) (*pb.Input
 	if s := status.Convert(err); s.Code() != codes.OK {
 		return nil, status.Errorf(s.Code(), "adminserver: SetWritable(): %v", s.Message())
 	}
-	return in, nil
+	return s.inputLog(ctx, in.GetDirectoryId(), in.GetLogId())
+}
+
+// RetireInputLog stops including a sealed input log in new revisions.
+func (s *Server) RetireInputLog(ctx context.Context, in *pb.RetireInputLogRequest) (*pb.InputLog, error) {
+	err := s.logsAdmin.RetireLog(ctx, in.GetDirectoryId(), in.GetLogId())
+	if s := status.Convert(err); s.Code() != codes.OK {
+		return nil, status.Errorf(s.Code(), "adminserver: RetireLog(): %v", s.Message())
+	}
+	return s.inputLog(ctx, in.GetDirectoryId(), in.GetLogId())
+}
+
+// inputLog returns the current state of logID.
+func (s *Server) inputLog(ctx context.Context, directoryID string, logID int64) (*pb.InputLog, error) {
+	logs, err := s.logsAdmin.ListInputLogs(ctx, directoryID)
+	if s := status.Convert(err); s.Code() != codes.OK {
+		return nil, status.Errorf(s.Code(), "adminserver: ListInputLogs(): %v", s.Message())
+	}
+	for _, l := range logs {
+		if l.LogId == logID {
+			return l, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "adminserver: log %d not found for directory %v", logID, directoryID)
 }
 
 // GarbageCollect looks for directories that have been deleted before the specified timestamp and fully deletes them.
+// The directory's input logs, queued mutations, revision definitions, and Trillian trees are deleted before the
+// directory itself so that GarbageCollect can be called again to finish a directory after a partial failure.
 func (s *Server) GarbageCollect(ctx context.Context, in *pb.GarbageCollectRequest) (*pb.GarbageCollectResponse, error) {
 	before, err := ptypes.Timestamp(in.GetBefore())
 	if err != nil {
@@ -437,20 +563,55 @@ func (s *Server) GarbageCollect(ctx context.Context, in *pb.GarbageCollectReques
 	}
 
 	// Search for directories deleted before in.Before.
-	deleted := []*pb.Directory{}
+	resp := &pb.GarbageCollectResponse{Directories: []*pb.Directory{}}
 	for _, d := range directories {
 		if d.Deleted && d.DeletedTimestamp.Before(before) {
-			req := &pb.GetDirectoryRequest{DirectoryId: d.DirectoryID, ShowDeleted: showDeleted}
-			dproto, err := s.GetDirectory(ctx, req)
+			dproto, err := s.fetchDirectory(ctx, d)
 			if err != nil {
 				return nil, err
 			}
+			data, err := s.data.DeleteDirectoryData(ctx, d.DirectoryID)
+			if err != nil {
+				return nil, status.Errorf(status.Code(err), "adminserver: delete data of %v: %v", d.DirectoryID, err)
+			}
+			for _, t := range []struct {
+				tree  *tpb.Tree
+				admin tpb.TrillianAdminClient
+			}{
+				{tree: d.Log, admin: s.logAdmin},
+				{tree: d.Map, admin: s.mapAdmin},
+			} {
+				if err := deleteTree(ctx, t.admin, t.tree.GetTreeId()); err != nil {
+					return nil, status.Errorf(status.Code(err), "adminserver: delete tree %v of %v: %v",
+						t.tree.GetTreeId(), d.DirectoryID, err)
+				}
+				data.DeletedTrees = append(data.DeletedTrees, t.tree.GetTreeId())
+			}
 			if err := s.directories.Delete(ctx, d.DirectoryID); err != nil {
 				return nil, err
 			}
-			deleted = append(deleted, dproto)
+			glog.Infof("GarbageCollect: deleted directory %v: %v", d.DirectoryID, data)
+			resp.Directories = append(resp.Directories, dproto)
+			resp.Deleted = append(resp.Deleted, data)
 		}
 	}
 
-	return &pb.GarbageCollectResponse{Directories: deleted}, nil
+	return resp, nil
+}
+
+// deleteTree deletes treeID. Trees that have already been deleted, either by
+// DeleteDirectory or by Trillian, are left alone.
+func deleteTree(ctx context.Context, admin tpb.TrillianAdminClient, treeID int64) error {
+	_, err := admin.DeleteTree(ctx, &tpb.DeleteTreeRequest{TreeId: treeID})
+	switch status.Code(err) {
+	case codes.OK, codes.NotFound:
+		return nil
+	case codes.FailedPrecondition:
+		// Trillian refuses to delete trees that are already soft-deleted.
+		tree, getErr := admin.GetTree(ctx, &tpb.GetTreeRequest{TreeId: treeID})
+		if getErr == nil && tree.GetDeleted() {
+			return nil
+		}
+	}
+	return err
 }
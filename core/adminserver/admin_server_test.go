@@ -17,12 +17,14 @@ package adminserver
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/directory"
 	"github.com/google/keytransparency/core/fake"
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
@@ -35,6 +37,8 @@ import (
 	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	sqldirectory "github.com/google/keytransparency/impl/sql/directory"
+	ktestdb "github.com/google/keytransparency/impl/sql/testdb"
 	tpb "github.com/google/trillian"
 
 	_ "github.com/google/trillian/crypto/keys/der/proto" // Register PrivateKey ProtoHandler
@@ -65,7 +69,7 @@ func newMiniEnv(ctx context.Context, t *testing.T) (*miniEnv, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error starting fake server: %v", err)
 	}
-	srv := New(s.LogClient, s.MapClient, s.AdminClient, s.AdminClient, fakeDirectories, nil, fakeBatcher{}, vrfKeyGen)
+	srv := New(s.LogClient, s.MapClient, s.AdminClient, s.AdminClient, fakeDirectories, nil, fakeBatcher{}, nil, vrfKeyGen, nil)
 
 	return &miniEnv{
 		ms:             s,
@@ -85,6 +89,13 @@ type fakeQueueAdmin struct{}
 func (fakeQueueAdmin) AddLogs(_ context.Context, _ string, _ ...int64) error          { return nil }
 func (fakeQueueAdmin) SetWritable(_ context.Context, _ string, _ int64, _ bool) error { return nil }
 func (fakeQueueAdmin) ListLogs(_ context.Context, _ string, _ bool) ([]int64, error)  { return nil, nil }
+func (fakeQueueAdmin) ListInputLogs(_ context.Context, _ string) ([]*pb.InputLog, error) {
+	return nil, nil
+}
+func (fakeQueueAdmin) RetireLog(_ context.Context, _ string, _ int64) error { return nil }
+func (fakeQueueAdmin) DeleteDirectoryData(_ context.Context, dirID string) (*pb.DeletedDirectoryData, error) {
+	return &pb.DeletedDirectoryData{DirectoryId: dirID}, nil
+}
 
 type fakeBatcher struct{}
 
@@ -178,7 +189,7 @@ func TestCreateRead(t *testing.T) {
 		t.Fatalf("Failed to create trillian log server: %v", err)
 	}
 
-	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, vrfKeyGen)
+	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, fakeQueueAdmin{}, vrfKeyGen, nil)
 
 	for _, tc := range []struct {
 		directoryID              string
@@ -230,7 +241,7 @@ func TestDelete(t *testing.T) {
 		t.Fatalf("Failed to create trillian log server: %v", err)
 	}
 
-	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, vrfKeyGen)
+	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, fakeQueueAdmin{}, vrfKeyGen, nil)
 
 	for _, tc := range []struct {
 		directoryID              string
@@ -283,7 +294,7 @@ func TestListDirectories(t *testing.T) {
 		t.Fatalf("Failed to create trillian log server: %v", err)
 	}
 
-	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, vrfKeyGen)
+	svr := New(logEnv.Log, mapEnv.Map, logEnv.Admin, mapEnv.Admin, storage, fakeQueueAdmin{}, fakeBatcher{}, fakeQueueAdmin{}, vrfKeyGen, nil)
 
 	for _, tc := range []struct {
 		directoryIDs []string
@@ -318,3 +329,261 @@ func TestListDirectories(t *testing.T) {
 		}
 	}
 }
+
+func TestSetRateLimitsErrors(t *testing.T) {
+	ctx := context.Background()
+	storage := fake.NewDirectoryStorage()
+	if err := storage.Write(ctx, &directory.Directory{DirectoryID: "dir"}); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	svr := &Server{directories: storage}
+	for _, tc := range []struct {
+		desc string
+		req  *pb.SetRateLimitsRequest
+		want codes.Code
+	}{
+		{desc: "negative rate", want: codes.InvalidArgument, req: &pb.SetRateLimitsRequest{DirectoryId: "dir",
+			RateLimits: &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: -1}}}},
+		{desc: "infinite rate", want: codes.InvalidArgument, req: &pb.SetRateLimitsRequest{DirectoryId: "dir",
+			RateLimits: &pb.RateLimits{PerDirectory: &pb.Quota{UpdatesPerSecond: math.Inf(1)}}}},
+		{desc: "negative burst", want: codes.InvalidArgument, req: &pb.SetRateLimitsRequest{DirectoryId: "dir",
+			RateLimits: &pb.RateLimits{PerPrincipal: &pb.Quota{UpdatesPerSecond: 1, Burst: -1}}}},
+		{desc: "missing directory", want: codes.NotFound, req: &pb.SetRateLimitsRequest{DirectoryId: "other",
+			RateLimits: &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 1}}}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := svr.SetRateLimits(ctx, tc.req); status.Code(err) != tc.want {
+				t.Errorf("SetRateLimits(): %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetRetentionPolicyErrors(t *testing.T) {
+	ctx := context.Background()
+	storage := fake.NewDirectoryStorage()
+	if err := storage.Write(ctx, &directory.Directory{DirectoryID: "dir"}); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	svr := &Server{directories: storage}
+	for _, tc := range []struct {
+		desc string
+		req  *pb.SetRetentionPolicyRequest
+		want codes.Code
+	}{
+		{desc: "negative revisions", want: codes.InvalidArgument, req: &pb.SetRetentionPolicyRequest{DirectoryId: "dir",
+			Retention: &pb.RetentionPolicy{Revisions: -1}}},
+		{desc: "negative max age", want: codes.InvalidArgument, req: &pb.SetRetentionPolicyRequest{DirectoryId: "dir",
+			Retention: &pb.RetentionPolicy{MaxAge: ptypes.DurationProto(-time.Hour)}}},
+		{desc: "missing directory", want: codes.NotFound, req: &pb.SetRetentionPolicyRequest{DirectoryId: "other",
+			Retention: &pb.RetentionPolicy{Revisions: 10}}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := svr.SetRetentionPolicy(ctx, tc.req); status.Code(err) != tc.want {
+				t.Errorf("SetRetentionPolicy(): %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+type fakeLogsAdmin struct {
+	fakeQueueAdmin
+	logs []*pb.InputLog
+}
+
+func (f *fakeLogsAdmin) ListInputLogs(_ context.Context, _ string) ([]*pb.InputLog, error) {
+	return f.logs, nil
+}
+
+func (f *fakeLogsAdmin) RetireLog(_ context.Context, dirID string, logID int64) error {
+	for _, l := range f.logs {
+		if l.LogId != logID {
+			continue
+		}
+		if l.State == pb.InputLog_ACTIVE {
+			return status.Errorf(codes.FailedPrecondition, "log %v/%v has not been sealed", dirID, logID)
+		}
+		l.State = pb.InputLog_RETIRED
+		return nil
+	}
+	return status.Errorf(codes.NotFound, "log %v/%v not found", dirID, logID)
+}
+
+func TestInputLogs(t *testing.T) {
+	ctx := context.Background()
+	svr := &Server{logsAdmin: &fakeLogsAdmin{logs: []*pb.InputLog{
+		{DirectoryId: "dir", LogId: 1, Writable: true},
+		{DirectoryId: "dir", LogId: 2},
+		{DirectoryId: "dir", LogId: 3, State: pb.InputLog_SEALED, FinalWatermark: 10},
+	}}}
+
+	for _, tc := range []struct {
+		filterWritable bool
+		want           []int64
+	}{
+		{filterWritable: false, want: []int64{1, 2, 3}},
+		{filterWritable: true, want: []int64{1}},
+	} {
+		resp, err := svr.ListInputLogs(ctx, &pb.ListInputLogsRequest{DirectoryId: "dir", FilterWritable: tc.filterWritable})
+		if err != nil {
+			t.Fatalf("ListInputLogs(): %v", err)
+		}
+		var got []int64
+		for _, l := range resp.Logs {
+			got = append(got, l.LogId)
+		}
+		if !cmp.Equal(got, tc.want) {
+			t.Errorf("ListInputLogs(filter_writable: %v): %v, want %v", tc.filterWritable, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		logID    int64
+		wantCode codes.Code
+		want     *pb.InputLog
+	}{
+		{logID: 2, wantCode: codes.FailedPrecondition},
+		{logID: 3, want: &pb.InputLog{DirectoryId: "dir", LogId: 3, State: pb.InputLog_RETIRED, FinalWatermark: 10}},
+		{logID: 4, wantCode: codes.NotFound},
+	} {
+		got, err := svr.RetireInputLog(ctx, &pb.RetireInputLogRequest{DirectoryId: "dir", LogId: tc.logID})
+		if status.Code(err) != tc.wantCode {
+			t.Errorf("RetireInputLog(%v): %v, want %v", tc.logID, err, tc.wantCode)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("RetireInputLog(%v): %v, want %v", tc.logID, got, tc.want)
+		}
+	}
+}
+
+type fakeDataDeleter struct {
+	err error
+}
+
+func (f *fakeDataDeleter) DeleteDirectoryData(_ context.Context, dirID string) (*pb.DeletedDirectoryData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &pb.DeletedDirectoryData{DirectoryId: dirID, InputLogs: 1, Mutations: 5, Batches: 2}, nil
+}
+
+func TestGarbageCollectCascade(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+	data := &fakeDataDeleter{}
+	e.srv.data = data
+	if err := e.srv.directories.Write(ctx, &directory.Directory{
+		DirectoryID:      "deleted",
+		Log:              &tpb.Tree{TreeId: 1},
+		Map:              &tpb.Tree{TreeId: 2},
+		Deleted:          true,
+		DeletedTimestamp: time.Now().Add(-time.Hour),
+	}); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	logTree := &tpb.DeleteTreeRequest{TreeId: 1}
+	mapTree := &tpb.DeleteTreeRequest{TreeId: 2}
+	alreadyDeleted := status.Errorf(codes.FailedPrecondition, "tree already soft deleted")
+
+	// Each step runs GarbageCollect again, as an operator would after a failure.
+	for _, tc := range []struct {
+		desc       string
+		dataErr    error
+		expect     func(e *miniEnv)
+		wantCode   codes.Code
+		wantExists bool
+		want       []*pb.DeletedDirectoryData
+	}{
+		{desc: "data fails", dataErr: status.Errorf(codes.Unavailable, "db down"),
+			expect:   func(e *miniEnv) {},
+			wantCode: codes.Unavailable, wantExists: true},
+		{desc: "tree fails",
+			expect: func(e *miniEnv) {
+				e.ms.Admin.EXPECT().DeleteTree(gomock.Any(), logTree).Return(nil, status.Errorf(codes.Unavailable, "trillian down"))
+			},
+			wantCode: codes.Unavailable, wantExists: true},
+		{desc: "trees already deleted",
+			expect: func(e *miniEnv) {
+				e.ms.Admin.EXPECT().DeleteTree(gomock.Any(), logTree).Return(nil, alreadyDeleted)
+				e.ms.Admin.EXPECT().GetTree(gomock.Any(), &tpb.GetTreeRequest{TreeId: 1}).Return(&tpb.Tree{TreeId: 1, Deleted: true}, nil)
+				e.ms.Admin.EXPECT().DeleteTree(gomock.Any(), mapTree).Return(nil, status.Errorf(codes.NotFound, "no tree"))
+			},
+			want: []*pb.DeletedDirectoryData{
+				{DirectoryId: "deleted", InputLogs: 1, Mutations: 5, Batches: 2, DeletedTrees: []int64{1, 2}},
+			}},
+		{desc: "nothing left", expect: func(e *miniEnv) {}, want: nil},
+	} {
+		data.err = tc.dataErr
+		tc.expect(e)
+		resp, err := e.srv.GarbageCollect(ctx, &pb.GarbageCollectRequest{Before: ptypes.TimestampNow()})
+		if got := status.Code(err); got != tc.wantCode {
+			t.Fatalf("%v: GarbageCollect(): %v, want %v", tc.desc, err, tc.wantCode)
+		}
+		if err == nil {
+			if got := resp.GetDeleted(); !cmp.Equal(got, tc.want, cmp.Comparer(proto.Equal)) {
+				t.Errorf("%v: GarbageCollect().Deleted: %v, want %v", tc.desc, got, tc.want)
+			}
+		}
+		_, err = e.srv.directories.Read(ctx, "deleted", true)
+		if exists := err == nil; exists != tc.wantExists {
+			t.Errorf("%v: directory exists: %v, want %v", tc.desc, exists, tc.wantExists)
+		}
+		if _, err := e.srv.directories.Read(ctx, "existingdirectory", false); err != nil {
+			t.Errorf("%v: active directory was deleted: %v", tc.desc, err)
+		}
+	}
+}
+
+// TestGarbageCollectRecentlyDeleted verifies that directories deleted after
+// the GarbageCollect cutoff survive when stored in SQL.
+func TestGarbageCollectRecentlyDeleted(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	db, done := ktestdb.NewSQLiteForTest(ctx, t)
+	defer done(ctx)
+	directories, err := sqldirectory.NewStorage(db)
+	if err != nil {
+		t.Fatalf("NewStorage(): %v", err)
+	}
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+	e.srv.directories = directories
+	e.srv.data = &fakeDataDeleter{}
+
+	before := time.Now().Add(-time.Minute)
+	if err := directories.Write(ctx, &directory.Directory{
+		DirectoryID: "recent",
+		Log:         &tpb.Tree{TreeId: 1},
+		Map:         &tpb.Tree{TreeId: 2},
+		VRF:         &keyspb.PublicKey{Der: []byte("pubkey")},
+		VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkey")},
+	}); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if err := directories.SetDelete(ctx, "recent", true); err != nil {
+		t.Fatalf("SetDelete(): %v", err)
+	}
+
+	beforeProto, err := ptypes.TimestampProto(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := e.srv.GarbageCollect(ctx, &pb.GarbageCollectRequest{Before: beforeProto})
+	if err != nil {
+		t.Fatalf("GarbageCollect(): %v", err)
+	}
+	if got := resp.GetDeleted(); len(got) != 0 {
+		t.Errorf("GarbageCollect().Deleted: %v, want none", got)
+	}
+	if _, err := directories.Read(ctx, "recent", true); err != nil {
+		t.Errorf("Read(recent): %v, want the directory to survive", err)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+const (
+	adminService         = "/google.keytransparency.v1.KeyTransparencyAdmin/"
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// readOnlyMethods are admin methods that take no action and are not audited.
+var readOnlyMethods = map[string]bool{
+	adminService + "ListDirectories":      true,
+	adminService + "GetDirectory":         true,
+	adminService + "ListInputLogs":        true,
+	adminService + "ListAdminAuditEvents": true,
+}
+
+// auditTimeout bounds each write to the audit log. Writes are detached from
+// the call, so that the result of a call is recorded even if its caller has
+// gone away.
+const auditTimeout = 10 * time.Second
+
+// AuditLog is an append-only record of administrative actions.
+type AuditLog interface {
+	// Append records event and returns the EventId assigned to it.
+	Append(ctx context.Context, event *pb.AdminAuditEvent) (int64, error)
+	// Complete appends the result of the call recorded by event eventID,
+	// which completed at completed. It returns codes.AlreadyExists if the
+	// result of eventID has already been recorded.
+	Complete(ctx context.Context, eventID int64, completed time.Time, st *status.Status) error
+	// List returns up to pageSize events with EventId > start, ordered by EventId.
+	List(ctx context.Context, start int64, pageSize int32) ([]*pb.AdminAuditEvent, error)
+}
+
+// AuditInterceptor returns a unary server interceptor that audits calls to
+// KeyTransparencyAdmin methods. It must be chained after
+// authorization.UnaryServerInterceptor, so that only authorized calls reach
+// it, and caller returns the identity that the authorization interceptor
+// placed in the context. Calls to other services are passed through.
+//
+// Every call to a method that may modify state is recorded in audit before it
+// runs, and fails with codes.Internal without running if it cannot be. Its
+// result is recorded when it completes; if that fails, its event keeps status
+// UNKNOWN.
+func AuditInterceptor(audit AuditLog, caller func(context.Context) string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, adminService) || readOnlyMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		event := newAuditEvent(time.Now(), caller(ctx), info.FullMethod, req)
+		eventID, err := appendEvent(audit, event)
+		if err != nil {
+			glog.Errorf("audit: failed to record %v by %v: %v", info.FullMethod, event.Caller, err)
+			return nil, status.Errorf(codes.Internal, "audit: failed to record %v", info.FullMethod)
+		}
+		resp, err := handler(ctx, req)
+
+		cctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+		defer cancel()
+		if aerr := audit.Complete(cctx, eventID, time.Now(), status.Convert(err)); aerr != nil {
+			glog.Errorf("audit: failed to record the result of event %v, %v by %v with status %v: %v",
+				eventID, info.FullMethod, event.Caller, status.Code(err), aerr)
+		}
+		return resp, err
+	}
+}
+
+// newAuditEvent returns the event of a call to method that was received at
+// start, before the call completes.
+func newAuditEvent(start time.Time, caller, method string, req interface{}) *pb.AdminAuditEvent {
+	event := &pb.AdminAuditEvent{
+		Caller:     caller,
+		Method:     method,
+		StatusCode: int32(codes.Unknown),
+	}
+	var err error
+	if event.Time, err = ptypes.TimestampProto(start); err != nil {
+		glog.Errorf("audit: TimestampProto(%v): %v", start, err)
+	}
+	if m, ok := req.(proto.Message); ok {
+		if event.Request, err = ptypes.MarshalAny(redact(m)); err != nil {
+			glog.Errorf("audit: MarshalAny(%T): %v", m, err)
+		}
+	}
+	return event
+}
+
+// appendEvent appends event to audit with a context detached from the call.
+func appendEvent(audit AuditLog, event *pb.AdminAuditEvent) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+	return audit.Append(ctx, event)
+}
+
+// redact returns a copy of req without private key material.
+func redact(req proto.Message) proto.Message {
+	switch r := req.(type) {
+	case *pb.CreateDirectoryRequest:
+		c := proto.Clone(r).(*pb.CreateDirectoryRequest)
+		c.VrfPrivateKey = nil
+		c.LogPrivateKey = nil
+		c.MapPrivateKey = nil
+		return c
+	default:
+		return req
+	}
+}
+
+// ListAdminAuditEvents returns recorded administrative actions in order.
+func (s *Server) ListAdminAuditEvents(ctx context.Context, in *pb.ListAdminAuditEventsRequest) (*pb.ListAdminAuditEventsResponse, error) {
+	if s.audit == nil {
+		return nil, status.Error(codes.Unimplemented, "audit log is not configured")
+	}
+	pageSize := in.GetPageSize()
+	switch {
+	case pageSize < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "page_size %v, want >= 0", pageSize)
+	case pageSize == 0:
+		pageSize = defaultAuditPageSize
+	case pageSize > maxAuditPageSize:
+		pageSize = maxAuditPageSize
+	}
+	var start int64
+	if in.GetPageToken() != "" {
+		var err error
+		if start, err = strconv.ParseInt(in.GetPageToken(), 10, 64); err != nil || start < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token %q", in.GetPageToken())
+		}
+	}
+
+	events, err := s.audit.List(ctx, start, pageSize)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "adminserver: audit.List(): %v", err)
+	}
+	resp := &pb.ListAdminAuditEventsResponse{Events: events}
+	if int32(len(events)) == pageSize {
+		resp.NextPageToken = strconv.FormatInt(events[len(events)-1].GetEventId(), 10)
+	}
+	return resp, nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+// fakeAuditLog is an in-memory AuditLog.
+type fakeAuditLog struct {
+	events      []*pb.AdminAuditEvent
+	err         error // Returned by Append if set.
+	completeErr error // Returned by Complete if set.
+}
+
+func (f *fakeAuditLog) Append(ctx context.Context, e *pb.AdminAuditEvent) (int64, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	e.EventId = int64(len(f.events) + 1)
+	f.events = append(f.events, e)
+	return e.EventId, nil
+}
+
+func (f *fakeAuditLog) Complete(ctx context.Context, eventID int64, completed time.Time, st *status.Status) error {
+	if f.completeErr != nil {
+		return f.completeErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e := f.events[eventID-1]
+	e.CompletionTime, _ = ptypes.TimestampProto(completed)
+	e.StatusCode = int32(st.Code())
+	e.StatusMessage = st.Message()
+	return nil
+}
+
+func (f *fakeAuditLog) List(_ context.Context, start int64, pageSize int32) ([]*pb.AdminAuditEvent, error) {
+	ret := []*pb.AdminAuditEvent{}
+	for _, e := range f.events {
+		if e.EventId > start && int32(len(ret)) < pageSize {
+			ret = append(ret, e)
+		}
+	}
+	return ret, nil
+}
+
+// allowAll audits every call as made by alice.
+func allowAll(audit AuditLog) grpc.UnaryServerInterceptor {
+	return AuditInterceptor(audit, func(context.Context) string { return "alice" })
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	key := &any.Any{TypeUrl: "type.googleapis.com/keyspb.PrivateKey", Value: []byte("secret")}
+	for _, tc := range []struct {
+		desc      string
+		method    string
+		req       interface{}
+		err       error
+		wantEvent bool
+		wantCode  codes.Code
+	}{
+		{desc: "delete", method: adminService + "DeleteDirectory", wantEvent: true,
+			req: &pb.DeleteDirectoryRequest{DirectoryId: "dir"}},
+		{desc: "failed delete", method: adminService + "DeleteDirectory", wantEvent: true,
+			req: &pb.DeleteDirectoryRequest{DirectoryId: "dir"},
+			err: status.Error(codes.NotFound, "no dir"), wantCode: codes.NotFound},
+		{desc: "create", method: adminService + "CreateDirectory", wantEvent: true,
+			req: &pb.CreateDirectoryRequest{DirectoryId: "dir", VrfPrivateKey: key, LogPrivateKey: key, MapPrivateKey: key}},
+		{desc: "read only", method: adminService + "ListDirectories",
+			req: &pb.ListDirectoriesRequest{}},
+		{desc: "other service", method: "/google.keytransparency.v1.KeyTransparency/GetUser",
+			req: &pb.GetUserRequest{}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			// The result is recorded after the caller has gone away.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			audit := &fakeAuditLog{}
+			interceptor := allowAll(audit)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				// The intent is recorded before the call runs.
+				if got := len(audit.events); tc.wantEvent && got != 1 {
+					t.Errorf("handler: recorded %v events, want 1", got)
+				} else if tc.wantEvent {
+					if e := audit.events[0]; codes.Code(e.StatusCode) != codes.Unknown || e.CompletionTime != nil {
+						t.Errorf("handler: event %v, want an uncompleted event", e)
+					}
+				}
+				cancel()
+				return nil, tc.err
+			}
+			if _, err := interceptor(ctx, tc.req, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler); err != tc.err {
+				t.Errorf("interceptor(): %v, want %v", err, tc.err)
+			}
+			if !tc.wantEvent {
+				if len(audit.events) != 0 {
+					t.Errorf("recorded %v, want no events", audit.events)
+				}
+				return
+			}
+			if len(audit.events) != 1 {
+				t.Fatalf("recorded %v events, want 1", len(audit.events))
+			}
+			e := audit.events[0]
+			if e.Caller != "alice" || e.Method != tc.method || codes.Code(e.StatusCode) != tc.wantCode ||
+				e.Time == nil || e.CompletionTime == nil {
+				t.Errorf("event: %v", e)
+			}
+			var req ptypes.DynamicAny
+			if err := ptypes.UnmarshalAny(e.Request, &req); err != nil {
+				t.Fatalf("UnmarshalAny(): %v", err)
+			}
+			if c, ok := req.Message.(*pb.CreateDirectoryRequest); ok {
+				if c.VrfPrivateKey != nil || c.LogPrivateKey != nil || c.MapPrivateKey != nil {
+					t.Errorf("recorded private keys: %v", c)
+				}
+				if c.DirectoryId != "dir" {
+					t.Errorf("DirectoryId: %v, want dir", c.DirectoryId)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditInterceptorAppendFails(t *testing.T) {
+	ctx := context.Background()
+	audit := &fakeAuditLog{err: errors.New("disk full")}
+	var ran bool
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		ran = true
+		return &empty.Empty{}, nil
+	}
+	resp, err := allowAll(audit)(ctx, &pb.DeleteDirectoryRequest{DirectoryId: "dir"},
+		&grpc.UnaryServerInfo{FullMethod: adminService + "DeleteDirectory"}, handler)
+	if got, want := status.Code(err), codes.Internal; got != want || resp != nil {
+		t.Errorf("interceptor(): %v, %v, want code %v", resp, err, want)
+	}
+	if ran {
+		t.Errorf("handler ran without an audit event")
+	}
+}
+
+func TestAuditInterceptorCompleteFails(t *testing.T) {
+	ctx := context.Background()
+	audit := &fakeAuditLog{completeErr: errors.New("disk full")}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return &empty.Empty{}, nil }
+	resp, err := allowAll(audit)(ctx, &pb.DeleteDirectoryRequest{DirectoryId: "dir"},
+		&grpc.UnaryServerInfo{FullMethod: adminService + "DeleteDirectory"}, handler)
+	if err != nil || resp == nil {
+		t.Errorf("interceptor(): %v, %v, want the result of the call", resp, err)
+	}
+	if len(audit.events) != 1 || codes.Code(audit.events[0].StatusCode) != codes.Unknown {
+		t.Errorf("recorded %v, want one event with status %v", audit.events, codes.Unknown)
+	}
+}
+
+func TestListAdminAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	audit := &fakeAuditLog{}
+	for i := 0; i < 5; i++ {
+		if _, err := audit.Append(ctx, &pb.AdminAuditEvent{Caller: "alice"}); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+	}
+	srv := &Server{audit: audit}
+
+	var got []int64
+	token := ""
+	for i := 0; ; i++ {
+		resp, err := srv.ListAdminAuditEvents(ctx, &pb.ListAdminAuditEventsRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ListAdminAuditEvents(): %v", err)
+		}
+		for _, e := range resp.Events {
+			got = append(got, e.EventId)
+		}
+		if token = resp.NextPageToken; token == "" {
+			break
+		}
+		if i > 5 {
+			t.Fatalf("pagination did not terminate")
+		}
+	}
+	if len(got) != 5 {
+		t.Errorf("ListAdminAuditEvents(): events %v, want 1 through 5", got)
+	}
+	for i, id := range got {
+		if id != int64(i+1) {
+			t.Errorf("ListAdminAuditEvents(): events %v, want 1 through 5", got)
+			break
+		}
+	}
+
+	for _, tc := range []struct {
+		srv  *Server
+		req  *pb.ListAdminAuditEventsRequest
+		want codes.Code
+	}{
+		{srv: srv, req: &pb.ListAdminAuditEventsRequest{PageToken: "x"}, want: codes.InvalidArgument},
+		{srv: srv, req: &pb.ListAdminAuditEventsRequest{PageSize: -1}, want: codes.InvalidArgument},
+		{srv: &Server{}, req: &pb.ListAdminAuditEventsRequest{}, want: codes.Unimplemented},
+	} {
+		if _, err := tc.srv.ListAdminAuditEvents(ctx, tc.req); status.Code(err) != tc.want {
+			t.Errorf("ListAdminAuditEvents(%v): %v, want code %v", tc.req, err, tc.want)
+		}
+	}
+}
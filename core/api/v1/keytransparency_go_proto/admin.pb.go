@@ -37,6 +37,39 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// State is a stage in the lifecycle of an input log.
+type InputLog_State int32
+
+const (
+	// ACTIVE logs are included in new revisions and may be writable.
+	InputLog_ACTIVE InputLog_State = 0
+	// SEALED logs are read-only and all of their mutations have been applied.
+	InputLog_SEALED InputLog_State = 1
+	// RETIRED logs are sealed logs that are no longer included in new
+	// revisions. Their mutations remain readable through older revisions.
+	InputLog_RETIRED InputLog_State = 2
+)
+
+var InputLog_State_name = map[int32]string{
+	0: "ACTIVE",
+	1: "SEALED",
+	2: "RETIRED",
+}
+
+var InputLog_State_value = map[string]int32{
+	"ACTIVE":  0,
+	"SEALED":  1,
+	"RETIRED": 2,
+}
+
+func (x InputLog_State) String() string {
+	return proto.EnumName(InputLog_State_name, int32(x))
+}
+
+func (InputLog_State) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{14, 0}
+}
+
 // Directory contains information on a single directory
 type Directory struct {
 	// DirectoryId can be any URL safe string.
@@ -54,7 +87,16 @@ type Directory struct {
 	// Deleted indicates whether the directory has been marked as deleted.
 	// By its presence in a response, this directory has not been garbage
 	// collected.
-	Deleted              bool     `protobuf:"varint,7,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Deleted bool `protobuf:"varint,7,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// rate_limits limits how quickly updates may be queued.
+	RateLimits *RateLimits `protobuf:"bytes,8,opt,name=rate_limits,json=rateLimits,proto3" json:"rate_limits,omitempty"`
+	// retention limits how long applied mutations are kept.
+	Retention *RetentionPolicy `protobuf:"bytes,9,opt,name=retention,proto3" json:"retention,omitempty"`
+	// allow_recovery is the recovery policy for deleted entries. If false,
+	// tombstones are final. If true, a mutation to a deleted entry is accepted
+	// when it is signed by the authorized keyset of the tombstone. It is set
+	// when the directory is created and never changes.
+	AllowRecovery        bool     `protobuf:"varint,10,opt,name=allow_recovery,json=allowRecovery,proto3" json:"allow_recovery,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -134,6 +176,193 @@ func (m *Directory) GetDeleted() bool {
 	return false
 }
 
+func (m *Directory) GetRateLimits() *RateLimits {
+	if m != nil {
+		return m.RateLimits
+	}
+	return nil
+}
+
+func (m *Directory) GetRetention() *RetentionPolicy {
+	if m != nil {
+		return m.Retention
+	}
+	return nil
+}
+
+func (m *Directory) GetAllowRecovery() bool {
+	if m != nil {
+		return m.AllowRecovery
+	}
+	return false
+}
+
+// Quota is a token bucket rate limit, kept by each keyserver instance.
+type Quota struct {
+	// updates_per_second is the rate at which the bucket refills.
+	UpdatesPerSecond float64 `protobuf:"fixed64,1,opt,name=updates_per_second,json=updatesPerSecond,proto3" json:"updates_per_second,omitempty"`
+	// burst is the size of the bucket. If unset, the bucket holds one second of
+	// updates, and at least one update.
+	Burst                int64    `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Quota) Reset()         { *m = Quota{} }
+func (m *Quota) String() string { return proto.CompactTextString(m) }
+func (*Quota) ProtoMessage()    {}
+func (*Quota) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{1}
+}
+
+func (m *Quota) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Quota.Unmarshal(m, b)
+}
+func (m *Quota) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Quota.Marshal(b, m, deterministic)
+}
+func (m *Quota) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Quota.Merge(m, src)
+}
+func (m *Quota) XXX_Size() int {
+	return xxx_messageInfo_Quota.Size(m)
+}
+func (m *Quota) XXX_DiscardUnknown() {
+	xxx_messageInfo_Quota.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Quota proto.InternalMessageInfo
+
+func (m *Quota) GetUpdatesPerSecond() float64 {
+	if m != nil {
+		return m.UpdatesPerSecond
+	}
+	return 0
+}
+
+func (m *Quota) GetBurst() int64 {
+	if m != nil {
+		return m.Burst
+	}
+	return 0
+}
+
+// RateLimits limits the rate at which updates are queued for a directory.
+// Unset quotas, and quotas with a zero updates_per_second, are not enforced.
+// Each keyserver instance enforces the quotas on its own, so the effective
+// limit of a directory served by N instances is up to N times each quota.
+type RateLimits struct {
+	// per_principal limits the updates sent by each authenticated caller.
+	PerPrincipal *Quota `protobuf:"bytes,1,opt,name=per_principal,json=perPrincipal,proto3" json:"per_principal,omitempty"`
+	// per_user limits the updates to each user.
+	PerUser *Quota `protobuf:"bytes,2,opt,name=per_user,json=perUser,proto3" json:"per_user,omitempty"`
+	// per_directory limits all updates to the directory.
+	PerDirectory         *Quota   `protobuf:"bytes,3,opt,name=per_directory,json=perDirectory,proto3" json:"per_directory,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RateLimits) Reset()         { *m = RateLimits{} }
+func (m *RateLimits) String() string { return proto.CompactTextString(m) }
+func (*RateLimits) ProtoMessage()    {}
+func (*RateLimits) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{2}
+}
+
+func (m *RateLimits) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RateLimits.Unmarshal(m, b)
+}
+func (m *RateLimits) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RateLimits.Marshal(b, m, deterministic)
+}
+func (m *RateLimits) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RateLimits.Merge(m, src)
+}
+func (m *RateLimits) XXX_Size() int {
+	return xxx_messageInfo_RateLimits.Size(m)
+}
+func (m *RateLimits) XXX_DiscardUnknown() {
+	xxx_messageInfo_RateLimits.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RateLimits proto.InternalMessageInfo
+
+func (m *RateLimits) GetPerPrincipal() *Quota {
+	if m != nil {
+		return m.PerPrincipal
+	}
+	return nil
+}
+
+func (m *RateLimits) GetPerUser() *Quota {
+	if m != nil {
+		return m.PerUser
+	}
+	return nil
+}
+
+func (m *RateLimits) GetPerDirectory() *Quota {
+	if m != nil {
+		return m.PerDirectory
+	}
+	return nil
+}
+
+// RetentionPolicy limits how long the mutations of a revision are kept after
+// the revision has been applied and published. Mutations are pruned once both
+// limits have passed. Mutations are kept forever if neither limit is set.
+type RetentionPolicy struct {
+	// revisions is the number of the most recently published revisions whose
+	// mutations are kept.
+	Revisions int64 `protobuf:"varint,1,opt,name=revisions,proto3" json:"revisions,omitempty"`
+	// max_age is how long mutations are kept after their revision was applied.
+	MaxAge               *duration.Duration `protobuf:"bytes,2,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *RetentionPolicy) Reset()         { *m = RetentionPolicy{} }
+func (m *RetentionPolicy) String() string { return proto.CompactTextString(m) }
+func (*RetentionPolicy) ProtoMessage()    {}
+func (*RetentionPolicy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{3}
+}
+
+func (m *RetentionPolicy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetentionPolicy.Unmarshal(m, b)
+}
+func (m *RetentionPolicy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetentionPolicy.Marshal(b, m, deterministic)
+}
+func (m *RetentionPolicy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetentionPolicy.Merge(m, src)
+}
+func (m *RetentionPolicy) XXX_Size() int {
+	return xxx_messageInfo_RetentionPolicy.Size(m)
+}
+func (m *RetentionPolicy) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetentionPolicy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetentionPolicy proto.InternalMessageInfo
+
+func (m *RetentionPolicy) GetRevisions() int64 {
+	if m != nil {
+		return m.Revisions
+	}
+	return 0
+}
+
+func (m *RetentionPolicy) GetMaxAge() *duration.Duration {
+	if m != nil {
+		return m.MaxAge
+	}
+	return nil
+}
+
 // ListDirectories request.
 // No pagination options are provided.
 type ListDirectoriesRequest struct {
@@ -149,7 +378,7 @@ func (m *ListDirectoriesRequest) Reset()         { *m = ListDirectoriesRequest{}
 func (m *ListDirectoriesRequest) String() string { return proto.CompactTextString(m) }
 func (*ListDirectoriesRequest) ProtoMessage()    {}
 func (*ListDirectoriesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{1}
+	return fileDescriptor_599f1e5eaea78ae3, []int{4}
 }
 
 func (m *ListDirectoriesRequest) XXX_Unmarshal(b []byte) error {
@@ -189,7 +418,7 @@ func (m *ListDirectoriesResponse) Reset()         { *m = ListDirectoriesResponse
 func (m *ListDirectoriesResponse) String() string { return proto.CompactTextString(m) }
 func (*ListDirectoriesResponse) ProtoMessage()    {}
 func (*ListDirectoriesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{2}
+	return fileDescriptor_599f1e5eaea78ae3, []int{5}
 }
 
 func (m *ListDirectoriesResponse) XXX_Unmarshal(b []byte) error {
@@ -232,7 +461,7 @@ func (m *GetDirectoryRequest) Reset()         { *m = GetDirectoryRequest{} }
 func (m *GetDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*GetDirectoryRequest) ProtoMessage()    {}
 func (*GetDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{3}
+	return fileDescriptor_599f1e5eaea78ae3, []int{6}
 }
 
 func (m *GetDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -273,9 +502,15 @@ type CreateDirectoryRequest struct {
 	MinInterval *duration.Duration `protobuf:"bytes,2,opt,name=min_interval,json=minInterval,proto3" json:"min_interval,omitempty"`
 	MaxInterval *duration.Duration `protobuf:"bytes,3,opt,name=max_interval,json=maxInterval,proto3" json:"max_interval,omitempty"`
 	// The private_key fields allows callers to set the private key.
-	VrfPrivateKey        *any.Any `protobuf:"bytes,4,opt,name=vrf_private_key,json=vrfPrivateKey,proto3" json:"vrf_private_key,omitempty"`
-	LogPrivateKey        *any.Any `protobuf:"bytes,5,opt,name=log_private_key,json=logPrivateKey,proto3" json:"log_private_key,omitempty"`
-	MapPrivateKey        *any.Any `protobuf:"bytes,6,opt,name=map_private_key,json=mapPrivateKey,proto3" json:"map_private_key,omitempty"`
+	VrfPrivateKey *any.Any `protobuf:"bytes,4,opt,name=vrf_private_key,json=vrfPrivateKey,proto3" json:"vrf_private_key,omitempty"`
+	LogPrivateKey *any.Any `protobuf:"bytes,5,opt,name=log_private_key,json=logPrivateKey,proto3" json:"log_private_key,omitempty"`
+	MapPrivateKey *any.Any `protobuf:"bytes,6,opt,name=map_private_key,json=mapPrivateKey,proto3" json:"map_private_key,omitempty"`
+	// rate_limits limits how quickly updates may be queued.
+	RateLimits *RateLimits `protobuf:"bytes,7,opt,name=rate_limits,json=rateLimits,proto3" json:"rate_limits,omitempty"`
+	// retention limits how long applied mutations are kept.
+	Retention *RetentionPolicy `protobuf:"bytes,8,opt,name=retention,proto3" json:"retention,omitempty"`
+	// allow_recovery sets the recovery policy for deleted entries.
+	AllowRecovery        bool     `protobuf:"varint,9,opt,name=allow_recovery,json=allowRecovery,proto3" json:"allow_recovery,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -285,7 +520,7 @@ func (m *CreateDirectoryRequest) Reset()         { *m = CreateDirectoryRequest{}
 func (m *CreateDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateDirectoryRequest) ProtoMessage()    {}
 func (*CreateDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{4}
+	return fileDescriptor_599f1e5eaea78ae3, []int{7}
 }
 
 func (m *CreateDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -348,6 +583,27 @@ func (m *CreateDirectoryRequest) GetMapPrivateKey() *any.Any {
 	return nil
 }
 
+func (m *CreateDirectoryRequest) GetRateLimits() *RateLimits {
+	if m != nil {
+		return m.RateLimits
+	}
+	return nil
+}
+
+func (m *CreateDirectoryRequest) GetRetention() *RetentionPolicy {
+	if m != nil {
+		return m.Retention
+	}
+	return nil
+}
+
+func (m *CreateDirectoryRequest) GetAllowRecovery() bool {
+	if m != nil {
+		return m.AllowRecovery
+	}
+	return false
+}
+
 // DeleteDirectoryRequest deletes a directory
 type DeleteDirectoryRequest struct {
 	DirectoryId          string   `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
@@ -360,7 +616,7 @@ func (m *DeleteDirectoryRequest) Reset()         { *m = DeleteDirectoryRequest{}
 func (m *DeleteDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteDirectoryRequest) ProtoMessage()    {}
 func (*DeleteDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{5}
+	return fileDescriptor_599f1e5eaea78ae3, []int{8}
 }
 
 func (m *DeleteDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -400,7 +656,7 @@ func (m *UndeleteDirectoryRequest) Reset()         { *m = UndeleteDirectoryReque
 func (m *UndeleteDirectoryRequest) String() string { return proto.CompactTextString(m) }
 func (*UndeleteDirectoryRequest) ProtoMessage()    {}
 func (*UndeleteDirectoryRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{6}
+	return fileDescriptor_599f1e5eaea78ae3, []int{9}
 }
 
 func (m *UndeleteDirectoryRequest) XXX_Unmarshal(b []byte) error {
@@ -428,6 +684,104 @@ func (m *UndeleteDirectoryRequest) GetDirectoryId() string {
 	return ""
 }
 
+// SetRateLimitsRequest replaces the rate limits of a directory.
+type SetRateLimitsRequest struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// rate_limits replaces the rate limits of the directory.
+	RateLimits           *RateLimits `protobuf:"bytes,2,opt,name=rate_limits,json=rateLimits,proto3" json:"rate_limits,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *SetRateLimitsRequest) Reset()         { *m = SetRateLimitsRequest{} }
+func (m *SetRateLimitsRequest) String() string { return proto.CompactTextString(m) }
+func (*SetRateLimitsRequest) ProtoMessage()    {}
+func (*SetRateLimitsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{10}
+}
+
+func (m *SetRateLimitsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetRateLimitsRequest.Unmarshal(m, b)
+}
+func (m *SetRateLimitsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetRateLimitsRequest.Marshal(b, m, deterministic)
+}
+func (m *SetRateLimitsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetRateLimitsRequest.Merge(m, src)
+}
+func (m *SetRateLimitsRequest) XXX_Size() int {
+	return xxx_messageInfo_SetRateLimitsRequest.Size(m)
+}
+func (m *SetRateLimitsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetRateLimitsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetRateLimitsRequest proto.InternalMessageInfo
+
+func (m *SetRateLimitsRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *SetRateLimitsRequest) GetRateLimits() *RateLimits {
+	if m != nil {
+		return m.RateLimits
+	}
+	return nil
+}
+
+// SetRetentionPolicyRequest replaces the retention policy of a directory.
+type SetRetentionPolicyRequest struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// retention replaces the retention policy of the directory.
+	Retention            *RetentionPolicy `protobuf:"bytes,2,opt,name=retention,proto3" json:"retention,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *SetRetentionPolicyRequest) Reset()         { *m = SetRetentionPolicyRequest{} }
+func (m *SetRetentionPolicyRequest) String() string { return proto.CompactTextString(m) }
+func (*SetRetentionPolicyRequest) ProtoMessage()    {}
+func (*SetRetentionPolicyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{11}
+}
+
+func (m *SetRetentionPolicyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetRetentionPolicyRequest.Unmarshal(m, b)
+}
+func (m *SetRetentionPolicyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetRetentionPolicyRequest.Marshal(b, m, deterministic)
+}
+func (m *SetRetentionPolicyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetRetentionPolicyRequest.Merge(m, src)
+}
+func (m *SetRetentionPolicyRequest) XXX_Size() int {
+	return xxx_messageInfo_SetRetentionPolicyRequest.Size(m)
+}
+func (m *SetRetentionPolicyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetRetentionPolicyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetRetentionPolicyRequest proto.InternalMessageInfo
+
+func (m *SetRetentionPolicyRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *SetRetentionPolicyRequest) GetRetention() *RetentionPolicy {
+	if m != nil {
+		return m.Retention
+	}
+	return nil
+}
+
 type ListInputLogsRequest struct {
 	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
 	// filter_writable will only return writable logs when set.
@@ -441,7 +795,7 @@ func (m *ListInputLogsRequest) Reset()         { *m = ListInputLogsRequest{} }
 func (m *ListInputLogsRequest) String() string { return proto.CompactTextString(m) }
 func (*ListInputLogsRequest) ProtoMessage()    {}
 func (*ListInputLogsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{7}
+	return fileDescriptor_599f1e5eaea78ae3, []int{12}
 }
 
 func (m *ListInputLogsRequest) XXX_Unmarshal(b []byte) error {
@@ -487,7 +841,7 @@ func (m *ListInputLogsResponse) Reset()         { *m = ListInputLogsResponse{} }
 func (m *ListInputLogsResponse) String() string { return proto.CompactTextString(m) }
 func (*ListInputLogsResponse) ProtoMessage()    {}
 func (*ListInputLogsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{8}
+	return fileDescriptor_599f1e5eaea78ae3, []int{13}
 }
 
 func (m *ListInputLogsResponse) XXX_Unmarshal(b []byte) error {
@@ -516,12 +870,20 @@ func (m *ListInputLogsResponse) GetLogs() []*InputLog {
 }
 
 // InputLog is an input log for a directory.
+//
+// Input logs are removed in stages: UpdateInputLog makes the log read-only,
+// the sequencer seals the log once every mutation in it has been applied to a
+// revision, and RetireInputLog then drops the log from future revisions.
 type InputLog struct {
 	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
 	LogId       int64  `protobuf:"varint,2,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
 	// writable controls whether new log items will be sent to this log.
-	// writable is not set by ListInputLogs.
-	Writable             bool     `protobuf:"varint,3,opt,name=writable,proto3" json:"writable,omitempty"`
+	Writable bool `protobuf:"varint,3,opt,name=writable,proto3" json:"writable,omitempty"`
+	// state is set by the server and ignored in requests.
+	State InputLog_State `protobuf:"varint,4,opt,name=state,proto3,enum=google.keytransparency.v1.InputLog_State" json:"state,omitempty"`
+	// final_watermark is the watermark just beyond the last mutation of a
+	// sealed or retired log.
+	FinalWatermark       int64    `protobuf:"varint,5,opt,name=final_watermark,json=finalWatermark,proto3" json:"final_watermark,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -531,7 +893,7 @@ func (m *InputLog) Reset()         { *m = InputLog{} }
 func (m *InputLog) String() string { return proto.CompactTextString(m) }
 func (*InputLog) ProtoMessage()    {}
 func (*InputLog) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{9}
+	return fileDescriptor_599f1e5eaea78ae3, []int{14}
 }
 
 func (m *InputLog) XXX_Unmarshal(b []byte) error {
@@ -573,6 +935,68 @@ func (m *InputLog) GetWritable() bool {
 	return false
 }
 
+func (m *InputLog) GetState() InputLog_State {
+	if m != nil {
+		return m.State
+	}
+	return InputLog_ACTIVE
+}
+
+func (m *InputLog) GetFinalWatermark() int64 {
+	if m != nil {
+		return m.FinalWatermark
+	}
+	return 0
+}
+
+// RetireInputLogRequest retires a sealed input log.
+type RetireInputLogRequest struct {
+	DirectoryId          string   `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	LogId                int64    `protobuf:"varint,2,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RetireInputLogRequest) Reset()         { *m = RetireInputLogRequest{} }
+func (m *RetireInputLogRequest) String() string { return proto.CompactTextString(m) }
+func (*RetireInputLogRequest) ProtoMessage()    {}
+func (*RetireInputLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{15}
+}
+
+func (m *RetireInputLogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RetireInputLogRequest.Unmarshal(m, b)
+}
+func (m *RetireInputLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RetireInputLogRequest.Marshal(b, m, deterministic)
+}
+func (m *RetireInputLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RetireInputLogRequest.Merge(m, src)
+}
+func (m *RetireInputLogRequest) XXX_Size() int {
+	return xxx_messageInfo_RetireInputLogRequest.Size(m)
+}
+func (m *RetireInputLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RetireInputLogRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RetireInputLogRequest proto.InternalMessageInfo
+
+func (m *RetireInputLogRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *RetireInputLogRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
 // GarbageCollect request.
 type GarbageCollectRequest struct {
 	// Soft-deleted directories with a deleted timestamp before this will be fully
@@ -587,7 +1011,7 @@ func (m *GarbageCollectRequest) Reset()         { *m = GarbageCollectRequest{} }
 func (m *GarbageCollectRequest) String() string { return proto.CompactTextString(m) }
 func (*GarbageCollectRequest) ProtoMessage()    {}
 func (*GarbageCollectRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{10}
+	return fileDescriptor_599f1e5eaea78ae3, []int{16}
 }
 
 func (m *GarbageCollectRequest) XXX_Unmarshal(b []byte) error {
@@ -616,17 +1040,20 @@ func (m *GarbageCollectRequest) GetBefore() *timestamp.Timestamp {
 }
 
 type GarbageCollectResponse struct {
-	Directories          []*Directory `protobuf:"bytes,1,rep,name=directories,proto3" json:"directories,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
-	XXX_unrecognized     []byte       `json:"-"`
-	XXX_sizecache        int32        `json:"-"`
+	Directories []*Directory `protobuf:"bytes,1,rep,name=directories,proto3" json:"directories,omitempty"`
+	// deleted reports the data that was removed for each fully deleted
+	// directory, in the same order as directories.
+	Deleted              []*DeletedDirectoryData `protobuf:"bytes,2,rep,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
 }
 
 func (m *GarbageCollectResponse) Reset()         { *m = GarbageCollectResponse{} }
 func (m *GarbageCollectResponse) String() string { return proto.CompactTextString(m) }
 func (*GarbageCollectResponse) ProtoMessage()    {}
 func (*GarbageCollectResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_599f1e5eaea78ae3, []int{11}
+	return fileDescriptor_599f1e5eaea78ae3, []int{17}
 }
 
 func (m *GarbageCollectResponse) XXX_Unmarshal(b []byte) error {
@@ -654,84 +1081,437 @@ func (m *GarbageCollectResponse) GetDirectories() []*Directory {
 	return nil
 }
 
+func (m *GarbageCollectResponse) GetDeleted() []*DeletedDirectoryData {
+	if m != nil {
+		return m.Deleted
+	}
+	return nil
+}
+
+// DeletedDirectoryData reports the data removed when a directory was fully
+// deleted. Rows that had already been removed by an earlier, partially
+// successful, GarbageCollect call are not counted again.
+type DeletedDirectoryData struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// input_logs is the number of input logs deleted.
+	InputLogs int64 `protobuf:"varint,2,opt,name=input_logs,json=inputLogs,proto3" json:"input_logs,omitempty"`
+	// mutations is the number of queued mutations deleted.
+	Mutations int64 `protobuf:"varint,3,opt,name=mutations,proto3" json:"mutations,omitempty"`
+	// batches is the number of revision definitions deleted.
+	Batches int64 `protobuf:"varint,4,opt,name=batches,proto3" json:"batches,omitempty"`
+	// deleted_trees are the IDs of the directory's Trillian trees, which have
+	// been deleted.
+	DeletedTrees         []int64  `protobuf:"varint,5,rep,packed,name=deleted_trees,json=deletedTrees,proto3" json:"deleted_trees,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletedDirectoryData) Reset()         { *m = DeletedDirectoryData{} }
+func (m *DeletedDirectoryData) String() string { return proto.CompactTextString(m) }
+func (*DeletedDirectoryData) ProtoMessage()    {}
+func (*DeletedDirectoryData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{18}
+}
+
+func (m *DeletedDirectoryData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletedDirectoryData.Unmarshal(m, b)
+}
+func (m *DeletedDirectoryData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletedDirectoryData.Marshal(b, m, deterministic)
+}
+func (m *DeletedDirectoryData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletedDirectoryData.Merge(m, src)
+}
+func (m *DeletedDirectoryData) XXX_Size() int {
+	return xxx_messageInfo_DeletedDirectoryData.Size(m)
+}
+func (m *DeletedDirectoryData) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletedDirectoryData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletedDirectoryData proto.InternalMessageInfo
+
+func (m *DeletedDirectoryData) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *DeletedDirectoryData) GetInputLogs() int64 {
+	if m != nil {
+		return m.InputLogs
+	}
+	return 0
+}
+
+func (m *DeletedDirectoryData) GetMutations() int64 {
+	if m != nil {
+		return m.Mutations
+	}
+	return 0
+}
+
+func (m *DeletedDirectoryData) GetBatches() int64 {
+	if m != nil {
+		return m.Batches
+	}
+	return 0
+}
+
+func (m *DeletedDirectoryData) GetDeletedTrees() []int64 {
+	if m != nil {
+		return m.DeletedTrees
+	}
+	return nil
+}
+
+// AdminAuditEvent records a call to a KeyTransparencyAdmin method.
+// Allowed calls are recorded before they run, and their result is recorded
+// when they complete.
+type AdminAuditEvent struct {
+	// event_id increases with every event recorded.
+	EventId int64 `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	// time is when the call was received.
+	Time *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// caller is the authenticated identity that made the call.
+	Caller string `protobuf:"bytes,3,opt,name=caller,proto3" json:"caller,omitempty"`
+	// method is the full gRPC method name that was called.
+	Method string `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`
+	// request is the request message, with private key material removed.
+	Request *any.Any `protobuf:"bytes,5,opt,name=request,proto3" json:"request,omitempty"`
+	// status_code is the canonical gRPC status code of the result.
+	StatusCode int32 `protobuf:"varint,6,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	// status_message is the error message of the result, if any.
+	StatusMessage string `protobuf:"bytes,7,opt,name=status_message,json=statusMessage,proto3" json:"status_message,omitempty"`
+	// completion_time is when the call completed. It is unset, and status_code
+	// is UNKNOWN, while the call runs, or if the server stopped before
+	// recording its result.
+	CompletionTime       *timestamp.Timestamp `protobuf:"bytes,8,opt,name=completion_time,json=completionTime,proto3" json:"completion_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AdminAuditEvent) Reset()         { *m = AdminAuditEvent{} }
+func (m *AdminAuditEvent) String() string { return proto.CompactTextString(m) }
+func (*AdminAuditEvent) ProtoMessage()    {}
+func (*AdminAuditEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{19}
+}
+
+func (m *AdminAuditEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AdminAuditEvent.Unmarshal(m, b)
+}
+func (m *AdminAuditEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AdminAuditEvent.Marshal(b, m, deterministic)
+}
+func (m *AdminAuditEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AdminAuditEvent.Merge(m, src)
+}
+func (m *AdminAuditEvent) XXX_Size() int {
+	return xxx_messageInfo_AdminAuditEvent.Size(m)
+}
+func (m *AdminAuditEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_AdminAuditEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AdminAuditEvent proto.InternalMessageInfo
+
+func (m *AdminAuditEvent) GetEventId() int64 {
+	if m != nil {
+		return m.EventId
+	}
+	return 0
+}
+
+func (m *AdminAuditEvent) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *AdminAuditEvent) GetCaller() string {
+	if m != nil {
+		return m.Caller
+	}
+	return ""
+}
+
+func (m *AdminAuditEvent) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *AdminAuditEvent) GetRequest() *any.Any {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *AdminAuditEvent) GetStatusCode() int32 {
+	if m != nil {
+		return m.StatusCode
+	}
+	return 0
+}
+
+func (m *AdminAuditEvent) GetStatusMessage() string {
+	if m != nil {
+		return m.StatusMessage
+	}
+	return ""
+}
+
+func (m *AdminAuditEvent) GetCompletionTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.CompletionTime
+	}
+	return nil
+}
+
+type ListAdminAuditEventsRequest struct {
+	// page_size is the maximum number of events to return.
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of a previous response.
+	PageToken            string   `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAdminAuditEventsRequest) Reset()         { *m = ListAdminAuditEventsRequest{} }
+func (m *ListAdminAuditEventsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListAdminAuditEventsRequest) ProtoMessage()    {}
+func (*ListAdminAuditEventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{20}
+}
+
+func (m *ListAdminAuditEventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAdminAuditEventsRequest.Unmarshal(m, b)
+}
+func (m *ListAdminAuditEventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAdminAuditEventsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListAdminAuditEventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAdminAuditEventsRequest.Merge(m, src)
+}
+func (m *ListAdminAuditEventsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListAdminAuditEventsRequest.Size(m)
+}
+func (m *ListAdminAuditEventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAdminAuditEventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAdminAuditEventsRequest proto.InternalMessageInfo
+
+func (m *ListAdminAuditEventsRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListAdminAuditEventsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type ListAdminAuditEventsResponse struct {
+	// events are in the order they were recorded.
+	Events []*AdminAuditEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// next_page_token is set when there may be more events to read.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAdminAuditEventsResponse) Reset()         { *m = ListAdminAuditEventsResponse{} }
+func (m *ListAdminAuditEventsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListAdminAuditEventsResponse) ProtoMessage()    {}
+func (*ListAdminAuditEventsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_599f1e5eaea78ae3, []int{21}
+}
+
+func (m *ListAdminAuditEventsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAdminAuditEventsResponse.Unmarshal(m, b)
+}
+func (m *ListAdminAuditEventsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAdminAuditEventsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListAdminAuditEventsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAdminAuditEventsResponse.Merge(m, src)
+}
+func (m *ListAdminAuditEventsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListAdminAuditEventsResponse.Size(m)
+}
+func (m *ListAdminAuditEventsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAdminAuditEventsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAdminAuditEventsResponse proto.InternalMessageInfo
+
+func (m *ListAdminAuditEventsResponse) GetEvents() []*AdminAuditEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *ListAdminAuditEventsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("google.keytransparency.v1.InputLog_State", InputLog_State_name, InputLog_State_value)
 	proto.RegisterType((*Directory)(nil), "google.keytransparency.v1.Directory")
+	proto.RegisterType((*Quota)(nil), "google.keytransparency.v1.Quota")
+	proto.RegisterType((*RateLimits)(nil), "google.keytransparency.v1.RateLimits")
+	proto.RegisterType((*RetentionPolicy)(nil), "google.keytransparency.v1.RetentionPolicy")
 	proto.RegisterType((*ListDirectoriesRequest)(nil), "google.keytransparency.v1.ListDirectoriesRequest")
 	proto.RegisterType((*ListDirectoriesResponse)(nil), "google.keytransparency.v1.ListDirectoriesResponse")
 	proto.RegisterType((*GetDirectoryRequest)(nil), "google.keytransparency.v1.GetDirectoryRequest")
 	proto.RegisterType((*CreateDirectoryRequest)(nil), "google.keytransparency.v1.CreateDirectoryRequest")
 	proto.RegisterType((*DeleteDirectoryRequest)(nil), "google.keytransparency.v1.DeleteDirectoryRequest")
 	proto.RegisterType((*UndeleteDirectoryRequest)(nil), "google.keytransparency.v1.UndeleteDirectoryRequest")
+	proto.RegisterType((*SetRateLimitsRequest)(nil), "google.keytransparency.v1.SetRateLimitsRequest")
+	proto.RegisterType((*SetRetentionPolicyRequest)(nil), "google.keytransparency.v1.SetRetentionPolicyRequest")
 	proto.RegisterType((*ListInputLogsRequest)(nil), "google.keytransparency.v1.ListInputLogsRequest")
 	proto.RegisterType((*ListInputLogsResponse)(nil), "google.keytransparency.v1.ListInputLogsResponse")
 	proto.RegisterType((*InputLog)(nil), "google.keytransparency.v1.InputLog")
+	proto.RegisterType((*RetireInputLogRequest)(nil), "google.keytransparency.v1.RetireInputLogRequest")
 	proto.RegisterType((*GarbageCollectRequest)(nil), "google.keytransparency.v1.GarbageCollectRequest")
 	proto.RegisterType((*GarbageCollectResponse)(nil), "google.keytransparency.v1.GarbageCollectResponse")
+	proto.RegisterType((*DeletedDirectoryData)(nil), "google.keytransparency.v1.DeletedDirectoryData")
+	proto.RegisterType((*AdminAuditEvent)(nil), "google.keytransparency.v1.AdminAuditEvent")
+	proto.RegisterType((*ListAdminAuditEventsRequest)(nil), "google.keytransparency.v1.ListAdminAuditEventsRequest")
+	proto.RegisterType((*ListAdminAuditEventsResponse)(nil), "google.keytransparency.v1.ListAdminAuditEventsResponse")
 }
 
 func init() { proto.RegisterFile("v1/admin.proto", fileDescriptor_599f1e5eaea78ae3) }
 
 var fileDescriptor_599f1e5eaea78ae3 = []byte{
-	// 936 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x96, 0xc1, 0x6e, 0xdb, 0x46,
-	0x10, 0x86, 0x41, 0x29, 0x56, 0x9c, 0x91, 0x23, 0xc1, 0x5b, 0xdb, 0x61, 0xd8, 0x22, 0x55, 0x99,
-	0xa2, 0x51, 0x7d, 0x20, 0x23, 0xf9, 0x50, 0x34, 0x4d, 0x0f, 0x69, 0xdc, 0xa4, 0x86, 0x73, 0x30,
-	0x08, 0x07, 0x05, 0xda, 0x83, 0xb2, 0x12, 0x57, 0xcc, 0xc2, 0x24, 0x97, 0x5d, 0xae, 0x98, 0x10,
-	0x41, 0x2e, 0x45, 0xd1, 0x53, 0x2f, 0x46, 0xd1, 0x37, 0xe8, 0xad, 0xe8, 0xd3, 0xf4, 0x15, 0xfa,
-	0x02, 0x7d, 0x83, 0x82, 0xcb, 0xa5, 0x2c, 0x51, 0x32, 0x2d, 0xbb, 0xcd, 0x49, 0xd8, 0x9d, 0xf9,
-	0x76, 0x7f, 0xce, 0x0c, 0x7f, 0x11, 0x5a, 0x49, 0xcf, 0xc6, 0x6e, 0x40, 0x43, 0x2b, 0xe2, 0x4c,
-	0x30, 0x74, 0xdb, 0x63, 0xcc, 0xf3, 0x89, 0x75, 0x42, 0x52, 0xc1, 0x71, 0x18, 0x47, 0x98, 0x93,
-	0x70, 0x94, 0x5a, 0x49, 0xcf, 0x30, 0x46, 0x3c, 0x8d, 0x04, 0xb3, 0x4f, 0x48, 0x1a, 0x47, 0x43,
-	0xf5, 0x93, 0x63, 0xc6, 0x07, 0x39, 0x66, 0xe3, 0x88, 0xda, 0x38, 0x0c, 0x99, 0xc0, 0x82, 0xb2,
-	0x30, 0x56, 0x51, 0x75, 0xa8, 0x2d, 0x57, 0xc3, 0xc9, 0xd8, 0xc6, 0x61, 0xaa, 0x42, 0x77, 0xca,
-	0x21, 0x77, 0xc2, 0x25, 0xab, 0xe2, 0xef, 0x97, 0xe3, 0x24, 0x88, 0x44, 0x01, 0x7f, 0x58, 0x0e,
-	0x0a, 0x1a, 0x90, 0x58, 0xe0, 0x20, 0x52, 0x09, 0x2d, 0xc1, 0xa9, 0xef, 0x53, 0xac, 0x4e, 0x33,
-	0xff, 0xac, 0xc1, 0x8d, 0x7d, 0xca, 0xc9, 0x48, 0x30, 0x9e, 0xa2, 0x8f, 0x60, 0xc3, 0x2d, 0x16,
-	0x03, 0xea, 0xea, 0x5a, 0x47, 0xeb, 0xde, 0x70, 0x9a, 0xd3, 0xbd, 0x03, 0x17, 0x75, 0xa0, 0xee,
-	0x33, 0x4f, 0xaf, 0x75, 0xb4, 0x6e, 0xb3, 0xdf, 0xb2, 0xa6, 0xc7, 0x1d, 0x73, 0x42, 0x9c, 0x2c,
-	0x94, 0x65, 0x04, 0x38, 0xd2, 0xeb, 0xcb, 0x33, 0x02, 0x1c, 0xa1, 0xbb, 0x50, 0x4f, 0xf8, 0x58,
-	0xbf, 0x26, 0x33, 0x36, 0x2d, 0x55, 0xb7, 0xa3, 0xc9, 0xd0, 0xa7, 0xa3, 0x43, 0x92, 0x3a, 0x59,
-	0x14, 0x3d, 0x84, 0x8d, 0x80, 0x86, 0x03, 0x1a, 0x0a, 0xc2, 0x13, 0xec, 0xeb, 0x6b, 0x32, 0xfb,
-	0xb6, 0xa5, 0xda, 0x51, 0x3c, 0xa1, 0xb5, 0xaf, 0xca, 0xe3, 0x34, 0x03, 0x1a, 0x1e, 0xa8, 0x6c,
-	0x49, 0xe3, 0xd7, 0x67, 0x74, 0xe3, 0x62, 0x1a, 0xbf, 0x9e, 0xd2, 0x3a, 0x5c, 0x77, 0x89, 0x4f,
-	0x04, 0x71, 0xf5, 0xeb, 0x1d, 0xad, 0xbb, 0xee, 0x14, 0x4b, 0xf3, 0x0b, 0xd8, 0x79, 0x46, 0x63,
-	0x51, 0x94, 0x8c, 0x92, 0xd8, 0x21, 0x3f, 0x4c, 0x48, 0x2c, 0xb2, 0xda, 0xc5, 0x2f, 0xd9, 0xab,
-	0x41, 0x01, 0x6a, 0x12, 0x6c, 0x66, 0x7b, 0xfb, 0x0a, 0xc6, 0x70, 0x6b, 0x01, 0x8e, 0x23, 0x16,
-	0xc6, 0x04, 0x3d, 0x81, 0x69, 0x95, 0x29, 0x89, 0x75, 0xad, 0x53, 0xef, 0x36, 0xfb, 0x1f, 0x5b,
-	0xe7, 0xce, 0x9e, 0x35, 0x6d, 0x9a, 0x33, 0x0b, 0x9a, 0xdf, 0xc3, 0x7b, 0x4f, 0x89, 0x38, 0x0b,
-	0x9e, 0x89, 0xbb, 0xa8, 0xb1, 0x65, 0xfd, 0xb5, 0x45, 0xfd, 0xff, 0xd4, 0x60, 0xe7, 0x31, 0x27,
-	0x58, 0x90, 0xab, 0x5c, 0x50, 0x6e, 0x68, 0xed, 0x3f, 0x35, 0xb4, 0x7e, 0xa9, 0x86, 0x3e, 0x84,
-	0x76, 0xc2, 0xc7, 0x83, 0x88, 0xd3, 0x04, 0x0b, 0x32, 0x38, 0x21, 0xa9, 0x9a, 0xbe, 0xad, 0x85,
-	0x03, 0x1e, 0x85, 0xa9, 0x73, 0x33, 0xe1, 0xe3, 0xa3, 0x3c, 0xf7, 0x90, 0xa4, 0x19, 0xed, 0x33,
-	0x6f, 0x8e, 0x5e, 0xab, 0xa2, 0x7d, 0xe6, 0xcd, 0xd3, 0x01, 0x8e, 0xe6, 0xe8, 0x46, 0x15, 0x1d,
-	0xe0, 0xe8, 0x8c, 0xce, 0x06, 0x2e, 0x2f, 0xff, 0x15, 0x4a, 0x6e, 0x7e, 0x09, 0xfa, 0xf3, 0xd0,
-	0xbd, 0x32, 0x3e, 0x84, 0xad, 0x6c, 0x5e, 0x0f, 0xc2, 0x68, 0x22, 0x9e, 0x31, 0x2f, 0xbe, 0x44,
-	0xb3, 0xef, 0x41, 0x7b, 0x4c, 0x7d, 0x41, 0xf8, 0xe0, 0x15, 0xa7, 0x02, 0x0f, 0x7d, 0xa2, 0x06,
-	0xaa, 0x95, 0x6f, 0x7f, 0xab, 0x76, 0xcd, 0x23, 0xd8, 0x2e, 0xdd, 0xa1, 0xde, 0x88, 0xcf, 0xe0,
-	0x9a, 0xcf, 0xbc, 0xe2, 0x55, 0xb8, 0x5b, 0xf1, 0x2a, 0x14, 0xac, 0x23, 0x01, 0xf3, 0x05, 0xac,
-	0x17, 0x3b, 0xab, 0x28, 0xdd, 0x86, 0x46, 0xd6, 0x5c, 0x9a, 0x4f, 0x7c, 0xdd, 0x59, 0xf3, 0x99,
-	0x77, 0xe0, 0x22, 0x03, 0xd6, 0xa7, 0xca, 0xeb, 0x52, 0xf9, 0x74, 0x6d, 0x1e, 0xc2, 0xf6, 0x53,
-	0xcc, 0x87, 0xd8, 0x23, 0x8f, 0x99, 0xef, 0x93, 0x91, 0x28, 0x0a, 0xd3, 0x87, 0xc6, 0x90, 0x8c,
-	0x19, 0x27, 0xf2, 0xa2, 0x66, 0xdf, 0x58, 0xe8, 0xf0, 0x71, 0xe1, 0xc7, 0x8e, 0xca, 0x34, 0x5f,
-	0xc0, 0x4e, 0xf9, 0xb0, 0xff, 0xd7, 0x13, 0xfa, 0x7f, 0x00, 0x6c, 0x1d, 0x92, 0xf4, 0x78, 0x26,
-	0xfb, 0x51, 0xf6, 0x07, 0x87, 0x4e, 0x35, 0x68, 0x97, 0x0c, 0x09, 0xf5, 0x2a, 0xce, 0x5f, 0xee,
-	0x7c, 0x46, 0xff, 0x32, 0x48, 0xfe, 0x6c, 0xe6, 0xad, 0x1f, 0xff, 0xfa, 0xfb, 0xd7, 0xda, 0x26,
-	0x6a, 0xdb, 0x49, 0xcf, 0x9e, 0x11, 0x8b, 0x7e, 0xd1, 0x60, 0x63, 0xd6, 0xc1, 0x90, 0x55, 0x71,
-	0xfa, 0x12, 0xab, 0x33, 0x56, 0x2a, 0x90, 0xf9, 0x89, 0xbc, 0xbf, 0x83, 0xee, 0x94, 0xee, 0xb7,
-	0xdf, 0xcc, 0xce, 0xcb, 0x5b, 0xf4, 0xb3, 0x06, 0xed, 0x92, 0xe5, 0x55, 0x96, 0x68, 0xb9, 0x3d,
-	0xae, 0x28, 0xca, 0x90, 0xa2, 0xb6, 0xcc, 0x72, 0x51, 0x1e, 0x68, 0xbb, 0xe8, 0x27, 0x0d, 0xda,
-	0x25, 0x23, 0xa8, 0x14, 0xb2, 0xdc, 0x34, 0x8c, 0x9d, 0x85, 0x89, 0xfc, 0x3a, 0xfb, 0x7c, 0x28,
-	0xea, 0xb1, 0x7b, 0x51, 0x3d, 0x4e, 0x35, 0xd8, 0x5c, 0xb0, 0x14, 0xb4, 0x57, 0x21, 0xe4, 0x3c,
-	0x03, 0x3a, 0x57, 0x8a, 0x2d, 0xa5, 0x7c, 0xba, 0x7b, 0xaf, 0x5a, 0xca, 0x83, 0x89, 0x3a, 0x18,
-	0xfd, 0xae, 0xc1, 0xcd, 0x39, 0x0f, 0x41, 0xf6, 0x05, 0x13, 0x59, 0x76, 0x34, 0xe3, 0xfe, 0xea,
-	0x80, 0x1a, 0xe0, 0xfb, 0x52, 0xe5, 0x2e, 0xea, 0x56, 0xab, 0xb4, 0x69, 0x46, 0x66, 0xbe, 0x84,
-	0x7e, 0xd3, 0xa0, 0x95, 0x8f, 0xc7, 0xd4, 0x9e, 0x56, 0x71, 0x35, 0x63, 0x95, 0x24, 0xf3, 0x73,
-	0x29, 0x67, 0xcf, 0xec, 0xad, 0x2a, 0xc7, 0x7e, 0x93, 0xbb, 0xde, 0x5b, 0xa9, 0xeb, 0x79, 0xe4,
-	0xbe, 0x53, 0x5d, 0xc6, 0x15, 0x74, 0x4d, 0xa0, 0x35, 0x6f, 0x8c, 0xa8, 0xaa, 0x4b, 0x4b, 0x0d,
-	0xd9, 0xe8, 0x5d, 0x82, 0xc8, 0x1b, 0xfb, 0xd5, 0x37, 0xdf, 0x3d, 0xf1, 0xa8, 0x78, 0x39, 0x19,
-	0x5a, 0x23, 0x16, 0xd8, 0xea, 0x7b, 0xba, 0x84, 0xdb, 0x23, 0xc6, 0xf3, 0x4f, 0xfb, 0xa4, 0x57,
-	0x8e, 0x0d, 0x3c, 0x36, 0xc8, 0x27, 0xba, 0x21, 0x7f, 0xf6, 0xfe, 0x0d, 0x00, 0x00, 0xff, 0xff,
-	0x2c, 0x1b, 0x9e, 0x3f, 0x52, 0x0c, 0x00, 0x00,
+	// 1744 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0xdd, 0x6e, 0x24, 0x47,
+	0x15, 0xa6, 0x67, 0x76, 0xfe, 0xce, 0xd8, 0x33, 0xde, 0xc2, 0xeb, 0xf4, 0xce, 0x2e, 0x89, 0xe9,
+	0x25, 0x89, 0xd7, 0x8a, 0x66, 0xd6, 0x5e, 0x20, 0xc1, 0x1b, 0x7e, 0x1c, 0xdb, 0x49, 0x2c, 0x2f,
+	0x92, 0xd3, 0xf6, 0x12, 0x01, 0x17, 0xad, 0x9a, 0xe9, 0xe3, 0xd9, 0x96, 0xfb, 0x8f, 0xea, 0x9a,
+	0xd9, 0x9d, 0x44, 0xb9, 0x00, 0x21, 0x84, 0x10, 0x37, 0x11, 0xe2, 0x01, 0x90, 0x10, 0x12, 0x20,
+	0x2e, 0x79, 0x08, 0xae, 0x41, 0xe2, 0x05, 0x10, 0xcf, 0xc0, 0x25, 0xaa, 0xea, 0xea, 0xf9, 0xe9,
+	0x99, 0xed, 0x69, 0x3b, 0x9b, 0x2b, 0xbb, 0x4e, 0x9d, 0xef, 0xd4, 0x57, 0xa7, 0x4e, 0x9d, 0xfa,
+	0x7a, 0xa0, 0x31, 0xdc, 0xe9, 0x50, 0xdb, 0x73, 0xfc, 0x76, 0xc8, 0x02, 0x1e, 0x90, 0xdb, 0xfd,
+	0x20, 0xe8, 0xbb, 0xd8, 0xbe, 0xc4, 0x11, 0x67, 0xd4, 0x8f, 0x42, 0xca, 0xd0, 0xef, 0x8d, 0xda,
+	0xc3, 0x9d, 0x56, 0xab, 0xc7, 0x46, 0x21, 0x0f, 0x3a, 0x97, 0x38, 0x8a, 0xc2, 0xae, 0xfa, 0x13,
+	0xc3, 0x5a, 0x77, 0x63, 0x58, 0x87, 0x86, 0x4e, 0x87, 0xfa, 0x7e, 0xc0, 0x29, 0x77, 0x02, 0x3f,
+	0x52, 0xb3, 0x2a, 0x68, 0x47, 0x8e, 0xba, 0x83, 0x8b, 0x0e, 0xf5, 0x47, 0x6a, 0xea, 0xd5, 0xf4,
+	0x94, 0x3d, 0x60, 0x12, 0xab, 0xe6, 0xef, 0xa4, 0xe7, 0xd1, 0x0b, 0x79, 0x02, 0x7e, 0x2d, 0x3d,
+	0xc9, 0x1d, 0x0f, 0x23, 0x4e, 0xbd, 0x50, 0x39, 0x34, 0x38, 0x73, 0x5c, 0xd7, 0xa1, 0x2a, 0x9a,
+	0xf1, 0xdf, 0x22, 0xd4, 0x0e, 0x1d, 0x86, 0x3d, 0x1e, 0xb0, 0x11, 0xf9, 0x3a, 0xac, 0xd8, 0xc9,
+	0xc0, 0x72, 0x6c, 0x5d, 0xdb, 0xd4, 0xb6, 0x6a, 0x66, 0x7d, 0x6c, 0x3b, 0xb6, 0xc9, 0x26, 0x14,
+	0xdd, 0xa0, 0xaf, 0x17, 0x36, 0xb5, 0xad, 0xfa, 0x6e, 0xa3, 0x3d, 0x0e, 0x77, 0xce, 0x10, 0x4d,
+	0x31, 0x25, 0x3c, 0x3c, 0x1a, 0xea, 0xc5, 0xc5, 0x1e, 0x1e, 0x0d, 0xc9, 0x3d, 0x28, 0x0e, 0xd9,
+	0x85, 0x7e, 0x43, 0x7a, 0xdc, 0x6c, 0xab, 0xbc, 0x9d, 0x0e, 0xba, 0xae, 0xd3, 0x3b, 0xc1, 0x91,
+	0x29, 0x66, 0xc9, 0xbb, 0xb0, 0xe2, 0x39, 0xbe, 0xe5, 0xf8, 0x1c, 0xd9, 0x90, 0xba, 0x7a, 0x49,
+	0x7a, 0xdf, 0x6e, 0xab, 0xe3, 0x48, 0x76, 0xd8, 0x3e, 0x54, 0xe9, 0x31, 0xeb, 0x9e, 0xe3, 0x1f,
+	0x2b, 0x6f, 0x89, 0xa6, 0xcf, 0x27, 0xe8, 0xf2, 0x72, 0x34, 0x7d, 0x3e, 0x46, 0xeb, 0x50, 0xb1,
+	0xd1, 0x45, 0x8e, 0xb6, 0x5e, 0xd9, 0xd4, 0xb6, 0xaa, 0x66, 0x32, 0x24, 0xef, 0x43, 0x9d, 0x51,
+	0x8e, 0x96, 0xeb, 0x78, 0x0e, 0x8f, 0xf4, 0xaa, 0x0c, 0xfb, 0x7a, 0xfb, 0x85, 0x35, 0xd2, 0x36,
+	0x29, 0xc7, 0xc7, 0xd2, 0xd9, 0x04, 0x36, 0xfe, 0x9f, 0x7c, 0x08, 0x35, 0x86, 0x1c, 0x7d, 0xb1,
+	0xb6, 0x5e, 0x93, 0x51, 0xb6, 0xb3, 0xa2, 0x24, 0xbe, 0xa7, 0x81, 0xeb, 0xf4, 0x46, 0xe6, 0x04,
+	0x4c, 0x5e, 0x87, 0x06, 0x75, 0xdd, 0xe0, 0x99, 0xc5, 0xb0, 0x17, 0x0c, 0x91, 0x8d, 0x74, 0x90,
+	0x94, 0x57, 0xa5, 0xd5, 0x54, 0x46, 0xe3, 0x04, 0x4a, 0x1f, 0x0d, 0x02, 0x4e, 0xc9, 0x5b, 0x40,
+	0x06, 0xa1, 0x4d, 0x39, 0x46, 0x56, 0x88, 0xcc, 0x8a, 0xb0, 0x17, 0xf8, 0xf1, 0x49, 0x6b, 0xe6,
+	0x9a, 0x9a, 0x39, 0x45, 0x76, 0x26, 0xed, 0x64, 0x1d, 0x4a, 0xdd, 0x01, 0x8b, 0xb8, 0x3c, 0xf0,
+	0xa2, 0x19, 0x0f, 0x8c, 0x7f, 0x6b, 0x00, 0x93, 0x8d, 0x91, 0x23, 0x58, 0x15, 0xa1, 0x42, 0xe6,
+	0xf8, 0x3d, 0x27, 0xa4, 0xae, 0x8c, 0x56, 0xdf, 0xdd, 0xcc, 0xd8, 0x90, 0xe4, 0x62, 0xae, 0x84,
+	0xc8, 0x4e, 0x13, 0x14, 0x79, 0x04, 0x55, 0x11, 0x66, 0x10, 0x21, 0xd3, 0x0b, 0x39, 0x23, 0x54,
+	0x42, 0x64, 0x4f, 0x22, 0x64, 0x09, 0x87, 0x71, 0xa9, 0xea, 0xc5, 0x9c, 0x11, 0x04, 0x87, 0xf1,
+	0x0d, 0x30, 0x7a, 0xd0, 0x4c, 0xe5, 0x9a, 0xdc, 0x15, 0x47, 0x35, 0x74, 0x22, 0x71, 0x7d, 0xe5,
+	0xce, 0x8a, 0xe6, 0xc4, 0x40, 0x76, 0xa1, 0x22, 0x0a, 0x8d, 0xf6, 0x51, 0x2f, 0x2c, 0xab, 0xb1,
+	0xb2, 0x47, 0x9f, 0xef, 0xf7, 0xd1, 0x78, 0x04, 0x1b, 0x8f, 0x9d, 0x88, 0x27, 0xab, 0x3a, 0x18,
+	0x99, 0xf8, 0xb3, 0x01, 0x46, 0x5c, 0x5c, 0xc0, 0xe8, 0x69, 0xf0, 0xcc, 0x4a, 0xaa, 0x4f, 0x93,
+	0x47, 0x59, 0x17, 0xb6, 0xc3, 0xd8, 0x64, 0x50, 0x78, 0x65, 0x0e, 0x1c, 0x85, 0x81, 0x1f, 0xa1,
+	0x28, 0x4e, 0x7b, 0x62, 0xd6, 0xb5, 0xcd, 0xe2, 0x56, 0x7d, 0xf7, 0x1b, 0x19, 0x19, 0x18, 0xef,
+	0xdb, 0x9c, 0x06, 0x1a, 0x3f, 0x85, 0xaf, 0x7e, 0x80, 0x7c, 0x32, 0x39, 0x21, 0xb7, 0xac, 0x3b,
+	0xa4, 0xf9, 0x17, 0xe6, 0xf9, 0xff, 0xe5, 0x06, 0x6c, 0x1c, 0x30, 0xa4, 0x1c, 0xaf, 0xb3, 0x40,
+	0xba, 0x2b, 0x14, 0xbe, 0x50, 0x57, 0x28, 0x5e, 0xa9, 0x2b, 0xbc, 0x0b, 0xcd, 0x21, 0xbb, 0x10,
+	0x65, 0x3e, 0x14, 0x2d, 0xe0, 0x12, 0x47, 0xaa, 0x85, 0xad, 0xcf, 0x05, 0xd8, 0xf7, 0x47, 0xe6,
+	0xea, 0x90, 0x5d, 0x9c, 0xc6, 0xbe, 0x27, 0x38, 0x12, 0x68, 0x37, 0xe8, 0xcf, 0xa0, 0x4b, 0x59,
+	0x68, 0x37, 0xe8, 0xcf, 0xa2, 0x3d, 0x1a, 0xce, 0xa0, 0xcb, 0x59, 0x68, 0x8f, 0x86, 0x53, 0xe8,
+	0x54, 0xd7, 0xaa, 0xbc, 0x94, 0xae, 0x55, 0x7d, 0xb9, 0x5d, 0xab, 0xb6, 0xa8, 0x6b, 0x3d, 0x82,
+	0x8d, 0xb8, 0x6e, 0xae, 0x51, 0x2b, 0xc6, 0x77, 0x41, 0x7f, 0xe2, 0xdb, 0xd7, 0x86, 0xff, 0x5c,
+	0x83, 0xf5, 0x33, 0xe4, 0x53, 0xa9, 0xc8, 0x5f, 0xa6, 0xa9, 0x84, 0x17, 0xae, 0x99, 0x70, 0xe3,
+	0xd7, 0x1a, 0xdc, 0x16, 0x1c, 0x52, 0x89, 0xcc, 0x4f, 0x64, 0xe6, 0xc4, 0x0a, 0x5f, 0xe0, 0xc4,
+	0x8c, 0x2e, 0xac, 0x8b, 0xbe, 0x73, 0xec, 0x87, 0x03, 0xfe, 0x38, 0xe8, 0x5f, 0x25, 0x1b, 0x6f,
+	0x42, 0xf3, 0xc2, 0x71, 0x39, 0x32, 0xeb, 0x19, 0x73, 0x38, 0xed, 0xba, 0xa8, 0x1a, 0x43, 0x23,
+	0x36, 0x7f, 0xac, 0xac, 0xc6, 0x29, 0xdc, 0x4a, 0xad, 0xa1, 0x3a, 0xdb, 0xdb, 0x70, 0xc3, 0x0d,
+	0xfa, 0x49, 0x4b, 0xbb, 0x97, 0xb1, 0x83, 0x04, 0x6b, 0x4a, 0x80, 0xf1, 0x3f, 0x0d, 0xaa, 0x89,
+	0x29, 0x0f, 0xd5, 0x5b, 0x50, 0x16, 0xb7, 0xd4, 0xb1, 0x93, 0x07, 0xcf, 0x0d, 0xfa, 0xc7, 0x36,
+	0x69, 0x41, 0x75, 0x4c, 0xbd, 0x28, 0xa9, 0x8f, 0xc7, 0xe4, 0xfb, 0x50, 0x8a, 0x38, 0xe5, 0x28,
+	0x9b, 0x41, 0x63, 0xf7, 0x7e, 0x0e, 0x72, 0xed, 0x33, 0x01, 0x30, 0x63, 0x5c, 0x9c, 0x1e, 0x9f,
+	0xba, 0xd6, 0x33, 0xca, 0x91, 0x79, 0x94, 0x5d, 0xca, 0xce, 0x50, 0x14, 0xe9, 0xf1, 0xa9, 0xfb,
+	0x71, 0x62, 0x35, 0xde, 0x82, 0x92, 0x04, 0x12, 0x80, 0xf2, 0xfe, 0xc1, 0xf9, 0xf1, 0x8f, 0x8e,
+	0xd6, 0xbe, 0x22, 0xfe, 0x3f, 0x3b, 0xda, 0x7f, 0x7c, 0x74, 0xb8, 0xa6, 0x91, 0x3a, 0x54, 0xcc,
+	0xa3, 0xf3, 0x63, 0xf3, 0xe8, 0x70, 0xad, 0x60, 0x7c, 0x04, 0xb7, 0x4c, 0xe4, 0x0e, 0xc3, 0x71,
+	0x4a, 0xf2, 0x9f, 0xd8, 0xe2, 0x34, 0x18, 0x27, 0x70, 0xeb, 0x03, 0xca, 0xba, 0xb4, 0x8f, 0x07,
+	0x81, 0xeb, 0x62, 0x8f, 0x27, 0x21, 0x77, 0xa1, 0xdc, 0xc5, 0x8b, 0x80, 0xa1, 0x7a, 0xfa, 0x5b,
+	0x73, 0x5d, 0xe9, 0x3c, 0x11, 0xa2, 0xa6, 0xf2, 0x34, 0xfe, 0xaa, 0xc1, 0x46, 0x3a, 0xda, 0xcb,
+	0x7d, 0xc8, 0xc8, 0xf1, 0x44, 0xc7, 0x15, 0x64, 0x8c, 0x4e, 0x56, 0x8c, 0xd8, 0x73, 0x1c, 0xea,
+	0x90, 0x0a, 0x7d, 0xa1, 0xf0, 0xc6, 0xdf, 0x35, 0x58, 0x5f, 0xe4, 0x91, 0x27, 0x9b, 0x5f, 0x03,
+	0x70, 0xc4, 0x19, 0x58, 0xb2, 0x86, 0xe3, 0x8c, 0xd6, 0x9c, 0xa4, 0xc8, 0x85, 0xc0, 0xf0, 0x06,
+	0xea, 0xfb, 0x40, 0x56, 0x57, 0xd1, 0x9c, 0x18, 0x84, 0x16, 0xed, 0x52, 0xde, 0x7b, 0x8a, 0x91,
+	0x2c, 0xb0, 0xa2, 0x99, 0x0c, 0xc9, 0x3d, 0x58, 0x55, 0xec, 0x2c, 0xce, 0x10, 0x23, 0xbd, 0xb4,
+	0x59, 0xdc, 0x2a, 0x9a, 0x2b, 0xca, 0x28, 0xf4, 0x76, 0x64, 0xfc, 0xa3, 0x00, 0xcd, 0x7d, 0xf1,
+	0x39, 0xb3, 0x3f, 0xb0, 0x1d, 0x7e, 0x34, 0x44, 0x9f, 0x93, 0xdb, 0x50, 0x45, 0xf1, 0x4f, 0x42,
+	0xb7, 0x68, 0x56, 0xe4, 0xf8, 0xd8, 0x26, 0x6d, 0xb8, 0x21, 0x3e, 0x19, 0xf4, 0xc2, 0xd2, 0x63,
+	0x94, 0x7e, 0x64, 0x03, 0xca, 0x3d, 0xea, 0xba, 0xc8, 0x24, 0xf1, 0x9a, 0xa9, 0x46, 0xc2, 0xee,
+	0x21, 0x7f, 0x1a, 0xd8, 0x92, 0x74, 0xcd, 0x54, 0x23, 0xd2, 0x86, 0x0a, 0x8b, 0x6b, 0x26, 0xf3,
+	0xf5, 0x4b, 0x9c, 0xc8, 0x6b, 0x50, 0x17, 0x97, 0x64, 0x10, 0x59, 0xbd, 0xc0, 0x46, 0xf9, 0xe6,
+	0x95, 0x4c, 0x88, 0x4d, 0x07, 0x81, 0x8d, 0xe2, 0x21, 0x51, 0x0e, 0x1e, 0x46, 0x91, 0x90, 0x61,
+	0x15, 0xb9, 0xe0, 0x6a, 0x6c, 0xfd, 0x61, 0x6c, 0x24, 0x07, 0xd0, 0xec, 0x05, 0x5e, 0xe8, 0xa2,
+	0x48, 0xaa, 0x25, 0xb7, 0x58, 0x5d, 0xba, 0xc5, 0xc6, 0x04, 0x22, 0x8c, 0xc6, 0x8f, 0xe1, 0x8e,
+	0x68, 0x4f, 0xa9, 0x74, 0x8e, 0x3b, 0xe1, 0x1d, 0xa8, 0x85, 0xb4, 0x8f, 0x56, 0xe4, 0x7c, 0x12,
+	0xdf, 0x83, 0x92, 0x59, 0x15, 0x86, 0x33, 0xe7, 0x13, 0x14, 0x35, 0x20, 0x27, 0x79, 0x70, 0x89,
+	0x71, 0x27, 0xae, 0x99, 0xd2, 0xfd, 0x5c, 0x18, 0x8c, 0xdf, 0x68, 0x70, 0x77, 0x71, 0x6c, 0x75,
+	0x25, 0xde, 0x83, 0xb2, 0x3c, 0xa3, 0xe4, 0x36, 0x64, 0x75, 0xf1, 0x54, 0x10, 0x53, 0x21, 0xc9,
+	0x1b, 0xd0, 0xf4, 0xf1, 0x39, 0xb7, 0xe6, 0x88, 0xac, 0x0a, 0xf3, 0x69, 0x42, 0x66, 0xf7, 0x5f,
+	0x4d, 0x58, 0x3f, 0xc1, 0xd1, 0xf9, 0x54, 0x58, 0x19, 0x92, 0x7c, 0xae, 0x41, 0x33, 0x25, 0x3e,
+	0xc9, 0x4e, 0x06, 0x91, 0xc5, 0x2a, 0xb7, 0xb5, 0x7b, 0x15, 0x48, 0xbc, 0x7f, 0xe3, 0x95, 0x5f,
+	0xfc, 0xf3, 0x3f, 0xbf, 0x2b, 0xdc, 0x24, 0xcd, 0xce, 0x70, 0xa7, 0x33, 0x7d, 0xc7, 0x7f, 0xab,
+	0xc1, 0xca, 0xb4, 0x5a, 0x25, 0xed, 0x8c, 0xe8, 0x0b, 0x64, 0x6d, 0x2b, 0x57, 0x5f, 0x31, 0xde,
+	0x90, 0xeb, 0x6f, 0x92, 0x57, 0x53, 0xeb, 0x77, 0x3e, 0x9d, 0xbe, 0xfd, 0x9f, 0x91, 0x5f, 0x69,
+	0xd0, 0x4c, 0xc9, 0xdb, 0xcc, 0x14, 0x2d, 0x96, 0xc2, 0x39, 0x49, 0xb5, 0x24, 0xa9, 0x75, 0x23,
+	0x9d, 0x94, 0x3d, 0x6d, 0x9b, 0xfc, 0x52, 0x83, 0x66, 0x4a, 0x3b, 0x65, 0x12, 0x59, 0xac, 0xb3,
+	0x5a, 0x1b, 0x73, 0xf7, 0xe3, 0x48, 0xfc, 0xde, 0x90, 0xe4, 0x63, 0x7b, 0x59, 0x3e, 0x3e, 0xd7,
+	0xe0, 0xe6, 0x9c, 0x0a, 0x23, 0x0f, 0x33, 0x88, 0xbc, 0x48, 0xb3, 0xbd, 0x90, 0x4a, 0x47, 0x52,
+	0xb9, 0xbf, 0xfd, 0x66, 0x36, 0x95, 0xbd, 0x81, 0x0a, 0x4c, 0xfe, 0xa4, 0xc1, 0xea, 0x8c, 0xb2,
+	0x23, 0x59, 0xef, 0xc2, 0x22, 0x0d, 0x98, 0xf3, 0x7c, 0xbe, 0x27, 0x99, 0xbd, 0xd3, 0xba, 0x9f,
+	0xcd, 0xac, 0xc3, 0x28, 0xc7, 0x58, 0x2a, 0xee, 0x4d, 0xeb, 0x46, 0xf2, 0x37, 0x0d, 0xc8, 0xbc,
+	0xfc, 0x23, 0xdf, 0x5c, 0xc2, 0x76, 0xa1, 0x5a, 0xcc, 0x49, 0xf9, 0x91, 0xa4, 0xfc, 0xad, 0xd6,
+	0xd6, 0x32, 0xca, 0xc9, 0x22, 0x7b, 0x53, 0xaa, 0xfe, 0x8f, 0x1a, 0xac, 0xce, 0x08, 0xb8, 0xcc,
+	0xc4, 0x2e, 0x92, 0x93, 0xad, 0x07, 0xf9, 0x01, 0xaa, 0x33, 0x3c, 0x90, 0x8c, 0xb7, 0xc9, 0x32,
+	0xc6, 0xf2, 0xc1, 0x15, 0x2f, 0x30, 0xf9, 0xbd, 0x06, 0x8d, 0xf8, 0xde, 0x8d, 0xa5, 0x61, 0x1e,
+	0x49, 0xd9, 0xca, 0xe3, 0x64, 0x7c, 0x47, 0xd2, 0x79, 0x68, 0xec, 0xe4, 0xa5, 0xd3, 0xf9, 0x34,
+	0x96, 0x5a, 0x9f, 0x49, 0x5e, 0x4f, 0xe4, 0x2f, 0x30, 0x5f, 0x1e, 0xaf, 0xd6, 0x35, 0x78, 0xfd,
+	0x59, 0x83, 0xc6, 0xac, 0x94, 0x24, 0x0f, 0xb2, 0x3f, 0x22, 0xe6, 0x55, 0x67, 0x3e, 0x92, 0x3f,
+	0x90, 0x24, 0xf7, 0x8c, 0x77, 0xae, 0x4c, 0x72, 0x8f, 0xc9, 0x55, 0xc9, 0x00, 0x1a, 0xb3, 0xa2,
+	0x32, 0x93, 0xea, 0x42, 0x35, 0xdb, 0xda, 0xb9, 0x02, 0x42, 0x3d, 0xcf, 0x7f, 0xd0, 0xe2, 0xcf,
+	0xa3, 0xf4, 0xfb, 0x4d, 0xbe, 0xbd, 0xa4, 0x9e, 0x5f, 0x20, 0x26, 0x5a, 0x6f, 0x5f, 0x19, 0xa7,
+	0xae, 0x83, 0x2e, 0x53, 0x48, 0xc8, 0x9a, 0x48, 0x21, 0x15, 0x0e, 0x9d, 0xf8, 0xf9, 0x7f, 0xef,
+	0xc3, 0x9f, 0xbc, 0xdf, 0x77, 0xf8, 0xd3, 0x41, 0xb7, 0xdd, 0x0b, 0xbc, 0x8e, 0xfa, 0xa5, 0x38,
+	0x15, 0xbe, 0xd3, 0x0b, 0x58, 0xfc, 0xa3, 0xf5, 0x70, 0x27, 0x3d, 0x67, 0xf5, 0x03, 0x2b, 0x6e,
+	0xbd, 0x65, 0xf9, 0xe7, 0xe1, 0xff, 0x07, 0x00, 0xc7, 0xe3, 0xf3, 0x7e, 0x2c, 0x17, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -762,15 +1542,25 @@ type KeyTransparencyAdminClient interface {
 	// UndeleteDirectory marks a previously deleted directory as active if it has
 	// not already been garbage collected.
 	UndeleteDirectory(ctx context.Context, in *UndeleteDirectoryRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// SetRateLimits replaces the rate limits of a directory.
+	SetRateLimits(ctx context.Context, in *SetRateLimitsRequest, opts ...grpc.CallOption) (*Directory, error)
+	// SetRetentionPolicy replaces the retention policy of a directory.
+	SetRetentionPolicy(ctx context.Context, in *SetRetentionPolicyRequest, opts ...grpc.CallOption) (*Directory, error)
 	// ListInputLogs returns a list of input logs for a directory.
 	ListInputLogs(ctx context.Context, in *ListInputLogsRequest, opts ...grpc.CallOption) (*ListInputLogsResponse, error)
 	// CreateInputLog returns a the created log.
 	CreateInputLog(ctx context.Context, in *InputLog, opts ...grpc.CallOption) (*InputLog, error)
 	// UpdateInputLog updates the write bit for an input log.
+	// Sealed and retired logs cannot be made writable again.
 	UpdateInputLog(ctx context.Context, in *InputLog, opts ...grpc.CallOption) (*InputLog, error)
+	// RetireInputLog stops including a sealed input log in new revisions.
+	RetireInputLog(ctx context.Context, in *RetireInputLogRequest, opts ...grpc.CallOption) (*InputLog, error)
 	// Fully delete soft-deleted directories that have been soft-deleted before
 	// the specified timestamp.
 	GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error)
+	// ListAdminAuditEvents returns the record of administrative actions taken
+	// through this API.
+	ListAdminAuditEvents(ctx context.Context, in *ListAdminAuditEventsRequest, opts ...grpc.CallOption) (*ListAdminAuditEventsResponse, error)
 }
 
 type keyTransparencyAdminClient struct {
@@ -826,6 +1616,24 @@ func (c *keyTransparencyAdminClient) UndeleteDirectory(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *keyTransparencyAdminClient) SetRateLimits(ctx context.Context, in *SetRateLimitsRequest, opts ...grpc.CallOption) (*Directory, error) {
+	out := new(Directory)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/SetRateLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyTransparencyAdminClient) SetRetentionPolicy(ctx context.Context, in *SetRetentionPolicyRequest, opts ...grpc.CallOption) (*Directory, error) {
+	out := new(Directory)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/SetRetentionPolicy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *keyTransparencyAdminClient) ListInputLogs(ctx context.Context, in *ListInputLogsRequest, opts ...grpc.CallOption) (*ListInputLogsResponse, error) {
 	out := new(ListInputLogsResponse)
 	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/ListInputLogs", in, out, opts...)
@@ -853,6 +1661,15 @@ func (c *keyTransparencyAdminClient) UpdateInputLog(ctx context.Context, in *Inp
 	return out, nil
 }
 
+func (c *keyTransparencyAdminClient) RetireInputLog(ctx context.Context, in *RetireInputLogRequest, opts ...grpc.CallOption) (*InputLog, error) {
+	out := new(InputLog)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/RetireInputLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *keyTransparencyAdminClient) GarbageCollect(ctx context.Context, in *GarbageCollectRequest, opts ...grpc.CallOption) (*GarbageCollectResponse, error) {
 	out := new(GarbageCollectResponse)
 	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/GarbageCollect", in, out, opts...)
@@ -862,6 +1679,15 @@ func (c *keyTransparencyAdminClient) GarbageCollect(ctx context.Context, in *Gar
 	return out, nil
 }
 
+func (c *keyTransparencyAdminClient) ListAdminAuditEvents(ctx context.Context, in *ListAdminAuditEventsRequest, opts ...grpc.CallOption) (*ListAdminAuditEventsResponse, error) {
+	out := new(ListAdminAuditEventsResponse)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparencyAdmin/ListAdminAuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyTransparencyAdminServer is the server API for KeyTransparencyAdmin service.
 type KeyTransparencyAdminServer interface {
 	// ListDirectories returns a list of all directories this Key Transparency
@@ -880,15 +1706,25 @@ type KeyTransparencyAdminServer interface {
 	// UndeleteDirectory marks a previously deleted directory as active if it has
 	// not already been garbage collected.
 	UndeleteDirectory(context.Context, *UndeleteDirectoryRequest) (*empty.Empty, error)
+	// SetRateLimits replaces the rate limits of a directory.
+	SetRateLimits(context.Context, *SetRateLimitsRequest) (*Directory, error)
+	// SetRetentionPolicy replaces the retention policy of a directory.
+	SetRetentionPolicy(context.Context, *SetRetentionPolicyRequest) (*Directory, error)
 	// ListInputLogs returns a list of input logs for a directory.
 	ListInputLogs(context.Context, *ListInputLogsRequest) (*ListInputLogsResponse, error)
 	// CreateInputLog returns a the created log.
 	CreateInputLog(context.Context, *InputLog) (*InputLog, error)
 	// UpdateInputLog updates the write bit for an input log.
+	// Sealed and retired logs cannot be made writable again.
 	UpdateInputLog(context.Context, *InputLog) (*InputLog, error)
+	// RetireInputLog stops including a sealed input log in new revisions.
+	RetireInputLog(context.Context, *RetireInputLogRequest) (*InputLog, error)
 	// Fully delete soft-deleted directories that have been soft-deleted before
 	// the specified timestamp.
 	GarbageCollect(context.Context, *GarbageCollectRequest) (*GarbageCollectResponse, error)
+	// ListAdminAuditEvents returns the record of administrative actions taken
+	// through this API.
+	ListAdminAuditEvents(context.Context, *ListAdminAuditEventsRequest) (*ListAdminAuditEventsResponse, error)
 }
 
 // UnimplementedKeyTransparencyAdminServer can be embedded to have forward compatible implementations.
@@ -910,6 +1746,12 @@ func (*UnimplementedKeyTransparencyAdminServer) DeleteDirectory(ctx context.Cont
 func (*UnimplementedKeyTransparencyAdminServer) UndeleteDirectory(ctx context.Context, req *UndeleteDirectoryRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UndeleteDirectory not implemented")
 }
+func (*UnimplementedKeyTransparencyAdminServer) SetRateLimits(ctx context.Context, req *SetRateLimitsRequest) (*Directory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRateLimits not implemented")
+}
+func (*UnimplementedKeyTransparencyAdminServer) SetRetentionPolicy(ctx context.Context, req *SetRetentionPolicyRequest) (*Directory, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRetentionPolicy not implemented")
+}
 func (*UnimplementedKeyTransparencyAdminServer) ListInputLogs(ctx context.Context, req *ListInputLogsRequest) (*ListInputLogsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListInputLogs not implemented")
 }
@@ -919,9 +1761,15 @@ func (*UnimplementedKeyTransparencyAdminServer) CreateInputLog(ctx context.Conte
 func (*UnimplementedKeyTransparencyAdminServer) UpdateInputLog(ctx context.Context, req *InputLog) (*InputLog, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateInputLog not implemented")
 }
+func (*UnimplementedKeyTransparencyAdminServer) RetireInputLog(ctx context.Context, req *RetireInputLogRequest) (*InputLog, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetireInputLog not implemented")
+}
 func (*UnimplementedKeyTransparencyAdminServer) GarbageCollect(ctx context.Context, req *GarbageCollectRequest) (*GarbageCollectResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GarbageCollect not implemented")
 }
+func (*UnimplementedKeyTransparencyAdminServer) ListAdminAuditEvents(ctx context.Context, req *ListAdminAuditEventsRequest) (*ListAdminAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAdminAuditEvents not implemented")
+}
 
 func RegisterKeyTransparencyAdminServer(s *grpc.Server, srv KeyTransparencyAdminServer) {
 	s.RegisterService(&_KeyTransparencyAdmin_serviceDesc, srv)
@@ -1017,6 +1865,42 @@ func _KeyTransparencyAdmin_UndeleteDirectory_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyTransparencyAdmin_SetRateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRateLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencyAdminServer).SetRateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.v1.KeyTransparencyAdmin/SetRateLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencyAdminServer).SetRateLimits(ctx, req.(*SetRateLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyTransparencyAdmin_SetRetentionPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRetentionPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencyAdminServer).SetRetentionPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.v1.KeyTransparencyAdmin/SetRetentionPolicy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencyAdminServer).SetRetentionPolicy(ctx, req.(*SetRetentionPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KeyTransparencyAdmin_ListInputLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListInputLogsRequest)
 	if err := dec(in); err != nil {
@@ -1071,6 +1955,24 @@ func _KeyTransparencyAdmin_UpdateInputLog_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyTransparencyAdmin_RetireInputLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetireInputLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencyAdminServer).RetireInputLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.v1.KeyTransparencyAdmin/RetireInputLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencyAdminServer).RetireInputLog(ctx, req.(*RetireInputLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KeyTransparencyAdmin_GarbageCollect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GarbageCollectRequest)
 	if err := dec(in); err != nil {
@@ -1089,6 +1991,24 @@ func _KeyTransparencyAdmin_GarbageCollect_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyTransparencyAdmin_ListAdminAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdminAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencyAdminServer).ListAdminAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.v1.KeyTransparencyAdmin/ListAdminAuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencyAdminServer).ListAdminAuditEvents(ctx, req.(*ListAdminAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _KeyTransparencyAdmin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "google.keytransparency.v1.KeyTransparencyAdmin",
 	HandlerType: (*KeyTransparencyAdminServer)(nil),
@@ -1113,6 +2033,14 @@ var _KeyTransparencyAdmin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UndeleteDirectory",
 			Handler:    _KeyTransparencyAdmin_UndeleteDirectory_Handler,
 		},
+		{
+			MethodName: "SetRateLimits",
+			Handler:    _KeyTransparencyAdmin_SetRateLimits_Handler,
+		},
+		{
+			MethodName: "SetRetentionPolicy",
+			Handler:    _KeyTransparencyAdmin_SetRetentionPolicy_Handler,
+		},
 		{
 			MethodName: "ListInputLogs",
 			Handler:    _KeyTransparencyAdmin_ListInputLogs_Handler,
@@ -1125,10 +2053,18 @@ var _KeyTransparencyAdmin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateInputLog",
 			Handler:    _KeyTransparencyAdmin_UpdateInputLog_Handler,
 		},
+		{
+			MethodName: "RetireInputLog",
+			Handler:    _KeyTransparencyAdmin_RetireInputLog_Handler,
+		},
 		{
 			MethodName: "GarbageCollect",
 			Handler:    _KeyTransparencyAdmin_GarbageCollect_Handler,
 		},
+		{
+			MethodName: "ListAdminAuditEvents",
+			Handler:    _KeyTransparencyAdmin_ListAdminAuditEvents_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "v1/admin.proto",
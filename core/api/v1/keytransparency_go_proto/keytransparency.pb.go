@@ -14,6 +14,7 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	trillian "github.com/google/trillian"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	grpc "google.golang.org/grpc"
@@ -92,10 +93,19 @@ type EntryUpdate struct {
 	// mutation authorizes the change to entry.
 	Mutation *SignedEntry `protobuf:"bytes,2,opt,name=mutation,proto3" json:"mutation,omitempty"`
 	// committed contains the data committed to in mutation.commitment.
-	Committed            *Committed `protobuf:"bytes,3,opt,name=committed,proto3" json:"committed,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+	Committed *Committed `protobuf:"bytes,3,opt,name=committed,proto3" json:"committed,omitempty"`
+	// app_id identifies the application the entry belongs to. The entry's index
+	// is derived from both user_id and app_id. An empty app_id selects the
+	// user's default entry.
+	AppId string `protobuf:"bytes,4,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// request_id optionally identifies the request that queued this update, so
+	// that it can be safely retried. Within the server's deduplication window, a
+	// batch of updates carrying request_ids that have already been queued is not
+	// queued again.
+	RequestId            string   `protobuf:"bytes,5,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *EntryUpdate) Reset()         { *m = EntryUpdate{} }
@@ -144,6 +154,20 @@ func (m *EntryUpdate) GetCommitted() *Committed {
 	return nil
 }
 
+func (m *EntryUpdate) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
+func (m *EntryUpdate) GetRequestId() string {
+	if m != nil {
+		return m.RequestId
+	}
+	return ""
+}
+
 // Entry is a signed change to a map entry.
 // Entry contains a commitment to profile and a set of authorized update keys.
 // Entry is placed in the verifiable map as leaf data.
@@ -155,7 +179,13 @@ type Entry struct {
 	// authorized_keys is the tink keyset that validates the signatures on the next entry.
 	AuthorizedKeyset []byte `protobuf:"bytes,9,opt,name=authorized_keyset,json=authorizedKeyset,proto3" json:"authorized_keyset,omitempty"`
 	// previous contains the SHA256 hash of SignedEntry.Entry the last time it was modified.
-	Previous             []byte   `protobuf:"bytes,8,opt,name=previous,proto3" json:"previous,omitempty"`
+	Previous []byte `protobuf:"bytes,8,opt,name=previous,proto3" json:"previous,omitempty"`
+	// deleted marks this entry as a tombstone. A tombstone has no commitment
+	// and must be signed by the authorized_keyset of the entry it replaces.
+	// Once deleted, an entry cannot be modified any further, unless the
+	// directory's allow_recovery is set, in which case a new entry signed by the
+	// tombstone's authorized_keyset may replace it.
+	Deleted              bool     `protobuf:"varint,10,opt,name=deleted,proto3" json:"deleted,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -214,6 +244,13 @@ func (m *Entry) GetPrevious() []byte {
 	return nil
 }
 
+func (m *Entry) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
 // SignedEntry is a cryptographically signed Entry.
 // SignedEntry will be storead as a trillian.Map leaf.
 type SignedEntry struct {
@@ -374,10 +411,16 @@ type GetUserRequest struct {
 	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	// last_verified is the last log root the client verified.
 	// Omitting this field will omit the log consistency proof from the response.
-	LastVerified         *LogRootRequest `protobuf:"bytes,4,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	LastVerified *LogRootRequest `protobuf:"bytes,4,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	// app_id identifies the application entry to fetch for user_id.
+	// An empty app_id selects the user's default entry.
+	AppId string `protobuf:"bytes,5,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// revision, if set, is the map revision to fetch the entry from.
+	// Omitting this field selects the latest revision.
+	Revision             int64    `protobuf:"varint,6,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GetUserRequest) Reset()         { *m = GetUserRequest{} }
@@ -426,6 +469,20 @@ func (m *GetUserRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+func (m *GetUserRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
+func (m *GetUserRequest) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
 // Leaf entry for a user.
 type MapLeaf struct {
 	// vrf_proof is the proof for the VRF on user_id.
@@ -550,10 +607,16 @@ type BatchGetUserRequest struct {
 	UserIds []string `protobuf:"bytes,2,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
 	// last_verified is the last log root the client verified.
 	// Omitting this field will omit the log consistency proof from the response.
-	LastVerified         *LogRootRequest `protobuf:"bytes,4,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	LastVerified *LogRootRequest `protobuf:"bytes,4,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	// app_id identifies the application entry to fetch for each of user_ids.
+	// An empty app_id selects the users' default entries.
+	AppId string `protobuf:"bytes,5,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// revision, if set, is the map revision to fetch the entries from.
+	// Omitting this field selects the latest revision.
+	Revision             int64    `protobuf:"varint,6,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *BatchGetUserRequest) Reset()         { *m = BatchGetUserRequest{} }
@@ -602,12 +665,29 @@ func (m *BatchGetUserRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+func (m *BatchGetUserRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
+func (m *BatchGetUserRequest) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
 // BatchGetUserIndexRequest identifies a set of users.
 type BatchGetUserIndexRequest struct {
 	// directory_id identifies the directory in which the users live.
 	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
 	// user_ids are the user identifiers
-	UserIds              []string `protobuf:"bytes,2,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	UserIds []string `protobuf:"bytes,2,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	// app_id identifies the application entry for each of user_ids.
+	// An empty app_id selects the users' default entries.
+	AppId                string   `protobuf:"bytes,3,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -652,6 +732,13 @@ func (m *BatchGetUserIndexRequest) GetUserIds() []string {
 	return nil
 }
 
+func (m *BatchGetUserIndexRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
 // BatchGetUserIndexRequest identifies a single user.
 type BatchGetUserIndexResponse struct {
 	// proofs is a map from user_id to its VRF proof.
@@ -759,10 +846,13 @@ type ListEntryHistoryRequest struct {
 	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
 	// last_verified is the last log root the client verified.
 	// Omitting this field will omit the log consistency proof from the response.
-	LastVerified         *LogRootRequest `protobuf:"bytes,7,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	LastVerified *LogRootRequest `protobuf:"bytes,7,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	// app_id identifies the application entry for user_id.
+	// An empty app_id selects the user's default entry.
+	AppId                string   `protobuf:"bytes,8,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ListEntryHistoryRequest) Reset()         { *m = ListEntryHistoryRequest{} }
@@ -825,6 +915,13 @@ func (m *ListEntryHistoryRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+func (m *ListEntryHistoryRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
 // ListEntryHistoryResponse requests a paginated history of keys for a user.
 type ListEntryHistoryResponse struct {
 	// values represents the list of keys this user_id has contained over time.
@@ -893,10 +990,13 @@ type ListUserRevisionsRequest struct {
 	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	// last_verified is the last log root the client verified.
 	// Omitting this field will omit the log consistency proof from the response.
-	LastVerified         *LogRootRequest `protobuf:"bytes,8,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	LastVerified *LogRootRequest `protobuf:"bytes,8,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	// app_id identifies the application entry for user_id.
+	// An empty app_id selects the user's default entry.
+	AppId                string   `protobuf:"bytes,9,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ListUserRevisionsRequest) Reset()         { *m = ListUserRevisionsRequest{} }
@@ -973,6 +1073,13 @@ func (m *ListUserRevisionsRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+func (m *ListUserRevisionsRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
 // MapRevision contains a map leaf at a speific revision.
 type MapRevision struct {
 	// map_root contains the map root and its inclusion in the log.
@@ -1102,10 +1209,13 @@ type BatchListUserRevisionsRequest struct {
 	PageToken string `protobuf:"bytes,6,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	// last_verified is the last log root the client verified.
 	// Omitting this field will omit the log consistency proof from the response.
-	LastVerified         *LogRootRequest `protobuf:"bytes,8,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	LastVerified *LogRootRequest `protobuf:"bytes,8,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	// app_id identifies the application entry for each of user_ids.
+	// An empty app_id selects the users' default entries.
+	AppId                string   `protobuf:"bytes,9,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *BatchListUserRevisionsRequest) Reset()         { *m = BatchListUserRevisionsRequest{} }
@@ -1182,6 +1292,13 @@ func (m *BatchListUserRevisionsRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+func (m *BatchListUserRevisionsRequest) GetAppId() string {
+	if m != nil {
+		return m.AppId
+	}
+	return ""
+}
+
 // BatchMapRevision contains a set of map leaves at a speific revision.
 type BatchMapRevision struct {
 	// map_root contains the map root and its inclusion in the log.
@@ -1494,6 +1611,68 @@ func (m *GetLatestRevisionRequest) GetLastVerified() *LogRootRequest {
 	return nil
 }
 
+// GetRevisionByTimeRequest identifies the revision that was current at a
+// particular time.
+type GetRevisionByTimeRequest struct {
+	// directory_id is the directory for which revisions are being requested.
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// time selects the latest revision whose map root timestamp is at or before
+	// time.
+	Time *timestamp.Timestamp `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	// last_verified is the last log root the client verified.
+	// Omitting this field will omit the log consistency proof from the response.
+	LastVerified         *LogRootRequest `protobuf:"bytes,3,opt,name=last_verified,json=lastVerified,proto3" json:"last_verified,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *GetRevisionByTimeRequest) Reset()         { *m = GetRevisionByTimeRequest{} }
+func (m *GetRevisionByTimeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRevisionByTimeRequest) ProtoMessage()    {}
+func (*GetRevisionByTimeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_9e925e13aa3e8f7d, []int{25}
+}
+
+func (m *GetRevisionByTimeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRevisionByTimeRequest.Unmarshal(m, b)
+}
+func (m *GetRevisionByTimeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRevisionByTimeRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRevisionByTimeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRevisionByTimeRequest.Merge(m, src)
+}
+func (m *GetRevisionByTimeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRevisionByTimeRequest.Size(m)
+}
+func (m *GetRevisionByTimeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRevisionByTimeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRevisionByTimeRequest proto.InternalMessageInfo
+
+func (m *GetRevisionByTimeRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *GetRevisionByTimeRequest) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func (m *GetRevisionByTimeRequest) GetLastVerified() *LogRootRequest {
+	if m != nil {
+		return m.LastVerified
+	}
+	return nil
+}
+
 // MapRoot contains the map root and its inclusion proof in the log.
 type MapRoot struct {
 	// map_root contains the signed map root for the sparse Merkle Tree.
@@ -1510,7 +1689,7 @@ func (m *MapRoot) Reset()         { *m = MapRoot{} }
 func (m *MapRoot) String() string { return proto.CompactTextString(m) }
 func (*MapRoot) ProtoMessage()    {}
 func (*MapRoot) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{25}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{26}
 }
 
 func (m *MapRoot) XXX_Unmarshal(b []byte) error {
@@ -1560,7 +1739,7 @@ func (m *LogRootRequest) Reset()         { *m = LogRootRequest{} }
 func (m *LogRootRequest) String() string { return proto.CompactTextString(m) }
 func (*LogRootRequest) ProtoMessage()    {}
 func (*LogRootRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{26}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{27}
 }
 
 func (m *LogRootRequest) XXX_Unmarshal(b []byte) error {
@@ -1611,7 +1790,7 @@ func (m *LogRoot) Reset()         { *m = LogRoot{} }
 func (m *LogRoot) String() string { return proto.CompactTextString(m) }
 func (*LogRoot) ProtoMessage()    {}
 func (*LogRoot) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{27}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{28}
 }
 
 func (m *LogRoot) XXX_Unmarshal(b []byte) error {
@@ -1665,7 +1844,7 @@ func (m *Revision) Reset()         { *m = Revision{} }
 func (m *Revision) String() string { return proto.CompactTextString(m) }
 func (*Revision) ProtoMessage()    {}
 func (*Revision) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{28}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{29}
 }
 
 func (m *Revision) XXX_Unmarshal(b []byte) error {
@@ -1729,7 +1908,7 @@ func (m *ListMutationsRequest) Reset()         { *m = ListMutationsRequest{} }
 func (m *ListMutationsRequest) String() string { return proto.CompactTextString(m) }
 func (*ListMutationsRequest) ProtoMessage()    {}
 func (*ListMutationsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{29}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{30}
 }
 
 func (m *ListMutationsRequest) XXX_Unmarshal(b []byte) error {
@@ -1794,7 +1973,7 @@ func (m *ListMutationsResponse) Reset()         { *m = ListMutationsResponse{} }
 func (m *ListMutationsResponse) String() string { return proto.CompactTextString(m) }
 func (*ListMutationsResponse) ProtoMessage()    {}
 func (*ListMutationsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_9e925e13aa3e8f7d, []int{30}
+	return fileDescriptor_9e925e13aa3e8f7d, []int{31}
 }
 
 func (m *ListMutationsResponse) XXX_Unmarshal(b []byte) error {
@@ -1858,6 +2037,7 @@ func init() {
 	proto.RegisterType((*BatchQueueUserUpdateRequest)(nil), "google.keytransparency.v1.BatchQueueUserUpdateRequest")
 	proto.RegisterType((*GetRevisionRequest)(nil), "google.keytransparency.v1.GetRevisionRequest")
 	proto.RegisterType((*GetLatestRevisionRequest)(nil), "google.keytransparency.v1.GetLatestRevisionRequest")
+	proto.RegisterType((*GetRevisionByTimeRequest)(nil), "google.keytransparency.v1.GetRevisionByTimeRequest")
 	proto.RegisterType((*MapRoot)(nil), "google.keytransparency.v1.MapRoot")
 	proto.RegisterType((*LogRootRequest)(nil), "google.keytransparency.v1.LogRootRequest")
 	proto.RegisterType((*LogRoot)(nil), "google.keytransparency.v1.LogRoot")
@@ -1869,131 +2049,139 @@ func init() {
 func init() { proto.RegisterFile("v1/keytransparency.proto", fileDescriptor_9e925e13aa3e8f7d) }
 
 var fileDescriptor_9e925e13aa3e8f7d = []byte{
-	// 1979 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x59, 0xcb, 0x6f, 0x1b, 0xc7,
-	0x19, 0xc7, 0xf0, 0x21, 0x2e, 0x3f, 0xbd, 0xa8, 0xb1, 0x62, 0xd3, 0x74, 0x6d, 0xb8, 0x93, 0xd6,
-	0x75, 0x1c, 0x84, 0x6b, 0xc9, 0x89, 0x23, 0xab, 0x75, 0x9d, 0x4a, 0xb1, 0x6c, 0xc9, 0x52, 0xeb,
-	0xac, 0x92, 0x36, 0xe8, 0x65, 0xb1, 0x12, 0x47, 0xe4, 0xc2, 0xe4, 0xee, 0x7a, 0x67, 0x48, 0x98,
-	0x36, 0x7c, 0xc9, 0xa5, 0x40, 0x5b, 0x14, 0x28, 0xd2, 0x43, 0x4f, 0x3d, 0x04, 0x45, 0x81, 0xa2,
-	0x40, 0x1f, 0xb9, 0xa5, 0x01, 0x8a, 0x16, 0xbd, 0xf5, 0x56, 0xf4, 0xda, 0xa3, 0xff, 0x90, 0x62,
-	0x1e, 0xbb, 0x5c, 0x3e, 0xb4, 0x24, 0x65, 0x19, 0x28, 0x72, 0x92, 0x66, 0xf6, 0xfb, 0xbe, 0xf9,
-	0x7d, 0xef, 0xf9, 0x86, 0x50, 0xee, 0xac, 0x98, 0x8f, 0x68, 0x97, 0x87, 0x8e, 0xc7, 0x02, 0x27,
-	0xa4, 0xde, 0x61, 0xb7, 0x1a, 0x84, 0x3e, 0xf7, 0xf1, 0xf9, 0xba, 0xef, 0xd7, 0x9b, 0xb4, 0x3a,
-	0xf8, 0xb5, 0xb3, 0x52, 0xf9, 0x9a, 0xfa, 0x64, 0x3a, 0x81, 0x6b, 0x3a, 0x9e, 0xe7, 0x73, 0x87,
-	0xbb, 0xbe, 0xc7, 0x14, 0x63, 0xe5, 0x82, 0xfe, 0x2a, 0x57, 0x07, 0xed, 0x23, 0x93, 0xb6, 0x02,
-	0xae, 0xa5, 0x56, 0x16, 0x78, 0xe8, 0x36, 0x9b, 0xae, 0xe3, 0xe9, 0xf5, 0xd9, 0x68, 0x6d, 0xb7,
-	0x9c, 0xc0, 0x76, 0x02, 0x37, 0xa2, 0xeb, 0xac, 0x98, 0x4e, 0xad, 0xe5, 0x6a, 0x3a, 0xb2, 0x02,
-	0xc5, 0x4d, 0xbf, 0xd5, 0x72, 0x39, 0xa7, 0x35, 0x5c, 0x82, 0xec, 0x23, 0xda, 0x2d, 0xa3, 0xcb,
-	0xe8, 0xea, 0x9c, 0x25, 0xfe, 0xc5, 0x18, 0x72, 0x35, 0x87, 0x3b, 0xe5, 0x8c, 0xdc, 0x92, 0xff,
-	0x93, 0x3f, 0x22, 0x98, 0xbd, 0xeb, 0xf1, 0xb0, 0xfb, 0x51, 0x50, 0x73, 0x38, 0xc5, 0xe7, 0xa0,
-	0xd0, 0x66, 0x34, 0xb4, 0xdd, 0x9a, 0xe4, 0x2c, 0x5a, 0x33, 0x62, 0xb9, 0x5d, 0xc3, 0x1b, 0x60,
-	0xb4, 0xda, 0x4a, 0x07, 0x29, 0x60, 0x76, 0xf5, 0x4a, 0xf5, 0x58, 0xe5, 0xab, 0xfb, 0x6e, 0xdd,
-	0xa3, 0x35, 0x29, 0xd8, 0x8a, 0xf9, 0xf0, 0x06, 0x14, 0x0f, 0x23, 0x7c, 0xe5, 0xac, 0x14, 0xf2,
-	0x8d, 0x14, 0x21, 0xb1, 0x2e, 0x56, 0x8f, 0x8d, 0xfc, 0x16, 0x41, 0x5e, 0xca, 0xc5, 0xcb, 0x90,
-	0x77, 0xbd, 0x1a, 0x7d, 0x22, 0x25, 0xcd, 0x59, 0x6a, 0x81, 0x2f, 0x01, 0x28, 0xe2, 0x16, 0xf5,
-	0x78, 0x79, 0x46, 0x7e, 0x4a, 0xec, 0xe0, 0x37, 0x61, 0xc9, 0x69, 0xf3, 0x86, 0x1f, 0xba, 0x4f,
-	0x69, 0xcd, 0x7e, 0x44, 0xbb, 0x8c, 0xf2, 0x72, 0x51, 0x92, 0x95, 0x7a, 0x1f, 0x1e, 0xc8, 0x7d,
-	0x5c, 0x01, 0x23, 0x08, 0x69, 0xc7, 0xf5, 0xdb, 0xac, 0x6c, 0x48, 0x9a, 0x78, 0xbd, 0x93, 0x33,
-	0x50, 0x29, 0xb3, 0x93, 0x33, 0x32, 0xa5, 0xec, 0x4e, 0xce, 0xc8, 0x95, 0xf2, 0x3b, 0x39, 0x23,
-	0x5f, 0x9a, 0xd9, 0xc9, 0x19, 0x85, 0x92, 0x41, 0x36, 0x61, 0x36, 0x61, 0x03, 0x81, 0x95, 0x8a,
-	0x7f, 0xb4, 0x3b, 0xd4, 0x42, 0x60, 0x65, 0x6e, 0xdd, 0x73, 0x78, 0x3b, 0xa4, 0xac, 0x9c, 0xb9,
-	0x9c, 0x15, 0x58, 0x7b, 0x3b, 0xe4, 0x17, 0x08, 0xe6, 0xf7, 0xb4, 0xf1, 0x1e, 0x86, 0xbe, 0x7f,
-	0xd4, 0xe7, 0x05, 0x74, 0x42, 0x2f, 0xdc, 0x02, 0x68, 0x52, 0xe7, 0xc8, 0x0e, 0x84, 0x44, 0xed,
-	0xcb, 0x4a, 0x35, 0x0e, 0xb9, 0x3d, 0x27, 0xd8, 0xa5, 0xce, 0xd1, 0xb6, 0x77, 0xd8, 0x6c, 0x33,
-	0xd7, 0xf7, 0xac, 0xa2, 0xa0, 0x96, 0xc7, 0x93, 0x1f, 0xc0, 0xc2, 0x9e, 0x13, 0x04, 0x34, 0xdc,
-	0xa3, 0xdc, 0x11, 0xf1, 0x83, 0x6f, 0xc3, 0x85, 0x86, 0x5b, 0x6f, 0x50, 0xc6, 0xed, 0xa3, 0x76,
-	0xb3, 0xd9, 0xb5, 0x0f, 0xfd, 0x56, 0xd0, 0xa4, 0x9c, 0xd6, 0x6c, 0x46, 0x1f, 0x4b, 0x8c, 0x59,
-	0xab, 0xac, 0x49, 0xb6, 0x04, 0xc5, 0x66, 0x44, 0xb0, 0x4f, 0x1f, 0x93, 0xcf, 0x10, 0x2c, 0xdc,
-	0xa3, 0xfc, 0x23, 0x46, 0x43, 0x8b, 0x3e, 0x6e, 0x53, 0xc6, 0xf1, 0xd7, 0x61, 0xae, 0xe6, 0x86,
-	0xf4, 0x90, 0xfb, 0x61, 0xb7, 0x17, 0x86, 0xb3, 0xf1, 0xde, 0x76, 0x2d, 0x19, 0xa4, 0x99, 0xbe,
-	0x20, 0xfd, 0x3e, 0xcc, 0x37, 0x1d, 0xc6, 0xed, 0x0e, 0x0d, 0xdd, 0x23, 0x97, 0xd6, 0xca, 0x39,
-	0xa9, 0xdd, 0x1b, 0x29, 0x36, 0xda, 0xf5, 0xeb, 0x96, 0xef, 0x73, 0x7d, 0xba, 0x35, 0x27, 0xf8,
-	0x7f, 0xa8, 0xd9, 0x77, 0x72, 0x46, 0xb6, 0x94, 0x23, 0x7f, 0x40, 0x50, 0xd0, 0x56, 0xc1, 0x17,
-	0xa0, 0xd8, 0x09, 0x23, 0xdb, 0x29, 0x67, 0x1a, 0x9d, 0x50, 0x99, 0x07, 0xdf, 0x81, 0x79, 0x91,
-	0xa0, 0x6e, 0x64, 0xba, 0x09, 0x8c, 0x3b, 0xd7, 0x72, 0x82, 0x78, 0x75, 0x2a, 0x09, 0xf2, 0x53,
-	0x04, 0x8b, 0xb1, 0x49, 0x59, 0xe0, 0x7b, 0x8c, 0xe2, 0x3b, 0x60, 0x88, 0xb0, 0x65, 0xbd, 0xb0,
-	0x79, 0x3d, 0x45, 0xac, 0xa5, 0x49, 0xad, 0x98, 0x09, 0xdf, 0x84, 0x9c, 0x88, 0x02, 0xad, 0x10,
-	0x49, 0x61, 0xd6, 0x1a, 0x5a, 0x92, 0x9e, 0xfc, 0x1e, 0xc1, 0x99, 0x0d, 0x87, 0x1f, 0x36, 0xa6,
-	0x77, 0xf2, 0x79, 0x30, 0xb4, 0x93, 0x55, 0x6a, 0x14, 0xad, 0x82, 0xf2, 0x32, 0x7b, 0x45, 0x6e,
-	0xfe, 0x18, 0xca, 0x49, 0xa8, 0xdb, 0xa2, 0x9c, 0x9c, 0x0a, 0x5e, 0xf2, 0x67, 0x04, 0xe7, 0x47,
-	0x88, 0xd6, 0xce, 0xf9, 0x18, 0x66, 0x64, 0x38, 0xb1, 0x32, 0xba, 0x9c, 0xbd, 0x3a, 0xbb, 0xfa,
-	0x5e, 0x8a, 0x1a, 0xc7, 0x4a, 0xa9, 0xca, 0x08, 0x64, 0x2a, 0xd7, 0xb5, 0xbc, 0xca, 0x2d, 0x98,
-	0x4d, 0x6c, 0x27, 0x3b, 0x42, 0x51, 0x75, 0x84, 0x65, 0xc8, 0x77, 0x9c, 0x66, 0x9b, 0xea, 0x96,
-	0xa0, 0x16, 0xeb, 0x99, 0x35, 0x44, 0xbe, 0xcc, 0xc0, 0x72, 0xbf, 0xe3, 0x4e, 0x2b, 0x94, 0x9e,
-	0xc0, 0x6b, 0x22, 0x49, 0x9a, 0xd4, 0xe9, 0x50, 0x66, 0x1f, 0x74, 0xed, 0x5e, 0x2a, 0x0b, 0xed,
-	0xb7, 0x26, 0xd4, 0x3e, 0x56, 0x5c, 0x05, 0x5c, 0x87, 0xb2, 0x8d, 0xae, 0xb4, 0x8a, 0xae, 0x77,
-	0x4b, 0xad, 0xc1, 0xfd, 0x4a, 0x03, 0xce, 0x8e, 0x26, 0x1e, 0x61, 0x99, 0xb5, 0xa4, 0x65, 0x26,
-	0x8b, 0xf8, 0x84, 0xf5, 0x5e, 0x20, 0x38, 0xb7, 0xeb, 0x32, 0x2e, 0xa5, 0xdf, 0x77, 0x99, 0x08,
-	0x91, 0xe3, 0x42, 0x69, 0x26, 0xb5, 0xbe, 0xf5, 0x37, 0xe1, 0x65, 0xc8, 0x33, 0xee, 0x84, 0x5c,
-	0xa2, 0xca, 0x5a, 0x6a, 0x21, 0x6a, 0x52, 0xe0, 0xd4, 0xa9, 0xcd, 0xdc, 0xa7, 0x54, 0x56, 0x8d,
-	0xbc, 0x65, 0x88, 0x8d, 0x7d, 0xf7, 0x29, 0x1d, 0xce, 0x95, 0xc2, 0xcb, 0xe6, 0x4a, 0xdc, 0xea,
-	0xc8, 0x73, 0x28, 0x0f, 0x6b, 0xa9, 0xe3, 0x64, 0x03, 0x66, 0xa4, 0x3d, 0xa2, 0xa8, 0xbe, 0x96,
-	0x72, 0xe0, 0x80, 0x4b, 0x2d, 0xcd, 0x89, 0x2f, 0x02, 0x78, 0xf4, 0x09, 0xb7, 0x93, 0x3a, 0x17,
-	0xc5, 0xce, 0xbe, 0xd8, 0x20, 0x9f, 0x67, 0xd4, 0xf9, 0x8a, 0x57, 0x85, 0x17, 0x3b, 0x8d, 0x36,
-	0xf2, 0x4d, 0x58, 0x90, 0x47, 0xda, 0x71, 0xa4, 0x67, 0xe5, 0xd9, 0xf3, 0x72, 0x37, 0x3a, 0x4a,
-	0x1c, 0x41, 0xbd, 0x5a, 0x8f, 0x28, 0x27, 0x89, 0x66, 0xa9, 0x57, 0x8b, 0x49, 0xfa, 0x5c, 0x93,
-	0x1f, 0x70, 0xcd, 0x45, 0x00, 0xf9, 0x91, 0xfb, 0x8f, 0xa8, 0xa7, 0xe3, 0x40, 0x92, 0x7f, 0x28,
-	0x36, 0x86, 0x3d, 0x67, 0xbc, 0xac, 0xe7, 0xc4, 0xc5, 0xe4, 0x67, 0x08, 0x66, 0xf7, 0x9c, 0x20,
-	0x46, 0x78, 0x1b, 0x0c, 0x91, 0x8e, 0xa1, 0xef, 0x73, 0x9d, 0xcf, 0x63, 0x62, 0x5d, 0x1e, 0x50,
-	0x68, 0xa9, 0x7f, 0x22, 0xf6, 0x29, 0x9b, 0x43, 0x41, 0x25, 0xe7, 0x11, 0xf9, 0x2f, 0x82, 0xf3,
-	0x23, 0x5c, 0xa8, 0x63, 0x68, 0x07, 0x16, 0x9b, 0x0e, 0x17, 0x77, 0x8b, 0xa6, 0x5f, 0x9f, 0x14,
-	0x62, 0x64, 0x83, 0x79, 0xc5, 0xaa, 0x97, 0xf8, 0x81, 0xea, 0xcd, 0x91, 0xb3, 0x98, 0x2e, 0x37,
-	0x57, 0xc6, 0x28, 0x1b, 0xd5, 0x2f, 0xd1, 0xa7, 0x63, 0x80, 0xf8, 0x0a, 0x2c, 0xca, 0xc0, 0x4c,
-	0xb8, 0x2f, 0x2b, 0xdd, 0x37, 0x2f, 0xb6, 0x1f, 0x46, 0x2e, 0x24, 0x5f, 0x64, 0xe0, 0xa2, 0x2c,
-	0x5a, 0x2f, 0x13, 0xa6, 0x29, 0x8d, 0xf0, 0xab, 0x1c, 0xa8, 0x7f, 0xc9, 0x40, 0x49, 0xda, 0xee,
-	0x14, 0xa3, 0x95, 0xa7, 0xf7, 0x9e, 0x8d, 0x71, 0xbd, 0x27, 0x01, 0xe5, 0xff, 0xb2, 0xef, 0xfc,
-	0x0d, 0xc1, 0xa5, 0xe3, 0xe2, 0xed, 0x15, 0xe4, 0xd4, 0xc3, 0xd1, 0x39, 0xf5, 0xe6, 0x14, 0x66,
-	0xec, 0x4f, 0x2c, 0xf2, 0x2b, 0x04, 0x58, 0x4d, 0xa2, 0xca, 0x9a, 0xc7, 0x64, 0x49, 0x7e, 0x38,
-	0x4b, 0xb6, 0x45, 0x8c, 0xf3, 0xb0, 0x6b, 0xb7, 0x25, 0xbb, 0xbe, 0x12, 0xa6, 0xa5, 0x77, 0x62,
-	0xec, 0x15, 0xb9, 0x10, 0x2f, 0x06, 0x26, 0x3b, 0x71, 0x35, 0xfc, 0x04, 0xc1, 0x05, 0x89, 0xfc,
-	0x83, 0x36, 0x6d, 0x53, 0x61, 0x58, 0xcd, 0x37, 0x79, 0x16, 0xbf, 0x07, 0x05, 0x85, 0x6c, 0x92,
-	0xca, 0x93, 0x84, 0x16, 0xb1, 0x91, 0x3f, 0x21, 0xc0, 0xf7, 0x68, 0x9c, 0xd5, 0x53, 0xd8, 0xa6,
-	0x32, 0x70, 0x67, 0xcb, 0x26, 0xae, 0x63, 0xa7, 0x7f, 0x97, 0xee, 0x99, 0xed, 0x53, 0x04, 0xe5,
-	0x7b, 0x94, 0xef, 0xca, 0xa0, 0x19, 0x87, 0x7b, 0x84, 0xcd, 0x86, 0xb0, 0x65, 0x4f, 0x01, 0x1b,
-	0x39, 0x90, 0xd3, 0x9c, 0x0c, 0xe0, 0xd5, 0xa1, 0x72, 0x72, 0xae, 0x37, 0xab, 0xa9, 0xe9, 0x79,
-	0xa8, 0x86, 0xbc, 0x0e, 0xf3, 0x22, 0x73, 0x92, 0x43, 0x9e, 0x98, 0xdb, 0xe7, 0x9a, 0x7e, 0x3d,
-	0x1e, 0xe4, 0xc8, 0x0e, 0x2c, 0xf4, 0x23, 0x11, 0x05, 0x56, 0x1c, 0x63, 0x37, 0x1c, 0xd6, 0x88,
-	0x06, 0x47, 0xb1, 0x71, 0xdf, 0x61, 0x0d, 0xf1, 0x91, 0x87, 0x54, 0x57, 0x5f, 0x75, 0xcf, 0x31,
-	0xc4, 0x86, 0xa8, 0xbe, 0xe4, 0x08, 0x0a, 0x51, 0xc2, 0xad, 0x82, 0x31, 0x90, 0xb5, 0x43, 0x78,
-	0xa3, 0x63, 0x0b, 0x4d, 0xcd, 0xf3, 0x2d, 0x58, 0x14, 0x3c, 0x87, 0xbe, 0xc7, 0x5c, 0xc6, 0x85,
-	0x9d, 0x34, 0xe2, 0x85, 0xa6, 0x5f, 0xdf, 0xec, 0xed, 0x92, 0x7f, 0x21, 0x30, 0x92, 0x2d, 0x63,
-	0x9c, 0x77, 0x92, 0xb5, 0x38, 0x3f, 0x7d, 0x2d, 0x1e, 0x51, 0x88, 0x66, 0x4e, 0x58, 0x88, 0x92,
-	0x41, 0xa7, 0x2e, 0xa8, 0xe4, 0x97, 0x08, 0x96, 0x45, 0x11, 0x8c, 0x9e, 0x4f, 0xd8, 0x29, 0xa5,
-	0x4b, 0x7f, 0x2b, 0xcc, 0x0e, 0xb6, 0xc2, 0xbe, 0x36, 0x9a, 0xeb, 0x6f, 0xa3, 0xe4, 0x27, 0x08,
-	0x5e, 0x1b, 0xc0, 0xa4, 0x8b, 0xf2, 0x16, 0x14, 0xa3, 0xe7, 0x19, 0x56, 0x9e, 0x91, 0xe5, 0xe1,
-	0x6a, 0x9a, 0x2d, 0x93, 0x6f, 0x42, 0x56, 0x8f, 0x75, 0xd4, 0xbd, 0xa4, 0x30, 0xe2, 0x5e, 0xb2,
-	0xfa, 0x8f, 0x33, 0xb0, 0xf8, 0x80, 0x76, 0x3f, 0x4c, 0xc8, 0xc5, 0x3f, 0x47, 0x30, 0x77, 0x8f,
-	0xf2, 0xf7, 0x23, 0x43, 0xe0, 0x6a, 0xfa, 0x8d, 0x3d, 0x26, 0xd4, 0x96, 0xad, 0xa4, 0xbd, 0x54,
-	0xc4, 0xc4, 0xe4, 0xca, 0x27, 0xff, 0x79, 0xf1, 0x69, 0xe6, 0x32, 0xbe, 0x64, 0x76, 0x56, 0xcc,
-	0xc8, 0xea, 0x2e, 0x65, 0xe6, 0xb3, 0xa4, 0x5b, 0x9e, 0xe3, 0xdf, 0x20, 0x98, 0x4d, 0x54, 0x3b,
-	0xfc, 0x56, 0x3a, 0x9a, 0x81, 0xea, 0x52, 0x99, 0x64, 0x28, 0x25, 0xdf, 0x96, 0x58, 0xde, 0xc1,
-	0x37, 0xd2, 0xb1, 0x98, 0x71, 0x83, 0x33, 0x9f, 0x45, 0xff, 0x3e, 0xc7, 0xbf, 0x43, 0xb0, 0x34,
-	0x54, 0xdc, 0xf0, 0x8d, 0x74, 0x98, 0x23, 0x4b, 0xe1, 0x64, 0x60, 0xdf, 0x95, 0x60, 0x57, 0xb0,
-	0x39, 0x29, 0xd8, 0x75, 0x95, 0x23, 0xf8, 0x33, 0x05, 0x34, 0x12, 0xb4, 0xcf, 0x43, 0xea, 0xb4,
-	0x5e, 0x89, 0x3d, 0xa7, 0x87, 0xc8, 0x24, 0x98, 0xeb, 0x08, 0x7f, 0x81, 0x60, 0xbe, 0x2f, 0x37,
-	0xb0, 0x99, 0x56, 0x06, 0x46, 0x64, 0x76, 0xe5, 0xfa, 0xe4, 0x0c, 0x2a, 0xed, 0xc8, 0x5d, 0x89,
-	0xf7, 0x0e, 0xbe, 0x7d, 0x02, 0xff, 0x9b, 0xbd, 0xac, 0xfb, 0x3b, 0x82, 0x33, 0x7d, 0x07, 0x68,
-	0x13, 0x4f, 0xad, 0xc1, 0xc4, 0x39, 0x4f, 0x76, 0x25, 0xf2, 0x2d, 0xfc, 0xfe, 0x4b, 0x21, 0xef,
-	0x99, 0xff, 0xd7, 0x08, 0x0a, 0x7a, 0x0a, 0xc7, 0x6f, 0x4c, 0x32, 0xa9, 0x2b, 0xc0, 0x53, 0x0c,
-	0xf5, 0xe4, 0xa6, 0x84, 0x7c, 0x1d, 0x57, 0xc7, 0x40, 0x16, 0x57, 0x72, 0x66, 0x3e, 0xd3, 0x37,
-	0x73, 0x99, 0x67, 0x73, 0xc9, 0x87, 0x9f, 0xd4, 0xba, 0x34, 0xe2, 0xad, 0xb1, 0x62, 0x4e, 0xf9,
-	0xa2, 0x44, 0xde, 0x91, 0x48, 0x4d, 0xfc, 0xd6, 0x24, 0x48, 0xd7, 0x0f, 0xb4, 0x08, 0xfc, 0x57,
-	0x04, 0x4b, 0x43, 0xef, 0x73, 0xa9, 0x05, 0xe1, 0xb8, 0xe7, 0xc6, 0xca, 0xdb, 0x27, 0x79, 0x02,
-	0x24, 0xeb, 0x12, 0xf7, 0xdb, 0x78, 0x75, 0x2a, 0xdc, 0x0a, 0xe6, 0x97, 0x08, 0x4a, 0x83, 0x6f,
-	0x39, 0x78, 0x75, 0x4c, 0x00, 0x8f, 0x78, 0xde, 0xaa, 0xdc, 0x98, 0x8a, 0x47, 0x23, 0xff, 0xae,
-	0x44, 0xbe, 0x86, 0x6f, 0x4e, 0x17, 0x1b, 0x66, 0x43, 0x03, 0xfd, 0x27, 0x82, 0xa5, 0xa1, 0x91,
-	0x07, 0x8f, 0x83, 0x32, 0x6a, 0x20, 0x4f, 0x35, 0xfd, 0xb1, 0x53, 0x15, 0xd9, 0x94, 0x0a, 0xdc,
-	0x26, 0x6b, 0x53, 0x2a, 0xd0, 0xab, 0x84, 0xe8, 0x1a, 0xfe, 0x37, 0x82, 0xb3, 0xa3, 0xa7, 0x37,
-	0xbc, 0x36, 0x2e, 0x20, 0x8e, 0xd5, 0xe7, 0xd6, 0x09, 0x38, 0xb5, 0x52, 0x1b, 0x52, 0xa9, 0xef,
-	0x90, 0x77, 0x27, 0x8f, 0x27, 0x21, 0xcc, 0x4a, 0xea, 0xf4, 0x39, 0x82, 0x92, 0x1c, 0x9a, 0x92,
-	0x3f, 0x32, 0xa6, 0xf5, 0x9e, 0xe1, 0xe9, 0xaf, 0x72, 0x36, 0x22, 0x8f, 0x7e, 0x2c, 0xad, 0xde,
-	0x6d, 0x05, 0xbc, 0x4b, 0x7e, 0x24, 0xf1, 0x7d, 0x40, 0xbe, 0x37, 0x99, 0xd1, 0x93, 0xe3, 0x61,
-	0x35, 0xf2, 0xc0, 0xfa, 0x63, 0x01, 0x6e, 0xbd, 0x6f, 0x76, 0x14, 0x1d, 0x73, 0x79, 0xd4, 0xb8,
-	0x87, 0x6f, 0x8e, 0x33, 0xe6, 0xe8, 0xf9, 0xf0, 0x58, 0x0d, 0x74, 0xc6, 0x92, 0x31, 0x0d, 0x53,
-	0xd9, 0x56, 0xc9, 0x96, 0x72, 0xd7, 0xd1, 0xb5, 0x8d, 0xfb, 0x3f, 0xde, 0xaa, 0xbb, 0xbc, 0xd1,
-	0x3e, 0xa8, 0x1e, 0xfa, 0x2d, 0x53, 0xff, 0x9c, 0x3c, 0x80, 0xcb, 0x3c, 0xf4, 0x43, 0xf5, 0x0b,
-	0xf4, 0xf0, 0x2f, 0xd8, 0x76, 0xdd, 0xb7, 0x15, 0x9c, 0x19, 0xf9, 0xe7, 0xc6, 0xff, 0x02, 0x00,
-	0x00, 0xff, 0xff, 0x99, 0x80, 0x04, 0x31, 0xe7, 0x1e, 0x00, 0x00,
+	// 2106 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe4, 0x5a, 0xcd, 0x8f, 0x1b, 0x49,
+	0x15, 0x57, 0xf9, 0xb3, 0xfd, 0x3c, 0x33, 0xf1, 0xd4, 0x4e, 0x12, 0xc7, 0x21, 0x61, 0xa8, 0x85,
+	0x90, 0xcd, 0x6a, 0xdd, 0x99, 0x49, 0x36, 0x3b, 0x19, 0x08, 0x59, 0x66, 0x36, 0x93, 0xcc, 0x64,
+	0x06, 0xb2, 0x3d, 0x59, 0x40, 0x5c, 0xac, 0x1e, 0xbb, 0xc6, 0x6e, 0xc5, 0xee, 0xee, 0x74, 0x97,
+	0xad, 0x38, 0x51, 0x2e, 0x7b, 0x41, 0x02, 0x84, 0x84, 0x96, 0x03, 0x27, 0x0e, 0x1c, 0xb8, 0x70,
+	0xe0, 0xe3, 0x06, 0x2b, 0x56, 0x48, 0x9c, 0xe0, 0x04, 0x42, 0xdc, 0x38, 0xee, 0x89, 0x7f, 0x81,
+	0x0b, 0xaa, 0x8f, 0x6e, 0xb7, 0xed, 0x9e, 0xb6, 0x3d, 0xeb, 0x48, 0x2b, 0x71, 0x8a, 0xab, 0xba,
+	0x3e, 0x7e, 0xef, 0xbd, 0xdf, 0x7b, 0xaf, 0xde, 0xcb, 0x40, 0xb9, 0xb7, 0xa6, 0x3f, 0xa1, 0x7d,
+	0xe6, 0x99, 0xb6, 0xef, 0x9a, 0x1e, 0xb5, 0xeb, 0xfd, 0xaa, 0xeb, 0x39, 0xcc, 0xc1, 0x17, 0x9a,
+	0x8e, 0xd3, 0x6c, 0xd3, 0xea, 0xe8, 0xd7, 0xde, 0x5a, 0xe5, 0x0b, 0xf2, 0x93, 0x6e, 0xba, 0x96,
+	0x6e, 0xda, 0xb6, 0xc3, 0x4c, 0x66, 0x39, 0xb6, 0x2f, 0x37, 0x56, 0x2e, 0xaa, 0xaf, 0x62, 0x74,
+	0xd4, 0x3d, 0xd6, 0x69, 0xc7, 0x65, 0xea, 0xd4, 0xca, 0x17, 0x47, 0x3f, 0x32, 0xab, 0x43, 0x7d,
+	0x66, 0x76, 0x5c, 0xb5, 0x60, 0x89, 0x79, 0x56, 0xbb, 0x6d, 0x99, 0xb6, 0x1a, 0x9f, 0x0b, 0xc6,
+	0xb5, 0x8e, 0xe9, 0xd6, 0x4c, 0xd7, 0x0a, 0xd6, 0xf5, 0xd6, 0x74, 0xb3, 0xd1, 0xb1, 0xd4, 0x3a,
+	0xb2, 0x06, 0x85, 0x6d, 0xa7, 0xd3, 0xb1, 0x18, 0xa3, 0x0d, 0x5c, 0x82, 0xf4, 0x13, 0xda, 0x2f,
+	0xa3, 0x55, 0x74, 0x75, 0xc1, 0xe0, 0x3f, 0x31, 0x86, 0x4c, 0xc3, 0x64, 0x66, 0x39, 0x25, 0xa6,
+	0xc4, 0x6f, 0xf2, 0x29, 0x82, 0xe2, 0x3d, 0x9b, 0x79, 0xfd, 0x0f, 0xdc, 0x86, 0xc9, 0x28, 0x3e,
+	0x0f, 0xf9, 0xae, 0x4f, 0xbd, 0x9a, 0xd5, 0x10, 0x3b, 0x0b, 0x46, 0x8e, 0x0f, 0x77, 0x1b, 0x78,
+	0x0b, 0xb4, 0x4e, 0x57, 0x0a, 0x29, 0x0e, 0x28, 0xae, 0x5f, 0xa9, 0x9e, 0xa8, 0x9d, 0xea, 0xa1,
+	0xd5, 0xb4, 0x69, 0x43, 0x1c, 0x6c, 0x84, 0xfb, 0xf0, 0x16, 0x14, 0xea, 0x01, 0xbe, 0x72, 0x5a,
+	0x1c, 0xf2, 0xe5, 0x84, 0x43, 0x42, 0x59, 0x8c, 0xc1, 0x36, 0x7c, 0x16, 0x72, 0xa6, 0xeb, 0x72,
+	0x7c, 0x19, 0x81, 0x2f, 0x6b, 0xba, 0xee, 0x6e, 0x03, 0x5f, 0x02, 0xf0, 0xe8, 0xd3, 0x2e, 0xf5,
+	0x19, 0xff, 0x94, 0x15, 0x9f, 0x0a, 0x6a, 0x66, 0xb7, 0x41, 0x3e, 0x41, 0x90, 0x15, 0x68, 0xf0,
+	0x0a, 0x64, 0x2d, 0xbb, 0x41, 0x9f, 0x89, 0xfb, 0x17, 0x0c, 0x39, 0xc0, 0x97, 0x01, 0xe4, 0x15,
+	0x1d, 0x6a, 0xb3, 0x72, 0x4e, 0x7c, 0x8a, 0xcc, 0xe0, 0x37, 0x61, 0xd9, 0xec, 0xb2, 0x96, 0xe3,
+	0x59, 0xcf, 0x69, 0xa3, 0xf6, 0x84, 0xf6, 0x7d, 0xca, 0xca, 0x05, 0xb1, 0xac, 0x34, 0xf8, 0xf0,
+	0x50, 0xcc, 0xe3, 0x0a, 0x68, 0xae, 0x47, 0x7b, 0x96, 0xd3, 0xf5, 0xcb, 0x9a, 0x58, 0x13, 0x8e,
+	0x71, 0x19, 0xf2, 0x0d, 0xda, 0xa6, 0x5c, 0x01, 0xb0, 0x8a, 0xae, 0x6a, 0x46, 0x30, 0xdc, 0xcb,
+	0x68, 0xa8, 0x94, 0xda, 0xcb, 0x68, 0xa9, 0x52, 0x7a, 0x2f, 0xa3, 0x65, 0x4a, 0xd9, 0xbd, 0x8c,
+	0x96, 0x2d, 0xe5, 0xf6, 0x32, 0x5a, 0xbe, 0xa4, 0x91, 0x6d, 0x28, 0x46, 0x74, 0xca, 0xa5, 0xa0,
+	0xfc, 0x87, 0x32, 0xaf, 0x1c, 0x70, 0x29, 0x7c, 0xab, 0x69, 0x9b, 0xac, 0xeb, 0x51, 0xbf, 0x9c,
+	0x5a, 0x4d, 0x73, 0x29, 0x06, 0x33, 0xe4, 0x27, 0x08, 0x16, 0x0f, 0x94, 0x31, 0x1e, 0x79, 0x8e,
+	0x73, 0x3c, 0x64, 0x55, 0x74, 0x4a, 0xab, 0xde, 0x06, 0x68, 0x53, 0xf3, 0xb8, 0xe6, 0xf2, 0x13,
+	0x15, 0x37, 0x2a, 0xd5, 0x90, 0xc2, 0x07, 0xa6, 0xbb, 0x4f, 0xcd, 0xe3, 0x5d, 0xbb, 0xde, 0xee,
+	0xfa, 0x96, 0x63, 0x1b, 0x05, 0xbe, 0x5a, 0x5c, 0x4f, 0xbe, 0x0d, 0x4b, 0x07, 0xa6, 0xeb, 0x52,
+	0xef, 0x80, 0x32, 0x93, 0xf3, 0x11, 0xdf, 0x81, 0x8b, 0x2d, 0xab, 0xd9, 0xe2, 0x76, 0x3c, 0xee,
+	0xb6, 0xdb, 0xfd, 0x5a, 0xdd, 0xe9, 0xb8, 0x42, 0x41, 0x35, 0x9f, 0x3e, 0x15, 0x18, 0xd3, 0x46,
+	0x59, 0x2d, 0xd9, 0xe1, 0x2b, 0xb6, 0x83, 0x05, 0x87, 0xf4, 0x29, 0xf9, 0x17, 0x82, 0xa5, 0xfb,
+	0x94, 0x7d, 0xe0, 0x53, 0xcf, 0x90, 0xc6, 0xc7, 0x5f, 0x82, 0x85, 0x86, 0xe5, 0xd1, 0x3a, 0x73,
+	0xbc, 0xfe, 0x80, 0xd6, 0xc5, 0x70, 0x6e, 0xb7, 0x11, 0x25, 0x7d, 0x6a, 0x88, 0xf4, 0xdf, 0x82,
+	0xc5, 0xb6, 0xe9, 0xb3, 0x5a, 0x8f, 0x7a, 0xd6, 0xb1, 0x45, 0x25, 0xe7, 0x8a, 0xeb, 0x6f, 0x24,
+	0xe8, 0x68, 0xdf, 0x69, 0x1a, 0x8e, 0xc3, 0xd4, 0xed, 0xc6, 0x02, 0xdf, 0xff, 0x1d, 0xb5, 0x3d,
+	0x42, 0xde, 0x6c, 0x94, 0xbc, 0x15, 0xd0, 0x38, 0x3f, 0xb8, 0x76, 0x04, 0xf7, 0xd2, 0x46, 0x38,
+	0xde, 0xcb, 0x68, 0xe9, 0x52, 0x86, 0xfc, 0x1a, 0x41, 0x5e, 0x29, 0x12, 0x5f, 0x84, 0x42, 0xcf,
+	0x0b, 0xd4, 0x2d, 0xed, 0xaf, 0xf5, 0x3c, 0xa9, 0x51, 0x7c, 0x17, 0x16, 0x79, 0x8c, 0xb0, 0x02,
+	0x6d, 0x4f, 0x61, 0x8f, 0x85, 0x8e, 0xe9, 0x86, 0xa3, 0x79, 0xf8, 0x28, 0xf9, 0x21, 0x82, 0x33,
+	0xa1, 0x15, 0x7c, 0xd7, 0xb1, 0x7d, 0x8a, 0xef, 0x46, 0x64, 0x94, 0x4c, 0x7b, 0x3d, 0xe1, 0x58,
+	0x43, 0x2d, 0x1d, 0x28, 0x02, 0xdf, 0x82, 0x0c, 0x27, 0x8e, 0x12, 0x88, 0x24, 0x6c, 0x56, 0x12,
+	0x1a, 0x62, 0x3d, 0xf9, 0x37, 0x82, 0xd7, 0xb6, 0x4c, 0x56, 0x6f, 0xcd, 0xce, 0x8b, 0x0b, 0xa0,
+	0x29, 0x5e, 0x48, 0x6f, 0x2a, 0x18, 0x79, 0x49, 0x0c, 0xff, 0xf3, 0xc3, 0x0c, 0x07, 0xca, 0x51,
+	0xe9, 0x76, 0x79, 0x38, 0x9b, 0x8f, 0x88, 0x03, 0x48, 0xe9, 0x08, 0x24, 0xf2, 0x5b, 0x04, 0x17,
+	0x62, 0x6e, 0x54, 0x66, 0xfe, 0x1e, 0xe4, 0x04, 0x31, 0xfd, 0x32, 0x5a, 0x4d, 0x5f, 0x2d, 0xae,
+	0xbf, 0x9b, 0xa0, 0x90, 0x13, 0x4f, 0xa9, 0x0a, 0x2e, 0xfb, 0x32, 0xd0, 0xa8, 0xf3, 0x2a, 0xb7,
+	0xa1, 0x18, 0x99, 0x8e, 0xa6, 0xb7, 0x82, 0x4c, 0x6f, 0x2b, 0x90, 0xed, 0x99, 0xed, 0x2e, 0x55,
+	0xf9, 0x4d, 0x0e, 0x36, 0x53, 0x1b, 0x88, 0x7c, 0x9c, 0x82, 0x95, 0x61, 0x0a, 0xcc, 0x8b, 0x94,
+	0xcf, 0xe0, 0x2c, 0x77, 0xb7, 0x36, 0x35, 0x7b, 0xd4, 0xaf, 0x1d, 0xf5, 0x6b, 0x83, 0x38, 0xc2,
+	0xa5, 0xdf, 0x99, 0x52, 0xfa, 0x50, 0x70, 0x49, 0xdd, 0x1e, 0xf5, 0xb7, 0xfa, 0x42, 0x2b, 0x2a,
+	0xd8, 0x2e, 0x77, 0x46, 0xe7, 0x2b, 0x2d, 0x38, 0x17, 0xbf, 0x38, 0x46, 0x33, 0x1b, 0x51, 0xcd,
+	0x4c, 0xe7, 0x3b, 0x11, 0xed, 0xfd, 0x17, 0xc1, 0xf9, 0x7d, 0xcb, 0x67, 0xe2, 0xf4, 0x07, 0x96,
+	0xcf, 0x99, 0x73, 0x12, 0xc3, 0x72, 0x89, 0xc1, 0x75, 0xf8, 0x45, 0xb1, 0x02, 0x59, 0x9f, 0x99,
+	0x1e, 0x13, 0xa8, 0xd2, 0x86, 0x1c, 0xf0, 0xe8, 0xe6, 0x9a, 0x4d, 0x5a, 0xf3, 0xad, 0xe7, 0x54,
+	0x10, 0x2f, 0x6b, 0x68, 0x7c, 0xe2, 0xd0, 0x7a, 0x4e, 0xc7, 0xbd, 0x2e, 0x3f, 0x2f, 0xaf, 0xd3,
+	0x22, 0x14, 0x8f, 0xa6, 0x5f, 0xf2, 0x12, 0xca, 0xe3, 0xc2, 0x2b, 0xfa, 0x6c, 0x41, 0x4e, 0xa8,
+	0x29, 0x20, 0xfb, 0xb5, 0x04, 0x1c, 0x23, 0x96, 0x36, 0xd4, 0x4e, 0xfe, 0x70, 0xb1, 0xe9, 0x33,
+	0x56, 0x8b, 0xaa, 0xa2, 0xc0, 0x67, 0x0e, 0xf9, 0x04, 0xf9, 0x6b, 0x4a, 0xde, 0x2f, 0xf7, 0x4a,
+	0xd6, 0xf9, 0xf3, 0x48, 0x6d, 0x5f, 0x81, 0x25, 0x71, 0x65, 0x2d, 0x74, 0x80, 0xb4, 0xb8, 0x7b,
+	0x51, 0xcc, 0x06, 0x57, 0xf1, 0x2b, 0xa8, 0xdd, 0x18, 0x2c, 0xca, 0x88, 0x45, 0x45, 0x6a, 0x37,
+	0xc2, 0x25, 0x43, 0x16, 0xcb, 0x8e, 0x58, 0xec, 0x12, 0x80, 0xf8, 0xc8, 0x9c, 0x27, 0xd4, 0x56,
+	0xf4, 0x10, 0xcb, 0x1f, 0xf3, 0x89, 0x71, 0x83, 0x6a, 0xf3, 0x32, 0x68, 0x61, 0xd8, 0xa0, 0xfc,
+	0x0d, 0xf5, 0x23, 0x04, 0xc5, 0x03, 0xd3, 0x0d, 0x81, 0xdf, 0x01, 0x8d, 0x3b, 0xaf, 0xe7, 0x38,
+	0xac, 0x8c, 0xa6, 0xf1, 0x0c, 0x71, 0x6f, 0xbe, 0x23, 0x7f, 0x04, 0xdb, 0x67, 0x4c, 0x4a, 0x79,
+	0xe9, 0xca, 0x22, 0x2f, 0x5d, 0x88, 0xb1, 0xac, 0xa2, 0xd6, 0x1e, 0x9c, 0x69, 0x9b, 0x8c, 0x3f,
+	0x83, 0xda, 0x4e, 0x73, 0x5a, 0x88, 0x81, 0x6a, 0x16, 0xe5, 0x56, 0x35, 0xc4, 0x0f, 0xe5, 0x9b,
+	0x20, 0xb0, 0xa1, 0xaf, 0x82, 0xd3, 0x95, 0x09, 0xc2, 0xaa, 0xe5, 0xe2, 0x7d, 0x10, 0x0c, 0x7c,
+	0x7c, 0x05, 0xce, 0x08, 0xbe, 0x46, 0xac, 0x2a, 0xd3, 0xc3, 0x22, 0x9f, 0x7e, 0x14, 0x58, 0x96,
+	0xfc, 0x3d, 0x05, 0x97, 0x44, 0x88, 0xfb, 0x2c, 0xec, 0x4d, 0xc8, 0x4e, 0xff, 0x87, 0xfc, 0xfd,
+	0x5d, 0x0a, 0x4a, 0x42, 0xa5, 0x73, 0x24, 0x31, 0x4b, 0x4e, 0x60, 0x5b, 0x93, 0x12, 0x58, 0x04,
+	0xca, 0xe7, 0x32, 0x79, 0x7d, 0x82, 0xe0, 0xf2, 0x49, 0x34, 0x7c, 0x05, 0xae, 0xf6, 0x28, 0xde,
+	0xd5, 0xde, 0x9c, 0x41, 0x8d, 0xc3, 0xfe, 0x46, 0x7e, 0x86, 0x00, 0xcb, 0xda, 0x5c, 0x6a, 0xf3,
+	0x04, 0xe7, 0xc9, 0x8e, 0x3b, 0xcf, 0x2e, 0xa7, 0x3e, 0xf3, 0xfa, 0xb5, 0xae, 0xd8, 0xae, 0x5e,
+	0xa8, 0x49, 0x5e, 0x1f, 0x69, 0x04, 0x70, 0x17, 0x09, 0x07, 0x23, 0xb5, 0x29, 0x7f, 0x76, 0x7e,
+	0x88, 0xe0, 0xa2, 0x40, 0xfe, 0x7e, 0x97, 0x76, 0x29, 0x57, 0xac, 0xda, 0x37, 0xbd, 0x73, 0xbf,
+	0x0b, 0x79, 0x89, 0x6c, 0x9a, 0x80, 0x14, 0x85, 0x16, 0x6c, 0x23, 0xbf, 0x41, 0x80, 0xef, 0xd3,
+	0xd0, 0xd9, 0x67, 0xd0, 0x4d, 0x65, 0xe4, 0xe1, 0x17, 0x79, 0x57, 0xcf, 0xfb, 0x69, 0x3f, 0xa4,
+	0xb6, 0x8f, 0x10, 0x94, 0xef, 0x53, 0xb6, 0x2f, 0x48, 0x33, 0x09, 0x77, 0x8c, 0xce, 0xc6, 0xb0,
+	0xa5, 0xe7, 0x80, 0x8d, 0xfc, 0x49, 0xa2, 0x0a, 0xf0, 0x6c, 0xf5, 0x1f, 0x5b, 0x9d, 0x59, 0x2c,
+	0x59, 0x85, 0x0c, 0x6f, 0x61, 0x85, 0xb5, 0xa6, 0x02, 0x13, 0xf4, 0xb7, 0xaa, 0x8f, 0x83, 0xfe,
+	0x96, 0x21, 0xd6, 0xcd, 0x5b, 0x0a, 0x72, 0x24, 0x8a, 0x63, 0xe1, 0x80, 0xeb, 0x63, 0xe1, 0xf0,
+	0xfc, 0xa0, 0xf4, 0x95, 0xfd, 0x8b, 0xb1, 0x18, 0xf8, 0x3a, 0x2c, 0x72, 0xcf, 0x8f, 0xd6, 0xcc,
+	0xbc, 0x73, 0xb2, 0xd0, 0x76, 0x9a, 0x61, 0x5d, 0x4c, 0xf6, 0x60, 0x69, 0x18, 0x03, 0xcf, 0x1b,
+	0xfc, 0x9a, 0x5a, 0xcb, 0xf4, 0x5b, 0x41, 0x1d, 0xce, 0x27, 0x1e, 0x98, 0x7e, 0x8b, 0x7f, 0x64,
+	0x1e, 0x55, 0x49, 0x45, 0xbe, 0xea, 0x34, 0x3e, 0xc1, 0x93, 0x0a, 0x39, 0x86, 0x7c, 0x10, 0x30,
+	0xd6, 0x41, 0x1b, 0x89, 0x3a, 0x63, 0x78, 0x83, 0x6b, 0xf3, 0x6d, 0xb5, 0xe7, 0xab, 0x70, 0x86,
+	0xef, 0xa9, 0x3b, 0xb6, 0x6f, 0xf9, 0x8c, 0x6b, 0x48, 0x21, 0x5e, 0x6a, 0x3b, 0xcd, 0xed, 0xc1,
+	0x2c, 0xf9, 0x1b, 0x02, 0x2d, 0x9a, 0x09, 0x27, 0xd9, 0x31, 0x9a, 0x4b, 0xb2, 0xb3, 0xe7, 0x92,
+	0x98, 0x40, 0x9a, 0x3b, 0x65, 0x20, 0x8d, 0x3a, 0x8d, 0x7c, 0x8e, 0x93, 0x9f, 0x22, 0x58, 0xe1,
+	0x41, 0x3c, 0x68, 0x60, 0xf9, 0x73, 0x72, 0xf7, 0xe1, 0x0c, 0x9f, 0x1e, 0xcd, 0xf0, 0x43, 0xaf,
+	0x83, 0xcc, 0xf0, 0xeb, 0x80, 0xfc, 0x00, 0xc1, 0xd9, 0x11, 0x4c, 0x2a, 0xa9, 0xec, 0x40, 0x21,
+	0x68, 0x90, 0xf9, 0xe5, 0x9c, 0x08, 0x6f, 0x57, 0x93, 0x74, 0x19, 0xed, 0xca, 0x19, 0x83, 0xad,
+	0x71, 0xcf, 0xad, 0x7c, 0xcc, 0x73, 0x6b, 0xfd, 0x3f, 0x2b, 0x70, 0xe6, 0x21, 0xed, 0x3f, 0x8e,
+	0x9c, 0x8b, 0x7f, 0x8c, 0x60, 0xe1, 0x3e, 0x65, 0xef, 0x05, 0x8a, 0xc0, 0xd5, 0xe4, 0xfa, 0x24,
+	0x5c, 0xa8, 0x34, 0x5b, 0x49, 0x6a, 0xfc, 0x84, 0x8b, 0xc9, 0x95, 0x0f, 0xff, 0xf9, 0xe9, 0x47,
+	0xa9, 0x55, 0x7c, 0x59, 0xef, 0xad, 0xe9, 0x81, 0xd6, 0x2d, 0xea, 0xeb, 0x2f, 0xa2, 0x66, 0x79,
+	0x89, 0x7f, 0x81, 0xa0, 0x18, 0x89, 0x32, 0xf8, 0xad, 0x64, 0x34, 0x23, 0xd1, 0xb1, 0x32, 0x4d,
+	0x65, 0x4e, 0xbe, 0x26, 0xb0, 0xbc, 0x8d, 0x6f, 0x24, 0x63, 0xd1, 0xc3, 0x04, 0xad, 0xbf, 0x08,
+	0x7e, 0xbe, 0xc4, 0xbf, 0x42, 0xb0, 0x3c, 0x16, 0x9c, 0xf1, 0x8d, 0x64, 0x98, 0xb1, 0xa1, 0x7c,
+	0x3a, 0xb0, 0xef, 0x08, 0xb0, 0x6b, 0x58, 0x9f, 0x16, 0xec, 0xa6, 0xf4, 0x91, 0x00, 0xe8, 0x70,
+	0xbc, 0x9e, 0x04, 0x34, 0x36, 0xba, 0xbf, 0x2a, 0xa0, 0x47, 0x12, 0xd2, 0x2f, 0x87, 0x81, 0x1e,
+	0x32, 0x8f, 0x9a, 0x9d, 0x57, 0x62, 0xf8, 0xd9, 0x21, 0xfa, 0x02, 0xcc, 0x75, 0x84, 0xff, 0x80,
+	0x60, 0x71, 0xc8, 0x89, 0xb1, 0x9e, 0x14, 0xaf, 0x62, 0x42, 0x50, 0xe5, 0xfa, 0xf4, 0x1b, 0x64,
+	0x7c, 0x20, 0xf7, 0x04, 0xde, 0xbb, 0xf8, 0xce, 0x29, 0x88, 0xaa, 0x0f, 0xc2, 0xc3, 0x9f, 0x11,
+	0xbc, 0x36, 0x74, 0x81, 0x52, 0xf1, 0xcc, 0x12, 0x4c, 0x1d, 0x9c, 0xc8, 0xbe, 0x40, 0xbe, 0x83,
+	0xdf, 0xfb, 0x4c, 0xc8, 0x07, 0xea, 0xff, 0x39, 0x82, 0xbc, 0x6a, 0x8e, 0xe0, 0x37, 0xa6, 0x69,
+	0xa0, 0x48, 0xc0, 0x33, 0xf4, 0x5a, 0xc8, 0x2d, 0x01, 0xf9, 0x3a, 0xae, 0x4e, 0x80, 0xcc, 0x6b,
+	0x1f, 0x5f, 0x7f, 0xa1, 0x4a, 0x20, 0x11, 0x10, 0x16, 0xa2, 0x6d, 0xba, 0xc4, 0x00, 0x1a, 0xd3,
+	0x63, 0xae, 0xe8, 0x33, 0xf6, 0xff, 0xc8, 0xdb, 0x02, 0xa9, 0x8e, 0xdf, 0x9a, 0x06, 0xe9, 0xe6,
+	0x91, 0x3a, 0x02, 0xff, 0x11, 0xc1, 0xf2, 0x58, 0x37, 0x35, 0x31, 0x20, 0x9c, 0xd4, 0x33, 0xae,
+	0xdc, 0x3c, 0x4d, 0xc3, 0x96, 0x6c, 0x0a, 0xdc, 0x37, 0xf1, 0xfa, 0x4c, 0xb8, 0x25, 0xcc, 0x8f,
+	0x11, 0x94, 0x46, 0x5b, 0x6c, 0x78, 0x7d, 0x02, 0x81, 0x63, 0x9a, 0x91, 0x95, 0x1b, 0x33, 0xed,
+	0x51, 0xc8, 0xbf, 0x21, 0x90, 0x6f, 0xe0, 0x5b, 0xb3, 0x71, 0x43, 0x6f, 0x29, 0xa0, 0x7f, 0x41,
+	0xb0, 0x3c, 0x56, 0x5b, 0xe2, 0x49, 0x50, 0xe2, 0x1a, 0x22, 0x95, 0x9b, 0xb3, 0x6d, 0x52, 0x02,
+	0x6c, 0x0b, 0x01, 0xee, 0x90, 0x8d, 0x19, 0x05, 0x18, 0x44, 0x42, 0x74, 0x0d, 0xff, 0x03, 0xc1,
+	0xb9, 0xf8, 0x32, 0x19, 0x6f, 0x4c, 0x22, 0xc4, 0x89, 0xf2, 0xdc, 0x3e, 0xc5, 0x4e, 0x25, 0xd4,
+	0x96, 0x10, 0xea, 0xeb, 0xe4, 0x9d, 0xe9, 0xf9, 0xc4, 0x0f, 0x33, 0xa2, 0x32, 0xfd, 0x1e, 0x41,
+	0x49, 0x54, 0xa7, 0xd1, 0xff, 0xdf, 0x4e, 0xca, 0x3d, 0xe3, 0x65, 0x76, 0xe5, 0xdc, 0x58, 0x2d,
+	0x73, 0x8f, 0xff, 0x47, 0x3e, 0xf9, 0xae, 0xc0, 0xf7, 0x3e, 0xf9, 0xe6, 0x74, 0x4a, 0x8f, 0xd6,
+	0xe1, 0xd5, 0xc0, 0x02, 0x9b, 0x4f, 0x39, 0xb8, 0xcd, 0xa1, 0x22, 0x9d, 0x67, 0xcc, 0x95, 0xb8,
+	0xba, 0x1a, 0xdf, 0x9a, 0xa4, 0xcc, 0xf8, 0x42, 0xfc, 0x44, 0x09, 0x94, 0xc7, 0x92, 0x09, 0x09,
+	0x73, 0xf3, 0x68, 0x70, 0xb6, 0x38, 0x77, 0x13, 0x5d, 0xdb, 0x7a, 0xf0, 0xfd, 0x9d, 0xa6, 0xc5,
+	0x5a, 0xdd, 0xa3, 0x6a, 0xdd, 0xe9, 0xe8, 0xf2, 0xfc, 0xd1, 0xbf, 0xa0, 0xd0, 0xeb, 0x8e, 0x27,
+	0xff, 0x3a, 0x62, 0xfc, 0xaf, 0x2b, 0x6a, 0x4d, 0xa7, 0x26, 0xe1, 0xe4, 0xc4, 0x3f, 0x37, 0xfe,
+	0x37, 0x00, 0x39, 0x85, 0xa6, 0x4f, 0x83, 0x21, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -2017,6 +2205,10 @@ type KeyTransparencyClient interface {
 	// GetLatestRevision returns the latest SignedMapRoot along with its inclusion
 	// proof in the log and the log's consistency proofs.
 	GetLatestRevision(ctx context.Context, in *GetLatestRevisionRequest, opts ...grpc.CallOption) (*Revision, error)
+	// GetRevisionByTime returns the latest SignedMapRoot whose timestamp is at
+	// or before the requested time, along with its inclusion proof in the log
+	// and the log's consistency proofs.
+	GetRevisionByTime(ctx context.Context, in *GetRevisionByTimeRequest, opts ...grpc.CallOption) (*Revision, error)
 	// GetRevisionStream streams new revisions from a requested starting point
 	// and continues as new revisions are created.
 	GetRevisionStream(ctx context.Context, in *GetRevisionRequest, opts ...grpc.CallOption) (KeyTransparency_GetRevisionStreamClient, error)
@@ -2086,6 +2278,15 @@ func (c *keyTransparencyClient) GetLatestRevision(ctx context.Context, in *GetLa
 	return out, nil
 }
 
+func (c *keyTransparencyClient) GetRevisionByTime(ctx context.Context, in *GetRevisionByTimeRequest, opts ...grpc.CallOption) (*Revision, error) {
+	out := new(Revision)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.v1.KeyTransparency/GetRevisionByTime", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *keyTransparencyClient) GetRevisionStream(ctx context.Context, in *GetRevisionRequest, opts ...grpc.CallOption) (KeyTransparency_GetRevisionStreamClient, error) {
 	stream, err := c.cc.NewStream(ctx, &_KeyTransparency_serviceDesc.Streams[0], "/google.keytransparency.v1.KeyTransparency/GetRevisionStream", opts...)
 	if err != nil {
@@ -2242,6 +2443,10 @@ type KeyTransparencyServer interface {
 	// GetLatestRevision returns the latest SignedMapRoot along with its inclusion
 	// proof in the log and the log's consistency proofs.
 	GetLatestRevision(context.Context, *GetLatestRevisionRequest) (*Revision, error)
+	// GetRevisionByTime returns the latest SignedMapRoot whose timestamp is at
+	// or before the requested time, along with its inclusion proof in the log
+	// and the log's consistency proofs.
+	GetRevisionByTime(context.Context, *GetRevisionByTimeRequest) (*Revision, error)
 	// GetRevisionStream streams new revisions from a requested starting point
 	// and continues as new revisions are created.
 	GetRevisionStream(*GetRevisionRequest, KeyTransparency_GetRevisionStreamServer) error
@@ -2289,6 +2494,9 @@ func (*UnimplementedKeyTransparencyServer) GetRevision(ctx context.Context, req
 func (*UnimplementedKeyTransparencyServer) GetLatestRevision(ctx context.Context, req *GetLatestRevisionRequest) (*Revision, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetLatestRevision not implemented")
 }
+func (*UnimplementedKeyTransparencyServer) GetRevisionByTime(ctx context.Context, req *GetRevisionByTimeRequest) (*Revision, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevisionByTime not implemented")
+}
 func (*UnimplementedKeyTransparencyServer) GetRevisionStream(req *GetRevisionRequest, srv KeyTransparency_GetRevisionStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetRevisionStream not implemented")
 }
@@ -2381,6 +2589,24 @@ func _KeyTransparency_GetLatestRevision_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyTransparency_GetRevisionByTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRevisionByTimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencyServer).GetRevisionByTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.v1.KeyTransparency/GetRevisionByTime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencyServer).GetRevisionByTime(ctx, req.(*GetRevisionByTimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KeyTransparency_GetRevisionStream_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(GetRevisionRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -2601,6 +2827,10 @@ var _KeyTransparency_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetLatestRevision",
 			Handler:    _KeyTransparency_GetLatestRevision_Handler,
 		},
+		{
+			MethodName: "GetRevisionByTime",
+			Handler:    _KeyTransparency_GetRevisionByTime_Handler,
+		},
 		{
 			MethodName: "ListMutations",
 			Handler:    _KeyTransparency_ListMutations_Handler,
@@ -46,7 +46,7 @@ func (c *Client) BatchCreateUser(ctx context.Context, users []*User,
 
 	mutations := make([]*entry.Mutation, 0, len(users))
 	for _, u := range users {
-		mutation := entry.NewMutation(indexByUser[u.UserID], c.DirectoryID, u.UserID)
+		mutation := entry.NewMutation(indexByUser[u.UserID], c.DirectoryID, u.UserID, c.AppID)
 
 		if err := mutation.SetCommitment(u.PublicKeyData); err != nil {
 			return err
@@ -135,11 +135,12 @@ func (c *Client) createMutation(smr *types.MapRootV1, leaf *pb.MapLeaf, u *User)
 	if leaf == nil {
 		return nil, fmt.Errorf("no leaf found for %v", u.UserID)
 	}
-	index, err := c.Index(leaf.GetVrfProof(), c.DirectoryID, u.UserID)
+	index, err := c.Index(leaf.GetVrfProof(), c.DirectoryID, u.UserID, c.AppID)
 	if err != nil {
 		return nil, err
 	}
-	mutation := entry.NewMutation(index, c.DirectoryID, u.UserID)
+	mutation := entry.NewMutation(index, c.DirectoryID, u.UserID, c.AppID)
+	mutation.AllowRecovery = c.allowRecovery
 
 	leafValue := leaf.MapInclusion.GetLeaf().GetLeafValue()
 	if err := mutation.SetPrevious(smr.Revision, leafValue, true); err != nil {
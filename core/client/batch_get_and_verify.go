@@ -30,6 +30,7 @@ func (c *Client) BatchVerifyGetUserIndex(ctx context.Context, userIDs []string)
 	resp, err := c.cli.BatchGetUserIndex(ctx, &pb.BatchGetUserIndexRequest{
 		DirectoryId: c.DirectoryID,
 		UserIds:     userIDs,
+		AppId:       c.AppID,
 	})
 	if err != nil {
 		return nil, err
@@ -71,7 +72,7 @@ func (c *Client) BatchVerifyGetUserIndex(ctx context.Context, userIDs []string)
 		go func() {
 			defer wg.Done()
 			for p := range proofs {
-				index, err := c.Index(p.proof, c.DirectoryID, p.userID)
+				index, err := c.Index(p.proof, c.DirectoryID, p.userID, c.AppID)
 				select {
 				case results <- result{userID: p.userID, index: index, err: err}:
 				case <-done:
@@ -106,6 +107,7 @@ func (c *Client) BatchVerifiedGetUser(ctx context.Context, userIDs []string) (
 		DirectoryId:  c.DirectoryID,
 		UserIds:      userIDs,
 		LastVerified: logReq,
+		AppId:        c.AppID,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -122,7 +124,7 @@ func (c *Client) BatchVerifiedGetUser(ctx context.Context, userIDs []string) (
 
 	leavesByUserID := make(map[string]*pb.MapLeaf)
 	for userID, leaf := range resp.MapLeavesByUserId {
-		if err := c.VerifyMapLeaf(c.DirectoryID, userID, leaf, smr); err != nil {
+		if err := c.VerifyMapLeaf(c.DirectoryID, userID, c.AppID, leaf, smr); err != nil {
 			return nil, nil, err
 		}
 		leavesByUserID[userID] = leaf
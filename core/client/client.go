@@ -19,6 +19,8 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -62,6 +64,9 @@ var (
 	ErrLogEmpty = errors.New("log is empty - directory initialization failed")
 	// ErrNonContiguous occurs when there are holes in a list of map roots.
 	ErrNonContiguous = errors.New("noncontiguous map roots")
+	// ErrDeleted occurs when the user's entry has been verifiably replaced by
+	// a tombstone. This is distinct from a proof of absence.
+	ErrDeleted = errors.New("user has been deleted")
 	// Vlog is the verbose logger. By default it outputs to /dev/null.
 	Vlog = log.New(ioutil.Discard, "", 0)
 )
@@ -69,12 +74,13 @@ var (
 // VerifierInterface is used to verify specific outputs from Key Transparency.
 type VerifierInterface interface {
 	verifier.LogTracker
-	// Index computes the index of a userID from a VRF proof, obtained from the server.
-	Index(vrfProof []byte, directoryID, userID string) ([]byte, error)
+	// Index computes the index of a userID's entry in the application appID
+	// from a VRF proof, obtained from the server.
+	Index(vrfProof []byte, directoryID, userID, appID string) ([]byte, error)
 	// VerifyMapRevision verifies that the map revision is correctly signed and included in the log.
 	VerifyMapRevision(lr *types.LogRootV1, smr *pb.MapRoot) (*types.MapRootV1, error)
 	// VerifyMapLeaf verifies everything about a MapLeaf.
-	VerifyMapLeaf(directoryID, userID string, in *pb.MapLeaf, smr *types.MapRootV1) error
+	VerifyMapLeaf(directoryID, userID, appID string, in *pb.MapLeaf, smr *types.MapRootV1) error
 	//
 	// Pair Verifiers
 	//
@@ -109,11 +115,25 @@ type Client struct {
 	VerifierInterface
 	cli         pb.KeyTransparencyClient
 	DirectoryID string
-	reduce      ReduceMutationFn
-	RetryDelay  time.Duration
+	// AppID selects the application entry of each user that the client
+	// reads and writes. An empty AppID selects the users' default entries.
+	AppID string
+	// allowRecovery is the recovery policy of the directory. If set, deleted
+	// entries may be replaced by new ones.
+	allowRecovery bool
+	reduce        ReduceMutationFn
+	RetryDelay    time.Duration
+	// QueueTimeout bounds each attempt to queue a mutation, so that an
+	// attempt that times out is retried within the caller's deadline.
+	// Zero means attempts are only bounded by the caller's context.
+	QueueTimeout time.Duration
 }
 
-// NewFromConfig creates a new client from a config
+// DefaultQueueTimeout is the QueueTimeout of clients created with New.
+const DefaultQueueTimeout = 30 * time.Second
+
+// NewFromConfig creates a new client from a config, as returned by
+// GetDirectory, including the recovery policy of the directory.
 func NewFromConfig(ktClient pb.KeyTransparencyClient, config *pb.Directory,
 	trackerFactory verifier.LogTrackerFactory) (*Client, error) {
 	ktVerifier, err := verifier.NewFromDirectory(config, trackerFactory)
@@ -125,10 +145,13 @@ func NewFromConfig(ktClient pb.KeyTransparencyClient, config *pb.Directory,
 		return nil, err
 	}
 
-	return New(ktClient, config.DirectoryId, minInterval, ktVerifier), nil
+	c := New(ktClient, config.DirectoryId, minInterval, ktVerifier)
+	c.allowRecovery = config.GetAllowRecovery()
+	c.reduce = entry.NewReduceFn(c.allowRecovery)
+	return c, nil
 }
 
-// New creates a new client.
+// New creates a new client for a directory that does not allow recovery.
 func New(ktClient pb.KeyTransparencyClient,
 	directoryID string,
 	retryDelay time.Duration,
@@ -137,18 +160,38 @@ func New(ktClient pb.KeyTransparencyClient,
 		VerifierInterface: ktVerifier,
 		cli:               ktClient,
 		DirectoryID:       directoryID,
-		reduce:            entry.ReduceFn,
+		reduce:            entry.NewReduceFn(false),
 		RetryDelay:        retryDelay,
+		QueueTimeout:      DefaultQueueTimeout,
 	}
 }
 
 // GetUser returns an entry if it exists, and nil if it does not.
+// Returns ErrDeleted along with the map root if the user has been deleted.
 func (c *Client) GetUser(ctx context.Context, userID string, opts ...grpc.CallOption) (
 	*types.MapRootV1, []byte, error) {
 	smr, e, err := c.VerifiedGetUser(ctx, userID)
 	return smr, e.GetCommitted().GetData(), err
 }
 
+// GetUserAtTime returns the user's entry as of the latest revision published
+// at or before t, along with that revision's map root. The entry is nil if the
+// user did not exist at t. Returns ErrDeleted along with the map root if the
+// user had been deleted by t.
+func (c *Client) GetUserAtTime(ctx context.Context, userID string, t time.Time) (
+	*types.MapRootV1, []byte, error) {
+	mr, err := c.VerifiedGetRevisionByTime(ctx, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mr.Revision == 0 {
+		// Revision 0 is the empty map created with the directory.
+		return mr, nil, nil
+	}
+	smr, e, err := c.VerifiedGetUserByRevision(ctx, userID, int64(mr.Revision))
+	return smr, e.GetCommitted().GetData(), err
+}
+
 // PaginateHistory iteratively calls ListHistory to satisfy the start and end requirements.
 // Returns a list of map roots and profiles at each revision.
 func (c *Client) PaginateHistory(ctx context.Context, userID string, start, end int64) (
@@ -228,6 +271,8 @@ func (m uint64Slice) Less(i, j int) bool { return m[i] < m[j] }
 
 // Update creates and submits a mutation for a user, and waits for it to appear.
 // Returns codes.FailedPrecondition if there was a race condition.
+// If the mutation could not be queued, it is returned with the error so that
+// it can be retried with QueueMutation under the same request ID.
 func (c *Client) Update(ctx context.Context, u *User, signers []tink.Signer, opts ...grpc.CallOption) (*entry.Mutation, error) {
 	// 1. pb.User + ExistingEntry -> Mutation.
 	m, err := c.CreateMutation(ctx, u)
@@ -237,7 +282,7 @@ func (c *Client) Update(ctx context.Context, u *User, signers []tink.Signer, opt
 
 	// 2. Queue Mutation.
 	if err := c.QueueMutation(ctx, m, signers, opts...); err != nil {
-		return nil, err
+		return m, err
 	}
 
 	// 3. Wait for update.
@@ -245,7 +290,17 @@ func (c *Client) Update(ctx context.Context, u *User, signers []tink.Signer, opt
 }
 
 // QueueMutation signs an entry.Mutation and sends it to the server.
+// QueueMutation assigns m a request ID if it does not have one. Retries,
+// including calls to QueueMutation with the same m, carry that request ID so
+// the mutation is queued at most once.
 func (c *Client) QueueMutation(ctx context.Context, m *entry.Mutation, signers []tink.Signer, opts ...grpc.CallOption) error {
+	if m.RequestID == "" {
+		id, err := newRequestID()
+		if err != nil {
+			return err
+		}
+		m.RequestID = id
+	}
 	update, err := m.SerializeAndSign(signers)
 	if err != nil {
 		return fmt.Errorf("failed SerializeAndSign: %v", err)
@@ -253,25 +308,61 @@ func (c *Client) QueueMutation(ctx context.Context, m *entry.Mutation, signers [
 
 	Vlog.Printf("Sending Update request...")
 	req := &pb.UpdateEntryRequest{DirectoryId: c.DirectoryID, EntryUpdate: update}
-	_, err = c.cli.QueueEntryUpdate(ctx, req, opts...)
-	return err
+	return c.queueEntryUpdate(ctx, req, opts...)
+}
+
+// queueEntryUpdate sends req to the server, retrying transient failures and
+// attempts that exceed QueueTimeout until ctx is done.
+func (c *Client) queueEntryUpdate(ctx context.Context, req *pb.UpdateEntryRequest, opts ...grpc.CallOption) error {
+	b := &backoff.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    10 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+	return b.Retry(ctx, func() error {
+		actx := ctx
+		if c.QueueTimeout > 0 {
+			var cancel context.CancelFunc
+			actx, cancel = context.WithTimeout(ctx, c.QueueTimeout)
+			defer cancel()
+		}
+		_, err := c.cli.QueueEntryUpdate(actx, req, opts...)
+		if err != nil {
+			Vlog.Printf("QueueEntryUpdate(): %v", err)
+		}
+		return err
+	})
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %v", err)
+	}
+	return hex.EncodeToString(id), nil
 }
 
 // CreateMutation fetches the current index and value for a user and prepares a mutation.
+// Returns ErrDeleted if the user has been deleted and the directory does not
+// allow recovery. Otherwise the mutation replaces the tombstone, and must be
+// signed by the keys that signed it.
 func (c *Client) CreateMutation(ctx context.Context, u *User) (*entry.Mutation, error) {
 	smr, e, err := c.VerifiedGetUser(ctx, u.UserID)
-	if err != nil {
+	if err != nil && !(err == ErrDeleted && c.allowRecovery) {
 		return nil, err
 	}
 	oldLeaf := e.GetMapInclusion().GetLeaf().GetLeafValue()
 	Vlog.Printf("Got current entry...")
 
-	index, err := c.Index(e.GetVrfProof(), c.DirectoryID, u.UserID)
+	index, err := c.Index(e.GetVrfProof(), c.DirectoryID, u.UserID, c.AppID)
 	if err != nil {
 		return nil, err
 	}
 
-	mutation := entry.NewMutation(index, c.DirectoryID, u.UserID)
+	mutation := entry.NewMutation(index, c.DirectoryID, u.UserID, c.AppID)
+	mutation.AllowRecovery = c.allowRecovery
 
 	if err := mutation.SetPrevious(smr.Revision, oldLeaf, true); err != nil {
 		return nil, err
@@ -290,6 +381,33 @@ func (c *Client) CreateMutation(ctx context.Context, u *User) (*entry.Mutation,
 	return mutation, nil
 }
 
+// Delete replaces the user's entry with a tombstone, and waits for it to appear.
+// The tombstone must be signed by the user's current authorized keys.
+// Once deleted, the user's entry cannot be modified any further, unless the
+// directory allows recovery, in which case Update replaces the tombstone.
+func (c *Client) Delete(ctx context.Context, userID string, signers []tink.Signer, opts ...grpc.CallOption) (*entry.Mutation, error) {
+	smr, e, err := c.VerifiedGetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	index, err := c.Index(e.GetVrfProof(), c.DirectoryID, userID, c.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := entry.NewMutation(index, c.DirectoryID, userID, c.AppID)
+	m.AllowRecovery = c.allowRecovery
+	if err := m.SetPrevious(smr.Revision, e.GetMapInclusion().GetLeaf().GetLeafValue(), true); err != nil {
+		return nil, err
+	}
+	m.SetDeleted()
+
+	if err := c.QueueMutation(ctx, m, signers, opts...); err != nil {
+		return nil, err
+	}
+	return c.WaitForUserUpdate(ctx, m)
+}
+
 // WaitForUserUpdate waits for the mutation to be applied or the context to timeout or cancel.
 func (c *Client) WaitForUserUpdate(ctx context.Context, m *entry.Mutation) (*entry.Mutation, error) {
 	for {
@@ -324,7 +442,7 @@ func (c *Client) waitOnceForUserUpdate(ctx context.Context, m *entry.Mutation) (
 
 	// GetUser.
 	smr, e, err := c.VerifiedGetUser(ctx, m.UserID)
-	if err != nil {
+	if err != nil && err != ErrDeleted {
 		return m, err
 	}
 	Vlog.Printf("Got current entry...")
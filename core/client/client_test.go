@@ -16,12 +16,17 @@ package client
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/keytransparency/core/mutator/entry"
 	"github.com/google/keytransparency/core/testutil"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/signature"
+	"github.com/google/tink/go/tink"
 	"github.com/google/trillian"
 	"github.com/google/trillian/types"
 	"github.com/kylelemons/godebug/pretty"
@@ -219,6 +224,11 @@ func TestPaginateHistory(t *testing.T) {
 
 type fakeKeyServer struct {
 	revisions map[int64]*pb.GetUserResponse
+	// user is returned by GetUser.
+	user *pb.GetUserResponse
+	// queueErrs are returned by successive QueueEntryUpdate calls.
+	queueErrs  []error
+	requestIDs []string
 }
 
 func (f *fakeKeyServer) ListEntryHistory(ctx context.Context, in *pb.ListEntryHistoryRequest) (*pb.ListEntryHistoryResponse, error) {
@@ -267,6 +277,10 @@ func (f *fakeKeyServer) GetLatestRevision(context.Context, *pb.GetLatestRevision
 	return nil, status.Error(codes.Unimplemented, "not implemented")
 }
 
+func (f *fakeKeyServer) GetRevisionByTime(context.Context, *pb.GetRevisionByTimeRequest) (*pb.Revision, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+
 func (f *fakeKeyServer) GetRevisionStream(*pb.GetRevisionRequest, pb.KeyTransparency_GetRevisionStreamServer) error {
 	return status.Error(codes.Unimplemented, "not implemented")
 }
@@ -280,7 +294,10 @@ func (f *fakeKeyServer) ListMutationsStream(*pb.ListMutationsRequest, pb.KeyTran
 }
 
 func (f *fakeKeyServer) GetUser(context.Context, *pb.GetUserRequest) (*pb.GetUserResponse, error) {
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+	if f.user == nil {
+		return nil, status.Error(codes.Unimplemented, "not implemented")
+	}
+	return f.user, nil
 }
 
 func (f *fakeKeyServer) BatchGetUser(context.Context, *pb.BatchGetUserRequest) (*pb.BatchGetUserResponse, error) {
@@ -292,17 +309,165 @@ func (f *fakeKeyServer) BatchGetUserIndex(context.Context,
 	return nil, status.Error(codes.Unimplemented, "not implemented")
 }
 
-func (f *fakeKeyServer) QueueEntryUpdate(context.Context, *pb.UpdateEntryRequest) (*empty.Empty, error) {
-	return nil, status.Error(codes.Unimplemented, "not implemented")
+// errStall makes fakeKeyServer.QueueEntryUpdate block until the call times out.
+var errStall = errors.New("stall")
+
+func (f *fakeKeyServer) QueueEntryUpdate(ctx context.Context, in *pb.UpdateEntryRequest) (*empty.Empty, error) {
+	f.requestIDs = append(f.requestIDs, in.GetEntryUpdate().GetRequestId())
+	if len(f.queueErrs) == 0 {
+		return &empty.Empty{}, nil
+	}
+	err := f.queueErrs[0]
+	f.queueErrs = f.queueErrs[1:]
+	if err == errStall {
+		<-ctx.Done()
+		return nil, status.Error(codes.DeadlineExceeded, "stalled")
+	}
+	return nil, err
 }
 
 func (f *fakeKeyServer) BatchQueueUserUpdate(context.Context, *pb.BatchQueueUserUpdateRequest) (*empty.Empty, error) {
 	return nil, status.Error(codes.Unimplemented, "not implemented")
 }
 
+func TestQueueEntryUpdateRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+	for _, tc := range []struct {
+		desc      string
+		errs      []error
+		wantCode  codes.Code
+		wantCalls int
+	}{
+		{desc: "success", wantCalls: 1},
+		{desc: "transient", errs: []error{unavailable, unavailable}, wantCalls: 3},
+		{desc: "permanent", errs: []error{unavailable, invalid}, wantCode: codes.InvalidArgument, wantCalls: 2},
+		{desc: "attempt timeout", errs: []error{errStall, errStall}, wantCalls: 3},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			srv := &fakeKeyServer{queueErrs: tc.errs}
+			s, stop, err := testutil.NewFakeKT(srv)
+			if err != nil {
+				t.Fatalf("NewFakeKT(): %v", err)
+			}
+			defer stop()
+			c := Client{cli: s.Client, QueueTimeout: 100 * time.Millisecond}
+			req := &pb.UpdateEntryRequest{EntryUpdate: &pb.EntryUpdate{RequestId: "id"}}
+			if err := c.queueEntryUpdate(ctx, req); status.Code(err) != tc.wantCode {
+				t.Errorf("queueEntryUpdate(): %v, want %v", err, tc.wantCode)
+			}
+			if got := len(srv.requestIDs); got != tc.wantCalls {
+				t.Errorf("QueueEntryUpdate called %v times, want %v", got, tc.wantCalls)
+			}
+			for _, id := range srv.requestIDs {
+				if id != "id" {
+					t.Errorf("request id: %v, want id", id)
+				}
+			}
+		})
+	}
+}
+
+// tombstone returns the leaf value of a deleted entry of userID, and the
+// signers of its authorized keys.
+func tombstone(t *testing.T, userID string) ([]byte, []tink.Signer) {
+	t.Helper()
+	handle, err := keyset.NewHandle(signature.ECDSAP256KeyTemplate())
+	if err != nil {
+		t.Fatalf("keyset.NewHandle(): %v", err)
+	}
+	signer, err := signature.NewSigner(handle)
+	if err != nil {
+		t.Fatalf("NewSigner(): %v", err)
+	}
+	pub, err := handle.Public()
+	if err != nil {
+		t.Fatalf("Public(): %v", err)
+	}
+	signers := []tink.Signer{signer}
+	index := make([]byte, 32)
+
+	m := entry.NewMutation(index, "dir", userID, "")
+	if err := m.SetCommitment([]byte("key1")); err != nil {
+		t.Fatalf("SetCommitment(): %v", err)
+	}
+	if err := m.ReplaceAuthorizedKeys(pub); err != nil {
+		t.Fatalf("ReplaceAuthorizedKeys(): %v", err)
+	}
+	created, err := m.SerializeAndSign(signers)
+	if err != nil {
+		t.Fatalf("SerializeAndSign(): %v", err)
+	}
+	leaf, err := entry.ToLeafValue(created.GetMutation())
+	if err != nil {
+		t.Fatalf("ToLeafValue(): %v", err)
+	}
+
+	d := entry.NewMutation(index, "dir", userID, "")
+	if err := d.SetPrevious(1, leaf, true); err != nil {
+		t.Fatalf("SetPrevious(): %v", err)
+	}
+	d.SetDeleted()
+	deleted, err := d.SerializeAndSign(signers)
+	if err != nil {
+		t.Fatalf("SerializeAndSign(): %v", err)
+	}
+	leaf, err = entry.ToLeafValue(deleted.GetMutation())
+	if err != nil {
+		t.Fatalf("ToLeafValue(): %v", err)
+	}
+	return leaf, signers
+}
+
+func TestCreateMutationRecovery(t *testing.T) {
+	ctx := context.Background()
+	userID := "alice"
+	leaf, signers := tombstone(t, userID)
+	srv := &fakeKeyServer{user: &pb.GetUserResponse{
+		Revision: &pb.Revision{MapRoot: &pb.MapRoot{MapRoot: &trillian.SignedMapRoot{MapRoot: []byte{2}}}},
+		Leaf: &pb.MapLeaf{MapInclusion: &trillian.MapLeafInclusion{
+			Leaf: &trillian.MapLeaf{LeafValue: leaf},
+		}},
+	}}
+	s, stop, err := testutil.NewFakeKT(srv)
+	if err != nil {
+		t.Fatalf("NewFakeKT(): %v", err)
+	}
+	defer stop()
+
+	for _, tc := range []struct {
+		allowRecovery bool
+		wantErr       error
+	}{
+		{allowRecovery: false, wantErr: ErrDeleted},
+		{allowRecovery: true},
+	} {
+		c := Client{
+			VerifierInterface: &fakeVerifier{},
+			cli:               s.Client,
+			allowRecovery:     tc.allowRecovery,
+		}
+		m, err := c.CreateMutation(ctx, &User{UserID: userID, PublicKeyData: []byte("key2")})
+		if err != tc.wantErr {
+			t.Fatalf("CreateMutation(allowRecovery: %v): %v, want %v", tc.allowRecovery, err, tc.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if !m.AllowRecovery {
+			t.Errorf("CreateMutation(allowRecovery: %v).AllowRecovery: false, want true", tc.allowRecovery)
+		}
+		if _, err := m.SerializeAndSign(signers); err != nil {
+			t.Errorf("SerializeAndSign(): %v", err)
+		}
+	}
+}
+
 type fakeVerifier struct{}
 
-func (f *fakeVerifier) Index(vrfProof []byte, directoryID, userID string) ([]byte, error) {
+func (f *fakeVerifier) Index(vrfProof []byte, directoryID, userID, appID string) ([]byte, error) {
 	return make([]byte, 32), nil
 }
 
@@ -318,7 +483,7 @@ func (f *fakeVerifier) VerifyMapRevision(logRoot *types.LogRootV1, smr *pb.MapRo
 	return &types.MapRootV1{Revision: uint64(smr.MapRoot.MapRoot[0])}, nil
 }
 
-func (f *fakeVerifier) VerifyMapLeaf(directoryID, userID string,
+func (f *fakeVerifier) VerifyMapLeaf(directoryID, userID, appID string,
 	in *pb.MapLeaf, smr *types.MapRootV1) error {
 	return nil
 }
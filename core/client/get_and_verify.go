@@ -17,20 +17,49 @@ package client
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian/types"
 
+	"github.com/google/keytransparency/core/mutator/entry"
+
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
 // VerifiedGetUser fetches and verifies the results of GetUser.
+// If the user's entry is a verified tombstone, the map root and leaf are
+// returned along with ErrDeleted.
 func (c *Client) VerifiedGetUser(ctx context.Context, userID string) (*types.MapRootV1, *pb.MapLeaf, error) {
+	return c.verifiedGetUser(ctx, userID, 0)
+}
+
+// VerifiedGetUserByRevision fetches and verifies the user's entry as of the
+// given map revision. Tombstones are reported as in VerifiedGetUser.
+func (c *Client) VerifiedGetUserByRevision(ctx context.Context, userID string, revision int64) (
+	*types.MapRootV1, *pb.MapLeaf, error) {
+	if revision <= 0 {
+		return nil, nil, fmt.Errorf("revision=%v, want > 0", revision)
+	}
+	mr, leaf, err := c.verifiedGetUser(ctx, userID, revision)
+	if mr != nil && int64(mr.Revision) != revision {
+		return nil, nil, fmt.Errorf("map revision %v returned, want %v", mr.Revision, revision)
+	}
+	return mr, leaf, err
+}
+
+// verifiedGetUser fetches and verifies the user's entry at revision.
+// Revision 0 selects the latest revision.
+func (c *Client) verifiedGetUser(ctx context.Context, userID string, revision int64) (
+	*types.MapRootV1, *pb.MapLeaf, error) {
 	logReq := c.LastVerifiedLogRoot()
 	req := &pb.GetUserRequest{
 		DirectoryId:  c.DirectoryID,
 		UserId:       userID,
 		LastVerified: logReq,
+		AppId:        c.AppID,
+		Revision:     revision,
 	}
 	resp, err := c.cli.GetUser(ctx, req)
 	if err != nil {
@@ -45,9 +74,16 @@ func (c *Client) VerifiedGetUser(ctx context.Context, userID string) (*types.Map
 	if err != nil {
 		return nil, nil, err
 	}
-	if err := c.VerifyMapLeaf(c.DirectoryID, userID, resp.Leaf, mr); err != nil {
+	if err := c.VerifyMapLeaf(c.DirectoryID, userID, c.AppID, resp.Leaf, mr); err != nil {
 		return nil, nil, err
 	}
+	deleted, err := entry.IsTombstone(resp.Leaf.GetMapInclusion().GetLeaf().GetLeafValue())
+	if err != nil {
+		return nil, nil, err
+	}
+	if deleted {
+		return mr, resp.Leaf, ErrDeleted
+	}
 
 	return mr, resp.Leaf, nil
 }
@@ -113,6 +149,55 @@ func (c *Client) VerifiedGetRevision(ctx context.Context, revision int64) (*type
 	return mr, nil
 }
 
+// VerifiedGetRevisionByTime fetches the latest map revision published at or
+// before t. The timestamp of the returned revision is checked against t, and
+// unless it is the latest revision, the following revision is fetched to
+// check that it was published after t.
+func (c *Client) VerifiedGetRevisionByTime(ctx context.Context, t time.Time) (*types.MapRootV1, error) {
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil, err
+	}
+	logReq := c.LastVerifiedLogRoot()
+	resp, err := c.cli.GetRevisionByTime(ctx, &pb.GetRevisionByTimeRequest{
+		DirectoryId:  c.DirectoryID,
+		Time:         ts,
+		LastVerified: logReq,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lr, err := c.VerifyLogRoot(logReq, resp.GetLatestLogRoot())
+	if err != nil {
+		return nil, err
+	}
+	mr, err := c.VerifyMapRevision(lr, resp.GetMapRoot())
+	if err != nil {
+		return nil, err
+	}
+	if mr.TimestampNanos > uint64(t.UnixNano()) {
+		return nil, fmt.Errorf("map revision %v published at %v, after %v",
+			mr.Revision, time.Unix(0, int64(mr.TimestampNanos)), t)
+	}
+
+	latest, err := mapRevisionFor(lr)
+	if err != nil {
+		return nil, err
+	}
+	if mr.Revision < latest {
+		next, err := c.VerifiedGetRevision(ctx, int64(mr.Revision)+1)
+		if err != nil {
+			return nil, err
+		}
+		if next.TimestampNanos <= uint64(t.UnixNano()) {
+			return nil, fmt.Errorf("map revision %v published at %v, not after %v",
+				next.Revision, time.Unix(0, int64(next.TimestampNanos)), t)
+		}
+	}
+	return mr, nil
+}
+
 // VerifiedListHistory performs one list history operation, verifies and returns the results.
 func (c *Client) VerifiedListHistory(ctx context.Context, userID string, start int64, count int32) (
 	map[*types.MapRootV1][]byte, int64, error) {
@@ -120,6 +205,7 @@ func (c *Client) VerifiedListHistory(ctx context.Context, userID string, start i
 	resp, err := c.cli.ListEntryHistory(ctx, &pb.ListEntryHistoryRequest{
 		DirectoryId:  c.DirectoryID,
 		UserId:       userID,
+		AppId:        c.AppID,
 		LastVerified: logReq,
 		Start:        start,
 		PageSize:     count,
@@ -143,7 +229,7 @@ func (c *Client) VerifiedListHistory(ctx context.Context, userID string, start i
 		if err != nil {
 			return nil, 0, err
 		}
-		if err := c.VerifyMapLeaf(c.DirectoryID, userID, v.Leaf, mr); err != nil {
+		if err := c.VerifyMapLeaf(c.DirectoryID, userID, c.AppID, v.Leaf, mr); err != nil {
 			return nil, 0, err
 		}
 		Vlog.Printf("Processing entry for %v, revision %v", userID, mr.Revision)
@@ -43,6 +43,9 @@ var (
 
 	timeout = 500 * time.Millisecond
 
+	// clientCert is presented to KT servers that authenticate clients with TLS certificates.
+	clientCert *tls.Certificate
+
 	multiLogWriter = multi.NewWriter(os.Stderr)
 
 	// Vlog is the verbose logger. By default it outputs to stderr (logcat on Android), but other destination can be
@@ -69,6 +72,21 @@ func SetTimeout(ms int32) {
 	timeout = time.Duration(ms) * time.Millisecond
 }
 
+// SetClientCertificate sets the PEM encoded certificate and private key presented to
+// KT servers added by subsequent calls to AddKtServer. Empty arguments clear the certificate.
+func SetClientCertificate(certPEM, keyPEM []byte) error {
+	if len(certPEM) == 0 && len(keyPEM) == 0 {
+		clientCert = nil
+		return nil
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("error parsing client certificate: %v", err)
+	}
+	clientCert = &cert
+	return nil
+}
+
 // AddKtServer creates a new grpc client to handle connections to the ktURL server and adds it to the global map of clients.
 func AddKtServer(ktURL string, insecureTLS bool, ktTLSCertPEM []byte) error {
 	if _, exists := clients[ktURL]; exists {
@@ -141,22 +159,24 @@ func transportCreds(ktURL string, insecure bool, ktTLSCertPEM []byte) (credentia
 		return nil, err
 	}
 
+	config := &tls.Config{ServerName: host}
 	switch {
 	case insecure: // Impatient insecure.
 		Vlog.Printf("Warning: Skipping verification of KT Server's TLS certificate.")
-		return credentials.NewTLS(&tls.Config{
-			InsecureSkipVerify: true, // nolint: gosec
-		}), nil
+		config.InsecureSkipVerify = true // nolint: gosec
 
 	case len(ktTLSCertPEM) != 0: // Custom CA Cert.
 		cp := x509.NewCertPool()
 		if !cp.AppendCertsFromPEM(ktTLSCertPEM) {
 			return nil, fmt.Errorf("failed to append certificates")
 		}
-		creds := credentials.NewTLS(&tls.Config{ServerName: host, RootCAs: cp})
-		return creds, nil
+		config.RootCAs = cp
 
 	default: // Use the local set of root certs.
-		return credentials.NewClientTLSFromCert(nil, host), nil
 	}
+
+	if clientCert != nil {
+		config.Certificates = []tls.Certificate{*clientCert}
+	}
+	return credentials.NewTLS(config), nil
 }
@@ -60,12 +60,18 @@ type Config struct {
 	HistoryCount    int
 	HistoryPageSize int
 
+	// BatchWriterID is the identity that multi-user batch writes are sent as.
+	// It must be authorized to update any user in the directory.
+	// Single user writes are sent as the user being updated.
+	BatchWriterID string
+
 	Duration time.Duration
 }
 
 // Hammer represents a single run of the hammer.
 type Hammer struct {
 	callOptions CallOptions
+	batchWriter string
 	timeout     time.Duration
 	ktCli       pb.KeyTransparencyClient
 	directory   *pb.Directory
@@ -115,6 +121,7 @@ func (h *Hammer) Run(ctx context.Context, numWorkers int, c Config) error {
 	if err != nil {
 		return err
 	}
+	h.batchWriter = c.BatchWriterID
 
 	if ok := c.TestTypes["batch"]; ok {
 		// Batch Write users
@@ -236,8 +243,12 @@ func (w *worker) writeOp(ctx context.Context, req *reqArgs) error {
 		return err
 	}
 
+	writer := w.batchWriter
+	if len(req.UserIDs) == 1 {
+		writer = req.UserIDs[0]
+	}
 	cctx, cancel = context.WithTimeout(ctx, w.timeout)
-	err = w.client.BatchQueueUserUpdate(cctx, mutations, w.signers)
+	err = w.client.BatchQueueUserUpdate(cctx, mutations, w.signers, w.callOptions(writer)...)
 	cancel()
 	if err != nil {
 		return err
@@ -28,7 +28,7 @@ func (v *Verifier) VerifyGetUser(req *pb.GetUserRequest, resp *pb.GetUserRespons
 	if err != nil {
 		return err
 	}
-	return v.VerifyMapLeaf(req.DirectoryId, req.UserId, resp.Leaf, mr)
+	return v.VerifyMapLeaf(req.DirectoryId, req.UserId, req.AppId, resp.Leaf, mr)
 }
 
 // VerifyBatchGetUser verifies that the retrieved profiles are correct.
@@ -42,7 +42,7 @@ func (v *Verifier) VerifyBatchGetUser(req *pb.BatchGetUserRequest, resp *pb.Batc
 		return err
 	}
 	for userID, leaf := range resp.MapLeavesByUserId {
-		if err := v.VerifyMapLeaf(req.DirectoryId, userID, leaf, mr); err != nil {
+		if err := v.VerifyMapLeaf(req.DirectoryId, userID, req.AppId, leaf, mr); err != nil {
 			return err
 		}
 	}
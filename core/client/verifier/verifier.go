@@ -101,9 +101,9 @@ func NewFromDirectory(config *pb.Directory, f LogTrackerFactory) (*Verifier, err
 	return New(vrfPubKey, mapVerifier, logVerifier, tracker), nil
 }
 
-// Index computes the index from a VRF proof.
-func (v *Verifier) Index(vrfProof []byte, directoryID, userID string) ([]byte, error) {
-	index, err := v.vrf.ProofToHash([]byte(userID), vrfProof)
+// Index computes the index of userID's entry in the application appID from a VRF proof.
+func (v *Verifier) Index(vrfProof []byte, directoryID, userID, appID string) ([]byte, error) {
+	index, err := v.vrf.ProofToHash(vrf.UniqueID(userID, appID), vrfProof)
 	if err != nil {
 		return nil, fmt.Errorf("vrf.ProofToHash(): %v", err)
 	}
@@ -114,12 +114,12 @@ func (v *Verifier) Index(vrfProof []byte, directoryID, userID string) ([]byte, e
 //  - Verify commitment.
 //  - Verify VRF and index.
 //  - Verify map inclusion proof.
-func (v *Verifier) VerifyMapLeaf(directoryID, userID string,
+func (v *Verifier) VerifyMapLeaf(directoryID, userID, appID string,
 	in *pb.MapLeaf, mapRoot *types.MapRootV1) error {
 	if mapRoot == nil {
 		return status.Errorf(codes.Internal, "nil MapRoot")
 	}
-	glog.V(5).Infof("VerifyMapLeaf(%v/%v): %# v", directoryID, userID, in)
+	glog.V(5).Infof("VerifyMapLeaf(%v/%v/%v): %# v", directoryID, userID, appID, in)
 
 	// Unpack the merkle tree leaf value.
 	leafValue := in.GetMapInclusion().GetLeaf().GetLeafValue()
@@ -132,9 +132,15 @@ func (v *Verifier) VerifyMapLeaf(directoryID, userID string,
 		return err
 	}
 
-	// If this is not a proof of absence, verify the connection between
-	// profileData and the commitment in the merkle tree leaf.
-	if in.GetCommitted() != nil {
+	// Tombstones do not commit to any data.
+	if e.GetDeleted() && (len(e.GetCommitment()) != 0 || len(in.GetCommitted().GetData()) != 0) {
+		v.verbose.Printf("✗ Tombstone verification failed.")
+		return fmt.Errorf("deleted entry has committed data")
+	}
+
+	// If this is not a proof of absence or a tombstone, verify the connection
+	// between profileData and the commitment in the merkle tree leaf.
+	if in.GetCommitted() != nil && !e.GetDeleted() {
 		commitment := e.GetCommitment()
 		data := in.GetCommitted().GetData()
 		nonce := in.GetCommitted().GetKey()
@@ -145,7 +151,7 @@ func (v *Verifier) VerifyMapLeaf(directoryID, userID string,
 	}
 	v.verbose.Printf("✓ Commitment verified.")
 
-	index, err := v.Index(in.GetVrfProof(), directoryID, userID)
+	index, err := v.Index(in.GetVrfProof(), directoryID, userID, appID)
 	if err != nil {
 		v.verbose.Printf("✗ VRF verification failed.")
 		return err
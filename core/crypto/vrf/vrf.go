@@ -17,8 +17,15 @@ package vrf
 
 import (
 	"crypto"
+	"encoding/binary"
+	"unicode/utf8"
 )
 
+// appIDPrefix starts every VRF input that includes an application ID.
+// 0xff never occurs in UTF-8, so such inputs cannot collide with a plain
+// user ID.
+const appIDPrefix = 0xff
+
 // A VRF is a pseudorandom function f_k from a secret key k, such that that
 // knowledge of k not only enables one to evaluate f_k at for any message m,
 // but also to provide an NP-proof that the value f_k(m) is indeed correct
@@ -38,3 +45,34 @@ type PublicKey interface {
 	// ProofToHash verifies the NP-proof supplied by Proof and outputs Index.
 	ProofToHash(m, proof []byte) (index [32]byte, err error)
 }
+
+// UniqueID returns the VRF input for userID's entry in the application appID.
+// An empty appID returns userID unchanged, so that entries created before
+// application IDs were introduced keep their index.
+//
+// Inputs are unique only if userID is valid UTF-8: a user ID starting with
+// 0xff could equal the input of another user ID and appID. Callers must
+// reject other user IDs, see ValidUserID.
+func UniqueID(userID, appID string) []byte {
+	if appID == "" {
+		return []byte(userID)
+	}
+	b := make([]byte, 0, 1+4+len(userID)+4+len(appID))
+	b = append(b, appIDPrefix)
+	b = appendLengthPrefixed(b, userID)
+	b = appendLengthPrefixed(b, appID)
+	return b
+}
+
+// ValidUserID returns true if UniqueID(userID, appID) cannot collide with the
+// input of any other valid user ID and application ID.
+func ValidUserID(userID string) bool {
+	return utf8.ValidString(userID)
+}
+
+func appendLengthPrefixed(b []byte, s string) []byte {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(s)))
+	b = append(b, l[:]...)
+	return append(b, s...)
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vrf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUniqueID(t *testing.T) {
+	for _, tc := range []struct {
+		userID, appID string
+		want          []byte
+	}{
+		{userID: "alice", want: []byte("alice")},
+		{userID: "alice", appID: "app", want: []byte("\xff\x00\x00\x00\x05alice\x00\x00\x00\x03app")},
+		{userID: "", appID: "app", want: []byte("\xff\x00\x00\x00\x00\x00\x00\x00\x03app")},
+	} {
+		if got := UniqueID(tc.userID, tc.appID); !bytes.Equal(got, tc.want) {
+			t.Errorf("UniqueID(%q, %q): %q, want %q", tc.userID, tc.appID, got, tc.want)
+		}
+	}
+
+	// Different splits of the same characters must not collide.
+	a := UniqueID("ab", "c")
+	b := UniqueID("a", "bc")
+	if bytes.Equal(a, b) {
+		t.Errorf("UniqueID(ab, c) == UniqueID(a, bc): %q", a)
+	}
+}
+
+func TestUniqueIDCollision(t *testing.T) {
+	// A user ID that is not UTF-8 can spell out the input of another user in
+	// an application. ValidUserID must reject it.
+	forged := string(UniqueID("alice", "app"))
+	if !bytes.Equal(UniqueID(forged, ""), UniqueID("alice", "app")) {
+		t.Fatalf("UniqueID(%q, \"\") no longer collides with UniqueID(alice, app)", forged)
+	}
+	for _, tc := range []struct {
+		userID string
+		want   bool
+	}{
+		{userID: "alice", want: true},
+		{userID: "", want: true},
+		{userID: "ålice", want: true},
+		{userID: forged, want: false},
+		{userID: "\xff", want: false},
+		{userID: "alice\xc3", want: false},
+	} {
+		if got := ValidUserID(tc.userID); got != tc.want {
+			t.Errorf("ValidUserID(%q): %v, want %v", tc.userID, got, tc.want)
+		}
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/golang/protobuf/proto"
 	tpb "github.com/google/trillian"
 	"github.com/google/trillian/crypto/keyspb"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
 // Directory stores configuration information for a single Key Transparency instance.
@@ -36,6 +38,13 @@ type Directory struct {
 	// TODO(gbelvin): specify mutation function
 	Deleted          bool
 	DeletedTimestamp time.Time
+	// RateLimits limits how quickly updates may be queued. May be nil.
+	RateLimits *pb.RateLimits
+	// Retention limits how long applied mutations are kept. May be nil.
+	Retention *pb.RetentionPolicy
+	// AllowRecovery permits mutations signed by the keys of a tombstone to
+	// replace it. It is set when the directory is created.
+	AllowRecovery bool
 }
 
 // Storage is an interface for storing multi-tenant configuration information.
@@ -50,4 +59,8 @@ type Storage interface {
 	SetDelete(ctx context.Context, directoryID string, isDeleted bool) error
 	// HardDelete the directory.
 	Delete(ctx context.Context, directoryID string) error
+	// SetRateLimits replaces the rate limits of the directory.
+	SetRateLimits(ctx context.Context, directoryID string, limits *pb.RateLimits) error
+	// SetRetention replaces the retention policy of the directory.
+	SetRetention(ctx context.Context, directoryID string, retention *pb.RetentionPolicy) error
 }
@@ -16,14 +16,20 @@ package fake
 
 import (
 	"context"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/google/keytransparency/core/directory"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
 // DirectoryStorage implements directory.Storage
 type DirectoryStorage struct {
+	mu          sync.RWMutex
 	directories map[string]*directory.Directory
 }
 
@@ -34,44 +40,88 @@ func NewDirectoryStorage() *DirectoryStorage {
 	}
 }
 
-// List returns a list of active directories
+// List returns a list of directories ordered by ID, including soft deleted ones if deleted is set.
 func (a *DirectoryStorage) List(ctx context.Context, deleted bool) ([]*directory.Directory, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	ret := make([]*directory.Directory, 0, len(a.directories))
 	for _, d := range a.directories {
-		ret = append(ret, d)
+		if d.Deleted && !deleted {
+			continue
+		}
+		dd := *d
+		ret = append(ret, &dd)
 	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].DirectoryID < ret[j].DirectoryID })
 	return ret, nil
 }
 
 // Write adds a new directory.
 func (a *DirectoryStorage) Write(ctx context.Context, d *directory.Directory) error {
-	a.directories[d.DirectoryID] = d
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.directories[d.DirectoryID]; ok {
+		return status.Errorf(codes.AlreadyExists, "Directory %v already exists", d.DirectoryID)
+	}
+	dd := *d
+	a.directories[d.DirectoryID] = &dd
 	return nil
 }
 
 // Read returns existing directories.
 func (a *DirectoryStorage) Read(ctx context.Context, id string, showDeleted bool) (*directory.Directory, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 	d, ok := a.directories[id]
 	if !ok || d.Deleted && !showDeleted {
 		return nil, status.Errorf(codes.NotFound, "Directory %v not found", id)
 	}
-	return d, nil
+	dd := *d
+	return &dd, nil
 }
 
 // SetDelete deletes or undeletes a directory.
 func (a *DirectoryStorage) SetDelete(ctx context.Context, id string, isDeleted bool) error {
-	_, ok := a.directories[id]
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, ok := a.directories[id]
+	if !ok {
+		return status.Errorf(codes.NotFound, "Directory %v not found", id)
+	}
+	d.Deleted = isDeleted
+	d.DeletedTimestamp = time.Now()
+	return nil
+}
+
+// SetRateLimits replaces the rate limits of a directory.
+func (a *DirectoryStorage) SetRateLimits(ctx context.Context, id string, limits *pb.RateLimits) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, ok := a.directories[id]
 	if !ok {
 		return status.Errorf(codes.NotFound, "Directory %v not found", id)
 	}
-	a.directories[id].Deleted = isDeleted
+	d.RateLimits = limits
+	return nil
+}
+
+// SetRetention replaces the retention policy of a directory.
+func (a *DirectoryStorage) SetRetention(ctx context.Context, id string, retention *pb.RetentionPolicy) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	d, ok := a.directories[id]
+	if !ok {
+		return status.Errorf(codes.NotFound, "Directory %v not found", id)
+	}
+	d.Retention = retention
 	return nil
 }
 
 // Delete permanently deletes a directory.
 func (a *DirectoryStorage) Delete(ctx context.Context, id string) error {
-	_, ok := a.directories[id]
-	if !ok {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.directories[id]; !ok {
 		return status.Errorf(codes.NotFound, "Directory %v not found", id)
 	}
 	delete(a.directories, id)
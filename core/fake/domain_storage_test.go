@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/integration/storagetest"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -105,3 +106,10 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+func TestDirectoryStorageIntegration(t *testing.T) {
+	storagetest.RunDirectoryStorageTests(t,
+		func(context.Context, *testing.T) (directory.Storage, func(context.Context)) {
+			return NewDirectoryStorage(), func(context.Context) {}
+		})
+}
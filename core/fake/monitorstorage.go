@@ -15,11 +15,14 @@
 package fake
 
 import (
+	"sync"
+
 	"github.com/google/keytransparency/core/monitorstorage"
 )
 
 // MonitorStorage is an in-memory store for the monitoring results.
 type MonitorStorage struct {
+	mu     sync.RWMutex
 	store  map[int64]*monitorstorage.Result
 	latest int64
 }
@@ -33,23 +36,31 @@ func NewMonitorStorage() *MonitorStorage {
 
 // Set stores the given data as a MonitoringResult which can be retrieved by Get.
 func (s *MonitorStorage) Set(revision int64, r *monitorstorage.Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if _, ok := s.store[revision]; ok {
 		return monitorstorage.ErrAlreadyStored
 	}
 	s.store[revision] = r
-	s.latest = revision
+	if revision > s.latest {
+		s.latest = revision
+	}
 	return nil
 }
 
 // Get returns the Result for the given revision. It returns ErrNotFound if the revision does not exist.
 func (s *MonitorStorage) Get(revision int64) (*monitorstorage.Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if result, ok := s.store[revision]; ok {
 		return result, nil
 	}
 	return nil, monitorstorage.ErrNotFound
 }
 
-// LatestRevision is a convenience method to retrieve the latest stored revision.
+// LatestRevision is a convenience method to retrieve the highest stored revision.
 func (s *MonitorStorage) LatestRevision() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.latest
 }
@@ -37,6 +37,8 @@ type Env struct {
 	Directory *pb.Directory
 	Timeout   time.Duration
 	CallOpts  CallOptions
+	// AdminCallOpts authenticate as a principal that may update any user in Directory.
+	AdminCallOpts []grpc.CallOption
 }
 
 // CallOptions returns grpc.CallOptions for the requested user.
@@ -47,6 +49,9 @@ type CallOptions func(userID string) []grpc.CallOption
 type NamedTestFn struct {
 	Name string
 	Fn   func(context.Context, *Env, *testing.T) []*tpb.Action
+	// AllowRecovery runs the test in a directory that allows deleted entries
+	// to be recovered.
+	AllowRecovery bool
 }
 
 // AllTests contains all the integration tests.
@@ -58,8 +63,13 @@ var AllTests = []NamedTestFn{
 	{Name: "TestBatchGetUser", Fn: TestBatchGetUser},
 	{Name: "TestListHistory", Fn: TestListHistory},
 	{Name: "TestBatchUpdate", Fn: TestBatchUpdate},
+	{Name: "TestBatchUpdateDenied", Fn: TestBatchUpdateDenied},
 	{Name: "TestBatchCreate", Fn: TestBatchCreate},
 	{Name: "TestBatchListUserRevisions", Fn: TestBatchListUserRevisions},
+	{Name: "TestDeleteUser", Fn: TestDeleteUser},
+	{Name: "TestRecoverUser", Fn: TestRecoverUser, AllowRecovery: true},
+	{Name: "TestAppEntries", Fn: TestAppEntries},
+	{Name: "TestHistoricalLookups", Fn: TestHistoricalLookups},
 	// Monitor Tests
 	{Name: "TestMonitor", Fn: TestMonitor},
 }
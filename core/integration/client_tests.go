@@ -32,6 +32,7 @@ import (
 
 	"github.com/google/tink/go/keyset"
 	"github.com/google/tink/go/tink"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -143,7 +144,7 @@ func TestBatchCreate(ctx context.Context, env *Env, t *testing.T) []*tpb.Action
 
 			cctx, cancel := context.WithTimeout(ctx, env.Timeout)
 			defer cancel()
-			if err := env.Client.BatchCreateUser(cctx, users, signers1); err != nil {
+			if err := env.Client.BatchCreateUser(cctx, users, signers1, env.AdminCallOpts...); err != nil {
 				t.Fatalf("BatchCreateUser(): %v", err)
 			}
 		})
@@ -181,7 +182,7 @@ func TestBatchUpdate(ctx context.Context, env *Env, t *testing.T) []*tpb.Action
 			if err != nil {
 				t.Fatalf("BatchCreateMutation(): %v", err)
 			}
-			if err := env.Client.BatchQueueUserUpdate(ctx, mutations, signers1); err != nil {
+			if err := env.Client.BatchQueueUserUpdate(ctx, mutations, signers1, env.AdminCallOpts...); err != nil {
 				t.Fatalf("BatchQueueUserUpdate(): %v", err)
 			}
 		})
@@ -189,6 +190,37 @@ func TestBatchUpdate(ctx context.Context, env *Env, t *testing.T) []*tpb.Action
 	return nil
 }
 
+// TestBatchUpdateDenied verifies that batch updates are rejected when the
+// caller is not authorized to update every user in the batch.
+func TestBatchUpdateDenied(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
+	signers1 := testutil.SignKeysetsFromPEMs(testPrivKey1)
+	authorizedKeys1 := testutil.VerifyKeysetFromPEMs(testPubKey1)
+
+	users := []*client.User{
+		{UserID: "alice", PublicKeyData: []byte("alice-key"), AuthorizedKeys: authorizedKeys1},
+		{UserID: "bob", PublicKeyData: []byte("bob-key"), AuthorizedKeys: authorizedKeys1},
+	}
+	mutations, err := env.Client.BatchCreateMutation(ctx, users)
+	if err != nil {
+		t.Fatalf("BatchCreateMutation(): %v", err)
+	}
+	err = env.Client.BatchQueueUserUpdate(ctx, mutations, signers1, env.CallOpts("alice")...)
+	st := status.Convert(err)
+	if got, want := st.Code(), codes.PermissionDenied; got != want {
+		t.Fatalf("BatchQueueUserUpdate(): %v, want %v", err, want)
+	}
+	var denied []string
+	for _, d := range st.Details() {
+		if ri, ok := d.(*errdetails.ResourceInfo); ok {
+			denied = append(denied, ri.ResourceName)
+		}
+	}
+	if got, want := denied, []string{"bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BatchQueueUserUpdate(): denied %v, want %v", got, want)
+	}
+	return nil
+}
+
 // TestEmptyGetAndUpdate verifies set/get semantics.
 func TestEmptyGetAndUpdate(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
 	go runSequencer(ctx, t, env)
@@ -328,7 +360,7 @@ func TestEmptyGetAndUpdate(ctx context.Context, env *Env, t *testing.T) []*tpb.A
 				}
 				cctx, cancel := context.WithTimeout(ctx, env.Timeout)
 				defer cancel()
-				_, err := env.Client.Update(cctx, u, tc.signers)
+				_, err := env.Client.Update(cctx, u, tc.signers, tc.opts...)
 				if err != nil {
 					t.Errorf("Update(%v): %v", tc.userID, err)
 				}
@@ -338,6 +370,183 @@ func TestEmptyGetAndUpdate(ctx context.Context, env *Env, t *testing.T) []*tpb.A
 	return transcript
 }
 
+// TestDeleteUser verifies that deleted users are reported as deleted, and
+// cannot be updated afterwards.
+func TestDeleteUser(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
+	go runSequencer(ctx, t, env)
+
+	userID := "dave"
+	signers := testutil.SignKeysetsFromPEMs(testPrivKey1)
+	u := &client.User{
+		UserID:         userID,
+		PublicKeyData:  []byte("dave-key1"),
+		AuthorizedKeys: testutil.VerifyKeysetFromPEMs(testPubKey1),
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, env.Timeout)
+	defer cancel()
+	if _, err := env.Client.Update(cctx, u, signers, env.CallOpts(userID)...); err != nil {
+		t.Fatalf("Update(%v): %v", userID, err)
+	}
+	if _, err := env.Client.Delete(cctx, userID, signers, env.CallOpts(userID)...); err != nil {
+		t.Fatalf("Delete(%v): %v", userID, err)
+	}
+	if _, _, err := env.Client.VerifiedGetUser(cctx, userID); err != client.ErrDeleted {
+		t.Errorf("VerifiedGetUser(%v): %v, want %v", userID, err, client.ErrDeleted)
+	}
+	if _, err := env.Client.Update(cctx, u, signers, env.CallOpts(userID)...); err != client.ErrDeleted {
+		t.Errorf("Update(%v) after delete: %v, want %v", userID, err, client.ErrDeleted)
+	}
+	return nil
+}
+
+// TestRecoverUser verifies that deleted users can be updated again by the
+// keys of their tombstone in directories that allow recovery.
+func TestRecoverUser(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
+	go runSequencer(ctx, t, env)
+
+	userID := "frank"
+	signers := testutil.SignKeysetsFromPEMs(testPrivKey1)
+	u := &client.User{
+		UserID:         userID,
+		PublicKeyData:  []byte("frank-key1"),
+		AuthorizedKeys: testutil.VerifyKeysetFromPEMs(testPubKey1),
+	}
+
+	cctx, cancel := context.WithTimeout(ctx, env.Timeout)
+	defer cancel()
+	if _, err := env.Client.Update(cctx, u, signers, env.CallOpts(userID)...); err != nil {
+		t.Fatalf("Update(%v): %v", userID, err)
+	}
+	if _, err := env.Client.Delete(cctx, userID, signers, env.CallOpts(userID)...); err != nil {
+		t.Fatalf("Delete(%v): %v", userID, err)
+	}
+	if _, _, err := env.Client.VerifiedGetUser(cctx, userID); err != client.ErrDeleted {
+		t.Fatalf("VerifiedGetUser(%v): %v, want %v", userID, err, client.ErrDeleted)
+	}
+
+	u.PublicKeyData = []byte("frank-key2")
+	if _, err := env.Client.Update(cctx, u, signers, env.CallOpts(userID)...); err != nil {
+		t.Fatalf("Update(%v) after delete: %v", userID, err)
+	}
+	_, got, err := env.Client.GetUser(cctx, userID)
+	if err != nil {
+		t.Fatalf("GetUser(%v): %v", userID, err)
+	}
+	if !bytes.Equal(got, u.PublicKeyData) {
+		t.Errorf("GetUser(%v): %s, want %s", userID, got, u.PublicKeyData)
+	}
+	return nil
+}
+
+// TestAppEntries verifies that a user's entries for different applications
+// are independent of each other.
+func TestAppEntries(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
+	go runSequencer(ctx, t, env)
+
+	userID := "erin"
+	signers := testutil.SignKeysetsFromPEMs(testPrivKey1)
+	authorizedKeys := testutil.VerifyKeysetFromPEMs(testPubKey1)
+
+	for _, tc := range []struct {
+		appID   string
+		profile []byte
+	}{
+		{appID: "", profile: []byte("erin-default")},
+		{appID: "messaging", profile: []byte("erin-messaging")},
+		{appID: "codesigning", profile: []byte("erin-codesigning")},
+	} {
+		cli := *env.Client
+		cli.AppID = tc.appID
+		u := &client.User{
+			UserID:         userID,
+			PublicKeyData:  tc.profile,
+			AuthorizedKeys: authorizedKeys,
+		}
+		cctx, cancel := context.WithTimeout(ctx, env.Timeout)
+		_, err := cli.Update(cctx, u, signers, env.CallOpts(userID)...)
+		cancel()
+		if err != nil {
+			t.Fatalf("Update(%v, app: %q): %v", userID, tc.appID, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		appID string
+		want  []byte
+	}{
+		{appID: "", want: []byte("erin-default")},
+		{appID: "messaging", want: []byte("erin-messaging")},
+		{appID: "codesigning", want: []byte("erin-codesigning")},
+		{appID: "unused", want: nil},
+	} {
+		cli := *env.Client
+		cli.AppID = tc.appID
+		_, got, err := cli.GetUser(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUser(%v, app: %q): %v", userID, tc.appID, err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("GetUser(%v, app: %q): %s, want %s", userID, tc.appID, got, tc.want)
+		}
+	}
+	return nil
+}
+
+// TestHistoricalLookups tests reading a user's entry at a past revision and
+// at a past point in time.
+func TestHistoricalLookups(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
+	go runSequencer(ctx, t, env)
+
+	userID := "frank"
+	signers := testutil.SignKeysetsFromPEMs(testPrivKey1)
+	authorizedKeys := testutil.VerifyKeysetFromPEMs(testPubKey1)
+
+	profiles := [][]byte{[]byte("frank-key1"), []byte("frank-key2")}
+	roots := make([]*types.MapRootV1, 0, len(profiles))
+	for _, profile := range profiles {
+		u := &client.User{
+			UserID:         userID,
+			PublicKeyData:  profile,
+			AuthorizedKeys: authorizedKeys,
+		}
+		cctx, cancel := context.WithTimeout(ctx, env.Timeout)
+		_, err := env.Client.Update(cctx, u, signers, env.CallOpts(userID)...)
+		cancel()
+		if err != nil {
+			t.Fatalf("Update(%v): %v", userID, err)
+		}
+		mr, _, err := env.Client.GetUser(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetUser(%v): %v", userID, err)
+		}
+		roots = append(roots, mr)
+	}
+
+	for i, mr := range roots {
+		_, leaf, err := env.Client.VerifiedGetUserByRevision(ctx, userID, int64(mr.Revision))
+		if err != nil {
+			t.Fatalf("VerifiedGetUserByRevision(%v, %v): %v", userID, mr.Revision, err)
+		}
+		if got, want := leaf.GetCommitted().GetData(), profiles[i]; !bytes.Equal(got, want) {
+			t.Errorf("VerifiedGetUserByRevision(%v, %v): %s, want %s", userID, mr.Revision, got, want)
+		}
+
+		at := time.Unix(0, int64(mr.TimestampNanos))
+		gotRoot, got, err := env.Client.GetUserAtTime(ctx, userID, at)
+		if err != nil {
+			t.Fatalf("GetUserAtTime(%v, %v): %v", userID, at, err)
+		}
+		if gotRoot.Revision != mr.Revision {
+			t.Errorf("GetUserAtTime(%v, %v).Revision: %v, want %v", userID, at, gotRoot.Revision, mr.Revision)
+		}
+		if want := profiles[i]; !bytes.Equal(got, want) {
+			t.Errorf("GetUserAtTime(%v, %v): %s, want %s", userID, at, got, want)
+		}
+	}
+	return nil
+}
+
 // TestBatchGetUser tests fetching multiple users in a single request.
 func TestBatchGetUser(ctx context.Context, env *Env, t *testing.T) []*tpb.Action {
 	go runSequencer(ctx, t, env)
@@ -373,7 +582,7 @@ func TestBatchGetUser(ctx context.Context, env *Env, t *testing.T) []*tpb.Action
 	}
 	cctx, cancel := context.WithTimeout(ctx, env.Timeout)
 	defer cancel()
-	if err := env.Client.BatchCreateUser(cctx, users, signers1); err != nil {
+	if err := env.Client.BatchCreateUser(cctx, users, signers1, env.AdminCallOpts...); err != nil {
 		t.Fatalf("BatchCreateUser(): %v", err)
 	}
 	if err := env.Client.WaitForRevision(cctx, 1); err != nil {
@@ -21,6 +21,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/sequencer"
+	"github.com/google/keytransparency/core/sequencer/election"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -42,6 +43,7 @@ func RunBatchStorageTests(t *testing.T, factory batchStorageFactory) {
 		"TestWriteBatch": b.TestWriteBatch,
 		"TestReadBatch":  b.TestReadBatch,
 		"TestHighestRev": b.TestHighestRev,
+		"TestFencing":    b.TestFencing,
 	} {
 		t.Run(name, func(t *testing.T) { f(ctx, t, factory) })
 	}
@@ -141,3 +143,29 @@ func (*BatchTests) TestHighestRev(ctx context.Context, t *testing.T, f batchStor
 		}
 	}
 }
+
+func (*BatchTests) TestFencing(ctx context.Context, t *testing.T, f batchStorageFactory) {
+	domainID := "fencingtest"
+	b, done := f(ctx, t, domainID)
+	defer done(ctx)
+	for _, tc := range []struct {
+		rev   int64
+		token int64
+		want  codes.Code
+	}{
+		// Tests are cumulative.
+		{rev: 1, token: 2, want: codes.OK},
+		{rev: 2, token: 3, want: codes.OK},
+		{rev: 3, token: 2, want: codes.FailedPrecondition},
+		{rev: 3, token: 3, want: codes.OK},
+		{rev: 4, token: 0, want: codes.OK}, // Writes without a token are not fenced.
+	} {
+		err := b.WriteBatchSources(election.WithToken(ctx, tc.token), domainID, tc.rev, &spb.MapMetadata{})
+		if got := status.Code(err); got != tc.want {
+			t.Errorf("WriteBatchSources(rev %v, token %v): %v, want %v", tc.rev, tc.token, err, tc.want)
+		}
+	}
+	if got, err := b.HighestRev(ctx, domainID); err != nil || got != 4 {
+		t.Errorf("HighestRev(): %v, %v, want 4", got, err)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/trillian/crypto/keyspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	tpb "github.com/google/trillian"
+)
+
+// directoryStorageFactory returns a new, empty database object, and a function for cleaning it up.
+type directoryStorageFactory func(ctx context.Context, t *testing.T) (directory.Storage, func(context.Context))
+
+// RunDirectoryStorageTests runs all the directory storage tests against the provided storage implementation.
+func RunDirectoryStorageTests(t *testing.T, factory directoryStorageFactory) {
+	ctx := context.Background()
+	b := &directoryTests{}
+	for name, f := range map[string]func(ctx context.Context, t *testing.T, f directoryStorageFactory){
+		// TODO(gbelvin): Discover test methods via reflection.
+		"TestWriteRead":      b.TestWriteRead,
+		"TestWriteDuplicate": b.TestWriteDuplicate,
+		"TestNotFound":       b.TestNotFound,
+		"TestList":           b.TestList,
+		"TestSetDelete":      b.TestSetDelete,
+		"TestDelete":         b.TestDelete,
+		"TestSetPolicies":    b.TestSetPolicies,
+		"TestConcurrent":     b.TestConcurrent,
+	} {
+		t.Run(name, func(t *testing.T) { f(ctx, t, factory) })
+	}
+}
+
+type directoryTests struct{}
+
+// newDirectory returns a directory with every field that storage must preserve.
+func newDirectory(directoryID string) *directory.Directory {
+	return &directory.Directory{
+		DirectoryID: directoryID,
+		Map:         &tpb.Tree{TreeId: 1},
+		Log:         &tpb.Tree{TreeId: 2},
+		VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+		VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+		MinInterval: 1 * time.Second,
+		MaxInterval: 5 * time.Second,
+	}
+}
+
+func listIDs(ctx context.Context, t *testing.T, s directory.Storage, deleted bool) []string {
+	t.Helper()
+	directories, err := s.List(ctx, deleted)
+	if err != nil {
+		t.Fatalf("List(%v): %v", deleted, err)
+	}
+	ids := []string{}
+	for _, d := range directories {
+		ids = append(ids, d.DirectoryID)
+	}
+	return ids
+}
+
+func (directoryTests) TestWriteRead(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	want := newDirectory("TestWriteRead")
+	want.RateLimits = &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 0.5, Burst: 2}}
+	want.Retention = &pb.RetentionPolicy{Revisions: 10, MaxAge: ptypes.DurationProto(time.Hour)}
+	want.AllowRecovery = true
+	if err := s.Write(ctx, want); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	for _, showDeleted := range []bool{false, true} {
+		got, err := s.Read(ctx, want.DirectoryID, showDeleted)
+		if err != nil {
+			t.Fatalf("Read(%v): %v", showDeleted, err)
+		}
+		if !cmp.Equal(got, want, cmp.Comparer(proto.Equal)) {
+			t.Errorf("Read(%v): diff(-got, +want): %v", showDeleted, cmp.Diff(got, want, cmp.Comparer(proto.Equal)))
+		}
+	}
+}
+
+func (directoryTests) TestWriteDuplicate(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	d := newDirectory("TestWriteDuplicate")
+	if err := s.Write(ctx, d); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	dup := newDirectory(d.DirectoryID)
+	dup.MinInterval = time.Hour
+	if err := s.Write(ctx, dup); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Write(duplicate): %v, want %v", err, codes.AlreadyExists)
+	}
+	got, err := s.Read(ctx, d.DirectoryID, false)
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if got.MinInterval != d.MinInterval {
+		t.Errorf("Read(): MinInterval %v, want the original %v", got.MinInterval, d.MinInterval)
+	}
+}
+
+func (directoryTests) TestNotFound(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	id := "TestNotFound"
+	for _, tc := range []struct {
+		desc string
+		f    func() error
+	}{
+		{desc: "Read", f: func() error { _, err := s.Read(ctx, id, false); return err }},
+		{desc: "Read deleted", f: func() error { _, err := s.Read(ctx, id, true); return err }},
+		{desc: "SetDelete", f: func() error { return s.SetDelete(ctx, id, true) }},
+		{desc: "SetRateLimits", f: func() error { return s.SetRateLimits(ctx, id, &pb.RateLimits{}) }},
+		{desc: "SetRetention", f: func() error { return s.SetRetention(ctx, id, &pb.RetentionPolicy{}) }},
+		{desc: "Delete", f: func() error { return s.Delete(ctx, id) }},
+	} {
+		if err := tc.f(); status.Code(err) != codes.NotFound {
+			t.Errorf("%v(): %v, want %v", tc.desc, err, codes.NotFound)
+		}
+	}
+}
+
+func (directoryTests) TestList(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	if got := listIDs(ctx, t, s, true); len(got) != 0 {
+		t.Errorf("List(): %v, want none", got)
+	}
+	for _, id := range []string{"c", "a", "d", "b"} {
+		if err := s.Write(ctx, newDirectory(id)); err != nil {
+			t.Fatalf("Write(): %v", err)
+		}
+	}
+	if err := s.SetDelete(ctx, "d", true); err != nil {
+		t.Fatalf("SetDelete(): %v", err)
+	}
+	for _, tc := range []struct {
+		deleted bool
+		want    []string
+	}{
+		{deleted: false, want: []string{"a", "b", "c"}},
+		{deleted: true, want: []string{"a", "b", "c", "d"}},
+	} {
+		if got := listIDs(ctx, t, s, tc.deleted); !cmp.Equal(got, tc.want) {
+			t.Errorf("List(%v): %v, want %v in order", tc.deleted, got, tc.want)
+		}
+	}
+}
+
+func (directoryTests) TestSetDelete(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	id := "TestSetDelete"
+	if err := s.Write(ctx, newDirectory(id)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	// Storage may keep timestamps to the second.
+	before := time.Now().Add(-time.Second)
+	if err := s.SetDelete(ctx, id, true); err != nil {
+		t.Fatalf("SetDelete(true): %v", err)
+	}
+	after := time.Now().Add(time.Second)
+
+	if _, err := s.Read(ctx, id, false); status.Code(err) != codes.NotFound {
+		t.Errorf("Read(soft deleted, false): %v, want %v", err, codes.NotFound)
+	}
+	d, err := s.Read(ctx, id, true)
+	if err != nil {
+		t.Fatalf("Read(soft deleted, true): %v", err)
+	}
+	if !d.Deleted {
+		t.Errorf("Read(soft deleted, true).Deleted: false, want true")
+	}
+	if d.DeletedTimestamp.Before(before) || d.DeletedTimestamp.After(after) {
+		t.Errorf("Read(soft deleted, true).DeletedTimestamp: %v, want between %v and %v", d.DeletedTimestamp, before, after)
+	}
+	if got := listIDs(ctx, t, s, false); len(got) != 0 {
+		t.Errorf("List(false): %v, want none", got)
+	}
+	listed, err := s.List(ctx, true)
+	if err != nil {
+		t.Fatalf("List(true): %v", err)
+	}
+	if len(listed) != 1 {
+		t.Fatalf("List(true): %v directories, want 1", len(listed))
+	}
+	if d := listed[0]; !d.Deleted || d.DeletedTimestamp.Before(before) || d.DeletedTimestamp.After(after) {
+		t.Errorf("List(true): Deleted %v, DeletedTimestamp %v, want true and between %v and %v",
+			d.Deleted, d.DeletedTimestamp, before, after)
+	}
+	// Deleting twice is not an error.
+	if err := s.SetDelete(ctx, id, true); err != nil {
+		t.Errorf("SetDelete(true) again: %v", err)
+	}
+
+	if err := s.SetDelete(ctx, id, false); err != nil {
+		t.Fatalf("SetDelete(false): %v", err)
+	}
+	d, err = s.Read(ctx, id, false)
+	if err != nil {
+		t.Fatalf("Read(undeleted): %v", err)
+	}
+	if d.Deleted {
+		t.Errorf("Read(undeleted).Deleted: true, want false")
+	}
+	if got, want := listIDs(ctx, t, s, false), []string{id}; !cmp.Equal(got, want) {
+		t.Errorf("List(false): %v, want %v", got, want)
+	}
+}
+
+func (directoryTests) TestDelete(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	id := "TestDelete"
+	if err := s.Write(ctx, newDirectory(id)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if err := s.SetDelete(ctx, id, true); err != nil {
+		t.Fatalf("SetDelete(): %v", err)
+	}
+	if err := s.Delete(ctx, id); err != nil {
+		t.Fatalf("Delete(): %v", err)
+	}
+	for _, showDeleted := range []bool{false, true} {
+		if _, err := s.Read(ctx, id, showDeleted); status.Code(err) != codes.NotFound {
+			t.Errorf("Read(%v): %v, want %v", showDeleted, err, codes.NotFound)
+		}
+	}
+	if got := listIDs(ctx, t, s, true); len(got) != 0 {
+		t.Errorf("List(true): %v, want none", got)
+	}
+	// The ID may be reused.
+	if err := s.Write(ctx, newDirectory(id)); err != nil {
+		t.Errorf("Write() after Delete(): %v", err)
+	}
+}
+
+func (directoryTests) TestSetPolicies(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	id := "TestSetPolicies"
+	if err := s.Write(ctx, newDirectory(id)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc       string
+		rateLimits *pb.RateLimits
+		retention  *pb.RetentionPolicy
+	}{
+		{desc: "set",
+			rateLimits: &pb.RateLimits{PerDirectory: &pb.Quota{UpdatesPerSecond: 100, Burst: 10}},
+			retention:  &pb.RetentionPolicy{Revisions: 5}},
+		{desc: "replace",
+			rateLimits: &pb.RateLimits{PerPrincipal: &pb.Quota{UpdatesPerSecond: 1}},
+			retention:  &pb.RetentionPolicy{MaxAge: ptypes.DurationProto(time.Minute)}},
+		{desc: "clear"},
+	} {
+		if err := s.SetRateLimits(ctx, id, tc.rateLimits); err != nil {
+			t.Fatalf("%v: SetRateLimits(): %v", tc.desc, err)
+		}
+		if err := s.SetRetention(ctx, id, tc.retention); err != nil {
+			t.Fatalf("%v: SetRetention(): %v", tc.desc, err)
+		}
+		d, err := s.Read(ctx, id, false)
+		if err != nil {
+			t.Fatalf("%v: Read(): %v", tc.desc, err)
+		}
+		if !proto.Equal(d.RateLimits, tc.rateLimits) {
+			t.Errorf("%v: RateLimits: %v, want %v", tc.desc, d.RateLimits, tc.rateLimits)
+		}
+		if !proto.Equal(d.Retention, tc.retention) {
+			t.Errorf("%v: Retention: %v, want %v", tc.desc, d.Retention, tc.retention)
+		}
+	}
+}
+
+func (directoryTests) TestConcurrent(ctx context.Context, t *testing.T, f directoryStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	const writers, perWriter = 4, 5
+	var wg sync.WaitGroup
+	var want []string
+	for w := 0; w < writers; w++ {
+		ids := make([]string, 0, perWriter)
+		for i := 0; i < perWriter; i++ {
+			ids = append(ids, fmt.Sprintf("dir%d-%d", w, i))
+		}
+		want = append(want, ids...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, id := range ids {
+				if err := s.Write(ctx, newDirectory(id)); err != nil {
+					t.Errorf("Write(%v): %v", id, err)
+					return
+				}
+				if err := s.SetRetention(ctx, id, &pb.RetentionPolicy{Revisions: 1}); err != nil {
+					t.Errorf("SetRetention(%v): %v", id, err)
+					return
+				}
+				if _, err := s.Read(ctx, id, false); err != nil {
+					t.Errorf("Read(%v): %v", id, err)
+					return
+				}
+				if _, err := s.List(ctx, false); err != nil {
+					t.Errorf("List(): %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := listIDs(ctx, t, s, false); !cmp.Equal(got, want) {
+		t.Errorf("List(): %v, want %v", got, want)
+	}
+}
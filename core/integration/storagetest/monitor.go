@@ -0,0 +1,170 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/monitorstorage"
+
+	tpb "github.com/google/trillian"
+)
+
+// monitorStorageFactory returns a new, empty database object, and a function for cleaning it up.
+type monitorStorageFactory func(ctx context.Context, t *testing.T) (monitorstorage.Interface, func(context.Context))
+
+// RunMonitorStorageTests runs all the monitor storage tests against the provided storage implementation.
+func RunMonitorStorageTests(t *testing.T, factory monitorStorageFactory) {
+	ctx := context.Background()
+	b := &monitorTests{}
+	for name, f := range map[string]func(ctx context.Context, t *testing.T, f monitorStorageFactory){
+		// TODO(gbelvin): Discover test methods via reflection.
+		"TestGetNotFound":    b.TestGetNotFound,
+		"TestSetGet":         b.TestSetGet,
+		"TestSetDuplicate":   b.TestSetDuplicate,
+		"TestLatestRevision": b.TestLatestRevision,
+		"TestConcurrent":     b.TestConcurrent,
+	} {
+		t.Run(name, func(t *testing.T) { f(ctx, t, factory) })
+	}
+}
+
+type monitorTests struct{}
+
+func newResult(rev int64) *monitorstorage.Result {
+	return &monitorstorage.Result{
+		Smr:  &tpb.SignedMapRoot{MapRoot: []byte{byte(rev)}},
+		Seen: time.Unix(rev, 0),
+	}
+}
+
+func (monitorTests) TestGetNotFound(ctx context.Context, t *testing.T, f monitorStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	if _, err := s.Get(1); err != monitorstorage.ErrNotFound {
+		t.Errorf("Get(): %v, want %v", err, monitorstorage.ErrNotFound)
+	}
+	if got := s.LatestRevision(); got != 0 {
+		t.Errorf("LatestRevision(): %v, want 0", got)
+	}
+}
+
+func (monitorTests) TestSetGet(ctx context.Context, t *testing.T, f monitorStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	for _, tc := range []struct {
+		rev  int64
+		want *monitorstorage.Result
+	}{
+		{rev: 1, want: newResult(1)},
+		{rev: 2, want: &monitorstorage.Result{
+			Smr:    &tpb.SignedMapRoot{MapRoot: []byte("root")},
+			Seen:   time.Unix(2, 0),
+			Errors: []error{errors.New("bad signature")},
+		}},
+	} {
+		if err := s.Set(tc.rev, tc.want); err != nil {
+			t.Fatalf("Set(%v): %v", tc.rev, err)
+		}
+		got, err := s.Get(tc.rev)
+		if err != nil {
+			t.Fatalf("Get(%v): %v", tc.rev, err)
+		}
+		if !proto.Equal(got.Smr, tc.want.Smr) {
+			t.Errorf("Get(%v).Smr: %v, want %v", tc.rev, got.Smr, tc.want.Smr)
+		}
+		if !got.Seen.Equal(tc.want.Seen) {
+			t.Errorf("Get(%v).Seen: %v, want %v", tc.rev, got.Seen, tc.want.Seen)
+		}
+		if len(got.Errors) != len(tc.want.Errors) {
+			t.Errorf("Get(%v).Errors: %v, want %v", tc.rev, got.Errors, tc.want.Errors)
+		}
+	}
+}
+
+func (monitorTests) TestSetDuplicate(ctx context.Context, t *testing.T, f monitorStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	want := newResult(1)
+	if err := s.Set(1, want); err != nil {
+		t.Fatalf("Set(): %v", err)
+	}
+	if err := s.Set(1, newResult(2)); err != monitorstorage.ErrAlreadyStored {
+		t.Errorf("Set(duplicate): %v, want %v", err, monitorstorage.ErrAlreadyStored)
+	}
+	got, err := s.Get(1)
+	if err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	if !proto.Equal(got.Smr, want.Smr) {
+		t.Errorf("Get(): %v, want the original %v", got.Smr, want.Smr)
+	}
+}
+
+func (monitorTests) TestLatestRevision(ctx context.Context, t *testing.T, f monitorStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	for _, tc := range []struct {
+		rev  int64
+		want int64
+	}{
+		{rev: 2, want: 2},
+		{rev: 5, want: 5},
+		{rev: 3, want: 5}, // Out of order results do not move the latest revision back.
+		{rev: 1, want: 5},
+	} {
+		if err := s.Set(tc.rev, newResult(tc.rev)); err != nil {
+			t.Fatalf("Set(%v): %v", tc.rev, err)
+		}
+		if got := s.LatestRevision(); got != tc.want {
+			t.Errorf("Set(%v): LatestRevision(): %v, want %v", tc.rev, got, tc.want)
+		}
+	}
+}
+
+func (monitorTests) TestConcurrent(ctx context.Context, t *testing.T, f monitorStorageFactory) {
+	s, done := f(ctx, t)
+	defer done(ctx)
+	const writers, perWriter = 4, 10
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		w := w
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				rev := int64(i*writers + w + 1)
+				if err := s.Set(rev, newResult(rev)); err != nil {
+					t.Errorf("Set(%v): %v", rev, err)
+					return
+				}
+				if _, err := s.Get(rev); err != nil {
+					t.Errorf("Get(%v): %v", rev, err)
+					return
+				}
+				s.LatestRevision()
+			}
+		}()
+	}
+	wg.Wait()
+	if got, want := s.LatestRevision(), int64(writers*perWriter); got != want {
+		t.Errorf("LatestRevision(): %v, want %v", got, want)
+	}
+}
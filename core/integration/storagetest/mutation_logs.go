@@ -17,6 +17,7 @@ package storagetest
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 
@@ -24,6 +25,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/keyserver"
 	"github.com/google/keytransparency/core/water"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
@@ -37,8 +40,10 @@ func RunMutationLogsTests(t *testing.T, factory mutationLogsFactory) {
 	b := &mutationLogsTests{}
 	for name, f := range map[string]func(ctx context.Context, t *testing.T, f mutationLogsFactory){
 		// TODO(gbelvin): Discover test methods via reflection.
-		"TestReadLog":      b.TestReadLog,
-		"TestReadLogExact": b.TestReadLogExact,
+		"TestReadLog":        b.TestReadLog,
+		"TestReadLogExact":   b.TestReadLogExact,
+		"TestReadLogContent": b.TestReadLogContent,
+		"TestSendRequestIDs": b.TestSendRequestIDs,
 	} {
 		t.Run(name, func(t *testing.T) { f(ctx, t, factory) })
 	}
@@ -156,3 +161,90 @@ func (mutationLogsTests) TestReadLogExact(ctx context.Context, t *testing.T, new
 		})
 	}
 }
+
+// TestReadLogContent ensures that mutations are read back with their committed data.
+func (mutationLogsTests) TestReadLogContent(ctx context.Context, t *testing.T, newForTest mutationLogsFactory) {
+	directoryID := "TestReadLogContent"
+	logID := int64(5) // Any log ID.
+	m, done := newForTest(ctx, t, directoryID, logID)
+	defer done(ctx)
+	want := []*pb.EntryUpdate{
+		{
+			Mutation:  &pb.SignedEntry{Entry: mustMarshal(t, &pb.Entry{Index: []byte{1}})},
+			Committed: &pb.Committed{Key: []byte("key"), Data: []byte("data")},
+		},
+		{
+			Mutation: &pb.SignedEntry{Entry: mustMarshal(t, &pb.Entry{Index: []byte{2}})},
+		},
+	}
+	if _, err := m.Send(ctx, directoryID, logID, want...); err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+
+	rows, err := m.ReadLog(ctx, directoryID, logID, water.Mark{}, water.NewMark(math.MaxInt64), 100)
+	if err != nil {
+		t.Fatalf("ReadLog(): %v", err)
+	}
+	if got := len(rows); got != len(want) {
+		t.Fatalf("ReadLog(): len: %v, want %v", got, len(want))
+	}
+	for i, r := range rows {
+		if !proto.Equal(r.Mutation, want[i].Mutation) {
+			t.Errorf("ReadLog()[%d].Mutation: %v, want %v", i, r.Mutation, want[i].Mutation)
+		}
+		if !proto.Equal(r.ExtraData, want[i].Committed) {
+			t.Errorf("ReadLog()[%d].ExtraData: %v, want %v", i, r.ExtraData, want[i].Committed)
+		}
+	}
+}
+
+// TestSendRequestIDs ensures that batches are queued at most once per request ID.
+func (mutationLogsTests) TestSendRequestIDs(ctx context.Context, t *testing.T, newForTest mutationLogsFactory) {
+	directoryID := "TestSendRequestIDs"
+	logID := int64(5) // Any log ID.
+	m, done := newForTest(ctx, t, directoryID, logID)
+	defer done(ctx)
+	update := func(i byte, requestID string) *pb.EntryUpdate {
+		return &pb.EntryUpdate{Mutation: &pb.SignedEntry{Entry: []byte{i}}, RequestId: requestID}
+	}
+
+	wm, err := m.Send(ctx, directoryID, logID, update(0, "a"), update(1, "b"))
+	if err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc     string
+		updates  []*pb.EntryUpdate
+		wantCode codes.Code
+		wantWM   bool
+	}{
+		{desc: "retry", updates: []*pb.EntryUpdate{update(0, "a"), update(1, "b")}, wantWM: true},
+		{desc: "reused id", updates: []*pb.EntryUpdate{update(0, "a"), update(2, "c")}, wantCode: codes.AlreadyExists},
+		{desc: "no ids", updates: []*pb.EntryUpdate{update(0, ""), update(1, "")}},
+		{desc: "new ids", updates: []*pb.EntryUpdate{update(3, "d")}},
+	} {
+		got, err := m.Send(ctx, directoryID, logID, tc.updates...)
+		if status.Code(err) != tc.wantCode {
+			t.Errorf("Send(%v): %v, want %v", tc.desc, err, tc.wantCode)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if same := got.Compare(wm) == 0; same != tc.wantWM {
+			t.Errorf("Send(%v): %v, original watermark %v, want same: %v", tc.desc, got, wm, tc.wantWM)
+		}
+	}
+
+	rows, err := m.ReadLog(ctx, directoryID, logID, water.Mark{}, water.NewMark(math.MaxInt64), 100)
+	if err != nil {
+		t.Fatalf("ReadLog(): %v", err)
+	}
+	var got []byte
+	for _, r := range rows {
+		got = append(got, r.Mutation.Entry...)
+	}
+	if want := []byte{0, 1, 0, 1, 3}; !cmp.Equal(got, want) {
+		t.Errorf("ReadLog(): %v, want %v", got, want)
+	}
+}
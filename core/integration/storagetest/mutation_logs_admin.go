@@ -22,6 +22,8 @@ import (
 	"github.com/google/keytransparency/core/adminserver"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
 // logAdminFactory returns a new database object, and a function for cleaning it up.
@@ -35,6 +37,7 @@ func RunLogsAdminTests(t *testing.T, factory logAdminFactory) {
 		// TODO(gbelvin): Discover test methods via reflection.
 		"TestSetWritable": b.TestSetWritable,
 		"TestListLogs":    b.TestListLogs,
+		"TestRetireLog":   b.TestRetireLog,
 	} {
 		t.Run(name, func(t *testing.T) { f(ctx, t, factory) })
 	}
@@ -96,3 +99,39 @@ func (logsAdminTests) TestListLogs(ctx context.Context, t *testing.T, f logAdmin
 		})
 	}
 }
+
+func (logsAdminTests) TestRetireLog(ctx context.Context, t *testing.T, f logAdminFactory) {
+	directoryID := "TestRetireLog"
+	m, done := f(ctx, t, directoryID, 1, 2)
+	defer done(ctx)
+	if err := m.SetWritable(ctx, directoryID, 1, false); err != nil {
+		t.Fatalf("SetWritable(): %v", err)
+	}
+	for _, tc := range []struct {
+		logID    int64
+		wantCode codes.Code
+	}{
+		{logID: 1, wantCode: codes.FailedPrecondition}, // Read-only, but not sealed.
+		{logID: 2, wantCode: codes.FailedPrecondition},
+		{logID: 3, wantCode: codes.NotFound},
+	} {
+		if err := m.RetireLog(ctx, directoryID, tc.logID); status.Code(err) != tc.wantCode {
+			t.Errorf("RetireLog(%v): %v, want %v", tc.logID, err, tc.wantCode)
+		}
+	}
+
+	logs, err := m.ListInputLogs(ctx, directoryID)
+	if err != nil {
+		t.Fatalf("ListInputLogs(): %v", err)
+	}
+	got := make(map[int64]bool)
+	for _, l := range logs {
+		if l.State != pb.InputLog_ACTIVE {
+			t.Errorf("ListInputLogs(): log %v is %v, want %v", l.LogId, l.State, pb.InputLog_ACTIVE)
+		}
+		got[l.LogId] = l.Writable
+	}
+	if want := map[int64]bool{1: false, 2: true}; !cmp.Equal(got, want) {
+		t.Errorf("ListInputLogs(): writable %v, want %v", got, want)
+	}
+}
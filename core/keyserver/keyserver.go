@@ -36,6 +36,7 @@ import (
 	"github.com/google/keytransparency/core/crypto/vrf/p256"
 	"github.com/google/keytransparency/core/directory"
 	"github.com/google/keytransparency/core/mutator"
+	"github.com/google/keytransparency/core/mutator/entry"
 	"github.com/google/keytransparency/core/water"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
@@ -47,12 +48,14 @@ import (
 const (
 	directoryIDLabel = "directoryid"
 	logIDLabel       = "logid"
+	quotaLabel       = "quota"
 )
 
 var (
 	initMetrics           sync.Once
 	watermarkWritten      monitoring.Gauge
 	sequencerQueueWritten monitoring.Counter
+	updatesThrottled      monitoring.Counter
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -64,6 +67,10 @@ func createMetrics(mf monitoring.MetricFactory) {
 		"keyserver_queue_written",
 		"Counter for each queue row that has been written",
 		directoryIDLabel, logIDLabel)
+	updatesThrottled = mf.NewCounter(
+		"keyserver_updates_throttled",
+		"Counter for each batch of updates rejected by a rate limit quota",
+		directoryIDLabel, quotaLabel)
 }
 
 // MutationLogs provides sets of roughly time ordered message logs.
@@ -73,6 +80,11 @@ type MutationLogs interface {
 	// watermark can be used as a lower bound argument of a ReadLog call. To
 	// acquire a watermark to use for the upper bound, use HighWatermark.
 	//
+	// If the mutations carry request IDs that were all sent together
+	// before, nothing is written and the watermark of the original batch
+	// is returned. Request IDs that were sent with a different batch
+	// result in an AlreadyExists error.
+	//
 	// TODO(gbelvin): Create a batch level object to make it clear that this a batch of updates.
 	Send(ctx context.Context, directoryID string, logID int64, mutation ...*pb.EntryUpdate) (water.Mark, error)
 	// ReadLog returns the messages in the (low, high] range stored in the
@@ -89,6 +101,9 @@ type MutationLogs interface {
 type BatchReader interface {
 	// ReadBatch returns the batch definitions for a given revision.
 	ReadBatch(ctx context.Context, directoryID string, rev int64) (*spb.MapMetadata, error)
+	// PrunedRevision returns the highest revision whose mutations have been
+	// pruned, or 0 if none have been.
+	PrunedRevision(ctx context.Context, directoryID string) (int64, error)
 }
 
 // NewFromWrappedKeyFunc returns a vrf private key from a proto.
@@ -104,10 +119,14 @@ type Server struct {
 	batches           BatchReader
 	newFromWrappedKey NewFromWrappedKeyFunc
 	revisionPageSize  int32
+	tokens            *PageTokens
+	limiter           *RateLimiter
 }
 
 // New creates a new instance of the key server.
 // revisionPageSize sets the maximum number of map revision to return per list API.
+// tokens protects the page tokens handed out by the list APIs.
+// limiter enforces the directories' rate limits. A nil limiter allows all updates.
 func New(tlog tpb.TrillianLogClient,
 	tmap tpb.TrillianMapClient,
 	verifyMutation mutator.VerifyMutationFn,
@@ -116,6 +135,8 @@ func New(tlog tpb.TrillianLogClient,
 	batches BatchReader,
 	metricsFactory monitoring.MetricFactory,
 	revisionPageSize int32,
+	tokens *PageTokens,
+	limiter *RateLimiter,
 ) *Server {
 	initMetrics.Do(func() { createMetrics(metricsFactory) })
 	return &Server{
@@ -127,6 +148,8 @@ func New(tlog tpb.TrillianLogClient,
 		batches:           batches,
 		newFromWrappedKey: p256.NewFromWrappedKey,
 		revisionPageSize:  revisionPageSize,
+		tokens:            tokens,
+		limiter:           limiter,
 	}
 }
 
@@ -138,6 +161,8 @@ func (s *Server) GetUser(ctx context.Context, in *pb.GetUserRequest) (*pb.GetUse
 		DirectoryId:  in.DirectoryId,
 		UserIds:      []string{in.UserId},
 		LastVerified: in.LastVerified,
+		AppId:        in.AppId,
+		Revision:     in.Revision,
 	}
 	resp, err := s.BatchGetUser(ctx, req)
 	if err != nil {
@@ -162,9 +187,9 @@ func (s *Server) GetUser(ctx context.Context, in *pb.GetUserRequest) (*pb.GetUse
 // getUserByRevision does NOT populate the following fields:
 // - LogRoot
 // - LogConsistency
-func (s *Server) getUserByRevision(ctx context.Context, sth *tpb.SignedLogRoot, d *directory.Directory, userID string,
-	rev int64) (*pb.GetUserResponse, error) {
-	resp, err := s.batchGetUserByRevision(ctx, sth, d, []string{userID}, rev)
+func (s *Server) getUserByRevision(ctx context.Context, sth *tpb.SignedLogRoot, d *directory.Directory,
+	userID, appID string, rev int64) (*pb.GetUserResponse, error) {
+	resp, err := s.batchGetUserByRevision(ctx, sth, d, []string{userID}, appID, rev)
 	if err != nil {
 		return nil, err
 	}
@@ -181,9 +206,9 @@ func (s *Server) getUserByRevision(ctx context.Context, sth *tpb.SignedLogRoot,
 	}, nil
 }
 
-// batchGetUserByRevision returns entries and proofs for a list of users.
+// batchGetUserByRevision returns entries and proofs for a list of users in the application appID.
 func (s *Server) batchGetUserByRevision(ctx context.Context, sth *tpb.SignedLogRoot, d *directory.Directory,
-	userIDs []string, mapRevision int64) (*pb.BatchGetUserResponse, error) {
+	userIDs []string, appID string, mapRevision int64) (*pb.BatchGetUserResponse, error) {
 	if mapRevision < 0 {
 		return nil, status.Errorf(codes.InvalidArgument,
 			"Revision is %v, want >= 0", mapRevision)
@@ -197,7 +222,7 @@ func (s *Server) batchGetUserByRevision(ctx context.Context, sth *tpb.SignedLogR
 	}
 
 	indexes := make([][]byte, 0, len(userIDs))
-	proofsByUser, usersByIndex, err := s.batchGetUserIndex(ctx, d, userIDs)
+	proofsByUser, usersByIndex, err := s.batchGetUserIndex(ctx, d, userIDs, appID)
 	if err != nil {
 		return nil, err
 	}
@@ -243,12 +268,18 @@ func (s *Server) batchGetUserByRevision(ctx context.Context, sth *tpb.SignedLogR
 		var committed *pb.Committed
 		if mapLeafInclusion.Leaf.LeafValue != nil {
 			extraData := mapLeafInclusion.Leaf.ExtraData
-			if extraData == nil {
+			switch deleted, err := entry.IsTombstone(mapLeafInclusion.Leaf.LeafValue); {
+			case err != nil:
+				return nil, status.Errorf(codes.Internal, "Cannot read leaf value")
+			case deleted:
+				// Tombstones do not have commitment data.
+			case extraData == nil:
 				return nil, status.Errorf(codes.Internal, "Missing commitment data")
-			}
-			committed = &pb.Committed{}
-			if err := proto.Unmarshal(extraData, committed); err != nil {
-				return nil, status.Errorf(codes.Internal, "Cannot read committed value")
+			default:
+				committed = &pb.Committed{}
+				if err := proto.Unmarshal(extraData, committed); err != nil {
+					return nil, status.Errorf(codes.Internal, "Cannot read committed value")
+				}
 			}
 		}
 		user, ok := usersByIndex[string(mapLeafInclusion.Leaf.GetIndex())]
@@ -298,6 +329,7 @@ func (s *Server) batchGetUserByRevision(ctx context.Context, sth *tpb.SignedLogR
 }
 
 // BatchGetUser returns a batch of users at the same revision.
+// The latest revision is used unless a revision is requested.
 func (s *Server) BatchGetUser(ctx context.Context, in *pb.BatchGetUserRequest) (*pb.BatchGetUserResponse, error) {
 	if in.DirectoryId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "Please specify a directory_id")
@@ -319,8 +351,18 @@ func (s *Server) BatchGetUser(ctx context.Context, in *pb.BatchGetUserRequest) (
 		errStr := fmt.Sprintf("BatchGetUser - latestRevision(log: %v, sth: %v)", d.Log.TreeId, sth)
 		return nil, logTopLevelErr(errStr, err)
 	}
+	switch {
+	case in.Revision < 0:
+		err := status.Errorf(codes.InvalidArgument, "Revision is %v, want >= 0", in.Revision)
+		return nil, logTopLevelErr("BatchGetUser", err)
+	case in.Revision > revision:
+		err := status.Errorf(codes.NotFound, "Revision %v has not been released yet", in.Revision)
+		return nil, logTopLevelErr("BatchGetUser", err)
+	case in.Revision > 0:
+		revision = in.Revision
+	}
 
-	entryProofs, err := s.batchGetUserByRevision(ctx, sth, d, in.UserIds, revision)
+	entryProofs, err := s.batchGetUserByRevision(ctx, sth, d, in.UserIds, in.AppId, revision)
 	if err != nil {
 		return nil, logTopLevelErr("BatchGetUser", err)
 	}
@@ -347,7 +389,7 @@ func (s *Server) BatchGetUserIndex(ctx context.Context,
 		errStr := fmt.Sprintf("BatchGetUserIndex - adminstorage.Read(%v)", in.DirectoryId)
 		return nil, logTopLevelErr(errStr, status.Errorf(st.Code(), "Cannot fetch directory info"))
 	}
-	proofsByUser, _, err := s.batchGetUserIndex(ctx, d, in.UserIds)
+	proofsByUser, _, err := s.batchGetUserIndex(ctx, d, in.UserIds, in.AppId)
 	if err != nil {
 		return nil, logTopLevelErr("BatchGetUserIndex", err)
 	}
@@ -355,7 +397,12 @@ func (s *Server) BatchGetUserIndex(ctx context.Context,
 }
 
 func (s *Server) batchGetUserIndex(ctx context.Context, d *directory.Directory,
-	userIDs []string) (proofsByUser map[string][]byte, usersByIndex map[string]string, err error) {
+	userIDs []string, appID string) (proofsByUser map[string][]byte, usersByIndex map[string]string, err error) {
+	for _, userID := range userIDs {
+		if !vrf.ValidUserID(userID) {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "%v: %q", ErrInvalidUserID, userID)
+		}
+	}
 	vrfPriv, err := s.newFromWrappedKey(ctx, d.VRFPriv)
 	if err != nil {
 		return nil, nil, err
@@ -383,7 +430,7 @@ func (s *Server) batchGetUserIndex(ctx context.Context, d *directory.Directory,
 			go func() {
 				defer wg.Done()
 				for userID := range uIDs {
-					index, proof := vrfPriv.Evaluate([]byte(userID))
+					index, proof := vrfPriv.Evaluate(vrf.UniqueID(userID, appID))
 					results <- result{userID, index, proof}
 				}
 			}()
@@ -434,7 +481,7 @@ func (s *Server) ListEntryHistory(ctx context.Context, in *pb.ListEntryHistoryRe
 	}
 	responses := make([]*pb.GetUserResponse, in.PageSize)
 	for i := range responses {
-		resp, err := s.getUserByRevision(ctx, sth, d, in.UserId, in.Start+int64(i))
+		resp, err := s.getUserByRevision(ctx, sth, d, in.UserId, in.AppId, in.Start+int64(i))
 		if st := status.Convert(err); st.Code() != codes.OK {
 			glog.Errorf("getUser failed for revision %v: %v", in.Start+int64(i), err)
 			return nil, status.Errorf(st.Code(), "GetUser failed")
@@ -467,16 +514,17 @@ func (s *Server) ListUserRevisions(ctx context.Context, in *pb.ListUserRevisions
 	*pb.ListUserRevisionsResponse, error) {
 	pageStart := in.StartRevision
 	lastVerified := in.LastVerified
-	if in.PageToken != "" {
+	pageToken := in.PageToken
+	// last_verified and page_token are allowed to change between paginated requests.
+	// Clear them here both for comparison and for binding next_page_token to the request.
+	in.LastVerified = nil
+	in.PageToken = ""
+	if pageToken != "" {
 		token := &rtpb.ListUserRevisionsToken{}
-		if err := DecodeToken(in.PageToken, token); err != nil {
-			glog.Errorf("invalid page token %v: %v", in.PageToken, err)
-			return nil, status.Errorf(codes.InvalidArgument, "Invalid page_token provided")
+		if err := s.tokens.Decode(pageToken, token, in); err != nil {
+			glog.Errorf("invalid page token %v: %v", pageToken, err)
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid page_token provided: %v", err)
 		}
-		// last_verified and page_token are allowed to change between paginated requests.
-		// Clear them here both for comparison and for encoding next_page_token in the response.
-		in.LastVerified = nil
-		in.PageToken = ""
 		if !proto.Equal(in, token.Request) {
 			return nil, status.Errorf(codes.InvalidArgument, "Request fields changed during pagination")
 		}
@@ -516,7 +564,7 @@ func (s *Server) ListUserRevisions(ctx context.Context, in *pb.ListUserRevisions
 	revisions := make([]*pb.MapRevision, numRevisions)
 	for i := range revisions {
 		rev := pageStart + int64(i)
-		resp, err := s.getUserByRevision(ctx, sth, d, in.UserId, rev)
+		resp, err := s.getUserByRevision(ctx, sth, d, in.UserId, in.AppId, rev)
 		if st := status.Convert(err); st.Code() != codes.OK {
 			glog.Errorf("getUser failed for revision %v: %v", rev, err)
 			return nil, status.Errorf(st.Code(), "GetUser failed")
@@ -534,7 +582,7 @@ func (s *Server) ListUserRevisions(ctx context.Context, in *pb.ListUserRevisions
 			Request:           in,
 			RevisionsReturned: (pageStart - in.StartRevision) + numRevisions,
 		}
-		token, err = EncodeToken(tokenProto)
+		token, err = s.tokens.Encode(tokenProto, in)
 		if st := status.Convert(err); st.Code() != codes.OK {
 			glog.Errorf("error encoding page token: %v", err)
 			return nil, status.Errorf(st.Code(), "Error encoding pagination token")
@@ -590,7 +638,7 @@ func (s *Server) BatchListUserRevisions(ctx context.Context, in *pb.BatchListUse
 	revisions := make([]*pb.BatchMapRevision, numRevisions)
 	for i := range revisions {
 		rev := pageStart + int64(i)
-		resp, err := s.batchGetUserByRevision(ctx, sth, d, in.UserIds, rev)
+		resp, err := s.batchGetUserByRevision(ctx, sth, d, in.UserIds, in.AppId, rev)
 		if st := status.Convert(err); st.Code() != codes.OK {
 			glog.Errorf("batchGetUser failed for revision %v: %v", rev, err)
 			return nil, status.Errorf(st.Code(), "BatchGetUser failed")
@@ -624,6 +672,9 @@ func (s *Server) BatchQueueUserUpdate(ctx context.Context, in *pb.BatchQueueUser
 	if in.DirectoryId == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "Please specify a directory_id")
 	}
+	if err := validateRequestIDs(in.Updates); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid request: %v", err)
+	}
 	// Lookup log and map info.
 	directory, err := s.directories.Read(ctx, in.DirectoryId, false)
 	if st := status.Convert(err); st.Code() != codes.OK {
@@ -661,6 +712,12 @@ func (s *Server) BatchQueueUserUpdate(ctx context.Context, in *pb.BatchQueueUser
 	}
 	tdone()
 
+	// Only valid updates count towards the rate limits. Retries are charged
+	// like new batches, even if logs.Send finds their request IDs were queued.
+	if err := s.limiter.Allow(ctx, directory.DirectoryID, directory.RateLimits, in.Updates); err != nil {
+		return nil, err
+	}
+
 	// Pick a random logID.  Note, this effectively picks a random QoS. See issue #1377.
 	// TODO(gbelvin): Define an explicit QoS / Load ballancing API.
 	wmLogID, err := s.randLog(ctx, directory.DirectoryID)
@@ -706,12 +763,13 @@ func (s *Server) GetDirectory(ctx context.Context, in *pb.GetDirectoryRequest) (
 	}
 
 	return &pb.Directory{
-		DirectoryId: directory.DirectoryID,
-		Log:         directory.Log,
-		Map:         directory.Map,
-		Vrf:         directory.VRF,
-		MinInterval: ptypes.DurationProto(directory.MinInterval),
-		MaxInterval: ptypes.DurationProto(directory.MaxInterval),
+		DirectoryId:   directory.DirectoryID,
+		Log:           directory.Log,
+		Map:           directory.Map,
+		Vrf:           directory.VRF,
+		MinInterval:   ptypes.DurationProto(directory.MinInterval),
+		MaxInterval:   ptypes.DurationProto(directory.MaxInterval),
+		AllowRecovery: directory.AllowRecovery,
 	}, nil
 }
 
@@ -17,6 +17,7 @@ package keyserver
 import (
 	"context"
 	"crypto"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -33,6 +34,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	rtpb "github.com/google/keytransparency/core/keyserver/readtoken_go_proto"
 	tpb "github.com/google/trillian"
 )
 
@@ -77,12 +79,17 @@ func newMiniEnv(ctx context.Context, t *testing.T) (*miniEnv, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error starting fake server: %v", err)
 	}
+	tokens, err := NewPageTokens(testPageTokenKey, true, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("NewPageTokens(): %v", err)
+	}
 	srv := &Server{
 		directories:       fakeAdmin,
 		tlog:              s.LogClient,
 		tmap:              s.MapClient,
 		newFromWrappedKey: fakeNewFromWrappedKey,
 		revisionPageSize:  10,
+		tokens:            tokens,
 	}
 	return &miniEnv{
 		s:              s,
@@ -199,3 +206,164 @@ func mustMarshalRoot(t *testing.T, lr *types.LogRootV1) *tpb.SignedLogRoot {
 		LogRoot: rootBytes,
 	}
 }
+
+func TestGetUserRevision(t *testing.T) {
+	ctx := context.Background()
+	treeSize := int64(5)
+	for _, tc := range []struct {
+		desc     string
+		revision int64
+		wantRev  int64
+		wantErr  codes.Code
+	}{
+		{desc: "latest", revision: 0, wantRev: treeSize - 1},
+		{desc: "historical", revision: 2, wantRev: 2},
+		{desc: "newest", revision: treeSize - 1, wantRev: treeSize - 1},
+		{desc: "future", revision: treeSize, wantErr: codes.NotFound},
+		{desc: "negative", revision: -1, wantErr: codes.InvalidArgument},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+			e, err := newMiniEnv(ctx, t)
+			if err != nil {
+				t.Fatalf("newMiniEnv(): %v", err)
+			}
+			defer e.Close()
+			e.s.Log.EXPECT().GetLatestSignedLogRoot(gomock.Any(), gomock.Any()).
+				Return(&tpb.GetLatestSignedLogRootResponse{
+					SignedLogRoot: mustMarshalRoot(t, &types.LogRootV1{TreeSize: uint64(treeSize)}),
+				}, nil)
+			if tc.wantErr == codes.OK {
+				e.s.Map.EXPECT().GetLeafByRevision(gomock.Any(),
+					matchers.ProtoEqual(
+						&tpb.GetMapLeafByRevisionRequest{
+							MapId:    mapID,
+							Index:    make([]byte, 32),
+							Revision: tc.wantRev,
+						})).
+					Return(&tpb.GetMapLeafResponse{
+						MapLeafInclusion: &tpb.MapLeafInclusion{
+							Leaf: &tpb.MapLeaf{
+								Index: make([]byte, 32),
+							},
+						},
+					}, nil)
+				e.s.Log.EXPECT().GetInclusionProof(gomock.Any(),
+					matchers.ProtoEqual(&tpb.GetInclusionProofRequest{
+						LeafIndex: tc.wantRev,
+						TreeSize:  treeSize,
+					})).
+					Return(&tpb.GetInclusionProofResponse{}, nil)
+			}
+
+			_, err = e.srv.GetUser(ctx, &pb.GetUserRequest{
+				DirectoryId: directoryID,
+				Revision:    tc.revision,
+			})
+			if got, want := status.Code(err), tc.wantErr; got != want {
+				t.Errorf("GetUser(): %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+func TestListUserRevisionsPageToken(t *testing.T) {
+	ctx := context.Background()
+	req := &pb.ListUserRevisionsRequest{
+		DirectoryId:   directoryID,
+		UserId:        "alice",
+		StartRevision: 1,
+		EndRevision:   10,
+	}
+	for _, tc := range []struct {
+		desc  string
+		token func(*PageTokens) string
+		req   *pb.ListUserRevisionsRequest
+	}{
+		{
+			desc:  "garbage",
+			token: func(*PageTokens) string { return "some_token" },
+			req:   req,
+		},
+		{
+			desc: "changed request",
+			token: func(p *PageTokens) string {
+				token, err := p.Encode(&rtpb.ListUserRevisionsToken{Request: req, RevisionsReturned: 2}, req)
+				if err != nil {
+					t.Fatalf("Encode(): %v", err)
+				}
+				return token
+			},
+			req: &pb.ListUserRevisionsRequest{
+				DirectoryId:   directoryID,
+				UserId:        "bob",
+				StartRevision: 1,
+				EndRevision:   10,
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			e, err := newMiniEnv(ctx, t)
+			if err != nil {
+				t.Fatalf("newMiniEnv(): %v", err)
+			}
+			defer e.Close()
+
+			in := *tc.req
+			in.PageToken = tc.token(e.srv.tokens)
+			_, err = e.srv.ListUserRevisions(ctx, &in)
+			if got, want := status.Code(err), codes.InvalidArgument; got != want {
+				t.Errorf("ListUserRevisions(): %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+func TestBatchGetUserIndexInvalidUserID(t *testing.T) {
+	ctx := context.Background()
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+
+	// A user ID that is not UTF-8 could share the index of alice in app.
+	forged := string(vrf.UniqueID("alice", "app"))
+	_, err = e.srv.BatchGetUserIndex(ctx, &pb.BatchGetUserIndexRequest{
+		DirectoryId: directoryID,
+		UserIds:     []string{"bob", forged},
+	})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("BatchGetUserIndex(): %v, want %v", err, want)
+	}
+}
+
+func TestBatchQueueUserUpdateValidatesBeforeRateLimit(t *testing.T) {
+	ctx := context.Background()
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+	limits := &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 0.001, Burst: 1}}
+	if err := e.srv.directories.SetRateLimits(ctx, directoryID, limits); err != nil {
+		t.Fatalf("SetRateLimits(): %v", err)
+	}
+	e.srv.limiter = NewRateLimiter(func(context.Context) string { return "" })
+	e.srv.verifyMutation = func(*pb.SignedEntry) error { return errors.New("invalid") }
+
+	// Invalid updates are rejected without taking alice's only token.
+	invalid := &pb.BatchQueueUserUpdateRequest{
+		DirectoryId: directoryID,
+		Updates:     []*pb.EntryUpdate{{UserId: "alice"}},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := e.srv.BatchQueueUserUpdate(ctx, invalid); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("BatchQueueUserUpdate(invalid): %v, want %v", err, codes.InvalidArgument)
+		}
+	}
+	if err := e.srv.limiter.Allow(ctx, directoryID, limits, invalid.Updates); err != nil {
+		t.Errorf("Allow(alice): %v", err)
+	}
+}
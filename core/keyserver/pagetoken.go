@@ -0,0 +1,221 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyserver
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	rtpb "github.com/google/keytransparency/core/keyserver/readtoken_go_proto"
+)
+
+// PageTokenKeySize is the minimum size of the secret used to protect page tokens.
+const PageTokenKeySize = 32
+
+// Page token formats. The first byte of every token identifies its format.
+const (
+	tokenFormatMAC  byte = 1 // PageToken || HMAC-SHA256
+	tokenFormatAEAD byte = 2 // nonce || AES-256-GCM(PageToken)
+)
+
+var (
+	// ErrInvalidToken occurs when a page token is malformed, has been
+	// tampered with, or was issued for a different request.
+	ErrInvalidToken = errors.New("keyserver: invalid page token")
+	// ErrExpiredToken occurs when a page token is presented after its expiry.
+	ErrExpiredToken = errors.New("keyserver: page token expired")
+)
+
+// PageTokens authenticates, and optionally encrypts, the pagination tokens
+// handed to clients. Each token is bound to the request it was issued for and
+// is only accepted until it expires.
+type PageTokens struct {
+	macKey []byte
+	aead   cipher.AEAD // nil if tokens are authenticated but not encrypted.
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewPageTokens returns a PageTokens that protects tokens with key.
+// key must be at least PageTokenKeySize bytes and must be shared by all
+// servers handing out tokens for the same directories.
+// If encrypt is set, token contents are hidden from clients as well.
+// Tokens are accepted for ttl after they are issued.
+func NewPageTokens(key []byte, encrypt bool, ttl time.Duration) (*PageTokens, error) {
+	if len(key) < PageTokenKeySize {
+		return nil, fmt.Errorf("page token key is %v bytes, want >= %v", len(key), PageTokenKeySize)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("page token ttl %v, want > 0", ttl)
+	}
+	p := &PageTokens{
+		macKey: deriveKey(key, "mac"),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+	if encrypt {
+		block, err := aes.NewCipher(deriveKey(key, "aead"))
+		if err != nil {
+			return nil, err
+		}
+		if p.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// NewEphemeralPageTokens returns an encrypting PageTokens with a random key.
+// Tokens will not be accepted by other servers or after a restart.
+func NewEphemeralPageTokens(ttl time.Duration) (*PageTokens, error) {
+	key := make([]byte, PageTokenKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return NewPageTokens(key, true, ttl)
+}
+
+// deriveKey returns a purpose specific sub key of key.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// Encode seals msg into a URL-safe page token bound to binding.
+// Empty messages encode to "", which signals the end of pagination.
+func (p *PageTokens) Encode(msg, binding proto.Message) (string, error) {
+	if proto.Size(msg) == 0 {
+		return "", nil
+	}
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	expires, err := ptypes.TimestampProto(p.now().Add(p.ttl))
+	if err != nil {
+		return "", err
+	}
+	envelope, err := proto.Marshal(&rtpb.PageToken{Token: b, Expires: expires})
+	if err != nil {
+		return "", err
+	}
+	ad, err := marshalBinding(binding)
+	if err != nil {
+		return "", err
+	}
+
+	var sealed []byte
+	if p.aead != nil {
+		nonce := make([]byte, p.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		sealed = append([]byte{tokenFormatAEAD}, nonce...)
+		sealed = p.aead.Seal(sealed, nonce, envelope, ad)
+	} else {
+		sealed = append([]byte{tokenFormatMAC}, envelope...)
+		sealed = append(sealed, p.mac(envelope, ad)...)
+	}
+	return base64.URLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode verifies that token was issued by Encode with the same binding and
+// has not expired, then unmarshals its contents into msg.
+func (p *PageTokens) Decode(token string, msg, binding proto.Message) error {
+	sealed, err := base64.URLEncoding.DecodeString(token)
+	if err != nil || len(sealed) == 0 {
+		return ErrInvalidToken
+	}
+	ad, err := marshalBinding(binding)
+	if err != nil {
+		return err
+	}
+
+	var envelope []byte
+	switch format, body := sealed[0], sealed[1:]; {
+	case p.aead != nil && format == tokenFormatAEAD:
+		n := p.aead.NonceSize()
+		if len(body) < n {
+			return ErrInvalidToken
+		}
+		if envelope, err = p.aead.Open(nil, body[:n], body[n:], ad); err != nil {
+			return ErrInvalidToken
+		}
+	case p.aead == nil && format == tokenFormatMAC:
+		if len(body) < sha256.Size {
+			return ErrInvalidToken
+		}
+		var tag []byte
+		envelope, tag = body[:len(body)-sha256.Size], body[len(body)-sha256.Size:]
+		if !hmac.Equal(tag, p.mac(envelope, ad)) {
+			return ErrInvalidToken
+		}
+	default:
+		return ErrInvalidToken
+	}
+	return p.open(envelope, msg)
+}
+
+// open checks the expiry of an authenticated envelope and unmarshals its contents.
+func (p *PageTokens) open(envelope []byte, msg proto.Message) error {
+	var pt rtpb.PageToken
+	if err := proto.Unmarshal(envelope, &pt); err != nil {
+		return ErrInvalidToken
+	}
+	expires, err := ptypes.Timestamp(pt.Expires)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if p.now().After(expires) {
+		return ErrExpiredToken
+	}
+	if err := proto.Unmarshal(pt.Token, msg); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// mac authenticates envelope together with the request binding.
+func (p *PageTokens) mac(envelope, ad []byte) []byte {
+	mac := hmac.New(sha256.New, p.macKey)
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(envelope)))
+	mac.Write(l[:])
+	mac.Write(envelope)
+	mac.Write(ad)
+	return mac.Sum(nil)
+}
+
+// marshalBinding deterministically serializes the request parameters a token is bound to.
+func marshalBinding(binding proto.Message) ([]byte, error) {
+	var buf proto.Buffer
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(binding); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	rtpb "github.com/google/keytransparency/core/keyserver/readtoken_go_proto"
+)
+
+var testPageTokenKey = bytes.Repeat([]byte{0x42}, PageTokenKeySize)
+
+func newTestPageTokens(t *testing.T, encrypt bool) *PageTokens {
+	t.Helper()
+	tokens, err := NewPageTokens(testPageTokenKey, encrypt, time.Hour)
+	if err != nil {
+		t.Fatalf("NewPageTokens(): %v", err)
+	}
+	return tokens
+}
+
+func TestNewPageTokens(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		key     []byte
+		ttl     time.Duration
+		wantErr bool
+	}{
+		{desc: "ok", key: testPageTokenKey, ttl: time.Hour},
+		{desc: "short key", key: testPageTokenKey[1:], ttl: time.Hour, wantErr: true},
+		{desc: "no ttl", key: testPageTokenKey, wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			_, err := NewPageTokens(tc.key, true, tc.ttl)
+			if got := err != nil; got != tc.wantErr {
+				t.Errorf("NewPageTokens(): %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncodeEmptyToken(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		got, err := newTestPageTokens(t, encrypt).Encode(&rtpb.ReadToken{}, &pb.ListMutationsRequest{})
+		if err != nil {
+			t.Fatalf("Encode(): %v", err)
+		}
+		if got != "" {
+			t.Errorf("Encode(encrypt: %v): %v, want \"\"", encrypt, got)
+		}
+	}
+}
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	rt := &rtpb.ReadToken{SliceIndex: 2, StartWatermark: 5}
+	binding := &pb.ListMutationsRequest{DirectoryId: "dir", Revision: 1}
+	for _, encrypt := range []bool{false, true} {
+		tokens := newTestPageTokens(t, encrypt)
+		token, err := tokens.Encode(rt, binding)
+		if err != nil {
+			t.Fatalf("Encode(): %v", err)
+		}
+		var got rtpb.ReadToken
+		if err := tokens.Decode(token, &got, binding); err != nil {
+			t.Fatalf("Decode(encrypt: %v): %v", encrypt, err)
+		}
+		if !proto.Equal(&got, rt) {
+			t.Errorf("Decode(encrypt: %v): %v, want %v", encrypt, &got, rt)
+		}
+	}
+}
+
+func TestPageTokenRejected(t *testing.T) {
+	rt := &rtpb.ReadToken{SliceIndex: 2, StartWatermark: 5}
+	binding := &pb.ListMutationsRequest{DirectoryId: "dir", Revision: 1}
+	mac := newTestPageTokens(t, false)
+	aead := newTestPageTokens(t, true)
+	otherKey, err := NewPageTokens(bytes.Repeat([]byte{0x43}, PageTokenKeySize), true, time.Hour)
+	if err != nil {
+		t.Fatalf("NewPageTokens(): %v", err)
+	}
+	expired := newTestPageTokens(t, true)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	mustEncode := func(p *PageTokens) string {
+		token, err := p.Encode(rt, binding)
+		if err != nil {
+			t.Fatalf("Encode(): %v", err)
+		}
+		return token
+	}
+	tamper := func(token string) string {
+		b, err := base64.URLEncoding.DecodeString(token)
+		if err != nil {
+			t.Fatalf("DecodeString(): %v", err)
+		}
+		b[len(b)/2] ^= 0x01
+		return base64.URLEncoding.EncodeToString(b)
+	}
+
+	for _, tc := range []struct {
+		desc    string
+		p       *PageTokens
+		token   string
+		binding proto.Message
+		want    error
+	}{
+		{desc: "garbage", p: aead, token: "some_token", binding: binding, want: ErrInvalidToken},
+		{desc: "unsigned", p: mac, token: base64.URLEncoding.EncodeToString(mustMarshal(t, rt)), binding: binding, want: ErrInvalidToken},
+		{desc: "tampered mac", p: mac, token: tamper(mustEncode(mac)), binding: binding, want: ErrInvalidToken},
+		{desc: "tampered aead", p: aead, token: tamper(mustEncode(aead)), binding: binding, want: ErrInvalidToken},
+		{desc: "other revision", p: aead, token: mustEncode(aead),
+			binding: &pb.ListMutationsRequest{DirectoryId: "dir", Revision: 2}, want: ErrInvalidToken},
+		{desc: "other directory", p: mac, token: mustEncode(mac),
+			binding: &pb.ListMutationsRequest{DirectoryId: "other", Revision: 1}, want: ErrInvalidToken},
+		{desc: "other key", p: aead, token: mustEncode(otherKey), binding: binding, want: ErrInvalidToken},
+		{desc: "mac token to aead", p: aead, token: mustEncode(mac), binding: binding, want: ErrInvalidToken},
+		{desc: "aead token to mac", p: mac, token: mustEncode(aead), binding: binding, want: ErrInvalidToken},
+		{desc: "expired", p: aead, token: mustEncode(expired), binding: binding, want: ErrExpiredToken},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var got rtpb.ReadToken
+			if err := tc.p.Decode(tc.token, &got, tc.binding); err != tc.want {
+				t.Errorf("Decode(): %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -15,46 +15,36 @@
 package keyserver
 
 import (
-	"encoding/base64"
-
 	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/sequencer/metadata"
+	"github.com/google/keytransparency/core/water"
 
 	rtpb "github.com/google/keytransparency/core/keyserver/readtoken_go_proto"
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
 
-// EncodeToken converts a protobuf into a URL-safe base64 encoded string.
-func EncodeToken(msg proto.Message) (string, error) {
-	b, err := proto.Marshal(msg)
-	if err != nil {
-		return "", err
-	}
-	return base64.URLEncoding.EncodeToString(b), nil
-}
-
-// DecodeToken turns a URL-safe base64 encoded protobuf back into its proto.
-func DecodeToken(token string, msg proto.Message) error {
-	b, err := base64.URLEncoding.DecodeString(token)
-	if err != nil {
-		return err
-	}
-	return proto.Unmarshal(b, msg)
-}
-
 // SourceList is a paginator for a list of source slices.
 type SourceList []*spb.MapMetadata_SourceSlice
 
-// ParseToken will return the first token if token is "", otherwise it will try to parse the read token.
-func (s SourceList) ParseToken(token string) (*rtpb.ReadToken, error) {
+// ParseToken will return the first token if token is "", otherwise it will try
+// to decode the read token and check that it refers to one of the sources.
+// binding is the set of request parameters the token was issued for.
+func (s SourceList) ParseToken(tokens *PageTokens, token string, binding proto.Message) (*rtpb.ReadToken, error) {
 	if token == "" {
 		return s.First(), nil
 	}
 	var rt rtpb.ReadToken
-	if err := DecodeToken(token, &rt); err != nil {
+	if err := tokens.Decode(token, &rt, binding); err != nil {
 		return nil, err
 	}
+	if rt.SliceIndex < 0 || rt.SliceIndex >= int64(len(s)) {
+		return nil, ErrInvalidToken
+	}
+	src := metadata.FromProto(s[rt.SliceIndex])
+	if wm := water.NewMark(rt.StartWatermark); wm.Compare(src.LowMark()) < 0 || wm.Compare(src.HighMark()) > 0 {
+		return nil, ErrInvalidToken
+	}
 	return &rt, nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"github.com/google/keytransparency/core/sequencer/metadata"
 	"github.com/google/keytransparency/core/water"
 
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 	rtpb "github.com/google/keytransparency/core/keyserver/readtoken_go_proto"
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
@@ -30,45 +31,50 @@ func newSourceSlice(logID int64, low, high water.Mark) *spb.MapMetadata_SourceSl
 	return metadata.New(logID, low, high).Proto()
 }
 
-func TestEncodeToken(t *testing.T) {
-	for _, tc := range []struct {
-		rt   *rtpb.ReadToken
-		want string
-	}{
-		{rt: &rtpb.ReadToken{}, want: ""},
-		//{rt: nil, want: ""},
-	} {
-		got, err := EncodeToken(tc.rt)
+func TestParseToken(t *testing.T) {
+	tokens := newTestPageTokens(t, true)
+	binding := &pb.ListMutationsRequest{DirectoryId: "dir", Revision: 1}
+	start := water.NewMark(100)
+	s := SourceList{
+		newSourceSlice(2, start.Add(2), start.Add(11)),
+		newSourceSlice(3, start.Add(11), start.Add(21)),
+	}
+	mustEncode := func(rt *rtpb.ReadToken) string {
+		token, err := tokens.Encode(rt, binding)
 		if err != nil {
-			t.Fatalf("EncodeToken(%v): %v", tc.rt, err)
-		}
-		if got != tc.want {
-			t.Fatalf("EncodeToken(%v): %v, want %v", tc.rt, got, tc.want)
+			t.Fatalf("Encode(%v): %v", rt, err)
 		}
-	}
-}
-
-func TestTokenEncodeDecode(t *testing.T) {
-	rt1 := &rtpb.ReadToken{SliceIndex: 2, StartWatermark: 5}
-	rt1Token, err := EncodeToken(rt1)
-	if err != nil {
-		t.Fatalf("EncodeToken(%v): %v", rt1, err)
+		return token
 	}
 	for _, tc := range []struct {
-		desc  string
-		token string
-		want  *rtpb.ReadToken
+		desc    string
+		token   string
+		want    *rtpb.ReadToken
+		wantErr error
 	}{
-		{desc: "empty", token: "", want: &rtpb.ReadToken{}},
-		{desc: "notempty", token: rt1Token, want: rt1},
+		{desc: "empty", token: "", want: s.First()},
+		{desc: "second slice", token: mustEncode(&rtpb.ReadToken{SliceIndex: 1, StartWatermark: start.Value() + 12}),
+			want: &rtpb.ReadToken{SliceIndex: 1, StartWatermark: start.Value() + 12}},
+		{desc: "slice out of range", token: mustEncode(&rtpb.ReadToken{SliceIndex: 2, StartWatermark: start.Value() + 12}),
+			wantErr: ErrInvalidToken},
+		{desc: "negative slice", token: mustEncode(&rtpb.ReadToken{SliceIndex: -1, StartWatermark: start.Value() + 12}),
+			wantErr: ErrInvalidToken},
+		{desc: "watermark below slice", token: mustEncode(&rtpb.ReadToken{SliceIndex: 1, StartWatermark: start.Value() + 2}),
+			wantErr: ErrInvalidToken},
+		{desc: "watermark above slice", token: mustEncode(&rtpb.ReadToken{SliceIndex: 0, StartWatermark: start.Value() + 12}),
+			wantErr: ErrInvalidToken},
+		{desc: "garbage", token: "some_token", wantErr: ErrInvalidToken},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
-			var got rtpb.ReadToken
-			if err := DecodeToken(tc.token, &got); err != nil {
-				t.Errorf("DecodeToken(%v): %v", tc.token, err)
+			got, err := s.ParseToken(tokens, tc.token, binding)
+			if err != tc.wantErr {
+				t.Fatalf("ParseToken(): %v, want %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
 			}
-			if !proto.Equal(&got, tc.want) {
-				t.Errorf("DecodeToken(%v): %v, want %v", tc.token, &got, tc.want)
+			if !proto.Equal(got, tc.want) {
+				t.Errorf("ParseToken(): %v, want %v", got, tc.want)
 			}
 		})
 	}
@@ -0,0 +1,206 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+// Quota names, used in errors and metrics.
+const (
+	quotaPrincipal = "principal"
+	quotaUser      = "user"
+	quotaDirectory = "directory"
+)
+
+// sweepInterval is how often buckets that have refilled are discarded.
+const sweepInterval = time.Minute
+
+// RateLimiter enforces the token bucket quotas in each directory's RateLimits.
+// Buckets are kept in memory and are not shared between processes, so each
+// keyserver instance enforces the full quotas on the calls it receives.
+type RateLimiter struct {
+	principal func(context.Context) string
+	now       func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+type bucketKey struct {
+	quota       string
+	directoryID string
+	id          string
+}
+
+// bucket is the state of a single token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// rate and burst are those last used with this bucket.
+	rate, burst float64
+}
+
+// NewRateLimiter returns a RateLimiter. principal returns the authenticated
+// caller in ctx, or "" if there is none.
+func NewRateLimiter(principal func(context.Context) string) *RateLimiter {
+	return &RateLimiter{
+		principal: principal,
+		now:       time.Now,
+		buckets:   make(map[bucketKey]*bucket),
+	}
+}
+
+// charge is a number of tokens to take from a bucket.
+type charge struct {
+	key   bucketKey
+	quota *pb.Quota
+	n     float64
+}
+
+// Allow takes a token for each update from every quota in limits that applies
+// to the caller, the updated users, and the directory. If any quota has too few
+// tokens, no tokens are taken and a ResourceExhausted error with RetryInfo and
+// QuotaFailure details is returned. A batch that takes more tokens than a
+// quota's burst can never be admitted, so it gets an InvalidArgument error
+// instead, which clients do not retry.
+//
+// Allow does not know whether the updates were already queued: retries of a
+// batch are charged again even if they carry request IDs that were seen before.
+func (r *RateLimiter) Allow(ctx context.Context, directoryID string, limits *pb.RateLimits, updates []*pb.EntryUpdate) error {
+	if r == nil || limits == nil || len(updates) == 0 {
+		return nil
+	}
+	var charges []charge
+	if q := limits.GetPerDirectory(); enforced(q) {
+		charges = append(charges, charge{bucketKey{quotaDirectory, directoryID, ""}, q, float64(len(updates))})
+	}
+	if q := limits.GetPerPrincipal(); enforced(q) {
+		if p := r.principal(ctx); p != "" {
+			charges = append(charges, charge{bucketKey{quotaPrincipal, directoryID, p}, q, float64(len(updates))})
+		}
+	}
+	if q := limits.GetPerUser(); enforced(q) {
+		perUser := make(map[string]float64)
+		var userIDs []string
+		for _, u := range updates {
+			id := u.GetUserId()
+			if perUser[id] == 0 {
+				userIDs = append(userIDs, id)
+			}
+			perUser[id]++
+		}
+		for _, id := range userIDs {
+			charges = append(charges, charge{bucketKey{quotaUser, directoryID, id}, q, perUser[id]})
+		}
+	}
+	if len(charges) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	r.sweep(now)
+
+	var violations []*errdetails.QuotaFailure_Violation
+	var retryDelay time.Duration
+	buckets := make([]*bucket, len(charges))
+	for i, c := range charges {
+		b := r.refill(c.key, c.quota, now)
+		buckets[i] = b
+		if c.n > b.burst {
+			return status.Errorf(codes.InvalidArgument, "%v updates for %v:%v exceed the burst of %v updates per %v",
+				c.n, c.key.quota, c.key.id, b.burst, c.key.quota)
+		}
+		if b.tokens >= c.n {
+			continue
+		}
+		wait := time.Duration((c.n - b.tokens) / b.rate * float64(time.Second))
+		if wait > retryDelay {
+			retryDelay = wait
+		}
+		violations = append(violations, &errdetails.QuotaFailure_Violation{
+			Subject:     fmt.Sprintf("%v:%v", c.key.quota, c.key.id),
+			Description: fmt.Sprintf("%v updates per second per %v exceeded", b.rate, c.key.quota),
+		})
+		updatesThrottled.Inc(directoryID, c.key.quota)
+	}
+	if len(violations) > 0 {
+		st, err := status.New(codes.ResourceExhausted, "rate limit exceeded").WithDetails(
+			&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(retryDelay)},
+			&errdetails.QuotaFailure{Violations: violations},
+		)
+		if err != nil {
+			return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %v", retryDelay)
+		}
+		return st.Err()
+	}
+	for i, c := range charges {
+		buckets[i].tokens -= c.n
+	}
+	return nil
+}
+
+// enforced returns true if q limits the rate of updates.
+func enforced(q *pb.Quota) bool {
+	return q.GetUpdatesPerSecond() > 0
+}
+
+// refill returns the bucket for key, topped up to the current time.
+func (r *RateLimiter) refill(key bucketKey, q *pb.Quota, now time.Time) *bucket {
+	rate := q.GetUpdatesPerSecond()
+	burst := float64(q.GetBurst())
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(rate))
+	}
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		r.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rate
+	}
+	b.tokens = math.Min(b.tokens, burst)
+	b.last, b.rate, b.burst = now, rate, burst
+	return b
+}
+
+// sweep discards buckets that would be full by now, since they are
+// indistinguishable from new buckets.
+func (r *RateLimiter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for k, b := range r.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst {
+			delete(r.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyserver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+func init() {
+	initMetrics.Do(func() { createMetrics(monitoring.InertMetricFactory{}) })
+}
+
+type principalKey struct{}
+
+func withPrincipal(ctx context.Context, p string) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func newTestLimiter() (*RateLimiter, func(time.Duration)) {
+	r := NewRateLimiter(func(ctx context.Context) string {
+		p, _ := ctx.Value(principalKey{}).(string)
+		return p
+	})
+	var mu sync.Mutex
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+	return r, advance
+}
+
+func updates(userIDs ...string) []*pb.EntryUpdate {
+	ret := make([]*pb.EntryUpdate, 0, len(userIDs))
+	for _, id := range userIDs {
+		ret = append(ret, &pb.EntryUpdate{UserId: id})
+	}
+	return ret
+}
+
+func TestRateLimiter(t *testing.T) {
+	alice := withPrincipal(context.Background(), "alice")
+	bob := withPrincipal(context.Background(), "bob")
+	type step struct {
+		ctx     context.Context
+		dir     string
+		updates []*pb.EntryUpdate
+		advance time.Duration
+		want    codes.Code
+	}
+	for _, tc := range []struct {
+		desc   string
+		limits *pb.RateLimits
+		steps  []step
+	}{
+		{desc: "no limits", steps: []step{
+			{ctx: alice, updates: updates("a", "a", "a", "a")},
+		}},
+		{desc: "zero rate", limits: &pb.RateLimits{PerUser: &pb.Quota{Burst: 1}}, steps: []step{
+			{ctx: alice, updates: updates("a", "a", "a")},
+		}},
+		{desc: "per principal", limits: &pb.RateLimits{PerPrincipal: &pb.Quota{UpdatesPerSecond: 1, Burst: 2}}, steps: []step{
+			{ctx: alice, updates: updates("a", "b")},
+			{ctx: alice, updates: updates("c"), want: codes.ResourceExhausted},
+			{ctx: bob, updates: updates("c")},
+			{ctx: alice, updates: updates("c"), advance: time.Second},
+			{ctx: alice, updates: updates("c"), want: codes.ResourceExhausted},
+		}},
+		{desc: "unauthenticated callers skip per principal", limits: &pb.RateLimits{PerPrincipal: &pb.Quota{UpdatesPerSecond: 1}}, steps: []step{
+			{ctx: context.Background(), updates: updates("a", "b", "c")},
+		}},
+		{desc: "per user", limits: &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 1}}, steps: []step{
+			{ctx: alice, updates: updates("a")},
+			{ctx: bob, updates: updates("a"), want: codes.ResourceExhausted},
+			{ctx: bob, updates: updates("b")},
+			{ctx: bob, updates: updates("c", "c"), want: codes.InvalidArgument},
+			{ctx: bob, updates: updates("c")},
+			{ctx: bob, updates: updates("a"), advance: time.Second},
+		}},
+		{desc: "batches over burst", limits: &pb.RateLimits{
+			PerDirectory: &pb.Quota{UpdatesPerSecond: 1, Burst: 3},
+			PerPrincipal: &pb.Quota{UpdatesPerSecond: 1, Burst: 2},
+		}, steps: []step{
+			{ctx: alice, updates: updates("a", "b", "c"), want: codes.InvalidArgument},
+			{ctx: context.Background(), updates: updates("a", "b", "c")},
+			{ctx: context.Background(), updates: updates("a", "b", "c", "d"), advance: time.Hour, want: codes.InvalidArgument},
+			{ctx: alice, updates: updates("a", "b")},
+		}},
+		{desc: "per directory", limits: &pb.RateLimits{PerDirectory: &pb.Quota{UpdatesPerSecond: 2}}, steps: []step{
+			{ctx: alice, dir: "d1", updates: updates("a", "b")},
+			{ctx: bob, dir: "d1", updates: updates("c"), want: codes.ResourceExhausted},
+			{ctx: bob, dir: "d2", updates: updates("c")},
+			{ctx: bob, dir: "d1", updates: updates("c"), advance: 500 * time.Millisecond},
+		}},
+		{desc: "denied batches take no tokens", limits: &pb.RateLimits{
+			PerDirectory: &pb.Quota{UpdatesPerSecond: 1, Burst: 10},
+			PerUser:      &pb.Quota{UpdatesPerSecond: 1},
+		}, steps: []step{
+			{ctx: alice, updates: updates("a", "b")},
+			{ctx: alice, updates: updates("a", "c", "d"), want: codes.ResourceExhausted},
+			{ctx: alice, updates: updates("c", "d", "e", "f", "g", "h", "i", "j")},
+			{ctx: alice, updates: updates("k"), want: codes.ResourceExhausted},
+		}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			r, advance := newTestLimiter()
+			for i, s := range tc.steps {
+				advance(s.advance)
+				err := r.Allow(s.ctx, s.dir, tc.limits, s.updates)
+				if got := status.Code(err); got != s.want {
+					t.Fatalf("step %v: Allow(): %v, want %v", i, err, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterRetryInfo(t *testing.T) {
+	r, _ := newTestLimiter()
+	ctx := withPrincipal(context.Background(), "alice")
+	limits := &pb.RateLimits{PerPrincipal: &pb.Quota{UpdatesPerSecond: 0.5}}
+	if err := r.Allow(ctx, "dir", limits, updates("a")); err != nil {
+		t.Fatalf("Allow(): %v", err)
+	}
+	err := r.Allow(ctx, "dir", limits, updates("a"))
+	var retry *errdetails.RetryInfo
+	var failure *errdetails.QuotaFailure
+	for _, d := range status.Convert(err).Details() {
+		switch d := d.(type) {
+		case *errdetails.RetryInfo:
+			retry = d
+		case *errdetails.QuotaFailure:
+			failure = d
+		}
+	}
+	if retry == nil || failure == nil {
+		t.Fatalf("Allow(): %v, want RetryInfo and QuotaFailure details", err)
+	}
+	if got, err := ptypes.Duration(retry.RetryDelay); err != nil || got != 2*time.Second {
+		t.Errorf("RetryDelay: %v, want %v", got, 2*time.Second)
+	}
+	if len(failure.Violations) != 1 || failure.Violations[0].Subject != "principal:alice" {
+		t.Errorf("Violations: %v, want principal:alice", failure.Violations)
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	r, advance := newTestLimiter()
+	limits := &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 1, Burst: 5}}
+	for _, u := range []string{"a", "b", "c"} {
+		if err := r.Allow(context.Background(), "dir", limits, updates(u)); err != nil {
+			t.Fatalf("Allow(): %v", err)
+		}
+	}
+	advance(sweepInterval)
+	if err := r.Allow(context.Background(), "dir", limits, updates("d")); err != nil {
+		t.Fatalf("Allow(): %v", err)
+	}
+	if got := len(r.buckets); got != 1 {
+		t.Errorf("%v buckets after sweep, want 1", got)
+	}
+}
@@ -6,6 +6,7 @@ package readtoken_go_proto
 import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	keytransparency_go_proto "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 	math "math"
 )
@@ -126,30 +127,86 @@ func (m *ListUserRevisionsToken) GetRevisionsReturned() int64 {
 	return 0
 }
 
+// PageToken is the envelope that is authenticated, and optionally encrypted,
+// before being handed to users as a page_token.
+type PageToken struct {
+	// token is the serialized ReadToken or ListUserRevisionsToken.
+	Token []byte `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// expires is the time after which the token will no longer be accepted.
+	Expires              *timestamp.Timestamp `protobuf:"bytes,2,opt,name=expires,proto3" json:"expires,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *PageToken) Reset()         { *m = PageToken{} }
+func (m *PageToken) String() string { return proto.CompactTextString(m) }
+func (*PageToken) ProtoMessage()    {}
+func (*PageToken) Descriptor() ([]byte, []int) {
+	return fileDescriptor_735a2ae6888918c9, []int{2}
+}
+
+func (m *PageToken) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PageToken.Unmarshal(m, b)
+}
+func (m *PageToken) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PageToken.Marshal(b, m, deterministic)
+}
+func (m *PageToken) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PageToken.Merge(m, src)
+}
+func (m *PageToken) XXX_Size() int {
+	return xxx_messageInfo_PageToken.Size(m)
+}
+func (m *PageToken) XXX_DiscardUnknown() {
+	xxx_messageInfo_PageToken.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PageToken proto.InternalMessageInfo
+
+func (m *PageToken) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+func (m *PageToken) GetExpires() *timestamp.Timestamp {
+	if m != nil {
+		return m.Expires
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ReadToken)(nil), "google.keytransparency.v1.ReadToken")
 	proto.RegisterType((*ListUserRevisionsToken)(nil), "google.keytransparency.v1.ListUserRevisionsToken")
+	proto.RegisterType((*PageToken)(nil), "google.keytransparency.v1.PageToken")
 }
 
 func init() { proto.RegisterFile("readtoken.proto", fileDescriptor_735a2ae6888918c9) }
 
 var fileDescriptor_735a2ae6888918c9 = []byte{
-	// 271 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x90, 0x41, 0x4b, 0xc3, 0x40,
-	0x10, 0x85, 0x49, 0x13, 0xb4, 0x6e, 0xc1, 0xd6, 0x3d, 0x48, 0xf4, 0x62, 0xe9, 0xc5, 0x5e, 0xdc,
-	0x50, 0xfb, 0x0f, 0x14, 0x0f, 0x16, 0xbd, 0x2c, 0x8a, 0xe0, 0x65, 0xd9, 0x26, 0x43, 0x5c, 0xd2,
-	0xee, 0xd6, 0xd9, 0x49, 0xb4, 0x7f, 0xc4, 0xdf, 0x2b, 0xdd, 0x10, 0x85, 0x8a, 0xa7, 0x81, 0x6f,
-	0x78, 0xef, 0xf1, 0x1e, 0x1b, 0x22, 0xe8, 0x82, 0x5c, 0x05, 0x56, 0x6c, 0xd0, 0x91, 0xe3, 0x67,
-	0xa5, 0x73, 0xe5, 0x0a, 0x44, 0x05, 0x5b, 0x42, 0x6d, 0xfd, 0x46, 0x23, 0xd8, 0x7c, 0x2b, 0x9a,
-	0xd9, 0x79, 0xda, 0xcc, 0xb2, 0x7d, 0x1c, 0x44, 0x13, 0xcd, 0x8e, 0x24, 0xe8, 0xe2, 0x69, 0xe7,
-	0xc3, 0x2f, 0xd8, 0xc0, 0xaf, 0x4c, 0x0e, 0xca, 0xd8, 0x02, 0x3e, 0xd3, 0x68, 0x1c, 0x4d, 0x63,
-	0xc9, 0x02, 0xba, 0xdf, 0x11, 0x7e, 0xc9, 0x86, 0x9e, 0x34, 0x92, 0xfa, 0xd0, 0x04, 0xb8, 0xd6,
-	0x58, 0xa5, 0xc9, 0x38, 0x9a, 0x26, 0xf2, 0x38, 0xe0, 0x97, 0x8e, 0x2e, 0x92, 0x7e, 0x6f, 0x14,
-	0x2f, 0x92, 0x7e, 0x3c, 0x4a, 0x26, 0x5f, 0x11, 0x3b, 0x7d, 0x30, 0x9e, 0x9e, 0x3d, 0xa0, 0x84,
-	0xc6, 0x78, 0xe3, 0xac, 0x6f, 0x03, 0x1f, 0xd9, 0x21, 0xc2, 0x7b, 0x0d, 0x9e, 0x42, 0xd8, 0xe0,
-	0x7a, 0x2e, 0xfe, 0x2d, 0x21, 0xfe, 0x78, 0xc8, 0x56, 0x2a, 0x3b, 0x0f, 0x7e, 0xc5, 0x38, 0x76,
-	0x4f, 0x85, 0x40, 0x35, 0x5a, 0x28, 0xd2, 0x5e, 0xa8, 0x71, 0x82, 0xbf, 0xb2, 0xf6, 0x71, 0x73,
-	0xf7, 0x7a, 0x5b, 0x1a, 0x7a, 0xab, 0x97, 0x22, 0x77, 0xeb, 0xac, 0x0d, 0xde, 0x9f, 0x29, 0xcb,
-	0x1d, 0x06, 0xe8, 0x01, 0x1b, 0xc0, 0xec, 0x67, 0x74, 0x55, 0x3a, 0x15, 0x26, 0x5c, 0x1e, 0x84,
-	0x33, 0xff, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x14, 0x27, 0xbb, 0xe3, 0x91, 0x01, 0x00, 0x00,
+	// 330 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0xcf, 0x4e, 0xea, 0x40,
+	0x14, 0xc6, 0x53, 0xe8, 0xbd, 0xc0, 0x70, 0x73, 0xe1, 0x36, 0x37, 0xa6, 0xb2, 0x81, 0xb0, 0x91,
+	0x8d, 0xd3, 0x00, 0x3e, 0x81, 0xc6, 0x85, 0x44, 0x13, 0x33, 0xc1, 0x90, 0xb8, 0x69, 0x86, 0xf6,
+	0x58, 0x27, 0xd0, 0x4e, 0x3d, 0x33, 0xad, 0xf0, 0x22, 0x3e, 0xaf, 0x61, 0xc6, 0xd1, 0x04, 0xe3,
+	0x6a, 0x32, 0xbf, 0x73, 0xbe, 0xef, 0xfc, 0x23, 0x3d, 0x04, 0x9e, 0x6a, 0xb9, 0x81, 0x82, 0x96,
+	0x28, 0xb5, 0x0c, 0x4e, 0x33, 0x29, 0xb3, 0x2d, 0xd0, 0x0d, 0xec, 0x35, 0xf2, 0x42, 0x95, 0x1c,
+	0xa1, 0x48, 0xf6, 0xb4, 0x9e, 0x0e, 0xc2, 0x7a, 0x1a, 0x1d, 0x63, 0x23, 0x1a, 0x0c, 0xad, 0x28,
+	0x32, 0xbf, 0x75, 0xf5, 0x14, 0x69, 0x91, 0x83, 0xd2, 0x3c, 0x2f, 0x6d, 0xc2, 0x98, 0x93, 0x0e,
+	0x03, 0x9e, 0x2e, 0x0f, 0x85, 0x82, 0x21, 0xe9, 0xaa, 0xad, 0x48, 0x20, 0x16, 0x45, 0x0a, 0xbb,
+	0xd0, 0x1b, 0x79, 0x93, 0x26, 0x23, 0x06, 0xdd, 0x1c, 0x48, 0x70, 0x46, 0x7a, 0x4a, 0x73, 0xd4,
+	0xf1, 0x2b, 0xd7, 0x80, 0x39, 0xc7, 0x4d, 0xe8, 0x8f, 0xbc, 0x89, 0xcf, 0xfe, 0x1a, 0xbc, 0x72,
+	0x74, 0xe1, 0xb7, 0x1b, 0xfd, 0xe6, 0xc2, 0x6f, 0x37, 0xfb, 0xfe, 0xf8, 0xcd, 0x23, 0x27, 0xb7,
+	0x42, 0xe9, 0x07, 0x05, 0xc8, 0xa0, 0x16, 0x4a, 0xc8, 0x42, 0xd9, 0x82, 0x77, 0xa4, 0x85, 0xf0,
+	0x52, 0x81, 0xd2, 0xa6, 0x58, 0x77, 0x36, 0xa7, 0x3f, 0x4e, 0x49, 0xbf, 0x79, 0x30, 0x2b, 0x65,
+	0xce, 0x23, 0x38, 0x27, 0x01, 0xba, 0x60, 0x8c, 0xa0, 0x2b, 0x2c, 0x20, 0x0d, 0x1b, 0x66, 0x8c,
+	0x7f, 0xf8, 0x25, 0xb3, 0x81, 0xf1, 0x8a, 0x74, 0xee, 0x79, 0x06, 0xb6, 0x95, 0xff, 0xe4, 0x97,
+	0xd9, 0xb6, 0x69, 0xe4, 0x0f, 0xb3, 0x9f, 0xe0, 0x82, 0xb4, 0x60, 0x57, 0x0a, 0x04, 0x65, 0x6c,
+	0xba, 0xb3, 0x81, 0x6b, 0xd0, 0x6d, 0x94, 0x2e, 0xdd, 0x46, 0x99, 0x4b, 0xbd, 0xbc, 0x7e, 0xbc,
+	0xca, 0x84, 0x7e, 0xae, 0xd6, 0x34, 0x91, 0x79, 0xf4, 0x71, 0x82, 0xa3, 0x89, 0xa2, 0x44, 0xa2,
+	0x81, 0x0a, 0xb0, 0x06, 0x8c, 0x3e, 0xcf, 0x1d, 0x67, 0x32, 0xb6, 0xe6, 0xbf, 0xcd, 0x33, 0x7f,
+	0x1f, 0x00, 0x8b, 0xd0, 0x86, 0xd2, 0x0b, 0x02, 0x00, 0x00,
 }
@@ -16,9 +16,12 @@ package keyserver
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian/types"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -85,6 +88,74 @@ func (s *Server) GetRevision(ctx context.Context, in *pb.GetRevisionRequest) (*p
 	return s.getRevisionByRevision(ctx, d, logRoot, logConsistency, in.GetRevision())
 }
 
+// GetRevisionByTime returns the latest revision whose map root timestamp is at or before the requested time.
+func (s *Server) GetRevisionByTime(ctx context.Context, in *pb.GetRevisionByTimeRequest) (*pb.Revision, error) {
+	t, err := ptypes.Timestamp(in.GetTime())
+	if err != nil {
+		glog.Errorf("GetRevisionByTime(): ptypes.Timestamp(%v): %v", in.GetTime(), err)
+		return nil, status.Error(codes.InvalidArgument, "Invalid time")
+	}
+
+	// Lookup log and map info.
+	d, err := s.directories.Read(ctx, in.DirectoryId, false)
+	if st := status.Convert(err); st.Code() != codes.OK {
+		glog.Errorf("GetRevisionByTime(): adminstorage.Read(%v): %v", in.DirectoryId, err)
+		return nil, status.Errorf(st.Code(), "Cannot fetch directory info %v", st.Message())
+	}
+
+	logRoot, logConsistency, err := s.latestLogRootProof(ctx, d, in.GetLastVerified().GetTreeSize())
+	if err != nil {
+		return nil, err
+	}
+	currentRevision, err := mapRevisionFor(logRoot)
+	if err != nil {
+		glog.Errorf("mapRevisionFor(log %v, sth%v): %v", d.Log.TreeId, logRoot, err)
+		return nil, err
+	}
+
+	revision, err := s.revisionAtTime(ctx, d, currentRevision, t)
+	if err != nil {
+		return nil, err
+	}
+	return s.getRevisionByRevision(ctx, d, logRoot, logConsistency, revision)
+}
+
+// revisionAtTime returns the latest revision in [0, currentRevision] whose
+// map root timestamp is at or before t. Map root timestamps are assumed to
+// increase with the revision number.
+func (s *Server) revisionAtTime(ctx context.Context, d *directory.Directory,
+	currentRevision int64, t time.Time) (int64, error) {
+	var searchErr error
+	// Find the first revision that was created after t.
+	after := sort.Search(int(currentRevision)+1, func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		resp, err := s.tmap.GetSignedMapRootByRevision(ctx, &tpb.GetSignedMapRootByRevisionRequest{
+			MapId:    d.Map.TreeId,
+			Revision: int64(i),
+		})
+		if err != nil {
+			glog.Errorf("GetSignedMapRootByRevision(%v, %v): %v", d.Map.TreeId, i, err)
+			searchErr = err
+			return true
+		}
+		var mapRoot types.MapRootV1
+		if err := mapRoot.UnmarshalBinary(resp.GetMapRoot().GetMapRoot()); err != nil {
+			searchErr = status.Errorf(codes.Internal, "keyserver: Failed to unmarshal map root: %v", err)
+			return true
+		}
+		return int64(mapRoot.TimestampNanos) > t.UnixNano()
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	if after == 0 {
+		return 0, status.Errorf(codes.NotFound, "keyserver: No revision at or before %v", t)
+	}
+	return int64(after - 1), nil
+}
+
 func (s *Server) getRevisionByRevision(ctx context.Context, d *directory.Directory,
 	logRoot *tpb.SignedLogRoot, logConsistency *tpb.Proof, mapRevision int64) (*pb.Revision, error) {
 	logInclusion, err := s.logInclusion(ctx, d, logRoot, mapRevision)
@@ -119,6 +190,21 @@ func (*Server) GetRevisionStream(in *pb.GetRevisionRequest, stream pb.KeyTranspa
 	return status.Error(codes.Unimplemented, "GetRevisionStream is unimplemented")
 }
 
+// checkNotPruned returns an OutOfRange error if the mutations of revision rev
+// of directoryID have been pruned.
+func (s *Server) checkNotPruned(ctx context.Context, directoryID string, rev int64) error {
+	pruned, err := s.batches.PrunedRevision(ctx, directoryID)
+	if st := status.Convert(err); st.Code() != codes.OK {
+		return status.Errorf(st.Code(), "PrunedRevision(%v): %v", directoryID, st.Message())
+	}
+	if rev <= pruned {
+		return status.Errorf(codes.OutOfRange,
+			"mutations of revision %v have been deleted, the earliest revision with mutations is %v",
+			rev, pruned+1)
+	}
+	return nil
+}
+
 // ListMutations returns the mutations that created an revision.
 func (s *Server) ListMutations(ctx context.Context, in *pb.ListMutationsRequest) (*pb.ListMutationsResponse, error) {
 	if err := validateListMutationsRequest(in); err != nil {
@@ -131,13 +217,22 @@ func (s *Server) ListMutations(ctx context.Context, in *pb.ListMutationsRequest)
 		glog.Errorf("ListMutations(): adminstorage.Read(%v): %v", in.DirectoryId, err)
 		return nil, status.Errorf(st.Code(), "Cannot fetch directory info: %v", st.Message())
 	}
+	if err := s.checkNotPruned(ctx, in.DirectoryId, in.Revision); err != nil {
+		return nil, err
+	}
 	meta, err := s.batches.ReadBatch(ctx, in.DirectoryId, in.Revision)
 	if st := status.Convert(err); st.Code() != codes.OK {
 		return nil, status.Errorf(st.Code(), "ReadBatch(%v, %v): %v", in.DirectoryId, in.Revision, st.Message())
 	}
-	rt, err := SourceList(meta.Sources).ParseToken(in.PageToken)
+	if len(meta.Sources) == 0 {
+		return &pb.ListMutationsResponse{}, nil // No mutations in this revision.
+	}
+	// Page tokens are only valid for the directory and revision they were issued for.
+	binding := &pb.ListMutationsRequest{DirectoryId: in.DirectoryId, Revision: in.Revision}
+	rt, err := SourceList(meta.Sources).ParseToken(s.tokens, in.PageToken, binding)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "Failed parsing page_token: %v: %v", in.PageToken, err)
+		glog.Errorf("ListMutations(): invalid page token %v: %v", in.PageToken, err)
+		return nil, status.Errorf(codes.InvalidArgument, "Failed parsing page_token: %v", err)
 	}
 
 	// Read PageSize + 1 messages from the log to see if there is another page.
@@ -150,6 +245,11 @@ func (s *Server) ListMutations(ctx context.Context, in *pb.ListMutationsRequest)
 			d.DirectoryID, logID, low, high, in.PageSize, err)
 		return nil, status.Errorf(st.Code(), "Reading mutations range failed: %v", st.Message())
 	}
+	// The revision may have been pruned while its mutations were read, in
+	// which case the read may be missing some of them.
+	if err := s.checkNotPruned(ctx, in.DirectoryId, in.Revision); err != nil {
+		return nil, err
+	}
 	moreInLogID := len(msgs) == int(in.PageSize+1)
 	var lastRow *mutator.LogMessage
 	if moreInLogID {
@@ -176,7 +276,7 @@ func (s *Server) ListMutations(ctx context.Context, in *pb.ListMutationsRequest)
 	for i, p := range proofs {
 		mutations[i].LeafProof = p
 	}
-	nextToken, err := EncodeToken(SourceList(meta.Sources).Next(rt, lastRow))
+	nextToken, err := s.tokens.Encode(SourceList(meta.Sources).Next(rt, lastRow), binding)
 	if st := status.Convert(err); st.Code() != codes.OK {
 		return nil, status.Errorf(st.Code(), "Failed creating next token: %v", st.Message())
 	}
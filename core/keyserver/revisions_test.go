@@ -22,11 +22,13 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/sequencer/metadata"
 	"github.com/google/keytransparency/core/water"
 	"github.com/google/keytransparency/impl/memory"
 	"github.com/google/trillian/testonly/matchers"
+	"github.com/google/trillian/types"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -88,29 +90,58 @@ func (b batchStorage) ReadBatch(ctx context.Context, dirID string, rev int64) (*
 	return &spb.MapMetadata{Sources: b[rev]}, nil
 }
 
-func MustEncodeToken(t *testing.T, low water.Mark) string {
+func (b batchStorage) PrunedRevision(ctx context.Context, dirID string) (int64, error) {
+	return 0, nil
+}
+
+// prunedBatches has deleted the mutations of revisions up to pruned.
+type prunedBatches struct {
+	batchStorage
+	pruned int64
+}
+
+func (b prunedBatches) PrunedRevision(ctx context.Context, dirID string) (int64, error) {
+	return b.pruned, nil
+}
+
+// pruningBatches prunes revisions up to pruned after the first call to
+// PrunedRevision, as if they were pruned while their mutations were read.
+type pruningBatches struct {
+	batchStorage
+	pruned int64
+	calls  *int
+}
+
+func (b pruningBatches) PrunedRevision(ctx context.Context, dirID string) (int64, error) {
+	*b.calls++
+	if *b.calls == 1 {
+		return 0, nil
+	}
+	return b.pruned, nil
+}
+
+func MustEncodeToken(t *testing.T, sliceIndex int64, low water.Mark, binding proto.Message) string {
 	t.Helper()
 
 	rt := &rtpb.ReadToken{
-		SliceIndex:     0,
+		SliceIndex:     sliceIndex,
 		StartWatermark: low.Value(),
 	}
-	token, err := EncodeToken(rt)
+	token, err := newTestPageTokens(t, true).Encode(rt, binding)
 	if err != nil {
-		t.Fatalf("EncodeToken(%v): %v", rt, err)
+		t.Fatalf("Encode(%v): %v", rt, err)
 	}
 	return token
 }
 
 func TestListMutations(t *testing.T) {
 	ctx := context.Background()
-	dirID := "TestListMutations"
 	logID := int64(0)
-	fakeLogs := memory.NewMutationLogs()
+	fakeLogs := memory.NewMutations()
 	idx := make([]water.Mark, 0, 12)
 	for i := int64(0); i < 12; i++ {
 		// Send one entry.
-		ws, err := fakeLogs.Send(ctx, dirID, logID, genEntryUpdates(t, i, i+1)...)
+		ws, err := fakeLogs.Send(ctx, directoryID, logID, genEntryUpdates(t, i, i+1)...)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -122,6 +153,7 @@ func TestListMutations(t *testing.T) {
 		2: SourceList{newSource(0, idx[7], idx[11])},
 	}
 
+	binding := &pb.ListMutationsRequest{DirectoryId: directoryID, Revision: 1}
 	for _, tc := range []struct {
 		desc       string
 		token      string
@@ -133,10 +165,13 @@ func TestListMutations(t *testing.T) {
 		{desc: "first page", pageSize: 6, start: 2, end: 7, wantNext: &rtpb.ReadToken{}},
 		{desc: "large page", pageSize: 10, start: 2, end: 7, wantNext: &rtpb.ReadToken{}},
 		{desc: "partial", pageSize: 4, start: 2, end: 6, wantNext: &rtpb.ReadToken{StartWatermark: idx[6].Value()}},
-		{desc: "large page with token", token: MustEncodeToken(t, idx[3]), pageSize: 10, start: 3, end: 7, wantNext: &rtpb.ReadToken{}},
-		{desc: "small page with token", token: MustEncodeToken(t, idx[3]), pageSize: 2, start: 3, end: 5,
+		{desc: "large page with token", token: MustEncodeToken(t, 0, idx[3], binding), pageSize: 10, start: 3, end: 7, wantNext: &rtpb.ReadToken{}},
+		{desc: "small page with token", token: MustEncodeToken(t, 0, idx[3], binding), pageSize: 2, start: 3, end: 5,
 			wantNext: &rtpb.ReadToken{StartWatermark: idx[5].Value()}},
 		{desc: "invalid page token", token: "some_token", pageSize: 0, wantErr: true},
+		{desc: "token for other revision", token: MustEncodeToken(t, 0, idx[3],
+			&pb.ListMutationsRequest{DirectoryId: directoryID, Revision: 2}), pageSize: 2, wantErr: true},
+		{desc: "slice out of range", token: MustEncodeToken(t, 1, idx[3], binding), pageSize: 2, wantErr: true},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			revision := int64(1)
@@ -147,7 +182,7 @@ func TestListMutations(t *testing.T) {
 				t.Fatalf("newMiniEnv(): %v", err)
 			}
 			defer e.Close()
-			e.srv.logs = &fakeLogs
+			e.srv.logs = fakeLogs
 			e.srv.batches = fakeBatches
 
 			if !tc.wantErr {
@@ -185,8 +220,10 @@ func TestListMutations(t *testing.T) {
 			}
 
 			var npt rtpb.ReadToken
-			if err := DecodeToken(resp.NextPageToken, &npt); err != nil {
-				t.Errorf("DecodeToken(): %v", err)
+			if resp.NextPageToken != "" {
+				if err := e.srv.tokens.Decode(resp.NextPageToken, &npt, binding); err != nil {
+					t.Errorf("Decode(): %v", err)
+				}
 			}
 			if !proto.Equal(&npt, tc.wantNext) {
 				t.Errorf("resp.NextPageToken:%v-> %v, want %v", resp.NextPageToken, &npt, tc.wantNext)
@@ -194,3 +231,128 @@ func TestListMutations(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRevisionByTime(t *testing.T) {
+	ctx := context.Background()
+	// Revision i has a map root timestamp of (i+1) * 10 seconds.
+	timestamp := func(rev int64) time.Time { return time.Unix((rev+1)*10, 0) }
+	const treeSize = 5
+
+	for _, tc := range []struct {
+		desc     string
+		time     time.Time
+		wantRev  int64
+		wantCode codes.Code
+	}{
+		{desc: "before first revision", time: timestamp(0).Add(-time.Second), wantCode: codes.NotFound},
+		{desc: "exactly first revision", time: timestamp(0), wantRev: 0},
+		{desc: "between revisions", time: timestamp(2).Add(time.Second), wantRev: 2},
+		{desc: "exactly middle revision", time: timestamp(3), wantRev: 3},
+		{desc: "after last revision", time: timestamp(treeSize + 10), wantRev: treeSize - 1},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+			defer cancel()
+			e, err := newMiniEnv(ctx, t)
+			if err != nil {
+				t.Fatalf("newMiniEnv(): %v", err)
+			}
+			defer e.Close()
+			e.s.Log.EXPECT().GetLatestSignedLogRoot(gomock.Any(), gomock.Any()).
+				Return(&tpb.GetLatestSignedLogRootResponse{
+					SignedLogRoot: mustMarshalRoot(t, &types.LogRootV1{TreeSize: treeSize}),
+				}, nil)
+			e.s.Map.EXPECT().GetSignedMapRootByRevision(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, in *tpb.GetSignedMapRootByRevisionRequest) (*tpb.GetSignedMapRootResponse, error) {
+					root := &types.MapRootV1{
+						Revision:       uint64(in.Revision),
+						TimestampNanos: uint64(timestamp(in.Revision).UnixNano()),
+					}
+					b, err := root.MarshalBinary()
+					if err != nil {
+						return nil, err
+					}
+					return &tpb.GetSignedMapRootResponse{MapRoot: &tpb.SignedMapRoot{MapRoot: b}}, nil
+				}).AnyTimes()
+			e.s.Log.EXPECT().GetInclusionProof(gomock.Any(), gomock.Any()).
+				Return(&tpb.GetInclusionProofResponse{}, nil).AnyTimes()
+
+			ts, err := ptypes.TimestampProto(tc.time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := e.srv.GetRevisionByTime(ctx, &pb.GetRevisionByTimeRequest{
+				DirectoryId: directoryID,
+				Time:        ts,
+			})
+			if got, want := status.Code(err), tc.wantCode; got != want {
+				t.Fatalf("GetRevisionByTime(): %v, want %v", err, want)
+			}
+			if err != nil {
+				return
+			}
+			var mapRoot types.MapRootV1
+			if err := mapRoot.UnmarshalBinary(resp.GetMapRoot().GetMapRoot().GetMapRoot()); err != nil {
+				t.Fatalf("UnmarshalBinary(): %v", err)
+			}
+			if got, want := int64(mapRoot.Revision), tc.wantRev; got != want {
+				t.Errorf("GetRevisionByTime(): revision %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestListMutationsPruned(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+	e.srv.batches = prunedBatches{batchStorage: batchStorage{}, pruned: 2}
+
+	for _, tc := range []struct {
+		revision int64
+		want     codes.Code
+	}{
+		{revision: 1, want: codes.OutOfRange},
+		{revision: 2, want: codes.OutOfRange},
+		{revision: 3, want: codes.OK},
+	} {
+		_, err := e.srv.ListMutations(ctx, &pb.ListMutationsRequest{
+			DirectoryId: directoryID,
+			Revision:    tc.revision,
+		})
+		if got := status.Code(err); got != tc.want {
+			t.Errorf("ListMutations(revision %v): %v, want %v", tc.revision, err, tc.want)
+		}
+	}
+}
+
+func TestListMutationsPrunedDuringRead(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	logs := memory.NewMutations()
+	low, err := logs.Send(ctx, directoryID, 0, genEntryUpdates(t, 0, 2)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := newMiniEnv(ctx, t)
+	if err != nil {
+		t.Fatalf("newMiniEnv(): %v", err)
+	}
+	defer e.Close()
+	var calls int
+	e.srv.logs = logs
+	e.srv.batches = pruningBatches{
+		batchStorage: batchStorage{1: SourceList{newSource(0, low, low.Add(2))}},
+		pruned:       1,
+		calls:        &calls,
+	}
+
+	_, err = e.srv.ListMutations(ctx, &pb.ListMutationsRequest{DirectoryId: directoryID, Revision: 1})
+	if got, want := status.Code(err), codes.OutOfRange; got != want {
+		t.Errorf("ListMutations(): %v, want %v", err, want)
+	}
+}
@@ -34,6 +34,8 @@ import (
 const (
 	MaxClockDrift = 5 * time.Minute
 	MinNonceLen   = 16
+	// MaxRequestIDLen is the longest request ID an EntryUpdate may carry.
+	MaxRequestIDLen = 64
 )
 
 var (
@@ -41,6 +43,8 @@ var (
 	ErrNoCommitted = errors.New("missing commitment")
 	// ErrCommittedKeyLen occurs when the committed key is too small.
 	ErrCommittedKeyLen = errors.New("committed.key is too small")
+	// ErrTombstoneCommitted occurs when a tombstone carries committed data.
+	ErrTombstoneCommitted = errors.New("tombstone must not have committed data")
 	// ErrWrongIndex occurs when the index in key value does not match the
 	// output of VRF.
 	ErrWrongIndex = errors.New("index does not match VRF")
@@ -52,10 +56,18 @@ var (
 	// ErrInvalidEnd occurs when the end revision of the ListUserRevisionsRequest
 	// is not in [start, currentRevision].
 	ErrInvalidEnd = errors.New("invalid end revision")
+	// ErrRequestIDLen occurs when a request ID is longer than MaxRequestIDLen.
+	ErrRequestIDLen = errors.New("request_id is too long")
+	// ErrDuplicateRequestID occurs when two updates in a batch share a request ID.
+	ErrDuplicateRequestID = errors.New("duplicate request_id in batch")
+	// ErrInvalidUserID occurs when a user ID is not valid UTF-8, which would
+	// let it collide with the VRF input of another user.
+	ErrInvalidUserID = errors.New("user_id is not valid UTF-8")
 )
 
 // validateEntryUpdate verifies
 // - Commitment in SignedEntryUpdate matches the serialized profile.
+// - Tombstones do not carry committed data.
 func validateEntryUpdate(in *pb.EntryUpdate, vrfPriv vrf.PrivateKey) error {
 	var entry pb.Entry
 	if err := proto.Unmarshal(in.GetMutation().GetEntry(), &entry); err != nil {
@@ -63,11 +75,22 @@ func validateEntryUpdate(in *pb.EntryUpdate, vrfPriv vrf.PrivateKey) error {
 	}
 
 	// Verify Index / VRF
-	index, _ := vrfPriv.Evaluate([]byte(in.UserId))
+	if !vrf.ValidUserID(in.UserId) {
+		return ErrInvalidUserID
+	}
+	index, _ := vrfPriv.Evaluate(vrf.UniqueID(in.UserId, in.AppId))
 	if got, want := entry.Index, index[:]; !bytes.Equal(got, want) {
 		return ErrWrongIndex
 	}
 
+	// Tombstones do not commit to any data.
+	if entry.GetDeleted() {
+		if in.GetCommitted() != nil {
+			return ErrTombstoneCommitted
+		}
+		return nil
+	}
+
 	// Verify correct commitment to profile.
 	committed := in.GetCommitted()
 	if committed == nil {
@@ -79,6 +102,26 @@ func validateEntryUpdate(in *pb.EntryUpdate, vrfPriv vrf.PrivateKey) error {
 	return commitments.Verify(in.UserId, entry.Commitment, committed.Data, committed.Key)
 }
 
+// validateRequestIDs verifies that the request IDs in updates are of a valid
+// length and are unique within the batch.
+func validateRequestIDs(updates []*pb.EntryUpdate) error {
+	seen := make(map[string]bool)
+	for _, u := range updates {
+		id := u.GetRequestId()
+		if id == "" {
+			continue
+		}
+		if len(id) > MaxRequestIDLen {
+			return ErrRequestIDLen
+		}
+		if seen[id] {
+			return ErrDuplicateRequestID
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
 // validateListEntryHistoryRequest ensures that start revision is in range [1,
 // currentRevision] and sets the page size if it is 0 or larger than what the server
 // can return (due to reaching currentRevision).
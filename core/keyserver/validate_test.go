@@ -15,10 +15,12 @@
 package keyserver
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/core/crypto/commitments"
+	"github.com/google/keytransparency/core/crypto/vrf"
 	"github.com/google/keytransparency/core/crypto/vrf/p256"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
@@ -78,3 +80,102 @@ func TestValidateUpdateEntryRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateTombstone(t *testing.T) {
+	userID := "joe"
+	vrfPriv, _ := p256.GenerateKey()
+	index, _ := vrfPriv.Evaluate([]byte(userID))
+
+	for _, tc := range []struct {
+		desc      string
+		committed *pb.Committed
+		want      error
+	}{
+		{desc: "tombstone"},
+		{desc: "tombstone with data", committed: &pb.Committed{Data: []byte("bar")}, want: ErrTombstoneCommitted},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := &pb.EntryUpdate{
+				UserId: userID,
+				Mutation: &pb.SignedEntry{
+					Entry: mustMarshal(t, &pb.Entry{
+						Index:   index[:],
+						Deleted: true,
+					}),
+				},
+				Committed: tc.committed,
+			}
+			if err := validateEntryUpdate(req, vrfPriv); err != tc.want {
+				t.Errorf("validateEntryUpdate(): %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateAppID(t *testing.T) {
+	profileData := []byte("bar")
+	userID := "joe"
+	vrfPriv, _ := p256.GenerateKey()
+	nonce, err := commitments.GenCommitmentKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitment := commitments.Commit(userID, profileData, nonce)
+	userIndex, _ := vrfPriv.Evaluate(vrf.UniqueID(userID, ""))
+	appIndex, _ := vrfPriv.Evaluate(vrf.UniqueID(userID, "app"))
+
+	// forged is not UTF-8, and its default index is joe's app index.
+	forged := string(vrf.UniqueID(userID, "app"))
+
+	for _, tc := range []struct {
+		desc   string
+		userID string
+		appID  string
+		index  [32]byte
+		want   error
+	}{
+		{desc: "default entry", userID: userID, index: userIndex},
+		{desc: "app entry", userID: userID, appID: "app", index: appIndex},
+		{desc: "app entry at default index", userID: userID, appID: "app", index: userIndex, want: ErrWrongIndex},
+		{desc: "default entry at app index", userID: userID, index: appIndex, want: ErrWrongIndex},
+		{desc: "forged user at app index", userID: forged, index: appIndex, want: ErrInvalidUserID},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			req := &pb.EntryUpdate{
+				UserId: tc.userID,
+				AppId:  tc.appID,
+				Mutation: &pb.SignedEntry{
+					Entry: mustMarshal(t, &pb.Entry{
+						Index:      tc.index[:],
+						Commitment: commitment,
+					}),
+				},
+				Committed: &pb.Committed{Key: nonce, Data: profileData},
+			}
+			if err := validateEntryUpdate(req, vrfPriv); err != tc.want {
+				t.Errorf("validateEntryUpdate(): %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateRequestIDs(t *testing.T) {
+	update := func(id string) *pb.EntryUpdate { return &pb.EntryUpdate{RequestId: id} }
+	for _, tc := range []struct {
+		desc    string
+		updates []*pb.EntryUpdate
+		want    error
+	}{
+		{desc: "no ids", updates: []*pb.EntryUpdate{update(""), update("")}},
+		{desc: "unique ids", updates: []*pb.EntryUpdate{update("a"), update("b"), update("")}},
+		{desc: "max length", updates: []*pb.EntryUpdate{update(strings.Repeat("a", MaxRequestIDLen))}},
+		{desc: "too long", updates: []*pb.EntryUpdate{update(strings.Repeat("a", MaxRequestIDLen+1))}, want: ErrRequestIDLen},
+		{desc: "duplicate", updates: []*pb.EntryUpdate{update("a"), update("a")}, want: ErrDuplicateRequestID},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := validateRequestIDs(tc.updates); got != tc.want {
+				t.Errorf("validateRequestIDs(): %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -39,6 +39,8 @@ type Monitor struct {
 	mapVerifier *tclient.MapVerifier
 	signer      *tcrypto.Signer
 	store       monitorstorage.Interface
+	// allowRecovery is the recovery policy of the directory for deleted entries.
+	allowRecovery bool
 }
 
 // NewFromDirectory produces a new monitor from a Directory object.
@@ -58,7 +60,12 @@ func NewFromDirectory(cli pb.KeyTransparencyClient,
 		return nil, fmt.Errorf("could not create kt client: %v", err)
 	}
 
-	return New(ktClient, mapVerifier, signer, store)
+	m, err := New(ktClient, mapVerifier, signer, store)
+	if err != nil {
+		return nil, err
+	}
+	m.allowRecovery = config.GetAllowRecovery()
+	return m, nil
 }
 
 // New creates a new instance of the monitor.
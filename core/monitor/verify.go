@@ -108,7 +108,7 @@ func (m *Monitor) verifyMutations(muts []*pb.MutationProof, oldRoot, expectedNew
 		}
 
 		// compute the new leaf
-		newValue, err := entry.MutateFn(oldLeaf, mut.GetMutation())
+		newValue, err := entry.Mutate(oldLeaf, mut.GetMutation(), m.allowRecovery)
 		if err != nil {
 			glog.Infof("Mutation did not verify: %v", err)
 			errs.AppendStatus(status.Newf(codes.DataLoss, "invalid mutation: %v", err).WithDetails(mut.GetMutation()))
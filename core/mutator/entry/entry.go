@@ -76,9 +76,12 @@ func (iv *IndexedValue) Marshal() (*tpb.MapLeaf, error) {
 	if err != nil {
 		return nil, fmt.Errorf("entry: ToLeafValue(): %v", err)
 	}
-	extraData, err := proto.Marshal(iv.Value.GetCommitted())
-	if err != nil {
-		return nil, fmt.Errorf("entry: proto.Marshal(): %v", err)
+	// Tombstones do not commit to any data.
+	var extraData []byte
+	if committed := iv.Value.GetCommitted(); committed != nil {
+		if extraData, err = proto.Marshal(committed); err != nil {
+			return nil, fmt.Errorf("entry: proto.Marshal(): %v", err)
+		}
 	}
 	return &tpb.MapLeaf{Index: iv.Index, LeafValue: leafValue, ExtraData: extraData}, nil
 }
@@ -103,3 +106,17 @@ func FromLeafValue(value []byte) (*pb.SignedEntry, error) {
 func ToLeafValue(update *pb.SignedEntry) ([]byte, error) {
 	return proto.Marshal(update)
 }
+
+// IsTombstone returns true if the trillian.MapLeaf.LeafValue contains an
+// Entry that has been deleted.
+func IsTombstone(value []byte) (bool, error) {
+	signed, err := FromLeafValue(value)
+	if err != nil {
+		return false, err
+	}
+	var e pb.Entry
+	if err := proto.Unmarshal(signed.GetEntry(), &e); err != nil {
+		return false, err
+	}
+	return e.GetDeleted(), nil
+}
@@ -89,6 +89,16 @@ func TestIndexedValue(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Marshal failed: %v", err)
 	}
+	tombstone := &IndexedValue{
+		Index: []byte("index"),
+		Value: &pb.EntryUpdate{
+			Mutation: &pb.SignedEntry{Entry: mustMarshal(t, &pb.Entry{Deleted: true})},
+		},
+	}
+	tombstoneLeaf, err := tombstone.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal(tombstone) failed: %v", err)
+	}
 
 	for _, tc := range []struct {
 		desc    string
@@ -99,6 +109,7 @@ func TestIndexedValue(t *testing.T) {
 		{desc: "empty leaf", mapLeaf: &tpb.MapLeaf{Index: []byte("index")}, want: &IndexedValue{Index: []byte("index")}},
 		{desc: "invalid", mapLeaf: &tpb.MapLeaf{LeafValue: []byte{2, 2, 2, 2, 2, 2}}, want: &IndexedValue{}, wantErr: true},
 		{desc: "valid", mapLeaf: leaf, want: iv},
+		{desc: "tombstone", mapLeaf: tombstoneLeaf, want: tombstone},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			got := &IndexedValue{}
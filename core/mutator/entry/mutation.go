@@ -29,7 +29,16 @@ import (
 
 // Mutation provides APIs for manipulating entries.
 type Mutation struct {
-	UserID      string
+	UserID string
+	AppID  string
+	// RequestID identifies the mutation to the server, which queues each
+	// request ID at most once. Reuse it when retrying the same mutation.
+	RequestID string
+	// AllowRecovery is the recovery policy of the directory. If set, the
+	// mutation may replace a deleted previous entry, and must be signed by
+	// the keys of the tombstone.
+	AllowRecovery bool
+
 	data, nonce []byte
 
 	prevRev         uint64
@@ -43,9 +52,10 @@ type Mutation struct {
 // - Create a new mutation for a user starting with the previous value with NewMutation.
 // - Change the value with SetCommitment and ReplaceAuthorizedKeys.
 // - Finalize the changes and create the mutation with SerializeAndSign.
-func NewMutation(index []byte, directoryID, userID string) *Mutation {
+func NewMutation(index []byte, directoryID, userID, appID string) *Mutation {
 	return &Mutation{
 		UserID: userID,
+		AppID:  appID,
 		entry: &pb.Entry{
 			Index: index,
 		},
@@ -103,6 +113,18 @@ func (m *Mutation) SetCommitment(data []byte) error {
 	return nil
 }
 
+// SetDeleted turns the mutation into a tombstone which deletes the entry.
+// The tombstone must be signed by the current authorized keys. Once applied,
+// no further changes to the entry are accepted, unless the directory allows
+// recovery, in which case a mutation with AllowRecovery set and signed by the
+// tombstone's keys may replace it.
+func (m *Mutation) SetDeleted() {
+	m.data = nil
+	m.nonce = nil
+	m.entry.Commitment = nil
+	m.entry.Deleted = true
+}
+
 // ReplaceAuthorizedKeys sets authorized keys to pubkeys.
 // pubkeys must contain at least one key.
 func (m *Mutation) ReplaceAuthorizedKeys(handle *keyset.Handle) error {
@@ -122,18 +144,24 @@ func (m *Mutation) SerializeAndSign(signers []tink.Signer) (*pb.EntryUpdate, err
 	}
 
 	// Sanity check the mutation's correctness.
-	if _, err := MutateFn(m.prevSignedEntry, mutation); err != nil {
+	if _, err := Mutate(m.prevSignedEntry, mutation, m.AllowRecovery); err != nil {
 		return nil, err
 	}
 
-	return &pb.EntryUpdate{
-		UserId:   m.UserID,
-		Mutation: mutation,
-		Committed: &pb.Committed{
+	update := &pb.EntryUpdate{
+		UserId:    m.UserID,
+		AppId:     m.AppID,
+		Mutation:  mutation,
+		RequestId: m.RequestID,
+	}
+	// Tombstones do not commit to any data.
+	if !m.entry.GetDeleted() {
+		update.Committed = &pb.Committed{
 			Key:  m.nonce,
 			Data: m.data,
-		},
-	}, nil
+		}
+	}
+	return update, nil
 }
 
 // Sign produces the mutation
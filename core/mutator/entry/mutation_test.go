@@ -57,7 +57,8 @@ func TestSerializeAndSign(t *testing.T) {
 			index := []byte{}
 			userID := "alice"
 
-			m := NewMutation(index, directoryID, userID)
+			m := NewMutation(index, directoryID, userID, "")
+			m.RequestID = "request"
 			if err := m.SetPrevious(0, tc.old, true); err != nil {
 				t.Fatalf("NewMutation(%v): %v", tc.old, err)
 			}
@@ -67,10 +68,13 @@ func TestSerializeAndSign(t *testing.T) {
 			if err := m.ReplaceAuthorizedKeys(tc.pubKeys); err != nil {
 				t.Fatalf("ReplaceAuthorizedKeys(%v): %v", tc.pubKeys, err)
 			}
-			_, err := m.SerializeAndSign(tc.signers)
+			update, err := m.SerializeAndSign(tc.signers)
 			if got := status.Code(err); got != tc.want {
 				t.Fatalf("SerializeAndSign(): %v, want %v", err, tc.want)
 			}
+			if err == nil && update.GetRequestId() != m.RequestID {
+				t.Errorf("SerializeAndSign().RequestId: %q, want %q", update.GetRequestId(), m.RequestID)
+			}
 		})
 	}
 }
@@ -94,7 +98,7 @@ func TestCreateAndVerify(t *testing.T) {
 			index := []byte{}
 			userID := "alice"
 
-			m := NewMutation(index, directoryID, userID)
+			m := NewMutation(index, directoryID, userID, "")
 			if err := m.SetPrevious(0, tc.old, true); err != nil {
 				t.Fatalf("NewMutation(%v): %v", tc.old, err)
 			}
@@ -130,3 +134,61 @@ func TestCreateAndVerify(t *testing.T) {
 		})
 	}
 }
+
+func TestSetDeleted(t *testing.T) {
+	userID := "alice"
+	signers := testutil.SignKeysetsFromPEMs(testPrivKey1)
+
+	// Create an entry to delete.
+	m := NewMutation([]byte{}, directoryID, userID, "")
+	if err := m.SetCommitment([]byte("foo")); err != nil {
+		t.Fatalf("SetCommitment(): %v", err)
+	}
+	if err := m.ReplaceAuthorizedKeys(testutil.VerifyKeysetFromPEMs(testPubKey1)); err != nil {
+		t.Fatalf("ReplaceAuthorizedKeys(): %v", err)
+	}
+	update, err := m.SerializeAndSign(signers)
+	if err != nil {
+		t.Fatalf("SerializeAndSign(): %v", err)
+	}
+	leaf, err := ToLeafValue(update.GetMutation())
+	if err != nil {
+		t.Fatalf("ToLeafValue(): %v", err)
+	}
+
+	// Delete it.
+	d := NewMutation([]byte{}, directoryID, userID, "")
+	if err := d.SetPrevious(0, leaf, true); err != nil {
+		t.Fatalf("SetPrevious(): %v", err)
+	}
+	d.SetDeleted()
+	tombstone, err := d.SerializeAndSign(signers)
+	if err != nil {
+		t.Fatalf("SerializeAndSign(tombstone): %v", err)
+	}
+	if tombstone.GetCommitted() != nil {
+		t.Errorf("tombstone.Committed: %v, want nil", tombstone.GetCommitted())
+	}
+	tombstoneLeaf, err := ToLeafValue(tombstone.GetMutation())
+	if err != nil {
+		t.Fatalf("ToLeafValue(): %v", err)
+	}
+	if deleted, err := IsTombstone(tombstoneLeaf); err != nil || !deleted {
+		t.Errorf("IsTombstone(): %v, %v, want true", deleted, err)
+	}
+	if deleted, err := IsTombstone(leaf); err != nil || deleted {
+		t.Errorf("IsTombstone(): %v, %v, want false", deleted, err)
+	}
+
+	// Tombstones cannot be modified.
+	u := NewMutation([]byte{}, directoryID, userID, "")
+	if err := u.SetPrevious(0, tombstoneLeaf, true); err != nil {
+		t.Fatalf("SetPrevious(): %v", err)
+	}
+	if err := u.SetCommitment([]byte("bar")); err != nil {
+		t.Fatalf("SetCommitment(): %v", err)
+	}
+	if _, err := u.SerializeAndSign(signers); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("SerializeAndSign(after tombstone): %v, want %v", err, codes.FailedPrecondition)
+	}
+}
@@ -58,6 +58,9 @@ func IsValidEntry(signedEntry *pb.SignedEntry) error {
 	if err := proto.Unmarshal(signedEntry.GetEntry(), &newEntry); err != nil {
 		return status.Errorf(codes.InvalidArgument, "proto.Unmarshal(): %v", err)
 	}
+	if newEntry.GetDeleted() && len(newEntry.GetCommitment()) != 0 {
+		return status.Errorf(codes.InvalidArgument, "mutation: tombstone must not contain a commitment")
+	}
 
 	ks, err := keyset.ReadWithNoSecrets(keyset.NewBinaryReader(
 		bytes.NewBuffer(newEntry.GetAuthorizedKeyset())))
@@ -68,7 +71,22 @@ func IsValidEntry(signedEntry *pb.SignedEntry) error {
 }
 
 // ReduceFn decides which of multiple updates can be applied in this revision.
+// Deleted entries are final.
 func ReduceFn(leaves []*pb.EntryUpdate, msgs []*pb.EntryUpdate,
+	emit func(*pb.EntryUpdate), emitErr func(error)) {
+	reduce(false, leaves, msgs, emit, emitErr)
+}
+
+// NewReduceFn returns the ReduceFn of a directory whose recovery policy is
+// allowRecovery.
+func NewReduceFn(allowRecovery bool) func(leaves, msgs []*pb.EntryUpdate,
+	emit func(*pb.EntryUpdate), emitErr func(error)) {
+	return func(leaves, msgs []*pb.EntryUpdate, emit func(*pb.EntryUpdate), emitErr func(error)) {
+		reduce(allowRecovery, leaves, msgs, emit, emitErr)
+	}
+}
+
+func reduce(allowRecovery bool, leaves []*pb.EntryUpdate, msgs []*pb.EntryUpdate,
 	emit func(*pb.EntryUpdate), emitErr func(error)) {
 	if got := len(leaves); got > 1 {
 		emitErr(status.Errorf(codes.Internal, "got %v map leaves, want 0 or 1", got))
@@ -87,7 +105,7 @@ func ReduceFn(leaves []*pb.EntryUpdate, msgs []*pb.EntryUpdate,
 	// Filter for mutations that are valid.
 	newEntries := make([]*pb.EntryUpdate, 0, len(msgs))
 	for i, msg := range msgs {
-		newValue, err := MutateFn(oldValue, msg.GetMutation())
+		newValue, err := Mutate(oldValue, msg.GetMutation(), allowRecovery)
 		if err != nil {
 			s := status.Convert(err)
 			emitErr(status.Errorf(s.Code(), "entry: ReduceFn(msg %d/%d): %v", i+1, len(msgs), s.Message()))
@@ -111,8 +129,19 @@ func ReduceFn(leaves []*pb.EntryUpdate, msgs []*pb.EntryUpdate,
 }
 
 // MutateFn verifies that newSignedEntry is a valid mutation for oldSignedEntry and returns the
-// application of newSignedEntry to oldSignedEntry.
+// application of newSignedEntry to oldSignedEntry. Deleted entries are final.
 func MutateFn(oldSignedEntry, newSignedEntry *pb.SignedEntry) (*pb.SignedEntry, error) {
+	return Mutate(oldSignedEntry, newSignedEntry, false)
+}
+
+// Mutate verifies that newSignedEntry is a valid mutation for oldSignedEntry
+// in a directory whose recovery policy is allowRecovery, and returns the
+// application of newSignedEntry to oldSignedEntry.
+// A tombstone is accepted if it is signed by the authorized keys of
+// oldSignedEntry. A deleted oldSignedEntry cannot be modified unless
+// allowRecovery is set, in which case it may be replaced by an entry that is
+// signed by the authorized keys of the tombstone.
+func Mutate(oldSignedEntry, newSignedEntry *pb.SignedEntry, allowRecovery bool) (*pb.SignedEntry, error) {
 	if err := IsValidEntry(newSignedEntry); err != nil {
 		return nil, err
 	}
@@ -133,6 +162,12 @@ func MutateFn(oldSignedEntry, newSignedEntry *pb.SignedEntry) (*pb.SignedEntry,
 		return nil, mutator.ErrReplay
 	}
 
+	// See docs/deleted-entries.md for the recovery policy.
+	if oldEntry.GetDeleted() && (!allowRecovery || newEntry.GetDeleted()) {
+		glog.Warningf("mutation modifies a deleted entry")
+		return nil, mutator.ErrDeleted
+	}
+
 	// Verify check-set semantics if Previous has been explicitly set.
 	if want := newEntry.GetPrevious(); want != nil {
 		prevEntryHash := sha256.Sum256(oldSignedEntry.GetEntry())
@@ -143,6 +178,9 @@ func MutateFn(oldSignedEntry, newSignedEntry *pb.SignedEntry) (*pb.SignedEntry,
 	}
 
 	if oldSignedEntry == nil {
+		if newEntry.GetDeleted() {
+			return nil, mutator.ErrNothingToDelete
+		}
 		// Skip verificaion checks if there is no previous oldSignedEntry.
 		return newSignedEntry, nil
 	}
@@ -23,6 +23,8 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/google/tink/go/keyset"
 	"github.com/google/tink/go/tink"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/testutil"
@@ -73,6 +75,15 @@ func TestCheckMutation(t *testing.T) {
 		Entry: mustMarshal(t, entryData2),
 	}
 
+	tombstone := &tpb.Entry{
+		Index:            key,
+		AuthorizedKeyset: keysetBytes(testPubKey1),
+		Deleted:          true,
+	}
+	signedTombstone := &tpb.SignedEntry{
+		Entry: mustMarshal(t, tombstone),
+	}
+
 	for _, tc := range []struct {
 		desc     string
 		mutation *Mutation
@@ -178,6 +189,57 @@ func TestCheckMutation(t *testing.T) {
 			},
 			signers: testutil.SignKeysetsFromPEMs(testPrivKey1, testPrivKey2),
 		},
+		{
+			desc: "Tombstone, working case",
+			old:  signedEntryData1,
+			mutation: &Mutation{
+				entry: &tpb.Entry{
+					Index:            key,
+					Previous:         hashEntry1[:],
+					AuthorizedKeyset: keysetBytes(testPubKey1),
+					Deleted:          true,
+				},
+			},
+			signers: testutil.SignKeysetsFromPEMs(testPrivKey1),
+		},
+		{
+			desc: "Tombstone, missing previous signature",
+			old:  signedEntryData1,
+			mutation: &Mutation{
+				entry: &tpb.Entry{
+					Index:            key,
+					AuthorizedKeyset: keysetBytes(testPubKey2),
+					Deleted:          true,
+				},
+			},
+			signers: testutil.SignKeysetsFromPEMs(testPrivKey2),
+			err:     mutator.ErrUnauthorized,
+		},
+		{
+			desc: "Tombstone, no previous entry",
+			mutation: &Mutation{
+				entry: &tpb.Entry{
+					Index:            key,
+					AuthorizedKeyset: keysetBytes(testPubKey1),
+					Deleted:          true,
+				},
+			},
+			signers: testutil.SignKeysetsFromPEMs(testPrivKey1),
+			err:     mutator.ErrNothingToDelete,
+		},
+		{
+			desc: "Mutation after tombstone",
+			old:  signedTombstone,
+			mutation: &Mutation{
+				entry: &tpb.Entry{
+					Index:            key,
+					Commitment:       []byte{2},
+					AuthorizedKeyset: keysetBytes(testPubKey1),
+				},
+			},
+			signers: testutil.SignKeysetsFromPEMs(testPrivKey1),
+			err:     mutator.ErrDeleted,
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			m, err := tc.mutation.sign(tc.signers)
@@ -190,3 +252,123 @@ func TestCheckMutation(t *testing.T) {
 		})
 	}
 }
+
+func TestTombstoneCommitment(t *testing.T) {
+	m := &Mutation{
+		entry: &tpb.Entry{
+			Commitment:       []byte{1},
+			AuthorizedKeyset: keysetBytes(testPubKey1),
+			Deleted:          true,
+		},
+	}
+	signed, err := m.sign(testutil.SignKeysetsFromPEMs(testPrivKey1))
+	if err != nil {
+		t.Fatalf("mutation.sign(): %v", err)
+	}
+	if got, want := status.Code(IsValidEntry(signed)), codes.InvalidArgument; got != want {
+		t.Errorf("IsValidEntry(): %v, want %v", got, want)
+	}
+}
+
+func TestRecoveryPolicy(t *testing.T) {
+	key := []byte{0}
+	signedTombstone := &tpb.SignedEntry{
+		Entry: mustMarshal(t, &tpb.Entry{
+			Index:            key,
+			AuthorizedKeyset: keysetBytes(testPubKey1),
+			Deleted:          true,
+		}),
+	}
+	hashTombstone := sha256.Sum256(signedTombstone.Entry)
+
+	for _, tc := range []struct {
+		desc          string
+		entry         *tpb.Entry
+		signers       []tink.Signer
+		allowRecovery bool
+		err           error
+	}{
+		{
+			desc: "tombstones are final",
+			entry: &tpb.Entry{
+				Index:            key,
+				Commitment:       []byte{2},
+				AuthorizedKeyset: keysetBytes(testPubKey2),
+			},
+			signers: testutil.SignKeysetsFromPEMs(testPrivKey1, testPrivKey2),
+			err:     mutator.ErrDeleted,
+		},
+		{
+			desc: "recovery signed by the tombstone's keys",
+			entry: &tpb.Entry{
+				Index:            key,
+				Commitment:       []byte{2},
+				Previous:         hashTombstone[:],
+				AuthorizedKeyset: keysetBytes(testPubKey2),
+			},
+			signers:       testutil.SignKeysetsFromPEMs(testPrivKey1, testPrivKey2),
+			allowRecovery: true,
+		},
+		{
+			desc: "recovery not signed by the tombstone's keys",
+			entry: &tpb.Entry{
+				Index:            key,
+				Commitment:       []byte{2},
+				AuthorizedKeyset: keysetBytes(testPubKey2),
+			},
+			signers:       testutil.SignKeysetsFromPEMs(testPrivKey2),
+			allowRecovery: true,
+			err:           mutator.ErrUnauthorized,
+		},
+		{
+			desc: "recovery with the wrong previous hash",
+			entry: &tpb.Entry{
+				Index:            key,
+				Commitment:       []byte{2},
+				Previous:         []byte{1},
+				AuthorizedKeyset: keysetBytes(testPubKey1),
+			},
+			signers:       testutil.SignKeysetsFromPEMs(testPrivKey1),
+			allowRecovery: true,
+			err:           mutator.ErrPreviousHash,
+		},
+		{
+			desc: "second tombstone",
+			entry: &tpb.Entry{
+				Index:            key,
+				Previous:         hashTombstone[:],
+				AuthorizedKeyset: keysetBytes(testPubKey2),
+				Deleted:          true,
+			},
+			signers:       testutil.SignKeysetsFromPEMs(testPrivKey1, testPrivKey2),
+			allowRecovery: true,
+			err:           mutator.ErrDeleted,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			m, err := (&Mutation{entry: tc.entry}).sign(tc.signers)
+			if err != nil {
+				t.Fatalf("mutation.sign(): %v", err)
+			}
+			if _, got := Mutate(signedTombstone, m, tc.allowRecovery); got != tc.err {
+				t.Errorf("Mutate(allowRecovery: %v): %v, want %v", tc.allowRecovery, got, tc.err)
+			}
+
+			// The directory's ReduceFn applies the same policy.
+			var emitted []*tpb.EntryUpdate
+			var errs []error
+			NewReduceFn(tc.allowRecovery)(
+				[]*tpb.EntryUpdate{{Mutation: signedTombstone}},
+				[]*tpb.EntryUpdate{{Mutation: m}},
+				func(u *tpb.EntryUpdate) { emitted = append(emitted, u) },
+				func(err error) { errs = append(errs, err) })
+			wantEmitted := 0
+			if tc.err == nil {
+				wantEmitted = 1
+			}
+			if got := len(emitted); got != wantEmitted {
+				t.Errorf("ReduceFn emitted %v entries, want %v (errors: %v)", got, wantEmitted, errs)
+			}
+		})
+	}
+}
@@ -46,6 +46,13 @@ var (
 	// ErrUnauthorized occurs when the mutation has not been signed by a key in the
 	// previous entry.
 	ErrUnauthorized = status.Errorf(codes.PermissionDenied, "mutation: unauthorized")
+	// ErrDeleted occurs when a mutation attempts to modify an entry that has
+	// been replaced by a tombstone, and the directory's recovery policy does
+	// not allow it.
+	ErrDeleted = status.Errorf(codes.FailedPrecondition, "mutation: entry has been deleted")
+	// ErrNothingToDelete occurs when a tombstone is written to an index that
+	// has no previous entry.
+	ErrNothingToDelete = status.Errorf(codes.FailedPrecondition, "mutation: no entry to delete")
 )
 
 // VerifyMutationFn verifies that a mutation is internally consistent.
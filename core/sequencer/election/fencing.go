@@ -0,0 +1,44 @@
+// Copyright 2020 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package election
+
+import "context"
+
+// Fencer is implemented by elections whose masterships have fencing tokens.
+// Each new mastership of a resource has a higher token than the previous one.
+type Fencer interface {
+	// Token returns the fencing token of the current mastership, or 0 if
+	// this instance is not the master.
+	Token() int64
+}
+
+type tokenKey struct{}
+
+// WithToken returns a copy of ctx that carries the fencing token of a
+// mastership. Storage that supports fencing rejects writes made with ctx
+// once it has seen a higher token for the same resource.
+func WithToken(ctx context.Context, token int64) context.Context {
+	if token == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// Token returns the fencing token carried by ctx, or 0 if it carries none.
+// Writes without a token are not fenced.
+func Token(ctx context.Context) int64 {
+	token, _ := ctx.Value(tokenKey{}).(int64)
+	return token
+}
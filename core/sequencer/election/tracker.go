@@ -41,6 +41,12 @@ func createMetrics(mf monitoring.MetricFactory) {
 type mastership struct {
 	e        election2.Election
 	acquired time.Time
+	token    int64 // The fencing token of the mastership, or 0.
+}
+
+// watcher runs the election for a resource until it is canceled.
+type watcher struct {
+	cancel context.CancelFunc
 }
 
 // Tracker tracks mastership of a collection of resources.
@@ -49,7 +55,7 @@ type Tracker struct {
 	maxHold     time.Duration
 	master      map[string]mastership
 	masterMu    sync.RWMutex
-	watching    map[string]bool
+	watching    map[string]*watcher
 	watchingMu  sync.RWMutex
 	newResource chan string
 }
@@ -61,7 +67,7 @@ func NewTracker(factory election2.Factory, maxHold time.Duration, metricFactory
 		factory:     factory,
 		maxHold:     maxHold,
 		master:      make(map[string]mastership),
-		watching:    make(map[string]bool),
+		watching:    make(map[string]*watcher),
 		newResource: make(chan string),
 	}
 }
@@ -72,19 +78,50 @@ func (mt *Tracker) AddResource(res string) {
 	mt.newResource <- res
 }
 
+// RemoveResource stops the mastership tracker from running elections for res
+// and gives up mastership of res if it is held. Removing a resource that is
+// not being tracked is a no-op.
+func (mt *Tracker) RemoveResource(res string) {
+	mt.watchingMu.Lock()
+	w, ok := mt.watching[res]
+	delete(mt.watching, res)
+	mt.watchingMu.Unlock()
+	if !ok {
+		return
+	}
+	glog.Infof("election: no longer tracking %q", res)
+	w.cancel()
+	mt.setNotMaster(res)
+}
+
+// Resources returns the resources currently being tracked.
+func (mt *Tracker) Resources() []string {
+	mt.watchingMu.RLock()
+	defer mt.watchingMu.RUnlock()
+	ret := make([]string, 0, len(mt.watching))
+	for res := range mt.watching {
+		ret = append(ret, res)
+	}
+	return ret
+}
+
 // Run starts new watchers for new resources.
 func (mt *Tracker) Run(ctx context.Context) {
 	for {
 		select {
 		case res := <-mt.newResource:
-			if mt.setWatching(res) {
-				go func() {
-					defer mt.setNotWatching(res)
-					if err := mt.watchResource(ctx, res); err != nil {
-						glog.Errorf("watchResource(%v): %v", res, err)
-					}
-				}()
+			wctx, cancel := context.WithCancel(ctx)
+			w := &watcher{cancel: cancel}
+			if !mt.setWatching(res, w) {
+				cancel()
+				continue
 			}
+			go func() {
+				defer mt.setNotWatching(res, w)
+				if err := mt.watchResource(wctx, res); err != nil && wctx.Err() == nil {
+					glog.Errorf("watchResource(%v): %v", res, err)
+				}
+			}()
 		case <-ctx.Done():
 			glog.Infof("election: Run() exiting due to expired context: %v", ctx.Err())
 			return
@@ -129,7 +166,11 @@ func (mt *Tracker) watchOnce(ctx context.Context, e election2.Election, res stri
 		return err
 	}
 
-	mt.setMaster(res, mastership{e: e, acquired: time.Now()})
+	var token int64
+	if f, ok := e.(Fencer); ok {
+		token = f.Token()
+	}
+	mt.setMaster(res, mastership{e: e, acquired: time.Now(), token: token})
 	defer mt.setNotMaster(res)
 
 	<-mastershipCtx.Done()
@@ -141,22 +182,26 @@ func (mt *Tracker) watchOnce(ctx context.Context, e election2.Election, res stri
 	return nil
 }
 
-// setWatching sets mt.watching[res] to true.
-// Returns true if it set watching to true.
-func (mt *Tracker) setWatching(res string) bool {
+// setWatching sets mt.watching[res] to w if res is not already being watched.
+// Returns true if it set mt.watching[res].
+func (mt *Tracker) setWatching(res string, w *watcher) bool {
 	mt.watchingMu.Lock()
 	defer mt.watchingMu.Unlock()
-	if !mt.watching[res] {
-		mt.watching[res] = true
+	if _, ok := mt.watching[res]; !ok {
+		mt.watching[res] = w
 		return true
 	}
 	return false
 }
 
-func (mt *Tracker) setNotWatching(res string) {
+// setNotWatching clears mt.watching[res] if it is still w. A removed resource
+// may have been added again with a new watcher before w exits.
+func (mt *Tracker) setNotWatching(res string, w *watcher) {
 	mt.watchingMu.Lock()
 	defer mt.watchingMu.Unlock()
-	delete(mt.watching, res)
+	if mt.watching[res] == w {
+		delete(mt.watching, res)
+	}
 }
 
 func (mt *Tracker) setMaster(res string, m mastership) {
@@ -174,6 +219,7 @@ func (mt *Tracker) setNotMaster(res string) {
 }
 
 // Masterships returns a map of resources to mastership contexts.
+// Contexts of masterships with fencing tokens carry them; see WithToken.
 // Callers should cancel ctx when they no longer are actively using mastership.
 // If Masterships is not called periodically, we may retain masterships for longer than maxHold.
 func (mt *Tracker) Masterships(ctx context.Context) (map[string]context.Context, error) {
@@ -195,7 +241,7 @@ func (mt *Tracker) Masterships(ctx context.Context) (map[string]context.Context,
 		if err != nil {
 			return nil, err
 		}
-		mastershipCtx[res] = cctx
+		mastershipCtx[res] = WithToken(cctx, m.token)
 	}
 	return mastershipCtx, nil
 }
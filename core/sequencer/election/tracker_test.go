@@ -21,6 +21,7 @@ import (
 
 	"github.com/google/keytransparency/internal/forcemaster"
 	"github.com/google/trillian/monitoring/prometheus"
+	"github.com/google/trillian/util/election2"
 )
 
 // Ensure that mastership continues to work after resignTime.
@@ -71,3 +72,81 @@ func TestForceMaster(t *testing.T) {
 		t.Errorf("Masterships returned %v, want 0", got)
 	}
 }
+
+func TestRemoveResource(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	res := "removed resource"
+
+	mt := NewTracker(forcemaster.Factory{}, time.Hour, prometheus.MetricFactory{})
+	go mt.Run(ctx)
+	for _, tc := range []struct {
+		desc    string
+		update  func()
+		wantRes int
+	}{
+		{desc: "add", update: func() { mt.AddResource(res) }, wantRes: 1},
+		{desc: "remove", update: func() { mt.RemoveResource(res) }, wantRes: 0},
+		{desc: "remove again", update: func() { mt.RemoveResource(res) }, wantRes: 0},
+		{desc: "add again", update: func() { mt.AddResource(res) }, wantRes: 1},
+	} {
+		tc.update()
+		time.Sleep(10 * time.Millisecond) // Wait for watchers to start or exit.
+
+		if got := len(mt.Resources()); got != tc.wantRes {
+			t.Errorf("%v: Resources() returned %v resources, want %v", tc.desc, got, tc.wantRes)
+		}
+		m, err := mt.Masterships(ctx)
+		if err != nil {
+			t.Fatalf("%v: Masterships(): %v", tc.desc, err)
+		}
+		if got := len(m); got != tc.wantRes {
+			t.Errorf("%v: Masterships returned %v, want %v", tc.desc, got, tc.wantRes)
+		}
+	}
+}
+
+// fencedElection is always the master, with a fixed fencing token.
+type fencedElection struct {
+	*forcemaster.Election
+	token int64
+}
+
+func (e fencedElection) Token() int64 { return e.token }
+
+type fencedFactory struct{ token int64 }
+
+func (f fencedFactory) NewElection(ctx context.Context, resourceID string) (election2.Election, error) {
+	return fencedElection{Election: forcemaster.NewElection(resourceID), token: f.token}, nil
+}
+
+func TestMastershipTokens(t *testing.T) {
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+	res := "fenced resource"
+	for _, tc := range []struct {
+		desc    string
+		factory election2.Factory
+		want    int64
+	}{
+		{desc: "fenced", factory: fencedFactory{token: 7}, want: 7},
+		{desc: "unfenced", factory: forcemaster.Factory{}, want: 0},
+	} {
+		mt := NewTracker(tc.factory, time.Hour, prometheus.MetricFactory{})
+		go mt.Run(ctx)
+		mt.AddResource(res)
+		time.Sleep(10 * time.Millisecond) // Wait to acquire mastership.
+
+		m, err := mt.Masterships(ctx)
+		if err != nil {
+			t.Fatalf("%v: Masterships(): %v", tc.desc, err)
+		}
+		mctx, ok := m[res]
+		if !ok {
+			t.Fatalf("%v: Masterships() is missing %v", tc.desc, res)
+		}
+		if got := Token(mctx); got != tc.want {
+			t.Errorf("%v: Token(mastership) = %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequencer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/mutator"
+	"github.com/google/keytransparency/core/mutator/entry"
+	"github.com/google/keytransparency/core/sequencer/runner"
+
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	tpb "github.com/google/trillian"
+	tclient "github.com/google/trillian/client"
+)
+
+const (
+	// replayBatchSize is the number of mutations replay reads from a log at once.
+	replayBatchSize = 1000
+	// replayLeafChunkSize is the number of map leaves replay reads at once.
+	replayLeafChunkSize = 1000
+)
+
+// ReplayResult compares a recomputed map revision with the one in the map.
+type ReplayResult struct {
+	DirectoryID string
+	Revision    int64
+	// Mutations is the number of mutations in the batch of the revision.
+	Mutations int64
+	// Indexes is the number of distinct indexes the mutations touch.
+	Indexes int
+	// InvalidMutations is the number of mutations the reduce step rejected.
+	InvalidMutations int
+	// MetadataMatches is false if the batch definition differs from the
+	// metadata of the map root of the revision.
+	MetadataMatches bool
+	// FirstDivergentIndex is the lowest touched index whose recomputed leaf
+	// differs from the one in the map, or nil if they all match.
+	FirstDivergentIndex []byte
+	// Recomputed and Stored are the leaves at FirstDivergentIndex.
+	Recomputed, Stored *tpb.MapLeaf
+	// RootHash is the recomputed root hash and MapRootHash is the root hash
+	// in the map.
+	RootHash, MapRootHash []byte
+}
+
+// Matches returns true if the recomputed revision is identical to the map's.
+func (r *ReplayResult) Matches() bool {
+	return r.MetadataMatches && r.FirstDivergentIndex == nil && bytes.Equal(r.RootHash, r.MapRootHash)
+}
+
+// ReplayRevision independently recomputes revision rev of dir from its batch
+// definition and the mutations in logs, and compares the new leaves and root
+// hash with those in the map. The leaves of the previous revision are
+// verified against its map root, and their inclusion proofs are used to
+// recompute the root hash. Returns OutOfRange if the mutations of rev have
+// been pruned, since the revision can no longer be recomputed.
+func ReplayRevision(ctx context.Context, dir *directory.Directory, batcher Batcher, logs LogsReader,
+	pruner Pruner, tmap tpb.TrillianMapClient, rev int64) (*ReplayResult, error) {
+	if rev < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "revision %v has no mutations", rev)
+	}
+	if err := checkNotPruned(ctx, pruner, dir.DirectoryID, rev); err != nil {
+		return nil, err
+	}
+	c, err := tclient.NewMapClientFromTree(tmap, dir.Map)
+	if err != nil {
+		return nil, err
+	}
+	mapClient := &MapClient{MapClient: c}
+	_, prevRoot, err := mapClient.GetAndVerifyMapRootByRevision(ctx, rev-1)
+	if err != nil {
+		return nil, err
+	}
+	_, mapRoot, err := mapClient.GetAndVerifyMapRootByRevision(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := batcher.ReadBatch(ctx, dir.DirectoryID, rev)
+	if err != nil {
+		return nil, status.Errorf(status.Code(err), "ReadBatch(%v, %v): %v", dir.DirectoryID, rev, err)
+	}
+	var mapMeta spb.MapMetadata
+	if err := proto.Unmarshal(mapRoot.Metadata, &mapMeta); err != nil {
+		return nil, status.Errorf(codes.DataLoss, "map root metadata: %v", err)
+	}
+
+	// Read the previous leaves with inclusion proofs, and keep the proof
+	// nodes as the untouched subtrees of the new revision.
+	var mu sync.Mutex
+	prevLeaves := make(map[string]*tpb.MapLeaf)
+	nodes := make(map[string][]byte)
+	readLeaves := func(ctx context.Context, indexes [][]byte) ([]*tpb.MapLeaf, error) {
+		leaves, err := readVerifiedLeaves(ctx, c, prevRoot, rev-1, indexes, func(l *tpb.MapLeafInclusion) {
+			mu.Lock()
+			defer mu.Unlock()
+			prevLeaves[string(l.Leaf.Index)] = l.Leaf
+			addProofNodes(nodes, c.Hasher.BitLen(), l)
+		})
+		return leaves, err
+	}
+	readFn := func(ctx context.Context, slice *spb.MapMetadata_SourceSlice, directoryID string,
+		chunkSize int32, emit func(*mutator.LogMessage)) error {
+		return readLogMessages(ctx, logs, slice, directoryID, chunkSize, emit)
+	}
+	var invalid int
+	emitErr := func(err error) {
+		glog.V(1).Infof("ReplayRevision(%v, %v): %v", dir.DirectoryID, rev, err)
+		mu.Lock()
+		defer mu.Unlock()
+		invalid++
+	}
+	computed, err := computeRevision(ctx, &runner.Pipeline{DirectoryID: dir.DirectoryID, Workers: 1},
+		meta, readFn, readLeaves, entry.NewReduceFn(allowRecovery(&mapMeta, dir.AllowRecovery)),
+		replayBatchSize, replayLeafChunkSize, emitErr)
+	if err != nil {
+		return nil, err
+	}
+	// The revision may have been pruned while its mutations were read, in
+	// which case the read may be missing some of them.
+	if err := checkNotPruned(ctx, pruner, dir.DirectoryID, rev); err != nil {
+		return nil, err
+	}
+
+	rootHash, err := recomputeRoot(c, prevRoot, computed.leaves, nodes)
+	if err != nil {
+		return nil, err
+	}
+	ret := &ReplayResult{
+		DirectoryID:      dir.DirectoryID,
+		Revision:         rev,
+		Mutations:        computed.mutations,
+		Indexes:          computed.indexes,
+		InvalidMutations: invalid,
+		MetadataMatches:  proto.Equal(meta, &mapMeta),
+		RootHash:         rootHash,
+		MapRootHash:      mapRoot.RootHash,
+	}
+
+	// Touched indexes keep their previous value unless a mutation changed it.
+	want := make(map[string]*tpb.MapLeaf, len(prevLeaves))
+	for i, l := range prevLeaves {
+		want[i] = l
+	}
+	for _, l := range computed.leaves {
+		want[string(l.Index)] = l
+	}
+	indexes := make([][]byte, 0, len(want))
+	for _, l := range want {
+		indexes = append(indexes, l.Index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return bytes.Compare(indexes[i], indexes[j]) < 0 })
+	for start := 0; start < len(indexes); start += replayLeafChunkSize {
+		end := start + replayLeafChunkSize
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		stored, err := readVerifiedLeaves(ctx, c, mapRoot, rev, indexes[start:end], nil)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(stored, func(i, j int) bool { return bytes.Compare(stored[i].Index, stored[j].Index) < 0 })
+		for _, got := range stored {
+			if w := want[string(got.Index)]; !sameLeaf(w, got) {
+				ret.FirstDivergentIndex = got.Index
+				ret.Recomputed = w
+				ret.Stored = got
+				return ret, nil
+			}
+		}
+	}
+	return ret, nil
+}
+
+// checkNotPruned returns an OutOfRange error if the mutations of revision rev
+// of directoryID have been pruned.
+func checkNotPruned(ctx context.Context, pruner Pruner, directoryID string, rev int64) error {
+	pruned, err := pruner.PrunedRevision(ctx, directoryID)
+	if st := status.Convert(err); st.Code() != codes.OK {
+		return status.Errorf(st.Code(), "PrunedRevision(%v): %v", directoryID, st.Message())
+	}
+	if rev <= pruned {
+		return status.Errorf(codes.OutOfRange,
+			"mutations of revision %v have been deleted, the earliest revision with mutations is %v",
+			rev, pruned+1)
+	}
+	return nil
+}
+
+// readVerifiedLeaves returns the leaves at indexes in revision rev of the map
+// and verifies them against root. emit, if set, is called with each proof.
+func readVerifiedLeaves(ctx context.Context, c *tclient.MapClient, root *types.MapRootV1, rev int64,
+	indexes [][]byte, emit func(*tpb.MapLeafInclusion)) ([]*tpb.MapLeaf, error) {
+	resp, err := c.Conn.GetLeavesByRevision(ctx, &tpb.GetMapLeavesByRevisionRequest{
+		MapId:    c.MapID,
+		Index:    indexes,
+		Revision: rev,
+	})
+	if err != nil {
+		s := status.Convert(err)
+		return nil, status.Errorf(s.Code(), "GetLeavesByRevision(%v): %v", rev, s.Message())
+	}
+	if got, want := len(resp.MapLeafInclusion), len(indexes); got != want {
+		return nil, status.Errorf(codes.Internal, "GetLeavesByRevision(%v) len: %v, want %v", rev, got, want)
+	}
+	leaves := make([]*tpb.MapLeaf, 0, len(resp.MapLeafInclusion))
+	for _, l := range resp.MapLeafInclusion {
+		if err := c.VerifyMapLeafInclusionHash(root.RootHash, l); err != nil {
+			return nil, status.Errorf(codes.DataLoss, "revision %v index %x: %v", rev, l.GetLeaf().GetIndex(), err)
+		}
+		if emit != nil {
+			emit(l)
+		}
+		leaves = append(leaves, l.Leaf)
+	}
+	return leaves, nil
+}
+
+// nodeKey addresses a node at depth whose leftmost leaf is offset.
+func nodeKey(depth int, offset *big.Int) string {
+	return fmt.Sprintf("%d/%x", depth, offset.Bytes())
+}
+
+// addProofNodes adds the non-empty siblings in the inclusion proof of l to nodes.
+func addProofNodes(nodes map[string][]byte, bitLen int, l *tpb.MapLeafInclusion) {
+	index := new(big.Int).SetBytes(l.Leaf.Index)
+	for height, sibling := range l.Inclusion {
+		if len(sibling) == 0 {
+			continue // Empty subtrees are recomputed by HStar2.
+		}
+		offset := new(big.Int).Rsh(index, uint(height))
+		offset.SetBit(offset, 0, offset.Bit(0)^1)
+		offset.Lsh(offset, uint(height))
+		nodes[nodeKey(bitLen-height, offset)] = sibling
+	}
+}
+
+// recomputeRoot returns the root hash of prevRoot with leaves set. nodes must
+// hold the siblings of every leaf in prevRoot.
+func recomputeRoot(c *tclient.MapClient, prevRoot *types.MapRootV1, leaves []*tpb.MapLeaf,
+	nodes map[string][]byte) ([]byte, error) {
+	if len(leaves) == 0 {
+		return prevRoot.RootHash, nil
+	}
+	values := make([]*merkle.HStar2LeafHash, 0, len(leaves))
+	for _, l := range leaves {
+		values = append(values, &merkle.HStar2LeafHash{
+			Index:    new(big.Int).SetBytes(l.Index),
+			LeafHash: c.Hasher.HashLeaf(c.MapID, l.Index, l.LeafValue),
+		})
+	}
+	get := func(depth int, index *big.Int) ([]byte, error) {
+		return nodes[nodeKey(depth, index)], nil
+	}
+	hs2 := merkle.NewHStar2(c.MapID, c.Hasher)
+	return hs2.HStar2Nodes(nil, c.Hasher.BitLen(), values, get, nil)
+}
+
+// sameLeaf returns true if a and b have the same value and extra data.
+func sameLeaf(a, b *tpb.MapLeaf) bool {
+	return bytes.Equal(a.GetLeafValue(), b.GetLeafValue()) &&
+		bytes.Equal(a.GetExtraData(), b.GetExtraData())
+}
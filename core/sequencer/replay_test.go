@@ -0,0 +1,327 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequencer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/tink/go/keyset"
+	"github.com/google/tink/go/signature"
+	"github.com/google/tink/go/tink"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/crypto/keys/der"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/testonly/integration"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/fake"
+	"github.com/google/keytransparency/core/mutator/entry"
+	"github.com/google/keytransparency/impl/memory/trillianstorage"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	kmemory "github.com/google/keytransparency/impl/memory"
+	tpb "github.com/google/trillian"
+	_ "github.com/google/trillian/merkle/coniks" // Register hasher
+)
+
+func newMapEnv(ctx context.Context, t *testing.T) (*integration.MapEnv, *tpb.Tree) {
+	t.Helper()
+	admin := memory.NewAdminStorage(memory.NewTreeStorage())
+	env, err := integration.NewMapEnvWithRegistry(extension.Registry{
+		AdminStorage:  admin,
+		LogStorage:    trillianstorage.NewLogStorage(admin),
+		MapStorage:    trillianstorage.NewMapStorage(),
+		QuotaManager:  quota.Noop(),
+		MetricFactory: monitoring.InertMetricFactory{},
+		NewKeyProto: func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
+			return der.NewProtoFromSpec(spec)
+		},
+	}, true /* singleTX */)
+	if err != nil {
+		t.Fatalf("NewMapEnvWithRegistry(): %v", err)
+	}
+	tree, err := client.CreateAndInitTree(ctx, &tpb.CreateTreeRequest{
+		Tree: &tpb.Tree{
+			TreeState:          tpb.TreeState_ACTIVE,
+			TreeType:           tpb.TreeType_MAP,
+			HashStrategy:       tpb.HashStrategy_CONIKS_SHA256,
+			SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+			HashAlgorithm:      sigpb.DigitallySigned_SHA256,
+			MaxRootDuration:    ptypes.DurationProto(0),
+		},
+		KeySpec: &keyspb.Specification{
+			Params: &keyspb.Specification_EcdsaParams{
+				EcdsaParams: &keyspb.Specification_ECDSA{Curve: keyspb.Specification_ECDSA_P256},
+			},
+		},
+	}, env.Admin, env.Map, nil)
+	if err != nil {
+		env.Close()
+		t.Fatalf("CreateAndInitTree(): %v", err)
+	}
+	return env, tree
+}
+
+func userIndex(userID string) []byte {
+	h := sha256.Sum256([]byte(userID))
+	return h[:]
+}
+
+// newUpdate returns a valid mutation creating the account of userID.
+func newUpdate(t *testing.T, userID string) *pb.EntryUpdate {
+	t.Helper()
+	handle, err := keyset.NewHandle(signature.ECDSAP256KeyTemplate())
+	if err != nil {
+		t.Fatalf("keyset.NewHandle(): %v", err)
+	}
+	signer, err := signature.NewSigner(handle)
+	if err != nil {
+		t.Fatalf("NewSigner(): %v", err)
+	}
+	pub, err := handle.Public()
+	if err != nil {
+		t.Fatalf("Public(): %v", err)
+	}
+	m := entry.NewMutation(userIndex(userID), directoryID, userID, "")
+	if err := m.SetCommitment([]byte(userID)); err != nil {
+		t.Fatalf("SetCommitment(): %v", err)
+	}
+	if err := m.ReplaceAuthorizedKeys(pub); err != nil {
+		t.Fatalf("ReplaceAuthorizedKeys(): %v", err)
+	}
+	update, err := m.SerializeAndSign([]tink.Signer{signer})
+	if err != nil {
+		t.Fatalf("SerializeAndSign(): %v", err)
+	}
+	return update
+}
+
+func TestReplayRevision(t *testing.T) {
+	ctx := context.Background()
+	env, tree := newMapEnv(ctx, t)
+	defer env.Close()
+	dir := &directory.Directory{DirectoryID: directoryID, Map: tree}
+
+	directories := fake.NewDirectoryStorage()
+	if err := directories.Write(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	logs := kmemory.NewMutations()
+	if err := logs.AddLogs(ctx, directoryID, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		directories:      directories,
+		trillian:         &fakeTrillianFactory{twrite: &MapWriteClient{MapID: tree.TreeId, twrite: env.Write}},
+		batcher:          logs,
+		logs:             logs,
+		BatchSize:        10,
+		MapLeafChunkSize: 1,
+	}
+
+	// Each revision creates the accounts of users. The last revision is
+	// written to the map directly, with a value the mutations don't produce.
+	revisions := [][]string{
+		1: {"alice", "bob"},
+		2: {"carol"},
+		3: {"dave"},
+	}
+	tampered := int64(3)
+	for rev := int64(1); rev < int64(len(revisions)); rev++ {
+		var low, high = zero, zero
+		for i, userID := range revisions[rev] {
+			wm, err := logs.Send(ctx, directoryID, 0, newUpdate(t, userID))
+			if err != nil {
+				t.Fatalf("Send(): %v", err)
+			}
+			if i == 0 {
+				low = wm
+			}
+			high = wm.Add(1)
+		}
+		meta := &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{newSource(0, low, high)}}
+		if err := logs.WriteBatchSources(ctx, directoryID, rev, meta); err != nil {
+			t.Fatalf("WriteBatchSources(): %v", err)
+		}
+		if rev != tampered {
+			if _, err := s.ApplyRevision(ctx, &spb.ApplyRevisionRequest{DirectoryId: directoryID, Revision: rev}); err != nil {
+				t.Fatalf("ApplyRevision(%v): %v", rev, err)
+			}
+			continue
+		}
+		serializedMeta, err := proto.Marshal(meta)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := env.Write.WriteLeaves(ctx, &tpb.WriteMapLeavesRequest{
+			MapId:          tree.TreeId,
+			Leaves:         []*tpb.MapLeaf{{Index: userIndex("dave"), LeafValue: []byte("bogus")}},
+			ExpectRevision: rev,
+			Metadata:       serializedMeta,
+		}); err != nil {
+			t.Fatalf("WriteLeaves(): %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		rev           int64
+		wantMutations int64
+		wantDivergent []byte
+	}{
+		{rev: 1, wantMutations: 2},
+		{rev: 2, wantMutations: 1},
+		{rev: 3, wantMutations: 1, wantDivergent: userIndex("dave")},
+	} {
+		got, err := ReplayRevision(ctx, dir, logs, logs, logs, env.Map, tc.rev)
+		if err != nil {
+			t.Fatalf("ReplayRevision(%v): %v", tc.rev, err)
+		}
+		if got.Mutations != tc.wantMutations {
+			t.Errorf("ReplayRevision(%v).Mutations: %v, want %v", tc.rev, got.Mutations, tc.wantMutations)
+		}
+		if !got.MetadataMatches {
+			t.Errorf("ReplayRevision(%v).MetadataMatches: false, want true", tc.rev)
+		}
+		if !bytes.Equal(got.FirstDivergentIndex, tc.wantDivergent) {
+			t.Errorf("ReplayRevision(%v).FirstDivergentIndex: %x, want %x", tc.rev, got.FirstDivergentIndex, tc.wantDivergent)
+		}
+		if want := tc.wantDivergent == nil; got.Matches() != want {
+			t.Errorf("ReplayRevision(%v).Matches(): %v, want %v (root %x, map root %x)",
+				tc.rev, got.Matches(), want, got.RootHash, got.MapRootHash)
+		}
+	}
+
+	// Pruned revisions cannot be replayed.
+	meta, err := logs.ReadBatch(ctx, directoryID, 1)
+	if err != nil {
+		t.Fatalf("ReadBatch(): %v", err)
+	}
+	if _, err := logs.PruneRevision(ctx, directoryID, 1, meta); err != nil {
+		t.Fatalf("PruneRevision(): %v", err)
+	}
+	if _, err := ReplayRevision(ctx, dir, logs, logs, logs, env.Map, 1); status.Code(err) != codes.OutOfRange {
+		t.Errorf("ReplayRevision(pruned): %v, want %v", err, codes.OutOfRange)
+	}
+	if _, err := ReplayRevision(ctx, dir, logs, logs, logs, env.Map, 2); err != nil {
+		t.Errorf("ReplayRevision(2) after pruning 1: %v", err)
+	}
+}
+
+func TestReplayRecordedRecoveryPolicy(t *testing.T) {
+	ctx := context.Background()
+	env, tree := newMapEnv(ctx, t)
+	defer env.Close()
+	// The directory no longer allows recovery, but did when its revisions
+	// were defined.
+	dir := &directory.Directory{DirectoryID: directoryID, Map: tree}
+	directories := fake.NewDirectoryStorage()
+	if err := directories.Write(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	logs := kmemory.NewMutations()
+	if err := logs.AddLogs(ctx, directoryID, 0); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		directories:      directories,
+		trillian:         &fakeTrillianFactory{twrite: &MapWriteClient{MapID: tree.TreeId, twrite: env.Write}},
+		batcher:          logs,
+		logs:             logs,
+		BatchSize:        10,
+		MapLeafChunkSize: 1,
+	}
+
+	handle, err := keyset.NewHandle(signature.ECDSAP256KeyTemplate())
+	if err != nil {
+		t.Fatalf("keyset.NewHandle(): %v", err)
+	}
+	signer, err := signature.NewSigner(handle)
+	if err != nil {
+		t.Fatalf("NewSigner(): %v", err)
+	}
+	pub, err := handle.Public()
+	if err != nil {
+		t.Fatalf("Public(): %v", err)
+	}
+	// Revision 1 creates alice, revision 2 deletes her, and revision 3
+	// recovers her.
+	var prev *pb.EntryUpdate
+	for rev := int64(1); rev <= 3; rev++ {
+		m := entry.NewMutation(userIndex("alice"), directoryID, "alice", "")
+		m.AllowRecovery = true
+		if prev != nil {
+			leaf, err := entry.ToLeafValue(prev.GetMutation())
+			if err != nil {
+				t.Fatalf("ToLeafValue(): %v", err)
+			}
+			if err := m.SetPrevious(uint64(rev-1), leaf, true); err != nil {
+				t.Fatalf("SetPrevious(): %v", err)
+			}
+		}
+		switch rev {
+		case 1:
+			if err := m.ReplaceAuthorizedKeys(pub); err != nil {
+				t.Fatalf("ReplaceAuthorizedKeys(): %v", err)
+			}
+			fallthrough
+		case 3:
+			if err := m.SetCommitment([]byte("key")); err != nil {
+				t.Fatalf("SetCommitment(): %v", err)
+			}
+		case 2:
+			m.SetDeleted()
+		}
+		if prev, err = m.SerializeAndSign([]tink.Signer{signer}); err != nil {
+			t.Fatalf("SerializeAndSign(%v): %v", rev, err)
+		}
+		wm, err := logs.Send(ctx, directoryID, 0, prev)
+		if err != nil {
+			t.Fatalf("Send(): %v", err)
+		}
+		meta := &spb.MapMetadata{
+			Sources:        []*spb.MapMetadata_SourceSlice{newSource(0, wm, wm.Add(1))},
+			RecoveryPolicy: spb.MapMetadata_ALLOW_RECOVERY,
+		}
+		if err := logs.WriteBatchSources(ctx, directoryID, rev, meta); err != nil {
+			t.Fatalf("WriteBatchSources(): %v", err)
+		}
+		if _, err := s.ApplyRevision(ctx, &spb.ApplyRevisionRequest{DirectoryId: directoryID, Revision: rev}); err != nil {
+			t.Fatalf("ApplyRevision(%v): %v", rev, err)
+		}
+	}
+
+	got, err := ReplayRevision(ctx, dir, logs, logs, logs, env.Map, 3)
+	if err != nil {
+		t.Fatalf("ReplayRevision(3): %v", err)
+	}
+	if got.InvalidMutations != 0 || !got.Matches() {
+		t.Errorf("ReplayRevision(3): %v invalid mutations, matches %v, want 0 and true",
+			got.InvalidMutations, got.Matches())
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// Budget limits the number of workers that run at once across all the
+// pipelines sharing it, and the number that any one directory may use, so
+// that a large directory cannot starve the others.
+// A nil *Budget places no limits.
+type Budget struct {
+	total        chan struct{}
+	perDirectory int
+	mu           sync.Mutex
+	directories  map[string]chan struct{}
+}
+
+// NewBudget returns a Budget of total workers, of which at most perDirectory
+// may be used by one directory. A perDirectory of 0 or less, or greater than
+// total, lets a directory use the whole budget.
+func NewBudget(total, perDirectory int) *Budget {
+	if total < 1 {
+		total = 1
+	}
+	if perDirectory <= 0 || perDirectory > total {
+		perDirectory = total
+	}
+	return &Budget{
+		total:        make(chan struct{}, total),
+		perDirectory: perDirectory,
+		directories:  make(map[string]chan struct{}),
+	}
+}
+
+// PerDirectory returns the number of workers one directory may use.
+func (b *Budget) PerDirectory() int {
+	if b == nil {
+		return 0
+	}
+	return b.perDirectory
+}
+
+func (b *Budget) directory(directoryID string) chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, ok := b.directories[directoryID]
+	if !ok {
+		d = make(chan struct{}, b.perDirectory)
+		b.directories[directoryID] = d
+	}
+	return d
+}
+
+// Acquire blocks until directoryID may start another worker, or ctx is done.
+// Every successful Acquire must be followed by a Release.
+func (b *Budget) Acquire(ctx context.Context, directoryID string) error {
+	if b == nil {
+		return nil
+	}
+	// Take the directory's slot first, so that a directory waiting on its
+	// own limit does not hold slots the other directories could use.
+	d := b.directory(directoryID)
+	select {
+	case d <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case b.total <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		<-d
+		return ctx.Err()
+	}
+}
+
+// Release returns a worker acquired by directoryID to the budget.
+func (b *Budget) Release(directoryID string) {
+	if b == nil {
+		return
+	}
+	<-b.total
+	<-b.directory(directoryID)
+}
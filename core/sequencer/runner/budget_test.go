@@ -0,0 +1,59 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(2, 1)
+	ctx := context.Background()
+	if err := b.Acquire(ctx, "a"); err != nil {
+		t.Fatalf("Acquire(a): %v", err)
+	}
+
+	// Directory a has used its share of the budget, but b may still run.
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Acquire(cctx, "a"); err != context.DeadlineExceeded {
+		t.Errorf("Acquire(a): %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := b.Acquire(ctx, "b"); err != nil {
+		t.Fatalf("Acquire(b): %v", err)
+	}
+
+	// The budget is exhausted.
+	cctx, cancel = context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.Acquire(cctx, "c"); err != context.DeadlineExceeded {
+		t.Errorf("Acquire(c): %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	b.Release("a")
+	if err := b.Acquire(ctx, "c"); err != nil {
+		t.Errorf("Acquire(c) after Release(a): %v", err)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	if err := b.Acquire(context.Background(), "a"); err != nil {
+		t.Errorf("Acquire(): %v", err)
+	}
+	b.Release("a")
+}
@@ -17,13 +17,10 @@ package runner
 
 import (
 	"context"
-	"fmt"
-	"runtime"
 	"sync"
 
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/mutator/entry"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
@@ -48,106 +45,98 @@ func wrapErrFn(emitErr func(error), msg string) func(error) {
 	}
 }
 
+// Joiner groups MapLeaves and IndexedValues by index as they arrive.
+// It is safe for concurrent use.
+type Joiner struct {
+	incFn IncMetricFn
+	mu    sync.Mutex
+	rows  map[string]*Joined
+}
+
+// NewJoiner returns an empty Joiner.
+func NewJoiner(incFn IncMetricFn) *Joiner {
+	return &Joiner{incFn: incFn, rows: make(map[string]*Joined)}
+}
+
+// row returns the row for index, creating it if needed. j.mu must be held.
+func (j *Joiner) row(index []byte) *Joined {
+	r, ok := j.rows[string(index)]
+	if !ok {
+		r = &Joined{Index: index}
+		j.rows[string(index)] = r
+	}
+	return r
+}
+
+// AddLeaf adds a map leaf to the row for its index.
+func (j *Joiner) AddLeaf(l *entry.IndexedValue) {
+	j.incFn("Join1")
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	r := j.row(l.Index)
+	r.Values1 = append(r.Values1, l.Value)
+}
+
+// AddMsg adds a mutation to the row for its index, and reports whether it is
+// the first mutation for that index.
+func (j *Joiner) AddMsg(m *entry.IndexedValue) bool {
+	j.incFn("Join2")
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	r := j.row(m.Index)
+	r.Values2 = append(r.Values2, m.Value)
+	return len(r.Values2) == 1
+}
+
+// Len returns the number of distinct indexes seen so far.
+func (j *Joiner) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.rows)
+}
+
+// Rows emits every row added so far.
+func (j *Joiner) Rows() <-chan *Joined {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	ret := make(chan *Joined, len(j.rows))
+	defer close(ret)
+	for _, r := range j.rows {
+		ret <- r
+	}
+	return ret
+}
+
 // Join pairs up MapLeaves and IndexedValue by index.
 func Join(leaves []*entry.IndexedValue, msgs []*entry.IndexedValue, incFn IncMetricFn) <-chan *Joined {
-	joinMap := make(map[string]*Joined)
+	j := NewJoiner(incFn)
 	for _, l := range leaves {
-		incFn("Join1")
-		row, ok := joinMap[string(l.Index)]
-		if !ok {
-			row = &Joined{Index: l.Index}
-		}
-		row.Values1 = append(row.Values1, l.Value)
-		joinMap[string(l.Index)] = row
+		j.AddLeaf(l)
 	}
 	for _, m := range msgs {
-		incFn("Join2")
-		row, ok := joinMap[string(m.Index)]
-		if !ok {
-			row = &Joined{Index: m.Index}
-		}
-		row.Values2 = append(row.Values2, m.Value)
-		joinMap[string(m.Index)] = row
+		j.AddMsg(m)
 	}
-
-	ret := make(chan *Joined)
-	go func() {
-		defer close(ret)
-		for _, r := range joinMap {
-			ret <- r
-		}
-	}()
-	return ret
+	return j.Rows()
 }
 
 // MapMetaFn emits a source slice for every map slice.
 type MapMetaFn func(meta *spb.MapMetadata, emit func(*spb.MapMetadata_SourceSlice))
 
-// DoMapMetaFn runs MapMetaFn on meta and collects the outputs.
-func DoMapMetaFn(fn MapMetaFn, meta *spb.MapMetadata, incFn IncMetricFn) []*spb.MapMetadata_SourceSlice {
-	outs := make([]*spb.MapMetadata_SourceSlice, 0, len(meta.GetSources()))
-	incFn("MapMetaFn")
-	fn(meta, func(slice *spb.MapMetadata_SourceSlice) { outs = append(outs, slice) })
-	return outs
-}
-
 // ReadSliceFn emits the log messages referenced by slice.
 type ReadSliceFn func(ctx context.Context, slice *spb.MapMetadata_SourceSlice,
 	directoryID string, chunkSize int32,
 	emit func(*mutator.LogMessage)) error
 
-// DoReadFn runs ReadSliceFn on every source slice and collects the outputs.
-func DoReadFn(ctx context.Context, fn ReadSliceFn, slices []*spb.MapMetadata_SourceSlice,
-	directoryID string, chunkSize int32, incFn IncMetricFn) ([]*mutator.LogMessage, error) {
-	outs := make([]*mutator.LogMessage, 0, len(slices))
-	for _, s := range slices {
-		incFn("ReadSliceFn")
-		if err := fn(ctx, s, directoryID, chunkSize,
-			func(msg *mutator.LogMessage) { outs = append(outs, msg) },
-		); err != nil {
-			return nil, err
-		}
-	}
-	return outs, nil
-}
-
 // MapLogItemFn takes a log item and emits 0 or more KV<index, mutations> pairs.
 type MapLogItemFn func(logItem *mutator.LogMessage,
 	emit func(index []byte, mutation *pb.EntryUpdate), emitErr func(error))
 
-// DoMapLogItemsFn runs the MapLogItemsFn on each element of msgs.
-func DoMapLogItemsFn(fn MapLogItemFn, msgs []*mutator.LogMessage,
-	emitErr func(error), incFn IncMetricFn) []*entry.IndexedValue {
-	outs := make([]*entry.IndexedValue, 0, len(msgs))
-	for _, m := range msgs {
-		incFn("MapLogItemFn")
-		fn(m,
-			func(index []byte, value *pb.EntryUpdate) {
-				outs = append(outs, &entry.IndexedValue{Index: index, Value: value})
-			},
-			wrapErrFn(emitErr, "mapLogItemFn"),
-		)
-	}
-	return outs
-}
+// ReadMapLeavesFn returns the map leaves at indexes.
+type ReadMapLeavesFn func(ctx context.Context, indexes [][]byte) ([]*tpb.MapLeaf, error)
 
 // MapMapLeafFn converts an update into an IndexedValue.
 type MapMapLeafFn func(*tpb.MapLeaf) (*entry.IndexedValue, error)
 
-// DoMapMapLeafFn runs MapMapLeafFn on each MapLeaf.
-func DoMapMapLeafFn(fn MapMapLeafFn, leaves []*tpb.MapLeaf, incFn IncMetricFn) ([]*entry.IndexedValue, error) {
-	outs := make([]*entry.IndexedValue, 0, len(leaves))
-	for _, m := range leaves {
-		incFn("MapMapLeafFn")
-		out, err := fn(m)
-		if err != nil {
-			return nil, err
-		}
-		outs = append(outs, out)
-	}
-	return outs, nil
-}
-
 // ReduceMutationFn takes all the mutations for an index and an auxiliary input
 // of existing mapleaf(s) and emits a new value for the index.
 // ReduceMutationFn must be  idempotent, commutative, and associative.  i.e.
@@ -155,48 +144,3 @@ func DoMapMapLeafFn(fn MapMapLeafFn, leaves []*tpb.MapLeaf, incFn IncMetricFn) (
 // and it must be safe to run multiple times.
 type ReduceMutationFn func(msgs []*pb.EntryUpdate, leaves []*pb.EntryUpdate,
 	emit func(*pb.EntryUpdate), emitErr func(error))
-
-// DoReduceFn takes the set of mutations and applies them to given leaves.
-// Returns a channel of key value pairs that should be written to the map.
-func DoReduceFn(reduceFn ReduceMutationFn, joined <-chan *Joined, emitErr func(error),
-	incFn IncMetricFn) <-chan *entry.IndexedValue {
-	ret := make(chan *entry.IndexedValue)
-	go func() {
-		defer close(ret)
-		var wg sync.WaitGroup
-		defer wg.Wait()
-		// TODO(gbelvin): Configurable number of workers.
-		for w := 0; w < runtime.NumCPU(); w++ {
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				for j := range joined {
-					incFn("ReduceFn")
-					reduceFn(j.Values1, j.Values2,
-						func(e *pb.EntryUpdate) {
-							ret <- &entry.IndexedValue{Index: j.Index, Value: e}
-						},
-						wrapErrFn(emitErr, fmt.Sprintf("reduceFn on index %x", j.Index)),
-					)
-				}
-			}()
-		}
-	}()
-	return ret
-}
-
-// DoMarshalIndexedValues executes Marshal on each IndexedValue
-// If marshal fails, it will emit an error and continue with a subset of ivs.
-func DoMarshalIndexedValues(ivs <-chan *entry.IndexedValue, emitErr func(error), incFn IncMetricFn) []*tpb.MapLeaf {
-	ret := make([]*tpb.MapLeaf, 0, len(ivs))
-	for iv := range ivs {
-		incFn("MarshalIndexedValue")
-		mapLeaf, err := iv.Marshal()
-		if err != nil {
-			emitErr(status.Errorf(codes.Internal, "MarshalIndexedValue(): %v", err))
-			continue
-		}
-		ret = append(ret, mapLeaf)
-	}
-	return ret
-}
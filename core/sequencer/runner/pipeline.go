@@ -0,0 +1,245 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/google/keytransparency/core/mutator"
+	"github.com/google/keytransparency/core/mutator/entry"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	tpb "github.com/google/trillian"
+)
+
+// Metrics receives measurements from the stages of a Pipeline.
+// Nil functions are ignored.
+type Metrics struct {
+	// Inc counts the elements processed by each stage.
+	Inc IncMetricFn
+	// Latency records the time spent in each stage, in seconds.
+	Latency func(stage string, seconds float64)
+	// QueueDepth records the number of elements waiting at the input of
+	// each stage.
+	QueueDepth func(stage string, depth int)
+}
+
+func (m Metrics) inc(stage string) {
+	if m.Inc != nil {
+		m.Inc(stage)
+	}
+}
+
+// since records the time elapsed since start against stage.
+func (m Metrics) since(stage string, start time.Time) {
+	if m.Latency != nil {
+		m.Latency(stage, time.Since(start).Seconds())
+	}
+}
+
+func (m Metrics) queueDepth(stage string, depth int) {
+	if m.QueueDepth != nil {
+		m.QueueDepth(stage, depth)
+	}
+}
+
+// Pipeline runs the stages of the mapper pipeline for one directory.
+// Stages that consume channels run as elements arrive, so they can be chained
+// in separate goroutines.
+type Pipeline struct {
+	DirectoryID string
+	// Workers is the number of goroutines running the reduce stage.
+	// Defaults to runtime.NumCPU().
+	Workers int
+	// Budget, if set, is shared with the pipelines of other directories and
+	// bounds the reduce workers that run at once.
+	Budget *Budget
+	Metrics
+}
+
+// NewJoiner returns a Joiner reporting to the pipeline's metrics.
+func (p *Pipeline) NewJoiner() *Joiner {
+	return NewJoiner(p.inc)
+}
+
+// MapMeta runs MapMetaFn on meta and collects the outputs.
+func (p *Pipeline) MapMeta(fn MapMetaFn, meta *spb.MapMetadata) []*spb.MapMetadata_SourceSlice {
+	outs := make([]*spb.MapMetadata_SourceSlice, 0, len(meta.GetSources()))
+	p.inc("MapMetaFn")
+	fn(meta, func(slice *spb.MapMetadata_SourceSlice) { outs = append(outs, slice) })
+	return outs
+}
+
+// Read runs ReadSliceFn on every source slice concurrently and sends the
+// outputs to out as they are read. The capacity of out bounds the number of
+// messages held in memory. Read does not close out. Once ctx is done,
+// messages are dropped rather than sent.
+func (p *Pipeline) Read(ctx context.Context, fn ReadSliceFn, slices []*spb.MapMetadata_SourceSlice,
+	chunkSize int32, out chan<- *mutator.LogMessage) error {
+	g, gctx := errgroup.WithContext(ctx)
+	for _, s := range slices {
+		s := s
+		g.Go(func() error {
+			p.inc("ReadSliceFn")
+			defer p.since("ReadSliceFn", time.Now())
+			return fn(gctx, s, p.DirectoryID, chunkSize, func(msg *mutator.LogMessage) {
+				select {
+				case out <- msg:
+				case <-gctx.Done():
+				}
+			})
+		})
+	}
+	return g.Wait()
+}
+
+// MapLogItems runs MapLogItemFn on each element of msgs as it arrives, until
+// msgs is closed.
+func (p *Pipeline) MapLogItems(fn MapLogItemFn, msgs <-chan *mutator.LogMessage,
+	emit func(*entry.IndexedValue), emitErr func(error)) {
+	defer p.since("MapLogItemFn", time.Now())
+	for m := range msgs {
+		p.queueDepth("MapLogItemFn", len(msgs))
+		p.inc("MapLogItemFn")
+		fn(m,
+			func(index []byte, value *pb.EntryUpdate) {
+				emit(&entry.IndexedValue{Index: index, Value: value})
+			},
+			wrapErrFn(emitErr, "mapLogItemFn"),
+		)
+	}
+}
+
+// ReadMapLeaves runs ReadMapLeavesFn on the indexes received from indexes in
+// chunks of at most chunkSize, and emits each chunk of leaves as soon as it
+// has been read. A chunkSize of 0 or less reads all the indexes at once.
+func (p *Pipeline) ReadMapLeaves(ctx context.Context, fn ReadMapLeavesFn, indexes <-chan []byte,
+	chunkSize int, emit func([]*tpb.MapLeaf) error) error {
+	var chunk [][]byte
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		p.inc("ReadMapLeavesFn")
+		start := time.Now()
+		leaves, err := fn(ctx, chunk)
+		p.since("ReadMapLeavesFn", start)
+		if err != nil {
+			return err
+		}
+		chunk = nil
+		return emit(leaves)
+	}
+	for i := range indexes {
+		p.queueDepth("ReadMapLeavesFn", len(indexes))
+		chunk = append(chunk, i)
+		if chunkSize > 0 && len(chunk) >= chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// MapMapLeaves runs MapMapLeafFn on each MapLeaf.
+func (p *Pipeline) MapMapLeaves(fn MapMapLeafFn, leaves []*tpb.MapLeaf) ([]*entry.IndexedValue, error) {
+	outs := make([]*entry.IndexedValue, 0, len(leaves))
+	for _, m := range leaves {
+		p.inc("MapMapLeafFn")
+		out, err := fn(m)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+	return outs, nil
+}
+
+// Reduce takes the set of mutations and applies them to given leaves.
+// Returns a channel of key value pairs that should be written to the map.
+// Each row is reduced by one of p.Workers goroutines once the budget allows.
+// If ctx is done before every row has been reduced, the remaining rows are
+// dropped and ctx.Err() is emitted. joined is always drained, so that its
+// producer is never left blocked.
+func (p *Pipeline) Reduce(ctx context.Context, reduceFn ReduceMutationFn, joined <-chan *Joined,
+	emitErr func(error)) <-chan *entry.IndexedValue {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	ret := make(chan *entry.IndexedValue, workers)
+	go func() {
+		defer close(ret)
+		defer p.since("ReduceFn", time.Now())
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var err error
+				for j := range joined {
+					p.queueDepth("ReduceFn", len(joined))
+					if err != nil {
+						continue // Drop the rows left over.
+					}
+					if err = ctx.Err(); err == nil {
+						err = p.Budget.Acquire(ctx, p.DirectoryID)
+					}
+					if err != nil {
+						emitErr(status.FromContextError(err).Err())
+						continue
+					}
+					p.inc("ReduceFn")
+					reduceFn(j.Values1, j.Values2,
+						func(e *pb.EntryUpdate) {
+							ret <- &entry.IndexedValue{Index: j.Index, Value: e}
+						},
+						wrapErrFn(emitErr, fmt.Sprintf("reduceFn on index %x", j.Index)),
+					)
+					p.Budget.Release(p.DirectoryID)
+				}
+			}()
+		}
+	}()
+	return ret
+}
+
+// Marshal executes Marshal on each IndexedValue
+// If marshal fails, it will emit an error and continue with a subset of ivs.
+func (p *Pipeline) Marshal(ivs <-chan *entry.IndexedValue, emitErr func(error)) []*tpb.MapLeaf {
+	defer p.since("MarshalIndexedValue", time.Now())
+	ret := make([]*tpb.MapLeaf, 0, len(ivs))
+	for iv := range ivs {
+		p.queueDepth("MarshalIndexedValue", len(ivs))
+		p.inc("MarshalIndexedValue")
+		mapLeaf, err := iv.Marshal()
+		if err != nil {
+			emitErr(status.Errorf(codes.Internal, "MarshalIndexedValue(): %v", err))
+			continue
+		}
+		ret = append(ret, mapLeaf)
+	}
+	return ret
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/keytransparency/core/mutator"
+	"github.com/google/keytransparency/core/mutator/entry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+	tpb "github.com/google/trillian"
+)
+
+func TestReadMapLeaves(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		desc       string
+		indexes    int
+		chunkSize  int
+		wantChunks []int
+	}{
+		{desc: "empty", indexes: 0, chunkSize: 2},
+		{desc: "exact", indexes: 4, chunkSize: 2, wantChunks: []int{2, 2}},
+		{desc: "remainder", indexes: 5, chunkSize: 2, wantChunks: []int{2, 2, 1}},
+		{desc: "unchunked", indexes: 5, chunkSize: 0, wantChunks: []int{5}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			indexes := make(chan []byte, tc.indexes)
+			for i := 0; i < tc.indexes; i++ {
+				indexes <- []byte{byte(i)}
+			}
+			close(indexes)
+
+			var reads []int
+			var got [][]byte
+			fn := func(_ context.Context, idx [][]byte) ([]*tpb.MapLeaf, error) {
+				reads = append(reads, len(idx))
+				leaves := make([]*tpb.MapLeaf, 0, len(idx))
+				for _, i := range idx {
+					leaves = append(leaves, &tpb.MapLeaf{Index: i})
+				}
+				return leaves, nil
+			}
+			p := &Pipeline{DirectoryID: "dir"}
+			if err := p.ReadMapLeaves(ctx, fn, indexes, tc.chunkSize,
+				func(leaves []*tpb.MapLeaf) error {
+					for _, l := range leaves {
+						got = append(got, l.Index)
+					}
+					return nil
+				}); err != nil {
+				t.Fatalf("ReadMapLeaves(): %v", err)
+			}
+			if !cmp.Equal(reads, tc.wantChunks) {
+				t.Errorf("chunks: %v, want %v", reads, tc.wantChunks)
+			}
+			if len(got) != tc.indexes {
+				t.Errorf("emitted %v leaves, want %v", len(got), tc.indexes)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	ctx := context.Background()
+	slices := []*spb.MapMetadata_SourceSlice{{LogId: 0}, {LogId: 1}, {LogId: 2}}
+	// Each slice emits LogId+1 messages.
+	fn := func(_ context.Context, s *spb.MapMetadata_SourceSlice, _ string, _ int32,
+		emit func(*mutator.LogMessage)) error {
+		for i := int64(0); i <= s.LogId; i++ {
+			emit(&mutator.LogMessage{LogID: s.LogId})
+		}
+		return nil
+	}
+	// An unbuffered channel makes the readers block on the consumer.
+	out := make(chan *mutator.LogMessage)
+	errc := make(chan error, 1)
+	go func() {
+		p := &Pipeline{DirectoryID: "dir"}
+		errc <- p.Read(ctx, fn, slices, 10, out)
+		close(out)
+	}()
+	got := make(map[int64]int)
+	for m := range out {
+		got[m.LogID]++
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if want := map[int64]int{0: 1, 1: 2, 2: 3}; !cmp.Equal(got, want) {
+		t.Errorf("Read(): %v, want %v", got, want)
+	}
+}
+
+func TestReduce(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		desc    string
+		workers int
+		budget  *Budget
+		rows    int
+	}{
+		{desc: "default workers", rows: 10},
+		{desc: "one worker", workers: 1, rows: 10},
+		{desc: "budget", workers: 4, budget: NewBudget(2, 1), rows: 10},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var mu sync.Mutex
+			metrics := make(map[string]int)
+			var running, maxRunning int
+			p := &Pipeline{
+				DirectoryID: "dir",
+				Workers:     tc.workers,
+				Budget:      tc.budget,
+				Metrics: Metrics{
+					Inc: func(label string) {
+						mu.Lock()
+						defer mu.Unlock()
+						metrics[label]++
+					},
+				},
+			}
+			j := p.NewJoiner()
+			for i := 0; i < tc.rows; i++ {
+				j.AddMsg(&entry.IndexedValue{Index: []byte{byte(i)}, Value: &pb.EntryUpdate{}})
+			}
+			reduceFn := func(leaves, msgs []*pb.EntryUpdate, emit func(*pb.EntryUpdate), _ func(error)) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				emit(msgs[0])
+			}
+			var got int
+			for range p.Reduce(ctx, reduceFn, j.Rows(), func(err error) { t.Errorf("emitErr: %v", err) }) {
+				got++
+			}
+			if got != tc.rows {
+				t.Errorf("Reduce() emitted %v values, want %v", got, tc.rows)
+			}
+			if metrics["ReduceFn"] != tc.rows {
+				t.Errorf("ReduceFn count: %v, want %v", metrics["ReduceFn"], tc.rows)
+			}
+			if max := tc.budget.PerDirectory(); max > 0 && maxRunning > max {
+				t.Errorf("%v reduce workers ran at once, want at most %v", maxRunning, max)
+			}
+		})
+	}
+}
+
+func TestReduceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &Pipeline{DirectoryID: "dir", Workers: 2, Budget: NewBudget(1, 1)}
+
+	// An unbuffered producer blocks until every row has been received.
+	const rows = 10
+	joined := make(chan *Joined)
+	produced := make(chan struct{})
+	go func() {
+		defer close(produced)
+		defer close(joined)
+		for i := 0; i < rows; i++ {
+			joined <- &Joined{Index: []byte{byte(i)}, Values2: []*pb.EntryUpdate{{}}}
+		}
+	}()
+	reduceFn := func(_, _ []*pb.EntryUpdate, _ func(*pb.EntryUpdate), _ func(error)) {
+		t.Errorf("reduceFn called after ctx was canceled")
+	}
+	var mu sync.Mutex
+	var errs []error
+	emitErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	var got int
+	for range p.Reduce(ctx, reduceFn, joined, emitErr) {
+		got++
+	}
+	if got != 0 {
+		t.Errorf("Reduce() emitted %v values, want 0", got)
+	}
+	select {
+	case <-produced:
+	case <-time.After(time.Second):
+		t.Fatalf("Reduce() returned without draining joined")
+	}
+	if len(errs) == 0 {
+		t.Errorf("Reduce() emitted no errors, want %v", codes.Canceled)
+	}
+	for _, err := range errs {
+		if got, want := status.Code(err), codes.Canceled; got != want {
+			t.Errorf("Reduce() emitted %v, want %v", err, want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequencer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/internal/backoff"
+
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+// Scheduler runs a sequencing state machine for every directory this
+// sequencer is master for. Each cycle defines a revision, applies it and
+// publishes it, starting each step as soon as the previous one finishes.
+// A directory that is caught up waits Refresh before its next cycle, and a
+// directory whose cycle failed backs off before retrying.
+type Scheduler struct {
+	sequencer *Sequencer
+	// BatchSize is the maximum number of mutations in a revision.
+	BatchSize int32
+	// Refresh is the time a caught up directory waits for new mutations.
+	Refresh time.Duration
+	// Backoff is the policy of directories whose cycles fail. Each
+	// directory backs off independently.
+	Backoff backoff.Backoff
+
+	mu      sync.Mutex
+	running map[string]*directoryState
+}
+
+// NewScheduler returns a Scheduler for the masterships of s.
+func NewScheduler(s *Sequencer, batchSize int32, refresh time.Duration) *Scheduler {
+	return &Scheduler{
+		sequencer: s,
+		BatchSize: batchSize,
+		Refresh:   refresh,
+		Backoff: backoff.Backoff{
+			Min:    time.Second,
+			Max:    time.Minute,
+			Factor: 2,
+			Jitter: true,
+		},
+		running: make(map[string]*directoryState),
+	}
+}
+
+// directoryState is the state machine of one directory.
+type directoryState struct {
+	mu     sync.Mutex
+	status *spb.DirectoryStatus
+
+	// The fields below are only used by the goroutine running the directory.
+	// unpublished is set while applied revisions may not have been published.
+	unpublished  bool
+	lastEstimate time.Time
+}
+
+func (d *directoryState) setPhase(phase spb.DirectoryStatus_Phase) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Phase = phase
+	d.status.PhaseStart = ptypes.TimestampNow()
+}
+
+// RunSequencer runs the sequencer until ctx is done. It tracks masterships
+// of every directory, adding new directories every dirRefresh, runs the state
+// machine of each mastership and prunes applied mutations every prune.
+func (s *Scheduler) RunSequencer(ctx context.Context, dirRefresh, prune time.Duration) {
+	glog.Infof("Sequencer starting")
+	signer := s.sequencer
+	go signer.TrackMasterships(ctx)
+
+	if err := signer.AddAllDirectories(ctx); err != nil {
+		glog.Errorf("RunSequencer(AddAllDirectories): %v", err)
+	}
+	refreshTicker := time.NewTicker(dirRefresh)
+	defer refreshTicker.Stop()
+	go PeriodicallyRun(ctx, refreshTicker.C, func(ctx context.Context) {
+		if err := signer.AddAllDirectories(ctx); err != nil {
+			glog.Errorf("PeriodicallyRun(AddAllDirectories): %v", err)
+		}
+	})
+
+	pruneTicker := time.NewTicker(prune)
+	defer pruneTicker.Stop()
+	go PeriodicallyRun(ctx, pruneTicker.C, func(ctx context.Context) {
+		if err := signer.PruneRevisionsForAllMasterships(ctx); err != nil {
+			glog.Errorf("PeriodicallyRun(PruneRevisionsForAllMasterships): %v", err)
+		}
+	})
+
+	// Define, apply and publish revisions of each directory we are master for.
+	s.Run(ctx, dirRefresh)
+}
+
+// Run starts the state machine of each directory this sequencer becomes
+// master for, checking masterships every interval, until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.startMasterships(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startMasterships starts the state machines of new masterships.
+func (s *Scheduler) startMasterships(ctx context.Context) {
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	masterships, err := s.sequencer.tracker.Masterships(cctx)
+	if err != nil {
+		glog.Errorf("Masterships(): %v", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for dirID := range masterships {
+		if _, ok := s.running[dirID]; ok {
+			continue
+		}
+		d := &directoryState{
+			status:      &spb.DirectoryStatus{DirectoryId: dirID},
+			unpublished: true,
+		}
+		s.running[dirID] = d
+		go func(dirID string) {
+			s.runDirectory(ctx, dirID, d)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.running, dirID)
+		}(dirID)
+	}
+}
+
+// runDirectory runs the cycles of dirID while this sequencer is its master.
+func (s *Scheduler) runDirectory(ctx context.Context, dirID string, d *directoryState) {
+	glog.Infof("Sequencing directory %v", dirID)
+	defer glog.Infof("Stopped sequencing directory %v", dirID)
+	b := s.Backoff
+	for {
+		mctx, cancel, ok := s.mastership(ctx, dirID)
+		if !ok {
+			return
+		}
+		progress, err := s.cycle(mctx, dirID, d)
+		cancel()
+
+		var wait time.Duration
+		d.mu.Lock()
+		switch {
+		case err != nil:
+			glog.Errorf("Sequencing directory %v: %v", dirID, err)
+			d.status.LastError = err.Error()
+			d.status.LastErrorTime = ptypes.TimestampNow()
+			d.status.ConsecutiveFailures++
+			wait = b.Duration()
+		case progress:
+			d.status.ConsecutiveFailures = 0
+			b.Reset()
+		default:
+			d.status.ConsecutiveFailures = 0
+			b.Reset()
+			wait = s.Refresh
+		}
+		d.mu.Unlock()
+		if err != nil {
+			d.setPhase(spb.DirectoryStatus_BACKING_OFF)
+		} else {
+			d.setPhase(spb.DirectoryStatus_IDLE)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// mastership returns a context that is done when this sequencer loses
+// mastership of dirID, and false if it is not the master.
+func (s *Scheduler) mastership(ctx context.Context, dirID string) (context.Context, context.CancelFunc, bool) {
+	cctx, cancel := context.WithCancel(ctx)
+	masterships, err := s.sequencer.tracker.Masterships(cctx)
+	if err != nil {
+		glog.Errorf("Masterships(): %v", err)
+	}
+	mctx, ok := masterships[dirID]
+	if !ok {
+		cancel()
+		return nil, nil, false
+	}
+	return mctx, cancel, true
+}
+
+// cycle runs one define, apply and publish cycle for dirID, and reports
+// whether it defined a new revision.
+func (s *Scheduler) cycle(ctx context.Context, dirID string, d *directoryState) (bool, error) {
+	client := s.sequencer.sequencerClient
+	if time.Since(d.lastEstimate) >= s.Refresh {
+		d.lastEstimate = time.Now()
+		if _, err := client.EstimateBacklog(ctx, &spb.EstimateBacklogRequest{
+			DirectoryId:       dirID,
+			MaxUnappliedCount: 100000,
+		}); err != nil {
+			glog.Warningf("EstimateBacklog(%v): %v", dirID, err)
+		}
+	}
+
+	d.setPhase(spb.DirectoryStatus_DEFINING)
+	defined, err := client.DefineRevisions(ctx, &spb.DefineRevisionsRequest{
+		DirectoryId:  dirID,
+		MinBatch:     1,
+		MaxBatch:     s.BatchSize,
+		MaxUnapplied: 1,
+		FencingToken: election.Token(ctx),
+	})
+	if err != nil {
+		return false, status.Errorf(status.Code(err), "DefineRevisions(): %v", status.Convert(err).Message())
+	}
+	progress := defined.HighestDefined > defined.HighestApplied
+
+	if progress {
+		d.setPhase(spb.DirectoryStatus_APPLYING)
+		if _, err := client.ApplyRevisions(ctx, &spb.ApplyRevisionsRequest{
+			DirectoryId:  dirID,
+			FencingToken: election.Token(ctx),
+		}); err != nil {
+			return false, status.Errorf(status.Code(err), "ApplyRevisions(): %v", status.Convert(err).Message())
+		}
+		d.mu.Lock()
+		d.status.HighestApplied = defined.HighestDefined
+		d.mu.Unlock()
+		d.unpublished = true
+	}
+
+	if d.unpublished {
+		d.setPhase(spb.DirectoryStatus_PUBLISHING)
+		if _, err := client.PublishRevisions(ctx, &spb.PublishRevisionsRequest{DirectoryId: dirID}); err != nil {
+			return false, status.Errorf(status.Code(err), "PublishRevisions(): %v", status.Convert(err).Message())
+		}
+		d.unpublished = false
+	}
+	return progress, nil
+}
+
+// Status returns the status of dirID, or of every directory being sequenced
+// if dirID is empty.
+func (s *Scheduler) Status(dirID string) ([]*spb.DirectoryStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]*spb.DirectoryStatus, 0, len(s.running))
+	for id, d := range s.running {
+		if dirID != "" && id != dirID {
+			continue
+		}
+		d.mu.Lock()
+		ret = append(ret, proto.Clone(d.status).(*spb.DirectoryStatus))
+		d.mu.Unlock()
+	}
+	if dirID != "" && len(ret) == 0 {
+		return nil, status.Errorf(codes.NotFound, "directory %v is not being sequenced by this server", dirID)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].DirectoryId < ret[j].DirectoryId })
+	return ret, nil
+}
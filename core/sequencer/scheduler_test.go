@@ -0,0 +1,139 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sequencer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/internal/backoff"
+	"github.com/google/keytransparency/internal/forcemaster"
+
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+// fakeSequencerClient has backlog revisions worth of mutations to sequence.
+type fakeSequencerClient struct {
+	spb.KeyTransparencySequencerClient
+	mu         sync.Mutex
+	backlog    int64
+	defined    int64
+	applied    int64
+	published  int64
+	defineErrs int
+}
+
+func (f *fakeSequencerClient) EstimateBacklog(ctx context.Context, in *spb.EstimateBacklogRequest,
+	opts ...grpc.CallOption) (*spb.EstimateBacklogResponse, error) {
+	return &spb.EstimateBacklogResponse{DirectoryId: in.DirectoryId}, nil
+}
+
+func (f *fakeSequencerClient) DefineRevisions(ctx context.Context, in *spb.DefineRevisionsRequest,
+	opts ...grpc.CallOption) (*spb.DefineRevisionsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.defineErrs > 0 {
+		f.defineErrs--
+		return nil, status.Errorf(codes.Unavailable, "storage is down")
+	}
+	if f.defined == f.applied && f.backlog > 0 {
+		f.backlog--
+		f.defined++
+	}
+	return &spb.DefineRevisionsResponse{HighestApplied: f.applied, HighestDefined: f.defined}, nil
+}
+
+func (f *fakeSequencerClient) ApplyRevisions(ctx context.Context, in *spb.ApplyRevisionsRequest,
+	opts ...grpc.CallOption) (*empty.Empty, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.applied = f.defined
+	return &empty.Empty{}, nil
+}
+
+func (f *fakeSequencerClient) PublishRevisions(ctx context.Context, in *spb.PublishRevisionsRequest,
+	opts ...grpc.CallOption) (*spb.PublishRevisionsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published = f.applied
+	return &spb.PublishRevisionsResponse{}, nil
+}
+
+func TestScheduler(t *testing.T) {
+	for _, tc := range []struct {
+		desc       string
+		backlog    int64
+		defineErrs int
+		wantErr    string
+	}{
+		{desc: "chained", backlog: 3},
+		{desc: "backoff", backlog: 1, defineErrs: 2, wantErr: "storage is down"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			client := &fakeSequencerClient{backlog: tc.backlog, defineErrs: tc.defineErrs}
+			tracker := election.NewTracker(forcemaster.Factory{}, time.Hour, monitoring.InertMetricFactory{})
+			go tracker.Run(ctx)
+			tracker.AddResource(directoryID)
+
+			// A long refresh shows that revisions are chained rather than
+			// sequenced once per refresh.
+			s := NewScheduler(New(client, nil, tracker), 10, time.Hour)
+			s.Backoff = backoff.Backoff{Min: time.Millisecond, Max: time.Millisecond, Factor: 1}
+			go s.Run(ctx, 10*time.Millisecond)
+
+			var st *spb.DirectoryStatus
+			for deadline := time.Now().Add(10 * time.Second); ; {
+				if statuses, err := s.Status(directoryID); err == nil {
+					st = statuses[0]
+					if st.HighestApplied == tc.backlog && st.Phase == spb.DirectoryStatus_IDLE {
+						break
+					}
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("Status(): %v, want %v revisions applied", st, tc.backlog)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			client.mu.Lock()
+			published := client.published
+			client.mu.Unlock()
+			if published != tc.backlog {
+				t.Errorf("published %v revisions, want %v", published, tc.backlog)
+			}
+			if got := st.LastError; !strings.Contains(got, tc.wantErr) || (tc.wantErr == "") != (got == "") {
+				t.Errorf("LastError: %q, want %q", got, tc.wantErr)
+			}
+			if st.ConsecutiveFailures != 0 {
+				t.Errorf("ConsecutiveFailures: %v, want 0", st.ConsecutiveFailures)
+			}
+			if _, err := s.Status("unknown"); status.Code(err) != codes.NotFound {
+				t.Errorf("Status(unknown): %v, want %v", err, codes.NotFound)
+			}
+		})
+	}
+}
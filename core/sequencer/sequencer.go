@@ -77,15 +77,23 @@ func (s *Sequencer) TrackMasterships(ctx context.Context) {
 }
 
 // AddAllDirectories adds all directories to the set of resources
-// this sequencer attempts to obtain mastership for.
+// this sequencer attempts to obtain mastership for, and removes directories
+// that have been deleted or garbage collected.
 func (s *Sequencer) AddAllDirectories(ctx context.Context) error {
 	directories, err := s.directories.List(ctx, false /*deleted*/)
 	if err != nil {
 		return fmt.Errorf("admin.List(): %v", err)
 	}
+	active := make(map[string]bool)
 	for _, d := range directories {
+		active[d.DirectoryID] = true
 		s.AddDirectory(d.DirectoryID)
 	}
+	for _, dir := range s.tracker.Resources() {
+		if !active[dir] {
+			s.RemoveDirectory(dir)
+		}
+	}
 	return nil
 }
 
@@ -97,6 +105,15 @@ func (s *Sequencer) AddDirectory(dirIDs ...string) {
 	}
 }
 
+// RemoveDirectory removes dirIDs from the set of resources this sequencer
+// attempts to obtain mastership for.
+func (s *Sequencer) RemoveDirectory(dirIDs ...string) {
+	for _, dir := range dirIDs {
+		knownDirectories.Set(0, dir)
+		s.tracker.RemoveResource(dir)
+	}
+}
+
 // ForAllMasterships runs f once for all directories this server is master for.
 func (s *Sequencer) ForAllMasterships(ctx context.Context, f func(ctx context.Context, dirID string) error) error {
 	cctx, cancel := context.WithCancel(ctx)
@@ -132,6 +149,7 @@ func (s *Sequencer) DefineRevisionsForAllMasterships(ctx context.Context, batchS
 			MinBatch:     1,
 			MaxBatch:     batchSize,
 			MaxUnapplied: 1,
+			FencingToken: election.Token(ctx),
 		}
 		if _, err := s.sequencerClient.DefineRevisions(ctx, req); err != nil {
 			glog.Errorf("DefineRevisions for %v failed: %v", dirID, err)
@@ -146,7 +164,7 @@ func (s *Sequencer) DefineRevisionsForAllMasterships(ctx context.Context, batchS
 // master for.
 func (s *Sequencer) ApplyRevisionsForAllMasterships(ctx context.Context) error {
 	return s.ForAllMasterships(ctx, func(ctx context.Context, dirID string) error {
-		req := &spb.ApplyRevisionsRequest{DirectoryId: dirID}
+		req := &spb.ApplyRevisionsRequest{DirectoryId: dirID, FencingToken: election.Token(ctx)}
 		if _, err := s.sequencerClient.ApplyRevisions(ctx, req); err != nil {
 			glog.Errorf("ApplyRevisions for %v failed: %v", dirID, err)
 			return err
@@ -168,3 +186,16 @@ func (s *Sequencer) PublishLogForAllMasterships(ctx context.Context) error {
 		return nil
 	})
 }
+
+// PruneRevisionsForAllMasterships runs KeyTransparencySequencer.PruneRevisions
+// on all directories this sequencer is currently master for.
+func (s *Sequencer) PruneRevisionsForAllMasterships(ctx context.Context) error {
+	return s.ForAllMasterships(ctx, func(ctx context.Context, dirID string) error {
+		req := &spb.PruneRevisionsRequest{DirectoryId: dirID, FencingToken: election.Token(ctx)}
+		if _, err := s.sequencerClient.PruneRevisions(ctx, req); err != nil {
+			glog.Errorf("PruneRevisions for %v failed: %v", dirID, err)
+			return err
+		}
+		return nil
+	})
+}
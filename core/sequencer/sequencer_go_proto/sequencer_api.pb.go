@@ -13,6 +13,7 @@ import (
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
 	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
@@ -30,9 +31,89 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// RecoveryPolicy is whether deleted entries may be recovered.
+type MapMetadata_RecoveryPolicy int32
+
+const (
+	// RECOVERY_POLICY_UNSPECIFIED revisions were defined before the policy
+	// was recorded, and are applied with the directory's current policy.
+	MapMetadata_RECOVERY_POLICY_UNSPECIFIED MapMetadata_RecoveryPolicy = 0
+	// DENY_RECOVERY rejects mutations of deleted entries.
+	MapMetadata_DENY_RECOVERY MapMetadata_RecoveryPolicy = 1
+	// ALLOW_RECOVERY accepts mutations that recover deleted entries.
+	MapMetadata_ALLOW_RECOVERY MapMetadata_RecoveryPolicy = 2
+)
+
+var MapMetadata_RecoveryPolicy_name = map[int32]string{
+	0: "RECOVERY_POLICY_UNSPECIFIED",
+	1: "DENY_RECOVERY",
+	2: "ALLOW_RECOVERY",
+}
+
+var MapMetadata_RecoveryPolicy_value = map[string]int32{
+	"RECOVERY_POLICY_UNSPECIFIED": 0,
+	"DENY_RECOVERY":               1,
+	"ALLOW_RECOVERY":              2,
+}
+
+func (x MapMetadata_RecoveryPolicy) String() string {
+	return proto.EnumName(MapMetadata_RecoveryPolicy_name, int32(x))
+}
+
+func (MapMetadata_RecoveryPolicy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{0, 0}
+}
+
+// Phase is the step of the sequencing cycle a directory is in.
+type DirectoryStatus_Phase int32
+
+const (
+	DirectoryStatus_PHASE_UNSPECIFIED DirectoryStatus_Phase = 0
+	// IDLE waits for new mutations.
+	DirectoryStatus_IDLE DirectoryStatus_Phase = 1
+	// DEFINING defines a revision from outstanding mutations.
+	DirectoryStatus_DEFINING DirectoryStatus_Phase = 2
+	// APPLYING applies defined revisions to the map.
+	DirectoryStatus_APPLYING DirectoryStatus_Phase = 3
+	// PUBLISHING publishes map roots to the log of map roots.
+	DirectoryStatus_PUBLISHING DirectoryStatus_Phase = 4
+	// BACKING_OFF waits before retrying after an error.
+	DirectoryStatus_BACKING_OFF DirectoryStatus_Phase = 5
+)
+
+var DirectoryStatus_Phase_name = map[int32]string{
+	0: "PHASE_UNSPECIFIED",
+	1: "IDLE",
+	2: "DEFINING",
+	3: "APPLYING",
+	4: "PUBLISHING",
+	5: "BACKING_OFF",
+}
+
+var DirectoryStatus_Phase_value = map[string]int32{
+	"PHASE_UNSPECIFIED": 0,
+	"IDLE":              1,
+	"DEFINING":          2,
+	"APPLYING":          3,
+	"PUBLISHING":        4,
+	"BACKING_OFF":       5,
+}
+
+func (x DirectoryStatus_Phase) String() string {
+	return proto.EnumName(DirectoryStatus_Phase_name, int32(x))
+}
+
+func (DirectoryStatus_Phase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{14, 0}
+}
+
 type MapMetadata struct {
 	// sources is a list of log sources that were used to construct this map revision.
-	Sources              []*MapMetadata_SourceSlice `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	Sources []*MapMetadata_SourceSlice `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	// recovery_policy is the directory's recovery policy when this revision was
+	// defined. The revision is applied and replayed with this policy, even if
+	// the directory's policy has since changed.
+	RecoveryPolicy       MapMetadata_RecoveryPolicy `protobuf:"varint,3,opt,name=recovery_policy,json=recoveryPolicy,proto3,enum=google.keytransparency.sequencer.MapMetadata_RecoveryPolicy" json:"recovery_policy,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
 	XXX_unrecognized     []byte                     `json:"-"`
 	XXX_sizecache        int32                      `json:"-"`
@@ -70,6 +151,13 @@ func (m *MapMetadata) GetSources() []*MapMetadata_SourceSlice {
 	return nil
 }
 
+func (m *MapMetadata) GetRecoveryPolicy() MapMetadata_RecoveryPolicy {
+	if m != nil {
+		return m.RecoveryPolicy
+	}
+	return MapMetadata_RECOVERY_POLICY_UNSPECIFIED
+}
+
 // SourceSlice is the range of inputs that have been included in a map
 // revision.
 type MapMetadata_SourceSlice struct {
@@ -149,7 +237,11 @@ type DefineRevisionsRequest struct {
 	MaxBatch int32 `protobuf:"varint,3,opt,name=max_batch,json=maxBatch,proto3" json:"max_batch,omitempty"`
 	// max_unapplied is the maximum number of revisions that can be defined ahead
 	// of applied revisions.
-	MaxUnapplied         int32    `protobuf:"varint,4,opt,name=max_unapplied,json=maxUnapplied,proto3" json:"max_unapplied,omitempty"`
+	MaxUnapplied int32 `protobuf:"varint,4,opt,name=max_unapplied,json=maxUnapplied,proto3" json:"max_unapplied,omitempty"`
+	// fencing_token is the fencing token of the caller's mastership of the
+	// directory, or 0 if its election has none. Storage rejects writes whose
+	// token is older than one it has seen.
+	FencingToken         int64    `protobuf:"varint,5,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -208,6 +300,13 @@ func (m *DefineRevisionsRequest) GetMaxUnapplied() int32 {
 	return 0
 }
 
+func (m *DefineRevisionsRequest) GetFencingToken() int64 {
+	if m != nil {
+		return m.FencingToken
+	}
+	return 0
+}
+
 // DefineRevisionsResponse contains information about defined/applied revisions.
 type DefineRevisionsResponse struct {
 	// highest_applied is the current map revision, which is also the highest map
@@ -355,7 +454,11 @@ func (m *GetDefinedRevisionsResponse) GetHighestDefined() int64 {
 // ApplyRevisionsRequest triggers applying revisions to the directory's map.
 type ApplyRevisionsRequest struct {
 	// directory_id is the directory to apply revisions for.
-	DirectoryId          string   `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// fencing_token is the fencing token of the caller's mastership of the
+	// directory, or 0 if its election has none. Storage rejects writes whose
+	// token is older than one it has seen.
+	FencingToken         int64    `protobuf:"varint,2,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -393,6 +496,13 @@ func (m *ApplyRevisionsRequest) GetDirectoryId() string {
 	return ""
 }
 
+func (m *ApplyRevisionsRequest) GetFencingToken() int64 {
+	if m != nil {
+		return m.FencingToken
+	}
+	return 0
+}
+
 // ApplyRevisionRequest contains information needed to create a new revision.
 type ApplyRevisionRequest struct {
 	// directory_id is the directory to apply the mutations to.
@@ -694,7 +804,297 @@ func (m *EstimateBacklogResponse) GetUnappliedCount() int32 {
 	return 0
 }
 
+// PruneRevisionsRequest deletes the mutations of revisions that have passed
+// the retention policy of the directory.
+type PruneRevisionsRequest struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// fencing_token is the fencing token of the caller's mastership of the
+	// directory, or 0 if its election has none. Storage rejects writes whose
+	// token is older than one it has seen.
+	FencingToken         int64    `protobuf:"varint,2,opt,name=fencing_token,json=fencingToken,proto3" json:"fencing_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PruneRevisionsRequest) Reset()         { *m = PruneRevisionsRequest{} }
+func (m *PruneRevisionsRequest) String() string { return proto.CompactTextString(m) }
+func (*PruneRevisionsRequest) ProtoMessage()    {}
+func (*PruneRevisionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{12}
+}
+
+func (m *PruneRevisionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PruneRevisionsRequest.Unmarshal(m, b)
+}
+func (m *PruneRevisionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PruneRevisionsRequest.Marshal(b, m, deterministic)
+}
+func (m *PruneRevisionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PruneRevisionsRequest.Merge(m, src)
+}
+func (m *PruneRevisionsRequest) XXX_Size() int {
+	return xxx_messageInfo_PruneRevisionsRequest.Size(m)
+}
+func (m *PruneRevisionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PruneRevisionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PruneRevisionsRequest proto.InternalMessageInfo
+
+func (m *PruneRevisionsRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *PruneRevisionsRequest) GetFencingToken() int64 {
+	if m != nil {
+		return m.FencingToken
+	}
+	return 0
+}
+
+// PruneRevisionsResponse contains metrics about the pruning operation.
+type PruneRevisionsResponse struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// pruned_revision is the highest revision whose mutations have been deleted.
+	PrunedRevision int64 `protobuf:"varint,2,opt,name=pruned_revision,json=prunedRevision,proto3" json:"pruned_revision,omitempty"`
+	// mutations is the number of mutations deleted by this request.
+	Mutations            int64    `protobuf:"varint,3,opt,name=mutations,proto3" json:"mutations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PruneRevisionsResponse) Reset()         { *m = PruneRevisionsResponse{} }
+func (m *PruneRevisionsResponse) String() string { return proto.CompactTextString(m) }
+func (*PruneRevisionsResponse) ProtoMessage()    {}
+func (*PruneRevisionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{13}
+}
+
+func (m *PruneRevisionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PruneRevisionsResponse.Unmarshal(m, b)
+}
+func (m *PruneRevisionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PruneRevisionsResponse.Marshal(b, m, deterministic)
+}
+func (m *PruneRevisionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PruneRevisionsResponse.Merge(m, src)
+}
+func (m *PruneRevisionsResponse) XXX_Size() int {
+	return xxx_messageInfo_PruneRevisionsResponse.Size(m)
+}
+func (m *PruneRevisionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PruneRevisionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PruneRevisionsResponse proto.InternalMessageInfo
+
+func (m *PruneRevisionsResponse) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *PruneRevisionsResponse) GetPrunedRevision() int64 {
+	if m != nil {
+		return m.PrunedRevision
+	}
+	return 0
+}
+
+func (m *PruneRevisionsResponse) GetMutations() int64 {
+	if m != nil {
+		return m.Mutations
+	}
+	return 0
+}
+
+// The KeyTransparency Sequencer API.
+// DirectoryStatus is the sequencing state of a directory this sequencer is
+// master for.
+type DirectoryStatus struct {
+	DirectoryId string `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	// phase is the current phase of the directory.
+	Phase DirectoryStatus_Phase `protobuf:"varint,2,opt,name=phase,proto3,enum=google.keytransparency.sequencer.DirectoryStatus_Phase" json:"phase,omitempty"`
+	// phase_start is when the directory entered phase.
+	PhaseStart *timestamp.Timestamp `protobuf:"bytes,3,opt,name=phase_start,json=phaseStart,proto3" json:"phase_start,omitempty"`
+	// last_error is the most recent error, if any. It is kept after the
+	// directory recovers.
+	LastError string `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	// last_error_time is when last_error happened.
+	LastErrorTime *timestamp.Timestamp `protobuf:"bytes,5,opt,name=last_error_time,json=lastErrorTime,proto3" json:"last_error_time,omitempty"`
+	// consecutive_failures is the number of cycles that have failed since the
+	// last successful one.
+	ConsecutiveFailures int32 `protobuf:"varint,6,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	// highest_applied is the highest map revision applied by this sequencer.
+	HighestApplied       int64    `protobuf:"varint,7,opt,name=highest_applied,json=highestApplied,proto3" json:"highest_applied,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DirectoryStatus) Reset()         { *m = DirectoryStatus{} }
+func (m *DirectoryStatus) String() string { return proto.CompactTextString(m) }
+func (*DirectoryStatus) ProtoMessage()    {}
+func (*DirectoryStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{14}
+}
+
+func (m *DirectoryStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DirectoryStatus.Unmarshal(m, b)
+}
+func (m *DirectoryStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DirectoryStatus.Marshal(b, m, deterministic)
+}
+func (m *DirectoryStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DirectoryStatus.Merge(m, src)
+}
+func (m *DirectoryStatus) XXX_Size() int {
+	return xxx_messageInfo_DirectoryStatus.Size(m)
+}
+func (m *DirectoryStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_DirectoryStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DirectoryStatus proto.InternalMessageInfo
+
+func (m *DirectoryStatus) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+func (m *DirectoryStatus) GetPhase() DirectoryStatus_Phase {
+	if m != nil {
+		return m.Phase
+	}
+	return DirectoryStatus_PHASE_UNSPECIFIED
+}
+
+func (m *DirectoryStatus) GetPhaseStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.PhaseStart
+	}
+	return nil
+}
+
+func (m *DirectoryStatus) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+func (m *DirectoryStatus) GetLastErrorTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastErrorTime
+	}
+	return nil
+}
+
+func (m *DirectoryStatus) GetConsecutiveFailures() int32 {
+	if m != nil {
+		return m.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (m *DirectoryStatus) GetHighestApplied() int64 {
+	if m != nil {
+		return m.HighestApplied
+	}
+	return 0
+}
+
+type GetSequencingStatusRequest struct {
+	// directory_id restricts the response to one directory. If empty, all the
+	// directories this sequencer is master for are returned.
+	DirectoryId          string   `protobuf:"bytes,1,opt,name=directory_id,json=directoryId,proto3" json:"directory_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencingStatusRequest) Reset()         { *m = GetSequencingStatusRequest{} }
+func (m *GetSequencingStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSequencingStatusRequest) ProtoMessage()    {}
+func (*GetSequencingStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{15}
+}
+
+func (m *GetSequencingStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencingStatusRequest.Unmarshal(m, b)
+}
+func (m *GetSequencingStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencingStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSequencingStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencingStatusRequest.Merge(m, src)
+}
+func (m *GetSequencingStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSequencingStatusRequest.Size(m)
+}
+func (m *GetSequencingStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencingStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencingStatusRequest proto.InternalMessageInfo
+
+func (m *GetSequencingStatusRequest) GetDirectoryId() string {
+	if m != nil {
+		return m.DirectoryId
+	}
+	return ""
+}
+
+type GetSequencingStatusResponse struct {
+	Directories          []*DirectoryStatus `protobuf:"bytes,1,rep,name=directories,proto3" json:"directories,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *GetSequencingStatusResponse) Reset()         { *m = GetSequencingStatusResponse{} }
+func (m *GetSequencingStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSequencingStatusResponse) ProtoMessage()    {}
+func (*GetSequencingStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0a5d61b2e27141ee, []int{16}
+}
+
+func (m *GetSequencingStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencingStatusResponse.Unmarshal(m, b)
+}
+func (m *GetSequencingStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencingStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSequencingStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencingStatusResponse.Merge(m, src)
+}
+func (m *GetSequencingStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSequencingStatusResponse.Size(m)
+}
+func (m *GetSequencingStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencingStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencingStatusResponse proto.InternalMessageInfo
+
+func (m *GetSequencingStatusResponse) GetDirectories() []*DirectoryStatus {
+	if m != nil {
+		return m.Directories
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterEnum("google.keytransparency.sequencer.MapMetadata_RecoveryPolicy", MapMetadata_RecoveryPolicy_name, MapMetadata_RecoveryPolicy_value)
+	proto.RegisterEnum("google.keytransparency.sequencer.DirectoryStatus_Phase", DirectoryStatus_Phase_name, DirectoryStatus_Phase_value)
 	proto.RegisterType((*MapMetadata)(nil), "google.keytransparency.sequencer.MapMetadata")
 	proto.RegisterType((*MapMetadata_SourceSlice)(nil), "google.keytransparency.sequencer.MapMetadata.SourceSlice")
 	proto.RegisterType((*DefineRevisionsRequest)(nil), "google.keytransparency.sequencer.DefineRevisionsRequest")
@@ -708,58 +1108,90 @@ func init() {
 	proto.RegisterType((*PublishRevisionsResponse)(nil), "google.keytransparency.sequencer.PublishRevisionsResponse")
 	proto.RegisterType((*EstimateBacklogRequest)(nil), "google.keytransparency.sequencer.EstimateBacklogRequest")
 	proto.RegisterType((*EstimateBacklogResponse)(nil), "google.keytransparency.sequencer.EstimateBacklogResponse")
+	proto.RegisterType((*PruneRevisionsRequest)(nil), "google.keytransparency.sequencer.PruneRevisionsRequest")
+	proto.RegisterType((*PruneRevisionsResponse)(nil), "google.keytransparency.sequencer.PruneRevisionsResponse")
+	proto.RegisterType((*DirectoryStatus)(nil), "google.keytransparency.sequencer.DirectoryStatus")
+	proto.RegisterType((*GetSequencingStatusRequest)(nil), "google.keytransparency.sequencer.GetSequencingStatusRequest")
+	proto.RegisterType((*GetSequencingStatusResponse)(nil), "google.keytransparency.sequencer.GetSequencingStatusResponse")
 }
 
 func init() { proto.RegisterFile("sequencer_api.proto", fileDescriptor_0a5d61b2e27141ee) }
 
 var fileDescriptor_0a5d61b2e27141ee = []byte{
-	// 725 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x4b, 0x6f, 0xd3, 0x4a,
-	0x14, 0x96, 0x93, 0xb6, 0x37, 0x39, 0x69, 0x9b, 0x74, 0xfa, 0x8a, 0xdc, 0x5e, 0x29, 0xd7, 0x77,
-	0xd1, 0x22, 0x24, 0x47, 0x2a, 0x12, 0x7d, 0x08, 0x84, 0x68, 0x89, 0x50, 0x81, 0x4a, 0xc8, 0xa1,
-	0x1b, 0x36, 0xd6, 0xc4, 0x99, 0x3a, 0xa3, 0xd8, 0x1e, 0xd7, 0x33, 0x2e, 0x89, 0xc4, 0x82, 0x15,
-	0x12, 0x5b, 0xd8, 0xf1, 0x37, 0xf8, 0x4f, 0xfc, 0x0e, 0xe4, 0xc7, 0xa4, 0x49, 0xea, 0x28, 0x0f,
-	0x56, 0xed, 0x7c, 0xe7, 0x7c, 0xe7, 0x7c, 0x67, 0x66, 0xbe, 0x71, 0x60, 0x93, 0x93, 0xdb, 0x90,
-	0x78, 0x16, 0x09, 0x4c, 0xec, 0x53, 0xdd, 0x0f, 0x98, 0x60, 0xa8, 0x66, 0x33, 0x66, 0x3b, 0x44,
-	0xef, 0x92, 0xbe, 0x08, 0xb0, 0xc7, 0x7d, 0x1c, 0x10, 0xcf, 0xea, 0xeb, 0x83, 0x5c, 0x75, 0x2f,
-	0xc9, 0xa8, 0xc7, 0xf9, 0xad, 0xf0, 0xa6, 0x4e, 0x5c, 0x5f, 0xf4, 0x13, 0xba, 0xf6, 0x5b, 0x81,
-	0xd2, 0x15, 0xf6, 0xaf, 0x88, 0xc0, 0x6d, 0x2c, 0x30, 0x6a, 0xc2, 0x3f, 0x9c, 0x85, 0x81, 0x45,
-	0x78, 0x35, 0x57, 0xcb, 0x1f, 0x96, 0x8e, 0x4e, 0xf5, 0x69, 0x0d, 0xf4, 0x21, 0xbe, 0xde, 0x8c,
-	0xc9, 0x4d, 0x87, 0x5a, 0xc4, 0x90, 0x95, 0xd4, 0xcf, 0x50, 0x1a, 0xc2, 0xd1, 0x23, 0xa8, 0x38,
-	0xec, 0x13, 0xe1, 0xc2, 0xa4, 0x9e, 0xe5, 0x84, 0x9c, 0xde, 0x91, 0xaa, 0x52, 0x53, 0x0e, 0xf3,
-	0x46, 0x39, 0xc1, 0x2f, 0x25, 0x8c, 0x1e, 0xc3, 0x46, 0x87, 0xda, 0x9d, 0x28, 0x97, 0xf4, 0x64,
-	0x6e, 0x2e, 0xce, 0xad, 0xa4, 0x81, 0x86, 0xc4, 0xd1, 0x36, 0xac, 0x38, 0xcc, 0x36, 0x69, 0xbb,
-	0x9a, 0x8f, 0x33, 0x96, 0x1d, 0x66, 0x5f, 0xb6, 0xdf, 0x2c, 0x15, 0x94, 0x4a, 0x4e, 0xfb, 0xa9,
-	0xc0, 0xce, 0x2b, 0x72, 0x43, 0x3d, 0x62, 0x90, 0x3b, 0xca, 0x29, 0xf3, 0xb8, 0x11, 0x4d, 0xc0,
-	0x05, 0xfa, 0x0f, 0x56, 0xdb, 0x34, 0x20, 0x96, 0x60, 0x41, 0x3f, 0x62, 0x47, 0x5a, 0x8a, 0x46,
-	0x69, 0x80, 0x5d, 0xb6, 0xd1, 0x1e, 0x14, 0x5d, 0xea, 0x99, 0x2d, 0x2c, 0xac, 0x4e, 0xdc, 0x7f,
-	0xd9, 0x28, 0xb8, 0xd4, 0x3b, 0x8f, 0xd6, 0x71, 0x10, 0xf7, 0xd2, 0x60, 0x3e, 0x0d, 0xe2, 0x5e,
-	0x12, 0xfc, 0x1f, 0xd6, 0xa2, 0x60, 0xe8, 0x61, 0xdf, 0x77, 0x28, 0x69, 0x57, 0x97, 0xe2, 0x84,
-	0x55, 0x17, 0xf7, 0xae, 0x25, 0xa6, 0xdd, 0xc2, 0xee, 0x03, 0x6d, 0xdc, 0x67, 0x1e, 0x27, 0xe8,
-	0x00, 0xca, 0x72, 0x07, 0x64, 0x85, 0x64, 0xfe, 0xf5, 0x14, 0x7e, 0x99, 0xa0, 0xc3, 0x89, 0xed,
-	0xb8, 0x96, 0xdc, 0x06, 0x99, 0x98, 0x74, 0x90, 0xfb, 0xf1, 0x02, 0xd4, 0xd7, 0x44, 0x62, 0x0b,
-	0x6c, 0x89, 0xc6, 0x60, 0x2f, 0xb3, 0xc0, 0x64, 0xdd, 0xca, 0xac, 0xba, 0x73, 0x59, 0xba, 0xb5,
-	0x33, 0xd8, 0x8e, 0x38, 0xfd, 0x45, 0xc4, 0x5e, 0xc3, 0xd6, 0x08, 0x77, 0x8e, 0xa3, 0x57, 0xa1,
-	0x10, 0xa4, 0xac, 0x54, 0xd8, 0x60, 0xad, 0xfd, 0x50, 0xc6, 0x34, 0x0d, 0xc6, 0xff, 0xbb, 0xc2,
-	0x68, 0x1f, 0x8a, 0x6e, 0x28, 0xb0, 0x88, 0xc6, 0x4c, 0x8f, 0xf1, 0x1e, 0x40, 0xff, 0x02, 0xb8,
-	0xd8, 0x37, 0x1d, 0x82, 0xef, 0x08, 0x8f, 0x2f, 0x54, 0x14, 0xc6, 0xfe, 0xbb, 0x18, 0xd0, 0x0c,
-	0xd8, 0x7d, 0x1f, 0xb6, 0x1c, 0xca, 0x3b, 0x8b, 0x5c, 0xf5, 0x2d, 0x58, 0x6e, 0x39, 0xcc, 0xea,
-	0xc6, 0x9a, 0x0a, 0x46, 0xb2, 0xd0, 0x4e, 0xa0, 0xfa, 0xb0, 0x66, 0x3a, 0xeb, 0x3e, 0x14, 0xa5,
-	0x70, 0x5e, 0x55, 0x6a, 0xf9, 0x48, 0xcd, 0x00, 0xd0, 0xba, 0xb0, 0xd3, 0xe0, 0x82, 0xba, 0x58,
-	0x90, 0x73, 0x6c, 0x75, 0x1d, 0x66, 0xcf, 0x21, 0x46, 0x87, 0xcd, 0x11, 0xf7, 0x98, 0x16, 0x0b,
-	0x3d, 0x91, 0x3a, 0x70, 0x63, 0xd8, 0x43, 0x17, 0x51, 0x40, 0x23, 0xb0, 0xfb, 0xa0, 0xd9, 0xec,
-	0x27, 0x72, 0x00, 0xe5, 0xec, 0x4e, 0xeb, 0xe1, 0x48, 0x9b, 0xa3, 0x5f, 0x2b, 0x50, 0x7d, 0x4b,
-	0xfa, 0x1f, 0x86, 0xde, 0xc3, 0xa6, 0x7c, 0x0e, 0xd1, 0x57, 0x05, 0xca, 0x63, 0x6e, 0x46, 0x27,
-	0xd3, 0x5f, 0xd1, 0xec, 0xc7, 0x49, 0x3d, 0x5d, 0x80, 0x99, 0x4e, 0xfc, 0x5d, 0x81, 0xcd, 0x0c,
-	0x8b, 0xa2, 0x67, 0xd3, 0x4b, 0x4e, 0x7e, 0x1a, 0xd4, 0xe7, 0x0b, 0xb2, 0x53, 0x51, 0x18, 0xd6,
-	0x47, 0x5d, 0x8c, 0x8e, 0xa7, 0x17, 0xcc, 0xf4, 0xbd, 0xba, 0x23, 0x89, 0xf2, 0xcb, 0xa6, 0x37,
-	0xa2, 0x2f, 0x1b, 0xfa, 0xa2, 0xc0, 0xda, 0x08, 0x03, 0x3d, 0x9d, 0xb3, 0x85, 0xec, 0x70, 0x3c,
-	0x37, 0x2f, 0x9d, 0xf2, 0x9b, 0x02, 0x95, 0x71, 0xbf, 0xa0, 0x19, 0x8e, 0x72, 0x82, 0x6f, 0xd5,
-	0xb3, 0x45, 0xa8, 0xa9, 0x96, 0xe8, 0x3e, 0x8e, 0x99, 0x62, 0x96, 0xfb, 0x98, 0x6d, 0xda, 0x59,
-	0xee, 0xe3, 0x04, 0x07, 0x9e, 0x37, 0x3e, 0x5e, 0xd8, 0x54, 0x74, 0xc2, 0x96, 0x6e, 0x31, 0xb7,
-	0x9e, 0xfe, 0x2a, 0x19, 0x2b, 0x53, 0xb7, 0x58, 0x40, 0xea, 0x83, 0x5a, 0xf7, 0xff, 0x99, 0x36,
-	0x33, 0x93, 0x73, 0x5e, 0x89, 0xff, 0x3c, 0xf9, 0x13, 0x00, 0x00, 0xff, 0xff, 0x23, 0x84, 0xca,
-	0xef, 0x0f, 0x09, 0x00, 0x00,
+	// 1160 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x57, 0xd1, 0x4e, 0xe3, 0x46,
+	0x17, 0xfe, 0x9d, 0x90, 0xdd, 0xe4, 0x04, 0x92, 0x30, 0x2c, 0x10, 0x99, 0xfd, 0xb5, 0xd4, 0xbd,
+	0x58, 0xaa, 0x4a, 0x41, 0x9b, 0x4a, 0x5d, 0x68, 0xbb, 0xaa, 0x48, 0x62, 0xd8, 0x74, 0x03, 0x44,
+	0x0e, 0xb4, 0xa5, 0x37, 0xd6, 0xe0, 0x0c, 0xc9, 0x08, 0xc7, 0xe3, 0xb5, 0xc7, 0x94, 0x48, 0xbd,
+	0xa8, 0x5a, 0xa9, 0x52, 0x6f, 0xdb, 0x87, 0xe8, 0x63, 0xf4, 0x0d, 0xfa, 0x18, 0x7d, 0x8e, 0x6a,
+	0xec, 0x71, 0x48, 0x82, 0x11, 0x49, 0xaa, 0x5e, 0x91, 0x39, 0xe7, 0x7c, 0xdf, 0x39, 0x33, 0x67,
+	0xce, 0x7c, 0x06, 0xd6, 0x7c, 0xf2, 0x3e, 0x20, 0x8e, 0x45, 0x3c, 0x13, 0xbb, 0xb4, 0xe2, 0x7a,
+	0x8c, 0x33, 0xb4, 0xdd, 0x63, 0xac, 0x67, 0x93, 0xca, 0x35, 0x19, 0x72, 0x0f, 0x3b, 0xbe, 0x8b,
+	0x3d, 0xe2, 0x58, 0xc3, 0xca, 0x28, 0x56, 0xdd, 0x8a, 0x22, 0x76, 0xc3, 0xf8, 0xcb, 0xe0, 0x6a,
+	0x97, 0x0c, 0x5c, 0x3e, 0x8c, 0xe0, 0xea, 0x8b, 0x69, 0x27, 0xa7, 0x03, 0xe2, 0x73, 0x3c, 0x70,
+	0xa3, 0x00, 0xed, 0x8f, 0x34, 0xe4, 0x8f, 0xb1, 0x7b, 0x4c, 0x38, 0xee, 0x62, 0x8e, 0x51, 0x07,
+	0x9e, 0xfa, 0x2c, 0xf0, 0x2c, 0xe2, 0x97, 0x53, 0xdb, 0xe9, 0x9d, 0x7c, 0x75, 0xbf, 0xf2, 0x58,
+	0x05, 0x95, 0x31, 0x7c, 0xa5, 0x13, 0x82, 0x3b, 0x36, 0xb5, 0x88, 0x11, 0x33, 0x21, 0x02, 0x45,
+	0x8f, 0x58, 0xec, 0x86, 0x78, 0x43, 0xd3, 0x65, 0x36, 0xb5, 0x86, 0xe5, 0xf4, 0xb6, 0xb2, 0x53,
+	0xa8, 0x7e, 0x31, 0x1f, 0xb9, 0x21, 0x49, 0xda, 0x21, 0x87, 0x51, 0xf0, 0x26, 0xd6, 0xea, 0x0f,
+	0x90, 0x1f, 0x4b, 0x8f, 0x3e, 0x82, 0x92, 0xcd, 0xbe, 0x27, 0x3e, 0x37, 0xa9, 0x63, 0xd9, 0x81,
+	0x4f, 0x6f, 0x48, 0x59, 0xd9, 0x56, 0x76, 0xd2, 0x46, 0x31, 0xb2, 0x37, 0x63, 0x33, 0xfa, 0x18,
+	0x56, 0xfb, 0xb4, 0xd7, 0x17, 0xb1, 0xe4, 0x36, 0x8e, 0x4d, 0x85, 0xb1, 0x25, 0xe9, 0xd0, 0x63,
+	0x3b, 0x5a, 0x87, 0x27, 0x36, 0xeb, 0x99, 0xb4, 0x1b, 0x6e, 0x22, 0x6d, 0x64, 0x6c, 0xd6, 0x6b,
+	0x76, 0xb5, 0x6f, 0xa1, 0x30, 0x59, 0x1f, 0x7a, 0x01, 0x5b, 0x86, 0x5e, 0x3f, 0xfd, 0x5a, 0x37,
+	0x2e, 0xcc, 0xf6, 0x69, 0xab, 0x59, 0xbf, 0x30, 0xcf, 0x4f, 0x3a, 0x6d, 0xbd, 0xde, 0x3c, 0x6c,
+	0xea, 0x8d, 0xd2, 0xff, 0xd0, 0x2a, 0xac, 0x34, 0xf4, 0x93, 0x0b, 0x33, 0x8e, 0x2a, 0x29, 0x08,
+	0x41, 0xe1, 0xa0, 0xd5, 0x3a, 0xfd, 0xe6, 0xce, 0x96, 0xfa, 0x6a, 0x29, 0xab, 0x94, 0x52, 0xda,
+	0x9f, 0x0a, 0x6c, 0x34, 0xc8, 0x15, 0x75, 0x88, 0x41, 0x6e, 0xa8, 0x4f, 0x99, 0xe3, 0x1b, 0xe2,
+	0x94, 0x7c, 0x8e, 0x3e, 0x80, 0xe5, 0x2e, 0xf5, 0x88, 0xc5, 0x99, 0x37, 0x14, 0x75, 0x89, 0x5d,
+	0xe6, 0x8c, 0xfc, 0xc8, 0xd6, 0xec, 0xa2, 0x2d, 0xc8, 0x0d, 0xa8, 0x63, 0x5e, 0x62, 0x6e, 0xf5,
+	0xc3, 0x9d, 0x65, 0x8c, 0xec, 0x80, 0x3a, 0x35, 0xb1, 0x0e, 0x9d, 0xf8, 0x56, 0x3a, 0xd3, 0xd2,
+	0x89, 0x6f, 0x23, 0xe7, 0x87, 0xb0, 0x22, 0x9c, 0x81, 0x83, 0x5d, 0xd7, 0xa6, 0xa4, 0x5b, 0x5e,
+	0x0a, 0x03, 0x96, 0x07, 0xf8, 0xf6, 0x3c, 0xb6, 0x89, 0xa0, 0x2b, 0xe2, 0x58, 0xd4, 0xe9, 0x99,
+	0x9c, 0x5d, 0x13, 0xa7, 0x9c, 0x09, 0x8f, 0x66, 0x59, 0x1a, 0xcf, 0x84, 0x4d, 0x7b, 0x0f, 0x9b,
+	0xf7, 0x36, 0xe0, 0xbb, 0xcc, 0xf1, 0x09, 0x7a, 0x09, 0xc5, 0xb8, 0x01, 0x71, 0x9a, 0xe8, 0xf8,
+	0x0b, 0xd2, 0x7c, 0x20, 0x13, 0x8d, 0x05, 0x76, 0x43, 0xae, 0xb8, 0x0b, 0x71, 0x60, 0x94, 0xa1,
+	0x2b, 0x0f, 0xed, 0x4b, 0x50, 0x8f, 0x48, 0x6c, 0x5b, 0xe0, 0xdc, 0x34, 0x06, 0x5b, 0x89, 0x04,
+	0x0f, 0xd7, 0xad, 0xcc, 0x5a, 0x77, 0x2a, 0xa9, 0x6e, 0xcd, 0x84, 0x75, 0x81, 0x19, 0x2e, 0xd2,
+	0xe4, 0x7b, 0x5d, 0x48, 0x25, 0x74, 0xe1, 0x1c, 0x9e, 0x4d, 0x24, 0x98, 0x83, 0x5f, 0x85, 0xac,
+	0x27, 0x51, 0x92, 0x7a, 0xb4, 0xd6, 0x7e, 0x57, 0xa6, 0x0a, 0x1f, 0x9d, 0xd1, 0xbf, 0x23, 0x46,
+	0xcf, 0x21, 0x37, 0x08, 0x38, 0xe6, 0xe2, 0x2c, 0x64, 0xaf, 0xef, 0x0c, 0xe8, 0xff, 0x00, 0x03,
+	0xec, 0x9a, 0x36, 0xc1, 0x37, 0xc4, 0x2f, 0x2f, 0x49, 0x37, 0x76, 0x5b, 0xa1, 0x41, 0x33, 0x60,
+	0xb3, 0x1d, 0x5c, 0xda, 0xd4, 0xef, 0x2f, 0x72, 0x9e, 0xcf, 0x20, 0x73, 0x69, 0x33, 0xeb, 0x3a,
+	0xac, 0x29, 0x6b, 0x44, 0x0b, 0x6d, 0x0f, 0xca, 0xf7, 0x39, 0xe5, 0x5e, 0x9f, 0x43, 0x2e, 0x2e,
+	0xdc, 0x2f, 0x2b, 0xdb, 0x69, 0x51, 0xcd, 0xc8, 0xa0, 0x5d, 0xc3, 0x86, 0xee, 0x73, 0x3a, 0xc0,
+	0x9c, 0xd4, 0xb0, 0x75, 0x6d, 0xb3, 0xde, 0x1c, 0xc5, 0x54, 0x60, 0x6d, 0x62, 0x0e, 0x4d, 0x8b,
+	0x05, 0x0e, 0x97, 0xb3, 0xbc, 0x3a, 0x3e, 0x8d, 0x75, 0xe1, 0xd0, 0x08, 0x6c, 0xde, 0x4b, 0x36,
+	0x7b, 0x47, 0x5e, 0x42, 0x31, 0x39, 0x53, 0x21, 0x98, 0x4c, 0x63, 0xc2, 0x7a, 0xdb, 0x0b, 0x1c,
+	0xf2, 0x9f, 0xdd, 0xd7, 0x9f, 0x14, 0xd8, 0x98, 0xce, 0x30, 0xd7, 0x3e, 0x5c, 0x01, 0xee, 0x9a,
+	0x53, 0x17, 0xac, 0x10, 0x99, 0x8d, 0x99, 0xae, 0x99, 0xf6, 0x77, 0x1a, 0x8a, 0x8d, 0x98, 0xb6,
+	0xc3, 0x31, 0x0f, 0xfc, 0x59, 0xb2, 0x1f, 0x43, 0xc6, 0xed, 0x63, 0x3f, 0xd2, 0x92, 0x42, 0xf5,
+	0xf5, 0xe3, 0x72, 0x37, 0x95, 0xa4, 0xd2, 0x16, 0x70, 0x23, 0x62, 0x41, 0x9f, 0x43, 0x3e, 0xfc,
+	0x61, 0xfa, 0x1c, 0x7b, 0x3c, 0xac, 0x32, 0x5f, 0x55, 0x63, 0xd2, 0x58, 0xe3, 0x2b, 0x67, 0xb1,
+	0xc6, 0x1b, 0x10, 0x86, 0x77, 0x44, 0xb4, 0x98, 0x14, 0x1b, 0x0b, 0x81, 0xf3, 0x3c, 0xe6, 0x85,
+	0x93, 0x92, 0x33, 0x72, 0xc2, 0xa2, 0x0b, 0x03, 0xaa, 0x41, 0xf1, 0xce, 0x6d, 0x8a, 0xcf, 0x84,
+	0x72, 0xe6, 0x51, 0xfe, 0x95, 0x11, 0x5e, 0xd8, 0xd0, 0x2b, 0x78, 0x66, 0x89, 0xc6, 0x58, 0x01,
+	0xa7, 0x37, 0xc4, 0xbc, 0xc2, 0xd4, 0x0e, 0x3c, 0xe2, 0x97, 0x9f, 0x84, 0x37, 0x67, 0x6d, 0xcc,
+	0x77, 0x28, 0x5d, 0x49, 0x0f, 0xe8, 0xd3, 0xa4, 0x07, 0x54, 0x23, 0x90, 0x09, 0xcf, 0x02, 0xad,
+	0xc3, 0x6a, 0xfb, 0xed, 0x41, 0x47, 0x9f, 0xd2, 0xd2, 0x2c, 0x2c, 0x35, 0x1b, 0x2d, 0xbd, 0xa4,
+	0xa0, 0x65, 0xc8, 0x36, 0xf4, 0xc3, 0xe6, 0x49, 0xf3, 0xe4, 0xa8, 0x94, 0x12, 0xab, 0x83, 0x76,
+	0xbb, 0x75, 0x21, 0x56, 0x69, 0x54, 0x00, 0x68, 0x9f, 0xd7, 0x5a, 0xcd, 0xce, 0x5b, 0xb1, 0x5e,
+	0x42, 0x45, 0xc8, 0xd7, 0x0e, 0xea, 0xef, 0x9a, 0x27, 0x47, 0xe6, 0xe9, 0xe1, 0x61, 0x29, 0x23,
+	0x05, 0xa3, 0x13, 0xf5, 0x83, 0x3a, 0xbd, 0xa8, 0x0d, 0x73, 0x08, 0x86, 0x07, 0x5b, 0x89, 0x04,
+	0xf2, 0xca, 0x76, 0x60, 0x14, 0x4d, 0x49, 0xf4, 0x44, 0xe4, 0xab, 0xaf, 0xe6, 0xbe, 0x17, 0xc6,
+	0x38, 0x4b, 0xf5, 0xaf, 0x2c, 0x94, 0xdf, 0x91, 0xe1, 0xd9, 0x18, 0xb4, 0x13, 0x23, 0xd1, 0x2f,
+	0x0a, 0x14, 0xa7, 0x64, 0x17, 0xed, 0xcd, 0x90, 0x30, 0xf1, 0x53, 0x43, 0xdd, 0x5f, 0x00, 0x29,
+	0xb7, 0xfe, 0x9b, 0x02, 0x6b, 0x09, 0x5a, 0x8a, 0x66, 0xf8, 0x08, 0x7c, 0x58, 0xc3, 0xd5, 0x37,
+	0x0b, 0xa2, 0x65, 0x51, 0x18, 0x0a, 0x93, 0x72, 0x8b, 0x66, 0x18, 0xd2, 0x44, 0x81, 0x56, 0x37,
+	0xee, 0x0d, 0x8a, 0x2e, 0xbe, 0xc4, 0xd1, 0x8f, 0x0a, 0xac, 0x4c, 0x20, 0xd0, 0xa7, 0x73, 0xa6,
+	0x88, 0x33, 0xbc, 0x9e, 0x1b, 0x27, 0x77, 0xf9, 0xab, 0x02, 0xa5, 0x69, 0xcd, 0x42, 0x33, 0xb4,
+	0xf2, 0x01, 0xed, 0x54, 0x3f, 0x5b, 0x04, 0x2a, 0x6b, 0x11, 0xf7, 0x71, 0x4a, 0x98, 0x66, 0xb9,
+	0x8f, 0xc9, 0xc2, 0xa9, 0xee, 0x2f, 0x80, 0x94, 0x85, 0xfc, 0xac, 0x40, 0x61, 0x52, 0x58, 0x66,
+	0xe9, 0x7d, 0xa2, 0xd8, 0xa9, 0x7b, 0xf3, 0x03, 0x27, 0xa7, 0x62, 0xfa, 0xc1, 0x98, 0x71, 0x2a,
+	0x1e, 0x78, 0xa8, 0xd4, 0x37, 0x0b, 0xa2, 0xa3, 0xa2, 0x6a, 0xfa, 0x77, 0xf5, 0x1e, 0xe5, 0xfd,
+	0xe0, 0xb2, 0x62, 0xb1, 0xc1, 0xae, 0xfc, 0x1f, 0x72, 0x8a, 0x6a, 0xd7, 0x62, 0x1e, 0xd9, 0x1d,
+	0xf1, 0xdd, 0xfd, 0x32, 0x7b, 0xcc, 0x8c, 0x46, 0xe0, 0x49, 0xf8, 0xe7, 0x93, 0x7f, 0x06, 0x00,
+	0x99, 0x05, 0x4e, 0x47, 0xda, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -790,6 +1222,12 @@ type KeyTransparencySequencerClient interface {
 	PublishRevisions(ctx context.Context, in *PublishRevisionsRequest, opts ...grpc.CallOption) (*PublishRevisionsResponse, error)
 	// EstimateBacklog will update various counters on the server. Call periodically.
 	EstimateBacklog(ctx context.Context, in *EstimateBacklogRequest, opts ...grpc.CallOption) (*EstimateBacklogResponse, error)
+	// PruneRevisions deletes the mutations of revisions that have passed the
+	// retention policy of the directory.
+	PruneRevisions(ctx context.Context, in *PruneRevisionsRequest, opts ...grpc.CallOption) (*PruneRevisionsResponse, error)
+	// GetSequencingStatus returns the sequencing phase and last error of the
+	// directories this sequencer is master for.
+	GetSequencingStatus(ctx context.Context, in *GetSequencingStatusRequest, opts ...grpc.CallOption) (*GetSequencingStatusResponse, error)
 }
 
 type keyTransparencySequencerClient struct {
@@ -854,6 +1292,24 @@ func (c *keyTransparencySequencerClient) EstimateBacklog(ctx context.Context, in
 	return out, nil
 }
 
+func (c *keyTransparencySequencerClient) PruneRevisions(ctx context.Context, in *PruneRevisionsRequest, opts ...grpc.CallOption) (*PruneRevisionsResponse, error) {
+	out := new(PruneRevisionsResponse)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.sequencer.KeyTransparencySequencer/PruneRevisions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keyTransparencySequencerClient) GetSequencingStatus(ctx context.Context, in *GetSequencingStatusRequest, opts ...grpc.CallOption) (*GetSequencingStatusResponse, error) {
+	out := new(GetSequencingStatusResponse)
+	err := c.cc.Invoke(ctx, "/google.keytransparency.sequencer.KeyTransparencySequencer/GetSequencingStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeyTransparencySequencerServer is the server API for KeyTransparencySequencer service.
 type KeyTransparencySequencerServer interface {
 	// DefineRevisions returns the info on defined/applied revisions, after
@@ -872,6 +1328,12 @@ type KeyTransparencySequencerServer interface {
 	PublishRevisions(context.Context, *PublishRevisionsRequest) (*PublishRevisionsResponse, error)
 	// EstimateBacklog will update various counters on the server. Call periodically.
 	EstimateBacklog(context.Context, *EstimateBacklogRequest) (*EstimateBacklogResponse, error)
+	// PruneRevisions deletes the mutations of revisions that have passed the
+	// retention policy of the directory.
+	PruneRevisions(context.Context, *PruneRevisionsRequest) (*PruneRevisionsResponse, error)
+	// GetSequencingStatus returns the sequencing phase and last error of the
+	// directories this sequencer is master for.
+	GetSequencingStatus(context.Context, *GetSequencingStatusRequest) (*GetSequencingStatusResponse, error)
 }
 
 // UnimplementedKeyTransparencySequencerServer can be embedded to have forward compatible implementations.
@@ -896,6 +1358,12 @@ func (*UnimplementedKeyTransparencySequencerServer) PublishRevisions(ctx context
 func (*UnimplementedKeyTransparencySequencerServer) EstimateBacklog(ctx context.Context, req *EstimateBacklogRequest) (*EstimateBacklogResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method EstimateBacklog not implemented")
 }
+func (*UnimplementedKeyTransparencySequencerServer) PruneRevisions(ctx context.Context, req *PruneRevisionsRequest) (*PruneRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PruneRevisions not implemented")
+}
+func (*UnimplementedKeyTransparencySequencerServer) GetSequencingStatus(ctx context.Context, req *GetSequencingStatusRequest) (*GetSequencingStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSequencingStatus not implemented")
+}
 
 func RegisterKeyTransparencySequencerServer(s *grpc.Server, srv KeyTransparencySequencerServer) {
 	s.RegisterService(&_KeyTransparencySequencer_serviceDesc, srv)
@@ -1009,6 +1477,42 @@ func _KeyTransparencySequencer_EstimateBacklog_Handler(srv interface{}, ctx cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KeyTransparencySequencer_PruneRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PruneRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencySequencerServer).PruneRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.sequencer.KeyTransparencySequencer/PruneRevisions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencySequencerServer).PruneRevisions(ctx, req.(*PruneRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KeyTransparencySequencer_GetSequencingStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSequencingStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeyTransparencySequencerServer).GetSequencingStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/google.keytransparency.sequencer.KeyTransparencySequencer/GetSequencingStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeyTransparencySequencerServer).GetSequencingStatus(ctx, req.(*GetSequencingStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _KeyTransparencySequencer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "google.keytransparency.sequencer.KeyTransparencySequencer",
 	HandlerType: (*KeyTransparencySequencerServer)(nil),
@@ -1037,6 +1541,14 @@ var _KeyTransparencySequencer_serviceDesc = grpc.ServiceDesc{
 			MethodName: "EstimateBacklog",
 			Handler:    _KeyTransparencySequencer_EstimateBacklog_Handler,
 		},
+		{
+			MethodName: "PruneRevisions",
+			Handler:    _KeyTransparencySequencer_PruneRevisions_Handler,
+		},
+		{
+			MethodName: "GetSequencingStatus",
+			Handler:    _KeyTransparencySequencer_GetSequencingStatus_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "sequencer_api.proto",
@@ -17,6 +17,7 @@ package sequencer
 import (
 	"context"
 	"fmt"
+	"runtime"
 	"sort"
 	"strconv"
 	"sync"
@@ -24,19 +25,23 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/trillian/monitoring"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/google/keytransparency/core/directory"
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/mutator/entry"
+	"github.com/google/keytransparency/core/sequencer/election"
 	"github.com/google/keytransparency/core/sequencer/mapper"
 	"github.com/google/keytransparency/core/sequencer/metadata"
 	"github.com/google/keytransparency/core/sequencer/runner"
 	"github.com/google/keytransparency/core/water"
 
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 	tpb "github.com/google/trillian"
 )
@@ -62,8 +67,11 @@ var (
 	appliedLatency     monitoring.Histogram
 	mutationFailures   monitoring.Counter
 	fnLatency          monitoring.Histogram
+	pipelineQueueDepth monitoring.Gauge
 	logRootTrail       monitoring.Gauge
 	unappliedRevisions monitoring.Gauge
+	prunedRevision     monitoring.Gauge
+	mutationsPruned    monitoring.Counter
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -116,6 +124,10 @@ func createMetrics(mf monitoring.MetricFactory) {
 		"apply_revision_latency",
 		"Latency of sequencer apply revision operation in seconds",
 		directoryIDLabel, fnLabel)
+	pipelineQueueDepth = mf.NewGauge(
+		"pipeline_queue_depth",
+		"Number of elements waiting at the input of each apply revision stage",
+		directoryIDLabel, fnLabel)
 	logRootTrail = mf.NewGauge(
 		"log_root_trail",
 		"How many revisions have not been published to the log",
@@ -124,6 +136,14 @@ func createMetrics(mf monitoring.MetricFactory) {
 		"unapplied_revisions",
 		"How many revisions have been defined but haven't been applied to the map",
 	)
+	prunedRevision = mf.NewGauge(
+		"pruned_revision",
+		"Highest revision whose mutations have been deleted from the queue",
+		directoryIDLabel)
+	mutationsPruned = mf.NewCounter(
+		"mutations_pruned",
+		"Number of mutations deleted from the queue since process start",
+		directoryIDLabel)
 }
 
 // Watermarks is a map of watermarks by logID.
@@ -165,6 +185,8 @@ type LogsReader interface {
 // Batcher writes batch definitions to storage.
 type Batcher interface {
 	// WriteBatchSources saves the (low, high] boundaries used for each log in making this revision.
+	// Returns FailedPrecondition if ctx carries a fencing token older than one
+	// seen for dirID. See election.WithToken.
 	WriteBatchSources(ctx context.Context, dirID string, rev int64, meta *spb.MapMetadata) error
 	// ReadBatch returns the batch definitions for a given revision.
 	ReadBatch(ctx context.Context, directoryID string, rev int64) (*spb.MapMetadata, error)
@@ -172,16 +194,64 @@ type Batcher interface {
 	HighestRev(ctx context.Context, directoryID string) (int64, error)
 }
 
+// Pruner deletes the mutations of applied revisions.
+type Pruner interface {
+	// PruneRevision deletes the mutations read by revision rev, which are
+	// those below the high watermarks of meta, the batch definition of rev.
+	// Revisions must be pruned in order. Pruning a revision that has already
+	// been pruned does nothing. Returns the number of mutations deleted.
+	// Returns FailedPrecondition if ctx carries a stale fencing token.
+	PruneRevision(ctx context.Context, directoryID string, rev int64, meta *spb.MapMetadata) (int64, error)
+	// PrunedRevision returns the highest revision whose mutations have been
+	// pruned, or 0 if none have been.
+	PrunedRevision(ctx context.Context, directoryID string) (int64, error)
+	// ExpireRequests forgets the request IDs of mutations that were queued
+	// before the window in which retried requests are deduplicated.
+	// Returns the number of request IDs forgotten.
+	ExpireRequests(ctx context.Context, directoryID string) (int64, error)
+}
+
+// LogLifecycle tracks input logs as they are drained, sealed and retired.
+type LogLifecycle interface {
+	// ListInputLogs returns all the logs of directoryID, including retired logs.
+	ListInputLogs(ctx context.Context, directoryID string) ([]*pb.InputLog, error)
+	// SealLog marks the read-only log logID as sealed at final, the watermark
+	// just beyond its last mutation. Returns FailedPrecondition if the log is
+	// writable or holds mutations at or beyond final, or if ctx carries a
+	// stale fencing token.
+	SealLog(ctx context.Context, directoryID string, logID int64, final water.Mark) error
+}
+
 // Server implements KeyTransparencySequencerServer.
 type Server struct {
 	directories            directory.Storage
 	batcher                Batcher
 	trillian               trillianFactory
 	logs                   LogsReader
+	pruner                 Pruner
+	lifecycle              LogLifecycle
 	loopback               spb.KeyTransparencySequencerClient
 	BatchSize              int32
 	ApplyRevisionBatchSize uint64
 	LogPublishBatchSize    uint64
+	PruneBatchSize         int64
+	// MapLeafChunkSize is the maximum number of map leaves ApplyRevision
+	// reads from the map at once.
+	MapLeafChunkSize int
+	// ReduceWorkers is the number of goroutines reducing the mutations of
+	// one revision.
+	ReduceWorkers int
+	// ReduceBudget is shared by all directories and bounds the reduce
+	// workers that run at once.
+	ReduceBudget *runner.Budget
+	// MaxRevisionMutations bounds the mutations ApplyRevision holds in
+	// memory, by capping the MaxBatch of DefineRevisions. A revision exceeds
+	// it only by mutations sent in one batch, which cannot be split. Revisions
+	// defined before the cap was lowered are still applied in full.
+	MaxRevisionMutations int
+	// Scheduler, if set, is the sequencing loop reported by
+	// GetSequencingStatus.
+	Scheduler *Scheduler
 }
 
 // NewServer creates a new KeyTransparencySequencerServer.
@@ -192,6 +262,8 @@ func NewServer(
 	twrite tpb.TrillianMapWriteClient,
 	batcher Batcher,
 	logs LogsReader,
+	pruner Pruner,
+	lifecycle LogLifecycle,
 	loopback spb.KeyTransparencySequencerClient,
 	metricsFactory monitoring.MetricFactory,
 ) *Server {
@@ -206,10 +278,17 @@ func NewServer(
 		},
 		batcher:                batcher,
 		logs:                   logs,
+		pruner:                 pruner,
+		lifecycle:              lifecycle,
 		loopback:               loopback,
 		BatchSize:              10000,
 		ApplyRevisionBatchSize: 2,
 		LogPublishBatchSize:    10,
+		PruneBatchSize:         100,
+		MapLeafChunkSize:       1000,
+		ReduceWorkers:          runtime.NumCPU(),
+		ReduceBudget:           runner.NewBudget(runtime.NumCPU(), runtime.NumCPU()),
+		MaxRevisionMutations:   1000000,
 	}
 }
 
@@ -248,8 +327,10 @@ func (s *Server) EstimateBacklog(ctx context.Context, in *spb.EstimateBacklogReq
 
 // DefineRevisions returns the set of outstanding revisions that have not been
 // applied, after optionally defining a new revision of outstanding mutations.
+// The new revision is fenced by in.FencingToken.
 func (s *Server) DefineRevisions(ctx context.Context,
 	in *spb.DefineRevisionsRequest) (*spb.DefineRevisionsResponse, error) {
+	ctx = election.WithToken(ctx, in.FencingToken)
 	revs, err := s.GetDefinedRevisions(ctx,
 		&spb.GetDefinedRevisionsRequest{DirectoryId: in.DirectoryId})
 	if err != nil {
@@ -277,7 +358,14 @@ func (s *Server) DefineRevisions(ctx context.Context,
 		return nil, status.Errorf(st.Code(), "ReadBatch(): %v", st.Message())
 	}
 	// Advance the watermarks forward, to define a new batch.
-	count, meta, err := s.HighWatermarks(ctx, in.DirectoryId, lastMeta, in.MaxBatch)
+	maxBatch, minBatch := in.MaxBatch, in.MinBatch
+	if max := s.MaxRevisionMutations; max > 0 && maxBatch > int32(max) {
+		maxBatch = int32(max)
+	}
+	if minBatch > maxBatch {
+		minBatch = maxBatch
+	}
+	count, meta, err := s.HighWatermarks(ctx, in.DirectoryId, lastMeta, maxBatch)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "HighWatermarks(): %v", err)
 	}
@@ -289,7 +377,12 @@ func (s *Server) DefineRevisions(ctx context.Context,
 	// TODO(#1057): If time since last map revision > max timeout, define batch.
 	// TODO(#1047): If time since oldest queue item > max latency has elapsed, define batch.
 	// If count items >= min_batch, define batch.
-	if count >= in.MinBatch {
+	if count >= minBatch {
+		d, err := s.directories.Read(ctx, in.DirectoryId, false)
+		if err != nil {
+			return nil, err
+		}
+		meta.RecoveryPolicy = recoveryPolicy(d.AllowRecovery)
 		resp.HighestDefined++
 		nextRev := resp.HighestDefined
 		if err := s.batcher.WriteBatchSources(ctx, in.DirectoryId, nextRev, meta); err != nil {
@@ -340,8 +433,10 @@ func (s *Server) highestAppliedRev(ctx context.Context, dirID string) (int64, er
 }
 
 // ApplyRevisions builds multiple outstanding revisions of a single directory's
-// map by integrating the corresponding mutations.
+// map by integrating the corresponding mutations. Sealing drained logs is
+// fenced by in.FencingToken.
 func (s *Server) ApplyRevisions(ctx context.Context, in *spb.ApplyRevisionsRequest) (*empty.Empty, error) {
+	ctx = election.WithToken(ctx, in.FencingToken)
 	highestApplied, err := s.highestAppliedRev(ctx, in.DirectoryId)
 	if err != nil {
 		return nil, err
@@ -363,19 +458,92 @@ func (s *Server) ApplyRevisions(ctx context.Context, in *spb.ApplyRevisionsReque
 		}
 	}
 	glog.Infof("ApplyRevisions: applied revision(s) [%d, %d]", firstRev, highestApplied+i)
+	if err := s.sealDrainedLogs(ctx, in.DirectoryId); err != nil {
+		glog.Errorf("ApplyRevisions: sealDrainedLogs(%v): %v", in.DirectoryId, err)
+	}
 	return &empty.Empty{}, nil
 }
 
+// sealDrainedLogs seals the read-only logs of directoryID whose mutations have
+// all been applied to the latest map revision.
+func (s *Server) sealDrainedLogs(ctx context.Context, directoryID string) error {
+	logs, err := s.inputLogs(ctx, directoryID)
+	if err != nil {
+		return err
+	}
+	var drained []int64
+	for _, l := range logs {
+		if l.State == pb.InputLog_ACTIVE && !l.Writable {
+			drained = append(drained, l.LogId)
+		}
+	}
+	if len(drained) == 0 {
+		return nil
+	}
+
+	mapClient, err := s.trillian.MapClient(ctx, directoryID)
+	if err != nil {
+		return err
+	}
+	_, latestMapRoot, err := mapClient.GetAndVerifyLatestMapRoot(ctx)
+	if err != nil {
+		return err
+	}
+	var lastMeta spb.MapMetadata
+	if err := proto.Unmarshal(latestMapRoot.Metadata, &lastMeta); err != nil {
+		return err
+	}
+	applied := make(map[int64]water.Mark)
+	for _, source := range lastMeta.GetSources() {
+		if high := metadata.FromProto(source).HighMark(); applied[source.LogId].Compare(high) < 0 {
+			applied[source.LogId] = high
+		}
+	}
+	for _, logID := range drained {
+		err := s.lifecycle.SealLog(ctx, directoryID, logID, applied[logID])
+		switch st := status.Convert(err); st.Code() {
+		case codes.OK:
+			glog.Infof("sealDrainedLogs: sealed log %v/%v at %v", directoryID, logID, applied[logID])
+		case codes.FailedPrecondition:
+			// The log still has unapplied mutations.
+		default:
+			return fmt.Errorf("SealLog(%v/%v): %v", directoryID, logID, err)
+		}
+	}
+	return nil
+}
+
+// inputLogs returns the lifecycle state of the logs of directoryID, or nothing
+// if lifecycles are not tracked.
+func (s *Server) inputLogs(ctx context.Context, directoryID string) ([]*pb.InputLog, error) {
+	if s.lifecycle == nil {
+		return nil, nil
+	}
+	logs, err := s.lifecycle.ListInputLogs(ctx, directoryID)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	return logs, err
+}
+
 // readMessages returns the full set of EntryUpdates defined by sources.
 // chunkSize limits the number of messages to read from a log at one time.
 func (s *Server) readMessages(ctx context.Context, source *spb.MapMetadata_SourceSlice,
+	directoryID string, chunkSize int32,
+	emit func(*mutator.LogMessage)) error {
+	return readLogMessages(ctx, s.logs, source, directoryID, chunkSize, emit)
+}
+
+// readLogMessages emits the messages of logs defined by source, reading at
+// most chunkSize messages at a time.
+func readLogMessages(ctx context.Context, logs LogsReader, source *spb.MapMetadata_SourceSlice,
 	directoryID string, chunkSize int32,
 	emit func(*mutator.LogMessage)) error {
 	ss := metadata.FromProto(source)
 	low, high := ss.LowMark(), ss.HighMark()
 	for moreToRead := true; moreToRead; {
 		// Request one more item than chunkSize so we can find the next page token.
-		batch, err := s.logs.ReadLog(ctx, directoryID, source.LogId, low, high, chunkSize+1)
+		batch, err := logs.ReadLog(ctx, directoryID, source.LogId, low, high, chunkSize+1)
 		if err != nil {
 			return fmt.Errorf("logs.ReadLog(): %v", err)
 		}
@@ -394,6 +562,105 @@ func (s *Server) readMessages(ctx context.Context, source *spb.MapMetadata_Sourc
 	return nil
 }
 
+// computedRevision is the output of computeRevision.
+type computedRevision struct {
+	leaves    []*tpb.MapLeaf // The new map leaves.
+	mutations int64          // The number of mutations mapped to an index.
+	indexes   int            // The number of distinct indexes mutated.
+}
+
+// computeRevision runs the mapper pipeline over the mutations defined by meta,
+// which readFn reads, and the existing map leaves of their indexes, which
+// readLeaves reads, through reduceFn and returns the new map leaves of the
+// revision.
+func computeRevision(ctx context.Context, pipeline *runner.Pipeline, meta *spb.MapMetadata,
+	readFn runner.ReadSliceFn, readLeaves runner.ReadMapLeavesFn, reduceFn runner.ReduceMutationFn,
+	batchSize int32, leafChunkSize int, emitErr func(error)) (*computedRevision, error) {
+	logSlices := pipeline.MapMeta(mapper.MapMetaFn, meta)
+
+	// Read the log items of every slice concurrently, map them to indexed
+	// values and fetch the existing map leaves of their indexes as they are
+	// discovered.
+	joiner := pipeline.NewJoiner()
+	logItems := make(chan *mutator.LogMessage, batchSize)
+	indexes := make(chan []byte, leafChunkSize)
+	var mutations int64
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		defer close(logItems)
+		return pipeline.Read(gctx, readFn, logSlices, batchSize, logItems)
+	})
+	g.Go(func() error {
+		defer close(indexes)
+		pipeline.MapLogItems(entry.MapLogItemFn, logItems, func(iv *entry.IndexedValue) {
+			mutations++
+			if joiner.AddMsg(iv) {
+				select {
+				case indexes <- iv.Index:
+				case <-gctx.Done():
+				}
+			}
+		}, emitErr)
+		return nil
+	})
+	g.Go(func() error {
+		return pipeline.ReadMapLeaves(gctx, readLeaves, indexes, leafChunkSize,
+			func(leaves []*tpb.MapLeaf) error {
+				// Convert Trillian map leaves into indexed KT updates.
+				indexedLeaves, err := pipeline.MapMapLeaves(mapper.MapMapLeafFn, leaves)
+				if err != nil {
+					return err
+				}
+				for _, l := range indexedLeaves {
+					joiner.AddLeaf(l)
+				}
+				return nil
+			})
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// GroupByIndex.
+	joined := joiner.Rows()
+
+	// Apply mutations to values.
+	newIndexedLeaves := pipeline.Reduce(ctx, reduceFn, joined, emitErr)
+	glog.V(2).Infof("DoReduceFn reduced %v values on %v indexes", mutations, joiner.Len())
+
+	// Marshal new indexed values back into Trillian Map leaves.
+	newLeaves := pipeline.Marshal(newIndexedLeaves, emitErr)
+	// Reduce drops the rows left over when ctx is done, so don't return a
+	// partial revision.
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	return &computedRevision{leaves: newLeaves, mutations: mutations, indexes: joiner.Len()}, nil
+}
+
+// recoveryPolicy returns the policy to record in a revision defined while
+// the directory allows recovery or not.
+func recoveryPolicy(allow bool) spb.MapMetadata_RecoveryPolicy {
+	if allow {
+		return spb.MapMetadata_ALLOW_RECOVERY
+	}
+	return spb.MapMetadata_DENY_RECOVERY
+}
+
+// allowRecovery returns whether the revision defined by meta allows recovery.
+// Revisions that were defined before the policy was recorded use current, the
+// directory's current policy.
+func allowRecovery(meta *spb.MapMetadata, current bool) bool {
+	switch meta.GetRecoveryPolicy() {
+	case spb.MapMetadata_ALLOW_RECOVERY:
+		return true
+	case spb.MapMetadata_DENY_RECOVERY:
+		return false
+	default:
+		return current
+	}
+}
+
 // ApplyRevision applies the supplied mutations to the current map revision and creates a new revision.
 func (s *Server) ApplyRevision(ctx context.Context, in *spb.ApplyRevisionRequest) (*spb.ApplyRevisionResponse, error) {
 	start := time.Now()
@@ -405,61 +672,63 @@ func (s *Server) ApplyRevision(ctx context.Context, in *spb.ApplyRevisionRequest
 		return nil, status.Errorf(st.Code(), "ReadBatch(%v, %v): %v", in.DirectoryId, in.Revision, st.Message())
 	}
 	glog.Infof("ApplyRevision(): dir: %v, rev: %v, sources: %v", in.DirectoryId, in.Revision, meta)
-
-	incMetricFn := func(label string) { fnCount.Inc(in.DirectoryId, label) }
-
-	logSlices := runner.DoMapMetaFn(mapper.MapMetaFn, meta, incMetricFn)
-	logItems, err := runner.DoReadFn(ctx, s.readMessages, logSlices, in.DirectoryId, s.BatchSize, incMetricFn)
+	d, err := s.directories.Read(ctx, in.DirectoryId, false)
 	if err != nil {
 		return nil, err
 	}
 
+	pipeline := &runner.Pipeline{
+		DirectoryID: in.DirectoryId,
+		Workers:     s.ReduceWorkers,
+		Budget:      s.ReduceBudget,
+		Metrics: runner.Metrics{
+			Inc: func(label string) { fnCount.Inc(in.DirectoryId, label) },
+			Latency: func(label string, seconds float64) {
+				fnLatency.Observe(seconds, in.DirectoryId, label)
+			},
+			QueueDepth: func(label string, depth int) {
+				pipelineQueueDepth.Set(float64(depth), in.DirectoryId, label)
+			},
+		},
+	}
+
 	emitErrFn := func(err error) {
 		glog.Warning(err)
 		mutationFailures.Inc(in.DirectoryId, status.Code(err).String())
 	}
-	// Map Log Items
-	indexedValues := runner.DoMapLogItemsFn(entry.MapLogItemFn, logItems, emitErrFn, incMetricFn)
-
-	// Collect Indexes.
-	groupByIndex := make(map[string]bool)
-	for _, iv := range indexedValues {
-		groupByIndex[string(iv.Index)] = true
-	}
-	indexes := make([][]byte, 0, len(groupByIndex))
-	for i := range groupByIndex {
-		indexes = append(indexes, []byte(i))
-	}
-
-	// Read Map.
 	mapClient, err := s.trillian.MapWriteClient(ctx, in.DirectoryId)
 	if err != nil {
 		return nil, err
 	}
-	verifyLeafStart := time.Now()
-	leaves, err := mapClient.GetLeavesByRevision(ctx, in.Revision-1, indexes)
-	fnLatency.Observe(time.Since(verifyLeafStart).Seconds(), in.DirectoryId, "GetLeavesByRevision")
-	if err != nil {
-		return nil, err
-	}
 
+	// Log items are dropped once mapped; only their creation times are kept
+	// for the applied latency metric.
+	var createdMu sync.Mutex
+	createdAt := make(map[int64][]time.Time)
+	readFn := func(ctx context.Context, slice *spb.MapMetadata_SourceSlice, directoryID string,
+		chunkSize int32, emit func(*mutator.LogMessage)) error {
+		var created []time.Time
+		err := s.readMessages(ctx, slice, directoryID, chunkSize, func(m *mutator.LogMessage) {
+			created = append(created, m.CreatedAt)
+			emit(m)
+		})
+		createdMu.Lock()
+		defer createdMu.Unlock()
+		createdAt[slice.LogId] = append(createdAt[slice.LogId], created...)
+		return err
+	}
+	readLeaves := func(ctx context.Context, indexes [][]byte) ([]*tpb.MapLeaf, error) {
+		return mapClient.GetLeavesByRevision(ctx, in.Revision-1, indexes)
+	}
 	computeStart := time.Now()
-	// Convert Trillian map leaves into indexed KT updates.
-	indexedLeaves, err := runner.DoMapMapLeafFn(mapper.MapMapLeafFn, leaves, incMetricFn)
+	rev, err := computeRevision(ctx, pipeline, meta, readFn, readLeaves,
+		entry.NewReduceFn(allowRecovery(meta, d.AllowRecovery)),
+		s.BatchSize, s.MapLeafChunkSize, emitErrFn)
+	fnLatency.Observe(time.Since(computeStart).Seconds(), in.DirectoryId, "ProcessMutations")
 	if err != nil {
 		return nil, err
 	}
-
-	// GroupByIndex.
-	joined := runner.Join(indexedLeaves, indexedValues, incMetricFn)
-
-	// Apply mutations to values.
-	newIndexedLeaves := runner.DoReduceFn(entry.ReduceFn, joined, emitErrFn, incMetricFn)
-	glog.V(2).Infof("DoReduceFn reduced %v values on %v indexes", len(indexedValues), len(joined))
-
-	// Marshal new indexed values back into Trillian Map leaves.
-	newLeaves := runner.DoMarshalIndexedValues(newIndexedLeaves, emitErrFn, incMetricFn)
-	fnLatency.Observe(time.Since(computeStart).Seconds(), in.DirectoryId, "ProcessMutations")
+	newLeaves := rev.leaves
 
 	// Serialize metadata
 	serializedMeta, err := proto.Marshal(meta)
@@ -477,8 +746,12 @@ func (s *Server) ApplyRevision(ctx context.Context, in *spb.ApplyRevisionRequest
 	glog.V(2).Infof("CreateRevision: WriteLeaves:{Revision: %v}", in.Revision)
 
 	writtenAt := time.Now()
-	for _, li := range logItems {
-		appliedLatency.Observe(writtenAt.Sub(li.CreatedAt).Seconds(), in.DirectoryId, strconv.FormatInt(li.LogID, 10))
+	var logItemCount int
+	for logID, created := range createdAt {
+		logItemCount += len(created)
+		for _, c := range created {
+			appliedLatency.Observe(writtenAt.Sub(c).Seconds(), in.DirectoryId, strconv.FormatInt(logID, 10))
+		}
 	}
 
 	for _, s := range meta.Sources {
@@ -487,15 +760,29 @@ func (s *Server) ApplyRevision(ctx context.Context, in *spb.ApplyRevisionRequest
 	mapLeafCount.Add(float64(len(newLeaves)), in.DirectoryId)
 	mapRevisionCount.Inc(in.DirectoryId)
 	glog.Infof("ApplyRevision(): dir: %v, rev: %v, mutations: %v, indexes: %v, newleaves: %v",
-		in.DirectoryId, in.Revision, len(logItems), len(indexes), len(newLeaves))
+		in.DirectoryId, in.Revision, logItemCount, rev.indexes, len(newLeaves))
 	return &spb.ApplyRevisionResponse{
 		DirectoryId: in.DirectoryId,
 		Revision:    in.Revision,
-		Mutations:   int64(len(indexedValues)),
+		Mutations:   rev.mutations,
 		MapLeaves:   int64(len(newLeaves)),
 	}, nil
 }
 
+// GetSequencingStatus returns the sequencing phase and last error of the
+// directories this server is master for.
+func (s *Server) GetSequencingStatus(ctx context.Context,
+	in *spb.GetSequencingStatusRequest) (*spb.GetSequencingStatusResponse, error) {
+	if s.Scheduler == nil {
+		return nil, status.Errorf(codes.Unimplemented, "sequencing does not run in this server")
+	}
+	dirs, err := s.Scheduler.Status(in.GetDirectoryId())
+	if err != nil {
+		return nil, err
+	}
+	return &spb.GetSequencingStatusResponse{Directories: dirs}, nil
+}
+
 // PublishRevisions copies the MapRoots of all known map revisions into the Log of MapRoots.
 func (s *Server) PublishRevisions(ctx context.Context,
 	in *spb.PublishRevisionsRequest) (*spb.PublishRevisionsResponse, error) {
@@ -553,6 +840,89 @@ func (s *Server) PublishRevisions(ctx context.Context,
 	return &spb.PublishRevisionsResponse{Revisions: revs}, nil
 }
 
+// PruneRevisions deletes the mutations of revisions that are older than the
+// retention policy of the directory allows. Only revisions that have been
+// applied and published are pruned, and at most PruneBatchSize revisions are
+// pruned per call. Expired request IDs are deleted whatever the policy.
+// Pruning is fenced by in.FencingToken.
+func (s *Server) PruneRevisions(ctx context.Context, in *spb.PruneRevisionsRequest) (*spb.PruneRevisionsResponse, error) {
+	ctx = election.WithToken(ctx, in.FencingToken)
+	d, err := s.directories.Read(ctx, in.DirectoryId, false)
+	if err != nil {
+		return nil, err
+	}
+	expired, err := s.pruner.ExpireRequests(ctx, in.DirectoryId)
+	if err != nil {
+		return nil, err
+	}
+	if expired > 0 {
+		glog.V(2).Infof("PruneRevisions: expired %v request ids of %v", expired, in.DirectoryId)
+	}
+	pruned, err := s.pruner.PrunedRevision(ctx, in.DirectoryId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &spb.PruneRevisionsResponse{DirectoryId: in.DirectoryId, PrunedRevision: pruned}
+	keepRevisions := d.Retention.GetRevisions()
+	var maxAge time.Duration
+	if d.Retention.GetMaxAge() != nil {
+		if maxAge, err = ptypes.Duration(d.Retention.GetMaxAge()); err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid max_age: %v", err)
+		}
+	}
+	if keepRevisions <= 0 && maxAge <= 0 {
+		return resp, nil // Keep all mutations.
+	}
+
+	// Only prune revisions that verifiers have been able to see.
+	logClient, err := s.trillian.LogClient(ctx, in.DirectoryId)
+	if err != nil {
+		return nil, err
+	}
+	logRoot, err := logClient.UpdateRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mapClient, err := s.trillian.MapClient(ctx, in.DirectoryId)
+	if err != nil {
+		return nil, err
+	}
+	// The log of map roots holds revisions 0 to TreeSize-1.
+	last := int64(logRoot.TreeSize) - 1 - keepRevisions
+	if max := pruned + s.PruneBatchSize; last > max {
+		last = max
+	}
+	cutoff := time.Now().Add(-maxAge)
+	for rev := pruned + 1; rev <= last; rev++ {
+		if maxAge > 0 {
+			_, mapRoot, err := mapClient.GetAndVerifyMapRootByRevision(ctx, rev)
+			if err != nil {
+				return nil, err
+			}
+			if time.Unix(0, int64(mapRoot.TimestampNanos)).After(cutoff) {
+				break
+			}
+		}
+		meta, err := s.batcher.ReadBatch(ctx, in.DirectoryId, rev)
+		if err != nil {
+			return nil, err
+		}
+		n, err := s.pruner.PruneRevision(ctx, in.DirectoryId, rev, meta)
+		if err != nil {
+			return nil, err
+		}
+		resp.PrunedRevision = rev
+		resp.Mutations += n
+	}
+	prunedRevision.Set(float64(resp.PrunedRevision), in.DirectoryId)
+	mutationsPruned.Add(float64(resp.Mutations), in.DirectoryId)
+	if resp.PrunedRevision > pruned {
+		glog.Infof("PruneRevisions: pruned %v mutations in revisions [%d, %d]",
+			resp.Mutations, pruned+1, resp.PrunedRevision)
+	}
+	return resp, nil
+}
+
 // HighWatermarks returns the total count across all logs and the highest watermark for each log.
 // batchSize is a limit on the total number of items represented by the returned watermarks.
 // TODO(gbelvin): Block until a minBatchSize has been reached or a timeout has occurred.
@@ -581,6 +951,18 @@ func (s *Server) HighWatermarks(ctx context.Context, directoryID string, lastMet
 	if err != nil {
 		return 0, nil, err
 	}
+	// Retired logs are sealed and fully applied, so new revisions do not
+	// need to track them.
+	inputLogs, err := s.inputLogs(ctx, directoryID)
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, l := range inputLogs {
+		if l.State == pb.InputLog_RETIRED {
+			delete(ends, l.LogId)
+			delete(starts, l.LogId)
+		}
+	}
 	// TODO(gbelvin): Get HighWatermarks in parallel.
 	for _, logID := range logIDs {
 		low := ends[logID]
@@ -18,13 +18,20 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/types"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/fake"
+	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/sequencer/mapper"
 	"github.com/google/keytransparency/core/sequencer/metadata"
 	"github.com/google/keytransparency/core/sequencer/runner"
@@ -40,8 +47,6 @@ const directoryID = "directoryID"
 
 var zero = water.Mark{}
 
-func fakeMetric(_ string) {}
-
 func init() {
 	initMetrics.Do(func() { createMetrics(monitoring.InertMetricFactory{}) })
 }
@@ -65,12 +70,67 @@ func (t *fakeTrillianFactory) LogClient(_ context.Context, _ string) (trillianLo
 type fakeMap struct {
 	MapClient
 	latestMapRoot *types.MapRootV1
+	roots         map[int64]*types.MapRootV1
 }
 
 func (m *fakeMap) GetAndVerifyLatestMapRoot(_ context.Context) (*tpb.SignedMapRoot, *types.MapRootV1, error) {
 	return nil, m.latestMapRoot, nil
 }
 
+func (m *fakeMap) GetAndVerifyMapRootByRevision(_ context.Context, rev int64) (*tpb.SignedMapRoot, *types.MapRootV1, error) {
+	root, ok := m.roots[rev]
+	if !ok {
+		return nil, nil, fmt.Errorf("map root %v not found", rev)
+	}
+	return nil, root, nil
+}
+
+type fakeLog struct {
+	trillianLog
+	treeSize uint64
+}
+
+func (l *fakeLog) UpdateRoot(_ context.Context) (*types.LogRootV1, error) {
+	return &types.LogRootV1{TreeSize: l.treeSize}, nil
+}
+
+type fakePruner struct {
+	pruned  int64
+	revs    []int64
+	perRev  int64
+	expired int // Calls to ExpireRequests.
+}
+
+func (p *fakePruner) PruneRevision(_ context.Context, _ string, rev int64, _ *spb.MapMetadata) (int64, error) {
+	p.revs = append(p.revs, rev)
+	p.pruned = rev
+	return p.perRev, nil
+}
+func (p *fakePruner) PrunedRevision(_ context.Context, _ string) (int64, error) {
+	return p.pruned, nil
+}
+func (p *fakePruner) ExpireRequests(_ context.Context, _ string) (int64, error) {
+	p.expired++
+	return 0, nil
+}
+
+type fakeLifecycle struct {
+	logs    []*pb.InputLog
+	pending map[int64]bool
+	sealed  map[int64]water.Mark
+}
+
+func (l *fakeLifecycle) ListInputLogs(_ context.Context, _ string) ([]*pb.InputLog, error) {
+	return l.logs, nil
+}
+func (l *fakeLifecycle) SealLog(_ context.Context, dirID string, logID int64, final water.Mark) error {
+	if l.pending[logID] {
+		return status.Errorf(codes.FailedPrecondition, "log %v/%v has unapplied mutations", dirID, logID)
+	}
+	l.sealed[logID] = final
+	return nil
+}
+
 type fakeWrite struct{}
 
 func (m *fakeWrite) GetLeavesByRevision(ctx context.Context, in *tpb.GetMapLeavesByRevisionRequest, opts ...grpc.CallOption) (*tpb.MapLeaves, error) {
@@ -100,9 +160,9 @@ func (b *fakeBatcher) ReadBatch(_ context.Context, _ string, rev int64) (*spb.Ma
 	return meta, nil
 }
 
-func setupLogs(ctx context.Context, t *testing.T, dirID string, logLengths map[int64]int) (memory.MutationLogs, map[int64][]water.Mark) {
+func setupLogs(ctx context.Context, t *testing.T, dirID string, logLengths map[int64]int) (*memory.Mutations, map[int64][]water.Mark) {
 	t.Helper()
-	fakeLogs := memory.NewMutationLogs()
+	fakeLogs := memory.NewMutations()
 	idx := make(map[int64][]water.Mark)
 	for logID, msgs := range logLengths {
 		if err := fakeLogs.AddLogs(ctx, dirID, logID); err != nil {
@@ -127,10 +187,14 @@ func TestDefiningRevisions(t *testing.T) {
 	// Verify that outstanding revisions prevent future revisions from being created.
 	ctx := context.Background()
 	mapRev := int64(2)
-	dirID := "foobar"
-	fakeLogs, idx := setupLogs(ctx, t, dirID, map[int64]int{0: 10, 1: 20})
+	fakeLogs, idx := setupLogs(ctx, t, directoryID, map[int64]int{0: 10, 1: 20})
+	directories := fake.NewDirectoryStorage()
+	if err := directories.Write(ctx, &directory.Directory{DirectoryID: directoryID, AllowRecovery: true}); err != nil {
+		t.Fatalf("directories.Write(): %v", err)
+	}
 	s := Server{
-		logs: fakeLogs,
+		logs:        fakeLogs,
+		directories: directories,
 		trillian: &fakeTrillianFactory{
 			tmap: &fakeMap{latestMapRoot: &types.MapRootV1{Revision: uint64(mapRev)}},
 		},
@@ -193,14 +257,24 @@ func TestDefiningRevisions(t *testing.T) {
 			if got, want := drResp, drWant; !proto.Equal(got, want) {
 				t.Errorf("DefineRevisions(): %v, want %v", got, want)
 			}
+			if tc.wantNew == tc.highestRev {
+				return
+			}
+			// New revisions record the directory's recovery policy.
+			meta, err := s.batcher.ReadBatch(ctx, directoryID, tc.wantNew)
+			if err != nil {
+				t.Fatalf("ReadBatch(): %v", err)
+			}
+			if got, want := meta.RecoveryPolicy, spb.MapMetadata_ALLOW_RECOVERY; got != want {
+				t.Errorf("RecoveryPolicy: %v, want %v", got, want)
+			}
 		})
 	}
 }
 
 func TestReadMessages(t *testing.T) {
 	ctx := context.Background()
-	dirID := "TestReadMessages"
-	fakeLogs, idx := setupLogs(ctx, t, dirID, map[int64]int{0: 10, 1: 20})
+	fakeLogs, idx := setupLogs(ctx, t, directoryID, map[int64]int{0: 10, 1: 20})
 	s := Server{logs: fakeLogs}
 
 	for _, tc := range []struct {
@@ -219,11 +293,13 @@ func TestReadMessages(t *testing.T) {
 			newSource(1, idx[1][1], idx[1][10].Add(1)),
 		}}},
 	} {
-		logSlices := runner.DoMapMetaFn(mapper.MapMetaFn, tc.meta, fakeMetric)
-		logItems, err := runner.DoReadFn(ctx, s.readMessages, logSlices, directoryID, tc.batchSize, fakeMetric)
-		if err != nil {
+		p := &runner.Pipeline{DirectoryID: directoryID}
+		logSlices := p.MapMeta(mapper.MapMetaFn, tc.meta)
+		logItems := make(chan *mutator.LogMessage, 100)
+		if err := p.Read(ctx, s.readMessages, logSlices, tc.batchSize, logItems); err != nil {
 			t.Errorf("readMessages(): %v", err)
 		}
+		close(logItems)
 		if got := len(logItems); got != tc.want {
 			t.Errorf("readMessages(%v): len: %v, want %v", tc.meta, got, tc.want)
 		}
@@ -232,14 +308,14 @@ func TestReadMessages(t *testing.T) {
 
 func TestHighWatermarks(t *testing.T) {
 	ctx := context.Background()
-	dirID := "TestHighWatermark"
-	fakeLogs, idx := setupLogs(ctx, t, dirID, map[int64]int{0: 10, 1: 20})
+	fakeLogs, idx := setupLogs(ctx, t, directoryID, map[int64]int{0: 10, 1: 20})
 	s := Server{logs: fakeLogs}
 
 	for _, tc := range []struct {
 		desc      string
 		batchSize int32
 		count     int32
+		lifecycle LogLifecycle
 		last      *spb.MapMetadata
 		next      *spb.MapMetadata
 	}{
@@ -281,8 +357,21 @@ func TestHighWatermarks(t *testing.T) {
 				newSource(1, zero, zero),
 				newSource(3, water.NewMark(10), water.NewMark(10)),
 			}}},
+		{desc: "retired logs", batchSize: 0, count: 0,
+			lifecycle: &fakeLifecycle{logs: []*pb.InputLog{
+				{LogId: 0, Writable: true},
+				{LogId: 3, State: pb.InputLog_RETIRED},
+			}},
+			last: &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+				newSource(3, zero, water.NewMark(10)),
+			}},
+			next: &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+				newSource(0, zero, zero),
+				newSource(1, zero, zero),
+			}}},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
+			s.lifecycle = tc.lifecycle
 			count, next, err := s.HighWatermarks(ctx, directoryID, tc.last, tc.batchSize)
 			if err != nil {
 				t.Fatalf("HighWatermarks(): %v", err)
@@ -296,3 +385,207 @@ func TestHighWatermarks(t *testing.T) {
 		})
 	}
 }
+
+func TestSealDrainedLogs(t *testing.T) {
+	ctx := context.Background()
+	meta, err := proto.Marshal(&spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+		newSource(1, zero, water.NewMark(10)),
+		newSource(1, water.NewMark(10), water.NewMark(20)),
+		newSource(2, zero, water.NewMark(5)),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := &fakeLifecycle{
+		logs: []*pb.InputLog{
+			{LogId: 0, Writable: true},
+			{LogId: 1},
+			{LogId: 2},
+			{LogId: 3},
+			{LogId: 4, State: pb.InputLog_SEALED},
+			{LogId: 5, State: pb.InputLog_RETIRED},
+		},
+		pending: map[int64]bool{2: true},
+		sealed:  make(map[int64]water.Mark),
+	}
+	s := Server{
+		trillian: &fakeTrillianFactory{
+			tmap: &fakeMap{latestMapRoot: &types.MapRootV1{Metadata: meta}},
+		},
+		lifecycle: lifecycle,
+	}
+	if err := s.sealDrainedLogs(ctx, directoryID); err != nil {
+		t.Fatalf("sealDrainedLogs(): %v", err)
+	}
+	// Log 2 has unapplied mutations. Log 3 was never applied.
+	want := map[int64]water.Mark{1: water.NewMark(20), 3: zero}
+	if !cmp.Equal(lifecycle.sealed, want, cmp.AllowUnexported(water.Mark{})) {
+		t.Errorf("sealDrainedLogs(): sealed %v, want %v", lifecycle.sealed, want)
+	}
+}
+
+func TestPruneRevisions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	// Revisions 0 to 9 have been published, one per hour, ending now.
+	roots := make(map[int64]*types.MapRootV1)
+	batches := make(map[int64]*spb.MapMetadata)
+	for rev := int64(0); rev < 10; rev++ {
+		ts := now.Add(time.Duration(rev-9) * time.Hour)
+		roots[rev] = &types.MapRootV1{Revision: uint64(rev), TimestampNanos: uint64(ts.UnixNano())}
+		batches[rev] = &spb.MapMetadata{}
+	}
+	for _, tc := range []struct {
+		desc       string
+		retention  *pb.RetentionPolicy
+		pruned     int64
+		batchSize  int64
+		wantRevs   []int64
+		wantPruned int64
+	}{
+		{desc: "no policy", batchSize: 100},
+		{desc: "empty policy", retention: &pb.RetentionPolicy{}, batchSize: 100},
+		{desc: "revisions", retention: &pb.RetentionPolicy{Revisions: 6}, batchSize: 100,
+			wantRevs: []int64{1, 2, 3}, wantPruned: 3},
+		{desc: "revisions already pruned", retention: &pb.RetentionPolicy{Revisions: 6}, pruned: 3, batchSize: 100,
+			wantPruned: 3},
+		{desc: "max age", retention: &pb.RetentionPolicy{MaxAge: ptypes.DurationProto(90 * time.Minute)}, batchSize: 100,
+			wantRevs: []int64{1, 2, 3, 4, 5, 6, 7}, wantPruned: 7},
+		{desc: "both", retention: &pb.RetentionPolicy{Revisions: 5, MaxAge: ptypes.DurationProto(90 * time.Minute)}, batchSize: 100,
+			wantRevs: []int64{1, 2, 3, 4}, wantPruned: 4},
+		{desc: "batch size", retention: &pb.RetentionPolicy{Revisions: 1}, pruned: 2, batchSize: 3,
+			wantRevs: []int64{3, 4, 5}, wantPruned: 5},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			directories := fake.NewDirectoryStorage()
+			if err := directories.Write(ctx, &directory.Directory{DirectoryID: directoryID, Retention: tc.retention}); err != nil {
+				t.Fatalf("Write(): %v", err)
+			}
+			pruner := &fakePruner{pruned: tc.pruned, perRev: 2}
+			s := Server{
+				directories: directories,
+				trillian: &fakeTrillianFactory{
+					tmap: &fakeMap{roots: roots},
+					tlog: &fakeLog{treeSize: 10},
+				},
+				batcher:        &fakeBatcher{batches: batches},
+				pruner:         pruner,
+				PruneBatchSize: tc.batchSize,
+			}
+			resp, err := s.PruneRevisions(ctx, &spb.PruneRevisionsRequest{DirectoryId: directoryID})
+			if err != nil {
+				t.Fatalf("PruneRevisions(): %v", err)
+			}
+			if got, want := resp.PrunedRevision, tc.wantPruned; got != want {
+				t.Errorf("PruneRevisions().PrunedRevision: %v, want %v", got, want)
+			}
+			if got, want := resp.Mutations, 2*int64(len(tc.wantRevs)); got != want {
+				t.Errorf("PruneRevisions().Mutations: %v, want %v", got, want)
+			}
+			if !cmp.Equal(pruner.revs, tc.wantRevs) {
+				t.Errorf("pruned revisions: %v, want %v", pruner.revs, tc.wantRevs)
+			}
+			if pruner.expired != 1 {
+				t.Errorf("ExpireRequests() called %v times, want 1", pruner.expired)
+			}
+		})
+	}
+}
+
+func TestDefineRevisionsMaxMutations(t *testing.T) {
+	ctx := context.Background()
+	fakeLogs, _ := setupLogs(ctx, t, directoryID, map[int64]int{0: 10})
+	directories := fake.NewDirectoryStorage()
+	if err := directories.Write(ctx, &directory.Directory{DirectoryID: directoryID}); err != nil {
+		t.Fatalf("directories.Write(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc      string
+		max       int
+		maxBatch  int32
+		minBatch  int32
+		wantCount int
+	}{
+		{desc: "under max", max: 5, maxBatch: 3, minBatch: 1, wantCount: 3},
+		{desc: "capped", max: 2, maxBatch: 10, minBatch: 1, wantCount: 2},
+		{desc: "min above max", max: 2, maxBatch: 10, minBatch: 5, wantCount: 2},
+		{desc: "unbounded", maxBatch: 10, minBatch: 1, wantCount: 10},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			batcher := &fakeBatcher{batches: map[int64]*spb.MapMetadata{0: {}}}
+			s := Server{
+				logs:                 fakeLogs,
+				batcher:              batcher,
+				directories:          directories,
+				trillian:             &fakeTrillianFactory{tmap: &fakeMap{latestMapRoot: &types.MapRootV1{}}},
+				MaxRevisionMutations: tc.max,
+			}
+			if _, err := s.DefineRevisions(ctx, &spb.DefineRevisionsRequest{
+				DirectoryId: directoryID,
+				MinBatch:    tc.minBatch,
+				MaxBatch:    tc.maxBatch,
+			}); err != nil {
+				t.Fatalf("DefineRevisions(): %v", err)
+			}
+			meta, ok := batcher.batches[1]
+			if !ok {
+				t.Fatalf("DefineRevisions() did not define revision 1")
+			}
+			var count int
+			for _, source := range meta.Sources {
+				slice := metadata.FromProto(source)
+				msgs, err := fakeLogs.ReadLog(ctx, directoryID, source.LogId, slice.LowMark(), slice.HighMark(), 100)
+				if err != nil {
+					t.Fatalf("ReadLog(): %v", err)
+				}
+				count += len(msgs)
+			}
+			if count != tc.wantCount {
+				t.Errorf("revision 1 has %v mutations, want %v", count, tc.wantCount)
+			}
+		})
+	}
+}
+
+// A revision defined before MaxRevisionMutations was lowered is still applied.
+func TestApplyRevisionOverMaxMutations(t *testing.T) {
+	ctx := context.Background()
+	env, tree := newMapEnv(ctx, t)
+	defer env.Close()
+	logs := memory.NewMutations()
+	if err := logs.AddLogs(ctx, directoryID, 0); err != nil {
+		t.Fatal(err)
+	}
+	low, err := logs.Send(ctx, directoryID, 0, newUpdate(t, "alice"), newUpdate(t, "bob"))
+	if err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+	meta := &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{newSource(0, low, low.Add(1))}}
+	if err := logs.WriteBatchSources(ctx, directoryID, 1, meta); err != nil {
+		t.Fatalf("WriteBatchSources(): %v", err)
+	}
+	directories := fake.NewDirectoryStorage()
+	if err := directories.Write(ctx, &directory.Directory{DirectoryID: directoryID}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		directories:          directories,
+		trillian:             &fakeTrillianFactory{twrite: &MapWriteClient{MapID: tree.TreeId, twrite: env.Write}},
+		batcher:              logs,
+		logs:                 logs,
+		BatchSize:            10,
+		MapLeafChunkSize:     10,
+		MaxRevisionMutations: 1,
+	}
+	resp, err := s.ApplyRevision(ctx, &spb.ApplyRevisionRequest{DirectoryId: directoryID, Revision: 1})
+	if err != nil {
+		t.Fatalf("ApplyRevision(): %v", err)
+	}
+	if got, want := resp.Mutations, int64(2); got != want {
+		t.Errorf("ApplyRevision(): %v mutations, want %v", got, want)
+	}
+	if got, want := resp.MapLeaves, int64(2); got != want {
+		t.Errorf("ApplyRevision(): %v map leaves, want %v", got, want)
+	}
+}
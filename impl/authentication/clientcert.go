@@ -0,0 +1,105 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authentication
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ClientCertAuth authenticates callers by the TLS client certificate they
+// presented. The identity of a certificate is its first SAN email address, or
+// its subject common name if it has none.
+type ClientCertAuth struct {
+	roots *x509.CertPool
+}
+
+// NewClientCertAuth returns an authenticator that accepts client certificates
+// issued by the CAs in the PEM encoded caFile.
+func NewClientCertAuth(caFile string) (*ClientCertAuth, error) {
+	b, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("auth: no certificates found in %v", caFile)
+	}
+	return &ClientCertAuth{roots: roots}, nil
+}
+
+// ClientCAs returns the pool of CAs trusted to issue client certificates.
+func (a *ClientCertAuth) ClientCAs() *x509.CertPool {
+	return a.roots
+}
+
+// AuthFunc authenticates the client certificate of the connection in ctx.
+func (a *ClientCertAuth) AuthFunc(ctx context.Context) (context.Context, error) {
+	identity, err := a.verify(ctx)
+	if err != nil {
+		glog.V(2).Infof("Failed auth: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "auth: invalid client certificate")
+	}
+	return context.WithValue(ctx, securityContextKey, &SecurityContext{
+		Email: identity,
+	}), nil
+}
+
+// verify checks the peer's certificate chain and returns its identity.
+// The chain is verified here, rather than relying on the TLS listener, so that
+// connections accepted without a client certificate are never authenticated.
+func (a *ClientCertAuth) verify(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", errors.New("no peer")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return "", errors.New("not a TLS connection")
+	}
+	certs := tlsInfo.State.PeerCertificates
+	if len(certs) == 0 {
+		return "", errors.New("no client certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         a.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return "", err
+	}
+
+	switch leaf := certs[0]; {
+	case len(leaf.EmailAddresses) > 0:
+		return leaf.EmailAddresses[0], nil
+	case leaf.Subject.CommonName != "":
+		return leaf.Subject.CommonName, nil
+	default:
+		return "", errors.New("certificate has no email address or common name")
+	}
+}
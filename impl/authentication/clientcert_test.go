@@ -0,0 +1,161 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authentication
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a leaf certificate signed by ca.
+func (ca *testCA) issue(t *testing.T, cn string, emails []string, usage x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		Subject:        pkix.Name{CommonName: cn},
+		EmailAddresses: emails,
+		NotBefore:      time.Now().Add(-time.Hour),
+		NotAfter:       time.Now().Add(time.Hour),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, key.Public(), ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	return cert
+}
+
+func peerCtx(certs ...*x509.Certificate) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr:     &net.TCPAddr{},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: certs}},
+	})
+}
+
+func TestClientCertAuthFunc(t *testing.T) {
+	ca := newTestCA(t)
+	other := newTestCA(t)
+
+	dir, err := ioutil.TempDir("", "clientcert")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	a, err := NewClientCertAuth(caFile)
+	if err != nil {
+		t.Fatalf("NewClientCertAuth(): %v", err)
+	}
+
+	for _, tc := range []struct {
+		desc string
+		ctx  context.Context
+		want string // Empty if authentication should fail.
+	}{
+		{desc: "email", want: "svc@example.com",
+			ctx: peerCtx(ca.issue(t, "svc", []string{"svc@example.com"}, x509.ExtKeyUsageClientAuth))},
+		{desc: "common name", want: "updater.example.com",
+			ctx: peerCtx(ca.issue(t, "updater.example.com", nil, x509.ExtKeyUsageClientAuth))},
+		{desc: "no identity",
+			ctx: peerCtx(ca.issue(t, "", nil, x509.ExtKeyUsageClientAuth))},
+		{desc: "server certificate",
+			ctx: peerCtx(ca.issue(t, "svc", nil, x509.ExtKeyUsageServerAuth))},
+		{desc: "untrusted CA",
+			ctx: peerCtx(other.issue(t, "svc", []string{"svc@example.com"}, x509.ExtKeyUsageClientAuth))},
+		{desc: "no certificate", ctx: peerCtx()},
+		{desc: "no peer", ctx: context.Background()},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			sctx, err := a.AuthFunc(tc.ctx)
+			if tc.want == "" {
+				if got, want := status.Code(err), codes.Unauthenticated; got != want {
+					t.Fatalf("AuthFunc(): %v, want code %v", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthFunc(): %v", err)
+			}
+			validated, ok := FromContext(sctx)
+			if !ok {
+				t.Fatalf("FromContext(): no SecurityContext found")
+			}
+			if got := validated.Email; got != tc.want {
+				t.Errorf("Email: %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@
 // Key Transparency is designed to be used by identity providers -
 // IdP in OAuth parlance.  OAuth2 Access Tokens may be provided as
 // authentication information, which can be resolved to user information and
-// associated scopes on the backend.
+// associated scopes on the backend. OpenID Connect ID tokens and TLS client
+// certificates are also supported for deployments with their own IdP or with
+// service identities.
 package authentication
 
 import "context"
@@ -27,6 +29,14 @@ type SecurityContext struct {
 	Email string
 }
 
+// GetEmail returns the authenticated email address, or "" if s is nil.
+func (s *SecurityContext) GetEmail() string {
+	if s == nil {
+		return ""
+	}
+	return s.Email
+}
+
 // securityContextKey identifies SecurityContext within context.Context.
 var securityContextKey struct{}
 
@@ -0,0 +1,446 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"golang.org/x/sync/singleflight"
+
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+)
+
+// minKeyRefresh limits how often the JWKS is read after the first time,
+// whether or not reading it succeeds, so that tokens with unknown key IDs
+// cannot be used to flood the issuer with requests.
+const minKeyRefresh = time.Minute
+
+// OIDCConfig configures an OIDCAuth.
+type OIDCConfig struct {
+	// Issuer must match the iss claim of ID tokens.
+	Issuer string
+	// Audience must be one of the aud claims of ID tokens.
+	Audience string
+	// JWKS is the path or http(s) URL of the issuer's JSON Web Key Set.
+	JWKS string
+	// KeyCacheTTL is how long keys are cached before the JWKS is read again.
+	KeyCacheTTL time.Duration
+	// ClockSkew is the tolerance allowed when checking exp and nbf claims.
+	ClockSkew time.Duration
+}
+
+// OIDCAuth authenticates users with OpenID Connect ID tokens. Tokens are
+// verified locally against the issuer's signing keys.
+type OIDCAuth struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+	// refresh coalesces concurrent reads of the JWKS.
+	refresh singleflight.Group
+
+	mu      sync.Mutex
+	keys    map[string]*jwk // Keyed by kid.
+	fetched time.Time
+	// attempted is the time of the last attempt to read the JWKS.
+	attempted time.Time
+}
+
+// NewOIDCAuth creates a new authenticator for ID tokens issued by config.Issuer.
+// The JWKS is read once to check that it is reachable and valid.
+func NewOIDCAuth(ctx context.Context, config OIDCConfig) (*OIDCAuth, error) {
+	if config.Issuer == "" || config.Audience == "" || config.JWKS == "" {
+		return nil, errors.New("auth: issuer, audience and JWKS are required")
+	}
+	a := &OIDCAuth{
+		config: config,
+		client: http.DefaultClient,
+		now:    time.Now,
+	}
+	if err := a.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// AuthFunc authenticates the ID token present in ctx.
+func (a *OIDCAuth) AuthFunc(ctx context.Context) (context.Context, error) {
+	token, err := grpc_auth.AuthFromMD(ctx, "bearer")
+	if err != nil {
+		return nil, err
+	}
+	claims, err := a.verify(ctx, token)
+	if err != nil {
+		glog.V(2).Infof("Failed auth: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "auth: invalid token")
+	}
+	return context.WithValue(ctx, securityContextKey, &SecurityContext{
+		Email: claims.Email,
+	}), nil
+}
+
+// jwtHeader is the JOSE header of a JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// idClaims are the ID token claims checked by OIDCAuth.
+type idClaims struct {
+	Issuer        string   `json:"iss"`
+	Audience      audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	NotBefore     int64    `json:"nbf"`
+	Email         string   `json:"email"`
+	EmailVerified boolish  `json:"email_verified"`
+}
+
+// audience is the aud claim, which is either a string or a list of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*a = l
+	return nil
+}
+
+// boolish is a boolean claim that some issuers encode as a string.
+type boolish bool
+
+func (b *boolish) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", `"true"`:
+		*b = true
+	case "false", `"false"`, "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// verify checks the signature and claims of a compact serialized JWT.
+func (a *OIDCAuth) verify(ctx context.Context, token string) (*idClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %v", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %v", err)
+	}
+	key, err := a.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := key.verify(header.Alg, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	var claims idClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %v", err)
+	}
+	now := a.now()
+	switch {
+	case claims.Issuer != a.config.Issuer:
+		return nil, fmt.Errorf("issuer %q, want %q", claims.Issuer, a.config.Issuer)
+	case !claims.Audience.contains(a.config.Audience):
+		return nil, fmt.Errorf("audience %q, want %q", claims.Audience, a.config.Audience)
+	case claims.Expiry == 0 || now.After(time.Unix(claims.Expiry, 0).Add(a.config.ClockSkew)):
+		return nil, errors.New("token expired")
+	case claims.NotBefore != 0 && now.Add(a.config.ClockSkew).Before(time.Unix(claims.NotBefore, 0)):
+		return nil, errors.New("token not yet valid")
+	case claims.Email == "":
+		return nil, errors.New("missing email")
+	case !bool(claims.EmailVerified):
+		return nil, errors.New("unverified email address")
+	}
+	return &claims, nil
+}
+
+func (a audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// key returns the signing key identified by kid. The JWKS is read again if
+// the cached keys are stale, or if kid is unknown, which allows issuers to
+// rotate keys. Reads are limited to one per minKeyRefresh.
+func (a *OIDCAuth) key(ctx context.Context, kid string) (*jwk, error) {
+	a.mu.Lock()
+	k, ok := a.keys[kid]
+	now := a.now()
+	stale := a.config.KeyCacheTTL > 0 && now.Sub(a.fetched) > a.config.KeyCacheTTL
+	due := now.Sub(a.attempted) > minKeyRefresh
+	a.mu.Unlock()
+
+	if (!ok || stale) && due {
+		_, err, _ := a.refresh.Do("jwks", func() (interface{}, error) {
+			return nil, a.maybeRefreshKeys(ctx)
+		})
+		if err != nil {
+			glog.Errorf("Failed to refresh JWKS %v: %v", a.config.JWKS, err)
+		}
+		a.mu.Lock()
+		k, ok = a.keys[kid]
+		a.mu.Unlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return k, nil
+}
+
+// maybeRefreshKeys calls refreshKeys unless the JWKS was read less than
+// minKeyRefresh ago, by a call that finished just before this one started.
+func (a *OIDCAuth) maybeRefreshKeys(ctx context.Context) error {
+	a.mu.Lock()
+	due := a.now().Sub(a.attempted) > minKeyRefresh
+	a.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return a.refreshKeys(ctx)
+}
+
+// refreshKeys reads the JWKS and replaces the cached keys.
+func (a *OIDCAuth) refreshKeys(ctx context.Context) error {
+	a.mu.Lock()
+	a.attempted = a.now()
+	a.mu.Unlock()
+	b, err := a.readJWKS(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return fmt.Errorf("auth: parsing JWKS %v: %v", a.config.JWKS, err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.keys = keys
+	a.fetched = a.now()
+	return nil
+}
+
+func (a *OIDCAuth) readJWKS(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(a.config.JWKS, "https://") && !strings.HasPrefix(a.config.JWKS, "http://") {
+		return ioutil.ReadFile(a.config.JWKS)
+	}
+	req, err := http.NewRequest(http.MethodGet, a.config.JWKS, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth: fetching JWKS %v: %v", a.config.JWKS, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// jwk is a public signing key from a JSON Web Key Set.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	pub crypto.PublicKey
+}
+
+// parseJWKS returns the signing keys in a JSON Web Key Set, keyed by kid.
+// Keys of unsupported types are ignored.
+func parseJWKS(b []byte) (map[string]*jwk, error) {
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*jwk)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.Kid, err)
+		}
+		if pub == nil {
+			continue
+		}
+		k.pub = pub
+		keys[k.Kid] = k
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// verify checks sig over signed using algorithm alg.
+func (k *jwk) verify(alg string, signed, sig []byte) error {
+	if k.Alg != "" && k.Alg != alg {
+		return fmt.Errorf("algorithm %q, want %q", alg, k.Alg)
+	}
+	var hash crypto.Hash
+	// curve is the only curve an ES algorithm may be used with.
+	var curve elliptic.Curve
+	switch alg {
+	case "RS256":
+		hash = crypto.SHA256
+	case "RS384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	case "ES256":
+		hash, curve = crypto.SHA256, elliptic.P256()
+	case "ES384":
+		hash, curve = crypto.SHA384, elliptic.P384()
+	case "ES512":
+		hash, curve = crypto.SHA512, elliptic.P521()
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := k.pub.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %q does not match RSA key", alg)
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if curve != pub.Curve || len(sig) != 2*size {
+			return fmt.Errorf("algorithm %q does not match %v key", alg, pub.Curve.Params().Name)
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key type")
+	}
+}
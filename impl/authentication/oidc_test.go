@@ -0,0 +1,441 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authentication
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "keytransparency"
+)
+
+type testSigner struct {
+	kid string
+	alg string
+	key crypto.Signer
+}
+
+func (s *testSigner) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+	switch pub := s.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA", "kid": s.kid, "use": "sig",
+			"n": enc(pub.N.Bytes()),
+			"e": enc(big.NewInt(int64(pub.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		return map[string]string{
+			"kty": "EC", "kid": s.kid, "crv": "P-256",
+			"x": enc(pub.X.Bytes()),
+			"y": enc(pub.Y.Bytes()),
+		}
+	}
+	return nil
+}
+
+// sign returns a compact JWT of claims, signed with s.
+func (s *testSigner) sign(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	seg := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("json.Marshal(): %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := seg(map[string]string{"alg": s.alg, "kid": s.kid, "typ": "JWT"}) + "." + seg(claims)
+	hash := crypto.SHA256
+	if s.alg == "ES384" {
+		hash = crypto.SHA384
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	var sig []byte
+	switch k := s.key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest); err != nil {
+			t.Fatalf("SignPKCS1v15(): %v", err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			t.Fatalf("ecdsa.Sign(): %v", err)
+		}
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func newTestSigners(t *testing.T) (*testSigner, *testSigner) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey(): %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey(): %v", err)
+	}
+	return &testSigner{kid: "rsa", alg: "RS256", key: rsaKey},
+		&testSigner{kid: "ec", alg: "ES256", key: ecKey}
+}
+
+func jwks(t *testing.T, signers ...*testSigner) []byte {
+	t.Helper()
+	keys := []map[string]string{}
+	for _, s := range signers {
+		keys = append(keys, s.jwk())
+	}
+	b, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatalf("json.Marshal(): %v", err)
+	}
+	return b
+}
+
+// writeJWKS writes a JWKS to a temporary file and returns its path, along with
+// a function that removes it.
+func writeJWKS(t *testing.T, signers ...*testSigner) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "jwks")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	path := filepath.Join(dir, "jwks.json")
+	if err := ioutil.WriteFile(path, jwks(t, signers...), 0600); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func bearerCtx(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestOIDCAuthFunc(t *testing.T) {
+	ctx := context.Background()
+	rsaSigner, ecSigner := newTestSigners(t)
+	other, _ := newTestSigners(t)
+	unknown := &testSigner{kid: "unknown", alg: "RS256", key: other.key}
+	forged := &testSigner{kid: "rsa", alg: "RS256", key: other.key}
+	mismatched := &testSigner{kid: "ec", alg: "RS256", key: other.key}
+	// A valid signature by the P-256 key, but over a SHA-384 digest.
+	wrongCurve := &testSigner{kid: "ec", alg: "ES384", key: ecSigner.key}
+
+	path, cleanup := writeJWKS(t, rsaSigner, ecSigner)
+	defer cleanup()
+	a, err := NewOIDCAuth(ctx, OIDCConfig{
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		JWKS:      path,
+		ClockSkew: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuth(): %v", err)
+	}
+
+	now := time.Now()
+	claims := func(edit func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":            testIssuer,
+			"aud":            testAudience,
+			"exp":            now.Add(time.Hour).Unix(),
+			"iat":            now.Unix(),
+			"email":          "alice@example.com",
+			"email_verified": true,
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	for _, tc := range []struct {
+		desc   string
+		signer *testSigner
+		claims map[string]interface{}
+		ok     bool
+	}{
+		{desc: "rsa", signer: rsaSigner, claims: claims(nil), ok: true},
+		{desc: "ec", signer: ecSigner, claims: claims(nil), ok: true},
+		{desc: "audience list", signer: rsaSigner, ok: true, claims: claims(func(c map[string]interface{}) {
+			c["aud"] = []string{"other", testAudience}
+		})},
+		{desc: "string email_verified", signer: rsaSigner, ok: true, claims: claims(func(c map[string]interface{}) {
+			c["email_verified"] = "true"
+		})},
+		{desc: "within skew", signer: rsaSigner, ok: true, claims: claims(func(c map[string]interface{}) {
+			c["exp"] = now.Add(-30 * time.Second).Unix()
+		})},
+		{desc: "wrong issuer", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})},
+		{desc: "wrong audience", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			c["aud"] = "other"
+		})},
+		{desc: "expired", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			c["exp"] = now.Add(-time.Hour).Unix()
+		})},
+		{desc: "no expiry", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})},
+		{desc: "not yet valid", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			c["nbf"] = now.Add(time.Hour).Unix()
+		})},
+		{desc: "unverified email", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			c["email_verified"] = false
+		})},
+		{desc: "missing email_verified", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			delete(c, "email_verified")
+		})},
+		{desc: "missing email", signer: rsaSigner, claims: claims(func(c map[string]interface{}) {
+			delete(c, "email")
+		})},
+		{desc: "bad signature", signer: forged, claims: claims(nil)},
+		{desc: "unknown key", signer: unknown, claims: claims(nil)},
+		{desc: "algorithm mismatch", signer: mismatched, claims: claims(nil)},
+		{desc: "curve mismatch", signer: wrongCurve, claims: claims(nil)},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			sctx, err := a.AuthFunc(bearerCtx(tc.signer.sign(t, tc.claims)))
+			if !tc.ok {
+				if got, want := status.Code(err), codes.Unauthenticated; got != want {
+					t.Fatalf("AuthFunc(): %v, want code %v", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthFunc(): %v", err)
+			}
+			validated, ok := FromContext(sctx)
+			if !ok {
+				t.Fatalf("FromContext(): no SecurityContext found")
+			}
+			if got, want := validated.Email, "alice@example.com"; got != want {
+				t.Errorf("Email: %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestOIDCMalformedTokens(t *testing.T) {
+	ctx := context.Background()
+	rsaSigner, _ := newTestSigners(t)
+	path, cleanup := writeJWKS(t, rsaSigner)
+	defer cleanup()
+	a, err := NewOIDCAuth(ctx, OIDCConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKS:     path,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuth(): %v", err)
+	}
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"rsa"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"`+testIssuer+`"}`)) + "."
+	for _, token := range []string{"", "abc", "a.b.c", none} {
+		if _, err := a.AuthFunc(bearerCtx(token)); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("AuthFunc(%q): %v, want code %v", token, err, codes.Unauthenticated)
+		}
+	}
+	if _, err := a.AuthFunc(ctx); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("AuthFunc(no token): %v, want code %v", err, codes.Unauthenticated)
+	}
+}
+
+func TestNewOIDCAuthErrors(t *testing.T) {
+	ctx := context.Background()
+	rsaSigner, _ := newTestSigners(t)
+	path, cleanup := writeJWKS(t, rsaSigner)
+	defer cleanup()
+	empty := filepath.Join(filepath.Dir(path), "empty.json")
+	if err := ioutil.WriteFile(empty, []byte(`{"keys":[]}`), 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	for _, tc := range []struct {
+		desc   string
+		config OIDCConfig
+	}{
+		{desc: "no issuer", config: OIDCConfig{Audience: testAudience, JWKS: path}},
+		{desc: "no audience", config: OIDCConfig{Issuer: testIssuer, JWKS: path}},
+		{desc: "no jwks", config: OIDCConfig{Issuer: testIssuer, Audience: testAudience}},
+		{desc: "missing file", config: OIDCConfig{Issuer: testIssuer, Audience: testAudience, JWKS: path + ".missing"}},
+		{desc: "no keys", config: OIDCConfig{Issuer: testIssuer, Audience: testAudience, JWKS: empty}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if _, err := NewOIDCAuth(ctx, tc.config); err == nil {
+				t.Errorf("NewOIDCAuth(): nil, want error")
+			}
+		})
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := newTestSigners(t)
+	var served atomic.Value
+	served.Store(jwks(t, oldKey))
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Write(served.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	a, err := NewOIDCAuth(ctx, OIDCConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		JWKS:        srv.URL,
+		KeyCacheTTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuth(): %v", err)
+	}
+	now := time.Now()
+	a.now = func() time.Time { return now }
+	claims := map[string]interface{}{
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"exp":            now.Add(3 * time.Hour).Unix(),
+		"email":          "alice@example.com",
+		"email_verified": true,
+	}
+
+	if _, err := a.AuthFunc(bearerCtx(oldKey.sign(t, claims))); err != nil {
+		t.Fatalf("AuthFunc(old key): %v", err)
+	}
+	served.Store(jwks(t, newKey))
+
+	// Unknown keys do not trigger a refresh while the keys are fresh.
+	if _, err := a.AuthFunc(bearerCtx(newKey.sign(t, claims))); err == nil {
+		t.Errorf("AuthFunc(new key) before refresh: nil, want error")
+	}
+	if got, want := atomic.LoadInt32(&fetches), int32(1); got != want {
+		t.Errorf("fetches: %v, want %v", got, want)
+	}
+
+	// An unknown key triggers a refresh once minKeyRefresh has passed.
+	now = now.Add(2 * minKeyRefresh)
+	if _, err := a.AuthFunc(bearerCtx(newKey.sign(t, claims))); err != nil {
+		t.Errorf("AuthFunc(new key): %v", err)
+	}
+	if _, err := a.AuthFunc(bearerCtx(oldKey.sign(t, claims))); err == nil {
+		t.Errorf("AuthFunc(old key) after rotation: nil, want error")
+	}
+
+	// Keys are refreshed once the cache expires.
+	served.Store(jwks(t, oldKey))
+	now = now.Add(2 * time.Hour)
+	if _, err := a.AuthFunc(bearerCtx(oldKey.sign(t, claims))); err != nil {
+		t.Errorf("AuthFunc(old key) after cache expiry: %v", err)
+	}
+}
+
+func TestOIDCKeyRefreshLimit(t *testing.T) {
+	ctx := context.Background()
+	oldKey, newKey := newTestSigners(t)
+	var served atomic.Value
+	served.Store(jwks(t, oldKey))
+	var fetches, failing int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if atomic.LoadInt32(&failing) != 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(served.Load().([]byte))
+	}))
+	defer srv.Close()
+
+	a, err := NewOIDCAuth(ctx, OIDCConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		JWKS:     srv.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuth(): %v", err)
+	}
+	now := time.Now().Add(2 * minKeyRefresh)
+	a.now = func() time.Time { return now }
+	claims := map[string]interface{}{
+		"iss":            testIssuer,
+		"aud":            testAudience,
+		"exp":            now.Add(time.Hour).Unix(),
+		"email":          "alice@example.com",
+		"email_verified": true,
+	}
+	token := newKey.sign(t, claims)
+
+	// Concurrent tokens with unknown keys read the JWKS once, and a failed
+	// read is not retried before minKeyRefresh has passed.
+	atomic.StoreInt32(&failing, 1)
+	for round := 0; round < 2; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := a.AuthFunc(bearerCtx(token)); err == nil {
+					t.Errorf("AuthFunc(unknown key): nil, want error")
+				}
+			}()
+		}
+		wg.Wait()
+		if got, want := atomic.LoadInt32(&fetches), int32(2); got != want {
+			t.Errorf("round %v: fetches: %v, want %v", round, got, want)
+		}
+	}
+
+	atomic.StoreInt32(&failing, 0)
+	served.Store(jwks(t, newKey))
+	now = now.Add(2 * minKeyRefresh)
+	if _, err := a.AuthFunc(bearerCtx(token)); err != nil {
+		t.Errorf("AuthFunc(new key): %v", err)
+	}
+	if got, want := atomic.LoadInt32(&fetches), int32(3); got != want {
+		t.Errorf("fetches: %v, want %v", got, want)
+	}
+}
@@ -18,9 +18,17 @@ package authorization
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
 
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/impl/authentication"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -32,54 +40,200 @@ import (
 type AuthzFunc func(context.Context, interface{}) error
 
 // AuthzPolicy contains the authorization policy.
+// The policy may be replaced at runtime with SetPolicy or Reload.
 type AuthzPolicy struct {
+	mu     sync.RWMutex
 	Policy *authzpb.AuthorizationPolicy
 }
 
+// LoadPolicy reads an AuthorizationPolicy in protobuf text format from path.
+func LoadPolicy(path string) (*authzpb.AuthorizationPolicy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p authzpb.AuthorizationPolicy
+	if err := proto.UnmarshalText(string(b), &p); err != nil {
+		return nil, fmt.Errorf("parsing policy %v: %v", path, err)
+	}
+	return &p, nil
+}
+
+// SetPolicy replaces the policy in effect.
+func (a *AuthzPolicy) SetPolicy(p *authzpb.AuthorizationPolicy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Policy = p
+}
+
+// Reload replaces the policy in effect with the one stored at path.
+// The policy in effect is left unchanged if path cannot be read.
+func (a *AuthzPolicy) Reload(path string) error {
+	p, err := LoadPolicy(path)
+	if err != nil {
+		return err
+	}
+	a.SetPolicy(p)
+	return nil
+}
+
+// ReloadOnSignal reloads the policy from path each time the process receives
+// one of sigs, until ctx is done.
+func (a *AuthzPolicy) ReloadOnSignal(ctx context.Context, path string, sigs ...os.Signal) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sigs...)
+	defer signal.Stop(c)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c:
+			if err := a.Reload(path); err != nil {
+				glog.Errorf("Failed to reload authorization policy: %v", err)
+				continue
+			}
+			glog.Infof("Reloaded authorization policy from %v", path)
+		}
+	}
+}
+
+func (a *AuthzPolicy) policy() *authzpb.AuthorizationPolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.Policy
+}
+
 // Authorize verifies that the identity issuing the call.
 // ctx must contain an authentication.SecurityContext.
 // A call is authorized if:
 //  1. userID matches SecurityContext.Email,
 //  2. or, SecurityContext.Email is authorized to do the action in directories/directoryID.
+//
+// Batch requests are authorized if every update is authorized. Otherwise the
+// returned PermissionDenied status carries an errdetails.ResourceInfo for each
+// denied update.
 func (a *AuthzPolicy) Authorize(ctx context.Context, m interface{}) error {
 	sctx, ok := authentication.FromContext(ctx)
 	if !ok {
 		return status.Error(codes.Unauthenticated, "Request does not contain a ValidatedSecurity object")
 	}
 
+	policy := a.policy()
 	switch t := m.(type) {
 	case *pb.UpdateEntryRequest:
-		return a.checkPermission(sctx, t.DirectoryId, t.EntryUpdate.UserId)
+		return checkPermission(policy, sctx, t.DirectoryId, t.GetEntryUpdate().GetUserId())
+	case *pb.BatchQueueUserUpdateRequest:
+		return checkBatchPermission(policy, sctx, t.DirectoryId, t.Updates)
 		// Can't authorize any other requests
 	default:
 		return status.Errorf(codes.PermissionDenied, "message type %T not recognized", t)
 	}
 }
 
-func (a *AuthzPolicy) checkPermission(sctx *authentication.SecurityContext, directoryID, userID string) error {
+// AuthorizeMethod authorizes calls to administrative RPCs.
+// ctx must contain an authentication.SecurityContext and the gRPC method being called.
+// A call is authorized if SecurityContext.Email is a principal of one of the
+// roles the policy assigns to the method, or to the method's service.
+func (a *AuthzPolicy) AuthorizeMethod(ctx context.Context, _ interface{}) error {
+	sctx, ok := authentication.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "Request does not contain a ValidatedSecurity object")
+	}
+	method, ok := grpc.Method(ctx)
+	if !ok {
+		return status.Error(codes.Internal, "Request does not identify a method")
+	}
+
+	policy := a.policy()
+	labels := policy.GetMethodToRoleLabels()
+	for _, key := range []string{method, serviceName(method)} {
+		if hasRole(policy, labels[key], sctx.Email) {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "%v is not authorized to call %v", sctx.Email, method)
+}
+
+func checkPermission(policy *authzpb.AuthorizationPolicy, sctx *authentication.SecurityContext, directoryID, userID string) error {
 	// Case 1.
 	if sctx.Email == userID {
 		return nil
 	}
 
 	// Case 2.
+	return checkDirectoryPermission(policy, sctx, directoryID)
+}
+
+func checkBatchPermission(policy *authzpb.AuthorizationPolicy, sctx *authentication.SecurityContext,
+	directoryID string, updates []*pb.EntryUpdate) error {
+	// Case 1 covers every update to the caller's own entry.
+	var others []int
+	for i, u := range updates {
+		if u.GetUserId() != sctx.Email {
+			others = append(others, i)
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+
+	// Case 2 must cover the remaining updates.
+	dirErr := checkDirectoryPermission(policy, sctx, directoryID)
+	if dirErr == nil {
+		return nil
+	}
+	if status.Code(dirErr) != codes.PermissionDenied {
+		return dirErr
+	}
+
+	st := status.Newf(codes.PermissionDenied, "%v is not authorized to update all users in the batch", sctx.Email)
+	denied := make([]proto.Message, 0, len(others))
+	for _, i := range others {
+		u := updates[i]
+		denied = append(denied, &errdetails.ResourceInfo{
+			ResourceType: "user",
+			ResourceName: u.GetUserId(),
+			Description:  fmt.Sprintf("updates[%d]: %v is not authorized to update %q", i, sctx.Email, u.GetUserId()),
+		})
+	}
+	stWithDetails, err := st.WithDetails(denied...)
+	if err != nil {
+		return st.Err()
+	}
+	return stWithDetails.Err()
+}
+
+func checkDirectoryPermission(policy *authzpb.AuthorizationPolicy, sctx *authentication.SecurityContext, directoryID string) error {
 	rLabel, err := resourceLabel(directoryID)
 	if err != nil {
 		return err
 	}
-	roles, ok := a.Policy.GetResourceToRoleLabels()[rLabel]
+	roles, ok := policy.GetResourceToRoleLabels()[rLabel]
 	if !ok {
 		return status.Errorf(codes.PermissionDenied, "%v does not have a defined policy", rLabel)
 	}
-	for _, l := range roles.GetLabels() {
-		role := a.Policy.GetRoles()[l]
-		if isPrincipalInRole(role, sctx.Email) {
-			return nil
-		}
+	if hasRole(policy, roles, sctx.Email) {
+		return nil
 	}
 	return status.Errorf(codes.PermissionDenied, "%v is not authorized to act on %v", sctx.Email, rLabel)
 }
 
+// hasRole returns true if identity is a principal of one of the roles in labels.
+func hasRole(policy *authzpb.AuthorizationPolicy, labels *authzpb.AuthorizationPolicy_RoleLabels, identity string) bool {
+	for _, l := range labels.GetLabels() {
+		role := policy.GetRoles()[l]
+		if isPrincipalInRole(role, identity) {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceName returns the "/package.Service/" prefix of a full gRPC method name.
+func serviceName(method string) string {
+	return method[:strings.LastIndex(method, "/")+1]
+}
+
 func resourceLabel(directoryID string) (string, error) {
 	if strings.Contains(directoryID, "/") {
 		return "", status.Errorf(codes.InvalidArgument, "resource label contains invalid character '/'")
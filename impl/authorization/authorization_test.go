@@ -17,10 +17,16 @@ package authorization
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/google/keytransparency/impl/authentication"
 	"github.com/grpc-ecosystem/go-grpc-middleware/util/metautils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -176,3 +182,211 @@ func TestResouceLabel(t *testing.T) {
 		}
 	}
 }
+
+func authCtx(t *testing.T, email string) context.Context {
+	t.Helper()
+	ctx := context.Background()
+	// Convert outgoing context to incoming context.
+	inCtx := metautils.ExtractOutgoing(authentication.WithOutgoingFakeAuth(ctx, email)).ToIncoming(ctx)
+	sctx, err := authentication.FakeAuthFunc(inCtx)
+	if err != nil {
+		t.Fatalf("FakeAuthFunc(): %v", err)
+	}
+	return sctx
+}
+
+func TestAuthorizeBatch(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		email       string
+		directoryID string
+		userIDs     []string
+		wantCode    codes.Code
+		wantDenied  []string
+	}{
+		{
+			description: "empty batch",
+			email:       testUser,
+			directoryID: "1",
+		},
+		{
+			description: "self updating own profile",
+			email:       testUser,
+			directoryID: "1",
+			userIDs:     []string{testUser, testUser},
+		},
+		{
+			description: "directory admin",
+			email:       admin1,
+			directoryID: "1",
+			userIDs:     []string{testUser, "other@example.com"},
+		},
+		{
+			description: "admin of other directory",
+			email:       admin3,
+			directoryID: "1",
+			userIDs:     []string{admin3, testUser, "other@example.com"},
+			wantCode:    codes.PermissionDenied,
+			wantDenied:  []string{testUser, "other@example.com"},
+		},
+		{
+			description: "self updating own profile in unlisted directory",
+			email:       testUser,
+			directoryID: "5",
+			userIDs:     []string{testUser},
+		},
+		{
+			description: "invalid directory",
+			email:       testUser,
+			directoryID: "1/1",
+			userIDs:     []string{testUser, "other@example.com"},
+			wantCode:    codes.InvalidArgument,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			req := &pb.BatchQueueUserUpdateRequest{DirectoryId: tc.directoryID}
+			for _, u := range tc.userIDs {
+				req.Updates = append(req.Updates, &pb.EntryUpdate{UserId: u})
+			}
+			err := authz.Authorize(authCtx(t, tc.email), req)
+			st := status.Convert(err)
+			if got, want := st.Code(), tc.wantCode; got != want {
+				t.Fatalf("Authorize(): %v, want %v", err, want)
+			}
+			var denied []string
+			for _, d := range st.Details() {
+				if ri, ok := d.(*errdetails.ResourceInfo); ok {
+					denied = append(denied, ri.ResourceName)
+				}
+			}
+			if !reflect.DeepEqual(denied, tc.wantDenied) {
+				t.Errorf("Authorize(): denied %v, want %v", denied, tc.wantDenied)
+			}
+		})
+	}
+}
+
+// TestAuthorizeBatchMatchesSingle checks that a batch of one update is
+// authorized exactly like the equivalent single update.
+func TestAuthorizeBatchMatchesSingle(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		email       string
+		directoryID string
+		userID      string
+	}{
+		{description: "self", email: testUser, directoryID: "1", userID: testUser},
+		{description: "self in unlisted directory", email: testUser, directoryID: "5", userID: testUser},
+		{description: "self in invalid directory", email: testUser, directoryID: "1/1", userID: testUser},
+		{description: "admin", email: admin1, directoryID: "1", userID: testUser},
+		{description: "admin of other directory", email: admin3, directoryID: "1", userID: testUser},
+		{description: "other in unlisted directory", email: admin1, directoryID: "5", userID: testUser},
+		{description: "other in invalid directory", email: admin1, directoryID: "1/1", userID: testUser},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			ctx := authCtx(t, tc.email)
+			single := authz.Authorize(ctx, &pb.UpdateEntryRequest{
+				DirectoryId: tc.directoryID,
+				EntryUpdate: &pb.EntryUpdate{UserId: tc.userID},
+			})
+			batch := authz.Authorize(ctx, &pb.BatchQueueUserUpdateRequest{
+				DirectoryId: tc.directoryID,
+				Updates:     []*pb.EntryUpdate{{UserId: tc.userID}},
+			})
+			if got, want := status.Code(batch), status.Code(single); got != want {
+				t.Errorf("Authorize(batch): %v, want %v like Authorize(single): %v", batch, want, single)
+			}
+		})
+	}
+}
+
+// fakeStream identifies the method being called to grpc.Method.
+type fakeStream struct {
+	grpc.ServerTransportStream
+	method string
+}
+
+func (s fakeStream) Method() string { return s.method }
+
+func TestAuthorizeMethod(t *testing.T) {
+	policy, err := LoadPolicy("testdata/policy.textproto")
+	if err != nil {
+		t.Fatalf("LoadPolicy(): %v", err)
+	}
+	a := &AuthzPolicy{Policy: policy}
+	const (
+		directoryAdmin = "admin@example.com"
+		logOperator    = "operator@example.com"
+		adminSvc       = "/google.keytransparency.v1.KeyTransparencyAdmin/"
+		sequencerSvc   = "/google.keytransparency.sequencer.KeyTransparencySequencer/"
+	)
+	for _, tc := range []struct {
+		email    string
+		method   string
+		wantCode codes.Code
+	}{
+		{email: directoryAdmin, method: adminSvc + "CreateDirectory"},
+		{email: directoryAdmin, method: adminSvc + "DeleteDirectory"},
+		{email: directoryAdmin, method: adminSvc + "CreateInputLog"},
+		{email: directoryAdmin, method: sequencerSvc + "ApplyRevision", wantCode: codes.PermissionDenied},
+		{email: logOperator, method: adminSvc + "CreateInputLog"},
+		{email: logOperator, method: sequencerSvc + "ApplyRevision"},
+		{email: logOperator, method: adminSvc + "DeleteDirectory", wantCode: codes.PermissionDenied},
+		{email: testUser, method: adminSvc + "GetDirectory", wantCode: codes.PermissionDenied},
+		{email: directoryAdmin, method: "/google.keytransparency.v1.KeyTransparency/GetUser", wantCode: codes.PermissionDenied},
+	} {
+		ctx := grpc.NewContextWithServerTransportStream(authCtx(t, tc.email), fakeStream{method: tc.method})
+		err := a.AuthorizeMethod(ctx, nil)
+		if got, want := status.Code(err), tc.wantCode; got != want {
+			t.Errorf("AuthorizeMethod(%v, %v): %v, want %v", tc.email, tc.method, err, want)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "authz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policy.textproto")
+
+	a := &AuthzPolicy{Policy: &authzpb.AuthorizationPolicy{}}
+	req := &pb.UpdateEntryRequest{DirectoryId: "1", EntryUpdate: &pb.EntryUpdate{UserId: testUser}}
+	ctx := authCtx(t, admin1)
+
+	for _, tc := range []struct {
+		description string
+		policy      string
+		wantErr     bool
+		wantCode    codes.Code
+	}{
+		{
+			description: "grant",
+			policy: `roles { key: "r1" value { principals: "admin1@example.com" } }
+				resource_to_role_labels { key: "directories/1" value { labels: "r1" } }`,
+		},
+		{
+			description: "invalid policy keeps previous",
+			policy:      `roles {`,
+			wantErr:     true,
+		},
+		{
+			description: "revoke",
+			policy:      `roles { key: "r1" value { principals: "admin2@example.com" } }`,
+			wantCode:    codes.PermissionDenied,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			if err := ioutil.WriteFile(path, []byte(tc.policy), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := a.Reload(path); (err != nil) != tc.wantErr {
+				t.Fatalf("Reload(): %v, wantErr %v", err, tc.wantErr)
+			}
+			if got, want := status.Code(a.Authorize(ctx, req)), tc.wantCode; got != want {
+				t.Errorf("Authorize(): %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -26,6 +26,14 @@ type AuthorizationPolicy struct {
 	Roles map[string]*AuthorizationPolicy_Role `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	// resource_to_role_labels specifies the authorization policy keyed by resource directory_id.
 	ResourceToRoleLabels map[string]*AuthorizationPolicy_RoleLabels `protobuf:"bytes,3,rep,name=resource_to_role_labels,json=resourceToRoleLabels,proto3" json:"resource_to_role_labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// method_to_role_labels specifies the roles allowed to call administrative
+	// RPCs, such as those of KeyTransparencyAdmin and KeyTransparencySequencer.
+	// Keys are full gRPC method names, e.g.
+	// "/google.keytransparency.v1.KeyTransparencyAdmin/CreateDirectory", or
+	// service names ending in "/", e.g.
+	// "/google.keytransparency.v1.KeyTransparencySequencer/", which apply to
+	// every method of the service.
+	MethodToRoleLabels   map[string]*AuthorizationPolicy_RoleLabels `protobuf:"bytes,4,rep,name=method_to_role_labels,json=methodToRoleLabels,proto3" json:"method_to_role_labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	XXX_NoUnkeyedLiteral struct{}                                   `json:"-"`
 	XXX_unrecognized     []byte                                     `json:"-"`
 	XXX_sizecache        int32                                      `json:"-"`
@@ -70,6 +78,13 @@ func (m *AuthorizationPolicy) GetResourceToRoleLabels() map[string]*Authorizatio
 	return nil
 }
 
+func (m *AuthorizationPolicy) GetMethodToRoleLabels() map[string]*AuthorizationPolicy_RoleLabels {
+	if m != nil {
+		return m.MethodToRoleLabels
+	}
+	return nil
+}
+
 // Resource contains the resource being accessed.
 type AuthorizationPolicy_Resource struct {
 	// directory_id contains the Key Transparency directory of this entry.
@@ -194,6 +209,7 @@ func (m *AuthorizationPolicy_RoleLabels) GetLabels() []string {
 
 func init() {
 	proto.RegisterType((*AuthorizationPolicy)(nil), "google.keytransparency.impl.AuthorizationPolicy")
+	proto.RegisterMapType((map[string]*AuthorizationPolicy_RoleLabels)(nil), "google.keytransparency.impl.AuthorizationPolicy.MethodToRoleLabelsEntry")
 	proto.RegisterMapType((map[string]*AuthorizationPolicy_RoleLabels)(nil), "google.keytransparency.impl.AuthorizationPolicy.ResourceToRoleLabelsEntry")
 	proto.RegisterMapType((map[string]*AuthorizationPolicy_Role)(nil), "google.keytransparency.impl.AuthorizationPolicy.RolesEntry")
 	proto.RegisterType((*AuthorizationPolicy_Resource)(nil), "google.keytransparency.impl.AuthorizationPolicy.Resource")
@@ -204,28 +220,30 @@ func init() {
 func init() { proto.RegisterFile("authz.proto", fileDescriptor_6b30dada73a254d2) }
 
 var fileDescriptor_6b30dada73a254d2 = []byte{
-	// 358 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0x41, 0x4b, 0xe3, 0x40,
-	0x14, 0xc7, 0x49, 0xd2, 0x96, 0xf6, 0x65, 0x0f, 0x65, 0x76, 0xd9, 0xcd, 0x66, 0x61, 0xc9, 0x2e,
-	0xcb, 0x92, 0x53, 0x02, 0x2d, 0x82, 0xe8, 0x49, 0xa5, 0x07, 0xab, 0x07, 0x1b, 0x3c, 0x79, 0x09,
-	0xd3, 0x74, 0x48, 0x87, 0x4e, 0xf3, 0xc2, 0x64, 0x22, 0xa4, 0x37, 0xc1, 0xef, 0xea, 0xd7, 0x90,
-	0x64, 0xa2, 0x56, 0xa9, 0x82, 0x3d, 0xcd, 0xbc, 0x37, 0xef, 0xfd, 0xfe, 0xff, 0x79, 0x33, 0x60,
-	0xd3, 0x52, 0x2d, 0x37, 0x41, 0x2e, 0x51, 0x21, 0xf9, 0x95, 0x22, 0xa6, 0x82, 0x05, 0x2b, 0x56,
-	0x29, 0x49, 0xb3, 0x22, 0xa7, 0x92, 0x65, 0x49, 0x15, 0xf0, 0x75, 0x2e, 0xfe, 0x3e, 0x74, 0xe0,
-	0xeb, 0x49, 0xa9, 0x96, 0x28, 0xf9, 0x86, 0x2a, 0x8e, 0xd9, 0x15, 0x0a, 0x9e, 0x54, 0x64, 0x06,
-	0x5d, 0x89, 0x82, 0x15, 0x8e, 0xe9, 0x59, 0xbe, 0x3d, 0x3a, 0x0e, 0x3e, 0x80, 0x04, 0x3b, 0x00,
-	0x41, 0x54, 0x77, 0x4f, 0x32, 0x25, 0xab, 0x48, 0x93, 0xc8, 0x9d, 0x01, 0x3f, 0x24, 0x2b, 0xb0,
-	0x94, 0x09, 0x8b, 0x15, 0xc6, 0x75, 0x36, 0x16, 0x74, 0xce, 0x44, 0xe1, 0x58, 0x8d, 0xca, 0xf4,
-	0xf3, 0x2a, 0x2d, 0xef, 0x1a, 0x6b, 0xbd, 0xcb, 0x06, 0xa6, 0x45, 0xbf, 0xc9, 0x1d, 0x47, 0xee,
-	0x18, 0xfa, 0x4f, 0x2d, 0xe4, 0x0f, 0x7c, 0x59, 0x70, 0xc9, 0x12, 0x85, 0xb2, 0x8a, 0xf9, 0xc2,
-	0x31, 0x3c, 0xc3, 0x1f, 0x44, 0xf6, 0x73, 0xee, 0x7c, 0x31, 0xed, 0xf4, 0xcd, 0xa1, 0xe5, 0xfe,
-	0x87, 0x4e, 0x8d, 0x20, 0xbf, 0x01, 0x72, 0xc9, 0xb3, 0x84, 0xe7, 0x54, 0x14, 0x8e, 0xe1, 0x59,
-	0xfe, 0x20, 0xda, 0xca, 0xb8, 0xff, 0x00, 0x5e, 0xa4, 0xc8, 0x77, 0xe8, 0xb5, 0x97, 0xd3, 0x95,
-	0x6d, 0xe4, 0xa2, 0xae, 0xd2, 0x36, 0xc9, 0x10, 0xac, 0x15, 0xab, 0x5a, 0xed, 0x7a, 0x4b, 0x2e,
-	0xa0, 0x7b, 0x4b, 0x45, 0xc9, 0x1c, 0xd3, 0x33, 0x7c, 0x7b, 0x74, 0xb0, 0xd7, 0xe4, 0x23, 0xcd,
-	0x38, 0x32, 0x0f, 0x0d, 0xf7, 0xde, 0x80, 0x9f, 0xef, 0xce, 0x69, 0x87, 0x81, 0xd9, 0x6b, 0x03,
-	0xfb, 0x3d, 0xbd, 0x96, 0xd8, 0xb2, 0x71, 0x3a, 0xb9, 0x39, 0x4b, 0xb9, 0x5a, 0x96, 0xf3, 0x20,
-	0xc1, 0x75, 0xa8, 0x99, 0xe1, 0x1b, 0x66, 0x58, 0x33, 0x43, 0xba, 0xcd, 0x6c, 0xa2, 0x4d, 0x9c,
-	0x62, 0xdc, 0xfc, 0xe6, 0x79, 0xaf, 0x59, 0xc6, 0x8f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xfc, 0xbb,
-	0x8c, 0xbc, 0xe3, 0x02, 0x00, 0x00,
+	// 389 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x93, 0xdf, 0x8b, 0x95, 0x40,
+	0x14, 0xc7, 0x19, 0xbd, 0x7b, 0xd9, 0x3d, 0xf6, 0xb0, 0x4c, 0x3f, 0xd6, 0x0c, 0xc2, 0x22, 0xc2,
+	0x27, 0x85, 0x5d, 0x82, 0xa8, 0xa7, 0x8a, 0x85, 0xda, 0x0a, 0x5a, 0xe9, 0xa9, 0x17, 0x99, 0xab,
+	0x83, 0x0e, 0x77, 0xf4, 0xc8, 0x38, 0x06, 0xde, 0x9e, 0x8a, 0xfe, 0xd9, 0xfe, 0x8b, 0xd0, 0xb1,
+	0xb0, 0xfb, 0x23, 0xb8, 0xf7, 0x61, 0x9f, 0x74, 0xce, 0x8c, 0x9f, 0xef, 0xe7, 0x70, 0x1c, 0x70,
+	0x58, 0xab, 0x8b, 0x55, 0x58, 0x2b, 0xd4, 0x48, 0x1f, 0xe4, 0x88, 0xb9, 0xe4, 0xe1, 0x92, 0x77,
+	0x5a, 0xb1, 0xaa, 0xa9, 0x99, 0xe2, 0x55, 0xda, 0x85, 0xa2, 0xac, 0xe5, 0xe3, 0x5f, 0x73, 0xb8,
+	0xfd, 0xaa, 0xd5, 0x05, 0x2a, 0xb1, 0x62, 0x5a, 0x60, 0xf5, 0x09, 0xa5, 0x48, 0x3b, 0x7a, 0x0d,
+	0x47, 0x0a, 0x25, 0x6f, 0x5c, 0xcb, 0xb7, 0x03, 0xe7, 0xfc, 0x65, 0xf8, 0x1f, 0x48, 0xb8, 0x05,
+	0x10, 0xc6, 0xfd, 0xd7, 0x97, 0x95, 0x56, 0x5d, 0x6c, 0x48, 0xf4, 0x3b, 0x81, 0x33, 0xc5, 0x1b,
+	0x6c, 0x55, 0xca, 0x13, 0x8d, 0x49, 0x5f, 0x4d, 0x24, 0x5b, 0x70, 0xd9, 0xb8, 0xf6, 0x90, 0x72,
+	0xb5, 0x7f, 0xca, 0xc8, 0xfb, 0x8c, 0x7d, 0xde, 0x87, 0x01, 0x66, 0x42, 0xef, 0xa8, 0x2d, 0x5b,
+	0xf4, 0x1b, 0xdc, 0x2d, 0xb9, 0x2e, 0x30, 0x5b, 0x17, 0x98, 0x0d, 0x02, 0x6f, 0xf7, 0x16, 0xf8,
+	0x38, 0xd0, 0x36, 0xe3, 0x69, 0xb9, 0xb1, 0xe1, 0x5d, 0xc0, 0xf1, 0x1f, 0x5f, 0xfa, 0x08, 0x6e,
+	0x65, 0x42, 0xf1, 0x54, 0xa3, 0xea, 0x12, 0x91, 0xb9, 0xc4, 0x27, 0xc1, 0x49, 0xec, 0xfc, 0xad,
+	0xbd, 0xcb, 0xae, 0x66, 0xc7, 0xd6, 0xa9, 0xed, 0x3d, 0x85, 0x59, 0x8f, 0xa0, 0x0f, 0x01, 0x6a,
+	0x25, 0xaa, 0x54, 0xd4, 0x4c, 0x36, 0x2e, 0xf1, 0xed, 0xe0, 0x24, 0x9e, 0x54, 0xbc, 0x27, 0x00,
+	0x93, 0x3e, 0xef, 0xc1, 0x7c, 0x6c, 0xcc, 0x9c, 0x1c, 0x57, 0x1e, 0x9a, 0x53, 0x46, 0x92, 0x9e,
+	0x82, 0xbd, 0xe4, 0xdd, 0x98, 0xdd, 0xbf, 0xd2, 0xf7, 0x70, 0xf4, 0x95, 0xc9, 0x96, 0xbb, 0x96,
+	0x4f, 0x02, 0xe7, 0xfc, 0xd9, 0x41, 0x63, 0x8f, 0x0d, 0xe3, 0x85, 0xf5, 0x9c, 0x78, 0x3f, 0x09,
+	0xdc, 0xdf, 0x39, 0xa4, 0x2d, 0x02, 0xd7, 0xff, 0x0a, 0x1c, 0xf6, 0xdf, 0x99, 0x88, 0xa9, 0xc6,
+	0x0f, 0x02, 0x67, 0x3b, 0x46, 0x75, 0x63, 0x12, 0xaf, 0x2f, 0xbf, 0xbc, 0xc9, 0x85, 0x2e, 0xda,
+	0x45, 0x98, 0x62, 0x19, 0x19, 0x66, 0xb4, 0xc6, 0x8c, 0x7a, 0x66, 0xc4, 0xa6, 0xcc, 0x61, 0xb5,
+	0x4a, 0x72, 0x4c, 0x86, 0xfb, 0xbc, 0x98, 0x0f, 0x8f, 0x8b, 0xdf, 0x03, 0x00, 0xfd, 0x4d, 0xd1,
+	0xc4, 0xe5, 0x03, 0x00, 0x00,
 }
@@ -30,10 +30,22 @@ type AuthPair struct {
 	AuthzFunc AuthzFunc
 }
 
+// lookup returns the AuthPair for fullMethod. Keys in authFuncs are either
+// full method names, or service names ending in "/" which apply to every
+// method of the service. Full method names take precedence.
+func lookup(authFuncs map[string]AuthPair, fullMethod string) (AuthPair, bool) {
+	if policy, ok := authFuncs[fullMethod]; ok {
+		return policy, true
+	}
+	policy, ok := authFuncs[serviceName(fullMethod)]
+	return policy, ok
+}
+
 // UnaryServerInterceptor returns a new unary server interceptor that performs per-request auth.
+// authFuncs is keyed by full method name or by service name, see lookup.
 func UnaryServerInterceptor(authFuncs map[string]AuthPair) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		policy, ok := authFuncs[info.FullMethod]
+		policy, ok := lookup(authFuncs, info.FullMethod)
 		if !ok {
 			glog.V(2).Infof("auth interceptor: no handler for %v", info.FullMethod)
 			// If no auth handler was found for this method, invoke the method directly.
@@ -51,9 +63,10 @@ func UnaryServerInterceptor(authFuncs map[string]AuthPair) grpc.UnaryServerInter
 }
 
 // StreamServerInterceptor returns a new stream server interceptor that performs per-request auth.
+// authFuncs is keyed by full method name or by service name, see lookup.
 func StreamServerInterceptor(authFuncs map[string]AuthPair) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		policy, ok := authFuncs[info.FullMethod]
+		policy, ok := lookup(authFuncs, info.FullMethod)
 		if !ok {
 			glog.V(2).Infof("auth interceptor: no handler for %v", info.FullMethod)
 			// If no auth handler was found for this method, invoke the method directly.
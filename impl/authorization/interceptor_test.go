@@ -0,0 +1,39 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authorization
+
+import (
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	authFuncs := map[string]AuthPair{
+		"/pkg.Service/Method": {},
+		"/pkg.Admin/":         {},
+	}
+	for _, tc := range []struct {
+		method string
+		want   bool
+	}{
+		{method: "/pkg.Service/Method", want: true},
+		{method: "/pkg.Service/Other", want: false},
+		{method: "/pkg.Admin/Create", want: true},
+		{method: "/pkg.AdminX/Create", want: false},
+	} {
+		if _, got := lookup(authFuncs, tc.method); got != tc.want {
+			t.Errorf("lookup(%v): %v, want %v", tc.method, got, tc.want)
+		}
+	}
+}
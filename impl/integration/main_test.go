@@ -36,7 +36,7 @@ func TestIntegration(t *testing.T) {
 		t.Run(test.Name, func(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			env := NewEnv(ctx, t)
+			env := NewEnv(ctx, t, test.AllowRecovery)
 			defer env.Close()
 			cctx, cancel := context.WithCancel(ctx)
 			actions := test.Fn(cctx, env.Env, t)
@@ -0,0 +1,95 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+// AuditLog is an append-only audit log held in memory. The results of calls
+// are kept apart from their events, which are never modified.
+// It implements adminserver.AuditLog.
+type AuditLog struct {
+	mu          sync.RWMutex
+	events      []*pb.AdminAuditEvent
+	completions map[int64]*pb.AdminAuditEvent // CompletionTime and status by EventId.
+}
+
+// NewAuditLog returns an empty AuditLog.
+func NewAuditLog() *AuditLog {
+	return &AuditLog{completions: make(map[int64]*pb.AdminAuditEvent)}
+}
+
+// Append records event and returns the EventId assigned to it, starting at 1.
+func (l *AuditLog) Append(_ context.Context, event *pb.AdminAuditEvent) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := proto.Clone(event).(*pb.AdminAuditEvent)
+	e.EventId = int64(len(l.events)) + 1
+	l.events = append(l.events, e)
+	return e.EventId, nil
+}
+
+// Complete records the result of the call recorded by event eventID.
+// Returns NotFound if there is no such event, and AlreadyExists if its result
+// has already been recorded.
+func (l *AuditLog) Complete(_ context.Context, eventID int64, completed time.Time, st *status.Status) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if eventID < 1 || eventID > int64(len(l.events)) {
+		return status.Errorf(codes.NotFound, "audit event %v not found", eventID)
+	}
+	if l.events[eventID-1].CompletionTime != nil || l.completions[eventID] != nil {
+		return status.Errorf(codes.AlreadyExists, "audit event %v has already completed", eventID)
+	}
+	ts, err := ptypes.TimestampProto(completed)
+	if err != nil {
+		return err
+	}
+	l.completions[eventID] = &pb.AdminAuditEvent{
+		CompletionTime: ts,
+		StatusCode:     int32(st.Code()),
+		StatusMessage:  st.Message(),
+	}
+	return nil
+}
+
+// List returns up to pageSize events with EventId > start, ordered by EventId.
+func (l *AuditLog) List(_ context.Context, start int64, pageSize int32) ([]*pb.AdminAuditEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	i := sort.Search(len(l.events), func(i int) bool { return l.events[i].EventId > start })
+	events := []*pb.AdminAuditEvent{}
+	for ; i < len(l.events) && int32(len(events)) < pageSize; i++ {
+		e := proto.Clone(l.events[i]).(*pb.AdminAuditEvent)
+		if c, ok := l.completions[e.EventId]; ok {
+			e.CompletionTime = c.CompletionTime
+			e.StatusCode = c.StatusCode
+			e.StatusMessage = c.StatusMessage
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := context.Background()
+	l := NewAuditLog()
+	for i, method := range []string{"a", "b", "c"} {
+		id, err := l.Append(ctx, &pb.AdminAuditEvent{Method: method, StatusCode: int32(codes.Unknown)})
+		if err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+		if want := int64(i) + 1; id != want {
+			t.Errorf("Append(%v): EventId %v, want %v", method, id, want)
+		}
+	}
+	for _, tc := range []struct {
+		start    int64
+		pageSize int32
+		want     []string
+	}{
+		{start: 0, pageSize: 10, want: []string{"a", "b", "c"}},
+		{start: 0, pageSize: 2, want: []string{"a", "b"}},
+		{start: 2, pageSize: 10, want: []string{"c"}},
+		{start: 3, pageSize: 10, want: []string{}},
+	} {
+		events, err := l.List(ctx, tc.start, tc.pageSize)
+		if err != nil {
+			t.Fatalf("List(): %v", err)
+		}
+		got := []string{}
+		for i, e := range events {
+			got = append(got, e.Method)
+			if want := tc.start + int64(i) + 1; e.EventId != want {
+				t.Errorf("List(%v, %v): event %v has EventId %v, want %v", tc.start, tc.pageSize, i, e.EventId, want)
+			}
+		}
+		if !cmp.Equal(got, tc.want) {
+			t.Errorf("List(%v, %v): %v, want %v", tc.start, tc.pageSize, got, tc.want)
+		}
+	}
+
+	if err := l.Complete(ctx, 2, time.Unix(10, 0), status.New(codes.NotFound, "no dir")); err != nil {
+		t.Fatalf("Complete(): %v", err)
+	}
+	events, err := l.List(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	if e := events[0]; codes.Code(e.StatusCode) != codes.NotFound || e.StatusMessage != "no dir" || e.CompletionTime.GetSeconds() != 10 {
+		t.Errorf("completed event: %v", e)
+	}
+	if err := l.Complete(ctx, 4, time.Now(), status.New(codes.OK, "")); status.Code(err) != codes.NotFound {
+		t.Errorf("Complete(missing): %v, want %v", err, codes.NotFound)
+	}
+	if err := l.Complete(ctx, 2, time.Now(), status.New(codes.OK, "")); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Complete(completed): %v, want %v", err, codes.AlreadyExists)
+	}
+}
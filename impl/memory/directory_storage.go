@@ -0,0 +1,160 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/trillian/crypto/keyspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	tpb "github.com/google/trillian"
+)
+
+// DirectoryStorage implements directory.Storage in memory.
+// Directories are copied on the way in and out, so callers may modify them.
+type DirectoryStorage struct {
+	mu          sync.RWMutex
+	directories map[string]*directory.Directory
+}
+
+// NewDirectoryStorage returns an empty DirectoryStorage.
+func NewDirectoryStorage() *DirectoryStorage {
+	return &DirectoryStorage{directories: make(map[string]*directory.Directory)}
+}
+
+// List returns the directories ordered by ID, including soft-deleted
+// directories if deleted is true.
+func (s *DirectoryStorage) List(_ context.Context, deleted bool) ([]*directory.Directory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ret := make([]*directory.Directory, 0, len(s.directories))
+	for _, d := range s.directories {
+		if d.Deleted && !deleted {
+			continue
+		}
+		ret = append(ret, copyDirectory(d))
+	}
+	sort.Slice(ret, func(a, b int) bool { return ret[a].DirectoryID < ret[b].DirectoryID })
+	return ret, nil
+}
+
+// Write adds a new directory.
+func (s *DirectoryStorage) Write(_ context.Context, d *directory.Directory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.directories[d.DirectoryID]; ok {
+		return status.Errorf(codes.AlreadyExists, "directory %v already exists", d.DirectoryID)
+	}
+	s.directories[d.DirectoryID] = copyDirectory(d)
+	return nil
+}
+
+// Read returns the directory directoryID.
+func (s *DirectoryStorage) Read(_ context.Context, directoryID string, showDeleted bool) (*directory.Directory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.directories[directoryID]
+	if !ok || d.Deleted && !showDeleted {
+		return nil, status.Errorf(codes.NotFound, "directory %v not found", directoryID)
+	}
+	return copyDirectory(d), nil
+}
+
+// SetDelete deletes or undeletes a directory.
+func (s *DirectoryStorage) SetDelete(_ context.Context, directoryID string, isDeleted bool) error {
+	return s.update(directoryID, func(d *directory.Directory) {
+		d.Deleted = isDeleted
+		d.DeletedTimestamp = time.Now()
+	})
+}
+
+// SetRateLimits replaces the rate limits of a directory.
+func (s *DirectoryStorage) SetRateLimits(_ context.Context, directoryID string, limits *pb.RateLimits) error {
+	return s.update(directoryID, func(d *directory.Directory) {
+		d.RateLimits = cloneRateLimits(limits)
+	})
+}
+
+// SetRetention replaces the retention policy of a directory.
+func (s *DirectoryStorage) SetRetention(_ context.Context, directoryID string, retention *pb.RetentionPolicy) error {
+	return s.update(directoryID, func(d *directory.Directory) {
+		d.Retention = cloneRetention(retention)
+	})
+}
+
+// Delete permanently deletes a directory.
+func (s *DirectoryStorage) Delete(_ context.Context, directoryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.directories[directoryID]; !ok {
+		return status.Errorf(codes.NotFound, "directory %v not found", directoryID)
+	}
+	delete(s.directories, directoryID)
+	return nil
+}
+
+// update calls f with directoryID while holding the write lock.
+func (s *DirectoryStorage) update(directoryID string, f func(d *directory.Directory)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.directories[directoryID]
+	if !ok {
+		return status.Errorf(codes.NotFound, "directory %v not found", directoryID)
+	}
+	f(d)
+	return nil
+}
+
+// copyDirectory returns a deep copy of d.
+func copyDirectory(d *directory.Directory) *directory.Directory {
+	c := *d
+	if d.Map != nil {
+		c.Map = proto.Clone(d.Map).(*tpb.Tree)
+	}
+	if d.Log != nil {
+		c.Log = proto.Clone(d.Log).(*tpb.Tree)
+	}
+	if d.VRF != nil {
+		c.VRF = proto.Clone(d.VRF).(*keyspb.PublicKey)
+	}
+	if d.VRFPriv != nil {
+		c.VRFPriv = proto.Clone(d.VRFPriv)
+	}
+	c.RateLimits = cloneRateLimits(d.RateLimits)
+	c.Retention = cloneRetention(d.Retention)
+	return &c
+}
+
+func cloneRateLimits(limits *pb.RateLimits) *pb.RateLimits {
+	if limits == nil {
+		return nil
+	}
+	return proto.Clone(limits).(*pb.RateLimits)
+}
+
+func cloneRetention(retention *pb.RetentionPolicy) *pb.RetentionPolicy {
+	if retention == nil {
+		return nil
+	}
+	return proto.Clone(retention).(*pb.RetentionPolicy)
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/integration/storagetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+func TestDirectoryStorage(t *testing.T) {
+	ctx := context.Background()
+	s := NewDirectoryStorage()
+	for _, id := range []string{"b", "a", "c"} {
+		if err := s.Write(ctx, &directory.Directory{DirectoryID: id}); err != nil {
+			t.Fatalf("Write(%v): %v", id, err)
+		}
+	}
+
+	// Test cases are cumulative.
+	for _, tc := range []struct {
+		desc     string
+		f        func() error
+		wantCode codes.Code
+	}{
+		{desc: "write existing", f: func() error { return s.Write(ctx, &directory.Directory{DirectoryID: "a"}) },
+			wantCode: codes.AlreadyExists},
+		{desc: "soft delete", f: func() error { return s.SetDelete(ctx, "b", true) }},
+		{desc: "soft delete missing", f: func() error { return s.SetDelete(ctx, "x", true) },
+			wantCode: codes.NotFound},
+		{desc: "rate limits", f: func() error {
+			return s.SetRateLimits(ctx, "a", &pb.RateLimits{PerUser: &pb.Quota{UpdatesPerSecond: 1}})
+		}},
+		{desc: "retention", f: func() error { return s.SetRetention(ctx, "a", &pb.RetentionPolicy{Revisions: 2}) }},
+		{desc: "delete", f: func() error { return s.Delete(ctx, "c") }},
+		{desc: "delete missing", f: func() error { return s.Delete(ctx, "c") }, wantCode: codes.NotFound},
+	} {
+		if err := tc.f(); status.Code(err) != tc.wantCode {
+			t.Fatalf("%v: %v, want %v", tc.desc, err, tc.wantCode)
+		}
+	}
+
+	for _, tc := range []struct {
+		deleted bool
+		want    []string
+	}{
+		{deleted: false, want: []string{"a"}},
+		{deleted: true, want: []string{"a", "b"}},
+	} {
+		dirs, err := s.List(ctx, tc.deleted)
+		if err != nil {
+			t.Fatalf("List(): %v", err)
+		}
+		var got []string
+		for _, d := range dirs {
+			got = append(got, d.DirectoryID)
+		}
+		if !cmp.Equal(got, tc.want) {
+			t.Errorf("List(%v): %v, want %v", tc.deleted, got, tc.want)
+		}
+	}
+
+	if _, err := s.Read(ctx, "b", false); status.Code(err) != codes.NotFound {
+		t.Errorf("Read(soft deleted): %v, want %v", err, codes.NotFound)
+	}
+	b, err := s.Read(ctx, "b", true)
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	if !b.Deleted || b.DeletedTimestamp.IsZero() {
+		t.Errorf("Read(b): Deleted: %v at %v, want deleted with a timestamp", b.Deleted, b.DeletedTimestamp)
+	}
+
+	// Directories that are read are copies.
+	a, err := s.Read(ctx, "a", false)
+	if err != nil {
+		t.Fatalf("Read(): %v", err)
+	}
+	a.Retention.Revisions = 10
+	a.Deleted = true
+	if again, err := s.Read(ctx, "a", false); err != nil || again.Retention.GetRevisions() != 2 {
+		t.Errorf("Read(a) after modifying a copy: %v, %v, want 2 retained revisions", again, err)
+	}
+}
+
+func TestDirectoryStorageIntegration(t *testing.T) {
+	storagetest.RunDirectoryStorageTests(t,
+		func(context.Context, *testing.T) (directory.Storage, func(context.Context)) {
+			return NewDirectoryStorage(), func(context.Context) {}
+		})
+}
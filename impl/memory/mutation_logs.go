@@ -12,105 +12,238 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package memory supplies fake in-memory implementations for testing purposes.
 package memory
 
 import (
 	"context"
-	"fmt"
 	"sort"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/water"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
-var clock = uint64(10) // Start logical clock at an arbitrary, non-zero place.
+type batch struct {
+	wm         water.Mark
+	msgs       []*mutator.LogMessage
+	requestIDs map[string]bool
+}
 
-// NewMutationLogs creates a new fake MutationLogs.
-func NewMutationLogs() MutationLogs {
-	return make(MutationLogs)
+// AddLogs adds logIDs to the mutation database.
+// Logs that already exist are left unchanged.
+func (m *Mutations) AddLogs(_ context.Context, directoryID string, logIDs ...int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.dir(directoryID)
+	for _, logID := range logIDs {
+		if _, ok := d.logs[logID]; !ok {
+			d.logs[logID] = &pb.InputLog{DirectoryId: directoryID, LogId: logID, Writable: true}
+		}
+	}
+	return nil
 }
 
-type batch struct {
-	wm   water.Mark
-	msgs []*mutator.LogMessage
+// SetWritable enables or disables new writes from going to logID.
+// Sealed and retired logs cannot be enabled.
+func (m *Mutations) SetWritable(_ context.Context, directoryID string, logID int64, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err := m.inputLog(directoryID, logID)
+	if err != nil {
+		return err
+	}
+	if enabled && l.State != pb.InputLog_ACTIVE {
+		return status.Errorf(codes.FailedPrecondition, "log %d of directory %v is %v", logID, directoryID, l.State)
+	}
+	l.Writable = enabled
+	return nil
 }
 
-// MutationLogs is a fake, in-memory implementation of a keyserver.MutationLogss.
-// All requests are presumed to be for the same domainID.
-// TODO(gbelvin): Support multiple domainIDs, if tests call for it.
-// MutationLogs is NOT threadsafe.
-type MutationLogs map[int64][]batch // Map of logID to Slice of LogMessages
+// SealLog marks the read-only log logID as sealed at final, the watermark just
+// beyond its last mutation. Returns FailedPrecondition if the log is writable
+// or holds mutations at or beyond final, or if ctx carries a stale fencing
+// token. Sealing a sealed or retired log does nothing.
+func (m *Mutations) SealLog(ctx context.Context, directoryID string, logID int64, final water.Mark) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err := m.inputLog(directoryID, logID)
+	if err != nil {
+		return err
+	}
+	if err := m.dirs[directoryID].checkFence(ctx, directoryID); err != nil {
+		return err
+	}
+	switch {
+	case l.State != pb.InputLog_ACTIVE:
+		return nil
+	case l.Writable:
+		return status.Errorf(codes.FailedPrecondition, "log %d of directory %v is writable", logID, directoryID)
+	}
+	logShard := m.dirs[directoryID].queue[logID]
+	if n := len(logShard); n > 0 && logShard[n-1].wm.Compare(final) >= 0 {
+		return status.Errorf(codes.FailedPrecondition,
+			"log %d of directory %v has mutations at or beyond %v", logID, directoryID, final)
+	}
+	l.State = pb.InputLog_SEALED
+	l.FinalWatermark = int64(final.Value())
+	return nil
+}
 
-// AddLogs adds logIDs to the mutation database.
-func (m MutationLogs) AddLogs(_ context.Context, _ string, logIDs ...int64) error {
-	for _, logID := range logIDs {
-		m[logID] = make([]batch, 0)
+// RetireLog marks the sealed log logID as retired. Returns FailedPrecondition
+// if the log has not been sealed. Retiring a retired log does nothing.
+func (m *Mutations) RetireLog(_ context.Context, directoryID string, logID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l, err := m.inputLog(directoryID, logID)
+	if err != nil {
+		return err
+	}
+	if l.State == pb.InputLog_ACTIVE {
+		return status.Errorf(codes.FailedPrecondition, "log %d of directory %v has not been sealed", logID, directoryID)
 	}
+	l.State = pb.InputLog_RETIRED
 	return nil
 }
 
-// ListLogs returns a sorted list of logIDs.
-func (m MutationLogs) ListLogs(_ context.Context, _ string, writable bool) ([]int64, error) {
-	logIDs := []int64{}
-	for logID := range m {
-		logIDs = append(logIDs, logID)
+// inputLog returns the registered log logID. m.mu must be held.
+func (m *Mutations) inputLog(directoryID string, logID int64) (*pb.InputLog, error) {
+	if d, ok := m.dirs[directoryID]; ok {
+		if l, ok := d.logs[logID]; ok {
+			return l, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "log %d not found for directory %v", logID, directoryID)
+}
+
+// ListInputLogs returns all the logs of directoryID, including retired logs,
+// ordered by logID.
+func (m *Mutations) ListInputLogs(_ context.Context, directoryID string) ([]*pb.InputLog, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var logs []*pb.InputLog
+	if d, ok := m.dirs[directoryID]; ok {
+		for _, l := range d.logs {
+			logs = append(logs, proto.Clone(l).(*pb.InputLog))
+		}
+	}
+	if len(logs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no log found for directory %v", directoryID)
+	}
+	sort.Slice(logs, func(a, b int) bool { return logs[a].LogId < logs[b].LogId })
+	return logs, nil
+}
+
+// ListLogs returns a sorted list of the logs of directoryID that have not
+// been retired, optionally filtered for writable logs.
+func (m *Mutations) ListLogs(_ context.Context, directoryID string, writable bool) ([]int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var logIDs []int64
+	if d, ok := m.dirs[directoryID]; ok {
+		for logID, l := range d.logs {
+			if l.State == pb.InputLog_RETIRED || writable && !l.Writable {
+				continue
+			}
+			logIDs = append(logIDs, logID)
+		}
+	}
+	if len(logIDs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no log found for directory %v", directoryID)
 	}
 	sort.Slice(logIDs, func(a, b int) bool { return logIDs[a] < logIDs[b] })
 	return logIDs, nil
 }
 
 // Send stores a batch of mutations in a given logID.
-func (m MutationLogs) Send(_ context.Context, _ string, logID int64, mutation ...*pb.EntryUpdate) (water.Mark, error) {
-	wm := water.NewMark(clock)
-	clock++
-	// Only save the Merkle tree bits.
-	entries := make([]*pb.SignedEntry, 0, len(mutation))
-	for _, i := range mutation {
-		entries = append(entries, i.Mutation)
+// Request IDs are remembered until their mutations are pruned.
+func (m *Mutations) Send(_ context.Context, directoryID string, logID int64, mutation ...*pb.EntryUpdate) (water.Mark, error) {
+	requestIDs := make(map[string]bool)
+	for _, u := range mutation {
+		if id := u.GetRequestId(); id != "" {
+			requestIDs[id] = true
+		}
 	}
 
-	logShard := m[logID]
-	if len(logShard) > 0 && logShard[len(logShard)-1].wm.Compare(wm) > 0 {
-		return water.Mark{}, fmt.Errorf("inserting mutation entry %v out of order", wm)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.dir(directoryID)
+	if wm, sent, err := d.findRequests(requestIDs); err != nil || sent {
+		return wm, err
+	}
+	// Reject writes to read-only logs so that they can be sealed.
+	if l, ok := d.logs[logID]; ok && !l.Writable {
+		return water.Mark{}, status.Errorf(codes.FailedPrecondition, "log %d of directory %v is read-only", logID, directoryID)
 	}
 
-	// Convert []SignedEntry into []LogMessage for storage.
-	msgs := make([]*mutator.LogMessage, 0, len(entries))
-	for i, e := range entries {
-		m := &mutator.LogMessage{
+	wm := water.NewMark(m.clock)
+	m.clock++
+	// Convert []EntryUpdate into []LogMessage for storage.
+	msgs := make([]*mutator.LogMessage, 0, len(mutation))
+	now := time.Now()
+	for i, u := range mutation {
+		msgs = append(msgs, &mutator.LogMessage{
 			LogID:     logID,
 			ID:        wm,
 			LocalID:   int64(i),
-			CreatedAt: time.Now(),
-			Mutation:  e,
-		}
-		msgs = append(msgs, m)
+			CreatedAt: now,
+			Mutation:  proto.Clone(u.GetMutation()).(*pb.SignedEntry),
+			ExtraData: proto.Clone(u.GetCommitted()).(*pb.Committed),
+		})
 	}
-	m[logID] = append(logShard, batch{wm: wm, msgs: msgs})
+	d.queue[logID] = append(d.queue[logID], batch{wm: wm, msgs: msgs, requestIDs: requestIDs})
 	return wm, nil
 }
 
+// findRequests returns true and the watermark of the original batch if all of
+// requestIDs were sent together, and false if none of them were sent.
+func (d *dirData) findRequests(requestIDs map[string]bool) (water.Mark, bool, error) {
+	if len(requestIDs) == 0 {
+		return water.Mark{}, false, nil
+	}
+	for _, logShard := range d.queue {
+		for _, b := range logShard {
+			var found int
+			for id := range requestIDs {
+				if b.requestIDs[id] {
+					found++
+				}
+			}
+			switch found {
+			case 0:
+				continue
+			case len(requestIDs):
+				return b.wm, true, nil
+			default:
+				return water.Mark{}, false, status.Errorf(codes.AlreadyExists, "some request ids were sent with a different batch")
+			}
+		}
+	}
+	return water.Mark{}, false, nil
+}
+
 // ReadLog returns mutations between [low, high).  Always returns complete batches.
 // ReadLog will return more items than batchSize if necessary to return a complete batch.
-func (m MutationLogs) ReadLog(_ context.Context, _ string,
+func (m *Mutations) ReadLog(_ context.Context, directoryID string,
 	logID int64, low, high water.Mark, batchSize int32) ([]*mutator.LogMessage, error) {
-	logShard := m[logID]
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	logShard := m.logShard(directoryID, logID)
 	if len(logShard) == 0 || batchSize == 0 {
 		return nil, nil
 	}
 	start := sort.Search(len(logShard), func(i int) bool { return logShard[i].wm.Compare(low) >= 0 })
 	end := sort.Search(len(logShard), func(i int) bool { return logShard[i].wm.Compare(high) >= 0 })
-	// If the search is unsuccessful, i will be equal to len(logShard).
-	if start == len(logShard) && logShard[start-1].wm.Compare(low) < 0 {
-		return nil, fmt.Errorf("invalid argument: low: %v, want <= max watermark: %v", low, logShard[start-1].wm)
-	}
 	out := make([]*mutator.LogMessage, 0, batchSize)
 	for i := start; i < end; i++ {
-		out = append(out, logShard[i].msgs...)
+		for _, msg := range logShard[i].msgs {
+			msg := *msg
+			out = append(out, &msg)
+		}
 		if int32(len(out)) >= batchSize {
 			break
 		}
@@ -119,9 +252,11 @@ func (m MutationLogs) ReadLog(_ context.Context, _ string,
 }
 
 // HighWatermark returns the highest watermark batchSize items beyond start.
-func (m MutationLogs) HighWatermark(_ context.Context, _ string, logID int64, start water.Mark,
+func (m *Mutations) HighWatermark(_ context.Context, directoryID string, logID int64, start water.Mark,
 	batchSize int32) (int32, water.Mark, error) {
-	logShard := m[logID]
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	logShard := m.logShard(directoryID, logID)
 	i := sort.Search(len(logShard), func(i int) bool { return logShard[i].wm.Compare(start) >= 0 })
 
 	count := int32(0)
@@ -132,3 +267,11 @@ func (m MutationLogs) HighWatermark(_ context.Context, _ string, logID int64, st
 	}
 	return count, high, nil
 }
+
+// logShard returns the queued batches of logID. m.mu must be held.
+func (m *Mutations) logShard(directoryID string, logID int64) []batch {
+	if d, ok := m.dirs[directoryID]; ok {
+		return d.queue[logID]
+	}
+	return nil
+}
@@ -16,31 +16,190 @@ package memory
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"sync"
 	"testing"
 
+	"github.com/google/keytransparency/core/adminserver"
 	"github.com/google/keytransparency/core/integration/storagetest"
 	"github.com/google/keytransparency/core/keyserver"
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/core/water"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
 
+func newForTest(ctx context.Context, t testing.TB, dirID string, logIDs ...int64) (*Mutations, func(context.Context)) {
+	m := NewMutations()
+	if err := m.AddLogs(ctx, dirID, logIDs...); err != nil {
+		t.Fatalf("AddLogs(): %v", err)
+	}
+	return m, func(context.Context) {}
+}
+
 // Tests for the tests!
 func TestMutationLogsIntegration(t *testing.T) {
 	storagetest.RunMutationLogsTests(t,
 		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (keyserver.MutationLogs, func(context.Context)) {
-			m := NewMutationLogs()
-			if err := m.AddLogs(ctx, dirID, logIDs...); err != nil {
-				t.Fatal(err)
-			}
-			return m, func(context.Context) {}
+			return newForTest(ctx, t, dirID, logIDs...)
+		})
+}
+
+func TestLogsAdminIntegration(t *testing.T) {
+	storagetest.RunLogsAdminTests(t,
+		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (adminserver.LogsAdmin, func(context.Context)) {
+			return newForTest(ctx, t, dirID, logIDs...)
 		})
 }
 
 func TestMutationLogsReaderIntegration(t *testing.T) {
 	storagetest.RunMutationLogsReaderTests(t,
 		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (storagetest.LogsReadWriter, func(context.Context)) {
-			m := NewMutationLogs()
-			if err := m.AddLogs(ctx, dirID, logIDs...); err != nil {
-				t.Fatal(err)
-			}
-			return m, func(context.Context) {}
+			return newForTest(ctx, t, dirID, logIDs...)
 		})
 }
+
+func TestDirectoriesAreSeparate(t *testing.T) {
+	ctx := context.Background()
+	m, done := newForTest(ctx, t, "a", 1)
+	defer done(ctx)
+	if err := m.AddLogs(ctx, "b", 2); err != nil {
+		t.Fatalf("AddLogs(): %v", err)
+	}
+	if _, err := m.Send(ctx, "a", 1, &pb.EntryUpdate{RequestId: "r"}); err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+	// Request IDs are scoped to a directory.
+	if _, err := m.Send(ctx, "b", 1, &pb.EntryUpdate{RequestId: "r"}, &pb.EntryUpdate{}); err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+
+	for _, tc := range []struct {
+		dirID    string
+		wantLogs []int64
+		wantMsgs int
+	}{
+		{dirID: "a", wantLogs: []int64{1}, wantMsgs: 1},
+		{dirID: "b", wantLogs: []int64{2}, wantMsgs: 2},
+	} {
+		logIDs, err := m.ListLogs(ctx, tc.dirID, false)
+		if err != nil {
+			t.Fatalf("ListLogs(%v): %v", tc.dirID, err)
+		}
+		if fmt.Sprint(logIDs) != fmt.Sprint(tc.wantLogs) {
+			t.Errorf("ListLogs(%v): %v, want %v", tc.dirID, logIDs, tc.wantLogs)
+		}
+		msgs, err := m.ReadLog(ctx, tc.dirID, 1, water.Mark{}, water.NewMark(math.MaxInt64), 10)
+		if err != nil {
+			t.Fatalf("ReadLog(%v): %v", tc.dirID, err)
+		}
+		if len(msgs) != tc.wantMsgs {
+			t.Errorf("ReadLog(%v): %v mutations, want %v", tc.dirID, len(msgs), tc.wantMsgs)
+		}
+	}
+	if _, err := m.ListLogs(ctx, "c", false); status.Code(err) != codes.NotFound {
+		t.Errorf("ListLogs(c): %v, want %v", err, codes.NotFound)
+	}
+}
+
+func TestLogLifecycle(t *testing.T) {
+	ctx := context.Background()
+	directoryID := "TestLogLifecycle"
+	m, done := newForTest(ctx, t, directoryID, 1, 2)
+	defer done(ctx)
+	wm, err := m.Send(ctx, directoryID, 1, &pb.EntryUpdate{})
+	if err != nil {
+		t.Fatalf("Send(): %v", err)
+	}
+	final := wm.Add(1)
+
+	// Test cases are cumulative.
+	for _, tc := range []struct {
+		desc     string
+		f        func() error
+		wantCode codes.Code
+	}{
+		{desc: "seal writable", f: func() error { return m.SealLog(ctx, directoryID, 1, final) },
+			wantCode: codes.FailedPrecondition},
+		{desc: "read-only", f: func() error { return m.SetWritable(ctx, directoryID, 1, false) }},
+		{desc: "send read-only", f: func() error { _, err := m.Send(ctx, directoryID, 1, &pb.EntryUpdate{}); return err },
+			wantCode: codes.FailedPrecondition},
+		{desc: "seal undrained", f: func() error { return m.SealLog(ctx, directoryID, 1, wm) },
+			wantCode: codes.FailedPrecondition},
+		{desc: "newer master", f: func() error {
+			return m.WriteBatchSources(election.WithToken(ctx, 3), directoryID, 1, &spb.MapMetadata{})
+		}},
+		{desc: "seal stale token", f: func() error { return m.SealLog(election.WithToken(ctx, 2), directoryID, 1, final) },
+			wantCode: codes.FailedPrecondition},
+		{desc: "seal", f: func() error { return m.SealLog(ctx, directoryID, 1, final) }},
+		{desc: "enable sealed", f: func() error { return m.SetWritable(ctx, directoryID, 1, true) },
+			wantCode: codes.FailedPrecondition},
+		{desc: "retire", f: func() error { return m.RetireLog(ctx, directoryID, 1) }},
+		{desc: "retire again", f: func() error { return m.RetireLog(ctx, directoryID, 1) }},
+	} {
+		if err := tc.f(); status.Code(err) != tc.wantCode {
+			t.Fatalf("%v: %v, want %v", tc.desc, err, tc.wantCode)
+		}
+	}
+
+	logIDs, err := m.ListLogs(ctx, directoryID, false)
+	if err != nil {
+		t.Fatalf("ListLogs(): %v", err)
+	}
+	if fmt.Sprint(logIDs) != "[2]" {
+		t.Errorf("ListLogs(): %v, want [2]", logIDs)
+	}
+	logs, err := m.ListInputLogs(ctx, directoryID)
+	if err != nil {
+		t.Fatalf("ListInputLogs(): %v", err)
+	}
+	if got := logs[0]; got.State != pb.InputLog_RETIRED || got.FinalWatermark != int64(final.Value()) {
+		t.Errorf("ListInputLogs(): %v, want RETIRED at %v", got, final)
+	}
+}
+
+// TestSendConcurrent is intended to be run with the race detector.
+func TestSendConcurrent(t *testing.T) {
+	ctx := context.Background()
+	const writers, sends = 8, 20
+	m := NewMutations()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		dirID := fmt.Sprintf("dir%d", w%2)
+		logID := int64(w % 4)
+		if err := m.AddLogs(ctx, dirID, logID); err != nil {
+			t.Fatalf("AddLogs(): %v", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < sends; i++ {
+				if _, err := m.Send(ctx, dirID, logID, &pb.EntryUpdate{}); err != nil {
+					t.Errorf("Send(): %v", err)
+					return
+				}
+				if _, _, err := m.HighWatermark(ctx, dirID, logID, water.Mark{}, math.MaxInt32); err != nil {
+					t.Errorf("HighWatermark(): %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var total int32
+	for w := 0; w < 4; w++ {
+		count, _, err := m.HighWatermark(ctx, fmt.Sprintf("dir%d", w%2), int64(w), water.Mark{}, math.MaxInt32)
+		if err != nil {
+			t.Fatalf("HighWatermark(): %v", err)
+		}
+		total += count
+	}
+	if want := int32(writers * sends); total != want {
+		t.Errorf("sent %v mutations, want %v", total, want)
+	}
+}
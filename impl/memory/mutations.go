@@ -0,0 +1,213 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory supplies in-memory implementations of the storage interfaces
+// used by the keyserver, sequencer and admin server. All implementations are
+// safe for concurrent use and keep the data of each directory separate.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/core/sequencer/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+// Mutations stores input logs, queued mutations and revision definitions in
+// memory. It implements the same interfaces as mutationstorage.Mutations.
+type Mutations struct {
+	mu    sync.RWMutex
+	clock uint64 // Logical clock used to assign watermarks.
+	dirs  map[string]*dirData
+}
+
+// dirData holds the data of a single directory.
+type dirData struct {
+	logs    map[int64]*pb.InputLog // Registered logs, by logID.
+	queue   map[int64][]batch      // Queued mutations, by logID.
+	batches map[int64]*spb.MapMetadata
+	pruned  int64 // Highest pruned revision.
+	token   int64 // Highest fencing token seen.
+}
+
+// checkFence records the fencing token carried by ctx as the highest token
+// seen for the directory, and returns FailedPrecondition if a higher one has
+// already been seen. Writes without a token are not fenced.
+func (d *dirData) checkFence(ctx context.Context, directoryID string) error {
+	token := election.Token(ctx)
+	if token == 0 {
+		return nil
+	}
+	if token < d.token {
+		return status.Errorf(codes.FailedPrecondition,
+			"fencing token %v of directory %v has been superseded by a newer master", token, directoryID)
+	}
+	d.token = token
+	return nil
+}
+
+// NewMutations creates a new, empty Mutations.
+func NewMutations() *Mutations {
+	return &Mutations{
+		clock: 10, // Start logical clock at an arbitrary, non-zero place.
+		dirs:  make(map[string]*dirData),
+	}
+}
+
+// dir returns the data of directoryID, creating it if needed.
+// m.mu must be held for writing.
+func (m *Mutations) dir(directoryID string) *dirData {
+	d, ok := m.dirs[directoryID]
+	if !ok {
+		d = &dirData{
+			logs:    make(map[int64]*pb.InputLog),
+			queue:   make(map[int64][]batch),
+			batches: make(map[int64]*spb.MapMetadata),
+		}
+		m.dirs[directoryID] = d
+	}
+	return d
+}
+
+// WriteBatchSources saves the definition of revision rev.
+// If revision has already been defined, this will fail.
+// Returns FailedPrecondition if ctx carries a stale fencing token.
+func (m *Mutations) WriteBatchSources(ctx context.Context, directoryID string, rev int64, sources *spb.MapMetadata) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.dir(directoryID)
+	if err := d.checkFence(ctx, directoryID); err != nil {
+		return err
+	}
+	if _, ok := d.batches[rev]; ok {
+		return status.Errorf(codes.AlreadyExists, "revision %v of directory %v already defined", rev, directoryID)
+	}
+	d.batches[rev] = proto.Clone(sources).(*spb.MapMetadata)
+	return nil
+}
+
+// ReadBatch returns the batch definitions for a given revision.
+func (m *Mutations) ReadBatch(_ context.Context, directoryID string, rev int64) (*spb.MapMetadata, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	meta, ok := m.dirs[directoryID].getBatch(rev)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "revision %v not found", rev)
+	}
+	return proto.Clone(meta).(*spb.MapMetadata), nil
+}
+
+func (d *dirData) getBatch(rev int64) (*spb.MapMetadata, bool) {
+	if d == nil {
+		return nil, false
+	}
+	meta, ok := d.batches[rev]
+	return meta, ok
+}
+
+// HighestRev returns the highest defined revision number for directoryID.
+func (m *Mutations) HighestRev(_ context.Context, directoryID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var highest int64
+	if d, ok := m.dirs[directoryID]; ok {
+		for rev := range d.batches {
+			if rev > highest {
+				highest = rev
+			}
+		}
+	}
+	return highest, nil
+}
+
+// PruneRevision deletes the mutations read by revision rev, which are those
+// below the high watermarks of meta, the batch definition of rev.
+// Revisions must be pruned in order. Pruning a revision that has already been
+// pruned does nothing. Returns the number of mutations deleted.
+// Returns FailedPrecondition if ctx carries a stale fencing token.
+func (m *Mutations) PruneRevision(ctx context.Context, directoryID string, rev int64, meta *spb.MapMetadata) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.dir(directoryID)
+	if err := d.checkFence(ctx, directoryID); err != nil {
+		return 0, err
+	}
+	switch {
+	case rev <= d.pruned:
+		return 0, nil
+	case rev != d.pruned+1:
+		return 0, status.Errorf(codes.FailedPrecondition,
+			"cannot prune revision %v before revision %v", rev, d.pruned+1)
+	}
+
+	var deleted int64
+	for _, source := range meta.GetSources() {
+		high := metadata.FromProto(source).HighMark()
+		logShard := d.queue[source.GetLogId()]
+		i := sort.Search(len(logShard), func(i int) bool { return logShard[i].wm.Compare(high) >= 0 })
+		for _, b := range logShard[:i] {
+			deleted += int64(len(b.msgs))
+		}
+		d.queue[source.GetLogId()] = logShard[i:]
+	}
+	d.pruned = rev
+	return deleted, nil
+}
+
+// PrunedRevision returns the highest revision whose mutations have been
+// pruned, or 0 if none have been.
+func (m *Mutations) PrunedRevision(_ context.Context, directoryID string) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if d, ok := m.dirs[directoryID]; ok {
+		return d.pruned, nil
+	}
+	return 0, nil
+}
+
+// ExpireRequests does nothing: request IDs are kept with their mutations, and
+// deleted when the mutations are pruned.
+func (m *Mutations) ExpireRequests(_ context.Context, directoryID string) (int64, error) {
+	return 0, nil
+}
+
+// DeleteDirectoryData deletes the input logs, queued mutations, and revision
+// definitions of directoryID. Deleting the data of a directory that has none
+// succeeds.
+func (m *Mutations) DeleteDirectoryData(_ context.Context, directoryID string) (*pb.DeletedDirectoryData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	deleted := &pb.DeletedDirectoryData{DirectoryId: directoryID}
+	d, ok := m.dirs[directoryID]
+	if !ok {
+		return deleted, nil
+	}
+	deleted.InputLogs = int64(len(d.logs))
+	for _, logShard := range d.queue {
+		for _, b := range logShard {
+			deleted.Mutations += int64(len(b.msgs))
+		}
+	}
+	deleted.Batches = int64(len(d.batches))
+	delete(m.dirs, directoryID)
+	return deleted, nil
+}
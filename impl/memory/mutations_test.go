@@ -0,0 +1,149 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/adminserver"
+	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/integration/storagetest"
+	"github.com/google/keytransparency/core/keyserver"
+	"github.com/google/keytransparency/core/sequencer"
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/core/sequencer/metadata"
+	"github.com/google/keytransparency/core/water"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+// Ensure that the memory backend provides every storage interface.
+var (
+	_ keyserver.MutationLogs  = &Mutations{}
+	_ keyserver.BatchReader   = &Mutations{}
+	_ sequencer.Batcher       = &Mutations{}
+	_ sequencer.LogsReader    = &Mutations{}
+	_ sequencer.Pruner        = &Mutations{}
+	_ sequencer.LogLifecycle  = &Mutations{}
+	_ adminserver.LogsAdmin   = &Mutations{}
+	_ adminserver.Batcher     = &Mutations{}
+	_ adminserver.DataDeleter = &Mutations{}
+	_ adminserver.AuditLog    = &AuditLog{}
+	_ directory.Storage       = &DirectoryStorage{}
+)
+
+func TestBatchIntegration(t *testing.T) {
+	storagetest.RunBatchStorageTests(t,
+		func(context.Context, *testing.T, string) (sequencer.Batcher, func(context.Context)) {
+			return NewMutations(), func(context.Context) {}
+		})
+}
+
+func TestPruneRevision(t *testing.T) {
+	ctx := context.Background()
+	directoryID := "TestPruneRevision"
+	m, done := newForTest(ctx, t, directoryID, 1)
+	defer done(ctx)
+	var marks []water.Mark
+	for i := 0; i < 3; i++ {
+		wm, err := m.Send(ctx, directoryID, 1, &pb.EntryUpdate{}, &pb.EntryUpdate{})
+		if err != nil {
+			t.Fatalf("Send(): %v", err)
+		}
+		marks = append(marks, wm)
+	}
+	batch := func(high int) *spb.MapMetadata {
+		return &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+			metadata.New(1, water.Mark{}, marks[high]).Proto(),
+		}}
+	}
+
+	for _, tc := range []struct {
+		desc        string
+		rev         int64
+		meta        *spb.MapMetadata
+		token       int64
+		wantDeleted int64
+		wantCode    codes.Code
+		wantPruned  int64
+		wantLeft    int
+	}{
+		{desc: "first", rev: 1, meta: batch(1), token: 2, wantDeleted: 2, wantPruned: 1, wantLeft: 4},
+		{desc: "again", rev: 1, meta: batch(1), wantDeleted: 0, wantPruned: 1, wantLeft: 4},
+		{desc: "skip", rev: 3, meta: batch(2), wantCode: codes.FailedPrecondition, wantPruned: 1, wantLeft: 4},
+		{desc: "stale token", rev: 2, meta: batch(2), token: 1, wantCode: codes.FailedPrecondition, wantPruned: 1, wantLeft: 4},
+		{desc: "next", rev: 2, meta: batch(2), wantDeleted: 2, wantPruned: 2, wantLeft: 2},
+	} {
+		deleted, err := m.PruneRevision(election.WithToken(ctx, tc.token), directoryID, tc.rev, tc.meta)
+		if got := status.Code(err); got != tc.wantCode {
+			t.Fatalf("%v: PruneRevision(): %v, want %v", tc.desc, err, tc.wantCode)
+		}
+		if deleted != tc.wantDeleted {
+			t.Errorf("%v: PruneRevision(): deleted %v, want %v", tc.desc, deleted, tc.wantDeleted)
+		}
+		if pruned, err := m.PrunedRevision(ctx, directoryID); err != nil || pruned != tc.wantPruned {
+			t.Errorf("%v: PrunedRevision(): %v, %v, want %v", tc.desc, pruned, err, tc.wantPruned)
+		}
+		msgs, err := m.ReadLog(ctx, directoryID, 1, water.Mark{}, water.NewMark(math.MaxInt64), 10)
+		if err != nil {
+			t.Fatalf("ReadLog(): %v", err)
+		}
+		if len(msgs) != tc.wantLeft {
+			t.Errorf("%v: log has %v mutations, want %v", tc.desc, len(msgs), tc.wantLeft)
+		}
+	}
+}
+
+func TestDeleteDirectoryData(t *testing.T) {
+	ctx := context.Background()
+	m, done := newForTest(ctx, t, "deleted", 1, 2)
+	defer done(ctx)
+	if err := m.AddLogs(ctx, "kept", 1); err != nil {
+		t.Fatalf("AddLogs(): %v", err)
+	}
+	for _, dirID := range []string{"deleted", "kept"} {
+		if _, err := m.Send(ctx, dirID, 1, &pb.EntryUpdate{}, &pb.EntryUpdate{}); err != nil {
+			t.Fatalf("Send(): %v", err)
+		}
+		if err := m.WriteBatchSources(ctx, dirID, 1, &spb.MapMetadata{}); err != nil {
+			t.Fatalf("WriteBatchSources(): %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		desc string
+		want *pb.DeletedDirectoryData
+	}{
+		{desc: "first", want: &pb.DeletedDirectoryData{DirectoryId: "deleted", InputLogs: 2, Mutations: 2, Batches: 1}},
+		{desc: "retry", want: &pb.DeletedDirectoryData{DirectoryId: "deleted"}},
+	} {
+		got, err := m.DeleteDirectoryData(ctx, "deleted")
+		if err != nil {
+			t.Fatalf("%v: DeleteDirectoryData(): %v", tc.desc, err)
+		}
+		if !proto.Equal(got, tc.want) {
+			t.Errorf("%v: DeleteDirectoryData(): %v, want %v", tc.desc, got, tc.want)
+		}
+	}
+	if _, err := m.ReadBatch(ctx, "kept", 1); err != nil {
+		t.Errorf("ReadBatch(kept): %v", err)
+	}
+}
@@ -0,0 +1,545 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trillianstorage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogStorage stores the sequenced leaves, Merkle nodes and latest signed root
+// of Trillian logs in memory. Only PREORDERED_LOG trees, which is what Key
+// Transparency uses, are supported: queueing leaves for the log to sequence
+// returns codes.Unimplemented.
+type LogStorage struct {
+	admin storage.AdminStorage
+	mu    sync.Mutex
+	trees map[int64]*logTree
+}
+
+// NewLogStorage returns an empty LogStorage. The trees it serves are listed in
+// admin.
+func NewLogStorage(admin storage.AdminStorage) *LogStorage {
+	return &LogStorage{
+		admin: admin,
+		trees: make(map[int64]*logTree),
+	}
+}
+
+// logTree holds the committed data of a single log.
+type logTree struct {
+	writer sync.Mutex // Serializes read-write transactions.
+
+	mu         sync.RWMutex // Protects the fields below.
+	root       *trillian.SignedLogRoot
+	leaves     map[int64]*trillian.LogLeaf // By leaf index.
+	identities map[string]bool             // LeafIdentityHashes of leaves.
+	nodes      nodeVersions
+}
+
+// tree returns the data of treeID, creating it if needed.
+func (m *LogStorage) tree(treeID int64) *logTree {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.trees[treeID]
+	if !ok {
+		t = &logTree{
+			leaves:     make(map[int64]*trillian.LogLeaf),
+			identities: make(map[string]bool),
+			nodes:      make(nodeVersions),
+		}
+		m.trees[treeID] = t
+	}
+	return t
+}
+
+// CheckDatabaseAccessible always succeeds.
+func (m *LogStorage) CheckDatabaseAccessible(context.Context) error { return nil }
+
+// Snapshot starts a read-only transaction that is not tied to a tree.
+func (m *LogStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
+	return &logSnapshot{admin: m.admin}, nil
+}
+
+// SnapshotForTree starts a read-only transaction. As with the SQL storage, the
+// transaction is returned along with storage.ErrTreeNeedsInit if the log has no
+// root yet.
+func (m *LogStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
+	return m.begin(tree, true)
+}
+
+// ReadWriteTransaction runs f in a transaction that writes the next revision
+// of tree. f is called with storage.ErrTreeNeedsInit pending if the log has
+// no root yet, so that it may be initialized.
+func (m *LogStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.LogTXFunc) error {
+	tx, err := m.begin(tree, false)
+	if err != nil && err != storage.ErrTreeNeedsInit {
+		return err
+	}
+	defer tx.Close()
+	if err := f(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// QueueLeaves is not supported.
+func (m *LogStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	return nil, errQueueUnimplemented
+}
+
+// AddSequencedLeaves stores leaves at the indices they name.
+func (m *LogStorage) AddSequencedLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	tx, err := m.begin(tree, false)
+	if err != nil {
+		if tx != nil {
+			tx.Close()
+		}
+		return nil, err
+	}
+	defer tx.Close()
+	res, err := tx.AddSequencedLeaves(ctx, leaves, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (m *LogStorage) begin(tree *trillian.Tree, readonly bool) (*logTX, error) {
+	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	t := m.tree(tree.TreeId)
+	tx := &logTX{
+		t:             t,
+		treeType:      tree.TreeType,
+		hashSize:      hasher.Size(),
+		readonly:      readonly,
+		open:          true,
+		writeRevision: -1,
+	}
+	if !readonly {
+		t.writer.Lock()
+		tx.unlock = t.writer.Unlock
+		tx.leaves = make(map[int64]*trillian.LogLeaf)
+		tx.nodes = make(map[string]storage.Node)
+	}
+
+	t.mu.RLock()
+	tx.slr = t.root
+	t.mu.RUnlock()
+	if tx.slr == nil {
+		return tx, storage.ErrTreeNeedsInit
+	}
+	if err := tx.root.UnmarshalBinary(tx.slr.LogRoot); err != nil {
+		tx.Close()
+		return nil, err
+	}
+	tx.writeRevision = int64(tx.root.Revision) + 1
+	return tx, nil
+}
+
+var errQueueUnimplemented = status.Error(codes.Unimplemented, "trillianstorage: only PREORDERED_LOG trees are supported")
+
+// logSnapshot lists the active logs in the admin storage.
+type logSnapshot struct {
+	admin storage.AdminStorage
+}
+
+// GetActiveLogIDs returns the IDs of the logs that are not deleted or frozen.
+func (s *logSnapshot) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
+	trees, err := storage.ListTrees(ctx, s.admin, false /* includeDeleted */)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, tree := range trees {
+		switch tree.TreeType {
+		case trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG:
+		default:
+			continue
+		}
+		switch tree.TreeState {
+		case trillian.TreeState_ACTIVE, trillian.TreeState_DRAINING:
+			ids = append(ids, tree.TreeId)
+		}
+	}
+	return ids, nil
+}
+
+func (s *logSnapshot) Commit(context.Context) error { return nil }
+func (s *logSnapshot) Rollback() error              { return nil }
+func (s *logSnapshot) Close() error                 { return nil }
+
+// logTX buffers writes until Commit.
+type logTX struct {
+	mu            sync.Mutex
+	t             *logTree
+	treeType      trillian.TreeType
+	hashSize      int
+	readonly      bool
+	open          bool
+	unlock        func()
+	slr           *trillian.SignedLogRoot // Latest root when the transaction began.
+	root          types.LogRootV1
+	writeRevision int64
+	leaves        map[int64]*trillian.LogLeaf
+	nodes         map[string]storage.Node
+	newRoot       *trillian.SignedLogRoot
+}
+
+func (tx *logTX) ReadRevision(ctx context.Context) (int64, error) {
+	return int64(tx.root.Revision), nil
+}
+
+func (tx *logTX) WriteRevision(ctx context.Context) (int64, error) {
+	if tx.writeRevision < 0 {
+		return tx.writeRevision, storage.ErrTreeNeedsInit
+	}
+	return tx.writeRevision, nil
+}
+
+// GetMerkleNodes returns the latest version at or before treeRevision of each
+// of ids that has been stored.
+func (tx *logTX) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	return tx.t.nodes.get(ids, treeRevision, tx.nodes, tx.writeRevision), nil
+}
+
+// SetMerkleNodes stores nodes at the write revision.
+func (tx *logTX) SetMerkleNodes(ctx context.Context, nodes []storage.Node) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		n.NodeRevision = tx.writeRevision
+		tx.nodes[n.NodeID.AsKey()] = n
+	}
+	return nil
+}
+
+// leaf returns the leaf at index, which may have been added by tx.
+// tx.mu and tx.t.mu must be held.
+func (tx *logTX) leaf(index int64) (*trillian.LogLeaf, bool) {
+	if l, ok := tx.leaves[index]; ok {
+		return l, true
+	}
+	l, ok := tx.t.leaves[index]
+	return l, ok
+}
+
+func (tx *logTX) GetSequencedLeafCount(ctx context.Context) (int64, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return 0, errClosed
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	return int64(len(tx.t.leaves) + len(tx.leaves)), nil
+}
+
+func (tx *logTX) GetLeavesByIndex(ctx context.Context, indices []int64) ([]*trillian.LogLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	if tx.treeType == trillian.TreeType_LOG {
+		for _, i := range indices {
+			if i < 0 || i >= int64(tx.root.TreeSize) {
+				return nil, status.Errorf(codes.OutOfRange, "invalid leaf index %d, want < TreeSize(%d)", i, tx.root.TreeSize)
+			}
+		}
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	ret := make([]*trillian.LogLeaf, 0, len(indices))
+	for _, i := range indices {
+		if l, ok := tx.leaf(i); ok {
+			ret = append(ret, proto.Clone(l).(*trillian.LogLeaf))
+		}
+	}
+	if got, want := len(ret), len(indices); got != want {
+		return nil, status.Errorf(codes.Internal, "len(ret): %d, want %d", got, want)
+	}
+	return ret, nil
+}
+
+func (tx *logTX) GetLeavesByRange(ctx context.Context, start, count int64) ([]*trillian.LogLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	return tx.getLeavesByRange(start, count)
+}
+
+// getLeavesByRange returns the contiguous leaves from start, up to count of
+// them. tx.mu must be held.
+func (tx *logTX) getLeavesByRange(start, count int64) ([]*trillian.LogLeaf, error) {
+	if count <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid count %d, want > 0", count)
+	}
+	if start < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid start %d, want >= 0", start)
+	}
+	treeSize := int64(tx.root.TreeSize)
+	if tx.treeType == trillian.TreeType_LOG {
+		if treeSize <= 0 {
+			return nil, status.Errorf(codes.OutOfRange, "empty tree")
+		} else if start >= treeSize {
+			return nil, status.Errorf(codes.OutOfRange, "invalid start %d, want < TreeSize(%d)", start, treeSize)
+		}
+		if maxCount := treeSize - start; count > maxCount {
+			count = maxCount
+		}
+	}
+
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	ret := make([]*trillian.LogLeaf, 0, count)
+	for i := start; i < start+count; i++ {
+		l, ok := tx.leaf(i)
+		if !ok {
+			if i < treeSize {
+				return nil, status.Errorf(codes.Internal, "leaf %d of tree of size %d is missing", i, treeSize)
+			}
+			break
+		}
+		ret = append(ret, proto.Clone(l).(*trillian.LogLeaf))
+	}
+	return ret, nil
+}
+
+func (tx *logTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	want := make(map[string]bool)
+	for _, h := range leafHashes {
+		want[string(h)] = true
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	var ret []*trillian.LogLeaf
+	for _, leaves := range []map[int64]*trillian.LogLeaf{tx.t.leaves, tx.leaves} {
+		for _, l := range leaves {
+			if want[string(l.MerkleLeafHash)] {
+				ret = append(ret, proto.Clone(l).(*trillian.LogLeaf))
+			}
+		}
+	}
+	if orderBySequence {
+		sort.Slice(ret, func(i, j int) bool { return ret[i].LeafIndex < ret[j].LeafIndex })
+	}
+	return ret, nil
+}
+
+// LatestSignedLogRoot returns the latest root when the transaction began.
+func (tx *logTX) LatestSignedLogRoot(ctx context.Context) (*trillian.SignedLogRoot, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	if tx.slr == nil {
+		return nil, storage.ErrTreeNeedsInit
+	}
+	return tx.slr, nil
+}
+
+// StoreSignedLogRoot makes root the latest root when the transaction commits.
+func (tx *logTX) StoreSignedLogRoot(ctx context.Context, root *trillian.SignedLogRoot) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open || tx.readonly {
+		return errClosed
+	}
+	var r types.LogRootV1
+	if err := r.UnmarshalBinary(root.LogRoot); err != nil {
+		return err
+	}
+	if tx.slr != nil && r.Revision <= tx.root.Revision {
+		return status.Errorf(codes.AlreadyExists, "log root at revision %v already stored", r.Revision)
+	}
+	tx.newRoot = proto.Clone(root).(*trillian.SignedLogRoot)
+	return nil
+}
+
+// QueueLeaves is not supported.
+func (tx *logTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error) {
+	return nil, errQueueUnimplemented
+}
+
+// DequeueLeaves returns the leaves that follow the current tree, up to limit
+// of them.
+func (tx *logTX) DequeueLeaves(ctx context.Context, limit int, cutoff time.Time) ([]*trillian.LogLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	if tx.treeType != trillian.TreeType_PREORDERED_LOG {
+		return nil, errQueueUnimplemented
+	}
+	return tx.getLeavesByRange(int64(tx.root.TreeSize), int64(limit))
+}
+
+// AddSequencedLeaves stores leaves at the indices they name. Leaves whose
+// index or LeafIdentityHash is already taken are reported with a
+// FailedPrecondition status and not stored.
+func (tx *logTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open || tx.readonly {
+		return nil, errClosed
+	}
+	queued, err := ptypes.TimestampProto(timestamp)
+	if err != nil {
+		return nil, err
+	}
+	integrated, err := ptypes.TimestampProto(time.Unix(0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	identities := make(map[string]bool)
+	for _, l := range tx.leaves {
+		identities[string(l.LeafIdentityHash)] = true
+	}
+	ok := status.New(codes.OK, "OK").Proto()
+	res := make([]*trillian.QueuedLogLeaf, len(leaves))
+	for i, leaf := range leaves {
+		if got, want := len(leaf.LeafIdentityHash), tx.hashSize; got != want {
+			return nil, status.Errorf(codes.FailedPrecondition, "leaves[%d] has incorrect hash size %d, want %d", i, got, want)
+		}
+		res[i] = &trillian.QueuedLogLeaf{Status: ok}
+		id := string(leaf.LeafIdentityHash)
+		if identities[id] || tx.t.identities[id] {
+			res[i].Status = status.New(codes.FailedPrecondition, "conflicting LeafIdentityHash").Proto()
+			continue
+		}
+		if _, exists := tx.leaf(leaf.LeafIndex); exists {
+			res[i].Status = status.New(codes.FailedPrecondition, "conflicting LeafIndex").Proto()
+			continue
+		}
+		l := proto.Clone(leaf).(*trillian.LogLeaf)
+		l.QueueTimestamp = queued
+		l.IntegrateTimestamp = integrated
+		tx.leaves[l.LeafIndex] = l
+		identities[id] = true
+	}
+	return res, nil
+}
+
+// UpdateSequencedLeaves is not supported.
+func (tx *logTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf) error {
+	return errQueueUnimplemented
+}
+
+func (tx *logTX) checkWritable() error {
+	if !tx.open || tx.readonly {
+		return errClosed
+	}
+	if tx.writeRevision < 0 {
+		return storage.ErrTreeNeedsInit
+	}
+	return nil
+}
+
+// Commit makes the writes of the transaction visible to readers.
+func (tx *logTX) Commit(context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return errClosed
+	}
+	tx.open = false
+	if tx.unlock != nil {
+		defer tx.unlock()
+	}
+	if tx.readonly {
+		return nil
+	}
+
+	t := tx.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, l := range tx.leaves {
+		t.leaves[i] = l
+		t.identities[string(l.LeafIdentityHash)] = true
+	}
+	t.nodes.commit(tx.nodes)
+	if tx.newRoot != nil {
+		t.root = tx.newRoot
+	}
+	return nil
+}
+
+// Rollback discards the writes of the transaction.
+func (tx *logTX) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return errClosed
+	}
+	tx.open = false
+	if tx.unlock != nil {
+		tx.unlock()
+	}
+	return nil
+}
+
+// Close rolls back the transaction if it is still open.
+func (tx *logTX) Close() error {
+	if tx.IsOpen() {
+		return tx.Rollback()
+	}
+	return nil
+}
+
+func (tx *logTX) IsOpen() bool {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.open
+}
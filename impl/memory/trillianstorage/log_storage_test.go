@@ -0,0 +1,127 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trillianstorage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/testonly/integration"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+
+	tpb "github.com/google/trillian"
+)
+
+func TestLogStorage(t *testing.T) {
+	ctx := context.Background()
+	env, err := integration.NewLogEnvWithRegistry(ctx, 1, newRegistry())
+	if err != nil {
+		t.Fatalf("NewLogEnvWithRegistry(): %v", err)
+	}
+	defer env.Close()
+	tree, err := client.CreateAndInitTree(ctx, &tpb.CreateTreeRequest{
+		Tree: &tpb.Tree{
+			TreeState:          tpb.TreeState_ACTIVE,
+			TreeType:           tpb.TreeType_PREORDERED_LOG,
+			HashStrategy:       tpb.HashStrategy_RFC6962_SHA256,
+			SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+			HashAlgorithm:      sigpb.DigitallySigned_SHA256,
+			MaxRootDuration:    ptypes.DurationProto(0),
+		},
+		KeySpec: keySpec,
+	}, env.Admin, nil, env.Log)
+	if err != nil {
+		t.Fatalf("CreateAndInitTree(): %v", err)
+	}
+	verifier, err := client.NewLogVerifierFromTree(tree)
+	if err != nil {
+		t.Fatalf("NewLogVerifierFromTree(): %v", err)
+	}
+
+	var trusted types.LogRootV1
+	var data [][]byte
+	for _, batch := range []int{3, 5} {
+		var leaves []*tpb.LogLeaf
+		for i := 0; i < batch; i++ {
+			leaf := verifier.BuildLeaf([]byte(fmt.Sprintf("leaf %d", len(data))))
+			leaf.LeafIndex = int64(len(data))
+			leaves = append(leaves, leaf)
+			data = append(data, leaf.LeafValue)
+		}
+		resp, err := env.Log.AddSequencedLeaves(ctx, &tpb.AddSequencedLeavesRequest{
+			LogId:  tree.TreeId,
+			Leaves: leaves,
+		})
+		if err != nil {
+			t.Fatalf("AddSequencedLeaves(): %v", err)
+		}
+		for _, r := range resp.Results {
+			if got, want := codes.Code(r.GetStatus().GetCode()), codes.OK; got != want {
+				t.Errorf("AddSequencedLeaves(): %v, want %v", r.GetStatus(), want)
+			}
+		}
+
+		// Wait for the sequencer to integrate the batch.
+		var root *types.LogRootV1
+		for root == nil || root.TreeSize < uint64(len(data)) {
+			time.Sleep(100 * time.Millisecond)
+			slr, err := env.Log.GetLatestSignedLogRoot(ctx, &tpb.GetLatestSignedLogRootRequest{
+				LogId:         tree.TreeId,
+				FirstTreeSize: int64(trusted.TreeSize),
+			})
+			if err != nil {
+				t.Fatalf("GetLatestSignedLogRoot(): %v", err)
+			}
+			root, err = verifier.VerifyRoot(&trusted, slr.SignedLogRoot, slr.Proof.GetHashes())
+			if err != nil {
+				t.Fatalf("VerifyRoot(): %v", err)
+			}
+		}
+		trusted = *root
+	}
+
+	for i, d := range data {
+		resp, err := env.Log.GetInclusionProof(ctx, &tpb.GetInclusionProofRequest{
+			LogId:     tree.TreeId,
+			LeafIndex: int64(i),
+			TreeSize:  int64(trusted.TreeSize),
+		})
+		if err != nil {
+			t.Fatalf("GetInclusionProof(%v): %v", i, err)
+		}
+		if err := verifier.VerifyInclusionAtIndex(&trusted, d, int64(i), resp.Proof.Hashes); err != nil {
+			t.Errorf("VerifyInclusionAtIndex(%v): %v", i, err)
+		}
+	}
+
+	// Leaves that are already stored are rejected.
+	dup := verifier.BuildLeaf(data[0])
+	resp, err := env.Log.AddSequencedLeaves(ctx, &tpb.AddSequencedLeavesRequest{
+		LogId:  tree.TreeId,
+		Leaves: []*tpb.LogLeaf{dup},
+	})
+	if err != nil {
+		t.Fatalf("AddSequencedLeaves(duplicate): %v", err)
+	}
+	if got := codes.Code(resp.Results[0].GetStatus().GetCode()); got == codes.OK {
+		t.Errorf("AddSequencedLeaves(duplicate): %v, want error", got)
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trillianstorage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MapStorage stores the leaves, Merkle nodes and signed roots of Trillian maps
+// in memory. Because read-write transactions are serialized, the map server
+// must be configured with UseSingleTransaction. Otherwise the sparse Merkle
+// tree writer would open nested transactions and deadlock.
+type MapStorage struct {
+	mu    sync.Mutex
+	trees map[int64]*mapTree
+}
+
+// NewMapStorage returns an empty MapStorage.
+func NewMapStorage() *MapStorage {
+	return &MapStorage{trees: make(map[int64]*mapTree)}
+}
+
+// mapTree holds the committed data of a single map.
+type mapTree struct {
+	writer sync.Mutex // Serializes read-write transactions.
+
+	mu     sync.RWMutex // Protects the fields below.
+	roots  map[int64]*trillian.SignedMapRoot
+	latest int64                  // Latest revision with a root, or -1.
+	leaves map[string][]leafAtRev // Versions by key hash, in revision order.
+	nodes  nodeVersions
+}
+
+type leafAtRev struct {
+	rev  int64
+	leaf *trillian.MapLeaf
+}
+
+// tree returns the data of treeID, creating it if needed.
+func (m *MapStorage) tree(treeID int64) *mapTree {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.trees[treeID]
+	if !ok {
+		t = &mapTree{
+			roots:  make(map[int64]*trillian.SignedMapRoot),
+			latest: -1,
+			leaves: make(map[string][]leafAtRev),
+			nodes:  make(nodeVersions),
+		}
+		m.trees[treeID] = t
+	}
+	return t
+}
+
+// CheckDatabaseAccessible always succeeds.
+func (m *MapStorage) CheckDatabaseAccessible(context.Context) error { return nil }
+
+// SnapshotForTree starts a read-only transaction.
+func (m *MapStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyMapTreeTX, error) {
+	return &mapTX{
+		t:             m.tree(tree.TreeId),
+		readonly:      true,
+		open:          true,
+		readRevision:  -1,
+		writeRevision: -1,
+	}, nil
+}
+
+// ReadWriteTransaction runs f in a transaction that writes the next revision
+// of tree. As with the SQL storage, f is called with storage.ErrTreeNeedsInit
+// pending if the map has no roots yet, so that it may be initialized.
+func (m *MapStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.MapTXFunc) error {
+	t := m.tree(tree.TreeId)
+	t.writer.Lock()
+	t.mu.RLock()
+	latest := t.latest
+	t.mu.RUnlock()
+
+	tx := &mapTX{
+		t:             t,
+		open:          true,
+		unlock:        t.writer.Unlock,
+		readRevision:  latest,
+		writeRevision: -1,
+		leaves:        make(map[string]*trillian.MapLeaf),
+		nodes:         make(map[string]storage.Node),
+	}
+	if latest >= 0 {
+		tx.writeRevision = latest + 1
+	}
+	defer tx.Close()
+	if err := f(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// mapTX buffers writes until Commit. The sparse Merkle tree writer calls it
+// from several goroutines at once.
+type mapTX struct {
+	mu            sync.Mutex
+	t             *mapTree
+	readonly      bool
+	open          bool
+	unlock        func()
+	readRevision  int64
+	writeRevision int64
+	leaves        map[string]*trillian.MapLeaf
+	nodes         map[string]storage.Node
+	root          *trillian.SignedMapRoot
+	rootRev       int64
+}
+
+func (tx *mapTX) ReadRevision(ctx context.Context) (int64, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.readRevision, nil
+}
+
+func (tx *mapTX) WriteRevision(ctx context.Context) (int64, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.writeRevision < 0 {
+		return tx.writeRevision, errors.New("trillianstorage: write revision not populated")
+	}
+	return tx.writeRevision, nil
+}
+
+// GetMerkleNodes returns the latest version at or before treeRevision of each
+// of ids that has been stored.
+func (tx *mapTX) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []storage.NodeID) ([]storage.Node, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+	return tx.t.nodes.get(ids, treeRevision, tx.nodes, tx.writeRevision), nil
+}
+
+// SetMerkleNodes stores nodes at the write revision.
+func (tx *mapTX) SetMerkleNodes(ctx context.Context, nodes []storage.Node) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		n.NodeRevision = tx.writeRevision
+		tx.nodes[n.NodeID.AsKey()] = n
+	}
+	return nil
+}
+
+// Get returns the latest version at or before revision of each of the leaves
+// in keyHashes that has been set. A negative revision reads the latest version.
+func (tx *mapTX) Get(ctx context.Context, revision int64, keyHashes [][]byte) ([]*trillian.MapLeaf, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	if revision < 0 {
+		revision = math.MaxInt64
+	}
+	tx.t.mu.RLock()
+	defer tx.t.mu.RUnlock()
+
+	ret := make([]*trillian.MapLeaf, 0, len(keyHashes))
+	for _, index := range keyHashes {
+		var leaf *trillian.MapLeaf
+		if l, ok := tx.leaves[string(index)]; ok && tx.writeRevision <= revision {
+			leaf = l
+		} else {
+			versions := tx.t.leaves[string(index)]
+			for i := len(versions) - 1; i >= 0; i-- {
+				if versions[i].rev <= revision {
+					leaf = versions[i].leaf
+					break
+				}
+			}
+		}
+		if leaf == nil {
+			continue
+		}
+		leaf = proto.Clone(leaf).(*trillian.MapLeaf)
+		leaf.Index = index
+		ret = append(ret, leaf)
+	}
+	return ret, nil
+}
+
+// Set stores value at keyHash in the write revision.
+func (tx *mapTX) Set(ctx context.Context, keyHash []byte, value *trillian.MapLeaf) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if err := tx.checkWritable(); err != nil {
+		return err
+	}
+	tx.leaves[string(keyHash)] = proto.Clone(value).(*trillian.MapLeaf)
+	return nil
+}
+
+func (tx *mapTX) GetSignedMapRoot(ctx context.Context, revision int64) (*trillian.SignedMapRoot, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	tx.t.mu.RLock()
+	root, ok := tx.t.roots[revision]
+	tx.t.mu.RUnlock()
+	if !ok {
+		if revision == 0 {
+			return nil, storage.ErrTreeNeedsInit
+		}
+		return nil, status.Errorf(codes.NotFound, "map root at revision %v not found", revision)
+	}
+	tx.readRevision = revision
+	return root, nil
+}
+
+func (tx *mapTX) LatestSignedMapRoot(ctx context.Context) (*trillian.SignedMapRoot, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return nil, errClosed
+	}
+	tx.t.mu.RLock()
+	latest := tx.t.latest
+	root := tx.t.roots[latest]
+	tx.t.mu.RUnlock()
+	if latest < 0 {
+		return nil, storage.ErrTreeNeedsInit
+	}
+	tx.readRevision = latest
+	return root, nil
+}
+
+// StoreSignedMapRoot stores root at the revision it names.
+func (tx *mapTX) StoreSignedMapRoot(ctx context.Context, root *trillian.SignedMapRoot) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open || tx.readonly {
+		return errClosed
+	}
+	var r types.MapRootV1
+	if err := r.UnmarshalBinary(root.MapRoot); err != nil {
+		return err
+	}
+	rev := int64(r.Revision)
+	tx.t.mu.RLock()
+	_, exists := tx.t.roots[rev]
+	tx.t.mu.RUnlock()
+	if exists || tx.root != nil {
+		return status.Errorf(codes.AlreadyExists, "map root at revision %v already stored", rev)
+	}
+	tx.root = proto.Clone(root).(*trillian.SignedMapRoot)
+	tx.rootRev = rev
+	return nil
+}
+
+func (tx *mapTX) checkWritable() error {
+	if !tx.open || tx.readonly {
+		return errClosed
+	}
+	if tx.writeRevision < 0 {
+		return storage.ErrTreeNeedsInit
+	}
+	return nil
+}
+
+// Commit makes the writes of the transaction visible to readers.
+func (tx *mapTX) Commit(context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return errClosed
+	}
+	tx.open = false
+	if tx.unlock != nil {
+		defer tx.unlock()
+	}
+	if tx.readonly {
+		return nil
+	}
+
+	t := tx.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, leaf := range tx.leaves {
+		t.leaves[key] = append(t.leaves[key], leafAtRev{rev: tx.writeRevision, leaf: leaf})
+	}
+	t.nodes.commit(tx.nodes)
+	if tx.root != nil {
+		t.roots[tx.rootRev] = tx.root
+		if tx.rootRev > t.latest {
+			t.latest = tx.rootRev
+		}
+	}
+	return nil
+}
+
+// Rollback discards the writes of the transaction.
+func (tx *mapTX) Rollback() error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if !tx.open {
+		return errClosed
+	}
+	tx.open = false
+	if tx.unlock != nil {
+		tx.unlock()
+	}
+	return nil
+}
+
+// Close rolls back the transaction if it is still open.
+func (tx *mapTX) Close() error {
+	if tx.IsOpen() {
+		return tx.Rollback()
+	}
+	return nil
+}
+
+func (tx *mapTX) IsOpen() bool {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.open
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trillianstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/crypto/keys/der"
+	"github.com/google/trillian/crypto/keyspb"
+	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/testonly/integration"
+
+	tpb "github.com/google/trillian"
+	_ "github.com/google/trillian/merkle/coniks"  // Register hasher
+	_ "github.com/google/trillian/merkle/rfc6962" // Register hasher
+)
+
+var (
+	_ storage.MapStorage = &MapStorage{}
+	_ storage.LogStorage = &LogStorage{}
+
+	keySpec = &keyspb.Specification{
+		Params: &keyspb.Specification_EcdsaParams{
+			EcdsaParams: &keyspb.Specification_ECDSA{Curve: keyspb.Specification_ECDSA_P256},
+		},
+	}
+)
+
+func newRegistry() extension.Registry {
+	admin := memory.NewAdminStorage(memory.NewTreeStorage())
+	return extension.Registry{
+		AdminStorage:  admin,
+		LogStorage:    NewLogStorage(admin),
+		MapStorage:    NewMapStorage(),
+		QuotaManager:  quota.Noop(),
+		MetricFactory: monitoring.InertMetricFactory{},
+		NewKeyProto: func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
+			return der.NewProtoFromSpec(spec)
+		},
+	}
+}
+
+func index(i byte) []byte {
+	h := sha256.Sum256([]byte{i})
+	return h[:]
+}
+
+func TestMapStorage(t *testing.T) {
+	ctx := context.Background()
+	env, err := integration.NewMapEnvWithRegistry(newRegistry(), true /* singleTX */)
+	if err != nil {
+		t.Fatalf("NewMapEnvWithRegistry(): %v", err)
+	}
+	defer env.Close()
+	tree, err := client.CreateAndInitTree(ctx, &tpb.CreateTreeRequest{
+		Tree: &tpb.Tree{
+			TreeState:          tpb.TreeState_ACTIVE,
+			TreeType:           tpb.TreeType_MAP,
+			HashStrategy:       tpb.HashStrategy_CONIKS_SHA256,
+			SignatureAlgorithm: sigpb.DigitallySigned_ECDSA,
+			HashAlgorithm:      sigpb.DigitallySigned_SHA256,
+			MaxRootDuration:    ptypes.DurationProto(0),
+		},
+		KeySpec: keySpec,
+	}, env.Admin, env.Map, nil)
+	if err != nil {
+		t.Fatalf("CreateAndInitTree(): %v", err)
+	}
+	verifier, err := client.NewMapVerifierFromTree(tree)
+	if err != nil {
+		t.Fatalf("NewMapVerifierFromTree(): %v", err)
+	}
+
+	// Each revision sets some leaves. want[rev] holds the value of every
+	// index at rev.
+	revisions := []map[byte]string{
+		1: {0: "a", 1: "b"},
+		2: {1: "c", 2: "d"},
+		3: {0: "e"},
+	}
+	want := []map[byte]string{0: {}}
+	for rev := int64(1); rev < int64(len(revisions)); rev++ {
+		values := make(map[byte]string)
+		for i, v := range want[rev-1] {
+			values[i] = v
+		}
+		var leaves []*tpb.MapLeaf
+		for i, v := range revisions[rev] {
+			leaves = append(leaves, &tpb.MapLeaf{Index: index(i), LeafValue: []byte(v)})
+			values[i] = v
+		}
+		want = append(want, values)
+		if _, err := env.Map.SetLeaves(ctx, &tpb.SetMapLeavesRequest{
+			MapId:    tree.TreeId,
+			Leaves:   leaves,
+			Revision: rev,
+		}); err != nil {
+			t.Fatalf("SetLeaves(rev: %v): %v", rev, err)
+		}
+	}
+
+	indexes := [][]byte{index(0), index(1), index(2), index(3)}
+	for rev, values := range want {
+		resp, err := env.Map.GetLeavesByRevision(ctx, &tpb.GetMapLeavesByRevisionRequest{
+			MapId:    tree.TreeId,
+			Index:    indexes,
+			Revision: int64(rev),
+		})
+		if err != nil {
+			t.Fatalf("GetLeavesByRevision(%v): %v", rev, err)
+		}
+		leaves, err := verifier.VerifyMapLeavesResponse(indexes, int64(rev), resp)
+		if err != nil {
+			t.Fatalf("VerifyMapLeavesResponse(rev: %v): %v", rev, err)
+		}
+		for _, l := range leaves {
+			var v string
+			for i := byte(0); i < 4; i++ {
+				if bytes.Equal(l.Index, index(i)) {
+					v = values[i]
+				}
+			}
+			if got := string(l.LeafValue); got != v {
+				t.Errorf("rev %v: leaf %x: %q, want %q", rev, l.Index, got, v)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trillianstorage implements in-memory Trillian map and log storage,
+// which lets Trillian servers run in-process without a database.
+//
+// Read-write transactions on a tree are serialized. Every revision of every
+// tree is kept until the process exits.
+package trillianstorage
+
+import (
+	"errors"
+
+	"github.com/google/trillian/storage"
+)
+
+var errClosed = errors.New("trillianstorage: transaction is closed")
+
+// nodeVersions holds every stored version of each Merkle node of a tree,
+// keyed by NodeID.AsKey(), in revision order.
+type nodeVersions map[string][]storage.Node
+
+// get returns the nodes in ids at or before treeRevision. Nodes found in
+// pending, which holds the writes of a transaction at writeRevision, shadow
+// the committed versions. Nodes that have never been stored are omitted.
+func (v nodeVersions) get(ids []storage.NodeID, treeRevision int64,
+	pending map[string]storage.Node, writeRevision int64) []storage.Node {
+	ret := make([]storage.Node, 0, len(ids))
+	for _, id := range ids {
+		key := id.AsKey()
+		if n, ok := pending[key]; ok && writeRevision <= treeRevision {
+			ret = append(ret, n)
+			continue
+		}
+		versions := v[key]
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i].NodeRevision <= treeRevision {
+				ret = append(ret, versions[i])
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// commit appends the nodes in pending.
+func (v nodeVersions) commit(pending map[string]storage.Node) {
+	for key, n := range pending {
+		v[key] = append(v[key], n)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adminaudit stores the audit log of administrative actions.
+package adminaudit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	ktsql "github.com/google/keytransparency/impl/sql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+// Migrations are the schema changes applied to the AdminAuditEvents table in order.
+var Migrations = []ktsql.Migration{
+	{
+		Description: "Create AdminAuditEvents",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL: {`
+CREATE TABLE IF NOT EXISTS AdminAuditEvents(
+  EventID       BIGINT       NOT NULL AUTO_INCREMENT,
+  TimeMicros    BIGINT       NOT NULL, -- In microseconds from Unix epoch.
+  Caller        VARCHAR(255) NOT NULL,
+  Method        VARCHAR(255) NOT NULL,
+  Request       MEDIUMBLOB   NOT NULL,
+  StatusCode    INTEGER      NOT NULL,
+  StatusMessage TEXT         NOT NULL,
+  PRIMARY KEY(EventID)
+);`},
+			ktsql.Postgres: {`
+CREATE TABLE IF NOT EXISTS AdminAuditEvents(
+  EventID       BIGSERIAL    NOT NULL,
+  TimeMicros    BIGINT       NOT NULL, -- In microseconds from Unix epoch.
+  Caller        VARCHAR(255) NOT NULL,
+  Method        VARCHAR(255) NOT NULL,
+  Request       BYTEA        NOT NULL,
+  StatusCode    INTEGER      NOT NULL,
+  StatusMessage TEXT         NOT NULL,
+  PRIMARY KEY(EventID)
+);`},
+			ktsql.SQLite: {`
+CREATE TABLE IF NOT EXISTS AdminAuditEvents(
+  EventID       INTEGER      PRIMARY KEY AUTOINCREMENT,
+  TimeMicros    BIGINT       NOT NULL, -- In microseconds from Unix epoch.
+  Caller        VARCHAR(255) NOT NULL,
+  Method        VARCHAR(255) NOT NULL,
+  Request       BLOB         NOT NULL,
+  StatusCode    INTEGER      NOT NULL,
+  StatusMessage TEXT         NOT NULL
+);`},
+		},
+	},
+	{
+		Description: "Create AdminAuditCompletions",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    createCompletions,
+			ktsql.Postgres: createCompletions,
+			ktsql.SQLite:   createCompletions,
+		},
+	},
+}
+
+// createCompletions records the results of calls in their own table, so that
+// neither table is ever updated. Events recorded before this migration were
+// recorded when their calls completed, with their results.
+var createCompletions = []string{
+	`CREATE TABLE IF NOT EXISTS AdminAuditCompletions(
+  EventID       BIGINT       NOT NULL,
+  TimeMicros    BIGINT       NOT NULL, -- In microseconds from Unix epoch.
+  StatusCode    INTEGER      NOT NULL,
+  StatusMessage TEXT         NOT NULL,
+  PRIMARY KEY(EventID)
+);`,
+	`INSERT INTO AdminAuditCompletions (EventID, TimeMicros, StatusCode, StatusMessage)
+SELECT EventID, TimeMicros, StatusCode, StatusMessage FROM AdminAuditEvents;`,
+}
+
+const (
+	insertSQL = `
+INSERT INTO AdminAuditEvents (TimeMicros, Caller, Method, Request, StatusCode, StatusMessage)
+VALUES (?, ?, ?, ?, ?, ?)`
+	completeSQL = `
+INSERT INTO AdminAuditCompletions (EventID, TimeMicros, StatusCode, StatusMessage)
+VALUES (?, ?, ?, ?);`
+	listSQL = `
+SELECT E.EventID, E.TimeMicros, C.TimeMicros, E.Caller, E.Method, E.Request,
+  COALESCE(C.StatusCode, E.StatusCode), COALESCE(C.StatusMessage, E.StatusMessage)
+FROM AdminAuditEvents AS E LEFT JOIN AdminAuditCompletions AS C ON C.EventID = E.EventID
+WHERE E.EventID > ? ORDER BY E.EventID ASC LIMIT ?;`
+)
+
+// Log is an append-only audit log backed by SQL tables. Calls are recorded in
+// AdminAuditEvents, and their results are appended to AdminAuditCompletions.
+// Rows are never updated. It implements adminserver.AuditLog.
+type Log struct {
+	db      *sql.DB
+	dialect ktsql.Dialect
+}
+
+// New returns an audit log stored in db, migrating the audit table to the
+// latest schema.
+func New(db *sql.DB) (*Log, error) {
+	if err := Migrate(context.Background(), db); err != nil {
+		return nil, err
+	}
+	return &Log{db: db, dialect: ktsql.DialectOf(db)}, nil
+}
+
+// Migrate applies any new Migrations to the AdminAuditEvents table in db.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return ktsql.Migrate(ctx, db, "adminaudit", Migrations)
+}
+
+// Append records event and returns the EventId assigned to it by the database.
+// If event has a CompletionTime, its result is recorded in the same transaction.
+func (l *Log) Append(ctx context.Context, event *pb.AdminAuditEvent) (_ int64, ret error) {
+	t, err := ptypes.Timestamp(event.GetTime())
+	if err != nil {
+		return 0, err
+	}
+	var request []byte
+	if event.GetRequest() != nil {
+		if request, err = proto.Marshal(event.GetRequest()); err != nil {
+			return 0, err
+		}
+	}
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+	args := []interface{}{toMicros(t), event.GetCaller(), event.GetMethod(),
+		request, event.GetStatusCode(), event.GetStatusMessage()}
+	var id int64
+	if l.dialect == ktsql.Postgres {
+		// lib/pq does not support LastInsertId.
+		if err := tx.QueryRowContext(ctx, l.dialect.Rebind(insertSQL+` RETURNING EventID;`), args...).Scan(&id); err != nil {
+			return 0, err
+		}
+	} else {
+		result, err := tx.ExecContext(ctx, l.dialect.Rebind(insertSQL+`;`), args...)
+		if err != nil {
+			return 0, err
+		}
+		if id, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+	if event.GetCompletionTime() != nil {
+		c, err := ptypes.Timestamp(event.GetCompletionTime())
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, l.dialect.Rebind(completeSQL),
+			id, toMicros(c), event.GetStatusCode(), event.GetStatusMessage()); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// Complete records the result of the call recorded by event eventID.
+// Returns NotFound if there is no such event, and AlreadyExists if its result
+// has already been recorded.
+func (l *Log) Complete(ctx context.Context, eventID int64, completed time.Time, st *status.Status) (ret error) {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+	var n int
+	if err := tx.QueryRowContext(ctx,
+		l.dialect.Rebind(`SELECT COUNT(*) FROM AdminAuditEvents WHERE EventID = ?;`), eventID).Scan(&n); err != nil {
+		return err
+	}
+	if n != 1 {
+		return status.Errorf(codes.NotFound, "audit event %v not found", eventID)
+	}
+	if _, err := tx.ExecContext(ctx, l.dialect.Rebind(completeSQL),
+		eventID, toMicros(completed), int32(st.Code()), st.Message()); ktsql.IsDuplicate(err) {
+		return status.Errorf(codes.AlreadyExists, "audit event %v has already completed", eventID)
+	} else if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
+
+func fromMicros(micros int64) time.Time {
+	return time.Unix(0, micros*int64(time.Microsecond))
+}
+
+// List returns up to pageSize events with EventId > start, ordered by EventId.
+func (l *Log) List(ctx context.Context, start int64, pageSize int32) ([]*pb.AdminAuditEvent, error) {
+	rows, err := l.db.QueryContext(ctx, l.dialect.Rebind(listSQL), start, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*pb.AdminAuditEvent{}
+	for rows.Next() {
+		var timeMicros int64
+		var completedMicros sql.NullInt64
+		var request []byte
+		e := &pb.AdminAuditEvent{}
+		if err := rows.Scan(&e.EventId, &timeMicros, &completedMicros, &e.Caller, &e.Method,
+			&request, &e.StatusCode, &e.StatusMessage); err != nil {
+			return nil, err
+		}
+		if e.Time, err = ptypes.TimestampProto(fromMicros(timeMicros)); err != nil {
+			return nil, err
+		}
+		if completedMicros.Valid {
+			if e.CompletionTime, err = ptypes.TimestampProto(fromMicros(completedMicros.Int64)); err != nil {
+				return nil, err
+			}
+		}
+		if len(request) > 0 {
+			e.Request = &any.Any{}
+			if err := proto.Unmarshal(request, e.Request); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adminaudit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/keytransparency/impl/sql/testdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	ktsql "github.com/google/keytransparency/impl/sql"
+)
+
+func TestAppendList(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			l, err := New(db)
+			if err != nil {
+				t.Fatalf("New(): %v", err)
+			}
+
+			req, err := ptypes.MarshalAny(&pb.DeleteDirectoryRequest{DirectoryId: "dir"})
+			if err != nil {
+				t.Fatalf("MarshalAny(): %v", err)
+			}
+			ts, err := ptypes.TimestampProto(time.Unix(1, 2000))
+			if err != nil {
+				t.Fatalf("TimestampProto(): %v", err)
+			}
+			var want []*pb.AdminAuditEvent
+			for i, caller := range []string{"alice", "bob", "carol"} {
+				e := &pb.AdminAuditEvent{
+					Time:          ts,
+					Caller:        caller,
+					Method:        "/google.keytransparency.v1.KeyTransparencyAdmin/DeleteDirectory",
+					Request:       req,
+					StatusCode:    int32(i),
+					StatusMessage: "msg",
+				}
+				if i > 0 {
+					e.CompletionTime = ts
+				}
+				id, err := l.Append(ctx, e)
+				if err != nil {
+					t.Fatalf("Append(): %v", err)
+				}
+				e.EventId = id
+				want = append(want, e)
+			}
+
+			for _, tc := range []struct {
+				start    int64
+				pageSize int32
+				want     []*pb.AdminAuditEvent
+			}{
+				{start: 0, pageSize: 10, want: want},
+				{start: 0, pageSize: 2, want: want[:2]},
+				{start: 2, pageSize: 10, want: want[2:]},
+				{start: 3, pageSize: 10, want: nil},
+			} {
+				got, err := l.List(ctx, tc.start, tc.pageSize)
+				if err != nil {
+					t.Fatalf("List(%v, %v): %v", tc.start, tc.pageSize, err)
+				}
+				if len(got) != len(tc.want) {
+					t.Fatalf("List(%v, %v): %v events, want %v", tc.start, tc.pageSize, len(got), len(tc.want))
+				}
+				for i, e := range got {
+					if e.EventId <= tc.start {
+						t.Errorf("List(%v, %v)[%v].EventId: %v, want > %v", tc.start, tc.pageSize, i, e.EventId, tc.start)
+					}
+					if !proto.Equal(e, tc.want[i]) {
+						t.Errorf("List(%v, %v)[%v]: %v, want %v", tc.start, tc.pageSize, i, e, tc.want[i])
+					}
+				}
+			}
+
+			// Record the result of the first call.
+			completed := time.Unix(3, 4000)
+			if err := l.Complete(ctx, want[0].EventId, completed, status.New(codes.NotFound, "no dir")); err != nil {
+				t.Fatalf("Complete(): %v", err)
+			}
+			got, err := l.List(ctx, 0, 1)
+			if err != nil {
+				t.Fatalf("List(): %v", err)
+			}
+			w := proto.Clone(want[0]).(*pb.AdminAuditEvent)
+			w.CompletionTime, _ = ptypes.TimestampProto(completed)
+			w.StatusCode, w.StatusMessage = int32(codes.NotFound), "no dir"
+			if !proto.Equal(got[0], w) {
+				t.Errorf("List() after Complete(): %v, want %v", got[0], w)
+			}
+			if err := l.Complete(ctx, 100, completed, status.New(codes.OK, "")); status.Code(err) != codes.NotFound {
+				t.Errorf("Complete(missing): %v, want %v", err, codes.NotFound)
+			}
+			if err := l.Complete(ctx, want[0].EventId, completed, status.New(codes.OK, "")); status.Code(err) != codes.AlreadyExists {
+				t.Errorf("Complete(completed): %v, want %v", err, codes.AlreadyExists)
+			}
+		})
+	}
+}
+
+// TestMigrateCompletions verifies that events recorded before results were
+// kept apart are listed as completed, and are left unchanged.
+func TestMigrateCompletions(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			if err := ktsql.Migrate(ctx, db, "adminaudit", Migrations[:1]); err != nil {
+				t.Fatalf("Migrate(v1): %v", err)
+			}
+			if _, err := db.ExecContext(ctx, dialect.Rebind(`INSERT INTO AdminAuditEvents
+				(TimeMicros, Caller, Method, Request, StatusCode, StatusMessage) VALUES (?, ?, ?, ?, ?, ?);`),
+				5000000, "alice", "DeleteDirectory", []byte{}, int32(codes.NotFound), "no dir"); err != nil {
+				t.Fatalf("insert v1 event: %v", err)
+			}
+
+			l, err := New(db)
+			if err != nil {
+				t.Fatalf("New(): %v", err)
+			}
+			got, err := l.List(ctx, 0, 10)
+			if err != nil {
+				t.Fatalf("List(): %v", err)
+			}
+			ts, _ := ptypes.TimestampProto(time.Unix(5, 0))
+			want := &pb.AdminAuditEvent{EventId: 1, Time: ts, CompletionTime: ts, Caller: "alice",
+				Method: "DeleteDirectory", StatusCode: int32(codes.NotFound), StatusMessage: "no dir"}
+			if len(got) != 1 || !proto.Equal(got[0], want) {
+				t.Errorf("List(): %v, want [%v]", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect identifies the flavor of SQL understood by a database.
+// Queries are written for MySQL and rewritten by the Dialect when needed.
+type Dialect string
+
+// Supported dialects.
+const (
+	MySQL    Dialect = "mysql"
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite3"
+)
+
+// DialectOf returns the Dialect of the database opened by db.
+func DialectOf(db *sql.DB) Dialect {
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return Postgres
+	case *sqlite3.SQLiteDriver:
+		return SQLite
+	default:
+		return MySQL
+	}
+}
+
+// Rebind rewrites the ? placeholders in query into the form used by d.
+func (d Dialect) Rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteString("$")
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// InsertIgnore rewrites an INSERT INTO query so that rows which would violate
+// a unique constraint are skipped rather than returning an error.
+func (d Dialect) InsertIgnore(query string) string {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	switch d {
+	case Postgres:
+		return query + " ON CONFLICT DO NOTHING;"
+	case SQLite:
+		return strings.Replace(query, "INSERT INTO", "INSERT OR IGNORE INTO", 1) + ";"
+	default:
+		return strings.Replace(query, "INSERT INTO", "INSERT IGNORE INTO", 1) + ";"
+	}
+}
+
+// IsDuplicate returns true if err was caused by inserting a row whose primary
+// or unique key already exists.
+func IsDuplicate(err error) bool {
+	switch e := err.(type) {
+	case *mysql.MySQLError:
+		return e.Number == 1062 // ER_DUP_ENTRY
+	case *pq.Error:
+		return e.Code == "23505" // unique_violation
+	}
+	return isSQLiteDuplicate(err)
+}
+
+// IsConflict returns true if err was caused by a concurrent transaction, such
+// as a deadlock, a serialization failure, or a duplicate key written by a
+// transaction that committed first. Retrying the transaction may succeed.
+func IsConflict(err error) bool {
+	if IsDuplicate(err) {
+		return true
+	}
+	switch e := err.(type) {
+	case *mysql.MySQLError:
+		switch e.Number {
+		case 1205, // ER_LOCK_WAIT_TIMEOUT
+			1213: // ER_LOCK_DEADLOCK
+			return true
+		}
+	case *pq.Error:
+		switch e.Code {
+		case "40001", // serialization_failure
+			"40P01": // deadlock_detected
+			return true
+		}
+	}
+	return isSQLiteConflict(err)
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestRebind(t *testing.T) {
+	query := `SELECT A FROM T WHERE B = ? AND C > ? LIMIT ?;`
+	for _, tc := range []struct {
+		dialect Dialect
+		want    string
+	}{
+		{dialect: MySQL, want: query},
+		{dialect: Postgres, want: `SELECT A FROM T WHERE B = $1 AND C > $2 LIMIT $3;`},
+		{dialect: SQLite, want: query},
+	} {
+		if got := tc.dialect.Rebind(query); got != tc.want {
+			t.Errorf("%v.Rebind(): %v, want %v", tc.dialect, got, tc.want)
+		}
+	}
+}
+
+func TestInsertIgnore(t *testing.T) {
+	query := `INSERT INTO T (A, B) VALUES (?, ?);`
+	for _, tc := range []struct {
+		dialect Dialect
+		want    string
+	}{
+		{dialect: MySQL, want: `INSERT IGNORE INTO T (A, B) VALUES (?, ?);`},
+		{dialect: Postgres, want: `INSERT INTO T (A, B) VALUES (?, ?) ON CONFLICT DO NOTHING;`},
+		{dialect: SQLite, want: `INSERT OR IGNORE INTO T (A, B) VALUES (?, ?);`},
+	} {
+		if got := tc.dialect.InsertIgnore(query); got != tc.want {
+			t.Errorf("%v.InsertIgnore(): %v, want %v", tc.dialect, got, tc.want)
+		}
+	}
+}
+
+func TestDialectOf(t *testing.T) {
+	for _, tc := range []struct {
+		driver string
+		want   Dialect
+	}{
+		{driver: "mysql", want: MySQL},
+		{driver: "postgres", want: Postgres},
+		{driver: "sqlite3", want: SQLite},
+	} {
+		// sql.Open does not connect to the database.
+		db, err := sql.Open(tc.driver, "")
+		if err != nil {
+			t.Fatalf("sql.Open(%v): %v", tc.driver, err)
+		}
+		if got := DialectOf(db); got != tc.want {
+			t.Errorf("DialectOf(%v): %v, want %v", tc.driver, got, tc.want)
+		}
+		db.Close()
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("conflict"), want: false},
+		{err: &mysql.MySQLError{Number: 1213}, want: true},
+		{err: &mysql.MySQLError{Number: 1062}, want: true},
+		{err: &mysql.MySQLError{Number: 1146}, want: false},
+		{err: &pq.Error{Code: "40001"}, want: true},
+		{err: &pq.Error{Code: "42P01"}, want: false},
+	} {
+		if got := IsConflict(tc.err); got != tc.want {
+			t.Errorf("IsConflict(%v): %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("duplicate"), want: false},
+		{err: &mysql.MySQLError{Number: 1062}, want: true},
+		{err: &mysql.MySQLError{Number: 1213}, want: false},
+		{err: &pq.Error{Code: "23505"}, want: true},
+		{err: &pq.Error{Code: "40001"}, want: false},
+	} {
+		if got := IsDuplicate(tc.err); got != tc.want {
+			t.Errorf("IsDuplicate(%v): %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
@@ -18,21 +18,27 @@ package directory
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/google/keytransparency/core/directory"
+	ktsql "github.com/google/keytransparency/impl/sql"
 	tpb "github.com/google/trillian"
 	"github.com/google/trillian/crypto/keyspb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
 )
 
-const (
-	createSQL = `
+// Migrations are the schema changes applied to the Directories table in order.
+var Migrations = []ktsql.Migration{
+	{
+		Description: "Create Directories",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL: {`
 CREATE TABLE IF NOT EXISTS Directories(
   DirectoryId           VARCHAR(40) NOT NULL,
   Map                   BLOB NOT NULL,
@@ -44,48 +50,106 @@ CREATE TABLE IF NOT EXISTS Directories(
   Deleted               INTEGER,
   DeleteTimeSeconds      BIGINT,
   PRIMARY KEY(DirectoryId)
-);`
+);`},
+			ktsql.Postgres: {`
+CREATE TABLE IF NOT EXISTS Directories(
+  DirectoryId           VARCHAR(40) NOT NULL,
+  Map                   BYTEA NOT NULL,
+  Log                   BYTEA NOT NULL,
+  VRFPublicKey          BYTEA NOT NULL,
+  VRFPrivateKey         BYTEA NOT NULL,
+  MinInterval           BIGINT NOT NULL,
+  MaxInterval           BIGINT NOT NULL,
+  Deleted               BOOLEAN,
+  DeleteTimeSeconds     BIGINT,
+  PRIMARY KEY(DirectoryId)
+);`},
+			ktsql.SQLite: {`
+CREATE TABLE IF NOT EXISTS Directories(
+  DirectoryId           VARCHAR(40) NOT NULL,
+  Map                   BLOB NOT NULL,
+  Log                   BLOB NOT NULL,
+  VRFPublicKey          BLOB NOT NULL,
+  VRFPrivateKey         BLOB NOT NULL,
+  MinInterval           BIGINT NOT NULL,
+  MaxInterval           BIGINT NOT NULL,
+  Deleted               INTEGER,
+  DeleteTimeSeconds     BIGINT,
+  PRIMARY KEY(DirectoryId)
+);`},
+		},
+	},
+	{
+		Description: "Add Directories.RateLimits",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {`ALTER TABLE Directories ADD COLUMN RateLimits BLOB;`},
+			ktsql.Postgres: {`ALTER TABLE Directories ADD COLUMN RateLimits BYTEA;`},
+			ktsql.SQLite:   {`ALTER TABLE Directories ADD COLUMN RateLimits BLOB;`},
+		},
+		// Tables created before schemas were versioned already have the column.
+		Applied: ktsql.HasColumn("Directories", "RateLimits"),
+	},
+	{
+		Description: "Add Directories.Retention",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {`ALTER TABLE Directories ADD COLUMN Retention BLOB;`},
+			ktsql.Postgres: {`ALTER TABLE Directories ADD COLUMN Retention BYTEA;`},
+			ktsql.SQLite:   {`ALTER TABLE Directories ADD COLUMN Retention BLOB;`},
+		},
+	},
+	{
+		Description: "Add Directories.AllowRecovery",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {`ALTER TABLE Directories ADD COLUMN AllowRecovery INTEGER NOT NULL DEFAULT 0;`},
+			ktsql.Postgres: {`ALTER TABLE Directories ADD COLUMN AllowRecovery BOOLEAN NOT NULL DEFAULT FALSE;`},
+			ktsql.SQLite:   {`ALTER TABLE Directories ADD COLUMN AllowRecovery INTEGER NOT NULL DEFAULT 0;`},
+		},
+	},
+}
+
+const (
 	writeSQL = `INSERT INTO Directories
-(DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+(DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds, RateLimits, Retention, AllowRecovery)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
 	readSQL = `
-SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds
-FROM Directories WHERE DirectoryId = ? AND Deleted = 0;`
+SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds, RateLimits, Retention, AllowRecovery
+FROM Directories WHERE DirectoryId = ? AND Deleted = FALSE;`
 	readDeletedSQL = `
-SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds
+SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds, RateLimits, Retention, AllowRecovery
 FROM Directories WHERE DirectoryId = ?;`
 	listSQL = `
-SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted
-FROM Directories WHERE Deleted = 0;`
+SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds, RateLimits, Retention, AllowRecovery
+FROM Directories WHERE Deleted = FALSE ORDER BY DirectoryId;`
 	listDeletedSQL = `
-SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted
-FROM Directories;`
-	setDeletedSQL = `UPDATE Directories SET Deleted = ?, DeleteTimeSeconds = ? WHERE DirectoryId = ?`
-	deleteSQL     = `DELETE FROM Directories WHERE DirectoryId = ?`
+SELECT DirectoryId, Map, Log, VRFPublicKey, VRFPrivateKey, MinInterval, MaxInterval, Deleted, DeleteTimeSeconds, RateLimits, Retention, AllowRecovery
+FROM Directories ORDER BY DirectoryId;`
+	existsSQL        = `SELECT 1 FROM Directories WHERE DirectoryId = ?;`
+	setDeletedSQL    = `UPDATE Directories SET Deleted = ?, DeleteTimeSeconds = ? WHERE DirectoryId = ?`
+	setRateLimitsSQL = `UPDATE Directories SET RateLimits = ? WHERE DirectoryId = ?`
+	setRetentionSQL  = `UPDATE Directories SET Retention = ? WHERE DirectoryId = ?`
+	deleteSQL        = `DELETE FROM Directories WHERE DirectoryId = ?`
 )
 
 type storage struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect ktsql.Dialect
 }
 
-// NewStorage returns a directory.Storage client backed by an SQL table.
+// NewStorage returns a directory.Storage client backed by an SQL table,
+// migrating the table to the latest schema.
 func NewStorage(db *sql.DB) (directory.Storage, error) {
-	s := &storage{
-		db: db,
-	}
-	// Create tables.
-	if err := s.create(); err != nil {
+	if err := Migrate(context.Background(), db); err != nil {
 		return nil, err
 	}
-	return s, nil
+	return &storage{
+		db:      db,
+		dialect: ktsql.DialectOf(db),
+	}, nil
 }
 
-func (s *storage) create() error {
-	_, err := s.db.Exec(createSQL)
-	if err != nil {
-		return fmt.Errorf("failed to create commitments tables: %v", err)
-	}
-	return nil
+// Migrate applies any new Migrations to the Directories table in db.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return ktsql.Migrate(ctx, db, "directories", Migrations)
 }
 
 func (s *storage) List(ctx context.Context, showDeleted bool) ([]*directory.Directory, error) {
@@ -95,7 +159,7 @@ func (s *storage) List(ctx context.Context, showDeleted bool) ([]*directory.Dire
 	} else {
 		query = listSQL
 	}
-	readStmt, err := s.db.PrepareContext(ctx, query)
+	readStmt, err := s.db.PrepareContext(ctx, s.dialect.Rebind(query))
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +172,8 @@ func (s *storage) List(ctx context.Context, showDeleted bool) ([]*directory.Dire
 	defer rows.Close()
 	ret := []*directory.Directory{}
 	for rows.Next() {
-		var pubkey, anyData, mapByte, logByte []byte
+		var pubkey, anyData, mapByte, logByte, rateLimits, retention []byte
+		var deletedUnix int64
 		var logTree tpb.Tree
 		var mapTree tpb.Tree
 		d := &directory.Directory{}
@@ -117,7 +182,15 @@ func (s *storage) List(ctx context.Context, showDeleted bool) ([]*directory.Dire
 			&mapByte, &logByte,
 			&pubkey, &anyData,
 			&d.MinInterval, &d.MaxInterval,
-			&d.Deleted); err != nil {
+			&d.Deleted, &deletedUnix,
+			&rateLimits, &retention, &d.AllowRecovery); err != nil {
+			return nil, err
+		}
+		d.DeletedTimestamp = time.Unix(deletedUnix, 0)
+		if d.RateLimits, err = unmarshalRateLimits(rateLimits); err != nil {
+			return nil, err
+		}
+		if d.Retention, err = unmarshalRetention(retention); err != nil {
 			return nil, err
 		}
 		// Unwrap protos.
@@ -159,8 +232,16 @@ func (s *storage) Write(ctx context.Context, d *directory.Directory) error {
 	if err != nil {
 		return err
 	}
+	rateLimits, err := marshalRateLimits(d.RateLimits)
+	if err != nil {
+		return err
+	}
+	retention, err := marshalRetention(d.Retention)
+	if err != nil {
+		return err
+	}
 	// Prepare SQL.
-	writeStmt, err := s.db.PrepareContext(ctx, writeSQL)
+	writeStmt, err := s.db.PrepareContext(ctx, s.dialect.Rebind(writeSQL))
 	if err != nil {
 		return err
 	}
@@ -173,7 +254,11 @@ func (s *storage) Write(ctx context.Context, d *directory.Directory) error {
 		false,
 		// Store January 1, year 1, 00:00:00 UTC, the time.Time zero value.
 		// Store this as unix seconds till Jan 1 1970, a large negative number.
-		time.Time{}.Unix())
+		time.Time{}.Unix(),
+		rateLimits, retention, d.AllowRecovery)
+	if ktsql.IsDuplicate(err) {
+		return status.Errorf(codes.AlreadyExists, "directory %v already exists", d.DirectoryID)
+	}
 	return err
 }
 
@@ -184,13 +269,13 @@ func (s *storage) Read(ctx context.Context, directoryID string, showDeleted bool
 	} else {
 		SQL = readSQL
 	}
-	readStmt, err := s.db.PrepareContext(ctx, SQL)
+	readStmt, err := s.db.PrepareContext(ctx, s.dialect.Rebind(SQL))
 	if err != nil {
 		return nil, err
 	}
 	defer readStmt.Close()
 	d := &directory.Directory{}
-	var pubkey, anyData []byte
+	var pubkey, anyData, rateLimits, retention []byte
 	var deletedUnix int64
 	var mapByte []byte
 	var logByte []byte
@@ -204,6 +289,8 @@ func (s *storage) Read(ctx context.Context, directoryID string, showDeleted bool
 		&d.MinInterval, &d.MaxInterval,
 		&d.Deleted,
 		&deletedUnix,
+		&rateLimits, &retention,
+		&d.AllowRecovery,
 	); err == sql.ErrNoRows {
 		return nil, status.Errorf(codes.NotFound, "%v", err)
 	} else if err != nil {
@@ -226,10 +313,56 @@ func (s *storage) Read(ctx context.Context, directoryID string, showDeleted bool
 	}
 	d.Map = &mapTree
 	d.Log = &logTree
+	if d.RateLimits, err = unmarshalRateLimits(rateLimits); err != nil {
+		return nil, err
+	}
+	if d.Retention, err = unmarshalRetention(retention); err != nil {
+		return nil, err
+	}
 
 	return d, nil
 }
 
+// marshalRateLimits serializes limits, storing nil as NULL.
+func marshalRateLimits(limits *pb.RateLimits) ([]byte, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	return proto.Marshal(limits)
+}
+
+// unmarshalRateLimits parses rate limits stored by marshalRateLimits.
+func unmarshalRateLimits(b []byte) (*pb.RateLimits, error) {
+	if b == nil {
+		return nil, nil
+	}
+	limits := &pb.RateLimits{}
+	if err := proto.Unmarshal(b, limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// marshalRetention serializes retention, storing nil as NULL.
+func marshalRetention(retention *pb.RetentionPolicy) ([]byte, error) {
+	if retention == nil {
+		return nil, nil
+	}
+	return proto.Marshal(retention)
+}
+
+// unmarshalRetention parses a retention policy stored by marshalRetention.
+func unmarshalRetention(b []byte) (*pb.RetentionPolicy, error) {
+	if b == nil {
+		return nil, nil
+	}
+	retention := &pb.RetentionPolicy{}
+	if err := proto.Unmarshal(b, retention); err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
 // unwrapAnyProto returns the proto object seralized inside a serialized any.Any
 func unwrapAnyProto(anyData []byte) (proto.Message, error) {
 	var anyPB any.Any
@@ -243,13 +376,49 @@ func unwrapAnyProto(anyData []byte) (proto.Message, error) {
 	return privKey.Message, nil
 }
 
+// update runs an UPDATE or DELETE statement against a single directory,
+// returning NotFound if the directory does not exist.
+func (s *storage) update(ctx context.Context, query, directoryID string, args ...interface{}) error {
+	result, err := s.db.ExecContext(ctx, s.dialect.Rebind(query), append(args, directoryID)...)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	// MySQL reports rows that were matched but left unchanged as unaffected.
+	var exists int
+	if err := s.db.QueryRowContext(ctx, s.dialect.Rebind(existsSQL), directoryID).Scan(&exists); err == sql.ErrNoRows {
+		return status.Errorf(codes.NotFound, "directory %v not found", directoryID)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (s *storage) SetDelete(ctx context.Context, directoryID string, isDeleted bool) error {
-	_, err := s.db.ExecContext(ctx, setDeletedSQL, isDeleted, time.Now().Unix(), directoryID)
-	return err
+	return s.update(ctx, setDeletedSQL, directoryID, isDeleted, time.Now().Unix())
+}
+
+// SetRateLimits replaces the rate limits of a directory.
+func (s *storage) SetRateLimits(ctx context.Context, directoryID string, limits *pb.RateLimits) error {
+	rateLimits, err := marshalRateLimits(limits)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, setRateLimitsSQL, directoryID, rateLimits)
+}
+
+// SetRetention replaces the retention policy of a directory.
+func (s *storage) SetRetention(ctx context.Context, directoryID string, retention *pb.RetentionPolicy) error {
+	b, err := marshalRetention(retention)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, setRetentionSQL, directoryID, b)
 }
 
 // Delete permanently deletes a directory.
 func (s *storage) Delete(ctx context.Context, directoryID string) error {
-	_, err := s.db.ExecContext(ctx, deleteSQL, directoryID)
-	return err
+	return s.update(ctx, deleteSQL, directoryID)
 }
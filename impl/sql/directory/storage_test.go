@@ -16,23 +16,28 @@ package directory
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/directory"
+	"github.com/google/keytransparency/core/integration/storagetest"
 	"github.com/google/keytransparency/impl/sql/testdb"
 	"github.com/google/trillian/crypto/keyspb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	ktsql "github.com/google/keytransparency/impl/sql"
 	tpb "github.com/google/trillian"
 )
 
-func newStorage(ctx context.Context, t *testing.T) (directory.Storage, func(context.Context)) {
+func newStorage(ctx context.Context, t *testing.T, newDB testdb.NewFunc) (directory.Storage, func(context.Context)) {
 	t.Helper()
-	db, done := testdb.NewForTest(ctx, t)
+	db, done := newDB(ctx, t)
 	s, err := NewStorage(db)
 	if err != nil {
 		done(ctx)
@@ -41,240 +46,309 @@ func newStorage(ctx context.Context, t *testing.T) (directory.Storage, func(cont
 	return s, done
 }
 
+// TestMigrateUnversioned checks that tables created with RateLimits before
+// schemas were versioned are migrated.
+func TestMigrateUnversioned(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			colType := "BLOB"
+			if dialect == ktsql.Postgres {
+				colType = "BYTEA"
+			}
+			create := strings.Replace(Migrations[0].Up[dialect][0],
+				"PRIMARY KEY", "RateLimits "+colType+",\n  PRIMARY KEY", 1)
+			if _, err := db.ExecContext(ctx, create); err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if err := Migrate(ctx, db); err != nil {
+				t.Fatalf("Migrate(): %v", err)
+			}
+			if v, err := ktsql.SchemaVersion(ctx, db, "directories"); err != nil || v != len(Migrations) {
+				t.Errorf("SchemaVersion(): %v, %v, want %v", v, err, len(Migrations))
+			}
+		})
+	}
+}
+
 func TestList(t *testing.T) {
-	ctx := context.Background()
-	s, done := newStorage(ctx, t)
-	defer done(ctx)
-	for _, tc := range []struct {
-		directories []*directory.Directory
-		readDeleted bool
-	}{
-		{
-			directories: []*directory.Directory{
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			s, done := newStorage(ctx, t, newDB)
+			defer done(ctx)
+			for _, tc := range []struct {
+				directories []*directory.Directory
+				readDeleted bool
+			}{
 				{
-					DirectoryID: "directory1",
-					Map: &tpb.Tree{
-						TreeId: 1,
+					directories: []*directory.Directory{
+						{
+							DirectoryID: "directory1",
+							Map: &tpb.Tree{
+								TreeId: 1,
+							},
+							Log: &tpb.Tree{
+								TreeId: 2,
+							},
+							VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+							VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+							MinInterval: 1 * time.Second,
+							MaxInterval: 5 * time.Second,
+						},
+						{
+							DirectoryID: "directory2",
+							Map: &tpb.Tree{
+								TreeId: 1,
+							},
+							Log: &tpb.Tree{
+								TreeId: 2,
+							},
+							VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+							VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+							MinInterval: 5 * time.Hour,
+							MaxInterval: 500 * time.Hour,
+						},
 					},
-					Log: &tpb.Tree{
-						TreeId: 2,
-					},
-					VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-					VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-					MinInterval: 1 * time.Second,
-					MaxInterval: 5 * time.Second,
 				},
-				{
-					DirectoryID: "directory2",
-					Map: &tpb.Tree{
-						TreeId: 1,
-					},
-					Log: &tpb.Tree{
-						TreeId: 2,
-					},
-					VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-					VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-					MinInterval: 5 * time.Hour,
-					MaxInterval: 500 * time.Hour,
-				},
-			},
-		},
-	} {
-		for _, d := range tc.directories {
-			if err := s.Write(ctx, d); err != nil {
-				t.Errorf("Write(): %v", err)
-				continue
-			}
-		}
+			} {
+				for _, d := range tc.directories {
+					if err := s.Write(ctx, d); err != nil {
+						t.Errorf("Write(): %v", err)
+						continue
+					}
+				}
 
-		directories, err := s.List(ctx, tc.readDeleted)
-		if err != nil {
-			t.Errorf("List(): %v", err)
-			continue
-		}
-		if got, want := directories, tc.directories; !cmp.Equal(got, want, cmp.Comparer(proto.Equal)) {
-			t.Errorf("List(): %#v, want %#v, diff: \n%v", got, want, cmp.Diff(got, want))
-		}
+				directories, err := s.List(ctx, tc.readDeleted)
+				if err != nil {
+					t.Errorf("List(): %v", err)
+					continue
+				}
+				if got, want := directories, tc.directories; !cmp.Equal(got, want, cmp.Comparer(proto.Equal)) {
+					t.Errorf("List(): %#v, want %#v, diff: \n%v", got, want, cmp.Diff(got, want))
+				}
+			}
+		})
 	}
 }
 
 func TestWriteReadDelete(t *testing.T) {
-	ctx := context.Background()
-	s, done := newStorage(ctx, t)
-	defer done(ctx)
-	for _, tc := range []struct {
-		desc                 string
-		d                    directory.Directory
-		write                bool
-		wantWriteErr         bool
-		setDelete, isDeleted bool
-		readDeleted          bool
-		wantReadErr          bool
-	}{
-		{
-			desc:  "Success",
-			write: true,
-			d: directory.Directory{
-				DirectoryID: "testdirectory",
-				Map: &tpb.Tree{
-					TreeId: 1,
-				},
-				Log: &tpb.Tree{
-					TreeId: 2,
-				},
-				VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-				VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-				MinInterval: 1 * time.Second,
-				MaxInterval: 5 * time.Second,
-			},
-		},
-		{
-			desc:  "Duplicate DirectoryID",
-			write: true,
-			d: directory.Directory{
-				DirectoryID: "testdirectory",
-				Map: &tpb.Tree{
-					TreeId: 1,
-				},
-				Log: &tpb.Tree{
-					TreeId: 2,
-				},
-				VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-				VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-				MinInterval: 1 * time.Second,
-				MaxInterval: 5 * time.Second,
-			},
-			wantWriteErr: true,
-		},
-		{
-			desc: "Delete",
-			d: directory.Directory{
-				DirectoryID: "testdirectory",
-				Map: &tpb.Tree{
-					TreeId: 1,
-				},
-				Log: &tpb.Tree{
-					TreeId: 2,
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			s, done := newStorage(ctx, t, newDB)
+			defer done(ctx)
+			rateLimits := &pb.RateLimits{
+				PerUser: &pb.Quota{UpdatesPerSecond: 0.5, Burst: 2},
+			}
+			retention := &pb.RetentionPolicy{Revisions: 10, MaxAge: ptypes.DurationProto(time.Hour)}
+			for _, tc := range []struct {
+				desc                 string
+				d                    directory.Directory
+				write                bool
+				wantWriteErr         bool
+				setDelete, isDeleted bool
+				readDeleted          bool
+				wantReadErr          bool
+			}{
+				{
+					desc:  "Success",
+					write: true,
+					d: directory.Directory{
+						DirectoryID: "testdirectory",
+						Map: &tpb.Tree{
+							TreeId: 1,
+						},
+						Log: &tpb.Tree{
+							TreeId: 2,
+						},
+						VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+						VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+						MinInterval: 1 * time.Second,
+						MaxInterval: 5 * time.Second,
+						RateLimits:  rateLimits,
+						Retention:   retention,
+					},
 				},
-				VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-				VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-				MinInterval: 1 * time.Second,
-				MaxInterval: 5 * time.Second,
-			},
-			setDelete:   true,
-			isDeleted:   true,
-			readDeleted: false,
-			wantReadErr: true,
-		},
-		{
-			desc: "Read deleted",
-			d: directory.Directory{
-				DirectoryID: "testdirectory",
-				Map: &tpb.Tree{
-					TreeId: 1,
+				{
+					desc:  "Duplicate DirectoryID",
+					write: true,
+					d: directory.Directory{
+						DirectoryID: "testdirectory",
+						Map: &tpb.Tree{
+							TreeId: 1,
+						},
+						Log: &tpb.Tree{
+							TreeId: 2,
+						},
+						VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+						VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+						MinInterval: 1 * time.Second,
+						MaxInterval: 5 * time.Second,
+						RateLimits:  rateLimits,
+						Retention:   retention,
+					},
+					wantWriteErr: true,
 				},
-				Log: &tpb.Tree{
-					TreeId: 2,
+				{
+					desc: "Delete",
+					d: directory.Directory{
+						DirectoryID: "testdirectory",
+						Map: &tpb.Tree{
+							TreeId: 1,
+						},
+						Log: &tpb.Tree{
+							TreeId: 2,
+						},
+						VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+						VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+						MinInterval: 1 * time.Second,
+						MaxInterval: 5 * time.Second,
+						RateLimits:  rateLimits,
+						Retention:   retention,
+					},
+					setDelete:   true,
+					isDeleted:   true,
+					readDeleted: false,
+					wantReadErr: true,
 				},
-				VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-				VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-				MinInterval: 1 * time.Second,
-				MaxInterval: 5 * time.Second,
-			},
-			setDelete:   true,
-			isDeleted:   true,
-			readDeleted: true,
-			wantReadErr: false,
-		},
-		{
-			desc: "Undelete",
-			d: directory.Directory{
-				DirectoryID: "testdirectory",
-				Map: &tpb.Tree{
-					TreeId: 1,
+				{
+					desc: "Read deleted",
+					d: directory.Directory{
+						DirectoryID: "testdirectory",
+						Map: &tpb.Tree{
+							TreeId: 1,
+						},
+						Log: &tpb.Tree{
+							TreeId: 2,
+						},
+						VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+						VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+						MinInterval: 1 * time.Second,
+						MaxInterval: 5 * time.Second,
+						RateLimits:  rateLimits,
+						Retention:   retention,
+					},
+					setDelete:   true,
+					isDeleted:   true,
+					readDeleted: true,
+					wantReadErr: false,
 				},
-				Log: &tpb.Tree{
-					TreeId: 2,
+				{
+					desc: "Undelete",
+					d: directory.Directory{
+						DirectoryID: "testdirectory",
+						Map: &tpb.Tree{
+							TreeId: 1,
+						},
+						Log: &tpb.Tree{
+							TreeId: 2,
+						},
+						VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+						VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
+						MinInterval: 1 * time.Second,
+						MaxInterval: 5 * time.Second,
+						RateLimits:  rateLimits,
+						Retention:   retention,
+					},
+					setDelete:   true,
+					isDeleted:   false,
+					readDeleted: false,
+					wantReadErr: false,
 				},
-				VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-				VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-				MinInterval: 1 * time.Second,
-				MaxInterval: 5 * time.Second,
-			},
-			setDelete:   true,
-			isDeleted:   false,
-			readDeleted: false,
-			wantReadErr: false,
-		},
-	} {
-		t.Run(tc.desc, func(t *testing.T) {
-			if tc.write {
-				err := s.Write(ctx, &tc.d)
-				if got, want := err != nil, tc.wantWriteErr; got != want {
-					t.Errorf("Write(): %v, want err: %v", err, want)
-					return
+			} {
+				t.Run(tc.desc, func(t *testing.T) {
+					if tc.write {
+						err := s.Write(ctx, &tc.d)
+						if got, want := err != nil, tc.wantWriteErr; got != want {
+							t.Errorf("Write(): %v, want err: %v", err, want)
+							return
+						}
+						if err != nil {
+							return
+						}
+					}
+					if tc.setDelete {
+						tc.d.DeletedTimestamp = time.Now().Truncate(time.Second)
+						tc.d.Deleted = tc.isDeleted
+						if err := s.SetDelete(ctx, tc.d.DirectoryID, tc.isDeleted); err != nil {
+							t.Errorf("SetDelete(%v, %v): %v", tc.d.DirectoryID, tc.isDeleted, err)
+							return
+						}
+					}
+
+					directory, err := s.Read(ctx, tc.d.DirectoryID, tc.readDeleted)
+					if got, want := err != nil, tc.wantReadErr; got != want {
+						t.Errorf("Read(): %v, want err: %v", err, want)
+					}
+					if err != nil {
+						return
+					}
+					if got, want := *directory, tc.d; !cmp.Equal(got, want, cmp.Comparer(proto.Equal)) {
+						t.Errorf("Read(%v, %v): %#v, want %#v, diff: \n%v",
+							tc.d.DirectoryID, tc.readDeleted, got, want, cmp.Diff(got, want))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			s, done := newStorage(ctx, t, newDB)
+			defer done(ctx)
+			for _, tc := range []struct {
+				directoryID string
+			}{
+				{directoryID: "test"},
+			} {
+				d := &directory.Directory{
+					Map: &tpb.Tree{
+						TreeId: 1,
+					},
+					Log: &tpb.Tree{
+						TreeId: 2,
+					},
+					DirectoryID: tc.directoryID,
+					VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
+					VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
 				}
-				if err != nil {
-					return
+				if err := s.Write(ctx, d); err != nil {
+					t.Errorf("Write(): %v", err)
 				}
-			}
-			if tc.setDelete {
-				tc.d.DeletedTimestamp = time.Now().Truncate(time.Second)
-				tc.d.Deleted = tc.isDeleted
-				if err := s.SetDelete(ctx, tc.d.DirectoryID, tc.isDeleted); err != nil {
-					t.Errorf("SetDelete(%v, %v): %v", tc.d.DirectoryID, tc.isDeleted, err)
-					return
+				if err := s.Delete(ctx, tc.directoryID); err != nil {
+					t.Errorf("Delete(): %v", err)
+				}
+				_, err := s.Read(ctx, tc.directoryID, true)
+				if got, want := status.Code(err), codes.NotFound; got != want {
+					t.Errorf("Read(): %v, wanted %v", got, want)
+				}
+				_, err = s.Read(ctx, tc.directoryID, false)
+				if got, want := status.Code(err), codes.NotFound; got != want {
+					t.Errorf("Read(): %v, wanted %v", got, want)
 				}
-			}
-
-			directory, err := s.Read(ctx, tc.d.DirectoryID, tc.readDeleted)
-			if got, want := err != nil, tc.wantReadErr; got != want {
-				t.Errorf("Read(): %v, want err: %v", err, want)
-			}
-			if err != nil {
-				return
-			}
-			if got, want := *directory, tc.d; !cmp.Equal(got, want, cmp.Comparer(proto.Equal)) {
-				t.Errorf("Read(%v, %v): %#v, want %#v, diff: \n%v",
-					tc.d.DirectoryID, tc.readDeleted, got, want, cmp.Diff(got, want))
 			}
 		})
 	}
 }
 
-func TestDelete(t *testing.T) {
-	ctx := context.Background()
-	s, done := newStorage(ctx, t)
-	defer done(ctx)
-	for _, tc := range []struct {
-		directoryID string
-	}{
-		{directoryID: "test"},
-	} {
-		d := &directory.Directory{
-			Map: &tpb.Tree{
-				TreeId: 1,
-			},
-			Log: &tpb.Tree{
-				TreeId: 2,
-			},
-			DirectoryID: tc.directoryID,
-			VRF:         &keyspb.PublicKey{Der: []byte("pubkeybytes")},
-			VRFPriv:     &keyspb.PrivateKey{Der: []byte("privkeybytes")},
-		}
-		if err := s.Write(ctx, d); err != nil {
-			t.Errorf("Write(): %v", err)
-		}
-		if err := s.Delete(ctx, tc.directoryID); err != nil {
-			t.Errorf("Delete(): %v", err)
-		}
-		_, err := s.Read(ctx, tc.directoryID, true)
-		if got, want := status.Code(err), codes.NotFound; got != want {
-			t.Errorf("Read(): %v, wanted %v", got, want)
-		}
-		_, err = s.Read(ctx, tc.directoryID, false)
-		if got, want := status.Code(err), codes.NotFound; got != want {
-			t.Errorf("Read(): %v, wanted %v", got, want)
-		}
+func TestDirectoryStorageIntegration(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			storagetest.RunDirectoryStorageTests(t,
+				func(ctx context.Context, t *testing.T) (directory.Storage, func(context.Context)) {
+					return newStorage(ctx, t, newDB)
+				})
+		})
 	}
 }
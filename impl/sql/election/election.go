@@ -0,0 +1,336 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package election implements master election with leases stored in an SQL
+// table, as an alternative to etcd for deployments that already run a
+// database.
+//
+// Each resource has one row in the Leases table. The master renews its lease
+// by incrementing the row's Version. Another instance takes over a lease
+// only once it has seen the Version stay the same for longer than a lease
+// duration, measured on its own clock, so instances don't need synchronized
+// clocks. The master gives up mastership one lease duration after the start
+// of its last successful renewal, which is before any takeover.
+//
+// Each new master increments the row's Token. Tokens are fencing tokens: the
+// sequencer sends the token of its mastership with the requests that define,
+// seal and prune, and mutation storage rejects writes whose token is older
+// than the highest one it has seen for the directory.
+package election
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/util/election2"
+
+	ktsql "github.com/google/keytransparency/impl/sql"
+)
+
+// Migrations are the schema changes applied to the Leases table in order.
+var Migrations = []ktsql.Migration{
+	{
+		Description: "Create Leases",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL: {`
+CREATE TABLE IF NOT EXISTS Leases(
+  ResourceID VARCHAR(255) NOT NULL,
+  InstanceID VARCHAR(255) NOT NULL, -- Empty if the lease is released.
+  Token      BIGINT       NOT NULL, -- Incremented by every new master.
+  Version    BIGINT       NOT NULL, -- Incremented by every change.
+  PRIMARY KEY(ResourceID)
+);`},
+			ktsql.Postgres: {`
+CREATE TABLE IF NOT EXISTS Leases(
+  ResourceID VARCHAR(255) NOT NULL,
+  InstanceID VARCHAR(255) NOT NULL, -- Empty if the lease is released.
+  Token      BIGINT       NOT NULL, -- Incremented by every new master.
+  Version    BIGINT       NOT NULL, -- Incremented by every change.
+  PRIMARY KEY(ResourceID)
+);`},
+			ktsql.SQLite: {`
+CREATE TABLE IF NOT EXISTS Leases(
+  ResourceID VARCHAR(255) NOT NULL,
+  InstanceID VARCHAR(255) NOT NULL, -- Empty if the lease is released.
+  Token      BIGINT       NOT NULL, -- Incremented by every new master.
+  Version    BIGINT       NOT NULL, -- Incremented by every change.
+  PRIMARY KEY(ResourceID)
+);`},
+		},
+	},
+}
+
+const (
+	readSQL = `
+SELECT InstanceID, Token, Version FROM Leases WHERE ResourceID = ?;`
+	insertSQL = `
+INSERT INTO Leases (ResourceID, InstanceID, Token, Version) VALUES (?, ?, 1, 1);`
+	takeOverSQL = `
+UPDATE Leases SET InstanceID = ?, Token = Token + 1, Version = Version + 1
+WHERE ResourceID = ? AND Version = ?;`
+	renewSQL = `
+UPDATE Leases SET Version = Version + 1
+WHERE ResourceID = ? AND InstanceID = ? AND Token = ?;`
+	releaseSQL = `
+UPDATE Leases SET InstanceID = '', Version = Version + 1
+WHERE ResourceID = ? AND InstanceID = ? AND Token = ?;`
+)
+
+// Factory creates Elections whose leases are stored in an SQL database.
+// It implements election2.Factory.
+type Factory struct {
+	db         *sql.DB
+	dialect    ktsql.Dialect
+	instanceID string
+	// LeaseDuration is the time a master keeps mastership without renewing
+	// its lease. Other instances take the lease over once they have seen no
+	// renewal for LeaseDuration plus RenewInterval.
+	LeaseDuration time.Duration
+	// RenewInterval is the time between renewals of a lease. It must be
+	// well below LeaseDuration.
+	RenewInterval time.Duration
+	// RetryInterval is the time between attempts to capture a lease held by
+	// another instance.
+	RetryInterval time.Duration
+}
+
+// NewFactory returns a Factory for instanceID, which must be unique among
+// the instances sharing db, migrating the Leases table to the latest schema.
+func NewFactory(db *sql.DB, instanceID string, leaseDuration time.Duration) (*Factory, error) {
+	if err := Migrate(context.Background(), db); err != nil {
+		return nil, err
+	}
+	return &Factory{
+		db:            db,
+		dialect:       ktsql.DialectOf(db),
+		instanceID:    instanceID,
+		LeaseDuration: leaseDuration,
+		RenewInterval: leaseDuration / 4,
+		RetryInterval: leaseDuration / 4,
+	}, nil
+}
+
+// Migrate applies any new Migrations to the Leases table in db.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return ktsql.Migrate(ctx, db, "election", Migrations)
+}
+
+// NewElection returns an Election for resourceID.
+func (f *Factory) NewElection(ctx context.Context, resourceID string) (election2.Election, error) {
+	return &Election{f: f, resourceID: resourceID}, nil
+}
+
+// Election is a participant in the election of the master of one resource.
+// It implements election2.Election.
+type Election struct {
+	f          *Factory
+	resourceID string
+
+	// seenVersion is the version of a lease held by another instance, and
+	// seenAt the time it was first read.
+	seenVersion int64
+	seenAt      time.Time
+
+	mu sync.Mutex
+	// token is the fencing token of the current mastership, or 0.
+	token int64
+	// lost is closed when the current mastership ends.
+	lost chan struct{}
+	// stop stops renewing the current mastership, and done is closed once
+	// renewals have stopped.
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+// Token returns the fencing token of the current mastership, or 0 if this
+// instance is not the master.
+func (e *Election) Token() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.token
+}
+
+// Await blocks until this instance is the master of the resource.
+func (e *Election) Await(ctx context.Context) error {
+	for {
+		if e.Token() != 0 {
+			return nil
+		}
+		start := time.Now()
+		token, err := e.tryCapture(ctx)
+		if err != nil {
+			glog.Warningf("election: capture %v: %v", e.resourceID, err)
+		}
+		if token != 0 {
+			e.becomeMaster(token, start)
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.f.RetryInterval):
+		}
+	}
+}
+
+// tryCapture creates, takes over or reclaims the lease, and returns the new
+// fencing token, or 0 if another instance still holds the lease.
+func (e *Election) tryCapture(ctx context.Context) (int64, error) {
+	var instanceID string
+	var token, version int64
+	err := e.f.db.QueryRowContext(ctx, e.f.dialect.Rebind(readSQL), e.resourceID).Scan(&instanceID, &token, &version)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err := e.f.db.ExecContext(ctx, e.f.dialect.Rebind(insertSQL), e.resourceID, e.f.instanceID); err != nil {
+			if ktsql.IsDuplicate(err) {
+				return 0, nil // Another instance created the lease first.
+			}
+			return 0, err
+		}
+		return 1, nil
+	case err != nil:
+		return 0, err
+	}
+
+	// A lease held by another instance, or by an earlier mastership of this
+	// instance, may only be taken over once it has expired.
+	if instanceID != "" {
+		if version != e.seenVersion || e.seenAt.IsZero() {
+			e.seenVersion, e.seenAt = version, time.Now()
+			return 0, nil
+		}
+		// Wait one more renewal interval for the master to notice that its
+		// lease expired.
+		if time.Since(e.seenAt) < e.f.LeaseDuration+e.f.RenewInterval {
+			return 0, nil
+		}
+		glog.Warningf("election: taking over %v from %v after lease expired", e.resourceID, instanceID)
+	}
+	res, err := e.f.db.ExecContext(ctx, e.f.dialect.Rebind(takeOverSQL), e.f.instanceID, e.resourceID, version)
+	if err != nil {
+		return 0, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return 0, err // The lease changed since it was read.
+	}
+	e.seenAt = time.Time{}
+	return token + 1, nil
+}
+
+// becomeMaster records the mastership of token, whose lease was written at
+// or after start, and starts renewing it.
+func (e *Election) becomeMaster(token int64, start time.Time) {
+	ctx, stop := context.WithCancel(context.Background())
+	lost := make(chan struct{})
+	done := make(chan struct{})
+	e.mu.Lock()
+	e.token, e.lost, e.stop, e.done = token, lost, stop, done
+	e.mu.Unlock()
+	glog.Infof("election: %v is master of %v with token %v", e.f.instanceID, e.resourceID, token)
+
+	go func() {
+		defer close(done)
+		e.renew(ctx, token, start.Add(e.f.LeaseDuration))
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.token == token {
+			e.token = 0
+		}
+		close(lost)
+	}()
+}
+
+// renew extends the lease of token until ctx is done, a renewal finds that
+// another instance took the lease over, or deadline passes without a
+// successful renewal.
+func (e *Election) renew(ctx context.Context, token int64, deadline time.Time) {
+	for {
+		wait := e.f.RenewInterval
+		if untilDeadline := time.Until(deadline); untilDeadline < wait {
+			wait = untilDeadline
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if !time.Now().Before(deadline) {
+			glog.Errorf("election: lease of %v expired", e.resourceID)
+			return
+		}
+
+		start := time.Now()
+		rctx, cancel := context.WithDeadline(ctx, deadline)
+		res, err := e.f.db.ExecContext(rctx, e.f.dialect.Rebind(renewSQL), e.resourceID, e.f.instanceID, token)
+		cancel()
+		if err != nil {
+			glog.Warningf("election: renew %v: %v", e.resourceID, err)
+			continue
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 1 {
+			glog.Errorf("election: lost lease of %v: %v", e.resourceID, err)
+			return
+		}
+		deadline = start.Add(e.f.LeaseDuration)
+	}
+}
+
+// WithMastership returns a context that is done when this instance stops
+// being the master, or when ctx is done.
+func (e *Election) WithMastership(ctx context.Context) (context.Context, error) {
+	cctx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	token, lost := e.token, e.lost
+	e.mu.Unlock()
+	if token == 0 {
+		cancel()
+		return cctx, nil
+	}
+	go func() {
+		defer cancel()
+		select {
+		case <-lost:
+		case <-cctx.Done():
+		}
+	}()
+	return cctx, nil
+}
+
+// Resign stops renewing the lease and releases it, so that another instance
+// can take it over without waiting for it to expire.
+func (e *Election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	token, stop, done := e.token, e.stop, e.done
+	e.mu.Unlock()
+	if token == 0 {
+		return nil
+	}
+	stop()
+	<-done
+	_, err := e.f.db.ExecContext(ctx, e.f.dialect.Rebind(releaseSQL), e.resourceID, e.f.instanceID, token)
+	return err
+}
+
+// Close resigns, even if ctx is done.
+func (e *Election) Close(ctx context.Context) error {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(), e.f.LeaseDuration)
+		defer cancel()
+	}
+	return e.Resign(ctx)
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package election
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/util/election2/testonly"
+
+	"github.com/google/keytransparency/impl/sql/testdb"
+)
+
+const (
+	resourceID = "directory"
+	lease      = 500 * time.Millisecond
+)
+
+func newElection(ctx context.Context, t *testing.T, db *sql.DB, instanceID string) (*Factory, *Election) {
+	t.Helper()
+	f, err := NewFactory(db, instanceID, lease)
+	if err != nil {
+		t.Fatalf("NewFactory(): %v", err)
+	}
+	e, err := f.NewElection(ctx, resourceID)
+	if err != nil {
+		t.Fatalf("NewElection(): %v", err)
+	}
+	return f, e.(*Election)
+}
+
+func TestElection(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			for _, nt := range testonly.Tests {
+				nt := nt
+				t.Run(nt.Name, func(t *testing.T) {
+					ctx := context.Background()
+					db, done := newDB(ctx, t)
+					defer done(ctx)
+					f, err := NewFactory(db, "instance", lease)
+					if err != nil {
+						t.Fatalf("NewFactory(): %v", err)
+					}
+					nt.Run(t, f)
+				})
+			}
+		})
+	}
+}
+
+func TestFailover(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			_, a := newElection(ctx, t, db, "a")
+			_, b := newElection(ctx, t, db, "b")
+
+			if err := a.Await(ctx); err != nil {
+				t.Fatalf("a.Await(): %v", err)
+			}
+			if got, want := a.Token(), int64(1); got != want {
+				t.Errorf("a.Token(): %v, want %v", got, want)
+			}
+
+			// a keeps renewing its lease, so b can't capture it.
+			cctx, cancel := context.WithTimeout(ctx, 3*lease)
+			defer cancel()
+			if err := b.Await(cctx); err != context.DeadlineExceeded {
+				t.Fatalf("b.Await(): %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			// A resigned lease is captured without waiting for it to expire.
+			mctx, err := a.WithMastership(ctx)
+			if err != nil {
+				t.Fatalf("a.WithMastership(): %v", err)
+			}
+			if err := a.Resign(ctx); err != nil {
+				t.Fatalf("a.Resign(): %v", err)
+			}
+			<-mctx.Done()
+			start := time.Now()
+			cctx, cancel = context.WithTimeout(ctx, lease)
+			defer cancel()
+			if err := b.Await(cctx); err != nil {
+				t.Fatalf("b.Await(): %v", err)
+			}
+			t.Logf("b became master %v after a resigned", time.Since(start))
+			if got, want := b.Token(), int64(2); got != want {
+				t.Errorf("b.Token(): %v, want %v", got, want)
+			}
+			if got := a.Token(); got != 0 {
+				t.Errorf("a.Token(): %v, want 0", got)
+			}
+			if err := b.Close(ctx); err != nil {
+				t.Errorf("b.Close(): %v", err)
+			}
+		})
+	}
+}
+
+func TestSplitBrain(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			fa, a := newElection(ctx, t, db, "a")
+			_, b := newElection(ctx, t, db, "b")
+			// a stops renewing its lease, as if it were partitioned from
+			// the database.
+			fa.RenewInterval = time.Hour
+
+			if err := a.Await(ctx); err != nil {
+				t.Fatalf("a.Await(): %v", err)
+			}
+			actx, err := a.WithMastership(ctx)
+			if err != nil {
+				t.Fatalf("a.WithMastership(): %v", err)
+			}
+			if err := b.Await(ctx); err != nil {
+				t.Fatalf("b.Await(): %v", err)
+			}
+			select {
+			case <-actx.Done():
+			default:
+				t.Fatal("b became master while a's mastership context was active")
+			}
+			if got, want := b.Token(), int64(2); got != want {
+				t.Errorf("b.Token(): %v, want %v", got, want)
+			}
+
+			// The fencing token of a's mastership no longer holds the lease.
+			res, err := db.ExecContext(ctx, fa.dialect.Rebind(renewSQL), resourceID, "a", 1)
+			if err != nil {
+				t.Fatalf("renew: %v", err)
+			}
+			if n, err := res.RowsAffected(); err != nil || n != 0 {
+				t.Errorf("renew with token 1: %v rows, %v, want 0 rows", n, err)
+			}
+			if err := a.Resign(ctx); err != nil {
+				t.Errorf("a.Resign(): %v", err)
+			}
+			bctx, err := b.WithMastership(ctx)
+			if err != nil {
+				t.Fatalf("b.WithMastership(): %v", err)
+			}
+			cctx, cancel := context.WithTimeout(ctx, 3*lease)
+			defer cancel()
+			if err := a.Await(cctx); err != context.DeadlineExceeded {
+				t.Errorf("a.Await(): %v, want %v", err, context.DeadlineExceeded)
+			}
+			if bctx.Err() != nil {
+				t.Errorf("b lost mastership: %v", bctx.Err())
+			}
+			if err := b.Close(ctx); err != nil {
+				t.Errorf("b.Close(): %v", err)
+			}
+		})
+	}
+}
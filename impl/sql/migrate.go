@@ -0,0 +1,160 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	createVersionsSQL = `
+CREATE TABLE IF NOT EXISTS SchemaVersions(
+  Component VARCHAR(64) NOT NULL,
+  Version   BIGINT      NOT NULL,
+  PRIMARY KEY(Component)
+);`
+	initVersionSQL   = `INSERT INTO SchemaVersions (Component, Version) VALUES (?, 0);`
+	readVersionSQL   = `SELECT Version FROM SchemaVersions WHERE Component = ?;`
+	updateVersionSQL = `UPDATE SchemaVersions SET Version = ? WHERE Component = ? AND Version = ?;`
+)
+
+// Migration is a change to the schema of a set of tables.
+// Migrations are listed in order: migrations[i] upgrades a schema from
+// version i to version i+1. Released migrations must never be edited.
+type Migration struct {
+	// Description says what the migration changes.
+	Description string
+	// Up are the statements that apply the migration in each Dialect.
+	Up map[Dialect][]string
+	// Applied, if set, returns true if the changes of the migration are
+	// already present in a schema that predates versioning. The migration is
+	// then recorded without running Up.
+	Applied func(ctx context.Context, db *sql.DB) (bool, error)
+}
+
+// HasColumn returns an Applied function for migrations that add column to
+// table.
+func HasColumn(table, column string) func(ctx context.Context, db *sql.DB) (bool, error) {
+	return func(ctx context.Context, db *sql.DB) (bool, error) {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE 1 = 0;", column, table))
+		if err != nil {
+			return false, nil // The column, or the table, does not exist.
+		}
+		return true, rows.Close()
+	}
+}
+
+// SchemaVersion returns the version of component's schema in db.
+// Returns 0 if no migrations have been applied.
+func SchemaVersion(ctx context.Context, db *sql.DB, component string) (int, error) {
+	d := DialectOf(db)
+	if _, err := db.ExecContext(ctx, createVersionsSQL); err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to create schema version table: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, d.Rebind(d.InsertIgnore(initVersionSQL)), component); err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to initialize schema version: %v", err)
+	}
+	var version int
+	if err := db.QueryRowContext(ctx, d.Rebind(readVersionSQL), component).Scan(&version); err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to read schema version: %v", err)
+	}
+	return version, nil
+}
+
+// Migrate applies the migrations that have not yet been applied to
+// component's schema in db. Migrate returns FailedPrecondition if the schema is
+// newer than the latest migration, so that old binaries refuse to run against
+// a schema they don't know about.
+func Migrate(ctx context.Context, db *sql.DB, component string, migrations []Migration) error {
+	d := DialectOf(db)
+	for {
+		version, err := SchemaVersion(ctx, db, component)
+		if err != nil {
+			return err
+		}
+		switch {
+		case version > len(migrations):
+			return status.Errorf(codes.FailedPrecondition,
+				"%v schema version %v is newer than the latest version known to this binary: %v",
+				component, version, len(migrations))
+		case version == len(migrations):
+			return nil
+		}
+		m := migrations[version]
+		stmts, ok := m.Up[d]
+		if !ok {
+			return status.Errorf(codes.Unimplemented, "%v migration %v is not defined for %v", component, version+1, d)
+		}
+		if m.Applied != nil {
+			applied, err := m.Applied(ctx, db)
+			if err != nil {
+				return status.Errorf(codes.Internal, "%v migration %v (%v): %v", component, version+1, m.Description, err)
+			}
+			if applied {
+				glog.Infof("Recording %v schema version %v, which is already present: %v", component, version+1, m.Description)
+				stmts = nil
+			}
+		}
+		glog.Infof("Migrating %v schema to version %v: %v", component, version+1, m.Description)
+		if err := apply(ctx, db, d, component, version, stmts); err != nil {
+			// Another process may have applied the same migration concurrently.
+			if v, vErr := SchemaVersion(ctx, db, component); vErr == nil && v > version {
+				continue
+			}
+			return status.Errorf(codes.Internal, "%v migration %v (%v) failed: %v",
+				component, version+1, m.Description, err)
+		}
+	}
+}
+
+// apply runs stmts and records the schema of component as being one version
+// greater than version. Some databases, notably MySQL, commit schema changes
+// immediately, so a failed migration may be partially applied.
+func apply(ctx context.Context, db *sql.DB, d Dialect, component string, version int, stmts []string) (ret error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				glog.Errorf("Rollback(): %v", err)
+			}
+		}
+	}()
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	result, err := tx.ExecContext(ctx, d.Rebind(updateVersionSQL), version+1, component, version)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows != 1 {
+		return status.Errorf(codes.Aborted, "%v schema version changed during migration", component)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newSQLite(t *testing.T) (*sql.DB, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "kt_migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open("file:" + filepath.Join(dir, "test.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func stmts(s ...string) map[Dialect][]string {
+	return map[Dialect][]string{SQLite: s}
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	createA := Migration{Description: "A", Up: stmts(`CREATE TABLE A (X INTEGER);`)}
+	createB := Migration{Description: "B", Up: stmts(`CREATE TABLE B (X INTEGER);`, `INSERT INTO B VALUES (1);`)}
+	broken := Migration{Description: "broken", Up: stmts(`CREATE TABLE C (X INTEGER);`, `NOT SQL;`)}
+	for _, tc := range []struct {
+		desc        string
+		steps       [][]Migration
+		wantCode    codes.Code
+		wantVersion int
+	}{
+		{desc: "empty", steps: [][]Migration{nil}, wantVersion: 0},
+		{desc: "fresh", steps: [][]Migration{{createA, createB}}, wantVersion: 2},
+		{desc: "idempotent", steps: [][]Migration{{createA, createB}, {createA, createB}}, wantVersion: 2},
+		{desc: "upgrade", steps: [][]Migration{{createA}, {createA, createB}}, wantVersion: 2},
+		{desc: "newer schema", steps: [][]Migration{{createA, createB}, {createA}},
+			wantCode: codes.FailedPrecondition, wantVersion: 2},
+		{desc: "failed migration", steps: [][]Migration{{createA, broken}},
+			wantCode: codes.Internal, wantVersion: 1},
+		{desc: "missing dialect", steps: [][]Migration{{createA, {Description: "mysql only"}}},
+			wantCode: codes.Unimplemented, wantVersion: 1},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			db, done := newSQLite(t)
+			defer done()
+			var err error
+			for _, migrations := range tc.steps {
+				err = Migrate(ctx, db, "test", migrations)
+			}
+			if got := status.Code(err); got != tc.wantCode {
+				t.Errorf("Migrate(): %v, want %v", err, tc.wantCode)
+			}
+			version, err := SchemaVersion(ctx, db, "test")
+			if err != nil {
+				t.Fatalf("SchemaVersion(): %v", err)
+			}
+			if version != tc.wantVersion {
+				t.Errorf("SchemaVersion(): %v, want %v", version, tc.wantVersion)
+			}
+		})
+	}
+}
+
+func TestMigrateApplied(t *testing.T) {
+	ctx := context.Background()
+	migrations := []Migration{
+		{Description: "create A", Up: stmts(`CREATE TABLE IF NOT EXISTS A (X INTEGER);`)},
+		{Description: "add A.Y", Up: stmts(`ALTER TABLE A ADD COLUMN Y INTEGER;`), Applied: HasColumn("A", "Y")},
+	}
+	for _, tc := range []struct {
+		desc   string
+		create string // Creates the table before schemas were versioned.
+	}{
+		{desc: "fresh"},
+		{desc: "unversioned", create: `CREATE TABLE A (X INTEGER);`},
+		{desc: "unversioned with column", create: `CREATE TABLE A (X INTEGER, Y INTEGER);`},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			db, done := newSQLite(t)
+			defer done()
+			if tc.create != "" {
+				if _, err := db.ExecContext(ctx, tc.create); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := Migrate(ctx, db, "test", migrations); err != nil {
+				t.Fatalf("Migrate(): %v", err)
+			}
+			if v, err := SchemaVersion(ctx, db, "test"); err != nil || v != 2 {
+				t.Errorf("SchemaVersion(): %v, %v, want 2", v, err)
+			}
+			if _, err := db.ExecContext(ctx, `INSERT INTO A (X, Y) VALUES (1, 2);`); err != nil {
+				t.Errorf("INSERT: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateComponents(t *testing.T) {
+	ctx := context.Background()
+	db, done := newSQLite(t)
+	defer done()
+	if err := Migrate(ctx, db, "a", []Migration{{Up: stmts(`CREATE TABLE A (X INTEGER);`)}}); err != nil {
+		t.Fatalf("Migrate(a): %v", err)
+	}
+	if v, err := SchemaVersion(ctx, db, "b"); err != nil || v != 0 {
+		t.Errorf("SchemaVersion(b): %v, %v, want 0", v, err)
+	}
+}
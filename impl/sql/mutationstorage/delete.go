@@ -0,0 +1,73 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutationstorage
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+)
+
+// DeleteDirectoryData deletes the input logs, queued mutations, and revision
+// definitions of directoryID. Deleting the data of a directory that has none
+// succeeds, so DeleteDirectoryData may be retried after a failure.
+func (m *Mutations) DeleteDirectoryData(ctx context.Context, directoryID string) (_ *pb.DeletedDirectoryData, ret error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+
+	deleted := &pb.DeletedDirectoryData{DirectoryId: directoryID}
+	for _, t := range []struct {
+		table string
+		count *int64
+	}{
+		{table: "Queue", count: &deleted.Mutations},
+		{table: "QueueRequests"},
+		{table: "Logs", count: &deleted.InputLogs},
+		{table: "Batches", count: &deleted.Batches},
+		{table: "PrunedRevisions"},
+		{table: "Fences"},
+	} {
+		result, err := tx.ExecContext(ctx,
+			m.dialect.Rebind(`DELETE FROM `+t.table+` WHERE DirectoryID = ?;`), directoryID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed deleting from %v: %v", t.table, err)
+		}
+		if t.count == nil {
+			continue
+		}
+		if *t.count, err = result.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	glog.Infof("mutationstorage: DeleteDirectoryData(%v): deleted %v input logs, %v mutations, %v batches",
+		directoryID, deleted.InputLogs, deleted.Mutations, deleted.Batches)
+	return deleted, nil
+}
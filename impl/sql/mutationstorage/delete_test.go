@@ -0,0 +1,78 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutationstorage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/water"
+	"github.com/google/keytransparency/impl/sql/testdb"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+func TestDeleteDirectoryData(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			m, done := newForTest(ctx, t, newDB, "deleted", 1, 2)
+			defer done(ctx)
+			if err := m.AddLogs(ctx, "kept", 1); err != nil {
+				t.Fatalf("AddLogs(): %v", err)
+			}
+			for _, dirID := range []string{"deleted", "kept"} {
+				if _, err := m.Send(ctx, dirID, 1, &pb.EntryUpdate{}, &pb.EntryUpdate{}); err != nil {
+					t.Fatalf("Send(): %v", err)
+				}
+				if err := m.WriteBatchSources(ctx, dirID, 1, &spb.MapMetadata{}); err != nil {
+					t.Fatalf("WriteBatchSources(): %v", err)
+				}
+			}
+
+			for _, tc := range []struct {
+				desc string
+				want *pb.DeletedDirectoryData
+			}{
+				{desc: "first", want: &pb.DeletedDirectoryData{DirectoryId: "deleted", InputLogs: 2, Mutations: 2, Batches: 1}},
+				{desc: "retry", want: &pb.DeletedDirectoryData{DirectoryId: "deleted"}},
+			} {
+				got, err := m.DeleteDirectoryData(ctx, "deleted")
+				if err != nil {
+					t.Fatalf("%v: DeleteDirectoryData(): %v", tc.desc, err)
+				}
+				if !proto.Equal(got, tc.want) {
+					t.Errorf("%v: DeleteDirectoryData(): %v, want %v", tc.desc, got, tc.want)
+				}
+			}
+
+			// The other directory is untouched.
+			logs, err := m.ListLogs(ctx, "kept", false)
+			if err != nil || len(logs) != 1 {
+				t.Errorf("ListLogs(kept): %v, %v, want 1 log", logs, err)
+			}
+			msgs, err := m.ReadLog(ctx, "kept", 1, water.Mark{}, water.NewMark(1<<62), 10)
+			if err != nil || len(msgs) != 2 {
+				t.Errorf("ReadLog(kept): %v mutations, %v, want 2", len(msgs), err)
+			}
+			if _, err := m.ReadBatch(ctx, "kept", 1); err != nil {
+				t.Errorf("ReadBatch(kept): %v", err)
+			}
+		})
+	}
+}
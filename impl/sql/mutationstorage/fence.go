@@ -0,0 +1,61 @@
+// Copyright 2020 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutationstorage
+
+import (
+	"context"
+	"database/sql"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/google/keytransparency/core/sequencer/election"
+)
+
+// checkFence records the fencing token carried by ctx as the highest token
+// seen for directoryID, and returns FailedPrecondition if a higher one has
+// already been seen, which means that a newer master has written to the
+// directory. Writes without a token are not fenced.
+//
+// The update locks the directory's fence until tx ends, so a newer master
+// cannot raise the token between the check and the write it guards.
+func (m *Mutations) checkFence(ctx context.Context, tx *sql.Tx, directoryID string) error {
+	token := election.Token(ctx)
+	if token == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(m.dialect.InsertIgnore(`INSERT INTO Fences (DirectoryID, Token, Version) VALUES (?, 0, 0);`)),
+		directoryID); err != nil {
+		return status.Errorf(codes.Internal, "failed creating fence: %v", err)
+	}
+	// Version changes on every write, so that a write with the current token
+	// counts as an affected row in every dialect.
+	result, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(`UPDATE Fences SET Token = ?, Version = Version + 1 WHERE DirectoryID = ? AND Token <= ?;`),
+		token, directoryID, token)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed updating fence: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return status.Errorf(codes.FailedPrecondition,
+			"fencing token %v of directory %v has been superseded by a newer master", token, directoryID)
+	}
+	return nil
+}
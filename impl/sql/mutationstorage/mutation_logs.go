@@ -23,39 +23,154 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/core/mutator"
 	"github.com/google/keytransparency/core/water"
+	"github.com/google/keytransparency/internal/backoff"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	ktsql "github.com/google/keytransparency/impl/sql"
 )
 
 // SetWritable enables or disables new writes from going to logID.
+// Sealed and retired logs cannot be enabled.
 func (m *Mutations) SetWritable(ctx context.Context, directoryID string, logID int64, enabled bool) error {
-	result, err := m.db.ExecContext(ctx,
-		`UPDATE Logs SET Enabled = ? WHERE DirectoryID = ? AND LogID = ?;`,
-		enabled, directoryID, logID)
-	if err != nil {
+	return m.updateLog(ctx, directoryID, logID, func(tx *sql.Tx, l *pb.InputLog) error {
+		if enabled && l.State != pb.InputLog_ACTIVE {
+			return status.Errorf(codes.FailedPrecondition, "log %d of directory %v is %v", logID, directoryID, l.State)
+		}
+		_, err := tx.ExecContext(ctx,
+			m.dialect.Rebind(`UPDATE Logs SET Enabled = ? WHERE DirectoryID = ? AND LogID = ?;`),
+			enabled, directoryID, logID)
 		return err
-	}
-	rows, err := result.RowsAffected()
+	})
+}
+
+// SealLog marks the read-only log logID as sealed at final, the watermark just
+// beyond its last mutation. Returns FailedPrecondition if the log is writable
+// or holds mutations at or beyond final, or if ctx carries a stale fencing
+// token. Sealing a sealed or retired log does nothing.
+func (m *Mutations) SealLog(ctx context.Context, directoryID string, logID int64, final water.Mark) error {
+	return m.updateLog(ctx, directoryID, logID, func(tx *sql.Tx, l *pb.InputLog) error {
+		if err := m.checkFence(ctx, tx, directoryID); err != nil {
+			return err
+		}
+		switch {
+		case l.State != pb.InputLog_ACTIVE:
+			return nil
+		case l.Writable:
+			return status.Errorf(codes.FailedPrecondition, "log %d of directory %v is writable", logID, directoryID)
+		}
+		var pending int64
+		if err := tx.QueryRowContext(ctx,
+			m.dialect.Rebind(`SELECT COUNT(*) FROM Queue WHERE DirectoryID = ? AND LogID = ? AND TimeMicros >= ?;`),
+			directoryID, logID, final.Value()).Scan(&pending); err != nil {
+			return err
+		}
+		if pending > 0 {
+			return status.Errorf(codes.FailedPrecondition,
+				"log %d of directory %v has %d mutations at or beyond %v", logID, directoryID, pending, final)
+		}
+		_, err := tx.ExecContext(ctx,
+			m.dialect.Rebind(`UPDATE Logs SET State = ?, FinalWatermark = ? WHERE DirectoryID = ? AND LogID = ?;`),
+			int32(pb.InputLog_SEALED), final.Value(), directoryID, logID)
+		return err
+	})
+}
+
+// RetireLog marks the sealed log logID as retired. Returns FailedPrecondition
+// if the log has not been sealed. Retiring a retired log does nothing.
+func (m *Mutations) RetireLog(ctx context.Context, directoryID string, logID int64) error {
+	return m.updateLog(ctx, directoryID, logID, func(tx *sql.Tx, l *pb.InputLog) error {
+		switch l.State {
+		case pb.InputLog_RETIRED:
+			return nil
+		case pb.InputLog_ACTIVE:
+			return status.Errorf(codes.FailedPrecondition, "log %d of directory %v has not been sealed", logID, directoryID)
+		}
+		_, err := tx.ExecContext(ctx,
+			m.dialect.Rebind(`UPDATE Logs SET State = ? WHERE DirectoryID = ? AND LogID = ?;`),
+			int32(pb.InputLog_RETIRED), directoryID, logID)
+		return err
+	})
+}
+
+// updateLog reads logID and calls update with it in a transaction.
+// Returns NotFound if the log does not exist.
+func (m *Mutations) updateLog(ctx context.Context, directoryID string, logID int64,
+	update func(tx *sql.Tx, l *pb.InputLog) error) (ret error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+	l, err := scanInputLog(tx.QueryRowContext(ctx,
+		m.dialect.Rebind(`SELECT LogID, Enabled, State, FinalWatermark FROM Logs WHERE DirectoryID = ? AND LogID = ?;`),
+		directoryID, logID))
+	switch {
+	case err == sql.ErrNoRows:
 		return status.Errorf(codes.NotFound, "log %d not found for directory %v", logID, directoryID)
+	case err != nil:
+		return err
+	}
+	l.DirectoryId = directoryID
+	if err := update(tx, l); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListInputLogs returns all the logs of directoryID, including retired logs,
+// ordered by logID.
+func (m *Mutations) ListInputLogs(ctx context.Context, directoryID string) ([]*pb.InputLog, error) {
+	rows, err := m.db.QueryContext(ctx,
+		m.dialect.Rebind(`SELECT LogID, Enabled, State, FinalWatermark FROM Logs WHERE DirectoryID = ? ORDER BY LogID;`),
+		directoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logs []*pb.InputLog
+	for rows.Next() {
+		l, err := scanInputLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		l.DirectoryId = directoryID
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(logs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no log found for directory %v", directoryID)
+	}
+	return logs, nil
+}
+
+// scanInputLog reads a row of LogID, Enabled, State, FinalWatermark.
+func scanInputLog(row interface{ Scan(...interface{}) error }) (*pb.InputLog, error) {
+	var l pb.InputLog
+	var state int32
+	if err := row.Scan(&l.LogId, &l.Writable, &state, &l.FinalWatermark); err != nil {
+		return nil, err
 	}
-	return err
+	l.State = pb.InputLog_State(state)
+	return &l, nil
 }
 
 // AddLogs creates and adds new logs for writing to a directory.
+// Logs that already exist are left unchanged, so AddLogs may be retried.
 func (m *Mutations) AddLogs(ctx context.Context, directoryID string, logIDs ...int64) error {
 	glog.Infof("mutationstorage: AddLog(%v, %v)", directoryID, logIDs)
 	for _, logID := range logIDs {
-		// TODO(gdbelvin): Use INSERT IGNORE to allow this function to be retried.
-		// TODO(gdbelvin): Migrate to a MySQL Docker image for unit tests.
-		// MySQL and SQLite do not have the same syntax for INSERT IGNORE.
 		if _, err := m.db.ExecContext(ctx,
-			`INSERT INTO Logs (DirectoryID, LogID, Enabled)  Values(?, ?, ?);`,
+			m.dialect.Rebind(m.dialect.InsertIgnore(`INSERT INTO Logs (DirectoryID, LogID, Enabled)  Values(?, ?, ?);`)),
 			directoryID, logID, true); err != nil {
 			return err
 		}
@@ -65,6 +180,7 @@ func (m *Mutations) AddLogs(ctx context.Context, directoryID string, logIDs ...i
 
 // Send writes mutations to the leading edge (by sequence number) of the mutations table.
 // Returns the logID/watermark pair that was written, or nil if nothing was written.
+// Watermarks are strictly increasing within a log, including across concurrent calls.
 // TODO(gbelvin): Make updates a slice.
 func (m *Mutations) Send(ctx context.Context, directoryID string, logID int64, updates ...*pb.EntryUpdate) (water.Mark, error) {
 	glog.Infof("mutationstorage: Send(%v, <mutation>)", directoryID)
@@ -72,32 +188,50 @@ func (m *Mutations) Send(ctx context.Context, directoryID string, logID int64, u
 		return water.Mark{}, nil
 	}
 	updateData := make([][]byte, 0, len(updates))
+	var requestIDs []string
 	for _, u := range updates {
 		data, err := proto.Marshal(u)
 		if err != nil {
 			return water.Mark{}, err
 		}
 		updateData = append(updateData, data)
+		if id := u.GetRequestId(); id != "" {
+			requestIDs = append(requestIDs, id)
+		}
 	}
-	// TODO(gbelvin): Implement retry with backoff for retryable errors if
-	// we get timestamp contention.
-	wm := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
-	if err := m.send(ctx, wm, directoryID, logID, updateData...); err != nil {
-		return water.Mark{}, err
+	// Transactions that conflict with a concurrent Send are retried.
+	b := backoff.Backoff{
+		Min:    time.Millisecond,
+		Max:    100 * time.Millisecond,
+		Factor: 2,
+		Jitter: true,
+	}
+	for attempt := 1; ; attempt++ {
+		now := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
+		wm, err := m.send(ctx, now, directoryID, logID, requestIDs, updateData...)
+		if status.Code(err) != codes.Aborted || attempt >= sendAttempts {
+			return wm, err
+		}
+		glog.V(2).Infof("mutationstorage: Send(%v, %v) attempt %d: %v", directoryID, logID, attempt, err)
+		select {
+		case <-time.After(b.Duration()):
+		case <-ctx.Done():
+			return water.Mark{}, status.FromContextError(ctx.Err()).Err()
+		}
 	}
-	return wm, nil
 }
 
-// ListLogs returns a list of all logs for directoryID, optionally filtered for writable logs.
+// ListLogs returns a list of all logs for directoryID that have not been
+// retired, optionally filtered for writable logs.
 func (m *Mutations) ListLogs(ctx context.Context, directoryID string, writable bool) ([]int64, error) {
 	var query string
 	if writable {
-		query = `SELECT LogID from Logs WHERE DirectoryID = ? AND Enabled = True;`
+		query = `SELECT LogID from Logs WHERE DirectoryID = ? AND State <> ? AND Enabled = TRUE;`
 	} else {
-		query = `SELECT LogID from Logs WHERE DirectoryID = ?;`
+		query = `SELECT LogID from Logs WHERE DirectoryID = ? AND State <> ?;`
 	}
 	var logIDs []int64
-	rows, err := m.db.QueryContext(ctx, query, directoryID)
+	rows, err := m.db.QueryContext(ctx, m.dialect.Rebind(query), directoryID, int32(pb.InputLog_RETIRED))
 	if err != nil {
 		return nil, err
 	}
@@ -119,13 +253,19 @@ func (m *Mutations) ListLogs(ctx context.Context, directoryID string, writable b
 	return logIDs, nil
 }
 
-// ts must be greater than all other timestamps currently recorded for directoryID.
-func (m *Mutations) send(ctx context.Context, wm water.Mark, directoryID string,
-	logID int64, mData ...[]byte) (ret error) {
+// The mutations are written at watermark now, or, if logID has already issued
+// a watermark at or after now, at one more than the last watermark issued in
+// logID. Watermarks within a log are therefore strictly increasing even if
+// clocks step backwards, concurrent writers read the same time, or the queue
+// has been pruned.
+// Returns the watermark of the original batch if requestIDs have been sent before.
+// Returns Aborted if the transaction conflicted with a concurrent send.
+func (m *Mutations) send(ctx context.Context, now water.Mark, directoryID string,
+	logID int64, requestIDs []string, mData ...[]byte) (_ water.Mark, ret error) {
 	tx, err := m.db.BeginTx(ctx,
 		&sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
-		return err
+		return water.Mark{}, txError(err, "failed to begin transaction")
 	}
 	defer func() {
 		if ret != nil {
@@ -135,26 +275,114 @@ func (m *Mutations) send(ctx context.Context, wm water.Mark, directoryID string,
 		}
 	}()
 
-	var maxTimestamp int64
-	if err := tx.QueryRowContext(ctx,
-		`SELECT COALESCE(MAX(TimeMicros), 0) FROM Queue WHERE DirectoryID = ? AND LogID = ?;`,
-		directoryID, logID).Scan(&maxTimestamp); err != nil {
-		return status.Errorf(codes.Internal, "could not find max timestamp: %v", err)
+	since := now.Value() - uint64(m.requestWindow/time.Microsecond)
+	orig, sent, err := m.findRequests(ctx, tx, directoryID, since, requestIDs)
+	if err != nil {
+		return water.Mark{}, err
+	}
+	if sent {
+		return orig, tx.Commit()
+	}
+
+	// Reject writes to read-only logs so that they can be sealed.
+	var enabled bool
+	var lastTimestamp int64
+	switch err := tx.QueryRowContext(ctx,
+		m.dialect.Rebind(`SELECT Enabled, LastWatermark FROM Logs WHERE DirectoryID = ? AND LogID = ?;`),
+		directoryID, logID).Scan(&enabled, &lastTimestamp); {
+	case err == sql.ErrNoRows:
+		// Writes to logs that were never added are not restricted. Such logs
+		// are never sequenced, so their queue is never pruned.
+		if err := tx.QueryRowContext(ctx,
+			m.dialect.Rebind(`SELECT COALESCE(MAX(TimeMicros), 0) FROM Queue WHERE DirectoryID = ? AND LogID = ?;`),
+			directoryID, logID).Scan(&lastTimestamp); err != nil {
+			return water.Mark{}, txError(err, "could not find max timestamp")
+		}
+	case err != nil:
+		return water.Mark{}, txError(err, "could not read log")
+	case !enabled:
+		return water.Mark{}, status.Errorf(codes.FailedPrecondition, "log %d of directory %v is read-only", logID, directoryID)
 	}
 
-	if wm.Value() <= uint64(maxTimestamp) {
-		return status.Errorf(codes.Aborted,
-			"current timestamp: %v, want > max-timestamp of queued mutations: %v", wm, maxTimestamp)
+	wm := now
+	if wm.Value() <= uint64(lastTimestamp) {
+		wm = water.NewMark(uint64(lastTimestamp) + 1)
+	}
+	if _, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(`UPDATE Logs SET LastWatermark = ? WHERE DirectoryID = ? AND LogID = ?;`),
+		wm.Value(), directoryID, logID); err != nil {
+		return water.Mark{}, txError(err, "failed updating last watermark")
 	}
 
 	for i, data := range mData {
 		if _, err = tx.ExecContext(ctx,
-			`INSERT INTO Queue (DirectoryID, LogID, TimeMicros, LocalID, Mutation) VALUES (?, ?, ?, ?, ?);`,
+			m.dialect.Rebind(`INSERT INTO Queue (DirectoryID, LogID, TimeMicros, LocalID, Mutation) VALUES (?, ?, ?, ?, ?);`),
 			directoryID, logID, wm.Value(), i, data); err != nil {
-			return status.Errorf(codes.Internal, "failed inserting into queue: %v", err)
+			return water.Mark{}, txError(err, "failed inserting into queue")
 		}
 	}
-	return tx.Commit()
+	for _, id := range requestIDs {
+		if _, err = tx.ExecContext(ctx,
+			m.dialect.Rebind(`INSERT INTO QueueRequests (DirectoryID, RequestID, LogID, TimeMicros) VALUES (?, ?, ?, ?);`),
+			directoryID, id, logID, wm.Value()); err != nil {
+			return water.Mark{}, txError(err, "failed inserting request id")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return water.Mark{}, txError(err, "failed to commit")
+	}
+	return wm, nil
+}
+
+// txError converts err into a status error. Conflicts with concurrent
+// transactions are Aborted so that they can be retried.
+func txError(err error, msg string) error {
+	if ktsql.IsConflict(err) {
+		return status.Errorf(codes.Aborted, "%v: %v", msg, err)
+	}
+	return status.Errorf(codes.Internal, "%v: %v", msg, err)
+}
+
+// findRequests looks up requestIDs that were sent after the since watermark.
+// Returns true and the watermark of the original batch if all of requestIDs
+// were sent together, and false if none of them were sent. Expired request IDs
+// are forgotten.
+func (m *Mutations) findRequests(ctx context.Context, tx *sql.Tx, directoryID string, since uint64,
+	requestIDs []string) (water.Mark, bool, error) {
+	var found int
+	var origLogID, origTime int64
+	for _, id := range requestIDs {
+		var logID, timeMicros int64
+		err := tx.QueryRowContext(ctx,
+			m.dialect.Rebind(`SELECT LogID, TimeMicros FROM QueueRequests WHERE DirectoryID = ? AND RequestID = ?;`),
+			directoryID, id).Scan(&logID, &timeMicros)
+		switch {
+		case err == sql.ErrNoRows:
+			continue
+		case err != nil:
+			return water.Mark{}, false, status.Errorf(codes.Internal, "could not read request id: %v", err)
+		case uint64(timeMicros) < since:
+			if _, err := tx.ExecContext(ctx,
+				m.dialect.Rebind(`DELETE FROM QueueRequests WHERE DirectoryID = ? AND RequestID = ?;`),
+				directoryID, id); err != nil {
+				return water.Mark{}, false, status.Errorf(codes.Internal, "could not expire request id: %v", err)
+			}
+			continue
+		}
+		if found > 0 && (logID != origLogID || timeMicros != origTime) {
+			return water.Mark{}, false, status.Errorf(codes.AlreadyExists, "request id %q was sent with a different batch", id)
+		}
+		found++
+		origLogID, origTime = logID, timeMicros
+	}
+	switch found {
+	case 0:
+		return water.Mark{}, false, nil
+	case len(requestIDs):
+		return water.NewMark(uint64(origTime)), true, nil
+	default:
+		return water.Mark{}, false, status.Errorf(codes.AlreadyExists, "some request ids were sent with a different batch")
+	}
 }
 
 // HighWatermark returns the highest watermark +1 in logID that is less than or
@@ -164,13 +392,13 @@ func (m *Mutations) HighWatermark(ctx context.Context, directoryID string, logID
 	var count int32
 	var highTimestamp int64
 	if err := m.db.QueryRowContext(ctx,
-		`SELECT COUNT(*), COALESCE(MAX(T1.TimeMicros), 0) FROM
+		m.dialect.Rebind(`SELECT COUNT(*), COALESCE(MAX(T1.TimeMicros), 0) FROM
 		(
 			SELECT Q.TimeMicros FROM Queue as Q
 			WHERE Q.DirectoryID = ? AND Q.LogID = ? AND Q.TimeMicros >= ?
 			ORDER BY Q.TimeMicros ASC
 			LIMIT ?
-		) AS T1`,
+		) AS T1`),
 		directoryID, logID, start.Value(), batchSize).
 		Scan(&count, &highTimestamp); err != nil {
 		return 0, start, err
@@ -188,10 +416,10 @@ func (m *Mutations) ReadLog(ctx context.Context, directoryID string,
 	logID int64, low, high water.Mark, batchSize int32) ([]*mutator.LogMessage, error) {
 	// Advance the low and high marks to the next highest quantum to preserve read semantics.
 	rows, err := m.db.QueryContext(ctx,
-		`SELECT TimeMicros, LocalID, Mutation FROM Queue
+		m.dialect.Rebind(`SELECT TimeMicros, LocalID, Mutation FROM Queue
 		WHERE DirectoryID = ? AND LogID = ? AND TimeMicros >= ? AND TimeMicros < ?
 		ORDER BY TimeMicros, LocalID ASC
-		LIMIT ?;`,
+		LIMIT ?;`),
 		directoryID, logID, low.Value(), high.Value(), batchSize)
 	if err != nil {
 		return nil, err
@@ -206,9 +434,9 @@ func (m *Mutations) ReadLog(ctx context.Context, directoryID string,
 	if len(msgs) > 0 {
 		last := msgs[len(msgs)-1]
 		restRows, err := m.db.QueryContext(ctx,
-			`SELECT TimeMicros, LocalID, Mutation FROM Queue
+			m.dialect.Rebind(`SELECT TimeMicros, LocalID, Mutation FROM Queue
 			WHERE DirectoryID = ? AND LogID = ? AND TimeMicros = ? AND LocalID > ?
-			ORDER BY LocalID ASC;`,
+			ORDER BY LocalID ASC;`),
 			directoryID, logID, last.ID.Value(), last.LocalID)
 		if err != nil {
 			return nil, err
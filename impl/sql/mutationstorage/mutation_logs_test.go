@@ -17,22 +17,28 @@ package mutationstorage
 import (
 	"context"
 	"fmt"
+	"math"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/keytransparency/core/adminserver"
 	"github.com/google/keytransparency/core/integration/storagetest"
 	"github.com/google/keytransparency/core/keyserver"
+	"github.com/google/keytransparency/core/sequencer/election"
 	"github.com/google/keytransparency/core/water"
 	"github.com/google/keytransparency/impl/sql/testdb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
 
-func newForTest(ctx context.Context, t testing.TB, dirID string, logIDs ...int64) (*Mutations, func(context.Context)) {
-	db, done := testdb.NewForTest(ctx, t)
+func newForTest(ctx context.Context, t testing.TB, newDB testdb.NewFunc, dirID string, logIDs ...int64) (*Mutations, func(context.Context)) {
+	db, done := newDB(ctx, t)
 	m, err := New(db)
 	if err != nil {
 		t.Fatalf("Failed to create mutation storage: %v", err)
@@ -44,31 +50,71 @@ func newForTest(ctx context.Context, t testing.TB, dirID string, logIDs ...int64
 }
 
 func TestMutationLogsIntegration(t *testing.T) {
-	storagetest.RunMutationLogsTests(t,
-		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (keyserver.MutationLogs, func(context.Context)) {
-			return newForTest(ctx, t, dirID, logIDs...)
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			storagetest.RunMutationLogsTests(t,
+				func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (keyserver.MutationLogs, func(context.Context)) {
+					return newForTest(ctx, t, newDB, dirID, logIDs...)
+				})
 		})
+	}
 }
 
 func TestLogsAdminIntegration(t *testing.T) {
-	storagetest.RunLogsAdminTests(t,
-		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (adminserver.LogsAdmin, func(context.Context)) {
-			return newForTest(ctx, t, dirID, logIDs...)
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			storagetest.RunLogsAdminTests(t,
+				func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (adminserver.LogsAdmin, func(context.Context)) {
+					return newForTest(ctx, t, newDB, dirID, logIDs...)
+				})
 		})
+	}
 }
 
 func TestMutationLogsReaderIntegration(t *testing.T) {
-	storagetest.RunMutationLogsReaderTests(t,
-		func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (storagetest.LogsReadWriter, func(context.Context)) {
-			return newForTest(ctx, t, dirID, logIDs...)
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			storagetest.RunMutationLogsReaderTests(t,
+				func(ctx context.Context, t *testing.T, dirID string, logIDs ...int64) (storagetest.LogsReadWriter, func(context.Context)) {
+					return newForTest(ctx, t, newDB, dirID, logIDs...)
+				})
 		})
+	}
+}
+
+func TestAddLogsRetry(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestAddLogsRetry"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1)
+			defer done(ctx)
+			if err := m.SetWritable(ctx, directoryID, 1, false); err != nil {
+				t.Fatalf("SetWritable(): %v", err)
+			}
+			if err := m.AddLogs(ctx, directoryID, 1, 2); err != nil {
+				t.Fatalf("AddLogs(): %v", err)
+			}
+			logIDs, err := m.ListLogs(ctx, directoryID, true)
+			if err != nil {
+				t.Fatalf("ListLogs(): %v", err)
+			}
+			if got, want := logIDs, []int64{2}; fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("ListLogs(writable): %v, want %v", got, want)
+			}
+		})
+	}
 }
 
 func BenchmarkSend(b *testing.B) {
 	ctx := context.Background()
 	directoryID := "BenchmarkSend"
 	logID := int64(1)
-	m, done := newForTest(ctx, b, directoryID, logID)
+	m, done := newForTest(ctx, b, testdb.NewForTest, directoryID, logID)
 	defer done(ctx)
 
 	update := &pb.EntryUpdate{Mutation: &pb.SignedEntry{Entry: []byte("xxxxxxxxxxxxxxxxxx")}}
@@ -100,32 +146,223 @@ func BenchmarkSend(b *testing.B) {
 }
 
 func TestSend(t *testing.T) {
-	ctx := context.Background()
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
 
-	directoryID := "TestSend"
-	m, done := newForTest(ctx, t, directoryID, 1, 2)
-	defer done(ctx)
-	update := []byte("bar")
-	wm1 := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
-	wm2 := wm1.Add(1000)
-	wm3 := wm2.Add(1)
+			directoryID := "TestSend"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1, 2)
+			defer done(ctx)
+			update := []byte("bar")
+			wm1 := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
+			wm2 := wm1.Add(1000)
+			wm3 := wm2.Add(1)
+			wm4 := wm2.Add(1000)
 
-	// Test cases are cumulative. Earlier test caes setup later test cases.
-	for _, tc := range []struct {
-		desc     string
-		wm       water.Mark
-		wantCode codes.Code
-	}{
-		// Enforce watermark uniqueness.
-		{desc: "First", wm: wm2},
-		{desc: "Second", wm: wm2, wantCode: codes.Aborted},
-		// Enforce a monotonically increasing watermark.
-		{desc: "Old", wm: wm1, wantCode: codes.Aborted},
-		{desc: "New", wm: wm3},
-	} {
-		err := m.send(ctx, tc.wm, directoryID, 1, update, update)
-		if got, want := status.Code(err), tc.wantCode; got != want {
-			t.Errorf("%v: send(): %v, got: %v, want %v", tc.desc, err, got, want)
-		}
+			// Test cases are cumulative. Earlier test caes setup later test cases.
+			for _, tc := range []struct {
+				desc   string
+				wm     water.Mark
+				logID  int64
+				wantWM water.Mark
+			}{
+				{desc: "First", wm: wm2, logID: 1, wantWM: wm2},
+				// Enforce watermark uniqueness.
+				{desc: "Second", wm: wm2, logID: 1, wantWM: wm2.Add(1)},
+				// Enforce a monotonically increasing watermark.
+				{desc: "Old", wm: wm1, logID: 1, wantWM: wm2.Add(2)},
+				{desc: "New", wm: wm3, logID: 1, wantWM: wm2.Add(3)},
+				{desc: "Future", wm: wm4, logID: 1, wantWM: wm4},
+				// Logs are independent.
+				{desc: "Other log", wm: wm1, logID: 2, wantWM: wm1},
+			} {
+				got, err := m.send(ctx, tc.wm, directoryID, tc.logID, nil, update, update)
+				if err != nil {
+					t.Fatalf("%v: send(): %v", tc.desc, err)
+				}
+				if got != tc.wantWM {
+					t.Errorf("%v: send(): %v, want %v", tc.desc, got, tc.wantWM)
+				}
+			}
+		})
+	}
+}
+
+func TestLogLifecycle(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestLogLifecycle"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1, 2)
+			defer done(ctx)
+			update := []byte("bar")
+			send := func() error {
+				_, err := m.send(ctx, water.NewMark(10), directoryID, 1, nil, update)
+				return err
+			}
+
+			// Test cases are cumulative.
+			for _, tc := range []struct {
+				desc     string
+				f        func() error
+				wantCode codes.Code
+			}{
+				{desc: "send", f: send},
+				{desc: "seal writable", f: func() error { return m.SealLog(ctx, directoryID, 1, water.NewMark(11)) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "retire active", f: func() error { return m.RetireLog(ctx, directoryID, 1) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "read-only", f: func() error { return m.SetWritable(ctx, directoryID, 1, false) }},
+				{desc: "send read-only", f: send, wantCode: codes.FailedPrecondition},
+				{desc: "seal undrained", f: func() error { return m.SealLog(ctx, directoryID, 1, water.NewMark(10)) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "newer master", f: func() error {
+					return m.WriteBatchSources(election.WithToken(ctx, 3), directoryID, 1, &spb.MapMetadata{})
+				}},
+				{desc: "seal stale token", f: func() error { return m.SealLog(election.WithToken(ctx, 2), directoryID, 1, water.NewMark(11)) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "seal", f: func() error { return m.SealLog(ctx, directoryID, 1, water.NewMark(11)) }},
+				{desc: "seal again", f: func() error { return m.SealLog(ctx, directoryID, 1, water.NewMark(12)) }},
+				{desc: "enable sealed", f: func() error { return m.SetWritable(ctx, directoryID, 1, true) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "retire", f: func() error { return m.RetireLog(ctx, directoryID, 1) }},
+				{desc: "retire again", f: func() error { return m.RetireLog(ctx, directoryID, 1) }},
+				{desc: "enable retired", f: func() error { return m.SetWritable(ctx, directoryID, 1, true) },
+					wantCode: codes.FailedPrecondition},
+				{desc: "seal missing", f: func() error { return m.SealLog(ctx, directoryID, 3, water.Mark{}) },
+					wantCode: codes.NotFound},
+			} {
+				if err := tc.f(); status.Code(err) != tc.wantCode {
+					t.Fatalf("%v: %v, want %v", tc.desc, err, tc.wantCode)
+				}
+			}
+
+			logIDs, err := m.ListLogs(ctx, directoryID, false)
+			if err != nil {
+				t.Fatalf("ListLogs(): %v", err)
+			}
+			if want := []int64{2}; !cmp.Equal(logIDs, want) {
+				t.Errorf("ListLogs(): %v, want %v", logIDs, want)
+			}
+			logs, err := m.ListInputLogs(ctx, directoryID)
+			if err != nil {
+				t.Fatalf("ListInputLogs(): %v", err)
+			}
+			want := []*pb.InputLog{
+				{DirectoryId: directoryID, LogId: 1, State: pb.InputLog_RETIRED, FinalWatermark: 11},
+				{DirectoryId: directoryID, LogId: 2, Writable: true},
+			}
+			if !cmp.Equal(logs, want, cmp.Comparer(proto.Equal)) {
+				t.Errorf("ListInputLogs(): %v, want %v", logs, want)
+			}
+		})
+	}
+}
+
+func TestSendRequestWindow(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+
+			directoryID := "TestSendRequestWindow"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1)
+			defer done(ctx)
+			m.requestWindow = time.Second
+			update := []byte("bar")
+			wm1 := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
+			wm2 := wm1.Add(uint64(time.Millisecond / time.Microsecond))
+			wm3 := wm1.Add(uint64(2 * time.Second / time.Microsecond))
+
+			// Test cases are cumulative.
+			for _, tc := range []struct {
+				desc   string
+				wm     water.Mark
+				wantWM water.Mark
+			}{
+				{desc: "First", wm: wm1, wantWM: wm1},
+				{desc: "Retry", wm: wm2, wantWM: wm1},
+				{desc: "Expired", wm: wm3, wantWM: wm3},
+			} {
+				got, err := m.send(ctx, tc.wm, directoryID, 1, []string{"a", "b"}, update, update)
+				if err != nil {
+					t.Fatalf("%v: send(): %v", tc.desc, err)
+				}
+				if got != tc.wantWM {
+					t.Errorf("%v: send(): %v, want %v", tc.desc, got, tc.wantWM)
+				}
+			}
+		})
+	}
+}
+
+// TestSendConcurrent verifies that concurrent writers to the same log are all
+// assigned distinct watermarks and that no update is lost.
+func TestSendConcurrent(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestSendConcurrent"
+			logID := int64(1)
+			m, done := newForTest(ctx, t, newDB, directoryID, logID)
+			defer done(ctx)
+
+			const writers = 8
+			const sends = 20
+			var mu sync.Mutex
+			sent := make(map[water.Mark]int)
+			var wg sync.WaitGroup
+			errs := make(chan error, writers*sends)
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < sends; i++ {
+						update := &pb.EntryUpdate{Mutation: &pb.SignedEntry{Entry: []byte(fmt.Sprintf("%d-%d", w, i))}}
+						wm, err := m.Send(ctx, directoryID, logID, update, update)
+						if err != nil {
+							errs <- err
+							continue
+						}
+						mu.Lock()
+						sent[wm]++
+						mu.Unlock()
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Errorf("Send(): %v", err)
+			}
+			for wm, n := range sent {
+				if n != 1 {
+					t.Errorf("watermark %v was returned %d times", wm, n)
+				}
+			}
+
+			msgs, err := m.ReadLog(ctx, directoryID, logID, water.Mark{}, water.NewMark(math.MaxInt64), writers*sends*2+1)
+			if err != nil {
+				t.Fatalf("ReadLog(): %v", err)
+			}
+			if got, want := len(msgs), writers*sends*2; got != want {
+				t.Errorf("ReadLog(): %d mutations, want %d", got, want)
+			}
+			entries := make(map[string]int)
+			for _, msg := range msgs {
+				if sent[msg.ID] == 0 {
+					t.Errorf("ReadLog(): unexpected watermark %v", msg.ID)
+				}
+				entries[string(msg.Mutation.GetEntry())]++
+			}
+			for entry, n := range entries {
+				if n != 2 {
+					t.Errorf("entry %v was written %d times, want 2", entry, n)
+				}
+			}
+		})
 	}
 }
@@ -20,23 +20,29 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	ktsql "github.com/google/keytransparency/impl/sql"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
 
-var (
-	createStmt = []string{
-		`CREATE TABLE IF NOT EXISTS Batches (
+// Migrations are the schema changes applied to the mutation tables in order.
+var Migrations = []ktsql.Migration{
+	{
+		Description: "Create Batches, Queue and Logs",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL: {
+				`CREATE TABLE IF NOT EXISTS Batches (
 		DomainID VARCHAR(30)   NOT NULL,
 		Revision BIGINT        NOT NULL,
 		Sources  BLOB          NOT NULL,
 		PRIMARY KEY(DomainID, Revision)
 	);`,
-		`CREATE TABLE IF NOT EXISTS Queue (
+				`CREATE TABLE IF NOT EXISTS Queue (
 		DirectoryID VARCHAR(30) NOT NULL,
 		LogID       BIGINT      NOT NULL,
 		TimeMicros  BIGINT      NOT NULL, -- In microseconds from Unix epoch.
@@ -44,66 +50,214 @@ var (
 		Mutation    BLOB        NOT NULL,
 		PRIMARY KEY(DirectoryID, LogID, TimeMicros, LocalID)
 	);`,
-		`CREATE TABLE IF NOT EXISTS Logs (
+				`CREATE TABLE IF NOT EXISTS Logs (
 		DirectoryID VARCHAR(30)   NOT NULL,
 		LogID    BIGINT           NOT NULL,
 		Enabled  INTEGER          NOT NULL,
 		PRIMARY KEY(DirectoryID, LogID)
 	);`,
-	}
-)
+			},
+			ktsql.Postgres: {
+				`CREATE TABLE IF NOT EXISTS Batches (
+		DomainID VARCHAR(30)   NOT NULL,
+		Revision BIGINT        NOT NULL,
+		Sources  BYTEA         NOT NULL,
+		PRIMARY KEY(DomainID, Revision)
+	);`,
+				`CREATE TABLE IF NOT EXISTS Queue (
+		DirectoryID VARCHAR(30) NOT NULL,
+		LogID       BIGINT      NOT NULL,
+		TimeMicros  BIGINT      NOT NULL, -- In microseconds from Unix epoch.
+		LocalID     BIGINT      NOT NULL,
+		Mutation    BYTEA       NOT NULL,
+		PRIMARY KEY(DirectoryID, LogID, TimeMicros, LocalID)
+	);`,
+				`CREATE TABLE IF NOT EXISTS Logs (
+		DirectoryID VARCHAR(30)   NOT NULL,
+		LogID    BIGINT           NOT NULL,
+		Enabled  BOOLEAN          NOT NULL,
+		PRIMARY KEY(DirectoryID, LogID)
+	);`,
+			},
+			ktsql.SQLite: {
+				`CREATE TABLE IF NOT EXISTS Batches (
+		DomainID VARCHAR(30)   NOT NULL,
+		Revision BIGINT        NOT NULL,
+		Sources  BLOB          NOT NULL,
+		PRIMARY KEY(DomainID, Revision)
+	);`,
+				`CREATE TABLE IF NOT EXISTS Queue (
+		DirectoryID VARCHAR(30) NOT NULL,
+		LogID       BIGINT      NOT NULL,
+		TimeMicros  BIGINT      NOT NULL, -- In microseconds from Unix epoch.
+		LocalID     BIGINT      NOT NULL,
+		Mutation    BLOB        NOT NULL,
+		PRIMARY KEY(DirectoryID, LogID, TimeMicros, LocalID)
+	);`,
+				`CREATE TABLE IF NOT EXISTS Logs (
+		DirectoryID VARCHAR(30)   NOT NULL,
+		LogID    BIGINT           NOT NULL,
+		Enabled  INTEGER          NOT NULL,
+		PRIMARY KEY(DirectoryID, LogID)
+	);`,
+			},
+		},
+	},
+	{
+		Description: "Create QueueRequests",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {createQueueRequests},
+			ktsql.Postgres: {createQueueRequests},
+			ktsql.SQLite:   {createQueueRequests},
+		},
+	},
+	{
+		Description: "Rename Batches.DomainID to DirectoryID",
+		Up: map[ktsql.Dialect][]string{
+			// RENAME COLUMN requires MySQL 8.0.
+			ktsql.MySQL:    {`ALTER TABLE Batches CHANGE DomainID DirectoryID VARCHAR(30) NOT NULL;`},
+			ktsql.Postgres: {`ALTER TABLE Batches RENAME COLUMN DomainID TO DirectoryID;`},
+			ktsql.SQLite:   {`ALTER TABLE Batches RENAME COLUMN DomainID TO DirectoryID;`},
+		},
+	},
+	{
+		Description: "Create PrunedRevisions",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {createPrunedRevisions},
+			ktsql.Postgres: {createPrunedRevisions},
+			ktsql.SQLite:   {createPrunedRevisions},
+		},
+	},
+	{
+		Description: "Add Logs.State and Logs.FinalWatermark",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    addLogStates,
+			ktsql.Postgres: addLogStates,
+			ktsql.SQLite:   addLogStates,
+		},
+	},
+	{
+		Description: "Create Fences",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    {createFences},
+			ktsql.Postgres: {createFences},
+			ktsql.SQLite:   {createFences},
+		},
+	},
+	{
+		Description: "Add Logs.LastWatermark",
+		Up: map[ktsql.Dialect][]string{
+			ktsql.MySQL:    addLastWatermark,
+			ktsql.Postgres: addLastWatermark,
+			ktsql.SQLite:   addLastWatermark,
+		},
+	},
+}
+
+// addLogStates records the lifecycle state of each input log.
+var addLogStates = []string{
+	`ALTER TABLE Logs ADD COLUMN State INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE Logs ADD COLUMN FinalWatermark BIGINT NOT NULL DEFAULT 0;`,
+}
+
+// addLastWatermark records the last watermark issued in each log, which
+// pruning the queue does not delete. It starts at the highest queued one.
+var addLastWatermark = []string{
+	`ALTER TABLE Logs ADD COLUMN LastWatermark BIGINT NOT NULL DEFAULT 0;`,
+	`UPDATE Logs SET LastWatermark = COALESCE((SELECT MAX(Queue.TimeMicros) FROM Queue
+		WHERE Queue.DirectoryID = Logs.DirectoryID AND Queue.LogID = Logs.LogID), 0);`,
+}
+
+const createQueueRequests = `CREATE TABLE IF NOT EXISTS QueueRequests (
+		DirectoryID VARCHAR(30) NOT NULL,
+		RequestID   VARCHAR(64) NOT NULL,
+		LogID       BIGINT      NOT NULL,
+		TimeMicros  BIGINT      NOT NULL, -- Watermark of the batch in Queue.
+		PRIMARY KEY(DirectoryID, RequestID)
+	);`
+
+const createPrunedRevisions = `CREATE TABLE IF NOT EXISTS PrunedRevisions (
+		DirectoryID VARCHAR(30) NOT NULL,
+		Revision    BIGINT      NOT NULL, -- Highest revision whose mutations were deleted.
+		PRIMARY KEY(DirectoryID)
+	);`
+
+const createFences = `CREATE TABLE IF NOT EXISTS Fences (
+		DirectoryID VARCHAR(30) NOT NULL,
+		Token       BIGINT      NOT NULL, -- Highest fencing token seen.
+		Version     BIGINT      NOT NULL, -- Incremented by every fenced write.
+		PRIMARY KEY(DirectoryID)
+	);`
+
+// RequestWindow is how long the request IDs of queued mutations are remembered.
+const RequestWindow = 24 * time.Hour
+
+// sendAttempts bounds the number of times Send retries a transaction that
+// conflicted with a concurrent Send.
+const sendAttempts = 10
 
 // Mutations implements mutator.MutationStorage and mutator.MutationQueue.
 type Mutations struct {
-	db *sql.DB
+	db            *sql.DB
+	dialect       ktsql.Dialect
+	requestWindow time.Duration
 }
 
-// New creates a new Mutations instance.
+// New creates a new Mutations instance, migrating the mutation tables to the
+// latest schema.
 func New(db *sql.DB) (*Mutations, error) {
-	m := &Mutations{
-		db: db,
-	}
-
-	// Create tables.
-	if err := m.createTables(); err != nil {
+	if err := Migrate(context.Background(), db); err != nil {
 		return nil, err
 	}
-	return m, nil
+	return &Mutations{
+		db:            db,
+		dialect:       ktsql.DialectOf(db),
+		requestWindow: RequestWindow,
+	}, nil
 }
 
-// createTables creates new database tables.
-func (m *Mutations) createTables() error {
-	for _, stmt := range createStmt {
-		_, err := m.db.Exec(stmt)
-		if err != nil {
-			return fmt.Errorf("failed to create mutation tables: %v", err)
-		}
-	}
-	return nil
+// Migrate applies any new Migrations to the mutation tables in db.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return ktsql.Migrate(ctx, db, "mutations", Migrations)
 }
 
 // WriteBatchSources saves the mutations in the database.
 // If revision has already been defined, this will fail.
+// Returns FailedPrecondition if ctx carries a stale fencing token.
 func (m *Mutations) WriteBatchSources(ctx context.Context, dirID string, rev int64,
-	sources *spb.MapMetadata) error {
+	sources *spb.MapMetadata) (ret error) {
 	sourceData, err := proto.Marshal(sources)
 	if err != nil {
 		return fmt.Errorf("proto.Marshal(): %v", err)
 	}
-	if _, err := m.db.ExecContext(ctx,
-		`INSERT INTO Batches (DomainID, Revision, Sources) VALUES (?, ?, ?);`,
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+	if err := m.checkFence(ctx, tx, dirID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(`INSERT INTO Batches (DirectoryID, Revision, Sources) VALUES (?, ?, ?);`),
 		dirID, rev, sourceData); err != nil {
 		return fmt.Errorf("insert batch boundary (%v, %v) failed: %v", dirID, rev, err)
 	}
-	return nil
+	return tx.Commit()
 }
 
 // ReadBatch returns the batch definitions for a given revision.
-func (m *Mutations) ReadBatch(ctx context.Context, domainID string, rev int64) (*spb.MapMetadata, error) {
+func (m *Mutations) ReadBatch(ctx context.Context, directoryID string, rev int64) (*spb.MapMetadata, error) {
 	var sourceData []byte
 	if err := m.db.QueryRowContext(ctx,
-		`SELECT Sources FROM Batches WHERE DomainID = ? AND Revision = ?;`,
-		domainID, rev).Scan(&sourceData); err == sql.ErrNoRows {
+		m.dialect.Rebind(`SELECT Sources FROM Batches WHERE DirectoryID = ? AND Revision = ?;`),
+		directoryID, rev).Scan(&sourceData); err == sql.ErrNoRows {
 		return nil, status.Errorf(codes.NotFound, "revision %v not found", rev)
 	} else if err != nil {
 		return nil, err
@@ -121,7 +275,7 @@ func (m *Mutations) ReadBatch(ctx context.Context, domainID string, rev int64) (
 func (m *Mutations) HighestRev(ctx context.Context, directoryID string) (int64, error) {
 	var rev int64
 	if err := m.db.QueryRowContext(ctx,
-		`SELECT COALESCE(MAX(Revision), 0) FROM Batches WHERE DomainID = ?`,
+		m.dialect.Rebind(`SELECT COALESCE(MAX(Revision), 0) FROM Batches WHERE DirectoryID = ?`),
 		directoryID).Scan(&rev); err != nil {
 		return 0, err
 	}
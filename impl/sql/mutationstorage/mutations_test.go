@@ -17,20 +17,67 @@ import (
 	"context"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/google/keytransparency/core/integration/storagetest"
 	"github.com/google/keytransparency/core/sequencer"
+	ktsql "github.com/google/keytransparency/impl/sql"
 	"github.com/google/keytransparency/impl/sql/testdb"
+
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
 )
 
 func TestBatchIntegration(t *testing.T) {
-	storageFactory := func(ctx context.Context, t *testing.T, _ string) (sequencer.Batcher, func(context.Context)) {
-		db, done := testdb.NewForTest(ctx, t)
-		m, err := New(db)
-		if err != nil {
-			t.Fatalf("Failed to create mutations: %v", err)
-		}
-		return m, done
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			storageFactory := func(ctx context.Context, t *testing.T, _ string) (sequencer.Batcher, func(context.Context)) {
+				db, done := newDB(ctx, t)
+				m, err := New(db)
+				if err != nil {
+					t.Fatalf("Failed to create mutations: %v", err)
+				}
+				return m, done
+			}
+
+			storagetest.RunBatchStorageTests(t, storageFactory)
+		})
 	}
+}
 
-	storagetest.RunBatchStorageTests(t, storageFactory)
+// TestMigrateDomainID verifies that batches written before Batches.DomainID
+// was renamed can still be read.
+func TestMigrateDomainID(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			defer done(ctx)
+			if err := ktsql.Migrate(ctx, db, "mutations", Migrations[:1]); err != nil {
+				t.Fatalf("Migrate(v1): %v", err)
+			}
+			want := &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{{LogId: 1, HighestExclusive: 10}}}
+			sourceData, err := proto.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.ExecContext(ctx,
+				dialect.Rebind(`INSERT INTO Batches (DomainID, Revision, Sources) VALUES (?, ?, ?);`),
+				"dir", 1, sourceData); err != nil {
+				t.Fatalf("insert v1 batch: %v", err)
+			}
+
+			m, err := New(db)
+			if err != nil {
+				t.Fatalf("New(): %v", err)
+			}
+			got, err := m.ReadBatch(ctx, "dir", 1)
+			if err != nil {
+				t.Fatalf("ReadBatch(): %v", err)
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("ReadBatch(): %v, want %v", got, want)
+			}
+		})
+	}
 }
@@ -0,0 +1,127 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutationstorage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/keytransparency/core/sequencer/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+// PruneRevision deletes the mutations read by revision rev, which are those
+// below the high watermarks of meta, the batch definition of rev.
+// Revisions must be pruned in order. Pruning a revision that has already been
+// pruned does nothing. Returns the number of mutations deleted.
+// Returns FailedPrecondition if ctx carries a stale fencing token.
+func (m *Mutations) PruneRevision(ctx context.Context, directoryID string, rev int64,
+	meta *spb.MapMetadata) (_ int64, ret error) {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if ret != nil {
+			if err := tx.Rollback(); err != nil {
+				ret = status.Errorf(codes.Internal, "%v, and could not rollback: %v", ret, err)
+			}
+		}
+	}()
+	if err := m.checkFence(ctx, tx, directoryID); err != nil {
+		return 0, err
+	}
+
+	pruned, err := m.prunedRevision(ctx, tx, directoryID)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case rev <= pruned:
+		return 0, tx.Commit()
+	case rev != pruned+1:
+		return 0, status.Errorf(codes.FailedPrecondition,
+			"cannot prune revision %v before revision %v", rev, pruned+1)
+	}
+
+	var deleted int64
+	for _, source := range meta.GetSources() {
+		high := metadata.FromProto(source).HighMark()
+		result, err := tx.ExecContext(ctx,
+			m.dialect.Rebind(`DELETE FROM Queue WHERE DirectoryID = ? AND LogID = ? AND TimeMicros < ?;`),
+			directoryID, source.GetLogId(), high.Value())
+		if err != nil {
+			return 0, status.Errorf(codes.Internal, "failed deleting from queue: %v", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		deleted += n
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(m.dialect.InsertIgnore(`INSERT INTO PrunedRevisions (DirectoryID, Revision) VALUES (?, 0);`)),
+		directoryID); err != nil {
+		return 0, status.Errorf(codes.Internal, "failed recording pruned revision: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		m.dialect.Rebind(`UPDATE PrunedRevisions SET Revision = ? WHERE DirectoryID = ?;`),
+		rev, directoryID); err != nil {
+		return 0, status.Errorf(codes.Internal, "failed recording pruned revision: %v", err)
+	}
+	glog.V(2).Infof("mutationstorage: PruneRevision(%v, %v): deleted %v mutations", directoryID, rev, deleted)
+	return deleted, tx.Commit()
+}
+
+// ExpireRequests deletes the request IDs of directoryID that were queued
+// before the request window, which Send no longer deduplicates.
+// Returns the number of request IDs deleted.
+func (m *Mutations) ExpireRequests(ctx context.Context, directoryID string) (int64, error) {
+	since := time.Now().Add(-m.requestWindow).UnixNano() / int64(time.Microsecond)
+	result, err := m.db.ExecContext(ctx,
+		m.dialect.Rebind(`DELETE FROM QueueRequests WHERE DirectoryID = ? AND TimeMicros < ?;`),
+		directoryID, since)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed expiring request ids: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// PrunedRevision returns the highest revision whose mutations have been
+// pruned, or 0 if none have been.
+func (m *Mutations) PrunedRevision(ctx context.Context, directoryID string) (int64, error) {
+	return m.prunedRevision(ctx, m.db, directoryID)
+}
+
+// rowQueryer is implemented by *sql.DB and *sql.Tx.
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (m *Mutations) prunedRevision(ctx context.Context, q rowQueryer, directoryID string) (int64, error) {
+	var rev int64
+	if err := q.QueryRowContext(ctx,
+		m.dialect.Rebind(`SELECT COALESCE(MAX(Revision), 0) FROM PrunedRevisions WHERE DirectoryID = ?;`),
+		directoryID).Scan(&rev); err != nil {
+		return 0, status.Errorf(codes.Internal, "could not read pruned revision: %v", err)
+	}
+	return rev, nil
+}
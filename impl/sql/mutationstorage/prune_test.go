@@ -0,0 +1,216 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mutationstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/keytransparency/core/sequencer/election"
+	"github.com/google/keytransparency/core/sequencer/metadata"
+	"github.com/google/keytransparency/core/water"
+	"github.com/google/keytransparency/impl/sql/testdb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/google/keytransparency/core/api/v1/keytransparency_go_proto"
+	spb "github.com/google/keytransparency/core/sequencer/sequencer_go_proto"
+)
+
+func TestPruneRevision(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestPruneRevision"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1, 2)
+			defer done(ctx)
+
+			data, err := proto.Marshal(&pb.EntryUpdate{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Send 3 mutations to each log.
+			marks := make(map[int64][]water.Mark)
+			for ts := uint64(1); ts <= 3; ts++ {
+				for _, logID := range []int64{1, 2} {
+					wm := water.NewMark(ts)
+					if _, err := m.send(ctx, wm, directoryID, logID, nil, data); err != nil {
+						t.Fatalf("send(): %v", err)
+					}
+					marks[logID] = append(marks[logID], wm)
+				}
+			}
+			batch := func(high int) *spb.MapMetadata {
+				return &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+					metadata.New(1, water.Mark{}, marks[1][high]).Proto(),
+					metadata.New(2, water.Mark{}, marks[2][high]).Proto(),
+				}}
+			}
+
+			for _, tc := range []struct {
+				desc        string
+				rev         int64
+				meta        *spb.MapMetadata
+				token       int64
+				wantDeleted int64
+				wantCode    codes.Code
+				wantPruned  int64
+				wantLeft    int
+			}{
+				{desc: "first", rev: 1, meta: batch(1), token: 2, wantDeleted: 2, wantPruned: 1, wantLeft: 2},
+				{desc: "again", rev: 1, meta: batch(1), wantDeleted: 0, wantPruned: 1, wantLeft: 2},
+				{desc: "skip", rev: 3, meta: batch(2), wantCode: codes.FailedPrecondition, wantPruned: 1, wantLeft: 2},
+				{desc: "stale token", rev: 2, meta: batch(2), token: 1, wantCode: codes.FailedPrecondition, wantPruned: 1, wantLeft: 2},
+				{desc: "next", rev: 2, meta: batch(2), wantDeleted: 2, wantPruned: 2, wantLeft: 1},
+			} {
+				deleted, err := m.PruneRevision(election.WithToken(ctx, tc.token), directoryID, tc.rev, tc.meta)
+				if got := status.Code(err); got != tc.wantCode {
+					t.Fatalf("%v: PruneRevision(): %v, want %v", tc.desc, err, tc.wantCode)
+				}
+				if deleted != tc.wantDeleted {
+					t.Errorf("%v: PruneRevision(): deleted %v, want %v", tc.desc, deleted, tc.wantDeleted)
+				}
+				pruned, err := m.PrunedRevision(ctx, directoryID)
+				if err != nil {
+					t.Fatalf("%v: PrunedRevision(): %v", tc.desc, err)
+				}
+				if pruned != tc.wantPruned {
+					t.Errorf("%v: PrunedRevision(): %v, want %v", tc.desc, pruned, tc.wantPruned)
+				}
+				for _, logID := range []int64{1, 2} {
+					msgs, err := m.ReadLog(ctx, directoryID, logID, water.Mark{}, water.NewMark(10), 10)
+					if err != nil {
+						t.Fatalf("ReadLog(): %v", err)
+					}
+					if len(msgs) != tc.wantLeft {
+						t.Errorf("%v: log %v has %v mutations, want %v", tc.desc, logID, len(msgs), tc.wantLeft)
+					}
+				}
+			}
+
+			// Other directories are unaffected.
+			if pruned, err := m.PrunedRevision(ctx, "other"); err != nil || pruned != 0 {
+				t.Errorf("PrunedRevision(other): %v, %v, want 0", pruned, err)
+			}
+			if _, err := m.Send(ctx, directoryID, 1, &pb.EntryUpdate{}); err != nil {
+				t.Errorf("Send() after pruning: %v", err)
+			}
+		})
+	}
+}
+
+// Mutations sent after a log was pruned empty are still sequenced, even if
+// the clock is behind the pruned mutations.
+func TestSendAfterPrune(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestSendAfterPrune"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1)
+			defer done(ctx)
+
+			data, err := proto.Marshal(&pb.EntryUpdate{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			wm, err := m.send(ctx, water.NewMark(100), directoryID, 1, nil, data)
+			if err != nil {
+				t.Fatalf("send(): %v", err)
+			}
+			high := wm.Add(1)
+			meta := &spb.MapMetadata{Sources: []*spb.MapMetadata_SourceSlice{
+				metadata.New(1, water.Mark{}, high).Proto(),
+			}}
+			if _, err := m.PruneRevision(ctx, directoryID, 1, meta); err != nil {
+				t.Fatalf("PruneRevision(): %v", err)
+			}
+
+			got, err := m.send(ctx, water.NewMark(50), directoryID, 1, nil, data)
+			if err != nil {
+				t.Fatalf("send(): %v", err)
+			}
+			if got.Compare(high) < 0 {
+				t.Errorf("send(): %v, want at least %v", got, high)
+			}
+			count, next, err := m.HighWatermark(ctx, directoryID, 1, high, 10)
+			if err != nil {
+				t.Fatalf("HighWatermark(): %v", err)
+			}
+			if count != 1 {
+				t.Errorf("HighWatermark(): count %v, want 1", count)
+			}
+			msgs, err := m.ReadLog(ctx, directoryID, 1, high, next, 10)
+			if err != nil {
+				t.Fatalf("ReadLog(): %v", err)
+			}
+			if len(msgs) != 1 || msgs[0].ID != got {
+				t.Errorf("ReadLog(): %v, want the mutation at %v", msgs, got)
+			}
+		})
+	}
+}
+
+func TestExpireRequests(t *testing.T) {
+	for dialect, newDB := range testdb.Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			directoryID := "TestExpireRequests"
+			m, done := newForTest(ctx, t, newDB, directoryID, 1)
+			defer done(ctx)
+			m.requestWindow = time.Second
+
+			update := []byte("bar")
+			now := water.NewMark(uint64(time.Duration(time.Now().UnixNano()) * time.Nanosecond / time.Microsecond))
+			old := water.NewMark(now.Value() - uint64(2*time.Second/time.Microsecond))
+			if _, err := m.send(ctx, old, directoryID, 1, []string{"old1", "old2"}, update, update); err != nil {
+				t.Fatalf("send(old): %v", err)
+			}
+			if _, err := m.send(ctx, now, directoryID, 1, []string{"new"}, update); err != nil {
+				t.Fatalf("send(new): %v", err)
+			}
+
+			expired, err := m.ExpireRequests(ctx, directoryID)
+			if err != nil {
+				t.Fatalf("ExpireRequests(): %v", err)
+			}
+			if expired != 2 {
+				t.Errorf("ExpireRequests(): %v, want 2", expired)
+			}
+			for _, tc := range []struct {
+				requestID string
+				want      int
+			}{
+				{requestID: "old1", want: 0},
+				{requestID: "old2", want: 0},
+				{requestID: "new", want: 1},
+			} {
+				var got int
+				if err := m.db.QueryRowContext(ctx,
+					m.dialect.Rebind(`SELECT COUNT(*) FROM QueueRequests WHERE DirectoryID = ? AND RequestID = ?;`),
+					directoryID, tc.requestID).Scan(&got); err != nil {
+					t.Fatalf("count %v: %v", tc.requestID, err)
+				}
+				if got != tc.want {
+					t.Errorf("request id %v: %v rows, want %v", tc.requestID, got, tc.want)
+				}
+			}
+		})
+	}
+}
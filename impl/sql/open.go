@@ -12,18 +12,40 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package sql provides functions for interacting with MySQL.
+// Package sql provides functions for interacting with MySQL, PostgreSQL and SQLite.
 package sql
 
 import (
 	"database/sql"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-sql-driver/mysql"
+
+	_ "github.com/lib/pq"           // Register the postgres driver.
+	_ "github.com/mattn/go-sqlite3" // Register the sqlite3 driver.
 )
 
-// Open the MySQL database specified by the dsn string.
+// Open the database specified by the dsn string.
+// DSNs starting with postgres:// or postgresql:// open a PostgreSQL database.
+// DSNs starting with sqlite:// or file: open an SQLite database file, e.g.
+// sqlite:///var/lib/kt/kt.db or file:kt.db?mode=memory.
+// All other DSNs, optionally prefixed with mysql://, open a MySQL database.
 func Open(dsn string) (*sql.DB, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return open("postgres", dsn)
+	case strings.HasPrefix(dsn, "sqlite://"):
+		return openSQLite("file:" + strings.TrimPrefix(dsn, "sqlite://"))
+	case strings.HasPrefix(dsn, "file:"):
+		return openSQLite(dsn)
+	default:
+		return openMySQL(strings.TrimPrefix(dsn, "mysql://"))
+	}
+}
+
+func openMySQL(dsn string) (*sql.DB, error) {
 	cfg, err := mysql.ParseDSN(dsn)
 	if err != nil {
 		return nil, err
@@ -34,7 +56,32 @@ func Open(dsn string) (*sql.DB, error) {
 	cfg.ParseTime = true       // Parse time values to time.Time
 	cfg.Loc = time.UTC
 
-	db, err := sql.Open("mysql", cfg.FormatDSN())
+	return open("mysql", cfg.FormatDSN())
+}
+
+func openSQLite(dsn string) (*sql.DB, error) {
+	path, rawQuery := dsn, ""
+	if i := strings.Index(dsn, "?"); i >= 0 {
+		path, rawQuery = dsn[:i], dsn[i+1:]
+	}
+	params, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite flags that affect storage logic.
+	if params.Get("_busy_timeout") == "" {
+		params.Set("_busy_timeout", "5000") // Wait for locks held by other connections.
+	}
+	if params.Get("_txlock") == "" {
+		params.Set("_txlock", "immediate") // Take the write lock when transactions begin.
+	}
+
+	return open("sqlite3", path+"?"+params.Encode())
+}
+
+func open(driverName, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
 		return nil, err
 	}
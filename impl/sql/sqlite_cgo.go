@@ -0,0 +1,41 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package sql
+
+import "github.com/mattn/go-sqlite3"
+
+// isSQLiteDuplicate returns true if err is an SQLite error caused by
+// inserting a row whose primary or unique key already exists.
+func isSQLiteDuplicate(err error) bool {
+	e, ok := err.(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.ExtendedCode == sqlite3.ErrConstraintPrimaryKey ||
+		e.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// isSQLiteConflict returns true if err is an SQLite error caused by another
+// connection holding a lock.
+func isSQLiteConflict(err error) bool {
+	e, ok := err.(sqlite3.Error)
+	if !ok {
+		return false
+	}
+	return e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+// +build cgo
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestSQLiteErrors(t *testing.T) {
+	for _, tc := range []struct {
+		err           error
+		wantDuplicate bool
+		wantConflict  bool
+	}{
+		{err: sqlite3.Error{Code: sqlite3.ErrBusy}, wantConflict: true},
+		{err: sqlite3.Error{Code: sqlite3.ErrLocked}, wantConflict: true},
+		{err: sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey},
+			wantDuplicate: true, wantConflict: true},
+		{err: sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique},
+			wantDuplicate: true, wantConflict: true},
+		{err: sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}},
+	} {
+		if got := IsDuplicate(tc.err); got != tc.wantDuplicate {
+			t.Errorf("IsDuplicate(%v): %v, want %v", tc.err, got, tc.wantDuplicate)
+		}
+		if got := IsConflict(tc.err); got != tc.wantConflict {
+			t.Errorf("IsConflict(%v): %v, want %v", tc.err, got, tc.wantConflict)
+		}
+	}
+}
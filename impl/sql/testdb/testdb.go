@@ -20,41 +20,142 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	ktsql "github.com/google/keytransparency/impl/sql"
 )
 
-var dataSourceURI = flag.String("kt_test_mysql_uri", "root@tcp(127.0.0.1)/", "The MySQL URI to use when running tests")
+var (
+	dataSourceURI = flag.String("kt_test_mysql_uri", "root@tcp(127.0.0.1)/", "The MySQL URI to use when running tests")
+	postgresURI   = flag.String("kt_test_postgres_uri", "",
+		"The PostgreSQL URI to use when running tests, e.g. postgres://postgres@127.0.0.1/?sslmode=disable. PostgreSQL tests are skipped if unset")
+)
+
+// NewFunc creates a temporary database.
+// Returns a function for deleting the database.
+type NewFunc func(ctx context.Context, t testing.TB) (*sql.DB, func(context.Context))
+
+// Backends returns a NewFunc for each supported database.
+func Backends() map[ktsql.Dialect]NewFunc {
+	return map[ktsql.Dialect]NewFunc{
+		ktsql.MySQL:    NewForTest,
+		ktsql.Postgres: NewPostgresForTest,
+		ktsql.SQLite:   NewSQLiteForTest,
+	}
+}
 
-// NewForTest creates a temporary database.
+// NewForTest creates a temporary MySQL database.
 // Returns a function for deleting the database.
 func NewForTest(ctx context.Context, t testing.TB) (*sql.DB, func(context.Context)) {
 	t.Helper()
-	db, err := ktsql.Open(*dataSourceURI)
+	return NewForTestURI(ctx, t, *dataSourceURI)
+}
+
+// NewPostgresForTest creates a temporary PostgreSQL database, or skips the
+// test if --kt_test_postgres_uri is unset.
+// Returns a function for deleting the database.
+func NewPostgresForTest(ctx context.Context, t testing.TB) (*sql.DB, func(context.Context)) {
+	t.Helper()
+	if *postgresURI == "" {
+		t.Skip("--kt_test_postgres_uri is unset")
+	}
+	return NewForTestURI(ctx, t, *postgresURI)
+}
+
+// NewSQLiteForTest creates a temporary SQLite database file.
+// SQLite databases need no server, so these tests always run.
+// Returns a function for deleting the database.
+func NewSQLiteForTest(ctx context.Context, t testing.TB) (*sql.DB, func(context.Context)) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "kt_test_sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ktsql.Open("file:" + filepath.Join(dir, "test.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	done := func(context.Context) {
+		db.Close()
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Failed to delete test database %q: %v", dir, err)
+		}
+	}
+	return db, done
+}
+
+// NewForTestURI creates a temporary database on the database server at uri.
+// The scheme of uri selects the database, as in ktsql.Open. SQLite URIs create
+// a temporary database file rather than one at the given path.
+// Returns a function for deleting the database.
+func NewForTestURI(ctx context.Context, t testing.TB, uri string) (*sql.DB, func(context.Context)) {
+	t.Helper()
+	if strings.HasPrefix(uri, "sqlite:") || strings.HasPrefix(uri, "file:") {
+		return NewSQLiteForTest(ctx, t)
+	}
+	admin, err := ktsql.Open(uri)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer admin.Close()
+	dialect := ktsql.DialectOf(admin)
 
 	dbName := fmt.Sprintf("test_%v", time.Now().UnixNano())
-	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE `%s`", dbName)); err != nil {
+	if _, err := admin.ExecContext(ctx, fmt.Sprintf("CREATE DATABASE %s", quote(dialect, dbName))); err != nil {
 		t.Fatalf("Failed to create test database: %v", err)
 	}
 
 	// Open test database
-	db.Close()
-	db, err = ktsql.Open(*dataSourceURI + dbName)
+	dbURI, err := withDatabase(dialect, uri, dbName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ktsql.Open(dbURI)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	done := func(ctx context.Context) {
-		defer db.Close()
-		if _, err := db.ExecContext(ctx, fmt.Sprintf("DROP DATABASE `%s`", dbName)); err != nil {
+		// A PostgreSQL database cannot be dropped by its own connections.
+		db.Close()
+		admin, err := ktsql.Open(uri)
+		if err != nil {
+			log.Printf("Failed to drop test database %q: %v", dbName, err)
+			return
+		}
+		defer admin.Close()
+		if _, err := admin.ExecContext(ctx, fmt.Sprintf("DROP DATABASE %s", quote(dialect, dbName))); err != nil {
 			log.Printf("Failed to drop test database %q: %v", dbName, err)
 		}
 	}
 	return db, done
 }
+
+// quote returns the database name quoted for use in a statement.
+func quote(dialect ktsql.Dialect, name string) string {
+	if dialect == ktsql.Postgres {
+		return fmt.Sprintf(`"%s"`, name)
+	}
+	return fmt.Sprintf("`%s`", name)
+}
+
+// withDatabase returns uri modified to connect to the database named name.
+func withDatabase(dialect ktsql.Dialect, uri, name string) (string, error) {
+	if dialect != ktsql.Postgres {
+		return uri + name, nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
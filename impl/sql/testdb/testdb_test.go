@@ -20,13 +20,18 @@ import (
 )
 
 func TestNewForTest(t *testing.T) {
-	ctx := context.Background()
-	db, done := NewForTest(ctx, t)
-	if err := db.Ping(); err != nil {
-		t.Error(err)
+	for dialect, newDB := range Backends() {
+		newDB := newDB
+		t.Run(string(dialect), func(t *testing.T) {
+			ctx := context.Background()
+			db, done := newDB(ctx, t)
+			if err := db.Ping(); err != nil {
+				t.Error(err)
+			}
+			done(ctx)
+			// Verify that the databse was really closed by looking for the
+			// error in the logs when running the test with -v
+			done(ctx)
+		})
 	}
-	done(ctx)
-	// Verify that the databse was really closed by looking for the
-	// error in the logs when running the test with -v
-	done(ctx)
 }
@@ -16,7 +16,7 @@ docker-compose build --parallel
 docker stack deploy -c docker-compose.yml -c docker-compose.prod.yml kt
 trap "docker stack rm kt" INT EXIT
 ./scripts/docker-stack-wait.sh -t 180 -n sequencer kt
-docker run -t --network kt_attachable gcr.io/key-transparency/init:${TRAVIS_COMMIT} sequencer:8080 -- curl -k -X POST https://sequencer:8080/v1/directories -d'{"directory_id":"default","min_interval":"1s","max_interval":"60s"}'
+docker run -t --network kt_attachable gcr.io/key-transparency/init:${TRAVIS_COMMIT} sequencer:8080 -- curl -k -H 'Authorization: FakeCredential admin@example.com' -X POST https://sequencer:8080/v1/directories -d'{"directory_id":"default","min_interval":"1s","max_interval":"60s"}'
 ./scripts/docker-stack-wait.sh -t 180 kt
 
 wget -T 60 --spider --retry-connrefused --waitretry=1 http://localhost:8081/readyz
@@ -28,6 +28,7 @@ wget -T 60 -O /dev/null --no-check-certificate  \
 PASSWORD="foobar"
 go run ./cmd/keytransparency-client authorized-keys create-keyset --password=${PASSWORD}
 go run ./cmd/keytransparency-client post foo@bar.com \
+	--fake-auth-userid=foo@bar.com \
 	--insecure \
 	--data='dGVzdA==' \
 	--password=${PASSWORD} \
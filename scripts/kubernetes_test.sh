@@ -58,6 +58,7 @@ wget -T 60 -O /dev/null --no-check-certificate  \
 PASSWORD="foobar"
 go run ./cmd/keytransparency-client authorized-keys create-keyset --password=${PASSWORD}
 go run ./cmd/keytransparency-client post foo@bar.com \
+	--fake-auth-userid=foo@bar.com \
 	--insecure \
 	--data='dGVzdA==' \
 	--password=${PASSWORD} \